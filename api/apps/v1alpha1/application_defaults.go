@@ -19,11 +19,13 @@ package v1alpha1
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 	"unicode"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -70,9 +72,23 @@ func (in *Parameter) Default() {
 		in.Replicas.Default()
 	case in.EnvironmentVariable != nil:
 		in.EnvironmentVariable.Default()
+	case in.Storage != nil:
+		in.Storage.Default()
 	}
 }
 
+// wellKnownSidecars are the names of commonly injected containers that are excluded from
+// container resources optimization by default.
+var wellKnownSidecars = []string{
+	"istio-proxy",
+	"linkerd-proxy",
+	"vault-agent",
+	"cloud-sql-proxy",
+	"cloudsql-proxy",
+	"fluent-bit",
+	"fluentd",
+}
+
 func (in *ContainerResources) Default() {
 	if in.Selector != "" {
 		in.LabelSelector = in.Selector
@@ -89,6 +105,10 @@ func (in *ContainerResources) Default() {
 	if len(in.Resources) == 0 {
 		in.Resources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
 	}
+
+	if in.ExcludeContainers == nil {
+		in.ExcludeContainers = append(in.ExcludeContainers, wellKnownSidecars...)
+	}
 }
 
 func (in *Replicas) Default() {
@@ -116,6 +136,12 @@ func (in *EnvironmentVariable) Default() {
 	}
 }
 
+func (in *Storage) Default() {
+	if in.Kind == "" {
+		in.Kind = "PersistentVolumeClaim"
+	}
+}
+
 func (in *Scenario) Default() {
 	if in.Name == "" {
 		switch {
@@ -125,6 +151,8 @@ func (in *Scenario) Default() {
 			in.Name = defaultScenarioName(in.Locust.Locustfile)
 		case in.Custom != nil:
 			in.Name = defaultCustomScenarioName(in.Custom)
+		case in.Mirror != nil:
+			in.Name = defaultScenarioName(in.Mirror.Service)
 		default:
 			in.Name = defaultName
 		}
@@ -140,7 +168,7 @@ func (in *Objective) Default() {
 	// A single goal will not produce a viable experiment, try to offset
 	if len(in.Goals) == 1 {
 		switch {
-		case in.Goals[0].Latency != nil:
+		case in.Goals[0].Latency != nil, in.Goals[0].Throughput != nil:
 			in.Goals = appendDefaultedGoal(in.Goals, Goal{Name: "cost"})
 		case in.Goals[0].Requests != nil:
 			in.Goals = appendDefaultedGoal(in.Goals, Goal{Name: "p95-latency"})
@@ -151,6 +179,9 @@ func (in *Objective) Default() {
 	// fail until runtime when under provisioned
 	in.enforceErrorRate()
 
+	// Ensure that every latency threshold is bounding a goal
+	in.enforceLatencyThresholds()
+
 	if in.Name == "" {
 		// Only consider optimized goals when computing the default name
 		var optimizedGoals []Goal
@@ -175,7 +206,7 @@ func (in *Objective) enforceErrorRate() {
 	var hasLatency bool
 	var hasErrorRate bool
 	for i := range in.Goals {
-		if in.Goals[i].Latency != nil {
+		if in.Goals[i].Latency != nil || in.Goals[i].Throughput != nil {
 			hasLatency = true
 		}
 		if in.Goals[i].ErrorRate != nil {
@@ -199,6 +230,42 @@ func (in *Objective) enforceErrorRate() {
 	})
 }
 
+func (in *Objective) enforceLatencyThresholds() {
+	// NOTE: Range over the goals that exist before we start appending new ones
+	for _, goal := range in.Goals {
+		if goal.Latency == nil {
+			continue
+		}
+
+		for _, lt := range sortedLatencyTypes(goal.Latency.Thresholds) {
+			threshold := goal.Latency.Thresholds[lt]
+
+			// Bound an existing goal for the same latency
+			if g := in.latencyGoal(lt); g != nil {
+				applyLatencyThreshold(g, threshold)
+				continue
+			}
+
+			nonOptimized := false
+			newGoal := Goal{
+				Optimize: &nonOptimized,
+				Latency:  &LatencyGoal{LatencyType: lt},
+			}
+			applyLatencyThreshold(&newGoal, threshold)
+			in.Goals = appendDefaultedGoal(in.Goals, newGoal)
+		}
+	}
+}
+
+func (in *Objective) latencyGoal(lt LatencyType) *Goal {
+	for i := range in.Goals {
+		if in.Goals[i].Latency != nil && FixLatency(in.Goals[i].Latency.LatencyType) == FixLatency(lt) {
+			return &in.Goals[i]
+		}
+	}
+	return nil
+}
+
 func appendDefaultedGoal(goals []Goal, goal Goal) []Goal {
 	goal.Default()
 	return append(goals, goal)
@@ -213,9 +280,15 @@ func (in *Goal) Default() {
 		case "error-rate", "error-ratio", "errors":
 			defaultErrorRateGoal(in, ErrorRateRequests)
 
+		case "throughput", "requests-per-second", "rps":
+			defaultThroughputGoal(in, ThroughputRequests)
+
 		case "duration", "time", "time-elapsed", "elapsed-time":
 			defaultDurationGoal(in, DurationTrial)
 
+		case "startup-time", "startup", "cold-start":
+			defaultDurationGoal(in, DurationStartup)
+
 		default:
 			if w := DefaultCostWeights(name); w != nil {
 				defaultRequestsGoalWeights(in, w)
@@ -228,6 +301,9 @@ func (in *Goal) Default() {
 		}
 	}
 
+	// Latency thresholds may be used without naming the latency
+	defaultLatencyThresholds(in)
+
 	// The request may have a selector but still needs weights
 	if in.Requests != nil && in.Requests.Weights == nil {
 		w := DefaultCostWeights(in.Name)
@@ -250,6 +326,10 @@ func (in *Goal) Default() {
 			in.Name = defaultObjectiveName("latency", string(in.Latency.LatencyType))
 		case in.ErrorRate != nil:
 			in.Name = defaultObjectiveName("error-rate")
+		case in.Throughput != nil:
+			in.Name = defaultObjectiveName("throughput")
+		case in.Duration != nil && in.Duration.DurationType == DurationStartup:
+			in.Name = defaultObjectiveName("startup-time")
 		case in.Duration != nil:
 			in.Name = defaultObjectiveName("duration")
 		default:
@@ -263,6 +343,7 @@ func isEmptyConfig(goal *Goal) bool {
 	return goal.Requests == nil &&
 		goal.Latency == nil &&
 		goal.ErrorRate == nil &&
+		goal.Throughput == nil &&
 		goal.Duration == nil &&
 		goal.Prometheus == nil &&
 		goal.Datadog == nil
@@ -294,6 +375,51 @@ func defaultLatencyGoal(goal *Goal, latency LatencyType) {
 	}
 }
 
+func defaultLatencyThresholds(goal *Goal) {
+	if goal.Latency == nil || len(goal.Latency.Thresholds) == 0 {
+		return
+	}
+
+	// Without an explicit latency, the goal is for the first threshold
+	if goal.Latency.LatencyType == "" {
+		goal.Latency.LatencyType = sortedLatencyTypes(goal.Latency.Thresholds)[0]
+	}
+}
+
+// applyLatencyThreshold bounds the goal using a latency threshold, explicit bounds on the goal take precedence.
+func applyLatencyThreshold(goal *Goal, threshold LatencyThreshold) {
+	if goal.Max == nil && threshold.Max != nil {
+		goal.Max = latencyQuantity(threshold.Max)
+	}
+	if goal.Min == nil && threshold.Min != nil {
+		goal.Min = latencyQuantity(threshold.Min)
+	}
+}
+
+// latencyQuantity returns the duration as a quantity of milliseconds, the unit latency metrics are reported in.
+func latencyQuantity(d *metav1.Duration) *resource.Quantity {
+	return resource.NewMilliQuantity(d.Microseconds(), resource.DecimalSI)
+}
+
+func sortedLatencyTypes(thresholds map[LatencyType]LatencyThreshold) []LatencyType {
+	result := make([]LatencyType, 0, len(thresholds))
+	for lt := range thresholds {
+		result = append(result, lt)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func defaultThroughputGoal(goal *Goal, throughput ThroughputType) {
+	if goal.Throughput == nil {
+		goal.Throughput = &ThroughputGoal{}
+	}
+
+	if goal.Throughput.ThroughputType == "" {
+		goal.Throughput.ThroughputType = throughput
+	}
+}
+
 func defaultErrorRateGoal(goal *Goal, errorRate ErrorRateType) {
 	if goal.ErrorRate == nil {
 		goal.ErrorRate = &ErrorRateGoal{}
@@ -17,11 +17,14 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestScenario_Default(t *testing.T) {
@@ -64,6 +67,20 @@ func TestScenario_Default(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "mirror",
+			scenario: Scenario{
+				Mirror: &MirrorScenario{
+					Service: "frontend",
+				},
+			},
+			expected: Scenario{
+				Name: "frontend",
+				Mirror: &MirrorScenario{
+					Service: "frontend",
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
@@ -119,6 +136,63 @@ func TestObjective_Default(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "latency thresholds",
+			objective: Objective{
+				Goals: []Goal{
+					{
+						Latency: &LatencyGoal{
+							Thresholds: map[LatencyType]LatencyThreshold{
+								"p95": {Max: &metav1.Duration{Duration: 200 * time.Millisecond}},
+								"p99": {Max: &metav1.Duration{Duration: time.Second}},
+							},
+						},
+					},
+				},
+			},
+			expected: Objective{
+				Name: "latency-p95-vs-cost",
+				Goals: []Goal{
+					{
+						Name: "latency-p95",
+						Max:  resource.NewMilliQuantity(200000, resource.DecimalSI),
+						Latency: &LatencyGoal{
+							LatencyType: "p95",
+							Thresholds: map[LatencyType]LatencyThreshold{
+								"p95": {Max: &metav1.Duration{Duration: 200 * time.Millisecond}},
+								"p99": {Max: &metav1.Duration{Duration: time.Second}},
+							},
+						},
+					},
+					{
+						Name: "cost",
+						Requests: &RequestsGoal{
+							Weights: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("17"),
+								corev1.ResourceMemory: resource.MustParse("3"),
+							},
+						},
+					},
+					{
+						Name:     "error-ratio",
+						Max:      resource.NewScaledQuantity(5, -2),
+						Optimize: new(bool),
+						ErrorRate: &ErrorRateGoal{
+							ErrorRateType: ErrorRateRequests,
+						},
+						Ignorable: true,
+					},
+					{
+						Name:     "latency-p99",
+						Max:      resource.NewMilliQuantity(1000000, resource.DecimalSI),
+						Optimize: new(bool),
+						Latency: &LatencyGoal{
+							LatencyType: "p99",
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
@@ -176,6 +250,32 @@ func TestGoal_Default(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "startup time",
+			goal: Goal{
+				Name: "startup-time",
+			},
+			expected: Goal{
+				Name: "startup-time",
+				Duration: &DurationGoal{
+					DurationType: DurationStartup,
+				},
+			},
+		},
+		{
+			desc: "startup time generated name",
+			goal: Goal{
+				Duration: &DurationGoal{
+					DurationType: DurationStartup,
+				},
+			},
+			expected: Goal{
+				Name: "startup-time",
+				Duration: &DurationGoal{
+					DurationType: DurationStartup,
+				},
+			},
+		},
 		{
 			desc: "requests missing weight",
 			goal: Goal{
@@ -195,6 +295,18 @@ func TestGoal_Default(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "throughput",
+			goal: Goal{
+				Name: "rps",
+			},
+			expected: Goal{
+				Name: "rps",
+				Throughput: &ThroughputGoal{
+					ThroughputType: ThroughputRequests,
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
@@ -248,3 +360,34 @@ func TestFixLatency(t *testing.T) {
 		})
 	}
 }
+
+func TestLatencyGoal_UnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected LatencyGoal
+	}{
+		{
+			desc:     "string",
+			input:    `"p99"`,
+			expected: LatencyGoal{LatencyType: "p99"},
+		},
+		{
+			desc:  "thresholds",
+			input: `{"p95":{"max":"200ms"}}`,
+			expected: LatencyGoal{
+				Thresholds: map[LatencyType]LatencyThreshold{
+					"p95": {Max: &metav1.Duration{Duration: 200 * time.Millisecond}},
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual := LatencyGoal{}
+			if assert.NoError(t, json.Unmarshal([]byte(c.input), &actual)) {
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
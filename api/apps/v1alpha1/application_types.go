@@ -49,6 +49,12 @@ type Application struct {
 
 	// The list of objectives to optimize the application for.
 	Objectives []Objective `json:"objectives,omitempty"`
+
+	// Datadog specifies how to collect objective metrics from Datadog instead of Prometheus.
+	Datadog *Datadog `json:"datadog,omitempty"`
+
+	// Promotion specifies when the best configuration found by an experiment is applied to the application.
+	Promotion *Promotion `json:"promotion,omitempty"`
 }
 
 // Parameter describes the strategy for tuning the application.
@@ -59,6 +65,8 @@ type Parameter struct {
 	Replicas *Replicas `json:"replicas,omitempty"`
 	// Information related to the discovery of environment variables.
 	EnvironmentVariable *EnvironmentVariable `json:"environmentVariable,omitempty"`
+	// Information related to the discovery of storage parameters like volume size or storage class.
+	Storage *Storage `json:"storage,omitempty"`
 }
 
 // ContainerResources specifies which resources in the application should have their container
@@ -78,8 +86,56 @@ type ContainerResources struct {
 	CreateIfNotPresent bool `json:"create,omitempty"`
 	// Per-namespace limit ranges for containers.
 	ContainerLimitRange map[string]corev1.LimitRangeItem `json:"containerLimitRange,omitempty"`
+	// The strategy used to tune the requests and limits. Can be one of the following values:
+	// `requests` (only tune requests), `limits` (only tune limits) or `ratio` (tune requests
+	// and set limits using the limit/request ratio). By default, requests and limits are tuned
+	// to the same value.
+	Strategy ResourcesStrategy `json:"strategy,omitempty"`
+	// The ratio of limits to requests used with the `ratio` strategy, e.g. `1.5`.
+	LimitRequestRatio *resource.Quantity `json:"limitRequestRatio,omitempty"`
+	// The relationship between the CPU limit and the tuned CPU request. Can be one of the following values:
+	// `equal` (the limit is the request), `double` (the limit is twice the request) or `none` (the CPU
+	// limit is removed). Takes precedence over the limit/request ratio for CPU.
+	CPULimit CPULimitCoupling `json:"cpuLimit,omitempty"`
+	// Explicit bounds for the optimized resources, used instead of the bounds derived from the observed values.
+	// The bounds are keyed by resource name only and apply to every matched container; use separate container
+	// resources entries (e.g. with a different selector) to bound containers differently.
+	Bounds map[corev1.ResourceName]ResourceBounds `json:"bounds,omitempty"`
+	// Names of containers (e.g. injected sidecars) that should not have their resources optimized.
+	// Defaults to a list of well-known sidecar containers such as `istio-proxy` and `linkerd-proxy`.
+	// Additional containers can be excluded on individual objects using the
+	// `apps.stormforge.io/exclude-containers` annotation.
+	ExcludeContainers []string `json:"excludeContainers,omitempty"`
+}
+
+// ResourceBounds overrides the range and baseline of a container resource parameter.
+type ResourceBounds struct {
+	// The minimum quantity.
+	Min *resource.Quantity `json:"min,omitempty"`
+	// The maximum quantity.
+	Max *resource.Quantity `json:"max,omitempty"`
+	// The baseline quantity.
+	Baseline *resource.Quantity `json:"baseline,omitempty"`
 }
 
+// ResourcesStrategy describes how container resource requests and limits are tuned.
+type ResourcesStrategy string
+
+const (
+	ResourcesStrategyRequests ResourcesStrategy = "requests"
+	ResourcesStrategyLimits   ResourcesStrategy = "limits"
+	ResourcesStrategyRatio    ResourcesStrategy = "ratio"
+)
+
+// CPULimitCoupling describes how the CPU limit is derived from the CPU request.
+type CPULimitCoupling string
+
+const (
+	CPULimitEqual  CPULimitCoupling = "equal"
+	CPULimitDouble CPULimitCoupling = "double"
+	CPULimitNone   CPULimitCoupling = "none"
+)
+
 // Replicas specifies which resources in the application should have their replica count optimized.
 type Replicas struct {
 	filters.ResourceMetaFilter
@@ -90,6 +146,12 @@ type Replicas struct {
 	Path string `json:"path,omitempty"`
 	// Create container resource specifications even if the original object does not contain them.
 	CreateIfNotPresent bool `json:"create,omitempty"`
+	// The minimum number of replicas, used instead of the default minimum.
+	Min *int32 `json:"min,omitempty"`
+	// The maximum number of replicas, used instead of the default maximum.
+	Max *int32 `json:"max,omitempty"`
+	// The baseline number of replicas, used instead of the observed value.
+	Baseline *int32 `json:"baseline,omitempty"`
 }
 
 // EnvironmentVariable specifies which environment variables in the application should have their value optimized.
@@ -109,12 +171,48 @@ type EnvironmentVariable struct {
 	Values []string `json:"values,omitempty"`
 }
 
+// Storage specifies which persistent volume claims in the application should have their storage size optimized.
+// Storage can be expanded but not shrunk, so the current size of the claim is the smallest size that will be tried.
+// StatefulSet volume claim templates and storage classes cannot be changed and are not optimized.
+type Storage struct {
+	filters.ResourceMetaFilter
+	// Regular expression matching the name of the persistent volume claim.
+	ClaimName string `json:"claimName,omitempty"`
+	// The discrete storage sizes to choose from, e.g. `["10Gi", "50Gi"]`. When specified, the size
+	// is optimized as a categorical parameter; sizes smaller than the current size are ignored.
+	Sizes []resource.Quantity `json:"sizes,omitempty"`
+}
+
 // Ingress describes the point of ingress to the application.
 type Ingress struct {
 	// The URL used to access the application from outside the cluster.
 	URL string `json:"url,omitempty"`
 }
 
+// Datadog describes how metrics for the application objectives are collected from Datadog.
+type Datadog struct {
+	// The name of the secret containing the Datadog `api-key` and `app-key`.
+	SecretName string `json:"secretName,omitempty"`
+	// The APM service name used for latency and error rate objectives.
+	Service string `json:"service,omitempty"`
+	// The APM operation name used for latency and error rate objectives, defaults to `http.request`.
+	Operation string `json:"operation,omitempty"`
+	// Queries used in place of the default queries, keyed by objective goal name. Queries are
+	// templates which may reference the trial, e.g. `{{ .Trial.Name }}`.
+	Queries map[string]string `json:"queries,omitempty"`
+	// The aggregator to use on the query results (one of: avg, last, max, min, sum).
+	Aggregator string `json:"aggregator,omitempty"`
+}
+
+// Promotion describes when the best configuration found by an experiment is automatically applied to the application.
+type Promotion struct {
+	// The minimum percentage improvement of the optimized objective over the baseline required for promotion.
+	MinImprovement int32 `json:"minImprovement,omitempty"`
+	// The amount of time after promotion during which the previous configuration is restored if the application
+	// fails to become ready.
+	RollbackWindow *metav1.Duration `json:"rollbackWindow,omitempty"`
+}
+
 // Scenario describes a specific pattern of load to optimize the application for.
 type Scenario struct {
 	// The name of scenario.
@@ -125,6 +223,54 @@ type Scenario struct {
 	Locust *LocustScenario `json:"locust,omitempty"`
 	// Custom configuration for the scenario.
 	Custom *CustomScenario `json:"custom,omitempty"`
+	// Traffic mirroring configuration for the scenario.
+	Mirror *MirrorScenario `json:"mirror,omitempty"`
+	// Additional data files (e.g. request bodies, headers or CSV datasets) available to the trial job.
+	Data []ScenarioData `json:"data,omitempty"`
+	// Customizations to the generated trial job, e.g. to satisfy cluster constraints.
+	JobOverrides *JobOverrides `json:"jobOverrides,omitempty"`
+	// Assertions evaluated against the load test metrics once the trial run completes.
+	Assertions *ScenarioAssertions `json:"assertions,omitempty"`
+}
+
+// ScenarioAssertions are service level assertions checked against the metrics collected from the load test; when
+// an assertion is violated the trial fails and is reported as infeasible. Only StormForge Performance and Locust
+// scenarios support assertions.
+type ScenarioAssertions struct {
+	// The maximum ratio of failed requests, e.g. `0.05` or `50m` for 5%.
+	MaxErrorRate *resource.Quantity `json:"maxErrorRate,omitempty"`
+	// The maximum 99th percentile latency in milliseconds.
+	MaxLatencyP99 *resource.Quantity `json:"maxLatencyP99,omitempty"`
+}
+
+// JobOverrides are merged into the trial job generated for a scenario.
+type JobOverrides struct {
+	// Compute resources for the load generating container(s).
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Environment variables for the load generating container(s), replacing any generated variables with the same name.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Tolerations for the trial job pod.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Additional containers to run in the trial job pod.
+	Containers []corev1.Container `json:"containers,omitempty"`
+	// The number of seconds to retain the trial job after it finishes.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// ScenarioData is a file made available to every container of the trial job in the `/mnt/data` directory.
+// Exactly one of the value, file or ConfigMap key reference should be specified.
+type ScenarioData struct {
+	// The file name of the data, must be a valid ConfigMap key.
+	Name string `json:"name,omitempty"`
+	// The inline contents of the data.
+	Value string `json:"value,omitempty"`
+	// Path to a local file containing the data.
+	File string `json:"file,omitempty"`
+	// Reference to an existing ConfigMap key containing the data.
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// Flag indicating the data is a Go template that should be rendered against the trial assignments
+	// (e.g. `{{ .Values.replicas }}`) before the trial job starts. Not supported for ConfigMap references.
+	Template bool `json:"template,omitempty"`
 }
 
 // StormForgeScenario is used to generate load using StormForge Performance testing.
@@ -147,6 +293,15 @@ type LocustScenario struct {
 	SpawnRate *int `json:"spawnRate,omitempty"`
 	// Stop after the specified amount of time.
 	RunTime *metav1.Duration `json:"runTime,omitempty"`
+	// Number of Locust workers to distribute the load across. The workers are run by a separate deployment
+	// which connects to the Locust master running in the trial job through a service.
+	Workers *int `json:"workers,omitempty"`
+	// The image to run Locust with, it must be compatible with the default Locust trial image.
+	Image string `json:"image,omitempty"`
+	// Additional pip requirements to install before Locust starts.
+	Requirements []string `json:"requirements,omitempty"`
+	// Additional environment variables for the Locust processes.
+	Env []corev1.EnvVar `json:"env,omitempty"`
 }
 
 // CustomScenario is used for advanced cases where more flexibility is required.
@@ -163,8 +318,44 @@ type CustomScenario struct {
 	ApproximateRuntimeSeconds int32 `json:"approximateRuntimeSeconds,omitempty"`
 	// Override the image of the first container in the trial pod.
 	Image string `json:"image,omitempty"`
+	// Override the entrypoint of the first container in the trial pod.
+	Command []string `json:"command,omitempty"`
+	// Override the arguments of the first container in the trial pod.
+	Args []string `json:"args,omitempty"`
+	// Additional environment variables for the first container in the trial pod.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// MirrorScenario is used to optimize the application using a copy of the production traffic instead of
+// synthetic load. The mirroring configuration is created before each trial and removed once it completes.
+type MirrorScenario struct {
+	// The service mesh or gateway implementation used to mirror the traffic. Can be one of the following
+	// values: `istio` (a VirtualService is used), `linkerd` or `gatewayAPI` (an HTTPRoute attached to the
+	// service is used). Defaults to `istio`.
+	Provider MirrorProvider `json:"provider,omitempty"`
+	// The name of the service receiving the production traffic.
+	Service string `json:"service,omitempty"`
+	// The port of the service receiving the production traffic.
+	Port int32 `json:"port,omitempty"`
+	// The name of the service selecting the pods being tuned by the trial.
+	MirrorService string `json:"mirrorService,omitempty"`
+	// The port of the mirror service, defaults to the production service port.
+	MirrorPort int32 `json:"mirrorPort,omitempty"`
+	// The percentage of production traffic to mirror, defaults to all of it.
+	Percentage *int32 `json:"percentage,omitempty"`
+	// The amount of time traffic is mirrored for each trial.
+	ObservationSeconds int32 `json:"observationSeconds,omitempty"`
 }
 
+// MirrorProvider identifies the implementation used to mirror traffic.
+type MirrorProvider string
+
+const (
+	MirrorIstio      MirrorProvider = "istio"
+	MirrorLinkerd    MirrorProvider = "linkerd"
+	MirrorGatewayAPI MirrorProvider = "gatewayAPI"
+)
+
 // Objective describes the goals of the optimization in terms of specific metrics.
 type Objective struct {
 	// The name of the objective. If omitted, a default name will be generated
@@ -186,6 +377,8 @@ type Goal struct {
 	Min *resource.Quantity `json:"min,omitempty"`
 	// Flag indicating that this objective should optimized instead of monitored (default: true).
 	Optimize *bool `json:"optimize,omitempty"`
+	// The relative weight of this objective when blending multiple objectives into a single recommendation.
+	Weight *resource.Quantity `json:"weight,omitempty"`
 
 	// Requests is used to optimize the resources consumed by an application.
 	Requests *RequestsGoal `json:"requests,omitempty"`
@@ -193,6 +386,8 @@ type Goal struct {
 	Latency *LatencyGoal `json:"latency,omitempty"`
 	// ErrorRate is used to optimize the failure rate of an application.
 	ErrorRate *ErrorRateGoal `json:"errorRate,omitempty"`
+	// Throughput is used to optimize the rate at which an application handles work.
+	Throughput *ThroughputGoal `json:"throughput,omitempty"`
 	// Duration is used to optimize the elapsed time of an application performing a fixed amount of work.
 	Duration *DurationGoal `json:"duration,omitempty"`
 	// Prometheus is used to optimize against a Prometheus metric.
@@ -216,6 +411,11 @@ type RequestsGoal struct {
 	Selector string `json:"selector,omitempty"`
 	// Weights are used to determine which container resources should be optimized.
 	Weights corev1.ResourceList `json:"weights,omitempty"`
+	// Flag indicating the cost should be computed from the price sheet resolved by the controller (the
+	// "optimize-pricing" config map in the trial namespace) instead of the weights. Node type prices require the
+	// `kube_pod_info` and `kube_node_labels` metrics, which the built-in Prometheus does not collect when it is
+	// restricted to namespaced permissions.
+	PriceSheet bool `json:"priceSheet,omitempty"`
 }
 
 // LatencyGoal is used to optimize the responsiveness of an application in a specific scenario.
@@ -225,10 +425,25 @@ type LatencyGoal struct {
 	// `percentile_50` (or `p50`, `median`, `med`), `percentile_95` (or `p95`),
 	// `percentile_99` (or `p99`).
 	LatencyType
+	// Thresholds the latencies must meet, keyed by the latency type. Thresholds are specified by using an object
+	// instead of a string, e.g. `latency: {p95: {max: 200ms}}`; configurations that do not meet a threshold are
+	// rejected instead of being optimized.
+	Thresholds map[LatencyType]LatencyThreshold `json:"-"`
+}
+
+// LatencyThreshold is a service level objective expressed as a range of acceptable latencies.
+type LatencyThreshold struct {
+	// The maximum acceptable latency.
+	Max *metav1.Duration `json:"max,omitempty"`
+	// The minimum acceptable latency.
+	Min *metav1.Duration `json:"min,omitempty"`
 }
 
-// UnmarshalJSON allows a latency objective to be specified as a simple string.
+// UnmarshalJSON allows a latency objective to be specified as a simple string or as a map of thresholds.
 func (in *LatencyGoal) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		return json.Unmarshal(data, &in.Thresholds)
+	}
 	return json.Unmarshal(data, &in.LatencyType)
 }
 
@@ -262,9 +477,27 @@ const (
 	ErrorRateRequests ErrorRateType = "requests"
 )
 
+// ThroughputGoal is used to optimize the throughput of an application in a specific scenario.
+type ThroughputGoal struct {
+	// The throughput to optimize. Can be one of the following values: `requests`.
+	ThroughputType
+}
+
+// UnmarshalJSON allows a throughput objective to be specified as a simple string.
+func (in *ThroughputGoal) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &in.ThroughputType)
+}
+
+// ThroughputType describes something which can be counted over time.
+type ThroughputType string
+
+const (
+	ThroughputRequests ThroughputType = "requests"
+)
+
 // DurationGoal is used to optimize the amount of time elapsed in a specific scenario.
 type DurationGoal struct {
-	// The duration to optimize. Can be one of the following values: `trial`.
+	// The duration to optimize. Can be one of the following values: `trial`, `startup`.
 	DurationType
 }
 
@@ -277,7 +510,8 @@ func (in *DurationGoal) UnmarshalJSON(data []byte) error {
 type DurationType string
 
 const (
-	DurationTrial DurationType = "trial"
+	DurationTrial   DurationType = "trial"
+	DurationStartup DurationType = "startup"
 )
 
 // PrometheusGoal is used to define an external optimization metric from Prometheus.
@@ -287,6 +521,9 @@ type PrometheusGoal struct {
 	// The URL of the Prometheus deployment, leave blank to leverage a Prometheus instance
 	// whose lifecycle is tied to the trial.
 	URL string `json:"url,omitempty"`
+	// The secret key containing the bearer token used to query the Prometheus deployment at the URL, this
+	// overrides any globally configured credentials (e.g. when querying a central Thanos deployment).
+	BearerTokenSecretRef *corev1.SecretKeySelector `json:"bearerTokenSecretRef,omitempty"`
 	// Flag indicating the goal of optimization should be to maximize a metric.
 	Maximize bool `json:"maximize,omitempty"`
 }
@@ -28,6 +28,13 @@ const (
 	// LabelObjective is the application objective associated with an object.
 	LabelObjective = "stormforge.io/objective"
 
+	// LabelRunKey identifies the application run (application, scenario and template revision) which created an object.
+	LabelRunKey = "stormforge.io/run-key"
+
 	// AnnotationLastScanned is the timestamp of the last application scan.
 	AnnotationLastScanned = "apps.stormforge.io/last-scanned"
+
+	// AnnotationExcludeContainers is a comma separated list of container names that should not have their
+	// resources optimized.
+	AnnotationExcludeContainers = "apps.stormforge.io/exclude-containers"
 )
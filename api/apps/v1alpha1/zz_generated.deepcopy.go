@@ -24,6 +24,7 @@ package v1alpha1
 import (
 	"github.com/thestormforge/konjure/pkg/konjure"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -66,6 +67,16 @@ func (in *Application) DeepCopyInto(out *Application) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Datadog != nil {
+		in, out := &in.Datadog, &out.Datadog
+		*out = new(Datadog)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Promotion != nil {
+		in, out := &in.Promotion, &out.Promotion
+		*out = new(Promotion)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Application.
@@ -102,6 +113,23 @@ func (in *ContainerResources) DeepCopyInto(out *ContainerResources) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.LimitRequestRatio != nil {
+		in, out := &in.LimitRequestRatio, &out.LimitRequestRatio
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Bounds != nil {
+		in, out := &in.Bounds, &out.Bounds
+		*out = make(map[v1.ResourceName]ResourceBounds, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.ExcludeContainers != nil {
+		in, out := &in.ExcludeContainers, &out.ExcludeContainers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerResources.
@@ -122,6 +150,23 @@ func (in *CustomScenario) DeepCopyInto(out *CustomScenario) {
 		*out = new(v1.PodTemplateSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomScenario.
@@ -134,6 +179,28 @@ func (in *CustomScenario) DeepCopy() *CustomScenario {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Datadog) DeepCopyInto(out *Datadog) {
+	*out = *in
+	if in.Queries != nil {
+		in, out := &in.Queries, &out.Queries
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Datadog.
+func (in *Datadog) DeepCopy() *Datadog {
+	if in == nil {
+		return nil
+	}
+	out := new(Datadog)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DatadogGoal) DeepCopyInto(out *DatadogGoal) {
 	*out = *in
@@ -218,6 +285,11 @@ func (in *Goal) DeepCopyInto(out *Goal) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 	if in.Requests != nil {
 		in, out := &in.Requests, &out.Requests
 		*out = new(RequestsGoal)
@@ -226,13 +298,18 @@ func (in *Goal) DeepCopyInto(out *Goal) {
 	if in.Latency != nil {
 		in, out := &in.Latency, &out.Latency
 		*out = new(LatencyGoal)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.ErrorRate != nil {
 		in, out := &in.ErrorRate, &out.ErrorRate
 		*out = new(ErrorRateGoal)
 		**out = **in
 	}
+	if in.Throughput != nil {
+		in, out := &in.Throughput, &out.Throughput
+		*out = new(ThroughputGoal)
+		**out = **in
+	}
 	if in.Duration != nil {
 		in, out := &in.Duration, &out.Duration
 		*out = new(DurationGoal)
@@ -241,7 +318,7 @@ func (in *Goal) DeepCopyInto(out *Goal) {
 	if in.Prometheus != nil {
 		in, out := &in.Prometheus, &out.Prometheus
 		*out = new(PrometheusGoal)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Datadog != nil {
 		in, out := &in.Datadog, &out.Datadog
@@ -275,9 +352,62 @@ func (in *Ingress) DeepCopy() *Ingress {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobOverrides) DeepCopyInto(out *JobOverrides) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobOverrides.
+func (in *JobOverrides) DeepCopy() *JobOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(JobOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LatencyGoal) DeepCopyInto(out *LatencyGoal) {
 	*out = *in
+	if in.Thresholds != nil {
+		in, out := &in.Thresholds, &out.Thresholds
+		*out = make(map[LatencyType]LatencyThreshold, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LatencyGoal.
@@ -290,6 +420,31 @@ func (in *LatencyGoal) DeepCopy() *LatencyGoal {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LatencyThreshold) DeepCopyInto(out *LatencyThreshold) {
+	*out = *in
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LatencyThreshold.
+func (in *LatencyThreshold) DeepCopy() *LatencyThreshold {
+	if in == nil {
+		return nil
+	}
+	out := new(LatencyThreshold)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocustScenario) DeepCopyInto(out *LocustScenario) {
 	*out = *in
@@ -308,6 +463,23 @@ func (in *LocustScenario) DeepCopyInto(out *LocustScenario) {
 		*out = new(metav1.Duration)
 		**out = **in
 	}
+	if in.Workers != nil {
+		in, out := &in.Workers, &out.Workers
+		*out = new(int)
+		**out = **in
+	}
+	if in.Requirements != nil {
+		in, out := &in.Requirements, &out.Requirements
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocustScenario.
@@ -320,6 +492,26 @@ func (in *LocustScenario) DeepCopy() *LocustScenario {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirrorScenario) DeepCopyInto(out *MirrorScenario) {
+	*out = *in
+	if in.Percentage != nil {
+		in, out := &in.Percentage, &out.Percentage
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorScenario.
+func (in *MirrorScenario) DeepCopy() *MirrorScenario {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorScenario)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Objective) DeepCopyInto(out *Objective) {
 	*out = *in
@@ -353,13 +545,18 @@ func (in *Parameter) DeepCopyInto(out *Parameter) {
 	if in.Replicas != nil {
 		in, out := &in.Replicas, &out.Replicas
 		*out = new(Replicas)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.EnvironmentVariable != nil {
 		in, out := &in.EnvironmentVariable, &out.EnvironmentVariable
 		*out = new(EnvironmentVariable)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(Storage)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Parameter.
@@ -375,6 +572,11 @@ func (in *Parameter) DeepCopy() *Parameter {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PrometheusGoal) DeepCopyInto(out *PrometheusGoal) {
 	*out = *in
+	if in.BearerTokenSecretRef != nil {
+		in, out := &in.BearerTokenSecretRef, &out.BearerTokenSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusGoal.
@@ -387,10 +589,45 @@ func (in *PrometheusGoal) DeepCopy() *PrometheusGoal {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Promotion) DeepCopyInto(out *Promotion) {
+	*out = *in
+	if in.RollbackWindow != nil {
+		in, out := &in.RollbackWindow, &out.RollbackWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Promotion.
+func (in *Promotion) DeepCopy() *Promotion {
+	if in == nil {
+		return nil
+	}
+	out := new(Promotion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Replicas) DeepCopyInto(out *Replicas) {
 	*out = *in
 	out.ResourceMetaFilter = in.ResourceMetaFilter
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Baseline != nil {
+		in, out := &in.Baseline, &out.Baseline
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Replicas.
@@ -425,6 +662,36 @@ func (in *RequestsGoal) DeepCopy() *RequestsGoal {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceBounds) DeepCopyInto(out *ResourceBounds) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Baseline != nil {
+		in, out := &in.Baseline, &out.Baseline
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceBounds.
+func (in *ResourceBounds) DeepCopy() *ResourceBounds {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceBounds)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Scenario) DeepCopyInto(out *Scenario) {
 	*out = *in
@@ -443,6 +710,28 @@ func (in *Scenario) DeepCopyInto(out *Scenario) {
 		*out = new(CustomScenario)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(MirrorScenario)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make([]ScenarioData, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.JobOverrides != nil {
+		in, out := &in.JobOverrides, &out.JobOverrides
+		*out = new(JobOverrides)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Assertions != nil {
+		in, out := &in.Assertions, &out.Assertions
+		*out = new(ScenarioAssertions)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Scenario.
@@ -455,6 +744,74 @@ func (in *Scenario) DeepCopy() *Scenario {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScenarioAssertions) DeepCopyInto(out *ScenarioAssertions) {
+	*out = *in
+	if in.MaxErrorRate != nil {
+		in, out := &in.MaxErrorRate, &out.MaxErrorRate
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxLatencyP99 != nil {
+		in, out := &in.MaxLatencyP99, &out.MaxLatencyP99
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScenarioAssertions.
+func (in *ScenarioAssertions) DeepCopy() *ScenarioAssertions {
+	if in == nil {
+		return nil
+	}
+	out := new(ScenarioAssertions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScenarioData) DeepCopyInto(out *ScenarioData) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScenarioData.
+func (in *ScenarioData) DeepCopy() *ScenarioData {
+	if in == nil {
+		return nil
+	}
+	out := new(ScenarioData)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Storage) DeepCopyInto(out *Storage) {
+	*out = *in
+	out.ResourceMetaFilter = in.ResourceMetaFilter
+	if in.Sizes != nil {
+		in, out := &in.Sizes, &out.Sizes
+		*out = make([]resource.Quantity, len(*in))
+		for i := range *in {
+			(*out)[i] = (*in)[i].DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Storage.
+func (in *Storage) DeepCopy() *Storage {
+	if in == nil {
+		return nil
+	}
+	out := new(Storage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StormForgeScenario) DeepCopyInto(out *StormForgeScenario) {
 	*out = *in
@@ -469,3 +826,18 @@ func (in *StormForgeScenario) DeepCopy() *StormForgeScenario {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThroughputGoal) DeepCopyInto(out *ThroughputGoal) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThroughputGoal.
+func (in *ThroughputGoal) DeepCopy() *ThroughputGoal {
+	if in == nil {
+		return nil
+	}
+	out := new(ThroughputGoal)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -22,7 +22,7 @@ import (
 
 // Replicas returns the effective replica (trial) count for the experiment
 func (in *Experiment) Replicas() int32 {
-	if in == nil || !in.DeletionTimestamp.IsZero() {
+	if in == nil || !in.DeletionTimestamp.IsZero() || in.IsPaused() {
 		return 0
 	}
 	if in.Spec.Replicas != nil {
@@ -31,6 +31,11 @@ func (in *Experiment) Replicas() int32 {
 	return 1
 }
 
+// IsPaused checks to see if the experiment has been paused
+func (in *Experiment) IsPaused() bool {
+	return in != nil && in.GetAnnotations()[AnnotationPaused] == "true"
+}
+
 // SetReplicas establishes a new replica (trial) count for the experiment
 func (in *Experiment) SetReplicas(r int) {
 	if in != nil {
@@ -129,14 +129,30 @@ const (
 	// reference is supplied, the trial itself is assumed). Queries are Go Templates evaluated against the
 	// the result of the API call.
 	MetricKubernetes MetricType = "kubernetes"
+	// MetricKubernetesObject metrics read a field from the live Kubernetes object using the target reference and
+	// selector (if no reference is supplied, the trial itself is assumed). Queries are JSON path expressions (with
+	// curly braces) evaluated against the object, e.g. `{.status.readyReplicas}`.
+	MetricKubernetesObject MetricType = "kubernetes-object"
 	// MetricPrometheus metrics issue PromQL queries to a matched service. Queries MUST evaluate to a scalar value.
 	MetricPrometheus MetricType = "prometheus"
+	// MetricPrometheusHistogram metrics compute a quantile of a Prometheus histogram over the trial run. Queries are
+	// the name of the histogram with optional label matchers, e.g. `http_request_duration_seconds{job="api"}`.
+	MetricPrometheusHistogram MetricType = "prometheus-histogram"
 	// MetricDatadog metrics issue queries to the Datadog service. Requires API and application key configuration.
 	MetricDatadog MetricType = "datadog"
 	// MetricJSONPath metrics fetch a JSON resource from the matched service. Queries are JSON path expression evaluated against the resource.
 	MetricJSONPath MetricType = "jsonpath"
 	// MetricNewRelic metrics issue queries to the New Relic service. Requires API and application key configuration.
 	MetricNewRelic MetricType = "newrelic"
+	// MetricInfluxDB metrics issue Flux queries to an InfluxDB v2 server. The trial run times are available to the
+	// query as `v.timeRangeStart` and `v.timeRangeStop`. Queries MUST produce a single value.
+	MetricInfluxDB MetricType = "influxdb"
+	// MetricPush metrics use values pushed by the trial run itself. Queries are the name of the pushed value.
+	MetricPush MetricType = "push"
+	// MetricUsage metrics use the resource usage of the patched pods sampled from the Kubernetes metrics API during the
+	// trial run (usage collection must be enabled on the controller). Queries are one of: cpu-avg, cpu-max,
+	// memory-avg, memory-max, cpu-utilization or memory-utilization.
+	MetricUsage MetricType = "usage"
 )
 
 // Metric represents an observable outcome from a trial run
@@ -151,20 +167,88 @@ type Metric struct {
 	Max *resource.Quantity `json:"max,omitempty"`
 	// Indicator that this metric should be optimized (default: true)
 	Optimize *bool `json:"optimize,omitempty"`
+	// The range of plausible values for the metric, values outside this range are assumed to be collection errors
+	// (e.g. a zero caused by a gap in the scraped data) and are collected once more before failing the trial
+	Plausible *MetricRange `json:"plausible,omitempty"`
+	// The relative weight of this metric when blending the optimized metrics into a single ranking of the trials
+	Weight *resource.Quantity `json:"weight,omitempty"`
 
-	// The metric collection type, one of: kubernetes|prometheus|datadog|jsonpath, default: kubernetes
+	// The metric collection type, one of: kubernetes|kubernetes-object|prometheus|datadog|prometheus-histogram|jsonpath|newrelic|influxdb|push|usage, default: kubernetes
 	Type MetricType `json:"type,omitempty"`
-	// Collection type specific query, e.g. Go template for "kubernetes", PromQL for "prometheus" or a JSON pointer expression (with curly braces) for "jsonpath"
+	// Collection type specific query, e.g. Go template for "kubernetes", PromQL for "prometheus", Flux for "influxdb" or a JSON pointer expression (with curly braces) for "jsonpath" and "kubernetes-object"
 	Query string `json:"query"`
 	// Collection type specific query for the error associated with collected metric value
 	ErrorQuery string `json:"errorQuery,omitempty"`
+	// The quantile (between 0 and 1) to compute for "prometheus-histogram" metrics, e.g. "0.95"
+	Quantile *resource.Quantity `json:"quantile,omitempty"`
 
 	// URL to use when querying remote metric sources.
 	URL string `json:"url,omitempty"`
+	// Reference to a secret key containing a bearer token used when querying remote metric sources.
+	BearerTokenSecretRef *corev1.SecretKeySelector `json:"bearerTokenSecretRef,omitempty"`
+	// Reference to a secret containing the credentials used when querying remote metric sources, for example
+	// the `api-key` and `app-key` of a Datadog metric or the `token`, `username`, `password` and `ca.crt` of a
+	// JSON path metric. Cannot be used with a bearer token secret reference.
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+	// Additional HTTP headers to include when querying remote metric sources.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Retry policy for failed requests to remote metric sources.
+	Retry *MetricRetry `json:"retry,omitempty"`
+	// Amount of time to wait after the trial run completes before collecting the metric, e.g. to allow for
+	// delayed ingestion in the metric source.
+	Delay *metav1.Duration `json:"delay,omitempty"`
+	// The interval of the trial run to observe, defaults to the entire trial run.
+	Window *MetricWindow `json:"window,omitempty"`
+	// How values are combined over the observed interval, one of: avg|max|min|last. Only supported by
+	// "prometheus" and "datadog" metrics.
+	Aggregation string `json:"aggregation,omitempty"`
+	// The unit of the collected values, e.g. "ms", "MiB" or "req/min". Values are converted to the canonical unit of
+	// the same kind (seconds, bytes, cores, ratio or per second) before they are recorded; min, max and the plausible
+	// range are expressed in this unit.
+	Unit string `json:"unit,omitempty"`
 	// Target reference of the Kubernetes object to query for metric information.
 	Target *ResourceTarget `json:"target,omitempty"`
 }
 
+// MetricWindow is the interval of a trial run used to collect a metric value
+type MetricWindow struct {
+	// Amount of time after the trial run starts to begin observing, used to exclude warm-up periods
+	Offset *metav1.Duration `json:"offset,omitempty"`
+	// Amount of time to observe, defaults to the remainder of the trial run
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// MetricRange is an inclusive range of metric values
+type MetricRange struct {
+	// The inclusive minimum value
+	Min *resource.Quantity `json:"min,omitempty"`
+	// The inclusive maximum value
+	Max *resource.Quantity `json:"max,omitempty"`
+}
+
+// MetricRetry controls how failed requests to remote metric sources are retried
+type MetricRetry struct {
+	// The maximum number of requests to make, defaults to 1 (no retries)
+	Attempts int32 `json:"attempts,omitempty"`
+	// The number of seconds to wait before the first retry, doubling for each subsequent retry, defaults to 1
+	BackoffSeconds int32 `json:"backoffSeconds,omitempty"`
+}
+
+// Guardrail is a bound on a metric that is checked while the trial run is still in progress, when the metric value
+// falls outside the bounds the trial is aborted and marked as failed
+type Guardrail struct {
+	// The name of the metric to check
+	Metric string `json:"metric"`
+	// The inclusive minimum allowed value for the metric
+	Min *resource.Quantity `json:"min,omitempty"`
+	// The inclusive maximum allowed value for the metric
+	Max *resource.Quantity `json:"max,omitempty"`
+	// InitialDelaySeconds is the number of seconds to wait after the trial run starts before checking the metric
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	// PeriodSeconds is how often (in seconds) to check the metric, defaults to 30
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+}
+
 // PatchReadinessGate contains a reference to a condition
 type PatchReadinessGate struct {
 	// ConditionType refers to a condition in the patched target's condition list
@@ -181,30 +265,81 @@ const (
 	PatchMerge PatchType = "merge"
 	// PatchJSON is the patch type for aJSON patch (RFC 6902)
 	PatchJSON PatchType = "json"
+	// PatchJSON6902 is the patch type for a JSON patch (RFC 6902) built from a list of templated operations
+	PatchJSON6902 PatchType = "json6902"
 )
 
 // PatchTemplate defines a target resource and a patch template to apply
 type PatchTemplate struct {
-	// The patch type, one of: strategic|merge|json, default: strategic
+	// The patch type, one of: strategic|merge|json|json6902, default: strategic
 	Type PatchType `json:"type,omitempty"`
 	// Direct reference to the object the patch should be applied to
 	TargetRef *corev1.ObjectReference `json:"targetRef,omitempty"`
-	// A Go Template that evaluates to valid patch
-	Patch string `json:"patch"`
+	// A Go Template that evaluates to valid patch, required unless the type is "json6902". Strategic merge patches can
+	// remove a field by setting it to `null` or replace a list wholesale by including a `$patch: replace` element
+	Patch string `json:"patch,omitempty"`
+	// The list of JSON patch operations to apply for "json6902" patches, useful for arrays and custom resources
+	// where a strategic merge patch cannot be used
+	Operations []JSONPatchOperation `json:"operations,omitempty"`
 	// ReadinessGates will be evaluated for patch target readiness. A patch target is ready if all conditions specified
 	// in the readiness gates have a status equal to "True". If no readiness gates are specified, some target types may
 	// have default gates assigned to them. Some condition checks may result in errors, e.g. a condition type of "Ready"
 	// is not allowed for a ConfigMap. Condition types starting with "stormforge.io/" may not appear in the patched
 	// target's condition list, but are still evaluated against the resource's state.
 	ReadinessGates []PatchReadinessGate `json:"readinessGates,omitempty"`
+	// Resize the container resources of the running pods in place instead of rolling out the patched workload, this
+	// requires the InPlacePodVerticalScaling feature and the pod "resize" subresource. If any of the pods cannot be
+	// resized, the workload is patched instead. The workload itself is not changed, pods replaced during the trial
+	// use the original container resources. Resized pods are always restored to match the workload once the trial
+	// is finished.
+	InPlaceResize bool `json:"inPlaceResize,omitempty"`
+	// Restore the original state of the target once the trial is finished or deleted. Restoring a workload usually
+	// requires an additional rollout at the end of every trial.
+	Restore bool `json:"restore,omitempty"`
+	// The wave in which the patch is applied, patches in lower waves are applied first and their targets must be ready
+	// before patches in the next wave are applied (e.g. to update a ConfigMap before the Deployment that consumes it)
+	Wave int32 `json:"wave,omitempty"`
+	// References to workloads which are restarted after the patch is applied, useful when the patch only changes
+	// configuration (e.g. a ConfigMap) that is read by the workload when it starts
+	RestartRefs []corev1.ObjectReference `json:"restartRefs,omitempty"`
+}
+
+// JSONPatchOperation is a single JSON patch (RFC 6902) operation, the path, from and value fields are Go Templates
+type JSONPatchOperation struct {
+	// The operation to perform, one of: add|remove|replace|move|copy|test
+	Op string `json:"op"`
+	// The JSON pointer to the location in the target document
+	Path string `json:"path"`
+	// The JSON pointer to the source location for "move" and "copy" operations
+	From string `json:"from,omitempty"`
+	// A Go Template that evaluates to the YAML (or JSON) value for "add", "replace" and "test" operations
+	Value string `json:"value,omitempty"`
+}
+
+// TrialEndpoint defines a trial specific endpoint (e.g. a trial-local dependency) to inject into a resource
+type TrialEndpoint struct {
+	// The name of the environment variable (or ConfigMap key) used to expose the endpoint
+	Name string `json:"name"`
+	// A Go Template that evaluates to the endpoint, e.g. `http://mock-{{ .Trial.Name }}:8080`
+	Value string `json:"value"`
+	// Direct reference to the object the endpoint should be injected into; ConfigMaps receive the endpoint as a data
+	// key while workloads receive the endpoint as an environment variable
+	TargetRef corev1.ObjectReference `json:"targetRef"`
+	// The name of the container to inject the endpoint into, required unless the target is a ConfigMap
+	ContainerName string `json:"containerName,omitempty"`
 }
 
-// NamespaceTemplateSpec is used as a template for creating new namespaces
+// NamespaceTemplateSpec is used as a template for creating new namespaces; namespaces created from the template are
+// deleted once they no longer have an active trial
 type NamespaceTemplateSpec struct {
 	// Standard object metadata
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// Specification of the namespace
 	Spec corev1.NamespaceSpec `json:"spec,omitempty"`
+	// ResourceQuota is used to create a resource quota in each new namespace
+	ResourceQuota *corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+	// LimitRange is used to create a limit range in each new namespace
+	LimitRange *corev1.LimitRangeSpec `json:"limitRange,omitempty"`
 }
 
 // TrialTemplateSpec is used as a template for creating new trials
@@ -223,6 +358,21 @@ const (
 	ExperimentComplete ExperimentConditionType = "stormforge.io/experiment-complete"
 	// ExperimentFailed is a condition that indicates an experiment failed
 	ExperimentFailed ExperimentConditionType = "stormforge.io/experiment-failed"
+	// ExperimentDeadlineReached is a condition that indicates the experiment deadline has passed
+	ExperimentDeadlineReached ExperimentConditionType = "stormforge.io/experiment-deadline-reached"
+	// ExperimentBudgetExhausted is a condition that indicates the experiment trial, duration or cost budget is exhausted
+	ExperimentBudgetExhausted ExperimentConditionType = "stormforge.io/experiment-budget-exhausted"
+	// ExperimentWaiting is a condition that indicates the experiment is queued behind controller concurrency limits
+	ExperimentWaiting ExperimentConditionType = "stormforge.io/experiment-waiting"
+	// ExperimentReplicasLimited is a condition that indicates only one of the experiment replicas can run at a time
+	// because every trial must use the same namespace
+	ExperimentReplicasLimited ExperimentConditionType = "stormforge.io/experiment-replicas-limited"
+	// ExperimentDrifted is a condition that indicates the live workloads no longer match the best trial of a completed
+	// experiment
+	ExperimentDrifted ExperimentConditionType = "stormforge.io/experiment-drifted"
+	// ExperimentPromoted is a condition that indicates the best trial of a completed experiment was applied to the
+	// cluster, the status is "unknown" while the patches are being applied
+	ExperimentPromoted ExperimentConditionType = "stormforge.io/experiment-promoted"
 )
 
 // ExperimentCondition represents an observed condition of an experiment
@@ -241,10 +391,60 @@ type ExperimentCondition struct {
 	Message string `json:"message,omitempty"`
 }
 
+// PromotionPolicy controls the automatic promotion of the best trial of a completed experiment
+type PromotionPolicy struct {
+	// MinImprovementPercent is the minimum relative improvement of the optimized metric over the baseline trial
+	// required to promote the best trial
+	MinImprovementPercent int32 `json:"minImprovementPercent,omitempty"`
+	// RollbackWindowSeconds is the amount of time after promotion during which the original configuration is restored
+	// if the promoted objects fail to become ready or the experiment is annotated with "stormforge.io/rollback=true"
+	RollbackWindowSeconds int32 `json:"rollbackWindowSeconds,omitempty"`
+}
+
+// RetryPolicy distinguishes trial failures caused by the infrastructure from failures caused by the trial assignments
+type RetryPolicy struct {
+	// Infrastructure controls retries of trials that failed for reasons unrelated to their assignments, e.g. evicted
+	// or preempted pods, stale trials (such as a container stuck pulling its image) or failed metric queries
+	Infrastructure *FailureRetry `json:"infrastructure,omitempty"`
+	// Assignment controls retries of trials that failed because of their assignments, e.g. patched pods
+	// that are unschedulable or containers that are OOMKilled or exit with an error
+	Assignment *FailureRetry `json:"assignment,omitempty"`
+}
+
+// FailureRetry defines how many times and how often a class of trial failure is retried
+type FailureRetry struct {
+	// Limit is the maximum number of times a trial is retried, defaults to 0
+	Limit int32 `json:"limit,omitempty"`
+	// BackoffSeconds is the number of seconds to wait before the first retry, the delay doubles with each retry
+	BackoffSeconds int32 `json:"backoffSeconds,omitempty"`
+}
+
 // ExperimentSpec defines the desired state of Experiment
 type ExperimentSpec struct {
-	// Replicas is the number of trials to execute concurrently, defaults to 1
+	// Replicas is the number of trials to execute concurrently, defaults to 1; running more than one trial at a time
+	// requires a namespace selector or template so each trial can use a separate namespace
 	Replicas *int32 `json:"replicas,omitempty"`
+	// Deadline is the time after which no new trials will be started, trials that are already running are allowed to
+	// finish before the experiment is completed
+	Deadline *metav1.Time `json:"deadline,omitempty"`
+	// MaxTrials is the maximum number of trials to run, once reached no new trials will be started and the experiment
+	// is completed after the trials that are already running finish
+	MaxTrials *int32 `json:"maxTrials,omitempty"`
+	// ActiveDeadlineSeconds is the duration, relative to the creation of the experiment, after which no new trials will
+	// be started; trials that are already running are allowed to finish before the experiment is completed
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	// MaxCost is the maximum total value of the cost metric across all completed trials, once reached no new trials
+	// will be started and the experiment is completed after the trials that are already running finish
+	MaxCost *resource.Quantity `json:"maxCost,omitempty"`
+	// CostMetric is the name of the metric used to compute the total cost of the experiment, defaults to "cost"
+	CostMetric string `json:"costMetric,omitempty"`
+	// RetryPolicy controls if failed trials are retried with the same assignments before the failure is reported
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// Schedule is a cron expression (evaluated in UTC) used to periodically re-run the experiment; each run is a new
+	// experiment created from this one once the previous run has finished
+	Schedule string `json:"schedule,omitempty"`
+	// Promotion applies the patches of the best trial to the cluster once the experiment completes
+	Promotion *PromotionPolicy `json:"promotion,omitempty"`
 	// Optimization defines additional configuration for the optimization
 	Optimization []Optimization `json:"optimization,omitempty"`
 	// Parameters defines the search space for the experiment
@@ -253,9 +453,13 @@ type ExperimentSpec struct {
 	Constraints []Constraint `json:"constraints,omitempty"`
 	// Metrics defines the outcomes for the experiment
 	Metrics []Metric `json:"metrics"`
+	// Guardrails are bounds on the metrics that are checked while the trial run is still in progress
+	Guardrails []Guardrail `json:"guardrails,omitempty"`
 	// Patches is a sequence of templates written against the experiment parameters that will be used to put the
 	// cluster into the desired state
 	Patches []PatchTemplate `json:"patches,omitempty"`
+	// Endpoints are trial specific endpoints injected into the cluster state alongside the patches
+	Endpoints []TrialEndpoint `json:"endpoints,omitempty"`
 	// NamespaceSelector is used to locate existing namespaces for trials
 	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 	// NamespaceTemplate can be specified to create new namespaces for trials; if specified created namespaces must be
@@ -277,6 +481,11 @@ type ExperimentStatus struct {
 	ActiveTrials int32 `json:"activeTrials"`
 	// Conditions is the current state of the experiment
 	Conditions []ExperimentCondition `json:"conditions,omitempty"`
+	// ConsumedTrials is the number of trials counted against the trial budget, including trials that were deleted
+	ConsumedTrials int32 `json:"consumedTrials,omitempty"`
+	// ConsumedCost is the total cost of the completed trials counted against the cost budget, including trials that
+	// were deleted
+	ConsumedCost *resource.Quantity `json:"consumedCost,omitempty"`
 	// TODO Number of trials: Succeeded, Failed int32 (this would need to be fetch remotely, falling back to the in cluster count)
 }
 
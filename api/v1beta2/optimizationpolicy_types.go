@@ -0,0 +1,73 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ParameterBounds restricts the domain of the experiment parameters matching a name
+type ParameterBounds struct {
+	// Name is a regular expression matched against the full parameter name, an empty name matches all parameters
+	Name string `json:"name,omitempty"`
+	// Min is the smallest inclusive minimum value allowed for matching parameters
+	Min *int32 `json:"min,omitempty"`
+	// Max is the largest inclusive maximum value allowed for matching parameters
+	Max *int32 `json:"max,omitempty"`
+}
+
+// OptimizationPolicySpec defines the cluster-wide guardrails for experiments
+type OptimizationPolicySpec struct {
+	// ParameterBounds are applied to the numeric parameters of every experiment, parameter ranges outside the
+	// bounds are narrowed to fit until the experiment is created on the server and are violations afterwards
+	ParameterBounds []ParameterBounds `json:"parameterBounds,omitempty"`
+	// ForbiddenNamespaces is the list of namespaces experiments are not allowed to run in
+	ForbiddenNamespaces []string `json:"forbiddenNamespaces,omitempty"`
+	// RequiredLabels is the list of label keys every experiment must have
+	RequiredLabels []string `json:"requiredLabels,omitempty"`
+	// MaxExperimentBudget is the largest number of trials an experiment may request
+	MaxExperimentBudget *int32 `json:"maxExperimentBudget,omitempty"`
+	// MaxReplicas is the largest number of trials an experiment may run in parallel
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=optpol
+
+// OptimizationPolicy is the Schema for the cluster-wide optimization policy API
+type OptimizationPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object metadata
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Specification of the policy
+	Spec OptimizationPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OptimizationPolicyList contains a list of OptimizationPolicy
+type OptimizationPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard list metadata
+	metav1.ListMeta `json:"metadata,omitempty"`
+	// The list of optimization policies
+	Items []OptimizationPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OptimizationPolicy{}, &OptimizationPolicyList{})
+}
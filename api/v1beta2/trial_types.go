@@ -56,6 +56,19 @@ type TrialReadinessGate struct {
 	// FailureThreshold is number of times that any of the specified ready conditions may be "False";
 	// defaults to 3, minimum value is 1
 	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+	// Expectations are JSON path expressions that must evaluate to the expected values on the readiness target, e.g.
+	// to wait for the phase of a custom resource managed by an operator
+	Expectations []ReadinessExpectation `json:"expectations,omitempty"`
+	// SettleSeconds is the number of seconds the readiness target must remain ready before the trial is ready
+	SettleSeconds int32 `json:"settleSeconds,omitempty"`
+}
+
+// ReadinessExpectation is a JSON path expression that must evaluate to an expected value
+type ReadinessExpectation struct {
+	// JSONPath is the expression to evaluate against the readiness target, e.g. `{.status.phase}`
+	JSONPath string `json:"jsonPath"`
+	// Value is the expected result of the expression, multiple results are separated by a space
+	Value string `json:"value"`
 }
 
 // HelmValue represents a value in a Helm template
@@ -119,16 +132,66 @@ type SetupTask struct {
 	Env []corev1.EnvVar `json:"env,omitempty"`
 	// Labels to associate with the setup task
 	Labels map[string]string `json:"labels,omitempty"`
-	// The Helm chart reference to release as part of this task
+	// The Helm chart reference to release as part of this task, may be a Go Template evaluated against the trial
 	HelmChart string `json:"helmChart,omitempty"`
-	// The Helm chart version, empty means use the latest
+	// The Helm chart version, empty means use the latest; may be a Go Template evaluated against the trial
 	HelmChartVersion string `json:"helmChartVersion,omitempty"`
+	// Apply the re-rendered Helm chart over the existing resources of the release named by the task instead of
+	// creating (and later deleting) the resources, this allows Helm managed applications to be tuned using Helm values;
+	// the patched workloads must finish rolling out before the task completes and the original resources are put back
+	// when the trial is deleted (unless deletion is skipped)
+	HelmPatch bool `json:"helmPatch,omitempty"`
 	// The Helm values to set, ignored unless helmChart is also set
 	HelmValues []HelmValue `json:"helmValues,omitempty"`
 	// The Helm values, ignored unless helmChart is also set
 	HelmValuesFrom []HelmValuesFromSource `json:"helmValuesFrom,omitempty"`
-	// The Helm repository to fetch the chart from
+	// The Helm repository to fetch the chart from, may be a Go Template evaluated against the trial
 	HelmRepository string `json:"helmRepository,omitempty"`
+	// Rules are the additional permissions required by the task, they are granted to the setup service account along
+	// with the default setup rules in namespaces created from the namespace template
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty"`
+}
+
+// ArgoWorkflowSpec represents the configuration necessary to run the trial as an Argo Workflow instead of a job
+type ArgoWorkflowSpec struct {
+	// WorkflowTemplateRef is the reference to the workflow template used to run the trial
+	WorkflowTemplateRef ArgoWorkflowTemplateRef `json:"workflowTemplateRef"`
+	// Entrypoint overrides the name of the template to invoke when the workflow is run
+	Entrypoint string `json:"entrypoint,omitempty"`
+	// Parameters are the workflow arguments, if empty every assignment is passed using the parameter name
+	Parameters []WorkflowParameter `json:"parameters,omitempty"`
+}
+
+// ArgoWorkflowTemplateRef is a reference to an Argo WorkflowTemplate or ClusterWorkflowTemplate
+type ArgoWorkflowTemplateRef struct {
+	// The name of the workflow template
+	Name string `json:"name"`
+	// Flag indicating the reference is to a ClusterWorkflowTemplate
+	ClusterScope bool `json:"clusterScope,omitempty"`
+}
+
+// TektonPipelineRunSpec represents the configuration necessary to run the trial as a Tekton PipelineRun instead of a job
+type TektonPipelineRunSpec struct {
+	// PipelineRef is the reference to the pipeline used to run the trial
+	PipelineRef TektonPipelineRef `json:"pipelineRef"`
+	// ServiceAccountName is the name of the service account used to run the pipeline
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Parameters are the pipeline params, if empty every assignment is passed using the parameter name
+	Parameters []WorkflowParameter `json:"parameters,omitempty"`
+}
+
+// TektonPipelineRef is a reference to a Tekton Pipeline
+type TektonPipelineRef struct {
+	// The name of the pipeline
+	Name string `json:"name"`
+}
+
+// WorkflowParameter represents a parameter passed to a workflow
+type WorkflowParameter struct {
+	// The name of the workflow parameter
+	Name string `json:"name"`
+	// The value of the workflow parameter. Templates are evaluated using the same rules as patches
+	Value string `json:"value"`
 }
 
 // PatchOperation represents a patch used to prepare the cluster for a trial run, includes the evaluated
@@ -143,6 +206,18 @@ type PatchOperation struct {
 	// The number of remaining attempts to apply the patch, will be automatically set
 	// to zero if the patch is successfully applied
 	AttemptsRemaining int `json:"attemptsRemaining,omitempty"`
+	// Flag indicating the container resources in the patch should be applied to the running pods in place
+	InPlaceResize bool `json:"inPlaceResize,omitempty"`
+	// Flag indicating the original state of the target should be restored once the trial is finished or deleted
+	Restore bool `json:"restore,omitempty"`
+	// Flag indicating the running pods of the target were resized in place, the restore data is applied to the
+	// pods instead of the target
+	InPlaceResized bool `json:"inPlaceResized,omitempty"`
+	// A merge patch captured before the patch is applied that reverts the changed fields back to their original
+	// values, it is applied once the trial is finished or deleted
+	RestoreData []byte `json:"restoreData,omitempty"`
+	// The wave in which the patch is applied
+	Wave int32 `json:"wave,omitempty"`
 }
 
 // ReadinessCheck represents a check to determine when the patched application is "ready" and it is
@@ -156,6 +231,10 @@ type ReadinessCheck struct {
 	// ConditionTypes are the status conditions that must be "True"; in addition to conditions that appear in the
 	// status of the target object, additional special conditions starting with "stormforge.io/" can be tested
 	ConditionTypes []string `json:"conditionTypes,omitempty"`
+	// Expectations are JSON path expressions that must evaluate to the expected values on the target object
+	Expectations []ReadinessExpectation `json:"expectations,omitempty"`
+	// SettleSeconds is the number of seconds the target must remain ready before the check is successful
+	SettleSeconds int32 `json:"settleSeconds,omitempty"`
 	// InitialDelaySeconds is the approximate number of seconds after all of the patches have been applied to start
 	// evaluating this check
 	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
@@ -166,6 +245,8 @@ type ReadinessCheck struct {
 	AttemptsRemaining int32 `json:"attemptsRemaining,omitempty"`
 	// LastCheckTime is the timestamp of the last evaluation attempt
 	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+	// ReadySince is the timestamp at which the target was first observed as ready, used to evaluate the settle time
+	ReadySince *metav1.Time `json:"readySince,omitempty"`
 }
 
 // Value represents an observed metric value after a trial run has completed successfully. Value names
@@ -196,8 +277,12 @@ const (
 	TrialSetupDeleted TrialConditionType = "stormforge.io/trial-setup-deleted"
 	// TrialPatched is a condition that indicates patches have been applied for a trial
 	TrialPatched TrialConditionType = "stormforge.io/trial-patched"
+	// TrialRestored is a condition that indicates the patched objects have been restored to their original state
+	TrialRestored TrialConditionType = "stormforge.io/trial-restored"
 	// TrialReady is a condition that indicates the application is ready after patches were applied
 	TrialReady TrialConditionType = "stormforge.io/trial-ready"
+	// TrialRunning is a condition that indicates the trial run has started, it is false once the trial run finishes
+	TrialRunning TrialConditionType = "stormforge.io/trial-running"
 	// TrialObserved is a condition that indicates a trial has had metrics collected
 	TrialObserved TrialConditionType = "stormforge.io/trial-observed"
 )
@@ -218,6 +303,20 @@ type TrialCondition struct {
 	Message string `json:"message,omitempty"`
 }
 
+// TrialTimeouts defines the maximum amount of time for each phase of the trial, when a phase does not finish in time
+// the trial fails with a reason identifying the phase (e.g. "RunTimeout"). Phases without a timeout are only bounded
+// when the trial has an approximate runtime.
+type TrialTimeouts struct {
+	// Setup is the maximum amount of time for the setup tasks to finish
+	Setup *metav1.Duration `json:"setup,omitempty"`
+	// Readiness is the maximum amount of time for the patched objects to become ready
+	Readiness *metav1.Duration `json:"readiness,omitempty"`
+	// Run is the maximum amount of time for the trial run to finish
+	Run *metav1.Duration `json:"run,omitempty"`
+	// Metrics is the maximum amount of time for the metrics to be collected
+	Metrics *metav1.Duration `json:"metrics,omitempty"`
+}
+
 // TrialSpec defines the desired state of Trial
 type TrialSpec struct {
 	// ExperimentRef is the reference to the experiment that contains the definitions to use for this trial,
@@ -229,18 +328,27 @@ type TrialSpec struct {
 	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 	// JobTemplate is the job template used to create trial run jobs
 	JobTemplate *batchv1beta1.JobTemplateSpec `json:"jobTemplate,omitempty"`
+	// ArgoWorkflow is used to run the trial as an Argo Workflow instead of using the job template
+	ArgoWorkflow *ArgoWorkflowSpec `json:"argoWorkflow,omitempty"`
+	// TektonPipelineRun is used to run the trial as a Tekton PipelineRun instead of using the job template
+	TektonPipelineRun *TektonPipelineRunSpec `json:"tektonPipelineRun,omitempty"`
 	// InitialDelaySeconds is number of seconds to wait after a trial becomes ready before starting the trial run job
 	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
 	// The offset used to adjust the start time to account for spin up of the trial run
 	StartTimeOffset *metav1.Duration `json:"startTimeOffset,omitempty"`
-	// The approximate amount of time the trial run should execute (not inclusive of the start time offset)
+	// The approximate amount of time the trial run should execute (not inclusive of the start time offset), when set
+	// trials which take a multiple of this time to finish a phase are recovered or failed as stale
 	ApproximateRuntime *metav1.Duration `json:"approximateRuntime,omitempty"`
 	// The minimum number of seconds before an attempt should be made to clean up the trial, if unset or negative no attempt is made to clean up the trial
 	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
 	// The minimum number of seconds before an attempt should be made to clean up a failed trial, defaults to TTLSecondsAfterFinished
 	TTLSecondsAfterFailure *int32 `json:"ttlSecondsAfterFailure,omitempty"`
+	// Timeouts are the maximum amount of time each phase of the trial can take before the trial is failed
+	Timeouts *TrialTimeouts `json:"timeouts,omitempty"`
 	// The readiness gates to check before running the trial job
 	ReadinessGates []TrialReadinessGate `json:"readinessGates,omitempty"`
+	// LabelTemplates are additional trial labels whose values are templates evaluated using the trial assignments
+	LabelTemplates map[string]string `json:"labelTemplates,omitempty"`
 
 	// Values are the collected metrics at the end of the trial run
 	Values []Value `json:"values,omitempty"`
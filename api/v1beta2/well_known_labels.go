@@ -27,9 +27,32 @@ const (
 	AnnotationReportTrialURL = "stormforge.io/report-trial-url"
 	// AnnotationServerSync controls additional behavior around synchronizing the experiment remotely
 	AnnotationServerSync = "stormforge.io/server-sync"
+	// AnnotationPaused stops the creation of new trials when set to "true", active trials are allowed to finish
+	AnnotationPaused = "stormforge.io/paused"
+	// AnnotationNamespaceTemplate is the namespace and name of the experiment whose namespace template created a
+	// namespace, only namespaces with this annotation are deleted when their trials are cleaned up
+	AnnotationNamespaceTemplate = "stormforge.io/namespace-template"
+	// AnnotationLastScheduleTime is the RFC 3339 time of the most recent run of a scheduled experiment
+	AnnotationLastScheduleTime = "stormforge.io/last-schedule-time"
+	// AnnotationScheduleReplicas is the replica count of a scheduled experiment, recorded before the experiment
+	// finishes so it can be restored on subsequent runs
+	AnnotationScheduleReplicas = "stormforge.io/schedule-replicas"
+	// AnnotationScheduleRun is the run number of an experiment created by a schedule
+	AnnotationScheduleRun = "stormforge.io/schedule-run"
+	// AnnotationPreviousRun is the name of the previous run of an experiment created by a schedule
+	AnnotationPreviousRun = "stormforge.io/previous-run"
+
+	// AnnotationPromotionRestore is a JSON list of patch operations which restore the objects patched when the best
+	// trial of the experiment was promoted, it is recorded before the objects are patched and removed once the
+	// rollback window ends
+	AnnotationPromotionRestore = "stormforge.io/promotion-restore"
+	// AnnotationRollback requests that a promoted configuration be rolled back when set to "true"
+	AnnotationRollback = "stormforge.io/rollback"
 
 	// LabelExperiment is the name of the experiment associated with an object
 	LabelExperiment = "stormforge.io/experiment"
+	// LabelScheduledExperiment is the name of the scheduled experiment that created a run
+	LabelScheduledExperiment = "stormforge.io/scheduled-experiment"
 )
 
 // Trial labels and annotations
@@ -38,6 +61,29 @@ const (
 	// AnnotationInitializer is a comma-delimited list of initializing processes. Similar to a "finalizer", the trial
 	// will not start executing until the initializer is empty.
 	AnnotationInitializer = "stormforge.io/initializer"
+	// AnnotationRecoveryAttempts is the number of times the controller has attempted to recover a stale trial
+	AnnotationRecoveryAttempts = "stormforge.io/recovery-attempts"
+	// AnnotationLastRecoveryTime is the RFC 3339 time of the most recent attempt to recover a stale trial
+	AnnotationLastRecoveryTime = "stormforge.io/last-recovery-time"
+	// AnnotationLastWaveTime is the RFC 3339 time at which the most recent wave of patches finished applying, the
+	// initial delay of readiness checks gating the next wave is measured from this time
+	AnnotationLastWaveTime = "stormforge.io/last-wave-time"
+	// AnnotationInfrastructureRetries is the number of times a trial's assignments have been retried following an
+	// infrastructure failure
+	AnnotationInfrastructureRetries = "stormforge.io/infrastructure-retries"
+	// AnnotationAssignmentRetries is the number of times a trial's assignments have been retried following an
+	// assignment failure
+	AnnotationAssignmentRetries = "stormforge.io/assignment-retries"
+	// AnnotationBudgetRecorded indicates how much of the trial was recorded in the consumed budget of the experiment,
+	// either "trial" once the trial is counted or "cost" once the cost of the completed trial is also included
+	AnnotationBudgetRecorded = "stormforge.io/budget-recorded"
+	// AnnotationPushedValues is a JSON object of the metric values pushed by the trial run
+	AnnotationPushedValues = "stormforge.io/pushed-values"
+	// AnnotationResourceUsage is a JSON summary of the resource usage sampled from the patched pods during the trial run
+	AnnotationResourceUsage = "stormforge.io/resource-usage"
+	// AnnotationRestartTrial is the name of the trial which restarted a workload, it is added to the pod template to
+	// force a rollout of the workload
+	AnnotationRestartTrial = "stormforge.io/restart-trial"
 
 	// LabelTrial contains the name of the trial associated with an object
 	LabelTrial = "stormforge.io/trial"
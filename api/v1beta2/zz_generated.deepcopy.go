@@ -30,6 +30,42 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArgoWorkflowSpec) DeepCopyInto(out *ArgoWorkflowSpec) {
+	*out = *in
+	out.WorkflowTemplateRef = in.WorkflowTemplateRef
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]WorkflowParameter, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArgoWorkflowSpec.
+func (in *ArgoWorkflowSpec) DeepCopy() *ArgoWorkflowSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ArgoWorkflowSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArgoWorkflowTemplateRef) DeepCopyInto(out *ArgoWorkflowTemplateRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArgoWorkflowTemplateRef.
+func (in *ArgoWorkflowTemplateRef) DeepCopy() *ArgoWorkflowTemplateRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ArgoWorkflowTemplateRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Assignment) DeepCopyInto(out *Assignment) {
 	*out = *in
@@ -171,6 +207,35 @@ func (in *ExperimentSpec) DeepCopyInto(out *ExperimentSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Deadline != nil {
+		in, out := &in.Deadline, &out.Deadline
+		*out = (*in).DeepCopy()
+	}
+	if in.MaxTrials != nil {
+		in, out := &in.MaxTrials, &out.MaxTrials
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxCost != nil {
+		in, out := &in.MaxCost, &out.MaxCost
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Promotion != nil {
+		in, out := &in.Promotion, &out.Promotion
+		*out = new(PromotionPolicy)
+		**out = **in
+	}
 	if in.Optimization != nil {
 		in, out := &in.Optimization, &out.Optimization
 		*out = make([]Optimization, len(*in))
@@ -197,6 +262,13 @@ func (in *ExperimentSpec) DeepCopyInto(out *ExperimentSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Guardrails != nil {
+		in, out := &in.Guardrails, &out.Guardrails
+		*out = make([]Guardrail, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Patches != nil {
 		in, out := &in.Patches, &out.Patches
 		*out = make([]PatchTemplate, len(*in))
@@ -204,6 +276,11 @@ func (in *ExperimentSpec) DeepCopyInto(out *ExperimentSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]TrialEndpoint, len(*in))
+		copy(*out, *in)
+	}
 	if in.NamespaceSelector != nil {
 		in, out := &in.NamespaceSelector, &out.NamespaceSelector
 		*out = new(v1.LabelSelector)
@@ -242,6 +319,11 @@ func (in *ExperimentStatus) DeepCopyInto(out *ExperimentStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ConsumedCost != nil {
+		in, out := &in.ConsumedCost, &out.ConsumedCost
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentStatus.
@@ -254,6 +336,46 @@ func (in *ExperimentStatus) DeepCopy() *ExperimentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailureRetry) DeepCopyInto(out *FailureRetry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailureRetry.
+func (in *FailureRetry) DeepCopy() *FailureRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(FailureRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Guardrail) DeepCopyInto(out *Guardrail) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Guardrail.
+func (in *Guardrail) DeepCopy() *Guardrail {
+	if in == nil {
+		return nil
+	}
+	out := new(Guardrail)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmValue) DeepCopyInto(out *HelmValue) {
 	*out = *in
@@ -315,6 +437,21 @@ func (in *HelmValuesFromSource) DeepCopy() *HelmValuesFromSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JSONPatchOperation) DeepCopyInto(out *JSONPatchOperation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JSONPatchOperation.
+func (in *JSONPatchOperation) DeepCopy() *JSONPatchOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(JSONPatchOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Metric) DeepCopyInto(out *Metric) {
 	*out = *in
@@ -333,6 +470,53 @@ func (in *Metric) DeepCopyInto(out *Metric) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Plausible != nil {
+		in, out := &in.Plausible, &out.Plausible
+		*out = new(MetricRange)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Quantile != nil {
+		in, out := &in.Quantile, &out.Quantile
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.BearerTokenSecretRef != nil {
+		in, out := &in.BearerTokenSecretRef, &out.BearerTokenSecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(MetricRetry)
+		**out = **in
+	}
+	if in.Delay != nil {
+		in, out := &in.Delay, &out.Delay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(MetricWindow)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Target != nil {
 		in, out := &in.Target, &out.Target
 		*out = new(ResourceTarget)
@@ -350,11 +534,86 @@ func (in *Metric) DeepCopy() *Metric {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricRange) DeepCopyInto(out *MetricRange) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricRange.
+func (in *MetricRange) DeepCopy() *MetricRange {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricRetry) DeepCopyInto(out *MetricRetry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricRetry.
+func (in *MetricRetry) DeepCopy() *MetricRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricWindow) DeepCopyInto(out *MetricWindow) {
+	*out = *in
+	if in.Offset != nil {
+		in, out := &in.Offset, &out.Offset
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricWindow.
+func (in *MetricWindow) DeepCopy() *MetricWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespaceTemplateSpec) DeepCopyInto(out *NamespaceTemplateSpec) {
 	*out = *in
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	if in.ResourceQuota != nil {
+		in, out := &in.ResourceQuota, &out.ResourceQuota
+		*out = new(corev1.ResourceQuotaSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LimitRange != nil {
+		in, out := &in.LimitRange, &out.LimitRange
+		*out = new(corev1.LimitRangeSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceTemplateSpec.
@@ -397,6 +656,106 @@ func (in *OrderConstraint) DeepCopy() *OrderConstraint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OptimizationPolicy) DeepCopyInto(out *OptimizationPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OptimizationPolicy.
+func (in *OptimizationPolicy) DeepCopy() *OptimizationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(OptimizationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OptimizationPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OptimizationPolicyList) DeepCopyInto(out *OptimizationPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OptimizationPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OptimizationPolicyList.
+func (in *OptimizationPolicyList) DeepCopy() *OptimizationPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(OptimizationPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OptimizationPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OptimizationPolicySpec) DeepCopyInto(out *OptimizationPolicySpec) {
+	*out = *in
+	if in.ParameterBounds != nil {
+		in, out := &in.ParameterBounds, &out.ParameterBounds
+		*out = make([]ParameterBounds, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ForbiddenNamespaces != nil {
+		in, out := &in.ForbiddenNamespaces, &out.ForbiddenNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequiredLabels != nil {
+		in, out := &in.RequiredLabels, &out.RequiredLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxExperimentBudget != nil {
+		in, out := &in.MaxExperimentBudget, &out.MaxExperimentBudget
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OptimizationPolicySpec.
+func (in *OptimizationPolicySpec) DeepCopy() *OptimizationPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OptimizationPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Parameter) DeepCopyInto(out *Parameter) {
 	*out = *in
@@ -422,6 +781,31 @@ func (in *Parameter) DeepCopy() *Parameter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterBounds) DeepCopyInto(out *ParameterBounds) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterBounds.
+func (in *ParameterBounds) DeepCopy() *ParameterBounds {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterBounds)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ParameterSelector) DeepCopyInto(out *ParameterSelector) {
 	*out = *in
@@ -446,6 +830,11 @@ func (in *PatchOperation) DeepCopyInto(out *PatchOperation) {
 		*out = make([]byte, len(*in))
 		copy(*out, *in)
 	}
+	if in.RestoreData != nil {
+		in, out := &in.RestoreData, &out.RestoreData
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchOperation.
@@ -486,6 +875,16 @@ func (in *PatchTemplate) DeepCopyInto(out *PatchTemplate) {
 		*out = make([]PatchReadinessGate, len(*in))
 		copy(*out, *in)
 	}
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]JSONPatchOperation, len(*in))
+		copy(*out, *in)
+	}
+	if in.RestartRefs != nil {
+		in, out := &in.RestartRefs, &out.RestartRefs
+		*out = make([]corev1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchTemplate.
@@ -498,6 +897,21 @@ func (in *PatchTemplate) DeepCopy() *PatchTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionPolicy) DeepCopyInto(out *PromotionPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionPolicy.
+func (in *PromotionPolicy) DeepCopy() *PromotionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
 	*out = *in
@@ -512,10 +926,19 @@ func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Expectations != nil {
+		in, out := &in.Expectations, &out.Expectations
+		*out = make([]ReadinessExpectation, len(*in))
+		copy(*out, *in)
+	}
 	if in.LastCheckTime != nil {
 		in, out := &in.LastCheckTime, &out.LastCheckTime
 		*out = (*in).DeepCopy()
 	}
+	if in.ReadySince != nil {
+		in, out := &in.ReadySince, &out.ReadySince
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessCheck.
@@ -528,6 +951,21 @@ func (in *ReadinessCheck) DeepCopy() *ReadinessCheck {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessExpectation) DeepCopyInto(out *ReadinessExpectation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessExpectation.
+func (in *ReadinessExpectation) DeepCopy() *ReadinessExpectation {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessExpectation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceTarget) DeepCopyInto(out *ResourceTarget) {
 	*out = *in
@@ -548,6 +986,31 @@ func (in *ResourceTarget) DeepCopy() *ResourceTarget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.Infrastructure != nil {
+		in, out := &in.Infrastructure, &out.Infrastructure
+		*out = new(FailureRetry)
+		**out = **in
+	}
+	if in.Assignment != nil {
+		in, out := &in.Assignment, &out.Assignment
+		*out = new(FailureRetry)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SetupTask) DeepCopyInto(out *SetupTask) {
 	*out = *in
@@ -596,6 +1059,13 @@ func (in *SetupTask) DeepCopyInto(out *SetupTask) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]rbacv1.PolicyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetupTask.
@@ -647,6 +1117,42 @@ func (in *SumConstraintParameter) DeepCopy() *SumConstraintParameter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TektonPipelineRef) DeepCopyInto(out *TektonPipelineRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TektonPipelineRef.
+func (in *TektonPipelineRef) DeepCopy() *TektonPipelineRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TektonPipelineRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TektonPipelineRunSpec) DeepCopyInto(out *TektonPipelineRunSpec) {
+	*out = *in
+	out.PipelineRef = in.PipelineRef
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]WorkflowParameter, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TektonPipelineRunSpec.
+func (in *TektonPipelineRunSpec) DeepCopy() *TektonPipelineRunSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TektonPipelineRunSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Trial) DeepCopyInto(out *Trial) {
 	*out = *in
@@ -691,6 +1197,22 @@ func (in *TrialCondition) DeepCopy() *TrialCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrialEndpoint) DeepCopyInto(out *TrialEndpoint) {
+	*out = *in
+	out.TargetRef = in.TargetRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrialEndpoint.
+func (in *TrialEndpoint) DeepCopy() *TrialEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(TrialEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrialList) DeepCopyInto(out *TrialList) {
 	*out = *in
@@ -736,6 +1258,11 @@ func (in *TrialReadinessGate) DeepCopyInto(out *TrialReadinessGate) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Expectations != nil {
+		in, out := &in.Expectations, &out.Expectations
+		*out = make([]ReadinessExpectation, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrialReadinessGate.
@@ -771,6 +1298,16 @@ func (in *TrialSpec) DeepCopyInto(out *TrialSpec) {
 		*out = new(v1beta1.JobTemplateSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ArgoWorkflow != nil {
+		in, out := &in.ArgoWorkflow, &out.ArgoWorkflow
+		*out = new(ArgoWorkflowSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TektonPipelineRun != nil {
+		in, out := &in.TektonPipelineRun, &out.TektonPipelineRun
+		*out = new(TektonPipelineRunSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.StartTimeOffset != nil {
 		in, out := &in.StartTimeOffset, &out.StartTimeOffset
 		*out = new(v1.Duration)
@@ -791,6 +1328,11 @@ func (in *TrialSpec) DeepCopyInto(out *TrialSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(TrialTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ReadinessGates != nil {
 		in, out := &in.ReadinessGates, &out.ReadinessGates
 		*out = make([]TrialReadinessGate, len(*in))
@@ -798,6 +1340,13 @@ func (in *TrialSpec) DeepCopyInto(out *TrialSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LabelTemplates != nil {
+		in, out := &in.LabelTemplates, &out.LabelTemplates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Values != nil {
 		in, out := &in.Values, &out.Values
 		*out = make([]Value, len(*in))
@@ -897,6 +1446,41 @@ func (in *TrialTemplateSpec) DeepCopy() *TrialTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrialTimeouts) DeepCopyInto(out *TrialTimeouts) {
+	*out = *in
+	if in.Setup != nil {
+		in, out := &in.Setup, &out.Setup
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Run != nil {
+		in, out := &in.Run, &out.Run
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrialTimeouts.
+func (in *TrialTimeouts) DeepCopy() *TrialTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(TrialTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Value) DeepCopyInto(out *Value) {
 	*out = *in
@@ -911,3 +1495,18 @@ func (in *Value) DeepCopy() *Value {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowParameter) DeepCopyInto(out *WorkflowParameter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowParameter.
+func (in *WorkflowParameter) DeepCopy() *WorkflowParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowParameter)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -251,8 +251,16 @@ func ConfigGlobals(cfg *config.OptimizeConfig, cmd *cobra.Command) {
 	_ = root.MarkFlagFilename("stormforgeconfig")
 	_ = root.MarkFlagFilename("kubeconfig")
 
+	// Progress reporting is shared by all commands
+	addLogFlags(root)
+
 	// Set the persistent pre-run on the root, individual commands can bypass this by supplying their own persistent pre-run
-	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error { return cfg.Load() }
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := validateLogFlags(); err != nil {
+			return err
+		}
+		return cfg.Load()
+	}
 }
 
 // WithContextE wraps a function that accepts a context in one that accepts a command and argument slice
@@ -347,3 +355,28 @@ func MapErrors(cmd *cobra.Command, f func(error) error) {
 		MapErrors(c, f)
 	}
 }
+
+// AddMigrationShim registers a legacy spelling as an alias of the command at the supplied path, a migration hint is
+// printed when the command is run using the legacy spelling (sub-commands of the alias run without a hint).
+func AddMigrationShim(root *cobra.Command, legacy string, path ...string) {
+	cmd, _, err := root.Find(path)
+	if err != nil || cmd == root {
+		panic(fmt.Sprintf("migration shim %q: unknown command %q", legacy, strings.Join(path, " ")))
+	}
+	cmd.Aliases = append(cmd.Aliases, legacy)
+
+	// Cobra ignores PreRun when PreRunE is set, so both need to be preserved
+	preRun, preRunE := cmd.PreRun, cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if cmd.CalledAs() == legacy {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Command %q has been renamed, use '%s' instead\n", legacy, cmd.CommandPath())
+		}
+		if preRunE != nil {
+			return preRunE(cmd, args)
+		}
+		if preRun != nil {
+			preRun(cmd, args)
+		}
+		return nil
+	}
+}
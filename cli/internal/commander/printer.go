@@ -76,6 +76,9 @@ const (
 	PrinterStreamList = "streamList"
 )
 
+// customColumnsPrefix is the output format prefix used to specify custom columns
+const customColumnsPrefix = "custom-columns="
+
 // ResourcePrinter formats an object to a byte stream
 type ResourcePrinter interface {
 	// PrintObj formats the specified object to the specified writer
@@ -217,8 +220,12 @@ func newPrintFlags(meta TableMeta, config map[string]string, additionalFormats m
 func (f *printFlags) addFlags(cmd *cobra.Command) {
 	// We only need an output flag if there is a choice
 	if len(f.allowedFormats) > 1 {
+		values := append([]string{}, f.allowedFormats...)
+		if f.isAllowed("") {
+			values = append(values, customColumnsPrefix+"...")
+		}
 		cmd.Flags().StringVarP(&f.outputFormat, "output", "o", f.outputFormat, "output `format`")
-		SetFlagValues(cmd, "output", f.allowedFormats...)
+		SetFlagValues(cmd, "output", values...)
 	}
 
 	// These flags only work with formats that require metadata, make sure we have at least one
@@ -231,9 +238,34 @@ func (f *printFlags) addFlags(cmd *cobra.Command) {
 	}
 }
 
+// isAllowed checks to see if the supplied format is allowed
+func (f *printFlags) isAllowed(outputFormat string) bool {
+	for _, allowedFormat := range f.allowedFormats {
+		if outputFormat == allowedFormat {
+			return true
+		}
+	}
+	return false
+}
+
 // toPrinter generates a new printer
 func (f *printFlags) toPrinter(printer *ResourcePrinter) error {
 	outputFormat := strings.ToLower(f.outputFormat)
+
+	// Custom columns use the table format with a user supplied list of columns
+	if strings.HasPrefix(outputFormat, customColumnsPrefix) && f.isAllowed("") {
+		p := &tablePrinter{
+			meta:       f.meta,
+			headers:    !f.noHeader,
+			showLabels: f.showLabels,
+		}
+		if err := p.parseCustomColumns(f.outputFormat[len(customColumnsPrefix):]); err != nil {
+			return err
+		}
+		*printer = p
+		return nil
+	}
+
 	for _, allowedFormat := range f.allowedFormats {
 		if outputFormat == allowedFormat {
 			switch outputFormat {
@@ -320,6 +352,29 @@ type tablePrinter struct {
 	showLabels bool
 	// outputFormat is the format this printer is generating (used to alter defaults)
 	outputFormat string
+	// headerNames overrides the header for each column, blank values use the default header
+	headerNames []string
+}
+
+// parseCustomColumns configures the columns and headers from a custom columns specification, the specification is
+// a comma separated list of columns with optional headers (e.g. "NAME:name,CPU:parameter_cpu")
+func (p *tablePrinter) parseCustomColumns(spec string) error {
+	for _, c := range strings.FieldsFunc(spec, printFlagsFieldSep) {
+		header, column := "", strings.TrimSpace(c)
+		if pos := strings.Index(column, ":"); pos >= 0 {
+			header, column = column[:pos], column[pos+1:]
+		}
+		if column == "" {
+			return fmt.Errorf("invalid custom column %q, expected HEADER:COLUMN", c)
+		}
+		p.columns = append(p.columns, column)
+		p.headerNames = append(p.headerNames, header)
+	}
+
+	if len(p.columns) == 0 {
+		return fmt.Errorf("custom columns format specified but no columns given")
+	}
+	return nil
 }
 
 // PrintObj generates the tabular data
@@ -349,6 +404,9 @@ func (p *tablePrinter) PrintObj(obj interface{}, w io.Writer) error {
 	if p.headers {
 		for i := range columns {
 			buf[i] = p.meta.Header(p.outputFormat, columns[i])
+			if i < len(p.headerNames) && p.headerNames[i] != "" {
+				buf[i] = p.headerNames[i]
+			}
 		}
 		if err = p.printRow(tw, buf); err != nil {
 			return err
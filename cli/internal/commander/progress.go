@@ -0,0 +1,212 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commander
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// LogFormatText produces human readable progress messages
+	LogFormatText = "text"
+	// LogFormatJSON produces one JSON object per progress message
+	LogFormatJSON = "json"
+)
+
+// logOptions holds the global (persistent flag) settings for progress reporting.
+var logOptions = struct {
+	Quiet  bool
+	Format string
+}{
+	Format: LogFormatText,
+}
+
+// addLogFlags registers the global progress and logging flags on the root command.
+func addLogFlags(root *cobra.Command) {
+	root.PersistentFlags().BoolVarP(&logOptions.Quiet, "quiet", "q", logOptions.Quiet, "suppress progress messages")
+	root.PersistentFlags().StringVar(&logOptions.Format, "log-format", logOptions.Format, "progress and log message `format`; one of: text|json")
+}
+
+// validateLogFlags checks the global logging flags.
+func validateLogFlags() error {
+	switch logOptions.Format {
+	case LogFormatText, LogFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported log format %q, must be one of: %s|%s", logOptions.Format, LogFormatText, LogFormatJSON)
+	}
+}
+
+// spinnerFrames are the frames used to animate progress on an interactive terminal.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Progress reports the status of a long running operation on the error stream. On an
+// interactive terminal an animated spinner is used, otherwise each status change is
+// written as a single line (or JSON object) suitable for CI logs. Command results
+// (including success messages) are still written to the output stream.
+type Progress struct {
+	w           io.Writer
+	quiet       bool
+	json        bool
+	interactive bool
+
+	mu      sync.Mutex
+	message string
+	start   time.Time
+	done    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// Progress creates a new progress reporter using the error stream. The reporter does
+// nothing until it is started.
+func (s *IOStreams) Progress() *Progress {
+	p := &Progress{
+		w:     s.ErrOut,
+		quiet: logOptions.Quiet,
+		json:  logOptions.Format == LogFormatJSON,
+	}
+	if p.w == nil {
+		p.w = io.Discard
+	}
+	if f, ok := p.w.(*os.File); ok && !p.json {
+		p.interactive = isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+	}
+	return p
+}
+
+// Start begins reporting progress with the supplied message.
+func (p *Progress) Start(message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.message = message
+	p.start = time.Now()
+	if p.quiet {
+		return
+	}
+
+	if !p.interactive {
+		p.emit("start", message, nil)
+		return
+	}
+
+	p.done = make(chan struct{})
+	p.stopped.Add(1)
+	go p.spin(p.done)
+}
+
+// Update changes the message being reported for the current operation.
+func (p *Progress) Update(message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.message = message
+	if !p.quiet && !p.interactive {
+		p.emit("update", message, nil)
+	}
+}
+
+// Stop ends the current operation, the supplied error (if any) is included in the
+// final status message.
+func (p *Progress) Stop(err error) {
+	// The spinner needs the lock to finish, so it must be released while waiting
+	p.mu.Lock()
+	done := p.done
+	p.done = nil
+	p.mu.Unlock()
+
+	if done != nil {
+		close(done)
+		p.stopped.Wait()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Errors are returned by the command, only the interactive line needs clearing
+	if p.quiet {
+		return
+	}
+
+	if p.interactive {
+		_, _ = fmt.Fprint(p.w, "\r\033[K")
+		if err == nil {
+			_, _ = fmt.Fprintf(p.w, "%s (%s)\n", p.message, time.Since(p.start).Round(time.Millisecond))
+		}
+		return
+	}
+
+	p.emit("stop", p.message, err)
+}
+
+func (p *Progress) spin(done <-chan struct{}) {
+	defer p.stopped.Done()
+
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+
+	for i := 0; ; i++ {
+		p.mu.Lock()
+		_, _ = fmt.Fprintf(p.w, "\r\033[K%s %s", spinnerFrames[i%len(spinnerFrames)], p.message)
+		p.mu.Unlock()
+
+		select {
+		case <-done:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// emit writes a single (non-interactive) progress line.
+func (p *Progress) emit(event, message string, err error) {
+	if !p.json {
+		switch {
+		case err != nil:
+			_, _ = fmt.Fprintf(p.w, "%s: %v\n", message, err)
+		case event == "stop":
+			_, _ = fmt.Fprintf(p.w, "%s: done (%s)\n", message, time.Since(p.start).Round(time.Millisecond))
+		default:
+			_, _ = fmt.Fprintln(p.w, message)
+		}
+		return
+	}
+
+	entry := map[string]interface{}{
+		"time":  time.Now().UTC().Format(time.RFC3339),
+		"level": "info",
+		"event": event,
+		"msg":   message,
+	}
+	if event == "stop" {
+		entry["elapsed"] = time.Since(p.start).Seconds()
+	}
+	if err != nil {
+		entry["level"] = "error"
+		entry["error"] = err.Error()
+	}
+
+	_ = json.NewEncoder(p.w).Encode(entry)
+}
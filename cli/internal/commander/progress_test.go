@@ -0,0 +1,154 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commander
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	cases := []struct {
+		desc     string
+		quiet    bool
+		format   string
+		err      error
+		expected []string
+	}{
+		{
+			desc:   "text",
+			format: LogFormatText,
+			expected: []string{
+				"Starting",
+				"Updating",
+				"Updating: done",
+			},
+		},
+		{
+			desc:   "text error",
+			format: LogFormatText,
+			err:    fmt.Errorf("failed"),
+			expected: []string{
+				"Starting",
+				"Updating",
+				"Updating: failed",
+			},
+		},
+		{
+			desc:   "quiet",
+			quiet:  true,
+			format: LogFormatText,
+		},
+		{
+			desc:   "quiet error",
+			quiet:  true,
+			format: LogFormatText,
+			err:    fmt.Errorf("failed"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			defer withLogOptions(c.quiet, c.format)()
+
+			var buf bytes.Buffer
+			p := (&IOStreams{ErrOut: &buf}).Progress()
+			p.Start("Starting")
+			p.Update("Updating")
+			p.Stop(c.err)
+
+			var actual []string
+			for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+				if line != "" {
+					actual = append(actual, line)
+				}
+			}
+			require.Len(t, actual, len(c.expected))
+			for i := range c.expected {
+				assert.True(t, strings.HasPrefix(actual[i], c.expected[i]), "expected %q to start with %q", actual[i], c.expected[i])
+			}
+		})
+	}
+}
+
+func TestProgress_JSON(t *testing.T) {
+	defer withLogOptions(false, LogFormatJSON)()
+
+	var buf bytes.Buffer
+	p := (&IOStreams{ErrOut: &buf}).Progress()
+	p.Start("Starting")
+	p.Update("Updating")
+	p.Stop(fmt.Errorf("failed"))
+
+	var entries []map[string]interface{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		entry := map[string]interface{}{}
+		require.NoError(t, dec.Decode(&entry))
+		entries = append(entries, entry)
+	}
+
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, "start", entries[0]["event"])
+		assert.Equal(t, "Starting", entries[0]["msg"])
+		assert.Equal(t, "update", entries[1]["event"])
+		assert.Equal(t, "Updating", entries[1]["msg"])
+		assert.Equal(t, "stop", entries[2]["event"])
+		assert.Equal(t, "error", entries[2]["level"])
+		assert.Equal(t, "failed", entries[2]["error"])
+		assert.Contains(t, entries[2], "elapsed")
+	}
+}
+
+func TestProgress_Interactive(t *testing.T) {
+	defer withLogOptions(false, LogFormatText)()
+
+	var buf bytes.Buffer
+	p := (&IOStreams{ErrOut: &buf}).Progress()
+	p.interactive = true
+
+	// Stopping concurrently with the spinner must not race (run with -race)
+	p.Start("Starting")
+	p.Update("Updating")
+	time.Sleep(150 * time.Millisecond)
+	p.Stop(nil)
+	p.Stop(nil)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.True(t, strings.HasPrefix(lines[len(lines)-1], "\r\033[KUpdating ("), "unexpected final line %q", lines[len(lines)-1])
+}
+
+func TestValidateLogFlags(t *testing.T) {
+	defer withLogOptions(false, "xml")()
+	assert.Error(t, validateLogFlags())
+
+	logOptions.Format = LogFormatJSON
+	assert.NoError(t, validateLogFlags())
+}
+
+// withLogOptions overrides the global log options, the returned function restores the original values.
+func withLogOptions(quiet bool, format string) func() {
+	original := logOptions
+	logOptions.Quiet, logOptions.Format = quiet, format
+	return func() { logOptions = original }
+}
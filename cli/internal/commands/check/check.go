@@ -36,7 +36,7 @@ func NewCommand(o *Options) *cobra.Command {
 	}
 
 	cmd.AddCommand(NewConfigCommand(&ConfigOptions{Config: o.Config}))
-	cmd.AddCommand(NewExperimentCommand(&ExperimentOptions{}))
+	cmd.AddCommand(NewExperimentCommand(&ExperimentOptions{Config: o.Config}))
 	cmd.AddCommand(NewVersionCommand(&VersionOptions{}))
 	cmd.AddCommand(NewControllerCommand(&ControllerOptions{Config: o.Config}))
 
@@ -128,8 +128,12 @@ func (o *ControllerOptions) CheckController(ctx context.Context) error {
 		return err
 	}
 
+	progress := o.Progress()
+	progress.Start("Waiting for the controller to be ready")
 	kubewait.Stdout = io.Discard
-	if err := kubewait.Run(); err != nil {
+	err = kubewait.Run()
+	progress.Stop(err)
+	if err != nil {
 		return fmt.Errorf("could not wait for controller pods: %w", err)
 	}
 
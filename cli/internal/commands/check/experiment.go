@@ -17,7 +17,10 @@ limitations under the License.
 package check
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"regexp"
@@ -31,11 +34,15 @@ import (
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
 	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"github.com/thestormforge/optimize-controller/v2/internal/metric"
 	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
 	"github.com/thestormforge/optimize-controller/v2/internal/validation"
+	"github.com/thestormforge/optimize-go/pkg/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -52,32 +59,52 @@ const (
 type ExperimentOptions struct {
 	// IOStreams are used to access the standard process streams
 	commander.IOStreams
-
-	Filename string
+	// Config is the Optimize Configuration used for server side validation
+	Config *config.OptimizeConfig
+
+	ExperimentName string
+	Filename       string
+	Manifests      []string
+	ServerDryRun   bool
+	QueryDryRun    bool
 }
 
 // NewExperimentCommand creates a new command for checking an experiment manifest
 func NewExperimentCommand(o *ExperimentOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "experiment",
+		Use:     "experiment [NAME]",
 		Short:   "Check an experiment",
-		Long:    "Check an experiment manifest",
+		Long:    "Check an experiment manifest or an experiment already in the cluster",
 		Aliases: []string{"exp"},
+		Args:    cobra.MaximumNArgs(1),
 
-		PreRun: commander.StreamsPreRun(&o.IOStreams),
-		RunE:   commander.WithContextE(o.checkExperiment),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			commander.SetStreams(&o.IOStreams, cmd)
+			if len(args) > 0 {
+				o.ExperimentName = args[0]
+			}
+			if (o.ExperimentName == "") == (o.Filename == "") {
+				return fmt.Errorf("exactly one of an experiment name or --filename is required")
+			}
+			return nil
+		},
+		RunE: commander.WithContextE(o.checkExperiment),
 	}
 
 	cmd.Flags().StringVarP(&o.Filename, "filename", "f", "", "`file` that contains the experiment to check")
 
+	cmd.Flags().StringSliceVar(&o.Manifests, "manifests", nil, "manifest `files` to simulate the experiment patches against")
+	cmd.Flags().BoolVar(&o.ServerDryRun, "server-dry-run", false, "validate the patched manifests using a server side dry run")
+	cmd.Flags().BoolVar(&o.QueryDryRun, "query-dry-run", false, "execute Prometheus metric queries against their configured server")
+
 	_ = cmd.MarkFlagFilename("filename", "yml", "yaml")
-	_ = cmd.MarkFlagRequired("filename")
+	_ = cmd.MarkFlagFilename("manifests", "yml", "yaml")
 
 	return cmd
 }
 
 func (o *ExperimentOptions) checkExperiment(ctx context.Context) error {
-	r, err := o.IOStreams.OpenFile(o.Filename)
+	r, err := o.openExperiment(ctx)
 	if err != nil {
 		return err
 	}
@@ -90,7 +117,11 @@ func (o *ExperimentOptions) checkExperiment(ctx context.Context) error {
 	}
 
 	// Create a new linter for traversing the experiment and reporting errors
-	l := &linter{}
+	l := &linter{
+		trial:                fakeTrial(exp),
+		hasNamespaceTemplate: exp.Spec.NamespaceTemplate != nil,
+		queryDryRun:          o.QueryDryRun,
+	}
 
 	// Set the recommended budget to 20x the number of parameters up to 400 trials
 	l.minExperimentBudget = 20 * len(exp.Spec.Parameters)
@@ -119,6 +150,13 @@ func (o *ExperimentOptions) checkExperiment(ctx context.Context) error {
 	// Use the linter to inspect the experiment
 	experiment.Walk(ctx, l, exp)
 
+	// Simulate the patches against the supplied manifests
+	if len(o.Manifests) > 0 {
+		if err := o.checkPatches(ctx, l.logger, exp); err != nil {
+			return err
+		}
+	}
+
 	// TODO Ideally we would just return an error here, but it would look strange alongside the other output
 	if hasError {
 		os.Exit(1)
@@ -127,11 +165,50 @@ func (o *ExperimentOptions) checkExperiment(ctx context.Context) error {
 	return nil
 }
 
+// openExperiment returns a reader for the experiment, either from a file or from the cluster.
+func (o *ExperimentOptions) openExperiment(ctx context.Context) (io.ReadCloser, error) {
+	if o.ExperimentName == "" {
+		return o.IOStreams.OpenFile(o.Filename)
+	}
+
+	get, err := o.Config.Kubectl(ctx, "get", "experiment", o.ExperimentName, "--output", "yaml")
+	if err != nil {
+		return nil, err
+	}
+	get.Stderr = o.ErrOut
+
+	out, err := get.Output()
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(out)), nil
+}
+
+// fakeTrial returns a trial used to render the experiment templates.
+func fakeTrial(exp *optimizev1beta2.Experiment) *optimizev1beta2.Trial {
+	t := simulatedTrials(exp)[0].trial
+	if t.Name == "" {
+		t.Name = exp.Name + "-000"
+	}
+
+	completionTime := metav1.Now()
+	startTime := metav1.NewTime(completionTime.Add(-trial.ExpectedDuration(t)))
+	t.Status.StartTime = &startTime
+	t.Status.CompletionTime = &completionTime
+	return t
+}
+
 type linter struct {
 	logger logr.Logger
 
 	// The minimum recommended value for the experiment budget optimization parameter.
 	minExperimentBudget int
+	// The trial used to render templates.
+	trial *optimizev1beta2.Trial
+	// Flag indicating the experiment creates namespaces for trials.
+	hasNamespaceTemplate bool
+	// Flag indicating Prometheus queries should be executed.
+	queryDryRun bool
 }
 
 func (l *linter) Visit(ctx context.Context, obj interface{}) experiment.Visitor {
@@ -164,6 +241,11 @@ func (l *linter) Visit(ctx context.Context, obj interface{}) experiment.Visitor
 			lint.V(vError).Info("Baseline must be specified on all parameters")
 		}
 
+	case *optimizev1beta2.ExperimentSpec:
+		if err := validation.CheckGuardrails(o); err != nil {
+			lint.Error(err, "Guardrail is invalid")
+		}
+
 	case []optimizev1beta2.Metric:
 		if len(o) == 0 {
 			lint.V(vError).Info("Metrics are required")
@@ -183,10 +265,15 @@ func (l *linter) Visit(ctx context.Context, obj interface{}) experiment.Visitor
 		switch o.Type {
 		case
 			optimizev1beta2.MetricKubernetes,
+			optimizev1beta2.MetricKubernetesObject,
 			optimizev1beta2.MetricPrometheus,
+			optimizev1beta2.MetricPrometheusHistogram,
 			optimizev1beta2.MetricJSONPath,
 			optimizev1beta2.MetricDatadog,
 			optimizev1beta2.MetricNewRelic,
+			optimizev1beta2.MetricInfluxDB,
+			optimizev1beta2.MetricPush,
+			optimizev1beta2.MetricUsage,
 			"": // Type is valid
 		default:
 			lint.V(vError).Info("Metric type is invalid", "type", o.Type)
@@ -195,13 +282,31 @@ func (l *linter) Visit(ctx context.Context, obj interface{}) experiment.Visitor
 		if o.Query == "" {
 			lint.V(vError).Info("Metric query is required")
 		} else {
-			checkQuery(lint, o)
+			checkQuery(lint, o, l.trial)
+			checkPrometheusQuery(ctx, lint, o, l.trial, l.queryDryRun)
+		}
+
+		switch o.Aggregation {
+		case "", "avg", "max", "min", "last":
+		default:
+			lint.V(vError).Info("Metric aggregation is invalid", "aggregation", o.Aggregation)
+		}
+		if o.Aggregation != "" && o.Type != optimizev1beta2.MetricPrometheus && o.Type != optimizev1beta2.MetricPrometheusHistogram && o.Type != optimizev1beta2.MetricDatadog {
+			lint.V(vError).Info("Metric aggregation is only supported for Prometheus and Datadog metrics", "type", o.Type)
+		}
+
+		if _, _, err := metric.ParseUnit(o.Unit); err != nil {
+			lint.V(vError).Info("Metric unit is invalid", "unit", o.Unit)
 		}
 
 		if o.Min != nil && o.Max != nil && o.Min.Cmp(*o.Max) <= 0 {
 			lint.V(vError).Info("Metric minimum must be strictly less then maximum")
 		}
 
+		if o.BearerTokenSecretRef != nil && o.CredentialsSecretRef != nil {
+			lint.V(vError).Info("Metric cannot specify both a bearer token and a credentials secret")
+		}
+
 		if u, err := url.Parse(o.URL); err != nil {
 			lint.V(vError).Info("Metric has invalid URL")
 		} else if u.Hostname() == "redskyops.dev" {
@@ -223,13 +328,37 @@ func (l *linter) Visit(ctx context.Context, obj interface{}) experiment.Visitor
 			}
 		}
 
+		if o.Type == optimizev1beta2.PatchJSON6902 {
+			if o.TargetRef == nil {
+				lint.V(vError).Info("JSON 6902 patch target is required")
+			}
+			if len(o.Operations) == 0 {
+				lint.V(vError).Info("JSON 6902 patch operations are required")
+			}
+			if o.Patch != "" {
+				lint.V(vWarn).Info("Patch is ignored for JSON 6902 patches, use operations instead")
+			}
+		} else if len(o.Operations) > 0 {
+			lint.V(vWarn).Info("Patch operations are ignored unless the patch type is json6902")
+		}
+
+		for i := range o.RestartRefs {
+			if o.RestartRefs[i].Kind == "" || o.RestartRefs[i].Name == "" {
+				lint.V(vError).Info("Patch restart kind and name are required")
+			}
+		}
+
 		if ok, _ := regexp.MatchString(`(?m) +$`, o.Patch); ok {
 			lint.V(vWarn).Info("Patch lines contains trailing space which may cause formatting issues")
 		}
-		if _, err := template.New().RenderPatch(o, &optimizev1beta2.Trial{}); err != nil {
+		if _, err := template.New().RenderPatch(o, l.trial); err != nil {
 			lint.Error(err, "Patch is not valid")
 		}
 
+	case *optimizev1beta2.TrialTemplateSpec:
+		checkSetupTasks(lint, &o.Spec, l.trial)
+		checkSetupPermissions(lint, &o.Spec, l.hasNamespaceTemplate)
+
 	case *batchv1beta1.JobTemplateSpec:
 		if o.Spec.BackoffLimit != nil && *o.Spec.BackoffLimit != 0 {
 			lint.V(vWarn).Info("Job backoffLimit should be 0", "backoffLimit", *o.Spec.BackoffLimit)
@@ -273,7 +402,94 @@ func checkBaseline(lint logr.Logger, p *optimizev1beta2.Parameter) {
 	}
 }
 
-func checkQuery(lint logr.Logger, m *optimizev1beta2.Metric) {
+func checkSetupTasks(lint logr.Logger, spec *optimizev1beta2.TrialSpec, t *optimizev1beta2.Trial) {
+	volumes := make(map[string]bool, len(spec.SetupVolumes))
+	for i := range spec.SetupVolumes {
+		volumes[spec.SetupVolumes[i].Name] = true
+	}
+
+	names := make(map[string]bool, len(spec.SetupTasks))
+	for i := range spec.SetupTasks {
+		task := &spec.SetupTasks[i]
+		lint := lint.WithValues("setupTask", task.Name)
+
+		if task.Name == "" {
+			lint.V(vError).Info("Setup task name is required")
+		} else if names[task.Name] {
+			lint.V(vError).Info("Setup task name must be unique")
+		}
+		names[task.Name] = true
+
+		for _, vm := range task.VolumeMounts {
+			if !volumes[vm.Name] {
+				lint.V(vError).Info("Setup task volume mount does not reference a setup volume", "volume", vm.Name)
+			}
+		}
+
+		if task.HelmChart == "" {
+			if len(task.HelmValues) > 0 || len(task.HelmValuesFrom) > 0 {
+				lint.V(vWarn).Info("Setup task Helm values are ignored without a Helm chart")
+			}
+			if task.HelmPatch {
+				lint.V(vWarn).Info("Setup task Helm patch is ignored without a Helm chart")
+			}
+			continue
+		}
+
+		if task.HelmPatch && task.SkipCreate {
+			lint.V(vWarn).Info("Setup task Helm patch is never applied when create is skipped")
+		}
+
+		if _, _, _, err := template.New().RenderHelmChart(task, t); err != nil {
+			lint.Error(err, "Setup task Helm chart is not valid")
+		}
+
+		for j := range task.HelmValues {
+			if _, err := template.New().RenderHelmValue(&task.HelmValues[j], t); err != nil {
+				lint.Error(err, "Setup task Helm value is not valid", "name", task.HelmValues[j].Name)
+			}
+		}
+
+		for _, hvf := range task.HelmValuesFrom {
+			if hvf.ConfigMap == nil || hvf.ConfigMap.Name == "" {
+				lint.V(vError).Info("Setup task Helm values must reference a config map")
+			}
+		}
+	}
+}
+
+func checkSetupPermissions(lint logr.Logger, spec *optimizev1beta2.TrialSpec, hasNamespaceTemplate bool) {
+	var hasTaskRules bool
+	for i := range spec.SetupTasks {
+		hasTaskRules = hasTaskRules || len(spec.SetupTasks[i].Rules) > 0
+	}
+
+	if len(spec.SetupTasks) > 0 && spec.SetupServiceAccountName == "" && len(spec.SetupDefaultRules) == 0 && spec.SetupDefaultClusterRole == "" && !hasTaskRules {
+		lint.V(vWarn).Info("Setup tasks run using the default service account which may not have the required permissions")
+	}
+
+	if !hasNamespaceTemplate && (len(spec.SetupDefaultRules) > 0 || spec.SetupDefaultClusterRole != "") {
+		lint.V(vWarn).Info("Setup default rules and cluster role are only granted in namespaces created from the namespace template")
+	}
+
+	if !hasNamespaceTemplate && hasTaskRules {
+		lint.V(vWarn).Info("Setup task rules are only granted in namespaces created from the namespace template")
+	}
+}
+
+func checkPrometheusQuery(ctx context.Context, lint logr.Logger, m *optimizev1beta2.Metric, t *optimizev1beta2.Trial, dryRun bool) {
+	// The bearer token secret is only available in the cluster
+	if dryRun && m.BearerTokenSecretRef != nil && m.URL != "" {
+		lint.V(vWarn).Info("Metric query dry run skipped, the bearer token secret is not available")
+		dryRun = false
+	}
+
+	if err := metric.ValidatePrometheusQueries(ctx, m, t, nil, dryRun); err != nil {
+		lint.Error(err, "Metric query is not valid")
+	}
+}
+
+func checkQuery(lint logr.Logger, m *optimizev1beta2.Metric, t *optimizev1beta2.Trial) {
 	switch m.Type {
 	case optimizev1beta2.MetricKubernetes, "":
 		if strings.Contains(m.Query, "resourceRequests") && (m.Target == nil || m.Target.Kind != "PodList") {
@@ -290,7 +506,7 @@ func checkQuery(lint logr.Logger, m *optimizev1beta2.Metric) {
 		target.SetGroupVersionKind(optimizev1beta2.GroupVersion.WithKind("Trial"))
 	}
 
-	q, _, err := template.New().RenderMetricQueries(m, &optimizev1beta2.Trial{}, target)
+	q, _, err := template.New().RenderMetricQueries(m, t, target)
 	if err != nil {
 		lint.Error(err, "Metric query failed to render", "query", m.Query)
 	}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package check
+
+import (
+	"testing"
+
+	"github.com/go-logr/zapr"
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCheckSetup(t *testing.T) {
+	cases := []struct {
+		desc                 string
+		spec                 optimizev1beta2.TrialSpec
+		hasNamespaceTemplate bool
+		expected             []string
+	}{
+		{
+			desc: "valid",
+			spec: optimizev1beta2.TrialSpec{
+				SetupServiceAccountName: "setup",
+				SetupVolumes:            []corev1.Volume{{Name: "values"}},
+				SetupTasks: []optimizev1beta2.SetupTask{{
+					Name:         "install",
+					HelmChart:    "stable/postgresql",
+					HelmValues:   []optimizev1beta2.HelmValue{{Name: "replicas", Value: intstr.FromString(`{{ .Values.replicas }}`)}},
+					VolumeMounts: []corev1.VolumeMount{{Name: "values"}},
+				}},
+			},
+		},
+		{
+			desc: "invalid references",
+			spec: optimizev1beta2.TrialSpec{
+				SetupServiceAccountName: "setup",
+				SetupTasks: []optimizev1beta2.SetupTask{
+					{
+						Name:           "install",
+						HelmChart:      "stable/postgresql",
+						HelmValues:     []optimizev1beta2.HelmValue{{Name: "replicas", Value: intstr.FromString(`{{ .Values.replicas `)}},
+						HelmValuesFrom: []optimizev1beta2.HelmValuesFromSource{{}},
+						VolumeMounts:   []corev1.VolumeMount{{Name: "missing"}},
+					},
+					{
+						Name:       "install",
+						HelmValues: []optimizev1beta2.HelmValue{{Name: "replicas"}},
+					},
+				},
+			},
+			expected: []string{
+				"Setup task volume mount does not reference a setup volume",
+				"Setup task Helm value is not valid",
+				"Setup task Helm values must reference a config map",
+				"Setup task name must be unique",
+				"Setup task Helm values are ignored without a Helm chart",
+			},
+		},
+		{
+			desc: "default service account",
+			spec: optimizev1beta2.TrialSpec{
+				SetupTasks: []optimizev1beta2.SetupTask{{Name: "install"}},
+			},
+			expected: []string{
+				"Setup tasks run using the default service account which may not have the required permissions",
+			},
+		},
+		{
+			desc: "rules without namespace template",
+			spec: optimizev1beta2.TrialSpec{
+				SetupTasks:        []optimizev1beta2.SetupTask{{Name: "install"}},
+				SetupDefaultRules: []rbacv1.PolicyRule{{Verbs: []string{"*"}}},
+			},
+			expected: []string{
+				"Setup default rules and cluster role are only granted in namespaces created from the namespace template",
+			},
+		},
+		{
+			desc: "rules with namespace template",
+			spec: optimizev1beta2.TrialSpec{
+				SetupTasks:        []optimizev1beta2.SetupTask{{Name: "install"}},
+				SetupDefaultRules: []rbacv1.PolicyRule{{Verbs: []string{"*"}}},
+			},
+			hasNamespaceTemplate: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			core, logs := observer.New(zapcore.WarnLevel)
+			lint := zapr.NewLogger(zap.New(core))
+
+			tr := &optimizev1beta2.Trial{}
+			tr.Spec.Assignments = []optimizev1beta2.Assignment{{Name: "replicas", Value: intstr.FromInt(3)}}
+
+			checkSetupTasks(lint, &c.spec, tr)
+			checkSetupPermissions(lint, &c.spec, c.hasNamespaceTemplate)
+
+			var actual []string
+			for _, e := range logs.All() {
+				actual = append(actual, e.Message)
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
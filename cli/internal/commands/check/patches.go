@@ -0,0 +1,303 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package check
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"github.com/thestormforge/optimize-controller/v2/internal/patch"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// simulatedTrial is a trial used to simulate the effect of the experiment patches.
+type simulatedTrial struct {
+	name  string
+	trial *optimizev1beta2.Trial
+}
+
+// checkPatches simulates each patch against the supplied manifests using the minimum, maximum and baseline
+// assignments, reporting patches which fail to apply, produce invalid resources or do not change anything.
+func (o *ExperimentOptions) checkPatches(ctx context.Context, logger logr.Logger, exp *optimizev1beta2.Experiment) error {
+	resources, err := ReadManifests(&o.IOStreams, o.Manifests)
+	if err != nil {
+		return err
+	}
+
+	te := template.New()
+	trials := simulatedTrials(exp)
+	var patched []*unstructured.Unstructured
+	for i := range exp.Spec.Patches {
+		p := &exp.Spec.Patches[i]
+		lint := logger.WithValues("path", fmt.Sprintf("/spec/patches/%d", i))
+
+		var changed, failed bool
+		for _, st := range trials {
+			ref, data, err := patch.RenderTemplate(te, st.trial, p)
+			if err != nil {
+				lint.Error(err, "Patch failed to render", "assignments", st.name)
+				failed = true
+				continue
+			}
+
+			// The trial job does not exist until the trial runs, there is nothing to patch
+			if trial.IsTrialJobReference(st.trial, ref) {
+				changed = true
+				break
+			}
+
+			target := FindResource(resources, ref)
+			if target == nil {
+				lint.V(vError).Info("Patch target was not found in the manifests", "kind", ref.Kind, "name", ref.Name)
+				failed = true
+				break
+			}
+
+			result, err := ApplyPatch(target, p.Type, data)
+			if err != nil {
+				lint.Error(err, "Patch failed to apply", "assignments", st.name)
+				failed = true
+				continue
+			}
+
+			if err := validateResource(result); err != nil {
+				lint.Error(err, "Patched resource is not valid", "assignments", st.name)
+				failed = true
+				continue
+			}
+
+			if !equality.Semantic.DeepEqual(target.Object, result.Object) {
+				changed = true
+			}
+			patched = append(patched, result)
+		}
+
+		if !changed && !failed {
+			lint.V(vError).Info("Patch does not change the target, check the field paths")
+		}
+	}
+
+	if o.ServerDryRun && len(patched) > 0 {
+		return o.serverDryRun(ctx, patched)
+	}
+
+	return nil
+}
+
+// ReadManifests reads all of the resources from the manifest files.
+func ReadManifests(streams *commander.IOStreams, filenames []string) ([]*unstructured.Unstructured, error) {
+	var resources []*unstructured.Unstructured
+	for _, filename := range filenames {
+		r, err := streams.OpenFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes, err := (&kio.ByteReader{Reader: r, OmitReaderAnnotations: true}).Read()
+		_ = r.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, node := range nodes {
+			data, err := node.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+
+			u := &unstructured.Unstructured{}
+			if err := u.UnmarshalJSON(data); err != nil {
+				return nil, err
+			}
+			resources = append(resources, u)
+		}
+	}
+	return resources, nil
+}
+
+// serverDryRun submits the patched resources to the cluster for validation without persisting them.
+func (o *ExperimentOptions) serverDryRun(ctx context.Context, resources []*unstructured.Unstructured) error {
+	var buf bytes.Buffer
+	for _, u := range resources {
+		data, err := u.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		node, err := yaml.ConvertJSONToYamlNode(string(data))
+		if err != nil {
+			return err
+		}
+
+		if err := (&kio.ByteWriter{Writer: &buf}).Write([]*yaml.RNode{node}); err != nil {
+			return err
+		}
+		_, _ = io.WriteString(&buf, "---\n")
+	}
+
+	kubectlApply, err := o.Config.Kubectl(ctx, "apply", "--dry-run=server", "-f", "-")
+	if err != nil {
+		return err
+	}
+	kubectlApply.Stdin = &buf
+	kubectlApply.Stdout = o.Out
+	kubectlApply.Stderr = o.ErrOut
+	return kubectlApply.Run()
+}
+
+// simulatedTrials returns trials with the minimum, maximum and (if every parameter has one) baseline assignments.
+func simulatedTrials(exp *optimizev1beta2.Experiment) []simulatedTrial {
+	newTrial := func(assignment func(p *optimizev1beta2.Parameter) *intstr.IntOrString) *optimizev1beta2.Trial {
+		t := &optimizev1beta2.Trial{}
+		experiment.PopulateTrialFromTemplate(exp, t)
+		t.Namespace = exp.Namespace
+		for i := range exp.Spec.Parameters {
+			p := &exp.Spec.Parameters[i]
+			v := assignment(p)
+			if v == nil {
+				return nil
+			}
+			t.Spec.Assignments = append(t.Spec.Assignments, optimizev1beta2.Assignment{Name: p.Name, Value: *v})
+		}
+		return t
+	}
+
+	trials := []simulatedTrial{
+		{name: "min", trial: newTrial(func(p *optimizev1beta2.Parameter) *intstr.IntOrString {
+			if len(p.Values) > 0 {
+				v := intstr.FromString(p.Values[0])
+				return &v
+			}
+			v := intstr.FromInt(int(p.Min))
+			return &v
+		})},
+		{name: "max", trial: newTrial(func(p *optimizev1beta2.Parameter) *intstr.IntOrString {
+			if len(p.Values) > 0 {
+				v := intstr.FromString(p.Values[len(p.Values)-1])
+				return &v
+			}
+			v := intstr.FromInt(int(p.Max))
+			return &v
+		})},
+	}
+
+	if t := newTrial(func(p *optimizev1beta2.Parameter) *intstr.IntOrString { return p.Baseline }); t != nil {
+		trials = append(trials, simulatedTrial{name: "baseline", trial: t})
+	}
+
+	return trials
+}
+
+// FindResource returns the resource matching the object reference.
+func FindResource(resources []*unstructured.Unstructured, ref *corev1.ObjectReference) *unstructured.Unstructured {
+	for _, u := range resources {
+		if u.GetKind() != ref.Kind || u.GetName() != ref.Name {
+			continue
+		}
+		if ref.APIVersion != "" && u.GetAPIVersion() != ref.APIVersion {
+			continue
+		}
+		if ref.Namespace != "" && u.GetNamespace() != "" && u.GetNamespace() != ref.Namespace {
+			continue
+		}
+		return u
+	}
+	return nil
+}
+
+// ApplyPatch applies the rendered patch data to a copy of the target resource.
+func ApplyPatch(target *unstructured.Unstructured, patchType optimizev1beta2.PatchType, data []byte) (*unstructured.Unstructured, error) {
+	original, err := target.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	switch patchType {
+	case optimizev1beta2.PatchStrategic, "":
+		obj, err := scheme.Scheme.New(target.GroupVersionKind())
+		if err != nil {
+			// Custom resources do not support strategic merge, fall back to a JSON merge patch
+			if data, err = patch.ToMergePatch(data); err == nil {
+				patched, err = jsonpatch.MergePatch(original, data)
+			}
+		} else {
+			patched, err = strategicpatch.StrategicMergePatch(original, data, obj)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+	case optimizev1beta2.PatchMerge:
+		if patched, err = jsonpatch.MergePatch(original, data); err != nil {
+			return nil, err
+		}
+
+	case optimizev1beta2.PatchJSON, optimizev1beta2.PatchJSON6902:
+		p, err := jsonpatch.DecodePatch(data)
+		if err != nil {
+			return nil, err
+		}
+		if patched, err = p.Apply(original); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown patch type: %s", patchType)
+	}
+
+	result := &unstructured.Unstructured{}
+	if err := result.UnmarshalJSON(patched); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// validateResource ensures the resource strictly decodes into its registered type, resources with unknown types
+// can only be validated by the server.
+func validateResource(u *unstructured.Unstructured) error {
+	obj, err := scheme.Scheme.New(u.GroupVersionKind())
+	if err != nil {
+		return nil
+	}
+
+	data, err := u.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(obj)
+}
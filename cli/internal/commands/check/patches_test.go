@@ -0,0 +1,102 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestApplyPatch(t *testing.T) {
+	target := &unstructured.Unstructured{}
+	require.NoError(t, target.UnmarshalJSON([]byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"app"},`+
+		`"spec":{"template":{"spec":{"containers":[{"name":"app","image":"app"}]}}}}`)))
+
+	cases := []struct {
+		desc            string
+		patchType       optimizev1beta2.PatchType
+		data            string
+		expectedChanged bool
+		expectedInvalid bool
+	}{
+		{
+			desc:            "strategic",
+			data:            `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":"1"}}}]}}}}`,
+			expectedChanged: true,
+		},
+		{
+			desc:            "strategic typo",
+			data:            `{"spec":{"template":{"spec":{"containers":[{"name":"app","resource":{"limits":{"cpu":"1"}}}]}}}}`,
+			expectedChanged: true,
+			expectedInvalid: true,
+		},
+		{
+			desc:            "strategic remove field",
+			data:            `{"spec":{"template":{"spec":{"containers":[{"name":"app","image":null}]}}}}`,
+			expectedChanged: true,
+		},
+		{
+			desc:            "strategic replace list",
+			data:            `{"spec":{"template":{"spec":{"containers":[{"$patch":"replace"},{"name":"other","image":"other"}]}}}}`,
+			expectedChanged: true,
+		},
+		{
+			desc:            "merge",
+			patchType:       optimizev1beta2.PatchMerge,
+			data:            `{"spec":{"replicas":3}}`,
+			expectedChanged: true,
+		},
+		{
+			desc:      "json no change",
+			patchType: optimizev1beta2.PatchJSON,
+			data:      `[{"op":"replace","path":"/metadata/name","value":"app"}]`,
+		},
+		{
+			desc:            "json6902 array append",
+			patchType:       optimizev1beta2.PatchJSON6902,
+			data:            `[{"op":"add","path":"/spec/template/spec/containers/0/args","value":["--workers=2"]}]`,
+			expectedChanged: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			result, err := ApplyPatch(target, c.patchType, []byte(c.data))
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedChanged, !equality.Semantic.DeepEqual(target.Object, result.Object))
+			assert.Equal(t, c.expectedInvalid, validateResource(result) != nil)
+		})
+	}
+}
+
+func TestApplyPatch_CustomResource(t *testing.T) {
+	target := &unstructured.Unstructured{}
+	require.NoError(t, target.UnmarshalJSON([]byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"w"},`+
+		`"spec":{"items":[{"name":"x"},{"name":"y"}],"settings":{"a":1,"b":2}}}`)))
+
+	result, err := ApplyPatch(target, optimizev1beta2.PatchStrategic, []byte(`{"spec":{"items":[{"$patch":"replace"},{"name":"z"}],"settings":{"a":null}}}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]interface{}{
+			"items":    []interface{}{map[string]interface{}{"name": "z"}},
+			"settings": map[string]interface{}{"b": int64(2)},
+		}, result.Object["spec"])
+	}
+}
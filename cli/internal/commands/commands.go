@@ -38,9 +38,13 @@ import (
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/grant_permissions"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/initialize"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/login"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/logs"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/pause"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/performance"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/ping"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/prune"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/reset"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/results"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/revoke"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/run"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/version"
@@ -74,18 +78,26 @@ func NewRootCommand() *cobra.Command {
 	// Kubernetes Commands
 	rootCmd.AddCommand(initialize.NewCommand(&initialize.Options{GeneratorOptions: initialize.GeneratorOptions{Config: cfg, IncludeBootstrapRole: true}}))
 	rootCmd.AddCommand(reset.NewCommand(&reset.Options{Config: cfg}))
+	rootCmd.AddCommand(prune.NewCommand(&prune.Options{Config: cfg}))
+	rootCmd.AddCommand(pause.NewPauseCommand(&pause.Options{Config: cfg}))
+	rootCmd.AddCommand(pause.NewResumeCommand(&pause.Options{Config: cfg}))
 	rootCmd.AddCommand(grant_permissions.NewCommand(&grant_permissions.Options{GeneratorOptions: grant_permissions.GeneratorOptions{Config: cfg}}))
 	rootCmd.AddCommand(authorize_cluster.NewCommand(&authorize_cluster.Options{GeneratorOptions: authorize_cluster.GeneratorOptions{Config: cfg}}))
 	rootCmd.AddCommand(generate.NewCommand(&generate.Options{Config: cfg}))
 	rootCmd.AddCommand(fix.NewCommand(&fix.Options{Config: cfg}))
 	rootCmd.AddCommand(export.NewCommand(&export.Options{Config: cfg}))
 	rootCmd.AddCommand(run.NewCommand(&run.Options{Config: cfg}))
+	rootCmd.AddCommand(logs.NewCommand(&logs.Options{Config: cfg}))
 
 	// Remote Server Commands
 	rootCmd.AddCommand(experiments.NewDeleteCommand(&experiments.DeleteOptions{Options: experiments.Options{Config: cfg}}))
 	rootCmd.AddCommand(experiments.NewGetCommand(&experiments.GetOptions{Options: experiments.Options{Config: cfg}, ChunkSize: 500}))
 	rootCmd.AddCommand(experiments.NewLabelCommand(&experiments.LabelOptions{Options: experiments.Options{Config: cfg}}))
 	rootCmd.AddCommand(experiments.NewSuggestCommand(&experiments.SuggestOptions{Options: experiments.Options{Config: cfg}}))
+	rootCmd.AddCommand(experiments.NewBackupCommand(&experiments.BackupOptions{Options: experiments.Options{Config: cfg}}))
+	rootCmd.AddCommand(experiments.NewRestoreCommand(&experiments.RestoreOptions{Options: experiments.Options{Config: cfg}}))
+	rootCmd.AddCommand(results.NewCommand(&results.Options{Config: cfg}))
+	rootCmd.AddCommand(results.NewTopCommand(&results.TopOptions{Config: cfg}))
 
 	// Administrative Commands
 	rootCmd.AddCommand(login.NewCommand(&login.Options{Config: cfg}))
@@ -99,12 +111,15 @@ func NewRootCommand() *cobra.Command {
 	rootCmd.AddCommand(docs.NewCommand(&docs.Options{}))
 	rootCmd.AddCommand(debug.NewCommand(&debug.Options{Config: cfg}))
 
-	// TODO Add 'backup' and 'restore' maintenance commands ('maint' subcommands?)
-	// TODO We need helpers for doing a "dry run" on patches to make configuration easier
 	// TODO Add a "trial cleanup" command to run setup tasks (perhaps remove labels from standard setupJob)
-	// TODO The "get" functionality needs to support templating so you can extract assignments for downstream use
 
 	commander.MapErrors(rootCmd, mapError)
+
+	// Legacy command spellings
+	commander.AddMigrationShim(rootCmd, "configure", "config")
+	commander.AddMigrationShim(rootCmd, "authorize", "authorize-cluster")
+	commander.AddMigrationShim(rootCmd, "grant", "grant-permissions")
+
 	return rootCmd
 }
 
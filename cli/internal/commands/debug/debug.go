@@ -37,6 +37,7 @@ func NewCommand(o *Options) *cobra.Command {
 	}
 
 	cmd.AddCommand(NewMetricQueryCommand(&MetricQueryOptions{Config: o.Config}))
+	cmd.AddCommand(NewRenderPatchesCommand(&RenderPatchesOptions{Config: o.Config}))
 
 	return cmd
 }
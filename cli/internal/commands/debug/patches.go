@@ -0,0 +1,299 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands/check"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"github.com/thestormforge/optimize-controller/v2/internal/patch"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	"github.com/thestormforge/optimize-go/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// RenderPatchesOptions configure a patch rendering debugging session.
+type RenderPatchesOptions struct {
+	Config *config.OptimizeConfig
+	commander.IOStreams
+
+	Filename    string
+	TrialName   string
+	Assignments map[string]string
+	Manifests   []string
+}
+
+// NewRenderPatchesCommand creates a command for rendering the experiment patches.
+func NewRenderPatchesCommand(o *RenderPatchesOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "render-patches",
+		Short:  "Debug patch templates",
+		Long:   "Render the experiment patches using specified assignments",
+		PreRun: commander.StreamsPreRun(&o.IOStreams),
+		RunE:   commander.WithoutArgsE(o.Debug),
+	}
+
+	cmd.Flags().StringVarP(&o.Filename, "experiment", "f", "", "`file` containing the experiment definition")
+	cmd.Flags().StringVar(&o.TrialName, "trial", "", "trial `name` to use")
+	cmd.Flags().StringToStringVarP(&o.Assignments, "set", "A", nil, "assign an explicit `key=value` to a parameter")
+	cmd.Flags().StringSliceVar(&o.Manifests, "manifests", nil, "manifest `files` to apply the patches to")
+
+	// Aliases for consistency with the other commands
+	cmd.Flags().StringVar(&o.Filename, "filename", "", "`file` containing the experiment definition")
+	cmd.Flags().StringToStringVar(&o.Assignments, "assign", nil, "assign an explicit `key=value` to a parameter")
+	_ = cmd.Flags().MarkHidden("filename")
+	_ = cmd.Flags().MarkHidden("assign")
+
+	_ = cmd.MarkFlagFilename("experiment", "yml", "yaml")
+	_ = cmd.MarkFlagFilename("filename", "yml", "yaml")
+
+	return cmd
+}
+
+func (o *RenderPatchesOptions) Debug() error {
+	if o.Filename == "" {
+		return fmt.Errorf("an experiment file is required, use --experiment")
+	}
+
+	// Read the experiment
+	r, err := o.IOStreams.OpenFile(o.Filename)
+	if err != nil {
+		return err
+	}
+
+	exp := &optimizev1beta2.Experiment{}
+	rr := commander.NewResourceReader()
+	if err := rr.ReadInto(r, exp); err != nil {
+		return err
+	}
+
+	// Create a new trial object with the requested assignments
+	t := &optimizev1beta2.Trial{}
+	t.Name = o.TrialName
+	experiment.PopulateTrialFromTemplate(exp, t)
+	if t.Namespace == "" {
+		t.Namespace = "default"
+	}
+	if t.Name == "" {
+		t.Name = t.GenerateName + "0"
+	}
+	if t.Spec.Assignments, err = assignments(exp, o.Assignments); err != nil {
+		return err
+	}
+
+	// Render the patches
+	ops, err := renderPatches(exp, t)
+	if err != nil {
+		return err
+	}
+
+	// Without manifests, just print the patches
+	if len(o.Manifests) == 0 {
+		return o.writePatches(ops)
+	}
+
+	resources, err := check.ReadManifests(&o.IOStreams, o.Manifests)
+	if err != nil {
+		return err
+	}
+
+	patched, err := applyPatches(resources, ops)
+	if err != nil {
+		return err
+	}
+
+	return o.writeResources(patched)
+}
+
+// assignments returns the trial assignments for the experiment parameters, using the baseline of any parameter
+// which was not explicitly assigned.
+func assignments(exp *optimizev1beta2.Experiment, values map[string]string) ([]optimizev1beta2.Assignment, error) {
+	known := make(map[string]bool, len(exp.Spec.Parameters))
+	for i := range exp.Spec.Parameters {
+		known[exp.Spec.Parameters[i].Name] = true
+	}
+
+	var unknown []string
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown parameters: %v", unknown)
+	}
+
+	var result []optimizev1beta2.Assignment
+	for i := range exp.Spec.Parameters {
+		p := &exp.Spec.Parameters[i]
+		if v, ok := values[p.Name]; ok {
+			result = append(result, optimizev1beta2.Assignment{Name: p.Name, Value: intstr.Parse(v)})
+		} else if p.Baseline != nil {
+			result = append(result, optimizev1beta2.Assignment{Name: p.Name, Value: *p.Baseline})
+		} else {
+			return nil, fmt.Errorf("missing assignment for parameter %q", p.Name)
+		}
+	}
+
+	return result, nil
+}
+
+// renderedPatch associates a patch operation with the experiment patch type it was rendered from.
+type renderedPatch struct {
+	index     int
+	patchType optimizev1beta2.PatchType
+	op        *optimizev1beta2.PatchOperation
+}
+
+// renderPatches renders each of the experiment patches (including workload restarts) using the trial assignments.
+func renderPatches(exp *optimizev1beta2.Experiment, t *optimizev1beta2.Trial) ([]renderedPatch, error) {
+	var result []renderedPatch
+	te := template.New()
+	for i := range exp.Spec.Patches {
+		p := &exp.Spec.Patches[i]
+
+		ref, data, err := patch.RenderTemplate(te, t, p)
+		if err != nil {
+			return nil, fmt.Errorf("patch %d: %w", i, err)
+		}
+
+		po, err := patch.CreatePatchOperation(t, p, ref, data)
+		if err != nil {
+			return nil, fmt.Errorf("patch %d: %w", i, err)
+		}
+		if po != nil {
+			// The operation may have been converted to a different patch type
+			var pt optimizev1beta2.PatchType
+			switch po.PatchType {
+			case types.StrategicMergePatchType:
+				pt = optimizev1beta2.PatchStrategic
+			case types.MergePatchType:
+				pt = optimizev1beta2.PatchMerge
+			case types.JSONPatchType:
+				pt = optimizev1beta2.PatchJSON
+			}
+			result = append(result, renderedPatch{index: i, patchType: pt, op: po})
+		}
+
+		for j := range p.RestartRefs {
+			ref, data, err := patch.RenderRestart(t, &p.RestartRefs[j])
+			if err != nil {
+				return nil, fmt.Errorf("patch %d: %w", i, err)
+			}
+			po := &optimizev1beta2.PatchOperation{TargetRef: *ref, Data: data}
+			result = append(result, renderedPatch{index: i, patchType: optimizev1beta2.PatchStrategic, op: po})
+		}
+	}
+	return result, nil
+}
+
+// applyPatches applies the rendered patches to the resources, returning the patched resources in the order they
+// were first patched.
+func applyPatches(resources []*unstructured.Unstructured, patches []renderedPatch) ([]*unstructured.Unstructured, error) {
+	var patched []*unstructured.Unstructured
+	for _, p := range patches {
+		target := check.FindResource(resources, &p.op.TargetRef)
+		if target == nil {
+			return nil, fmt.Errorf("patch %d: target %s was not found in the manifests", p.index, describeRef(&p.op.TargetRef))
+		}
+
+		result, err := check.ApplyPatch(target, p.patchType, p.op.Data)
+		if err != nil {
+			return nil, fmt.Errorf("patch %d: %w", p.index, err)
+		}
+
+		// Replace the target so subsequent patches are applied to the result
+		first := true
+		for i := range resources {
+			if resources[i] == target {
+				resources[i] = result
+			}
+		}
+		for i := range patched {
+			if patched[i] == target {
+				patched[i], first = result, false
+			}
+		}
+		if first {
+			patched = append(patched, result)
+		}
+	}
+	return patched, nil
+}
+
+// writePatches writes each of the rendered patches to the output stream.
+func (o *RenderPatchesOptions) writePatches(patches []renderedPatch) error {
+	var nodes []*yaml.RNode
+	for _, p := range patches {
+		data, err := yaml.ConvertJSONToYamlNode(string(p.op.Data))
+		if err != nil {
+			return err
+		}
+
+		node := yaml.NewMapRNode(nil)
+		if err := node.PipeE(yaml.SetField("target", yaml.NewStringRNode(describeRef(&p.op.TargetRef)))); err != nil {
+			return err
+		}
+		if err := node.PipeE(yaml.SetField("type", yaml.NewStringRNode(string(p.patchType)))); err != nil {
+			return err
+		}
+		if err := node.PipeE(yaml.SetField("patch", data)); err != nil {
+			return err
+		}
+		node.YNode().HeadComment = fmt.Sprintf("/spec/patches/%d", p.index)
+		nodes = append(nodes, node)
+	}
+
+	return o.YAMLWriter().Write(nodes)
+}
+
+// writeResources writes the patched resources to the output stream.
+func (o *RenderPatchesOptions) writeResources(resources []*unstructured.Unstructured) error {
+	var nodes []*yaml.RNode
+	for _, u := range resources {
+		data, err := u.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		node, err := yaml.ConvertJSONToYamlNode(string(data))
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+	}
+
+	return o.YAMLWriter().Write(nodes)
+}
+
+// describeRef returns a short description of the object reference.
+func describeRef(ref *corev1.ObjectReference) string {
+	if ref.Namespace != "" {
+		return fmt.Sprintf("%s/%s (%s)", ref.Kind, ref.Name, ref.Namespace)
+	}
+	return fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestAssignments(t *testing.T) {
+	baseline := intstr.FromInt(500)
+	exp := &optimizev1beta2.Experiment{
+		Spec: optimizev1beta2.ExperimentSpec{
+			Parameters: []optimizev1beta2.Parameter{
+				{Name: "cpu", Min: 100, Max: 2000, Baseline: &baseline},
+				{Name: "memory", Min: 128, Max: 2048},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc          string
+		values        map[string]string
+		expected      []optimizev1beta2.Assignment
+		expectedError string
+	}{
+		{
+			desc:   "explicit",
+			values: map[string]string{"cpu": "250", "memory": "1024"},
+			expected: []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromInt(250)},
+				{Name: "memory", Value: intstr.FromInt(1024)},
+			},
+		},
+		{
+			desc:   "baseline",
+			values: map[string]string{"memory": "1024"},
+			expected: []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromInt(500)},
+				{Name: "memory", Value: intstr.FromInt(1024)},
+			},
+		},
+		{
+			desc:          "missing",
+			values:        map[string]string{"cpu": "250"},
+			expectedError: `missing assignment for parameter "memory"`,
+		},
+		{
+			desc:          "unknown",
+			values:        map[string]string{"memory": "1024", "replicas": "3"},
+			expectedError: `unknown parameters: [replicas]`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := assignments(exp, c.values)
+			if c.expectedError != "" {
+				assert.EqualError(t, err, c.expectedError)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRenderPatches(t *testing.T) {
+	exp := &optimizev1beta2.Experiment{
+		Spec: optimizev1beta2.ExperimentSpec{
+			Patches: []optimizev1beta2.PatchTemplate{
+				{
+					TargetRef: &corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Name: "app-config"},
+					Patch:     `{"data":{"heap":"{{ .Values.memory }}"}}`,
+					RestartRefs: []corev1.ObjectReference{
+						{APIVersion: "apps/v1", Kind: "Deployment", Name: "app"},
+					},
+				},
+			},
+		},
+	}
+	tr := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo-0", Namespace: "default"},
+		Spec: optimizev1beta2.TrialSpec{
+			Assignments: []optimizev1beta2.Assignment{{Name: "memory", Value: intstr.FromInt(1024)}},
+		},
+	}
+
+	patches, err := renderPatches(exp, tr)
+	if !assert.NoError(t, err) || !assert.Len(t, patches, 2) {
+		return
+	}
+	assert.JSONEq(t, `{"data":{"heap":"1024"}}`, string(patches[0].op.Data))
+	assert.Equal(t, "Deployment", patches[1].op.TargetRef.Kind)
+
+	resources := []*unstructured.Unstructured{
+		{Object: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[string]interface{}{"name": "app"}}},
+		{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "app-config"}}},
+	}
+	patched, err := applyPatches(resources, patches)
+	if assert.NoError(t, err) && assert.Len(t, patched, 2) {
+		assert.Equal(t, "ConfigMap", patched[0].GetKind())
+		heap, _, _ := unstructured.NestedString(patched[0].Object, "data", "heap")
+		assert.Equal(t, "1024", heap)
+		restart, _, _ := unstructured.NestedString(patched[1].Object, "spec", "template", "metadata", "annotations", optimizev1beta2.AnnotationRestartTrial)
+		assert.Equal(t, "demo-0", restart)
+	}
+}
+
+func TestNewRenderPatchesCommand_Flags(t *testing.T) {
+	cases := []struct {
+		desc string
+		args []string
+	}{
+		{
+			desc: "experiment and set",
+			args: []string{"--experiment", "exp.yaml", "--set", "cpu=500m,memory=1Gi"},
+		},
+		{
+			desc: "filename and assign",
+			args: []string{"--filename", "exp.yaml", "--assign", "cpu=500m,memory=1Gi"},
+		},
+		{
+			desc: "shorthand",
+			args: []string{"-f", "exp.yaml", "-A", "cpu=500m,memory=1Gi"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			o := &RenderPatchesOptions{}
+			cmd := NewRenderPatchesCommand(o)
+			if assert.NoError(t, cmd.ParseFlags(c.args)) {
+				assert.Equal(t, "exp.yaml", o.Filename)
+				assert.Equal(t, map[string]string{"cpu": "500m", "memory": "1Gi"}, o.Assignments)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiments
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+// experimentBackup is the serialized form of an experiment and all of its trials
+type experimentBackup struct {
+	// Name is the experiment name (the experiment itself does not serialize it's name)
+	Name string `json:"name"`
+	// Experiment is the experiment definition, including labels
+	Experiment experimentsv1alpha1.Experiment `json:"experiment"`
+	// Trials are all of the trials of the experiment, including assignments, values and labels
+	Trials []experimentsv1alpha1.TrialItem `json:"trials,omitempty"`
+}
+
+// BackupOptions includes the configuration for backing up experiments
+type BackupOptions struct {
+	Options
+
+	// ExperimentNames are the experiments to back up, all experiments if empty
+	ExperimentNames []string
+	// Filename is the file to write the backup to
+	Filename string
+}
+
+// NewBackupCommand creates a new backup command
+func NewBackupCommand(o *BackupOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup [NAME ...]",
+		Short: "Back up experiments and trials",
+		Long:  "Back up StormForge Optimize experiments and all of their trials from the remote server to a local file",
+
+		ValidArgsFunction: o.validArgs,
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			o.ExperimentNames = args
+			commander.SetStreams(&o.IOStreams, cmd)
+			return commander.SetExperimentsAPI(&o.ExperimentsAPI, o.Config, cmd)
+		},
+		RunE: commander.WithContextE(o.backup),
+	}
+
+	cmd.Flags().StringVarP(&o.Filename, "filename", "f", "", "write the backup to a `file` instead of standard output")
+
+	_ = cmd.MarkFlagFilename("filename", "yml", "yaml")
+
+	return cmd
+}
+
+func (o *BackupOptions) backup(ctx context.Context) error {
+	names := o.ExperimentNames
+	if len(names) == 0 {
+		var err error
+		if names, err = o.allExperimentNames(ctx); err != nil {
+			return err
+		}
+	}
+
+	backups := make([]experimentBackup, 0, len(names))
+	for _, n := range names {
+		b, err := o.backupExperiment(ctx, experimentsv1alpha1.ExperimentName(n))
+		if err != nil {
+			return err
+		}
+		backups = append(backups, *b)
+	}
+
+	data, err := yaml.Marshal(backups)
+	if err != nil {
+		return err
+	}
+
+	if o.Filename == "" || o.Filename == "-" {
+		_, err := o.Out.Write(data)
+		return err
+	}
+
+	return os.WriteFile(o.Filename, data, 0644)
+}
+
+// allExperimentNames returns the names of every experiment on the server.
+func (o *BackupOptions) allExperimentNames(ctx context.Context) ([]string, error) {
+	l, err := o.ExperimentsAPI.GetAllExperiments(ctx, experimentsv1alpha1.ExperimentListQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	next := l.Link(api.RelationNext)
+	for next != "" {
+		n, err := o.ExperimentsAPI.GetAllExperimentsByPage(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		next = n.Link(api.RelationNext)
+		l.Experiments = append(l.Experiments, n.Experiments...)
+	}
+
+	names := make([]string, 0, len(l.Experiments))
+	for i := range l.Experiments {
+		if n := l.Experiments[i].Name.String(); n != "" {
+			names = append(names, n)
+		} else {
+			names = append(names, l.Experiments[i].DisplayName)
+		}
+	}
+	return names, nil
+}
+
+// backupExperiment collects the experiment and all of it's trials.
+func (o *BackupOptions) backupExperiment(ctx context.Context, n experimentsv1alpha1.ExperimentName) (*experimentBackup, error) {
+	exp, err := o.ExperimentsAPI.GetExperimentByName(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &experimentBackup{Name: n.String(), Experiment: exp}
+	if exp.Link(api.RelationTrials) == "" {
+		return b, nil
+	}
+
+	q := experimentsv1alpha1.TrialListQuery{}
+	q.SetStatus(experimentsv1alpha1.TrialActive, experimentsv1alpha1.TrialCompleted, experimentsv1alpha1.TrialFailed, experimentsv1alpha1.TrialStaged, experimentsv1alpha1.TrialAbandoned)
+	tl, err := o.ExperimentsAPI.GetAllTrials(ctx, exp.Link(api.RelationTrials), q)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Trials = tl.Trials
+	sort.Slice(b.Trials, func(i, j int) bool { return b.Trials[i].Number < b.Trials[j].Number })
+	return b, nil
+}
+
+// RestoreOptions includes the configuration for restoring experiments
+type RestoreOptions struct {
+	Options
+
+	// Filename is the file to read the backup from
+	Filename string
+}
+
+// NewRestoreCommand creates a new restore command
+func NewRestoreCommand(o *RestoreOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore experiments and trials",
+		Long: "Restore StormForge Optimize experiments and their finished trials from a backup to the remote server." +
+			" Trials are renumbered in the order they were originally created; active, staged and abandoned trials are not restored.",
+		Args: cobra.NoArgs,
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			commander.SetStreams(&o.IOStreams, cmd)
+			return commander.SetExperimentsAPI(&o.ExperimentsAPI, o.Config, cmd)
+		},
+		RunE: commander.WithContextE(o.restore),
+	}
+
+	cmd.Flags().StringVarP(&o.Filename, "filename", "f", "-", "the backup `file` to restore, - for stdin")
+
+	_ = cmd.MarkFlagFilename("filename", "yml", "yaml")
+
+	o.Printer = &verbPrinter{verb: "restored"}
+
+	return cmd
+}
+
+func (o *RestoreOptions) restore(ctx context.Context) error {
+	r, err := o.IOStreams.OpenFile(o.Filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var backups []experimentBackup
+	if err := yaml.Unmarshal(data, &backups); err != nil {
+		return fmt.Errorf("invalid backup: %w", err)
+	}
+
+	for i := range backups {
+		if err := o.restoreExperiment(ctx, &backups[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreExperiment creates the experiment and replays each of it's finished trials.
+func (o *RestoreOptions) restoreExperiment(ctx context.Context, b *experimentBackup) error {
+	exp, err := o.ExperimentsAPI.CreateExperimentByName(ctx, experimentsv1alpha1.ExperimentName(b.Name), b.Experiment)
+	if err != nil {
+		return err
+	}
+	if exp.DisplayName == "" {
+		exp.DisplayName = b.Name
+	}
+
+	if err := o.Printer.PrintObj(&exp, o.Out); err != nil {
+		return err
+	}
+
+	for i := range b.Trials {
+		t := &b.Trials[i]
+		if t.Status != experimentsv1alpha1.TrialCompleted && t.Status != experimentsv1alpha1.TrialFailed {
+			continue
+		}
+
+		ta, err := o.ExperimentsAPI.CreateTrial(ctx, exp.Link(api.RelationTrials), experimentsv1alpha1.TrialAssignments{
+			Assignments: t.Assignments,
+			Labels:      t.Labels,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := o.ExperimentsAPI.ReportTrial(ctx, ta.Location(), t.TrialValues); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
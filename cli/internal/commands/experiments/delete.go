@@ -19,11 +19,14 @@ package experiments
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
 	"github.com/thestormforge/optimize-controller/v2/internal/controller"
 	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
 	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 )
 
@@ -31,10 +34,23 @@ import (
 type DeleteOptions struct {
 	Options
 
+	// ApplicationsAPI is used to interact with the Optimize Applications API
+	ApplicationsAPI applications.API
 	// IgnoreNotFound treats missing resources as successful deletes
 	IgnoreNotFound bool
+	// Cascade is the policy for deleting experiments (remote, cluster or all) and the scenarios of applications
+	Cascade string
+	// KeepTrials preserves the server trials when deleting the experiment from the cluster
+	KeepTrials bool
 }
 
+// The cascade policies for deleting experiments
+const (
+	cascadeRemote  = "remote"
+	cascadeCluster = "cluster"
+	cascadeAll     = "all"
+)
+
 // NewDeleteCommand creates a new deletion command
 func NewDeleteCommand(o *DeleteOptions) *cobra.Command {
 	cmd := &cobra.Command{
@@ -49,11 +65,23 @@ func NewDeleteCommand(o *DeleteOptions) *cobra.Command {
 			if err := commander.SetExperimentsAPI(&o.ExperimentsAPI, o.Config, cmd); err != nil {
 				return err
 			}
+			if err := commander.SetApplicationsAPI(&o.ApplicationsAPI, o.Config, cmd); err != nil {
+				return err
+			}
+			if err := o.checkCascade(); err != nil {
+				return err
+			}
 			return o.setNames(args)
 		},
 		RunE: commander.WithContextE(o.delete),
 	}
 
+	cmd.Flags().StringVar(&o.Cascade, "cascade", "", "the `policy` for deleting experiments from the remote server, the cluster or all; applications also delete their scenarios")
+	cmd.Flags().BoolVar(&o.KeepTrials, "keep-trials", false, "preserve the trials on the remote server when deleting an experiment from the cluster")
+
+	cmd.Flags().Lookup("cascade").NoOptDefVal = cascadeAll
+	commander.SetFlagValues(cmd, "cascade", cascadeRemote, cascadeCluster, cascadeAll)
+
 	o.Printer = &verbPrinter{verb: "deleted"}
 
 	return cmd
@@ -70,6 +98,10 @@ func (o *DeleteOptions) delete(ctx context.Context) error {
 			if err := o.deleteExperiment(ctx, n.experimentName()); o.ignoreDeleteError(err) != nil {
 				return err
 			}
+		case typeApplication:
+			if err := o.deleteApplication(ctx, applications.ApplicationName(n.Name)); o.ignoreDeleteError(err) != nil {
+				return err
+			}
 		default:
 			return fmt.Errorf("cannot delete \"%s\"", n.Type)
 		}
@@ -77,6 +109,21 @@ func (o *DeleteOptions) delete(ctx context.Context) error {
 	return nil
 }
 
+// checkCascade verifies the cascade policy and it's compatibility with keeping trials
+func (o *DeleteOptions) checkCascade() error {
+	switch o.Cascade {
+	case "", cascadeRemote, cascadeCluster, cascadeAll:
+	default:
+		return fmt.Errorf("invalid cascade policy %q, must be one of: %s, %s, %s", o.Cascade, cascadeRemote, cascadeCluster, cascadeAll)
+	}
+
+	if o.KeepTrials && o.Cascade != cascadeCluster {
+		return fmt.Errorf("keeping trials requires the cluster cascade policy, the remote experiment cannot be deleted without its trials")
+	}
+
+	return nil
+}
+
 // ignoreDeleteError is a helper for ignoring errors that occur during deletion
 func (o *DeleteOptions) ignoreDeleteError(err error) error {
 	if o.IgnoreNotFound && controller.IgnoreNotFound(err) == nil {
@@ -88,6 +135,15 @@ func (o *DeleteOptions) ignoreDeleteError(err error) error {
 // deleteExperiment deletes an individual experiment by name
 //noinspection GoNilness
 func (o *DeleteOptions) deleteExperiment(ctx context.Context, name experimentsv1alpha1.ExperimentName) error {
+	if o.Cascade == cascadeCluster || o.Cascade == cascadeAll {
+		if err := o.deleteClusterExperiment(ctx, name.String()); err != nil {
+			return err
+		}
+		if o.Cascade == cascadeCluster {
+			return nil
+		}
+	}
+
 	exp, err := o.ExperimentsAPI.GetExperimentByName(ctx, name)
 	if err != nil {
 		return err
@@ -103,3 +159,88 @@ func (o *DeleteOptions) deleteExperiment(ctx context.Context, name experimentsv1
 
 	return o.Printer.PrintObj(&exp, o.Out)
 }
+
+// deleteApplication deletes an individual application by name, the scenarios of the application are only
+// deleted when cascading, otherwise their existence prevents the deletion
+func (o *DeleteOptions) deleteApplication(ctx context.Context, name applications.ApplicationName) error {
+	app, err := o.ApplicationsAPI.GetApplicationByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	selfURL := app.Link(api.RelationSelf)
+	if selfURL == "" {
+		return nil
+	}
+	if app.Name == "" {
+		app.Name = name
+	}
+
+	if scenariosURL := app.Link(api.RelationScenarios); scenariosURL != "" {
+		scenarios, err := o.ApplicationsAPI.ListScenarios(ctx, scenariosURL, applications.ScenarioListQuery{})
+		if err != nil {
+			return err
+		}
+
+		if len(scenarios.Scenarios) > 0 && o.Cascade != cascadeRemote && o.Cascade != cascadeAll {
+			return fmt.Errorf("application %q has %d scenario(s), use --cascade to delete them", name, len(scenarios.Scenarios))
+		}
+
+		for i := range scenarios.Scenarios {
+			scn := &scenarios.Scenarios[i]
+			if err := o.ApplicationsAPI.DeleteScenario(ctx, scn.Link(api.RelationSelf)); o.ignoreDeleteError(err) != nil {
+				return err
+			}
+			if err := o.Printer.PrintObj(scn, o.Out); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := o.ApplicationsAPI.DeleteApplication(ctx, selfURL); err != nil {
+		return err
+	}
+
+	return o.Printer.PrintObj(&app, o.Out)
+}
+
+// deleteClusterExperiment deletes the in-cluster experiment, when keeping trials the controller is prevented from
+// deleting the server experiment as the in-cluster experiment is cleaned up
+func (o *DeleteOptions) deleteClusterExperiment(ctx context.Context, name string) error {
+	if o.KeepTrials {
+		get, err := o.Config.Kubectl(ctx, "get", "experiment", name, "--ignore-not-found", "--output",
+			"jsonpath={.metadata.annotations."+strings.ReplaceAll(optimizev1beta2.AnnotationServerSync, ".", `\.`)+"}")
+		if err != nil {
+			return err
+		}
+		get.Stderr = o.ErrOut
+		out, err := get.Output()
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(string(out))) {
+		case "delete", "delete-completed":
+			annotate, err := o.Config.Kubectl(ctx, "annotate", "experiment", name, "--overwrite", optimizev1beta2.AnnotationServerSync+"=enabled")
+			if err != nil {
+				return err
+			}
+			annotate.Stderr = o.ErrOut
+			if err := annotate.Run(); err != nil {
+				return err
+			}
+		}
+	}
+
+	args := []string{"delete", "experiment", name}
+	if o.IgnoreNotFound {
+		args = append(args, "--ignore-not-found")
+	}
+
+	del, err := o.Config.Kubectl(ctx, args...)
+	if err != nil {
+		return err
+	}
+	del.Stdout = o.Out
+	del.Stderr = o.ErrOut
+	return del.Run()
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
 	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 	"github.com/thestormforge/optimize-go/pkg/config"
 	"k8s.io/client-go/util/jsonpath"
@@ -37,6 +38,8 @@ const (
 	typeExperiment resourceType = "experiment"
 	// typeTrial is the type argument to use for trials
 	typeTrial resourceType = "trial"
+	// typeApplication is the type argument to use for applications
+	typeApplication resourceType = "application"
 )
 
 // normalizeType returns a consistent value based on a user entered type. The returned plural type only
@@ -52,6 +55,10 @@ func normalizeType(t string) (normalType resourceType, pluralType string, err er
 		return typeTrial, string(typeTrial), nil
 	case "trials":
 		return typeTrial, string(typeTrial) + "s", nil
+	case "application", "app":
+		return typeApplication, string(typeApplication), nil
+	case "applications":
+		return typeApplication, string(typeApplication) + "s", nil
 	}
 	return "", "", fmt.Errorf("unknown resource type \"%s\"", t)
 }
@@ -229,6 +236,10 @@ func (v *verbPrinter) PrintObj(obj interface{}, w io.Writer) error {
 		_, _ = fmt.Fprintf(w, "experiment \"%s\" %s\n", o.DisplayName, v.verb)
 	case *experimentsv1alpha1.TrialItem:
 		_, _ = fmt.Fprintf(w, "trial \"%s-%03d\" %s\n", o.Experiment.DisplayName, o.Number, v.verb)
+	case *applications.Application:
+		_, _ = fmt.Fprintf(w, "application \"%s\" %s\n", o.Name, v.verb)
+	case *applications.ScenarioItem:
+		_, _ = fmt.Fprintf(w, "scenario \"%s\" %s\n", o.Name, v.verb)
 	default:
 		return fmt.Errorf("could not print \"%s\" for: %T", v.verb, obj)
 	}
@@ -18,9 +18,14 @@ package experiments
 
 import (
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"sigs.k8s.io/yaml"
 )
 
 func TestParseNames(t *testing.T) {
@@ -145,6 +150,20 @@ func TestParseNames(t *testing.T) {
 				{Type: typeTrial, Name: "foo-2", Number: -1},
 			},
 		},
+		{
+			desc: "Applications",
+			args: []string{"application", "foo", "app/bar", "applications/baz"},
+			names: []name{
+				{Type: typeApplication, Name: "foo", Number: -1},
+				{Type: typeApplication, Name: "bar", Number: -1},
+				{Type: typeApplication, Name: "baz", Number: -1},
+			},
+		},
+		{
+			desc: "ApplicationNumber",
+			args: []string{"app/foo/1"},
+			err:  "application name cannot include a number: app/foo/1",
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
@@ -201,3 +220,204 @@ func TestSortByField(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterTrials(t *testing.T) {
+	trials := []experimentsv1alpha1.TrialItem{
+		{
+			Number: 0,
+			Status: experimentsv1alpha1.TrialCompleted,
+			TrialAssignments: experimentsv1alpha1.TrialAssignments{
+				Assignments: []experimentsv1alpha1.Assignment{{ParameterName: "cpu", Value: api.FromInt64(500)}},
+			},
+		},
+		{
+			Number: 1,
+			Status: experimentsv1alpha1.TrialFailed,
+			TrialAssignments: experimentsv1alpha1.TrialAssignments{
+				Assignments: []experimentsv1alpha1.Assignment{{ParameterName: "cpu", Value: api.FromInt64(250)}},
+			},
+		},
+		{
+			Number: 2,
+			Status: experimentsv1alpha1.TrialCompleted,
+			TrialAssignments: experimentsv1alpha1.TrialAssignments{
+				Assignments: []experimentsv1alpha1.Assignment{{ParameterName: "cpu", Value: api.FromInt64(250)}},
+				Labels:      map[string]string{"best": "true"},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc          string
+		selector      string
+		fieldSelector string
+		expected      []int64
+	}{
+		{
+			desc:     "no selectors",
+			expected: []int64{0, 1, 2},
+		},
+		{
+			desc:          "status",
+			fieldSelector: "status=completed",
+			expected:      []int64{0, 2},
+		},
+		{
+			desc:          "assignment",
+			fieldSelector: "parameter.cpu=250",
+			expected:      []int64{1, 2},
+		},
+		{
+			desc:          "multiple fields",
+			fieldSelector: "status!=failed,parameter.cpu=250",
+			expected:      []int64{2},
+		},
+		{
+			desc:          "labels and fields",
+			selector:      "best=true",
+			fieldSelector: "status=completed",
+			expected:      []int64{2},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			o := &GetOptions{Selector: c.selector, FieldSelector: c.fieldSelector}
+			l := &experimentsv1alpha1.TrialList{Trials: append([]experimentsv1alpha1.TrialItem{}, trials...)}
+			if assert.NoError(t, o.filterAndSortTrials(l)) {
+				var actual []int64
+				for i := range l.Trials {
+					actual = append(actual, l.Trials[i].Number)
+				}
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExperimentBackup(t *testing.T) {
+	b := []experimentBackup{
+		{
+			Name: "my-exp",
+			Experiment: experimentsv1alpha1.Experiment{
+				DisplayName: "my-exp",
+				Labels:      map[string]string{"application": "my-app"},
+				Metrics:     []experimentsv1alpha1.Metric{{Name: "cost", Minimize: true}},
+				Parameters:  []experimentsv1alpha1.Parameter{{Name: "cpu", Type: experimentsv1alpha1.ParameterTypeInteger}},
+			},
+			Trials: []experimentsv1alpha1.TrialItem{
+				{
+					Number: 1,
+					Status: experimentsv1alpha1.TrialCompleted,
+					TrialAssignments: experimentsv1alpha1.TrialAssignments{
+						Metadata:    api.Metadata{},
+						Assignments: []experimentsv1alpha1.Assignment{{ParameterName: "cpu", Value: api.FromInt64(500)}},
+						Labels:      map[string]string{"best": "true"},
+					},
+					TrialValues: experimentsv1alpha1.TrialValues{
+						Values: []experimentsv1alpha1.Value{{MetricName: "cost", Value: 12.5}},
+					},
+				},
+				{
+					Number: 2,
+					Status: experimentsv1alpha1.TrialFailed,
+					TrialAssignments: experimentsv1alpha1.TrialAssignments{
+						Metadata:    api.Metadata{},
+						Assignments: []experimentsv1alpha1.Assignment{{ParameterName: "cpu", Value: api.FromInt64(100)}},
+					},
+					TrialValues: experimentsv1alpha1.TrialValues{
+						Failed:        true,
+						FailureReason: "OOMKilled",
+					},
+				},
+			},
+		},
+	}
+
+	data, err := yaml.Marshal(b)
+	require.NoError(t, err)
+
+	var actual []experimentBackup
+	require.NoError(t, yaml.Unmarshal(data, &actual))
+	assert.Equal(t, b, actual)
+}
+
+func TestSuggestOptions_ReadAssignments(t *testing.T) {
+	cases := []struct {
+		desc        string
+		input       string
+		assignments map[string]string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			desc:     "yaml",
+			input:    "cpu: 500\nmemory: 1.5\nmode: fast\n",
+			expected: map[string]string{"cpu": "500", "memory": "1.5", "mode": "fast"},
+		},
+		{
+			desc:     "json",
+			input:    `{"cpu": 1000000}`,
+			expected: map[string]string{"cpu": "1000000"},
+		},
+		{
+			desc:        "explicit assignments win",
+			input:       "cpu: 500\nmode: fast\n",
+			assignments: map[string]string{"cpu": "200"},
+			expected:    map[string]string{"cpu": "200", "mode": "fast"},
+		},
+		{
+			desc:        "nested value",
+			input:       "cpu:\n  value: 500\n",
+			expectedErr: "invalid assignment for parameter: cpu",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			o := &SuggestOptions{Filename: "-", Assignments: c.assignments}
+			o.In = strings.NewReader(c.input)
+
+			err := o.readAssignments()
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, o.Assignments)
+		})
+	}
+}
+
+func TestDeleteOptions_CheckCascade(t *testing.T) {
+	cases := []struct {
+		desc        string
+		cascade     string
+		keepTrials  bool
+		expectedErr string
+	}{
+		{desc: "default"},
+		{desc: "all", cascade: "all"},
+		{desc: "cluster keep trials", cascade: "cluster", keepTrials: true},
+		{
+			desc:        "invalid",
+			cascade:     "everything",
+			expectedErr: `invalid cascade policy "everything", must be one of: remote, cluster, all`,
+		},
+		{
+			desc:        "remote keep trials",
+			cascade:     "remote",
+			keepTrials:  true,
+			expectedErr: "keeping trials requires the cluster cascade policy, the remote experiment cannot be deleted without its trials",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			o := &DeleteOptions{Cascade: c.cascade, KeepTrials: c.keepTrials}
+			err := o.checkCascade()
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
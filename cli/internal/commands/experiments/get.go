@@ -20,11 +20,13 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
@@ -32,10 +34,11 @@ import (
 type GetOptions struct {
 	Options
 
-	ChunkSize int
-	SortBy    string
-	Selector  string
-	All       bool
+	ChunkSize     int
+	SortBy        string
+	Selector      string
+	FieldSelector string
+	All           bool
 }
 
 // NewGetCommand creates a new get command
@@ -59,6 +62,7 @@ func NewGetCommand(o *GetOptions) *cobra.Command {
 
 	cmd.Flags().IntVar(&o.ChunkSize, "chunk-size", o.ChunkSize, "fetch large lists in chunks rather then all at once")
 	cmd.Flags().StringVarP(&o.Selector, "selector", "l", o.Selector, "selector (label `query`) to filter on")
+	cmd.Flags().StringVar(&o.FieldSelector, "field-selector", o.FieldSelector, "selector (field `query`) to filter on, e.g. status=completed or parameter.cpu=500")
 	cmd.Flags().StringVar(&o.SortBy, "sort-by", o.SortBy, "sort list types using this JSONPath `expression`")
 	cmd.Flags().BoolVarP(&o.All, "all", "A", false, "include all resources")
 
@@ -235,6 +239,19 @@ func (o *GetOptions) filterAndSortExperiments(l *experimentsv1alpha1.ExperimentL
 		l.Experiments = nil
 	}
 
+	// Filter the experiment list using field selectors
+	if sel, err := fields.ParseSelector(o.FieldSelector); err != nil {
+		return err
+	} else if !sel.Empty() {
+		var filtered []experimentsv1alpha1.ExperimentItem
+		for i := range l.Experiments {
+			if sel.Matches(experimentFields(&l.Experiments[i])) {
+				filtered = append(filtered, l.Experiments[i])
+			}
+		}
+		l.Experiments = filtered
+	}
+
 	// If sorting was requested, sort using maps with all the sortable keys
 	if o.SortBy != "" {
 		sort.Slice(l.Experiments, sortByField(o.SortBy, func(i int) interface{} { return sortableExperimentData(&l.Experiments[i]) }))
@@ -243,6 +260,14 @@ func (o *GetOptions) filterAndSortExperiments(l *experimentsv1alpha1.ExperimentL
 	return nil
 }
 
+// experimentFields returns the fields of an experiment item which can be used in a field selector
+func experimentFields(item *experimentsv1alpha1.ExperimentItem) fields.Set {
+	return fields.Set{
+		"name":         item.DisplayName,
+		"observations": strconv.FormatInt(item.Observations, 10),
+	}
+}
+
 // sortableExperimentData slightly modifies the schema of the experiment item to make it easier to specify sort orders
 func sortableExperimentData(item *experimentsv1alpha1.ExperimentItem) map[string]interface{} {
 	d := make(map[string]interface{}, 2)
@@ -266,6 +291,19 @@ func (o *GetOptions) filterAndSortTrials(l *experimentsv1alpha1.TrialList) error
 		l.Trials = filtered
 	}
 
+	// Filter the trial list using field selectors
+	if sel, err := fields.ParseSelector(o.FieldSelector); err != nil {
+		return err
+	} else if !sel.Empty() {
+		var filtered []experimentsv1alpha1.TrialItem
+		for i := range l.Trials {
+			if sel.Matches(trialFields(&l.Trials[i])) {
+				filtered = append(filtered, l.Trials[i])
+			}
+		}
+		l.Trials = filtered
+	}
+
 	// If sorting was requested, sort using maps with all the sortable keys
 	if o.SortBy != "" {
 		sort.Slice(l.Trials, sortByField(o.SortBy, func(i int) interface{} { return sortableTrialData(&l.Trials[i]) }))
@@ -301,3 +339,22 @@ func sortableTrialData(item *experimentsv1alpha1.TrialItem) map[string]interface
 	d["values"] = values
 	return d
 }
+
+// trialFields returns the fields of a trial item which can be used in a field selector
+func trialFields(item *experimentsv1alpha1.TrialItem) fields.Set {
+	f := fields.Set{
+		"number":        strconv.FormatInt(item.Number, 10),
+		"status":        string(item.Status),
+		"failureReason": item.FailureReason,
+	}
+	for i := range item.Assignments {
+		f["parameter."+item.Assignments[i].ParameterName] = item.Assignments[i].Value.String()
+	}
+	for i := range item.Values {
+		f["metric."+item.Values[i].MetricName] = strconv.FormatFloat(item.Values[i].Value, 'f', -1, 64)
+	}
+	for k, v := range item.Labels {
+		f["label."+k] = v
+	}
+	return f
+}
@@ -19,7 +19,9 @@ package experiments
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math/rand"
 	"strings"
 
@@ -28,10 +30,9 @@ import (
 	"github.com/thestormforge/optimize-controller/v2/internal/validation"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"sigs.k8s.io/yaml"
 )
 
-// TODO Accept suggestion inputs from standard input, what formats?
-
 const (
 	DefaultNone     = "none"
 	DefaultMinimum  = "min"
@@ -45,6 +46,7 @@ type SuggestOptions struct {
 	Options
 
 	Assignments      map[string]string
+	Filename         string
 	AllowInteractive bool
 	DefaultBehavior  string
 	Labels           string
@@ -69,16 +71,22 @@ func NewSuggestCommand(o *SuggestOptions) *cobra.Command {
 	}
 
 	cmd.Flags().StringToStringVarP(&o.Assignments, "assign", "A", nil, "assign an explicit `key=value` to a parameter")
+	cmd.Flags().StringVarP(&o.Filename, "filename", "f", "", "read parameter assignments from a `file`, - for stdin")
 	cmd.Flags().BoolVar(&o.AllowInteractive, "interactive", false, "allow interactive prompts for unspecified parameter assignments")
 	cmd.Flags().StringVar(&o.DefaultBehavior, "default", "", "select the `behavior` for default values")
 	cmd.Flags().StringVarP(&o.Labels, "labels", "l", "", "comma separated `key=value` labels to apply to the trial")
 
 	commander.SetFlagValues(cmd, "default", DefaultNone, DefaultMinimum, DefaultMaximum, DefaultRandom)
+	_ = cmd.MarkFlagFilename("filename", "yml", "yaml", "json")
 
 	return cmd
 }
 
 func (o *SuggestOptions) suggest(ctx context.Context) error {
+	if err := o.readAssignments(); err != nil {
+		return err
+	}
+
 	exp, err := o.ExperimentsAPI.GetExperimentByName(ctx, o.Names[0].experimentName())
 	if err != nil {
 		return err
@@ -100,6 +108,50 @@ func (o *SuggestOptions) suggest(ctx context.Context) error {
 	return nil
 }
 
+// readAssignments reads a YAML or JSON map of parameter names to values from the configured file, explicit
+// assignments take precedence over the values in the file.
+func (o *SuggestOptions) readAssignments() error {
+	if o.Filename == "" {
+		return nil
+	}
+
+	r, err := o.IOStreams.OpenFile(o.Filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values, func(d *json.Decoder) *json.Decoder {
+		d.UseNumber()
+		return d
+	}); err != nil {
+		return fmt.Errorf("invalid assignments file: %w", err)
+	}
+
+	if o.Assignments == nil {
+		o.Assignments = make(map[string]string, len(values))
+	}
+	for k, v := range values {
+		if _, ok := o.Assignments[k]; ok {
+			continue
+		}
+		switch v.(type) {
+		case string, json.Number, bool:
+			o.Assignments[k] = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("invalid assignment for parameter: %s", k)
+		}
+	}
+
+	return nil
+}
+
 // SuggestAssignments creates new assignments object based on the parameters of the supplied experiment
 func (o *SuggestOptions) SuggestAssignments(exp *experimentsv1alpha1.Experiment, ta *experimentsv1alpha1.TrialAssignments) error {
 	for i := range exp.Parameters {
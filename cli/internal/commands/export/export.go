@@ -42,6 +42,7 @@ import (
 	"github.com/thestormforge/optimize-controller/v2/internal/server"
 	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
 	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	trialutil "github.com/thestormforge/optimize-controller/v2/internal/trial"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	applicationsv2 "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
 	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
@@ -74,11 +75,13 @@ type Options struct {
 	recommendationName string
 	patchOnly          bool
 	patchedTarget      bool
+	runbook            bool
 
 	// This is used for testing
 	Fs          filesys.FileSystem
 	inputData   []byte
 	experiment  *optimizev1beta2.Experiment
+	trial       *optimizev1beta2.Trial
 	application *optimizeappsv1alpha1.Application
 	resources   map[string]struct{}
 }
@@ -102,6 +105,7 @@ func NewCommand(o *Options) *cobra.Command {
 		Use:   "export TRIAL_NAME|APP_NAME|REC_NAME",
 		Short: "Export or apply a patch",
 		Long:  "Export trial parameters or a recommendation as a patch",
+		Args:  cobra.MaximumNArgs(1),
 
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			commander.SetStreams(&o.IOStreams, cmd)
@@ -139,6 +143,9 @@ func NewCommand(o *Options) *cobra.Command {
 
 	_ = cmd.MarkFlagFilename("filename", "yml", "yaml")
 
+	cmd.AddCommand(NewRunbookCommand(&Options{Config: o.Config}))
+	cmd.AddCommand(NewHelmValuesCommand(&HelmValuesOptions{Options: Options{Config: o.Config}}))
+
 	return cmd
 }
 
@@ -298,12 +305,20 @@ func (o *Options) runner(ctx context.Context) error {
 	}
 
 	// look up trial from api
+	progress := o.Progress()
+	progress.Start("Fetching details from the StormForge API")
 	trialDetails, err := o.getTrialDetails(ctx)
+
+	// Not a trial, try a recommendation instead
+	var recDetails *recommendationDetails
+	if err == nil && trialDetails == nil {
+		recDetails, err = o.getRecommendationDetails(ctx)
+	}
+	progress.Stop(err)
 	if err != nil {
 		return err
 	}
 
-	var recDetails *recommendationDetails
 	if trialDetails != nil {
 		// See if we have been given an experiment
 		if err := o.extractExperiment(trialDetails); err != nil {
@@ -324,11 +339,6 @@ func (o *Options) runner(ctx context.Context) error {
 				return err
 			}
 		}
-	} else {
-		recDetails, err = o.getRecommendationDetails(ctx)
-		if err != nil {
-			return err
-		}
 	}
 
 	var patches []types.Patch
@@ -340,6 +350,10 @@ func (o *Options) runner(ctx context.Context) error {
 		trial := &optimizev1beta2.Trial{}
 		experiment.PopulateTrialFromTemplate(o.experiment, trial)
 		server.ToClusterTrial(trial, trialDetails.Assignments)
+		if trial.Name == "" {
+			trial.Name = o.trialName
+		}
+		o.trial = trial
 
 		// render patches
 		if pp, err := createTrialKustomizePatches(o.experiment.Spec.Patches, trial); err != nil {
@@ -380,6 +394,10 @@ func (o *Options) runner(ctx context.Context) error {
 		return err
 	}
 
+	if o.runbook {
+		return o.writeRunbook(yamls)
+	}
+
 	if !o.patchedTarget {
 		fmt.Fprintln(o.Out, string(yamls))
 		return nil
@@ -405,6 +423,7 @@ func (o *Options) generateExperiment(trial *trialDetails) error {
 		Scenario:       trial.Scenario,
 		Objective:      trial.Objective,
 		FilterOptions:  opts,
+		NamespacedRBAC: true,
 	}
 
 	if gen.Scenario == "" && gen.Objective == "" {
@@ -513,17 +532,22 @@ func (o *Options) getTrialDetails(ctx context.Context) (*trialDetails, error) {
 // createTrialKustomizePatches translates a patchTemplate into a kustomize (json) patch
 func createTrialKustomizePatches(patchSpec []optimizev1beta2.PatchTemplate, trial *optimizev1beta2.Trial) ([]types.Patch, error) {
 	te := template.New()
-	patches := make([]types.Patch, len(patchSpec))
+	patches := make([]types.Patch, 0, len(patchSpec))
 
-	for idx, expPatch := range patchSpec {
+	for _, expPatch := range patchSpec {
 		ref, data, err := patch.RenderTemplate(te, trial, &expPatch)
 		if err != nil {
 			return nil, err
 		}
 
+		// Patches to the trial job cannot be applied to any of the resources
+		if trialutil.IsTrialJobReference(trial, ref) {
+			continue
+		}
+
 		switch expPatch.Type {
-		// If json patch, we can consume the patch as is
-		case optimizev1beta2.PatchJSON:
+		// If json patch (including rendered json6902 operations), we can consume the patch as is
+		case optimizev1beta2.PatchJSON, optimizev1beta2.PatchJSON6902:
 		// Otherwise we need to inject the type meta into the patch data
 		// because it says so
 		// https://github.com/kubernetes-sigs/kustomize/blob/master/examples/inlinePatch.md
@@ -538,6 +562,18 @@ func createTrialKustomizePatches(patchSpec []optimizev1beta2.PatchTemplate, tria
 			// objects for each, but that isnt really right or addressing the issue either
 			// So instead we'll do this dance with unstructured.
 
+			// Kustomize applies the patch as a strategic merge, make sure the directives and list semantics match
+			strategic := ref.APIVersion == "" || patch.SupportsStrategicMerge(ref.GroupVersionKind())
+			if expPatch.Type == optimizev1beta2.PatchMerge && strategic {
+				if data, err = patch.ToStrategicMergePatch(data); err != nil {
+					return nil, err
+				}
+			} else if !strategic {
+				if data, err = patch.ToMergePatch(data); err != nil {
+					return nil, err
+				}
+			}
+
 			// // Transition patch from json to map[string]interface
 			m := make(map[string]interface{})
 			if err := json.Unmarshal(data, &m); err != nil {
@@ -558,7 +594,7 @@ func createTrialKustomizePatches(patchSpec []optimizev1beta2.PatchTemplate, tria
 			}
 		}
 
-		patches[idx] = types.Patch{
+		patches = append(patches, types.Patch{
 			Patch: string(data),
 			Target: &types.Selector{
 				KrmId: types.KrmId{
@@ -570,7 +606,7 @@ func createTrialKustomizePatches(patchSpec []optimizev1beta2.PatchTemplate, tria
 					Name: ref.Name,
 				},
 			},
-		}
+		})
 	}
 
 	return patches, nil
@@ -208,6 +208,58 @@ func TestPatchApplication(t *testing.T) {
 	}
 }
 
+func TestRunbook(t *testing.T) {
+	_, _, expFile := createTempExperimentFile(t)
+	defer os.Remove(expFile.Name())
+
+	manifestFile := createTempManifests(t)
+	defer os.Remove(manifestFile.Name())
+
+	cfg := &config.OptimizeConfig{}
+
+	opts := &export.Options{Config: cfg}
+	opts.ExperimentsAPI = &fakeExperimentsAPI{}
+	cmd := export.NewRunbookCommand(opts)
+	commander.ConfigGlobals(cfg, cmd)
+
+	var b bytes.Buffer
+	cmd.SetOut(&b)
+	cmd.SetArgs([]string{
+		"--filename", expFile.Name(),
+		"--filename", manifestFile.Name(),
+		"--trial", "1234",
+		"sampleExperiment",
+	})
+
+	err := cmd.Execute()
+	require.NoError(t, err)
+
+	nodes, err := kio.FromBytes(b.Bytes())
+	require.NoError(t, err)
+
+	var kinds []string
+	for _, node := range nodes {
+		m, err := node.GetMeta()
+		require.NoError(t, err)
+		kinds = append(kinds, m.Kind)
+
+		if m.Kind == "Job" {
+			assert.Equal(t, "sampleExperiment-1234", m.Name)
+			assert.Equal(t, "trialRun", m.Labels["stormforge.io/trial-role"])
+		}
+	}
+	assert.Contains(t, kinds, "Deployment")
+	assert.Contains(t, kinds, "Job")
+	assert.NotContains(t, kinds, "Experiment")
+
+	dep, err := extractDeployment(b.Bytes(), "postgres")
+	require.NoError(t, err)
+
+	cpu := wannabeTrial.TrialAssignments.Assignments[0]
+	cpuLimits := dep.Spec.Template.Spec.Containers[0].Resources.Limits["cpu"]
+	assert.Equal(t, fmt.Sprintf("%sm", cpu.Value.String()), (&cpuLimits).String())
+}
+
 func extractDeployment(input []byte, name string) (*appsv1.Deployment, error) {
 	var deploymentBuf bytes.Buffer
 
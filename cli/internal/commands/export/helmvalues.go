@@ -0,0 +1,256 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"github.com/thestormforge/optimize-controller/v2/internal/server"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// HelmValuesOptions are the options for exporting the best trial as Helm values
+type HelmValuesOptions struct {
+	Options
+
+	experimentName string
+	mappingFile    string
+}
+
+// NewHelmValuesCommand creates a command for exporting the best trial of an experiment as Helm values
+func NewHelmValuesCommand(o *HelmValuesOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "helm-values",
+		Short: "Export the best trial as Helm values",
+		Long:  "Export the assignments of the best trial as a Helm values.yaml fragment",
+		Args:  cobra.NoArgs,
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			commander.SetStreams(&o.IOStreams, cmd)
+
+			if o.experimentName == "" {
+				return fmt.Errorf("an experiment name must be specified")
+			}
+
+			if o.ExperimentsAPI == nil {
+				return commander.SetExperimentsAPI(&o.ExperimentsAPI, o.Config, cmd)
+			}
+			return nil
+		},
+		RunE: commander.WithContextE(o.helmValues),
+	}
+
+	cmd.Flags().StringVar(&o.experimentName, "experiment", "", "the `name` of the experiment to export the best trial from")
+	cmd.Flags().StringSliceVarP(&o.inputFiles, "filename", "f", nil, "experiment `files` containing Helm values, - for stdin")
+	cmd.Flags().StringVar(&o.mappingFile, "mapping", "", "the Helm values mapping `file` to use instead of the experiment")
+
+	_ = cmd.MarkFlagFilename("filename", "yml", "yaml")
+	_ = cmd.MarkFlagFilename("mapping", "yml", "yaml")
+	_ = cmd.MarkFlagRequired("experiment")
+
+	return cmd
+}
+
+func (o *HelmValuesOptions) helmValues(ctx context.Context) error {
+	if err := o.readInput(); err != nil {
+		return err
+	}
+
+	progress := o.Progress()
+	progress.Start("Fetching details from the StormForge API")
+	trial, err := o.bestTrial(ctx)
+	progress.Stop(err)
+	if err != nil {
+		return err
+	}
+
+	helmValues, err := o.helmValuesMapping()
+	if err != nil {
+		return err
+	}
+
+	values, err := helmValuesNode(helmValues, trial)
+	if err != nil {
+		return err
+	}
+
+	return o.YAMLWriter().Write([]*yaml.RNode{values})
+}
+
+// bestTrial returns the cluster representation of the best trial of the experiment.
+func (o *HelmValuesOptions) bestTrial(ctx context.Context) (*optimizev1beta2.Trial, error) {
+	exp, err := o.ExperimentsAPI.GetExperimentByName(ctx, experimentsv1alpha1.ExperimentName(o.experimentName))
+	if err != nil {
+		return nil, err
+	}
+	if exp.Link(api.RelationTrials) == "" {
+		return nil, fmt.Errorf("unable to find trials for experiment")
+	}
+
+	query := experimentsv1alpha1.TrialListQuery{}
+	query.SetStatus(experimentsv1alpha1.TrialCompleted)
+	trialList, err := o.ExperimentsAPI.GetAllTrials(ctx, exp.Link(api.RelationTrials), query)
+	if err != nil {
+		return nil, err
+	}
+
+	best := bestTrial(exp.Metrics, trialList.Trials)
+	if best == nil {
+		return nil, fmt.Errorf("unable to find a completed trial for experiment %q", o.experimentName)
+	}
+
+	if err := o.extractExperiment(&trialDetails{Experiment: o.experimentName}); err != nil {
+		return nil, fmt.Errorf("got an error when looking for experiment: %w", err)
+	}
+
+	trial := &optimizev1beta2.Trial{}
+	if o.experiment != nil {
+		experiment.PopulateTrialFromTemplate(o.experiment, trial)
+	}
+	server.ToClusterTrial(trial, &best.TrialAssignments)
+	return trial, nil
+}
+
+// helmValuesMapping returns the Helm values used to map trial assignments, either from the mapping file or from the
+// setup tasks of the experiment.
+func (o *HelmValuesOptions) helmValuesMapping() ([]optimizev1beta2.HelmValue, error) {
+	var helmValues []optimizev1beta2.HelmValue
+
+	if o.mappingFile != "" {
+		r, err := o.IOStreams.OpenFile(o.mappingFile)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := sigsyaml.Unmarshal(data, &helmValues); err != nil {
+			return nil, fmt.Errorf("invalid Helm values mapping: %w", err)
+		}
+
+		return helmValues, nil
+	}
+
+	if o.experiment != nil {
+		for _, task := range o.experiment.Spec.TrialTemplate.Spec.SetupTasks {
+			if task.HelmChart != "" {
+				helmValues = append(helmValues, task.HelmValues...)
+			}
+		}
+	}
+
+	if len(helmValues) == 0 {
+		return nil, fmt.Errorf("unable to find Helm values for experiment %q, use --mapping to supply them", o.experimentName)
+	}
+
+	return helmValues, nil
+}
+
+// bestTrial returns the completed trial with the best value for the first optimized metric.
+func bestTrial(metrics []experimentsv1alpha1.Metric, trials []experimentsv1alpha1.TrialItem) *experimentsv1alpha1.TrialItem {
+	for _, m := range metrics {
+		if m.Optimize != nil && !*m.Optimize {
+			continue
+		}
+
+		var best *experimentsv1alpha1.TrialItem
+		var bestValue float64
+		for i := range trials {
+			if trials[i].Status != experimentsv1alpha1.TrialCompleted {
+				continue
+			}
+
+			for _, v := range trials[i].Values {
+				if v.MetricName != m.Name {
+					continue
+				}
+				if best == nil || (m.Minimize && v.Value < bestValue) || (!m.Minimize && v.Value > bestValue) {
+					best, bestValue = &trials[i], v.Value
+				}
+			}
+		}
+		return best
+	}
+	return nil
+}
+
+// helmValuesNode returns a values.yaml document containing the evaluated Helm values.
+func helmValuesNode(helmValues []optimizev1beta2.HelmValue, t *optimizev1beta2.Trial) (*yaml.RNode, error) {
+	te := template.New()
+	values := yaml.NewMapRNode(nil)
+	for i := range helmValues {
+		hv := &helmValues[i]
+
+		var value *yaml.RNode
+		switch {
+		case hv.ValueFrom != nil && hv.ValueFrom.ParameterRef != nil:
+			v, ok := t.GetAssignment(hv.ValueFrom.ParameterRef.Name)
+			if !ok {
+				return nil, fmt.Errorf("invalid parameter reference '%s' for Helm value '%s'", hv.ValueFrom.ParameterRef.Name, hv.Name)
+			}
+			if v.Type == intstr.String {
+				value = helmValueNode(v.StrVal, hv.ForceString)
+			} else {
+				value = helmValueNode(strconv.Itoa(int(v.IntVal)), hv.ForceString)
+			}
+
+		case hv.ValueFrom != nil:
+			return nil, fmt.Errorf("unknown source for Helm value '%s'", hv.Name)
+
+		default:
+			v, err := te.RenderHelmValue(hv, t)
+			if err != nil {
+				return nil, err
+			}
+			value = helmValueNode(v, hv.ForceString)
+		}
+
+		path := strings.Split(hv.Name, ".")
+		if err := values.PipeE(
+			yaml.PathGetter{Path: path[:len(path)-1], Create: yaml.MappingNode},
+			yaml.SetField(path[len(path)-1], value),
+		); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// helmValueNode returns a scalar node for a Helm value, the type is inferred unless it is forced to be a string.
+func helmValueNode(value string, forceString bool) *yaml.RNode {
+	if forceString {
+		return yaml.NewStringRNode(value)
+	}
+	return yaml.NewScalarRNode(value)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBestTrial(t *testing.T) {
+	newTrial := func(number int64, status experimentsv1alpha1.TrialStatus, cost float64) experimentsv1alpha1.TrialItem {
+		return experimentsv1alpha1.TrialItem{
+			Number: number,
+			Status: status,
+			TrialValues: experimentsv1alpha1.TrialValues{
+				Values: []experimentsv1alpha1.Value{{MetricName: "cost", Value: cost}},
+			},
+		}
+	}
+
+	trials := []experimentsv1alpha1.TrialItem{
+		newTrial(1, experimentsv1alpha1.TrialCompleted, 10),
+		newTrial(2, experimentsv1alpha1.TrialCompleted, 5),
+		newTrial(3, experimentsv1alpha1.TrialFailed, 1),
+		newTrial(4, experimentsv1alpha1.TrialCompleted, 20),
+	}
+
+	cases := []struct {
+		desc     string
+		metrics  []experimentsv1alpha1.Metric
+		expected int64
+	}{
+		{
+			desc:     "minimize",
+			metrics:  []experimentsv1alpha1.Metric{{Name: "cost", Minimize: true}},
+			expected: 2,
+		},
+		{
+			desc:     "maximize",
+			metrics:  []experimentsv1alpha1.Metric{{Name: "cost"}},
+			expected: 4,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			best := bestTrial(c.metrics, trials)
+			if assert.NotNil(t, best) {
+				assert.Equal(t, c.expected, best.Number)
+			}
+		})
+	}
+}
+
+func TestHelmValuesNode(t *testing.T) {
+	trial := &optimizev1beta2.Trial{}
+	trial.Spec.Assignments = []optimizev1beta2.Assignment{
+		{Name: "cpu", Value: intstr.FromInt(500)},
+		{Name: "mode", Value: intstr.FromString("fast")},
+	}
+
+	cases := []struct {
+		desc        string
+		helmValues  []optimizev1beta2.HelmValue
+		expected    string
+		expectedErr string
+	}{
+		{
+			desc: "parameter references",
+			helmValues: []optimizev1beta2.HelmValue{
+				{Name: "resources.requests.cpu", ValueFrom: &optimizev1beta2.HelmValueSource{ParameterRef: &optimizev1beta2.ParameterSelector{Name: "cpu"}}},
+				{Name: "mode", ValueFrom: &optimizev1beta2.HelmValueSource{ParameterRef: &optimizev1beta2.ParameterSelector{Name: "mode"}}},
+			},
+			expected: "resources:\n  requests:\n    cpu: 500\nmode: fast\n",
+		},
+		{
+			desc: "templates",
+			helmValues: []optimizev1beta2.HelmValue{
+				{Name: "resources.limits.cpu", Value: intstr.FromString("{{ .Values.cpu }}m")},
+				{Name: "replicas", Value: intstr.FromString("{{ .Values.cpu }}"), ForceString: true},
+			},
+			expected: "resources:\n  limits:\n    cpu: 500m\nreplicas: \"500\"\n",
+		},
+		{
+			desc: "missing parameter",
+			helmValues: []optimizev1beta2.HelmValue{
+				{Name: "memory", ValueFrom: &optimizev1beta2.HelmValueSource{ParameterRef: &optimizev1beta2.ParameterSelector{Name: "memory"}}},
+			},
+			expectedErr: "invalid parameter reference 'memory' for Helm value 'memory'",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			values, err := helmValuesNode(c.helmValues, trial)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			actual, err := values.String()
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-controller/v2/internal/patch"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	trialutil "github.com/thestormforge/optimize-controller/v2/internal/trial"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// NewRunbookCommand creates a command for exporting the manifests needed to reproduce a trial
+func NewRunbookCommand(o *Options) *cobra.Command {
+	var trialNumber int64
+
+	cmd := &cobra.Command{
+		Use:   "runbook EXPERIMENT_NAME",
+		Short: "Export the manifests to reproduce a trial",
+		Long:  "Export the patched resources and trial job needed to reproduce a trial without the controller",
+		Args:  cobra.ExactArgs(1),
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			commander.SetStreams(&o.IOStreams, cmd)
+
+			if trialNumber < 0 {
+				return fmt.Errorf("a trial number must be specified")
+			}
+			o.trialName = fmt.Sprintf("%s-%03d", args[0], trialNumber)
+			o.runbook = true
+
+			if o.ExperimentsAPI == nil {
+				return commander.SetExperimentsAPI(&o.ExperimentsAPI, o.Config, cmd)
+			}
+			return nil
+		},
+		RunE: commander.WithContextE(o.runner),
+	}
+
+	cmd.Flags().Int64Var(&trialNumber, "trial", -1, "the `number` of the trial to reproduce")
+	cmd.Flags().StringSliceVarP(&o.inputFiles, "filename", "f", nil, "experiment and related manifest `files` to export, - for stdin")
+
+	_ = cmd.MarkFlagFilename("filename", "yml", "yaml")
+	_ = cmd.MarkFlagRequired("trial")
+
+	return cmd
+}
+
+// writeRunbook writes the patched resources along with the trial job, excluding any Optimize resources
+// which would require the controller to be installed.
+func (o *Options) writeRunbook(yamls []byte) error {
+	job, err := o.runbookJob()
+	if err != nil {
+		return err
+	}
+
+	return kio.Pipeline{
+		Inputs: []kio.Reader{
+			&kio.ByteReader{Reader: bytes.NewReader(yamls)},
+			&kio.PackageBuffer{Nodes: []*yaml.RNode{job}},
+		},
+		Filters: []kio.Filter{kio.FilterFunc(excludeOptimizeResources)},
+		Outputs: []kio.Writer{o.YAMLWriter()},
+	}.Execute()
+}
+
+// runbookJob returns the trial job, including any patches the experiment applies to it.
+func (o *Options) runbookJob() (*yaml.RNode, error) {
+	if o.trial == nil {
+		return nil, fmt.Errorf("unable to find trial %q", o.trialName)
+	}
+
+	// Patches targeting the trial job are normally applied by the controller when the job is created
+	te := template.New()
+	for i := range o.experiment.Spec.Patches {
+		p := &o.experiment.Spec.Patches[i]
+		ref, data, err := patch.RenderTemplate(te, o.trial, p)
+		if err != nil {
+			return nil, err
+		}
+		if !trialutil.IsTrialJobReference(o.trial, ref) {
+			continue
+		}
+
+		po, err := patch.CreatePatchOperation(o.trial, p, ref, data)
+		if err != nil {
+			return nil, err
+		}
+		if po != nil {
+			o.trial.Status.PatchOperations = append(o.trial.Status.PatchOperations, *po)
+		}
+	}
+
+	job := trialutil.NewJob(o.trial)
+	job.SetGroupVersionKind(batchv1.SchemeGroupVersion.WithKind("Job"))
+
+	data, err := sigsyaml.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := yaml.Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// The job has never been created so there is no status or creation time to report
+	if err := node.PipeE(yaml.Clear("status")); err != nil {
+		return nil, err
+	}
+	if err := node.PipeE(yaml.Lookup("metadata"), yaml.Clear("creationTimestamp")); err != nil {
+		return nil, err
+	}
+	if err := node.PipeE(yaml.Lookup("spec", "template", "metadata"), yaml.Clear("creationTimestamp")); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// excludeOptimizeResources removes any resources that can only be handled by the Optimize Controller.
+func excludeOptimizeResources(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	result := make([]*yaml.RNode, 0, len(nodes))
+	for _, node := range nodes {
+		m, err := node.GetMeta()
+		if err != nil {
+			return nil, err
+		}
+
+		switch schema.FromAPIVersionAndKind(m.APIVersion, m.Kind).Group {
+		case optimizev1beta2.GroupVersion.Group, optimizeappsv1alpha1.GroupVersion.Group:
+			continue
+		}
+
+		result = append(result, node)
+	}
+	return result, nil
+}
@@ -18,6 +18,7 @@ package generate
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"unicode"
@@ -25,9 +26,11 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/konjure/pkg/konjure"
 	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
 	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
 	"github.com/thestormforge/optimize-go/pkg/config"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
 )
@@ -40,8 +43,10 @@ type ExperimentOptions struct {
 
 	Generator experiment.Generator
 
-	Filename  string
-	Resources []string
+	Filename        string
+	Resources       []string
+	Policies        []string
+	PrometheusToken string
 }
 
 // Other possible options:
@@ -66,16 +71,51 @@ func NewExperimentCommand(o *ExperimentOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&o.Filename, "filename", "f", o.Filename, "file that contains the application definition")
 	cmd.Flags().StringArrayVarP(&o.Resources, "resources", "r", nil, "additional resources to consider")
 	cmd.Flags().StringVar(&o.Generator.ExperimentName, "name", o.Generator.ExperimentName, "override the experiment `name`")
-	cmd.Flags().StringVarP(&o.Generator.Scenario, "scenario", "s", o.Generator.Scenario, "the application scenario to generate an experiment for")
+	cmd.Flags().StringVarP(&o.Generator.Scenario, "scenario", "s", o.Generator.Scenario, "the application scenario to generate an experiment for, a comma separated list or \"*\" generates multiple experiments")
+	cmd.Flags().BoolVar(&o.Generator.CombineScenarios, "combine-scenarios", false, "combine multiple scenarios into a single experiment")
 	cmd.Flags().StringVar(&o.Generator.Objective, "objective", o.Generator.Objective, "the application objective to generate an experiment for")
 	cmd.Flags().BoolVar(&o.Generator.IncludeApplicationResources, "include-resources", false, "include the application resources in the output")
+	cmd.Flags().StringArrayVar(&o.Policies, "policy", nil, "`file` containing an optimization policy the experiment must conform to")
+	cmd.Flags().StringVar(&o.Generator.PrometheusURL, "prometheus-url", o.Generator.PrometheusURL, "`url` of an existing Prometheus to query instead of installing one")
+	cmd.Flags().BoolVar(&o.Generator.NamespacedRBAC, "namespaced-rbac", false, "grant setup permissions using roles, cluster roles are only used for permissions that require them")
+	cmd.Flags().BoolVar(&o.Generator.Exporters, "exporters", false, "install kube-state-metrics for the built-in Prometheus, container usage is still read from cAdvisor")
+	cmd.Flags().StringVar(&o.PrometheusToken, "prometheus-token-secret", o.PrometheusToken, "secret `name:key` containing a bearer token for the existing Prometheus")
 
 	_ = cmd.MarkFlagFilename("filename", "yml", "yaml")
+	_ = cmd.MarkFlagFilename("policy", "yml", "yaml")
 
 	return cmd
 }
 
 func (o *ExperimentOptions) generate() error {
+	if o.PrometheusToken != "" {
+		name, key := o.PrometheusToken, "token"
+		if pos := strings.LastIndex(name, ":"); pos > 0 {
+			name, key = name[0:pos], name[pos+1:]
+		}
+		if o.Generator.PrometheusURL == "" {
+			return fmt.Errorf("a Prometheus URL is required when specifying a token secret")
+		}
+		o.Generator.PrometheusBearerTokenSecretRef = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			Key:                  key,
+		}
+	}
+
+	for _, filename := range o.Policies {
+		r, err := o.IOStreams.OpenFile(filename)
+		if err != nil {
+			return err
+		}
+
+		policy := optimizev1beta2.OptimizationPolicy{}
+		rr := commander.NewResourceReader()
+		if err := rr.ReadInto(r, &policy); err != nil {
+			return err
+		}
+		o.Generator.Policies = append(o.Generator.Policies, policy)
+	}
+
 	if o.Filename != "" {
 		r, err := o.IOStreams.OpenFile(o.Filename)
 		if err != nil {
@@ -201,15 +201,22 @@ func (o *GeneratorOptions) generateClusterRole(roleRef *rbacv1.RoleRef) *rbacv1.
 			// In-Cluster Generation RBAC
 			// // This is necessary to create the roles for the prometheus service account
 			rbacv1.PolicyRule{
-				Verbs:     []string{"get", "list", "watch", "create", "update", "delete"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
 				APIGroups: []string{"rbac.authorization.k8s.io"},
-				Resources: []string{"clusterroles", "clusterrolebindings"},
+				Resources: []string{"clusterroles", "clusterrolebindings", "roles", "rolebindings"},
+			},
+
+			// // This is necessary to create the target namespace of an experiment
+			rbacv1.PolicyRule{
+				Verbs:     []string{"get", "create", "delete"},
+				APIGroups: []string{""},
+				Resources: []string{"namespaces"},
 			},
 
 			// // _may_ be able to drop secrets from this
 			// // This is necessary to create the prometheus service account and configuration
 			rbacv1.PolicyRule{
-				Verbs:     []string{"get", "list", "watch", "create", "update", "delete"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
 				APIGroups: []string{""},
 				Resources: []string{"serviceaccounts", "configmaps", "secrets", "services"},
 			},
@@ -218,14 +225,14 @@ func (o *GeneratorOptions) generateClusterRole(roleRef *rbacv1.RoleRef) *rbacv1.
 			// // _may_ be able to drop extensions?
 			// // This is necessary to create the prometheus deployment
 			rbacv1.PolicyRule{
-				Verbs:     []string{"get", "list", "watch", "create", "update", "delete"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
 				APIGroups: []string{"apps", "extensions"},
 				Resources: []string{"deployments", "statefulsets"},
 			},
 
 			// // This is necessary to create the roles for the prometheus service account
 			rbacv1.PolicyRule{
-				Verbs:     []string{"get", "list", "watch", "create", "update", "delete"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
 				APIGroups: []string{"optimize.stormforge.io"},
 				Resources: []string{"experiments"},
 			},
@@ -75,7 +75,12 @@ func (o *Options) Initialize(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
-		if err := kubectlWait.Run(); err != nil {
+		progress := o.Progress()
+		progress.Start("Waiting for the custom resource definitions to be established")
+		kubectlWait.Stdout = io.Discard
+		err = kubectlWait.Run()
+		progress.Stop(err)
+		if err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,277 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Options are the configuration options for printing trial logs
+type Options struct {
+	// Config is the Optimize Configuration used to access the cluster
+	Config *config.OptimizeConfig
+	// IOStreams are used to access the standard process streams
+	commander.IOStreams
+
+	Namespace      string
+	ExperimentName string
+	TrialNumber    int64
+	Setup          bool
+	Job            bool
+	Target         bool
+}
+
+// NewCommand creates a new command for printing trial logs
+func NewCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs EXPERIMENT_NAME",
+		Short: "Print the logs for a trial",
+		Long:  "Print the interleaved logs of the setup tasks, trial job and patched workloads of a trial",
+		Args:  cobra.ExactArgs(1),
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			commander.SetStreams(&o.IOStreams, cmd)
+
+			// Allow the trial number to be part of the name, e.g. "my-exp-005"
+			o.ExperimentName = args[0]
+			if o.TrialNumber < 0 {
+				name, num := experimentsv1alpha1.SplitTrialName(args[0])
+				if num < 0 {
+					return fmt.Errorf("a trial number is required")
+				}
+				o.ExperimentName, o.TrialNumber = name.String(), num
+			}
+
+			// Default to the logs produced by the trial itself
+			if !o.Setup && !o.Job && !o.Target {
+				o.Setup, o.Job = true, true
+			}
+
+			return nil
+		},
+		RunE: commander.WithContextE(o.logs),
+	}
+
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", "", "experiment `namespace` in the cluster")
+	cmd.Flags().Int64Var(&o.TrialNumber, "trial", -1, "trial `number` to print the logs for")
+	cmd.Flags().BoolVar(&o.Setup, "setup", false, "include the logs from the setup tasks")
+	cmd.Flags().BoolVar(&o.Job, "job", false, "include the logs from the trial job")
+	cmd.Flags().BoolVar(&o.Target, "target", false, "include the logs from the patched workloads")
+
+	return cmd
+}
+
+func (o *Options) logs(ctx context.Context) error {
+	t, err := o.getTrial(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Logs from the trial namespace are filtered using the trial labels
+	var lines []logLine
+	if o.Setup {
+		l, err := o.getLogs(ctx, t.Namespace, "--selector", trialSelector(t, "trialSetup"))
+		if err != nil {
+			return err
+		}
+		lines = append(lines, l...)
+	}
+
+	if o.Job {
+		l, err := o.getLogs(ctx, t.Namespace, "--selector", trialSelector(t, "trialRun"))
+		if err != nil {
+			return err
+		}
+		lines = append(lines, l...)
+	}
+
+	// Logs from the patched workloads are restricted to the time the trial was running
+	if o.Target && t.Status.StartTime != nil {
+		for i := range t.Status.PatchOperations {
+			ref := &t.Status.PatchOperations[i].TargetRef
+			if ref.Name == "" {
+				continue
+			}
+
+			namespace := ref.Namespace
+			if namespace == "" {
+				namespace = t.Namespace
+			}
+
+			l, err := o.getLogs(ctx, namespace, fmt.Sprintf("%s/%s", strings.ToLower(ref.Kind), ref.Name))
+			if err != nil {
+				return err
+			}
+			for _, line := range l {
+				if line.Time.Before(t.Status.StartTime.Time) {
+					continue
+				}
+				if t.Status.CompletionTime != nil && line.Time.After(t.Status.CompletionTime.Time) {
+					continue
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	return writeLogs(o.Out, lines)
+}
+
+// getTrial returns the in-cluster trial for the requested experiment and trial number.
+func (o *Options) getTrial(ctx context.Context) (*optimizev1beta2.Trial, error) {
+	args := []string{"get", "experiment", o.ExperimentName, "--output", "json"}
+	if o.Namespace != "" {
+		args = append(args, "--namespace", o.Namespace)
+	}
+
+	exp := &optimizev1beta2.Experiment{}
+	if err := o.kubectlJSON(ctx, exp, args...); err != nil {
+		return nil, err
+	}
+
+	// Trials may be in other namespaces if the experiment uses a namespace selector or template
+	args = []string{"get", "trials", "--selector", metav1.FormatLabelSelector(exp.TrialSelector()), "--output", "json"}
+	if exp.Spec.NamespaceSelector != nil || exp.Spec.NamespaceTemplate != nil {
+		args = append(args, "--all-namespaces")
+	} else {
+		args = append(args, "--namespace", exp.Namespace)
+	}
+
+	trialList := &optimizev1beta2.TrialList{}
+	if err := o.kubectlJSON(ctx, trialList, args...); err != nil {
+		return nil, err
+	}
+
+	for i := range trialList.Items {
+		if _, num := experimentsv1alpha1.SplitTrialName(trialList.Items[i].Name); num == o.TrialNumber {
+			return &trialList.Items[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to find trial %d for experiment %q", o.TrialNumber, exp.Name)
+}
+
+// getLogs returns the time stamped logs for all containers of the selected pods.
+func (o *Options) getLogs(ctx context.Context, namespace string, arg ...string) ([]logLine, error) {
+	args := append([]string{
+		"logs",
+		"--namespace", namespace,
+		"--all-containers",
+		"--prefix",
+		"--timestamps",
+		"--ignore-errors",
+	}, arg...)
+
+	cmd, err := o.Config.Kubectl(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get logs, %w", err)
+	}
+
+	return readLogs(bytes.NewReader(output))
+}
+
+// kubectlJSON runs a kubectl command and decodes the JSON output.
+func (o *Options) kubectlJSON(ctx context.Context, obj interface{}, arg ...string) error {
+	cmd, err := o.Config.Kubectl(ctx, arg...)
+	if err != nil {
+		return err
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(output, obj)
+}
+
+// trialSelector returns the label selector for the pods with the specified role in the trial.
+func trialSelector(t *optimizev1beta2.Trial, role string) string {
+	return labels.SelectorFromSet(map[string]string{
+		optimizev1beta2.LabelTrial:     t.Name,
+		optimizev1beta2.LabelTrialRole: role,
+	}).String()
+}
+
+// logLine is a single line of log output.
+type logLine struct {
+	Time    time.Time
+	Prefix  string
+	Message string
+}
+
+// readLogs parses the output of `kubectl logs --prefix --timestamps`.
+func readLogs(r io.Reader) ([]logLine, error) {
+	var lines []logLine
+	var lastTime time.Time
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// Lines without a timestamp are treated as a continuation of the previous line
+		line := logLine{Time: lastTime, Message: scanner.Text()}
+
+		// Strip the `[pod/name/container]` prefix
+		if strings.HasPrefix(line.Message, "[") {
+			if pos := strings.Index(line.Message, "] "); pos > 0 {
+				line.Prefix, line.Message = line.Message[:pos+1], line.Message[pos+2:]
+			}
+		}
+
+		// Strip the timestamp
+		if pos := strings.IndexByte(line.Message, ' '); pos > 0 {
+			if ts, err := time.Parse(time.RFC3339Nano, line.Message[:pos]); err == nil {
+				line.Time, line.Message = ts, line.Message[pos+1:]
+			}
+		}
+		lastTime = line.Time
+
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// writeLogs interleaves the log lines by time.
+func writeLogs(w io.Writer, lines []logLine) error {
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Time.Before(lines[j].Time) })
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(w, "%s %s %s\n", line.Time.Format(time.RFC3339Nano), line.Prefix, line.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
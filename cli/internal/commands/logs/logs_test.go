@@ -0,0 +1,53 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterleaveLogs(t *testing.T) {
+	setup := `[pod/setup-abc/setup] 2021-06-01T10:00:00Z creating
+[pod/setup-abc/setup] 2021-06-01T10:00:03Z created
+`
+	job := `[pod/trial-xyz/load] 2021-06-01T10:00:05Z starting
+[pod/trial-xyz/load] 2021-06-01T10:00:01Z early
+[pod/trial-xyz/load] continued
+`
+
+	setupLines, err := readLogs(strings.NewReader(setup))
+	if !assert.NoError(t, err) {
+		return
+	}
+	jobLines, err := readLogs(strings.NewReader(job))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var out strings.Builder
+	if assert.NoError(t, writeLogs(&out, append(setupLines, jobLines...))) {
+		assert.Equal(t, `2021-06-01T10:00:00Z [pod/setup-abc/setup] creating
+2021-06-01T10:00:01Z [pod/trial-xyz/load] early
+2021-06-01T10:00:01Z [pod/trial-xyz/load] continued
+2021-06-01T10:00:03Z [pod/setup-abc/setup] created
+2021-06-01T10:00:05Z [pod/trial-xyz/load] starting
+`, out.String())
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pause
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+// Options is the configuration for pausing or resuming experiments
+type Options struct {
+	// Config is the Optimize Configuration used to access the cluster
+	Config *config.OptimizeConfig
+	// IOStreams are used to access the standard process streams
+	commander.IOStreams
+
+	// Paused is the desired state of the experiments
+	Paused bool
+	// Names are the experiments to update
+	Names []string
+}
+
+// NewPauseCommand creates a command for pausing experiments
+func NewPauseCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pause EXPERIMENT_NAME...",
+		Short: "Stop creating new trials",
+		Long:  "Pause experiments in the cluster, active trials are allowed to finish but no new trials are created",
+
+		Args: cobra.MinimumNArgs(1),
+
+		PreRun: func(cmd *cobra.Command, args []string) {
+			commander.SetStreams(&o.IOStreams, cmd)
+			o.Paused = true
+			o.Names = args
+		},
+		RunE: commander.WithContextE(o.annotate),
+	}
+
+	return cmd
+}
+
+// NewResumeCommand creates a command for resuming paused experiments
+func NewResumeCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resume EXPERIMENT_NAME...",
+		Short: "Resume creating new trials",
+		Long:  "Resume experiments in the cluster that were previously paused",
+
+		Args: cobra.MinimumNArgs(1),
+
+		PreRun: func(cmd *cobra.Command, args []string) {
+			commander.SetStreams(&o.IOStreams, cmd)
+			o.Paused = false
+			o.Names = args
+		},
+		RunE: commander.WithContextE(o.annotate),
+	}
+
+	return cmd
+}
+
+func (o *Options) annotate(ctx context.Context) error {
+	annotate, err := o.Config.Kubectl(ctx, o.annotateArgs()...)
+	if err != nil {
+		return err
+	}
+	annotate.Stdout = o.Out
+	annotate.Stderr = o.ErrOut
+	return annotate.Run()
+}
+
+// annotateArgs returns the kubectl arguments used to update the paused annotation.
+func (o *Options) annotateArgs() []string {
+	args := []string{"annotate", "experiments"}
+	args = append(args, o.Names...)
+	if o.Paused {
+		return append(args, "--overwrite", optimizev1beta2.AnnotationPaused+"=true")
+	}
+	return append(args, optimizev1beta2.AnnotationPaused+"-")
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pause
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotateArgs(t *testing.T) {
+	cases := []struct {
+		desc     string
+		options  Options
+		expected []string
+	}{
+		{
+			desc:     "pause",
+			options:  Options{Paused: true, Names: []string{"foo", "bar"}},
+			expected: []string{"annotate", "experiments", "foo", "bar", "--overwrite", "stormforge.io/paused=true"},
+		},
+		{
+			desc:     "resume",
+			options:  Options{Names: []string{"foo"}},
+			expected: []string{"annotate", "experiments", "foo", "stormforge.io/paused-"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.options.annotateArgs())
+		})
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prune
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-go/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// prunableTypes are the resource types the controller labels while running trials
+var prunableTypes = []string{
+	"jobs",
+	"configmaps",
+	"serviceaccounts",
+	"roles",
+	"rolebindings",
+	"clusterroles",
+	"clusterrolebindings",
+	"namespaces",
+}
+
+// Options is the configuration for pruning orphaned trial resources
+type Options struct {
+	// Config is the Optimize Configuration used to access the cluster
+	Config *config.OptimizeConfig
+	// IOStreams are used to access the standard process streams
+	commander.IOStreams
+
+	// DryRun only prints the resources that would be deleted
+	DryRun bool
+}
+
+// NewCommand creates a command for pruning orphaned trial resources
+func NewCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete orphaned trial resources",
+		Long: "Delete trial jobs, config maps, RBAC objects (including the cluster roles and bindings of generated " +
+			"experiments) and namespaces labeled for a trial or experiment which no longer exists",
+
+		PreRun: commander.StreamsPreRun(&o.IOStreams),
+		RunE:   commander.WithContextE(o.prune),
+	}
+
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "only print the resources that would be deleted")
+
+	return cmd
+}
+
+func (o *Options) prune(ctx context.Context) error {
+	owners, err := o.list(ctx, "experiments,trials")
+	if err != nil {
+		return err
+	}
+
+	items, err := o.list(ctx, strings.Join(prunableTypes, ","),
+		"--selector", optimizev1beta2.LabelExperiment+","+optimizev1beta2.LabelTrialRole)
+	if err != nil {
+		return err
+	}
+
+	orphans := orphaned(items, owners)
+	if len(orphans) == 0 {
+		_, _ = fmt.Fprintln(o.Out, "No orphaned resources found")
+		return nil
+	}
+
+	// Delete orphans one namespace at a time, cluster scoped resources (e.g. namespaces) go last
+	byNamespace := make(map[string][]string)
+	var namespaces []string
+	for _, item := range orphans {
+		if _, ok := byNamespace[item.Namespace]; !ok {
+			namespaces = append(namespaces, item.Namespace)
+		}
+		byNamespace[item.Namespace] = append(byNamespace[item.Namespace], resourceName(item))
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		if namespaces[i] == "" || namespaces[j] == "" {
+			return namespaces[j] == ""
+		}
+		return namespaces[i] < namespaces[j]
+	})
+
+	for _, ns := range namespaces {
+		args := []string{"delete", "--ignore-not-found", "--wait=false"}
+		if ns != "" {
+			args = append(args, "--namespace", ns)
+		}
+		if o.DryRun {
+			args = append(args, "--dry-run=client")
+		}
+		args = append(args, byNamespace[ns]...)
+
+		del, err := o.Config.Kubectl(ctx, args...)
+		if err != nil {
+			return err
+		}
+		del.Stdout = o.Out
+		del.Stderr = o.ErrOut
+		if err := del.Run(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// list returns the metadata for all of the resources of the specified types.
+func (o *Options) list(ctx context.Context, types string, args ...string) ([]metav1.PartialObjectMetadata, error) {
+	get, err := o.Config.Kubectl(ctx, append([]string{"get", types, "--all-namespaces", "--output", "json"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	get.Stderr = o.ErrOut
+
+	out, err := get.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	list := &metav1.PartialObjectMetadataList{}
+	if err := json.Unmarshal(out, list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// orphaned returns the items whose owning trial or experiment is not present in the list of owners.
+func orphaned(items, owners []metav1.PartialObjectMetadata) []metav1.PartialObjectMetadata {
+	// Experiment names are recorded without a namespace, e.g. a trial namespace is cluster scoped
+	experiments := make(map[string]bool)
+	trials := make(map[string]bool)
+	for _, owner := range owners {
+		switch owner.Kind {
+		case "Experiment":
+			experiments[owner.Name] = true
+		case "Trial":
+			trials[owner.Namespace+"/"+owner.Name] = true
+		}
+	}
+
+	var result []metav1.PartialObjectMetadata
+	for _, item := range items {
+		if trialName := item.Labels[optimizev1beta2.LabelTrial]; trialName != "" && item.Namespace != "" {
+			if !trials[item.Namespace+"/"+trialName] {
+				result = append(result, item)
+			}
+			continue
+		}
+
+		if expName := item.Labels[optimizev1beta2.LabelExperiment]; expName != "" && !experiments[expName] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// resourceName returns the `TYPE.GROUP/NAME` argument used to identify an item to kubectl.
+func resourceName(item metav1.PartialObjectMetadata) string {
+	kind := strings.ToLower(item.Kind)
+	if group := item.GroupVersionKind().Group; group != "" {
+		kind += "." + group
+	}
+	return kind + "/" + item.Name
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prune
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOrphaned(t *testing.T) {
+	obj := func(apiVersion, kind, namespace, name string, labels map[string]string) metav1.PartialObjectMetadata {
+		return metav1.PartialObjectMetadata{
+			TypeMeta:   metav1.TypeMeta{APIVersion: apiVersion, Kind: kind},
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		}
+	}
+	labels := func(exp, trial string) map[string]string {
+		l := map[string]string{optimizev1beta2.LabelExperiment: exp, optimizev1beta2.LabelTrialRole: "trialRun"}
+		if trial != "" {
+			l[optimizev1beta2.LabelTrial] = trial
+		}
+		return l
+	}
+
+	owners := []metav1.PartialObjectMetadata{
+		obj("optimize.stormforge.io/v1beta2", "Experiment", "default", "exp", nil),
+		obj("optimize.stormforge.io/v1beta2", "Trial", "default", "exp-001", nil),
+	}
+
+	cases := []struct {
+		desc     string
+		items    []metav1.PartialObjectMetadata
+		expected []string
+	}{
+		{
+			desc: "no orphans",
+			items: []metav1.PartialObjectMetadata{
+				obj("batch/v1", "Job", "default", "exp-001", labels("exp", "exp-001")),
+				obj("v1", "Namespace", "", "exp-abcde", labels("exp", "")),
+			},
+		},
+		{
+			desc: "missing trial",
+			items: []metav1.PartialObjectMetadata{
+				obj("batch/v1", "Job", "default", "exp-001", labels("exp", "exp-001")),
+				obj("batch/v1", "Job", "default", "exp-002", labels("exp", "exp-002")),
+			},
+			expected: []string{"job.batch/exp-002"},
+		},
+		{
+			desc: "trial in another namespace",
+			items: []metav1.PartialObjectMetadata{
+				obj("batch/v1", "Job", "other", "exp-001", labels("exp", "exp-001")),
+			},
+			expected: []string{"job.batch/exp-001"},
+		},
+		{
+			desc: "missing experiment",
+			items: []metav1.PartialObjectMetadata{
+				obj("v1", "Namespace", "", "gone-abcde", labels("gone", "")),
+				obj("rbac.authorization.k8s.io/v1", "Role", "gone-abcde", "optimize-setup-role", labels("gone", "")),
+			},
+			expected: []string{"namespace/gone-abcde", "role.rbac.authorization.k8s.io/optimize-setup-role"},
+		},
+		{
+			desc: "cluster role binding",
+			items: []metav1.PartialObjectMetadata{
+				obj("rbac.authorization.k8s.io/v1", "ClusterRoleBinding", "", "optimize-setup-prometheus-1234567", labels("gone", "")),
+				obj("rbac.authorization.k8s.io/v1", "ClusterRoleBinding", "", "optimize-setup-prometheus-abcdefg", labels("exp", "")),
+			},
+			expected: []string{"clusterrolebinding.rbac.authorization.k8s.io/optimize-setup-prometheus-1234567"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var actual []string
+			for _, item := range orphaned(c.items, owners) {
+				actual = append(actual, resourceName(item))
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
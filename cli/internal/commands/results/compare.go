@@ -0,0 +1,313 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+// CompareOptions are the options for comparing the results of two experiments
+type CompareOptions struct {
+	// Config is the Optimize Configuration
+	Config *config.OptimizeConfig
+	// ExperimentsAPI is used to interact with the Optimize Experiments API
+	ExperimentsAPI experimentsv1alpha1.API
+	// IOStreams are used to access the standard process streams
+	commander.IOStreams
+
+	ExperimentNames []string
+}
+
+// NewCompareCommand creates a new command for comparing experiment results
+func NewCompareCommand(o *CompareOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compare EXPERIMENT_A EXPERIMENT_B",
+		Short: "Compare experiment results",
+		Long:  "Compare the best trials and explored parameter space of two experiments sharing parameters and metrics",
+		Args:  cobra.ExactArgs(2),
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			o.ExperimentNames = args
+			commander.SetStreams(&o.IOStreams, cmd)
+			return commander.SetExperimentsAPI(&o.ExperimentsAPI, o.Config, cmd)
+		},
+		RunE: commander.WithContextE(o.compare),
+	}
+
+	return cmd
+}
+
+func (o *CompareOptions) compare(ctx context.Context) error {
+	_, a, err := getTrials(ctx, o.ExperimentsAPI, o.ExperimentNames[0], false)
+	if err != nil {
+		return err
+	}
+
+	_, b, err := getTrials(ctx, o.ExperimentsAPI, o.ExperimentNames[1], false)
+	if err != nil {
+		return err
+	}
+
+	c, err := newComparison(a, b)
+	if err != nil {
+		return err
+	}
+
+	return c.write(o.Out)
+}
+
+// comparison is the result of aligning the trials of two experiments.
+type comparison struct {
+	names     [2]string
+	objective string
+	best      [2]*experimentsv1alpha1.TrialItem
+	metrics   []metricComparison
+	overlap   []parameterOverlap
+}
+
+// metricComparison compares the best observed value of a single metric.
+type metricComparison struct {
+	metric experimentsv1alpha1.Metric
+	best   [2]*float64
+}
+
+// parameterOverlap describes how much of the explored space of a parameter is common to both experiments.
+type parameterOverlap struct {
+	name    string
+	overlap float64
+}
+
+// newComparison aligns the shared parameters and metrics of two experiments.
+func newComparison(a, b *experimentsv1alpha1.TrialList) (*comparison, error) {
+	c := &comparison{names: [2]string{a.Experiment.DisplayName, b.Experiment.DisplayName}}
+
+	for _, m := range a.Experiment.Metrics {
+		if !hasMetric(b.Experiment.Metrics, m.Name) {
+			continue
+		}
+
+		mc := metricComparison{metric: m}
+		for i, tl := range []*experimentsv1alpha1.TrialList{a, b} {
+			if t := bestTrial(tl.Trials, m); t != nil {
+				v, _ := strconv.ParseFloat(value(t, m.Name), 64)
+				mc.best[i] = &v
+			}
+		}
+		c.metrics = append(c.metrics, mc)
+
+		// The best trials are selected using the first shared objective
+		if c.objective == "" && (m.Optimize == nil || *m.Optimize) {
+			c.objective = m.Name
+			c.best[0] = bestTrial(a.Trials, m)
+			c.best[1] = bestTrial(b.Trials, m)
+		}
+	}
+
+	if len(c.metrics) == 0 {
+		return nil, fmt.Errorf("experiments %q and %q do not share any metrics", c.names[0], c.names[1])
+	}
+
+	for _, p := range a.Experiment.Parameters {
+		if hasParameter(b.Experiment.Parameters, p.Name) {
+			c.overlap = append(c.overlap, parameterOverlap{name: p.Name, overlap: overlap(a.Trials, b.Trials, p.Name)})
+		}
+	}
+
+	return c, nil
+}
+
+// write produces a human readable report of the comparison.
+func (c *comparison) write(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+
+	if c.objective != "" {
+		_, _ = fmt.Fprintf(w, "BEST TRIAL (%s)\t%s\t%s\tDELTA\n", c.objective, c.names[0], c.names[1])
+		_, _ = fmt.Fprintf(w, "number\t%s\t%s\t\n", trialNumber(c.best[0]), trialNumber(c.best[1]))
+		for _, mc := range c.metrics {
+			var values [2]*float64
+			for i := range c.best {
+				if c.best[i] == nil {
+					continue
+				}
+				if v, err := strconv.ParseFloat(value(c.best[i], mc.metric.Name), 64); err == nil {
+					values[i] = &v
+				}
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mc.metric.Name, formatFloat(values[0]), formatFloat(values[1]), formatDelta(values))
+		}
+		_, _ = fmt.Fprintln(w, "\t\t\t")
+	}
+
+	_, _ = fmt.Fprintf(w, "METRIC\tBEST %s\tBEST %s\tIMPROVEMENT\n", c.names[0], c.names[1])
+	for _, mc := range c.metrics {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mc.metric.Name, formatFloat(mc.best[0]), formatFloat(mc.best[1]), formatImprovement(mc))
+	}
+
+	if len(c.overlap) > 0 {
+		_, _ = fmt.Fprintln(w, "\t\t\t")
+		_, _ = fmt.Fprintln(w, "PARAMETER\tOVERLAP\t\t")
+		for _, po := range c.overlap {
+			_, _ = fmt.Fprintf(w, "%s\t%.0f%%\t\t\n", po.name, po.overlap*100)
+		}
+	}
+
+	return w.Flush()
+}
+
+// bestTrial returns the completed trial with the best value for the supplied metric.
+func bestTrial(trials []experimentsv1alpha1.TrialItem, m experimentsv1alpha1.Metric) *experimentsv1alpha1.TrialItem {
+	var best *experimentsv1alpha1.TrialItem
+	var bestValue float64
+	for i := range trials {
+		if trials[i].Status != experimentsv1alpha1.TrialCompleted {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(value(&trials[i], m.Name), 64)
+		if err != nil {
+			continue
+		}
+
+		if best == nil || (m.Minimize && v < bestValue) || (!m.Minimize && v > bestValue) {
+			best, bestValue = &trials[i], v
+		}
+	}
+	return best
+}
+
+// overlap returns the fraction of the combined explored space of a parameter that was explored by both experiments.
+func overlap(a, b []experimentsv1alpha1.TrialItem, name string) float64 {
+	aMin, aMax, aValues, aNumeric := explored(a, name)
+	bMin, bMax, bValues, bNumeric := explored(b, name)
+
+	// Numeric parameters are compared using the range of assigned values
+	if aNumeric && bNumeric {
+		union := math.Max(aMax, bMax) - math.Min(aMin, bMin)
+		if union == 0 {
+			return 1
+		}
+		return math.Max(math.Min(aMax, bMax)-math.Max(aMin, bMin), 0) / union
+	}
+
+	// Categorical parameters are compared using the set of assigned values
+	union := make(map[string]bool, len(aValues)+len(bValues))
+	var common int
+	for v := range aValues {
+		union[v] = true
+		if bValues[v] {
+			common++
+		}
+	}
+	for v := range bValues {
+		union[v] = true
+	}
+	if len(union) == 0 {
+		return 0
+	}
+	return float64(common) / float64(len(union))
+}
+
+// explored returns the range and distinct set of values assigned to a parameter.
+func explored(trials []experimentsv1alpha1.TrialItem, name string) (lo, hi float64, values map[string]bool, numeric bool) {
+	values = make(map[string]bool)
+	numeric = true
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for i := range trials {
+		for _, a := range trials[i].Assignments {
+			if a.ParameterName != name {
+				continue
+			}
+
+			values[a.Value.String()] = true
+			if a.Value.IsString {
+				numeric = false
+				continue
+			}
+
+			v := a.Value.Float64Value()
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	if len(values) == 0 {
+		return 0, 0, values, false
+	}
+	return lo, hi, values, numeric
+}
+
+func hasMetric(metrics []experimentsv1alpha1.Metric, name string) bool {
+	for i := range metrics {
+		if metrics[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasParameter(parameters []experimentsv1alpha1.Parameter, name string) bool {
+	for i := range parameters {
+		if parameters[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func trialNumber(t *experimentsv1alpha1.TrialItem) string {
+	if t == nil {
+		return "-"
+	}
+	return strconv.FormatInt(t.Number, 10)
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*v, 'g', 6, 64)
+}
+
+// formatDelta returns the difference between the second and first values.
+func formatDelta(values [2]*float64) string {
+	if values[0] == nil || values[1] == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+g", *values[1]-*values[0])
+}
+
+// formatImprovement returns the relative improvement of the second experiment over the first, taking into
+// consideration the direction of optimization.
+func formatImprovement(mc metricComparison) string {
+	if mc.best[0] == nil || mc.best[1] == nil || *mc.best[0] == 0 {
+		return "-"
+	}
+
+	improvement := (*mc.best[1] - *mc.best[0]) / math.Abs(*mc.best[0])
+	if mc.metric.Minimize {
+		improvement = -improvement
+	}
+	return fmt.Sprintf("%+.1f%%", improvement*100)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+func TestNewComparison(t *testing.T) {
+	newTrial := func(number int64, cpu int64, mode string, cost float64) experimentsv1alpha1.TrialItem {
+		return experimentsv1alpha1.TrialItem{
+			Number: number,
+			Status: experimentsv1alpha1.TrialCompleted,
+			TrialAssignments: experimentsv1alpha1.TrialAssignments{
+				Assignments: []experimentsv1alpha1.Assignment{
+					{ParameterName: "cpu", Value: api.FromInt64(cpu)},
+					{ParameterName: "mode", Value: api.FromString(mode)},
+				},
+			},
+			TrialValues: experimentsv1alpha1.TrialValues{
+				Values: []experimentsv1alpha1.Value{
+					{MetricName: "cost", Value: cost},
+				},
+			},
+		}
+	}
+
+	newTrialList := func(name string, metrics []experimentsv1alpha1.Metric, trials ...experimentsv1alpha1.TrialItem) *experimentsv1alpha1.TrialList {
+		return &experimentsv1alpha1.TrialList{
+			Experiment: &experimentsv1alpha1.Experiment{
+				DisplayName: name,
+				Metrics:     metrics,
+				Parameters: []experimentsv1alpha1.Parameter{
+					{Name: "cpu", Type: experimentsv1alpha1.ParameterTypeInteger},
+					{Name: "mode", Type: experimentsv1alpha1.ParameterTypeCategorical},
+				},
+			},
+			Trials: trials,
+		}
+	}
+
+	cost := []experimentsv1alpha1.Metric{{Name: "cost", Minimize: true}}
+
+	cases := []struct {
+		desc                string
+		a, b                *experimentsv1alpha1.TrialList
+		expectedBest        [2]int64
+		expectedImprovement string
+		expectedOverlap     []parameterOverlap
+		expectedErr         string
+	}{
+		{
+			desc: "improved",
+			a: newTrialList("before", cost,
+				newTrial(0, 100, "fast", 10),
+				newTrial(1, 300, "slow", 8),
+			),
+			b: newTrialList("after", cost,
+				newTrial(0, 200, "fast", 6),
+				newTrial(1, 500, "fast", 7),
+			),
+			expectedBest:        [2]int64{1, 0},
+			expectedImprovement: "+25.0%",
+			expectedOverlap: []parameterOverlap{
+				{name: "cpu", overlap: 0.25},
+				{name: "mode", overlap: 0.5},
+			},
+		},
+		{
+			desc:        "no shared metrics",
+			a:           newTrialList("before", cost),
+			b:           newTrialList("after", []experimentsv1alpha1.Metric{{Name: "duration", Minimize: true}}),
+			expectedErr: `experiments "before" and "after" do not share any metrics`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			cmp, err := newComparison(c.a, c.b)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			if assert.NotNil(t, cmp.best[0]) && assert.NotNil(t, cmp.best[1]) {
+				assert.Equal(t, c.expectedBest, [2]int64{cmp.best[0].Number, cmp.best[1].Number})
+			}
+			if assert.Len(t, cmp.metrics, 1) {
+				assert.Equal(t, c.expectedImprovement, formatImprovement(cmp.metrics[0]))
+			}
+			assert.Equal(t, c.expectedOverlap, cmp.overlap)
+		})
+	}
+}
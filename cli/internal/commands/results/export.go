@@ -0,0 +1,159 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+// ExportOptions are the options for exporting experiment results
+type ExportOptions struct {
+	// Config is the Optimize Configuration
+	Config *config.OptimizeConfig
+	// ExperimentsAPI is used to interact with the Optimize Experiments API
+	ExperimentsAPI experimentsv1alpha1.API
+	// IOStreams are used to access the standard process streams
+	commander.IOStreams
+
+	ExperimentName string
+	Filename       string
+	All            bool
+}
+
+// NewExportCommand creates a new command for exporting experiment results
+func NewExportCommand(o *ExportOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export EXPERIMENT_NAME",
+		Short: "Export experiment results",
+		Long:  "Export the trials of an experiment as CSV, one row per trial",
+		Args:  cobra.ExactArgs(1),
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			o.ExperimentName = args[0]
+			commander.SetStreams(&o.IOStreams, cmd)
+			return commander.SetExperimentsAPI(&o.ExperimentsAPI, o.Config, cmd)
+		},
+		RunE: commander.WithContextE(o.export),
+	}
+
+	cmd.Flags().StringVarP(&o.Filename, "filename", "f", "", "write the CSV to a `file` instead of standard output")
+	cmd.Flags().BoolVarP(&o.All, "all", "A", false, "include staged and abandoned trials")
+
+	_ = cmd.MarkFlagFilename("filename", "csv")
+
+	return cmd
+}
+
+func (o *ExportOptions) export(ctx context.Context) error {
+	_, tl, err := getTrials(ctx, o.ExperimentsAPI, o.ExperimentName, o.All)
+	if err != nil {
+		return err
+	}
+
+	if o.Filename == "" || o.Filename == "-" {
+		return writeCSV(o.Out, tl)
+	}
+
+	f, err := os.Create(o.Filename)
+	if err != nil {
+		return err
+	}
+	if err := writeCSV(f, tl); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeCSV writes one row per trial with the assignments, values, status and timestamps.
+func writeCSV(w io.Writer, tl *experimentsv1alpha1.TrialList) error {
+	header := []string{"number", "status"}
+	var parameterNames, metricNames []string
+	if tl.Experiment != nil {
+		for i := range tl.Experiment.Parameters {
+			parameterNames = append(parameterNames, tl.Experiment.Parameters[i].Name)
+			header = append(header, "parameter_"+tl.Experiment.Parameters[i].Name)
+		}
+		for i := range tl.Experiment.Metrics {
+			metricNames = append(metricNames, tl.Experiment.Metrics[i].Name)
+			header = append(header, "metric_"+tl.Experiment.Metrics[i].Name)
+		}
+	}
+	header = append(header, "startTime", "completionTime", "failureReason", "failureMessage")
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for i := range tl.Trials {
+		t := &tl.Trials[i]
+		record := []string{strconv.FormatInt(t.Number, 10), string(t.Status)}
+		for _, name := range parameterNames {
+			record = append(record, assignment(t, name))
+		}
+		for _, name := range metricNames {
+			record = append(record, value(t, name))
+		}
+		record = append(record, formatTime(t.StartTime), formatTime(t.CompletionTime), t.FailureReason, t.FailureMessage)
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// assignment returns the formatted parameter assignment for a trial, empty if it is not assigned.
+func assignment(t *experimentsv1alpha1.TrialItem, name string) string {
+	for i := range t.Assignments {
+		if t.Assignments[i].ParameterName == name {
+			return t.Assignments[i].Value.String()
+		}
+	}
+	return ""
+}
+
+// value returns the formatted metric value for a trial, empty if it was not observed.
+func value(t *experimentsv1alpha1.TrialItem, name string) string {
+	for i := range t.Values {
+		if t.Values[i].MetricName == name {
+			return strconv.FormatFloat(t.Values[i].Value, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+// formatTime returns the RFC 3339 representation of a timestamp, empty if it is not set.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+func TestWriteCSV(t *testing.T) {
+	start := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(5 * time.Minute)
+
+	exp := &experimentsv1alpha1.Experiment{
+		Parameters: []experimentsv1alpha1.Parameter{{Name: "cpu"}, {Name: "memory"}},
+		Metrics:    []experimentsv1alpha1.Metric{{Name: "cost"}},
+	}
+
+	tl := &experimentsv1alpha1.TrialList{
+		Experiment: exp,
+		Trials: []experimentsv1alpha1.TrialItem{
+			{
+				Number: 1,
+				Status: experimentsv1alpha1.TrialCompleted,
+				TrialAssignments: experimentsv1alpha1.TrialAssignments{
+					Assignments: []experimentsv1alpha1.Assignment{
+						{ParameterName: "cpu", Value: api.FromInt64(500)},
+						{ParameterName: "memory", Value: api.FromInt64(1024)},
+					},
+				},
+				TrialValues: experimentsv1alpha1.TrialValues{
+					Values:         []experimentsv1alpha1.Value{{MetricName: "cost", Value: 12.5}},
+					StartTime:      &start,
+					CompletionTime: &end,
+				},
+			},
+			{
+				Number: 2,
+				Status: experimentsv1alpha1.TrialFailed,
+				TrialAssignments: experimentsv1alpha1.TrialAssignments{
+					Assignments: []experimentsv1alpha1.Assignment{
+						{ParameterName: "cpu", Value: api.FromInt64(100)},
+					},
+				},
+				TrialValues: experimentsv1alpha1.TrialValues{
+					Failed:         true,
+					FailureReason:  "OOMKilled",
+					FailureMessage: "out of memory, try again",
+				},
+			},
+		},
+	}
+
+	var out strings.Builder
+	if assert.NoError(t, writeCSV(&out, tl)) {
+		assert.Equal(t, `number,status,parameter_cpu,parameter_memory,metric_cost,startTime,completionTime,failureReason,failureMessage
+1,completed,500,1024,12.5,2021-06-01T10:00:00Z,2021-06-01T10:05:00Z,,
+2,failed,100,,,,,OOMKilled,"out of memory, try again"
+`, out.String())
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+// objectiveMetrics returns the metrics that are being optimized.
+func objectiveMetrics(metrics []experimentsv1alpha1.Metric) []experimentsv1alpha1.Metric {
+	var result []experimentsv1alpha1.Metric
+	for i := range metrics {
+		if metrics[i].Optimize == nil || *metrics[i].Optimize {
+			result = append(result, metrics[i])
+		}
+	}
+	return result
+}
+
+// paretoOptimal returns a flag for each trial indicating if it is on the Pareto front of the completed trials.
+func paretoOptimal(objectives []experimentsv1alpha1.Metric, trials []experimentsv1alpha1.TrialItem) []bool {
+	// Collect the objective values, normalized so that lower is always better
+	values := make([][]float64, len(trials))
+	for i := range trials {
+		if trials[i].Status == experimentsv1alpha1.TrialCompleted {
+			values[i] = objectiveValues(objectives, &trials[i])
+		}
+	}
+
+	result := make([]bool, len(trials))
+	for i := range values {
+		if values[i] == nil {
+			continue
+		}
+
+		result[i] = true
+		for j := range values {
+			if i != j && values[j] != nil && dominates(values[j], values[i]) {
+				result[i] = false
+				break
+			}
+		}
+	}
+	return result
+}
+
+// objectiveValues returns the objective values for a trial, normalized so that lower is always better. Returns nil
+// if the trial is missing any of the values.
+func objectiveValues(objectives []experimentsv1alpha1.Metric, t *experimentsv1alpha1.TrialItem) []float64 {
+	v := make([]float64, 0, len(objectives))
+	for _, obj := range objectives {
+		for _, tv := range t.Values {
+			if tv.MetricName == obj.Name {
+				if obj.Minimize {
+					v = append(v, tv.Value)
+				} else {
+					v = append(v, -tv.Value)
+				}
+				break
+			}
+		}
+	}
+
+	if len(v) != len(objectives) {
+		return nil
+	}
+	return v
+}
+
+// dominates checks to see if a is no worse then b for every objective and better for at least one.
+func dominates(a, b []float64) bool {
+	var better bool
+	for i := range a {
+		if a[i] > b[i] {
+			return false
+		}
+		if a[i] < b[i] {
+			better = true
+		}
+	}
+	return better
+}
+
+// noWorse checks to see if a is no worse than b for every objective.
+func noWorse(a, b []float64) bool {
+	for i := range a {
+		if a[i] > b[i] {
+			return false
+		}
+	}
+	return true
+}
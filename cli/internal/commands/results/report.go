@@ -0,0 +1,434 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+const (
+	plotWidth  = 360
+	plotHeight = 240
+	plotMargin = 40
+)
+
+// ReportOptions are the options for generating an experiment report
+type ReportOptions struct {
+	// Config is the Optimize Configuration
+	Config *config.OptimizeConfig
+	// ExperimentsAPI is used to interact with the Optimize Experiments API
+	ExperimentsAPI experimentsv1alpha1.API
+	// IOStreams are used to access the standard process streams
+	commander.IOStreams
+
+	ExperimentName string
+	Filename       string
+}
+
+// NewReportCommand creates a new command for generating an experiment report
+func NewReportCommand(o *ReportOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report EXPERIMENT_NAME",
+		Short: "Generate an experiment report",
+		Long:  "Generate a standalone HTML report of the experiment results that can be shared without API access",
+		Args:  cobra.ExactArgs(1),
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			o.ExperimentName = args[0]
+			commander.SetStreams(&o.IOStreams, cmd)
+			return commander.SetExperimentsAPI(&o.ExperimentsAPI, o.Config, cmd)
+		},
+		RunE: commander.WithContextE(o.report),
+	}
+
+	cmd.Flags().StringVarP(&o.Filename, "filename", "f", "", "write the HTML to a `file` instead of standard output")
+
+	_ = cmd.MarkFlagFilename("filename", "html", "htm")
+
+	return cmd
+}
+
+func (o *ReportOptions) report(ctx context.Context) error {
+	_, tl, err := getTrials(ctx, o.ExperimentsAPI, o.ExperimentName, false)
+	if err != nil {
+		return err
+	}
+
+	r := newReport(tl, time.Now())
+
+	if o.Filename == "" || o.Filename == "-" {
+		return r.write(o.Out)
+	}
+
+	f, err := os.Create(o.Filename)
+	if err != nil {
+		return err
+	}
+	if err := r.write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// report is the data used to render the HTML report.
+type report struct {
+	Experiment string
+	Generated  string
+	Completed  int
+	Failed     int
+
+	Parameters   []string
+	Metrics      []string
+	Recommended  *reportTrial
+	ParetoPlot   *scatterPlot
+	ParetoTrials []reportTrial
+	Plots        []scatterPlot
+}
+
+// reportTrial is a trial with the assignments and values aligned to the report parameters and metrics.
+type reportTrial struct {
+	Number      int64
+	Assignments []string
+	Values      []string
+}
+
+// scatterPlot is a pre-computed SVG scatter plot.
+type scatterPlot struct {
+	Title  string
+	XLabel string
+	YLabel string
+	XMin   string
+	XMax   string
+	YMin   string
+	YMax   string
+	Points []plotPoint
+}
+
+// plotPoint is a single point on a scatter plot, in SVG coordinates.
+type plotPoint struct {
+	X         float64
+	Y         float64
+	Label     string
+	Highlight bool
+}
+
+// newReport computes the report contents for a list of trials.
+func newReport(tl *experimentsv1alpha1.TrialList, now time.Time) *report {
+	exp := tl.Experiment
+	r := &report{
+		Experiment: exp.DisplayName,
+		Generated:  now.UTC().Format(time.RFC1123),
+	}
+
+	for i := range exp.Parameters {
+		r.Parameters = append(r.Parameters, exp.Parameters[i].Name)
+	}
+	for i := range exp.Metrics {
+		r.Metrics = append(r.Metrics, exp.Metrics[i].Name)
+	}
+
+	var completed []experimentsv1alpha1.TrialItem
+	for i := range tl.Trials {
+		switch tl.Trials[i].Status {
+		case experimentsv1alpha1.TrialCompleted:
+			completed = append(completed, tl.Trials[i])
+		case experimentsv1alpha1.TrialFailed:
+			r.Failed++
+		}
+	}
+	r.Completed = len(completed)
+
+	objectives := objectiveMetrics(exp.Metrics)
+	var optimal []bool
+	if len(objectives) > 1 {
+		optimal = paretoOptimal(objectives, completed)
+		r.ParetoPlot = r.newPlot(fmt.Sprintf("%s vs. %s", objectives[1].Name, objectives[0].Name),
+			objectives[0].Name, objectives[1].Name, completed, optimal,
+			func(t *experimentsv1alpha1.TrialItem) (float64, bool) { return metricValue(t, objectives[0].Name) },
+			func(t *experimentsv1alpha1.TrialItem) (float64, bool) { return metricValue(t, objectives[1].Name) })
+		for i := range completed {
+			if optimal[i] {
+				r.ParetoTrials = append(r.ParetoTrials, r.newTrial(&completed[i]))
+			}
+		}
+	}
+
+	if t := recommendedTrial(objectives, completed, optimal); t != nil {
+		rt := r.newTrial(t)
+		r.Recommended = &rt
+	}
+
+	// Plot every parameter against every objective
+	for _, obj := range objectives {
+		for _, p := range exp.Parameters {
+			x := parameterAxis(completed, p.Name)
+			if plot := r.newPlot(fmt.Sprintf("%s vs. %s", obj.Name, p.Name), p.Name, obj.Name, completed, optimal,
+				x.value, func(t *experimentsv1alpha1.TrialItem) (float64, bool) { return metricValue(t, obj.Name) }); plot != nil {
+				if x.categories != nil {
+					plot.XMin, plot.XMax = x.categories[0], x.categories[len(x.categories)-1]
+				}
+				r.Plots = append(r.Plots, *plot)
+			}
+		}
+	}
+
+	return r
+}
+
+// newTrial aligns the trial assignments and values with the report columns.
+func (r *report) newTrial(t *experimentsv1alpha1.TrialItem) reportTrial {
+	rt := reportTrial{Number: t.Number}
+	for _, name := range r.Parameters {
+		rt.Assignments = append(rt.Assignments, assignment(t, name))
+	}
+	for _, name := range r.Metrics {
+		rt.Values = append(rt.Values, value(t, name))
+	}
+	return rt
+}
+
+// newPlot returns a scatter plot of the trials, nil if there are no plottable trials.
+func (r *report) newPlot(title, xLabel, yLabel string, trials []experimentsv1alpha1.TrialItem, highlight []bool, x, y func(*experimentsv1alpha1.TrialItem) (float64, bool)) *scatterPlot {
+	var xs, ys []float64
+	var idx []int
+	for i := range trials {
+		xv, xok := x(&trials[i])
+		yv, yok := y(&trials[i])
+		if xok && yok {
+			xs, ys, idx = append(xs, xv), append(ys, yv), append(idx, i)
+		}
+	}
+	if len(idx) == 0 {
+		return nil
+	}
+
+	xMin, xMax := bounds(xs)
+	yMin, yMax := bounds(ys)
+	plot := &scatterPlot{
+		Title:  title,
+		XLabel: xLabel,
+		YLabel: yLabel,
+		XMin:   strconv.FormatFloat(xMin, 'g', 4, 64),
+		XMax:   strconv.FormatFloat(xMax, 'g', 4, 64),
+		YMin:   strconv.FormatFloat(yMin, 'g', 4, 64),
+		YMax:   strconv.FormatFloat(yMax, 'g', 4, 64),
+	}
+	for j, i := range idx {
+		plot.Points = append(plot.Points, plotPoint{
+			X:         plotMargin + scale(xs[j], xMin, xMax)*(plotWidth-2*plotMargin),
+			Y:         plotHeight - plotMargin - scale(ys[j], yMin, yMax)*(plotHeight-2*plotMargin),
+			Label:     fmt.Sprintf("Trial %d: %s=%s, %s=%s", trials[i].Number, xLabel, strconv.FormatFloat(xs[j], 'g', -1, 64), yLabel, strconv.FormatFloat(ys[j], 'g', -1, 64)),
+			Highlight: highlight != nil && highlight[i],
+		})
+	}
+	return plot
+}
+
+// write renders the report as HTML.
+func (r *report) write(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
+
+// recommendedTrial returns the trial to recommend: a trial explicitly labeled as the best, the best trial of a single
+// objective experiment, or the Pareto optimal trial closest to the ideal point.
+func recommendedTrial(objectives []experimentsv1alpha1.Metric, trials []experimentsv1alpha1.TrialItem, optimal []bool) *experimentsv1alpha1.TrialItem {
+	for i := range trials {
+		if trials[i].Labels["best"] == "true" {
+			return &trials[i]
+		}
+	}
+
+	switch len(objectives) {
+	case 0:
+		return nil
+	case 1:
+		return bestTrial(trials, objectives[0])
+	}
+
+	// Normalize the objective values so the distance is not dominated by the metric with the largest scale
+	values := make([][]float64, len(trials))
+	lo := make([]float64, len(objectives))
+	hi := make([]float64, len(objectives))
+	for k := range objectives {
+		lo[k], hi[k] = math.Inf(1), math.Inf(-1)
+	}
+	for i := range trials {
+		if !optimal[i] {
+			continue
+		}
+		values[i] = objectiveValues(objectives, &trials[i])
+		for k, v := range values[i] {
+			lo[k], hi[k] = math.Min(lo[k], v), math.Max(hi[k], v)
+		}
+	}
+
+	var best *experimentsv1alpha1.TrialItem
+	bestDistance := math.Inf(1)
+	for i := range values {
+		if values[i] == nil {
+			continue
+		}
+
+		var d float64
+		for k, v := range values[i] {
+			d += math.Pow(scale(v, lo[k], hi[k]), 2)
+		}
+		if d < bestDistance {
+			best, bestDistance = &trials[i], d
+		}
+	}
+	return best
+}
+
+// axis maps a parameter to plot values, categorical parameters are plotted by their sorted position.
+type axis struct {
+	categories []string
+	value      func(*experimentsv1alpha1.TrialItem) (float64, bool)
+}
+
+// parameterAxis returns the axis used to plot the assignments of a parameter.
+func parameterAxis(trials []experimentsv1alpha1.TrialItem, name string) axis {
+	_, _, values, numeric := explored(trials, name)
+	if numeric {
+		return axis{value: func(t *experimentsv1alpha1.TrialItem) (float64, bool) {
+			for _, a := range t.Assignments {
+				if a.ParameterName == name {
+					return a.Value.Float64Value(), true
+				}
+			}
+			return 0, false
+		}}
+	}
+
+	a := axis{}
+	for v := range values {
+		a.categories = append(a.categories, v)
+	}
+	sort.Strings(a.categories)
+	a.value = func(t *experimentsv1alpha1.TrialItem) (float64, bool) {
+		v := assignment(t, name)
+		i := sort.SearchStrings(a.categories, v)
+		if v == "" || i >= len(a.categories) || a.categories[i] != v {
+			return 0, false
+		}
+		return float64(i), true
+	}
+	return a
+}
+
+// bounds returns the minimum and maximum of the supplied values.
+func bounds(values []float64) (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	return lo, hi
+}
+
+// scale returns the position of a value in the range [lo, hi] as a number between 0 and 1.
+func scale(v, lo, hi float64) float64 {
+	if hi == lo {
+		return 0.5
+	}
+	return (v - lo) / (hi - lo)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"coord": func(f float64) string { return strconv.FormatFloat(f, 'f', 1, 64) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Experiment }} - StormForge Optimize Report</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+th { background: #f4f4f4; }
+.plots { display: flex; flex-wrap: wrap; gap: 1em; }
+figure { margin: 0; }
+figcaption { font-weight: bold; text-align: center; }
+svg text { font-size: 10px; fill: #555; }
+circle { fill: #9aa5b1; fill-opacity: 0.7; }
+circle.optimal { fill: #e4572e; fill-opacity: 1; }
+</style>
+</head>
+<body>
+<h1>{{ .Experiment }}</h1>
+<p>Generated {{ .Generated }} from {{ .Completed }} completed and {{ .Failed }} failed trials.</p>
+{{- define "trialHeader" }}<tr><th>Trial</th>{{ range .Parameters }}<th>{{ . }}</th>{{ end }}{{ range .Metrics }}<th>{{ . }}</th>{{ end }}</tr>{{ end }}
+{{- define "trialRow" }}<tr><td>{{ .Number }}</td>{{ range .Assignments }}<td>{{ . }}</td>{{ end }}{{ range .Values }}<td>{{ . }}</td>{{ end }}</tr>{{ end }}
+{{- define "plot" }}
+<figure>
+<figcaption>{{ .Title }}</figcaption>
+<svg width="360" height="240" viewBox="0 0 360 240" xmlns="http://www.w3.org/2000/svg">
+<line x1="40" y1="200" x2="320" y2="200" stroke="#999"/>
+<line x1="40" y1="40" x2="40" y2="200" stroke="#999"/>
+<text x="40" y="214">{{ .XMin }}</text>
+<text x="320" y="214" text-anchor="end">{{ .XMax }}</text>
+<text x="180" y="230" text-anchor="middle">{{ .XLabel }}</text>
+<text x="36" y="200" text-anchor="end">{{ .YMin }}</text>
+<text x="36" y="44" text-anchor="end">{{ .YMax }}</text>
+<text x="40" y="30">{{ .YLabel }}</text>
+{{- range .Points }}
+<circle cx="{{ coord .X }}" cy="{{ coord .Y }}" r="4"{{ if .Highlight }} class="optimal"{{ end }}><title>{{ .Label }}</title></circle>
+{{- end }}
+</svg>
+</figure>
+{{- end }}
+<h2>Recommended Configuration</h2>
+{{- with .Recommended }}
+<table>
+{{ template "trialHeader" $ }}
+{{ template "trialRow" . }}
+</table>
+{{- else }}
+<p>No completed trials are available to recommend a configuration.</p>
+{{- end }}
+{{- if .ParetoPlot }}
+<h2>Pareto Frontier</h2>
+<div class="plots">{{ template "plot" .ParetoPlot }}</div>
+<table>
+{{ template "trialHeader" $ }}
+{{- range .ParetoTrials }}
+{{ template "trialRow" . }}
+{{- end }}
+</table>
+{{- end }}
+{{- if .Plots }}
+<h2>Parameters</h2>
+<div class="plots">
+{{- range .Plots }}{{ template "plot" . }}{{ end }}
+</div>
+{{- end }}
+</body>
+</html>
+`))
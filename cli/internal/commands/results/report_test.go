@@ -0,0 +1,137 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+func TestNewReport(t *testing.T) {
+	newTrial := func(number int64, cpu int64, tier string, cost, latency float64) experimentsv1alpha1.TrialItem {
+		return experimentsv1alpha1.TrialItem{
+			Number: number,
+			Status: experimentsv1alpha1.TrialCompleted,
+			TrialAssignments: experimentsv1alpha1.TrialAssignments{
+				Assignments: []experimentsv1alpha1.Assignment{
+					{ParameterName: "cpu", Value: api.FromInt64(cpu)},
+					{ParameterName: "tier", Value: api.FromString(tier)},
+				},
+			},
+			TrialValues: experimentsv1alpha1.TrialValues{
+				Values: []experimentsv1alpha1.Value{
+					{MetricName: "cost", Value: cost},
+					{MetricName: "latency", Value: latency},
+				},
+			},
+		}
+	}
+
+	exp := &experimentsv1alpha1.Experiment{
+		DisplayName: "my-exp",
+		Parameters: []experimentsv1alpha1.Parameter{
+			{Name: "cpu", Type: experimentsv1alpha1.ParameterTypeInteger},
+			{Name: "tier", Type: experimentsv1alpha1.ParameterTypeCategorical},
+		},
+		Metrics: []experimentsv1alpha1.Metric{
+			{Name: "cost", Minimize: true},
+			{Name: "latency", Minimize: true},
+		},
+	}
+
+	cases := []struct {
+		desc                string
+		trials              []experimentsv1alpha1.TrialItem
+		expectedRecommended int64
+		expectedPareto      []int64
+		expectedPlots       int
+	}{
+		{
+			desc: "closest to ideal",
+			trials: []experimentsv1alpha1.TrialItem{
+				newTrial(1, 1000, "small", 10, 100),
+				newTrial(2, 2000, "medium", 40, 40),
+				newTrial(3, 4000, "large", 100, 10),
+				newTrial(4, 4000, "large", 120, 50),
+				{Number: 5, Status: experimentsv1alpha1.TrialFailed},
+			},
+			expectedRecommended: 2,
+			expectedPareto:      []int64{1, 2, 3},
+			expectedPlots:       4,
+		},
+		{
+			desc: "labeled best",
+			trials: []experimentsv1alpha1.TrialItem{
+				newTrial(1, 1000, "small", 10, 100),
+				func() experimentsv1alpha1.TrialItem {
+					ti := newTrial(2, 4000, "large", 120, 50)
+					ti.Labels = map[string]string{"best": "true"}
+					return ti
+				}(),
+			},
+			expectedRecommended: 2,
+			expectedPareto:      []int64{1, 2},
+			expectedPlots:       4,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			r := newReport(&experimentsv1alpha1.TrialList{Experiment: exp, Trials: c.trials}, time.Unix(0, 0))
+
+			require.NotNil(t, r.Recommended)
+			assert.Equal(t, c.expectedRecommended, r.Recommended.Number)
+			assert.Len(t, r.Recommended.Assignments, len(exp.Parameters))
+			assert.Len(t, r.Recommended.Values, len(exp.Metrics))
+
+			var pareto []int64
+			for _, pt := range r.ParetoTrials {
+				pareto = append(pareto, pt.Number)
+			}
+			assert.Equal(t, c.expectedPareto, pareto)
+			assert.NotNil(t, r.ParetoPlot)
+			assert.Len(t, r.Plots, c.expectedPlots)
+
+			var buf bytes.Buffer
+			require.NoError(t, r.write(&buf))
+			assert.Contains(t, buf.String(), "<h1>my-exp</h1>")
+			assert.Contains(t, buf.String(), `class="optimal"`)
+		})
+	}
+}
+
+func TestParameterAxis(t *testing.T) {
+	trials := []experimentsv1alpha1.TrialItem{
+		{TrialAssignments: experimentsv1alpha1.TrialAssignments{Assignments: []experimentsv1alpha1.Assignment{{ParameterName: "tier", Value: api.FromString("small")}}}},
+		{TrialAssignments: experimentsv1alpha1.TrialAssignments{Assignments: []experimentsv1alpha1.Assignment{{ParameterName: "tier", Value: api.FromString("large")}}}},
+	}
+
+	a := parameterAxis(trials, "tier")
+	assert.Equal(t, []string{"large", "small"}, a.categories)
+
+	v, ok := a.value(&trials[0])
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, v)
+
+	_, ok = a.value(&experimentsv1alpha1.TrialItem{})
+	assert.False(t, ok)
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+// Options includes the configuration for displaying experiment results
+type Options struct {
+	// Config is the Optimize Configuration
+	Config *config.OptimizeConfig
+	// ExperimentsAPI is used to interact with the Optimize Experiments API
+	ExperimentsAPI experimentsv1alpha1.API
+	// Printer is the resource printer used to render the results
+	Printer commander.ResourcePrinter
+	// IOStreams are used to access the standard process streams
+	commander.IOStreams
+
+	ExperimentName string
+	All            bool
+	Watch          bool
+	WatchInterval  time.Duration
+}
+
+// NewCommand returns a new results command
+func NewCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "results EXPERIMENT_NAME",
+		Short: "Work with experiment results",
+		Long:  "Display the results of a StormForge Optimize experiment",
+		Args:  cobra.ExactArgs(1),
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			o.ExperimentName = args[0]
+			commander.SetStreams(&o.IOStreams, cmd)
+			return commander.SetExperimentsAPI(&o.ExperimentsAPI, o.Config, cmd)
+		},
+		RunE: commander.WithContextE(o.results),
+	}
+
+	cmd.Flags().BoolVarP(&o.All, "all", "A", false, "include staged and abandoned trials")
+	cmd.Flags().BoolVarP(&o.Watch, "watch", "w", false, "print trials as they finish")
+	cmd.Flags().DurationVar(&o.WatchInterval, "watch-interval", 15*time.Second, "the `duration` between checks for finished trials")
+
+	commander.SetPrinter(&resultsMeta{}, &o.Printer, cmd, nil)
+
+	cmd.AddCommand(NewExportCommand(&ExportOptions{Config: o.Config}))
+	cmd.AddCommand(NewCompareCommand(&CompareOptions{Config: o.Config}))
+	cmd.AddCommand(NewReportCommand(&ReportOptions{Config: o.Config}))
+
+	return cmd
+}
+
+// Results is the machine readable representation of an experiment's trials.
+type Results struct {
+	// The name of the experiment.
+	Experiment string `json:"experiment"`
+	// The trials of the experiment.
+	Trials []TrialResult `json:"trials"`
+
+	metrics []experimentsv1alpha1.Metric
+}
+
+// TrialResult is a trial with additional information computed from the other trials.
+type TrialResult struct {
+	experimentsv1alpha1.TrialItem
+
+	// Indicates the trial is not dominated by any other trial, only computed for multi-objective experiments.
+	ParetoOptimal *bool `json:"paretoOptimal,omitempty"`
+}
+
+func (o *Options) results(ctx context.Context) error {
+	if o.Watch {
+		return o.watch(ctx)
+	}
+
+	exp, tl, err := getTrials(ctx, o.ExperimentsAPI, o.ExperimentName, o.All)
+	if err != nil {
+		return err
+	}
+
+	return o.Printer.PrintObj(newResults(exp, tl), o.Out)
+}
+
+// newResults creates new results from a trial list.
+func newResults(exp *experimentsv1alpha1.Experiment, tl *experimentsv1alpha1.TrialList) *Results {
+	r := &Results{
+		Experiment: exp.DisplayName,
+		Trials:     make([]TrialResult, len(tl.Trials)),
+		metrics:    exp.Metrics,
+	}
+
+	for i := range tl.Trials {
+		r.Trials[i].TrialItem = tl.Trials[i]
+	}
+
+	objectives := objectiveMetrics(exp.Metrics)
+	if len(objectives) > 1 {
+		optimal := paretoOptimal(objectives, tl.Trials)
+		for i := range r.Trials {
+			if tl.Trials[i].Status == experimentsv1alpha1.TrialCompleted {
+				r.Trials[i].ParetoOptimal = &optimal[i]
+			}
+		}
+	}
+
+	return r
+}
+
+// getTrials returns the experiment and all of its trials from the Experiments API.
+func getTrials(ctx context.Context, expAPI experimentsv1alpha1.API, name string, all bool) (*experimentsv1alpha1.Experiment, *experimentsv1alpha1.TrialList, error) {
+	exp, err := expAPI.GetExperimentByName(ctx, experimentsv1alpha1.ExperimentName(name))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	q := experimentsv1alpha1.TrialListQuery{}
+	q.SetStatus(experimentsv1alpha1.TrialActive, experimentsv1alpha1.TrialCompleted, experimentsv1alpha1.TrialFailed)
+	if all {
+		q.AddStatus(experimentsv1alpha1.TrialStaged)
+		q.AddStatus(experimentsv1alpha1.TrialAbandoned)
+	}
+
+	tl, err := expAPI.GetAllTrials(ctx, exp.Link(api.RelationTrials), q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tl.Experiment = &exp
+	for i := range tl.Trials {
+		tl.Trials[i].Experiment = &exp
+	}
+
+	return &exp, &tl, nil
+}
+
+// resultsMeta is the metadata extraction necessary for printing results
+type resultsMeta struct{}
+
+// ExtractList returns the trial results
+func (m *resultsMeta) ExtractList(obj interface{}) ([]interface{}, error) {
+	if r, ok := obj.(*Results); ok {
+		list := make([]interface{}, len(r.Trials))
+		for i := range r.Trials {
+			list[i] = &r.Trials[i]
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unexpected results type: %T", obj)
+}
+
+// Columns returns the trial number, status and metric values
+func (m *resultsMeta) Columns(obj interface{}, outputFormat string, showLabels bool) []string {
+	columns := []string{"number", "status"}
+	if r, ok := obj.(*Results); ok {
+		for i := range r.metrics {
+			columns = append(columns, "metric_"+r.metrics[i].Name)
+		}
+		if outputFormat != "" && len(objectiveMetrics(r.metrics)) > 1 {
+			columns = append(columns, "paretoOptimal")
+		}
+	}
+	if showLabels {
+		columns = append(columns, "labels")
+	}
+	return columns
+}
+
+// ExtractValue returns a cell value
+func (m *resultsMeta) ExtractValue(obj interface{}, column string) (string, error) {
+	t, ok := obj.(*TrialResult)
+	if !ok {
+		return "", fmt.Errorf("unexpected trial type: %T", obj)
+	}
+
+	switch column {
+	case "name":
+		if t.Experiment != nil {
+			return fmt.Sprintf("%s-%03d", t.Experiment.DisplayName, t.Number), nil
+		}
+		return strconv.FormatInt(t.Number, 10), nil
+	case "number":
+		return strconv.FormatInt(t.Number, 10), nil
+	case "status":
+		return string(t.Status), nil
+	case "paretoOptimal":
+		if t.ParetoOptimal != nil {
+			return strconv.FormatBool(*t.ParetoOptimal), nil
+		}
+		return "", nil
+	case "labels":
+		var labels []string
+		for k, v := range t.Labels {
+			labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+		}
+		return strings.Join(labels, ","), nil
+	}
+
+	if mn := strings.TrimPrefix(column, "metric_"); mn != column {
+		return value(&t.TrialItem, mn), nil
+	}
+
+	return "", fmt.Errorf("unable to get value for column %s", column)
+}
+
+// Header returns the header name to use for a column
+func (m *resultsMeta) Header(outputFormat string, column string) string {
+	if strings.ToLower(outputFormat) == "csv" {
+		return column
+	}
+	if mn := strings.TrimPrefix(column, "metric_"); mn != column {
+		return strings.ToUpper(mn)
+	}
+	column = regexp.MustCompile("(.)([A-Z])").ReplaceAllString(column, "$1 $2")
+	return strings.ToUpper(column)
+}
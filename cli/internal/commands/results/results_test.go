@@ -0,0 +1,104 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+func TestNewResults(t *testing.T) {
+	no := false
+
+	newTrial := func(status experimentsv1alpha1.TrialStatus, cost, duration float64) experimentsv1alpha1.TrialItem {
+		return experimentsv1alpha1.TrialItem{
+			Status: status,
+			TrialValues: experimentsv1alpha1.TrialValues{
+				Values: []experimentsv1alpha1.Value{
+					{MetricName: "cost", Value: cost},
+					{MetricName: "duration", Value: duration},
+					{MetricName: "throughput", Value: 1},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		desc     string
+		metrics  []experimentsv1alpha1.Metric
+		trials   []experimentsv1alpha1.TrialItem
+		expected []*bool
+	}{
+		{
+			desc: "single objective",
+			metrics: []experimentsv1alpha1.Metric{
+				{Name: "cost", Minimize: true},
+				{Name: "duration", Minimize: true, Optimize: &no},
+			},
+			trials: []experimentsv1alpha1.TrialItem{
+				newTrial(experimentsv1alpha1.TrialCompleted, 1, 1),
+			},
+			expected: []*bool{nil},
+		},
+		{
+			desc: "multi-objective",
+			metrics: []experimentsv1alpha1.Metric{
+				{Name: "cost", Minimize: true},
+				{Name: "duration", Minimize: true},
+			},
+			trials: []experimentsv1alpha1.TrialItem{
+				newTrial(experimentsv1alpha1.TrialCompleted, 1, 10),
+				newTrial(experimentsv1alpha1.TrialCompleted, 10, 1),
+				newTrial(experimentsv1alpha1.TrialCompleted, 5, 5),
+				newTrial(experimentsv1alpha1.TrialCompleted, 6, 6),
+				newTrial(experimentsv1alpha1.TrialFailed, 0, 0),
+				newTrial(experimentsv1alpha1.TrialCompleted, 1, 10),
+			},
+			expected: []*bool{newBool(true), newBool(true), newBool(true), newBool(false), nil, newBool(true)},
+		},
+		{
+			desc: "maximize",
+			metrics: []experimentsv1alpha1.Metric{
+				{Name: "cost", Minimize: true},
+				{Name: "duration"},
+			},
+			trials: []experimentsv1alpha1.TrialItem{
+				newTrial(experimentsv1alpha1.TrialCompleted, 1, 1),
+				newTrial(experimentsv1alpha1.TrialCompleted, 1, 2),
+			},
+			expected: []*bool{newBool(false), newBool(true)},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &experimentsv1alpha1.Experiment{DisplayName: "test", Metrics: c.metrics}
+			r := newResults(exp, &experimentsv1alpha1.TrialList{Trials: c.trials})
+
+			var actual []*bool
+			for i := range r.Trials {
+				actual = append(actual, r.Trials[i].ParetoOptimal)
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func newBool(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,410 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-controller/v2/cli/internal/commander"
+	"github.com/thestormforge/optimize-controller/v2/internal/server"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+// TopOptions are the options for summarizing the resource usage of trials
+type TopOptions struct {
+	// Config is the Optimize Configuration
+	Config *config.OptimizeConfig
+	// ExperimentsAPI is used to interact with the Optimize Experiments API
+	ExperimentsAPI experimentsv1alpha1.API
+	// IOStreams are used to access the standard process streams
+	commander.IOStreams
+
+	ExperimentName    string
+	CostMetric        string
+	PerformanceMetric string
+	Weights           map[string]string
+	Limit             int
+}
+
+// NewTopCommand creates a new command for displaying resource usage summaries
+func NewTopCommand(o *TopOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Display resource usage",
+		Long:  "Display resource usage summaries of StormForge Optimize experiments",
+	}
+
+	cmd.AddCommand(NewTopTrialsCommand(o))
+
+	return cmd
+}
+
+// NewTopTrialsCommand creates a new command for ranking the resource usage of trials
+func NewTopTrialsCommand(o *TopOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trials EXPERIMENT_NAME",
+		Short: "Display the cheapest and fastest trials",
+		Long: "Rank the completed trials of an experiment by cost and performance, including the estimated" +
+			" monthly savings compared to the baseline trial",
+		Args: cobra.ExactArgs(1),
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			o.ExperimentName = args[0]
+			commander.SetStreams(&o.IOStreams, cmd)
+			return commander.SetExperimentsAPI(&o.ExperimentsAPI, o.Config, cmd)
+		},
+		RunE: commander.WithContextE(o.top),
+	}
+
+	cmd.Flags().StringVar(&o.CostMetric, "cost-metric", "", "the `name` of the cost metric, defaults to the first optimized metric containing \"cost\"")
+	cmd.Flags().StringVar(&o.PerformanceMetric, "performance-metric", "", "the `name` of the performance metric, defaults to the first optimized metric which is not the cost")
+	cmd.Flags().StringToStringVar(&o.Weights, "weight", nil, "the relative `weight` of a metric used to rank balanced trials, defaults to the weights of the experiment")
+	cmd.Flags().IntVar(&o.Limit, "limit", 5, "the maximum `number` of trials to include in each ranking")
+
+	return cmd
+}
+
+func (o *TopOptions) top(ctx context.Context) error {
+	exp, tl, err := getTrials(ctx, o.ExperimentsAPI, o.ExperimentName, false)
+	if err != nil {
+		return err
+	}
+
+	s, err := newTrialSummary(exp, tl.Trials, o.CostMetric, o.PerformanceMetric)
+	if err != nil {
+		return err
+	}
+
+	weights, err := metricWeights(exp, o.Weights)
+	if err != nil {
+		return err
+	}
+	s.balance(exp, tl.Trials, weights)
+
+	return s.write(o.Out, o.Limit)
+}
+
+// trialSummary ranks the completed trials of an experiment by cost and performance.
+type trialSummary struct {
+	cost        experimentsv1alpha1.Metric
+	cpu         string
+	memory      string
+	performance *experimentsv1alpha1.Metric
+	baseline    *experimentsv1alpha1.TrialItem
+	cheapest    []*experimentsv1alpha1.TrialItem
+	fastest     []*experimentsv1alpha1.TrialItem
+	balanced    []*experimentsv1alpha1.TrialItem
+}
+
+// newTrialSummary finds the cost components and performance metric of the experiment and ranks the trials.
+func newTrialSummary(exp *experimentsv1alpha1.Experiment, trials []experimentsv1alpha1.TrialItem, costMetric, performanceMetric string) (*trialSummary, error) {
+	s := &trialSummary{}
+
+	var costFound bool
+	for _, m := range exp.Metrics {
+		if costMetric == "" && (m.Optimize == nil || *m.Optimize) && strings.Contains(m.Name, "cost") {
+			costMetric = m.Name
+		}
+		if m.Name == costMetric {
+			s.cost, costFound = m, true
+		}
+	}
+	if !costFound {
+		return nil, fmt.Errorf("unable to find a cost metric for experiment %q, use --cost-metric to specify one", exp.DisplayName)
+	}
+
+	for i := range exp.Metrics {
+		m := &exp.Metrics[i]
+		switch {
+		case m.Name == s.cost.Name+"-cpu-requests":
+			s.cpu = m.Name
+		case m.Name == s.cost.Name+"-memory-requests":
+			s.memory = m.Name
+		case m.Name == s.cost.Name:
+			// Do not rank performance by cost
+		case performanceMetric == "" && s.performance == nil && (m.Optimize == nil || *m.Optimize):
+			s.performance = m
+		case m.Name == performanceMetric:
+			s.performance = m
+		}
+	}
+	if performanceMetric != "" && s.performance == nil {
+		return nil, fmt.Errorf("unable to find performance metric %q for experiment %q", performanceMetric, exp.DisplayName)
+	}
+
+	for i := range trials {
+		t := &trials[i]
+		if t.Status != experimentsv1alpha1.TrialCompleted {
+			continue
+		}
+		if t.Labels["baseline"] == "true" {
+			s.baseline = t
+		}
+		if _, ok := metricValue(t, s.cost.Name); ok {
+			s.cheapest = append(s.cheapest, t)
+		}
+		if s.performance != nil {
+			if _, ok := metricValue(t, s.performance.Name); ok {
+				s.fastest = append(s.fastest, t)
+			}
+		}
+	}
+
+	rank(s.cheapest, s.cost.Name, true)
+	if s.performance != nil {
+		rank(s.fastest, s.performance.Name, s.performance.Minimize)
+	}
+
+	return s, nil
+}
+
+// write produces a human readable summary of the rankings.
+func (s *trialSummary) write(out io.Writer, limit int) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+
+	columns := []string{s.cost.Name, s.cpu, s.memory}
+	if s.performance != nil {
+		columns = append(columns, s.performance.Name)
+	}
+
+	s.writeRanking(w, "CHEAPEST", columns, s.cheapest, limit)
+	if s.performance != nil {
+		_, _ = fmt.Fprintln(w)
+		s.writeRanking(w, "FASTEST", columns, s.fastest, limit)
+	}
+	if len(s.balanced) > 0 {
+		_, _ = fmt.Fprintln(w)
+		s.writeRanking(w, "BALANCED", columns, s.balanced, limit)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(s.cheapest) == 0 {
+		_, err := fmt.Fprintf(out, "\nNo completed trials reported %s\n", s.cost.Name)
+		return err
+	}
+
+	_, _ = fmt.Fprintln(out)
+	if s.baseline != nil {
+		cost, _ := metricValue(s.baseline, s.cost.Name)
+		_, _ = fmt.Fprintf(out, "Baseline trial %d has an estimated monthly %s of %.2f\n", s.baseline.Number, s.cost.Name, cost)
+	}
+	_, _ = fmt.Fprintf(out, "Cheapest trial %d %s\n", s.cheapest[0].Number, s.compareToBaseline(s.cheapest[0]))
+	if len(s.fastest) > 0 {
+		_, _ = fmt.Fprintf(out, "Fastest trial %d %s\n", s.fastest[0].Number, s.compareToBaseline(s.fastest[0]))
+	}
+	if len(s.balanced) > 0 {
+		_, _ = fmt.Fprintf(out, "Balanced trial %d %s\n", s.balanced[0].Number, s.compareToBaseline(s.balanced[0]))
+	}
+	return nil
+}
+
+// balance ranks the trials reporting every weighted metric using the weighted sum of their normalized metric values.
+func (s *trialSummary) balance(exp *experimentsv1alpha1.Experiment, trials []experimentsv1alpha1.TrialItem, weights map[string]float64) {
+	var metrics []experimentsv1alpha1.Metric
+	for _, m := range exp.Metrics {
+		if weights[m.Name] > 0 {
+			metrics = append(metrics, m)
+		}
+	}
+	if len(metrics) == 0 {
+		return
+	}
+
+	// Find the range of each metric so values on different scales can be blended
+	low, high := make(map[string]float64, len(metrics)), make(map[string]float64, len(metrics))
+	for i := range trials {
+		t := &trials[i]
+		if t.Status != experimentsv1alpha1.TrialCompleted || !hasMetricValues(t, metrics) {
+			continue
+		}
+		for _, m := range metrics {
+			v, _ := metricValue(t, m.Name)
+			if l, ok := low[m.Name]; !ok || v < l {
+				low[m.Name] = v
+			}
+			if h, ok := high[m.Name]; !ok || v > h {
+				high[m.Name] = v
+			}
+		}
+		s.balanced = append(s.balanced, t)
+	}
+
+	// Lower scores are better, each metric contributes between zero and its weight
+	scores := make(map[*experimentsv1alpha1.TrialItem]float64, len(s.balanced))
+	for _, t := range s.balanced {
+		for _, m := range metrics {
+			r := high[m.Name] - low[m.Name]
+			if r == 0 {
+				continue
+			}
+			v, _ := metricValue(t, m.Name)
+			n := (v - low[m.Name]) / r
+			if !m.Minimize {
+				n = 1 - n
+			}
+			scores[t] += weights[m.Name] * n
+		}
+	}
+
+	sort.SliceStable(s.balanced, func(i, j int) bool {
+		return scores[s.balanced[i]] < scores[s.balanced[j]]
+	})
+}
+
+// metricWeights returns the relative weights of the experiment metrics, the supplied weights replace the weights
+// recorded on the experiment.
+func metricWeights(exp *experimentsv1alpha1.Experiment, weights map[string]string) (map[string]float64, error) {
+	values := make(map[string]string, len(exp.Labels)+len(weights))
+	for k, v := range exp.Labels {
+		if name := strings.TrimPrefix(k, server.MetricWeightLabelPrefix); name != k {
+			values[name] = v
+		}
+	}
+	if len(weights) > 0 {
+		values = weights
+	}
+
+	result := make(map[string]float64, len(values))
+	for name, v := range values {
+		w, err := strconv.ParseFloat(v, 64)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight for metric %q: %s", name, v)
+		}
+		result[name] = w
+	}
+	return result, nil
+}
+
+// hasMetricValues checks to see if the trial reported a value for each of the metrics.
+func hasMetricValues(t *experimentsv1alpha1.TrialItem, metrics []experimentsv1alpha1.Metric) bool {
+	for _, m := range metrics {
+		if _, ok := metricValue(t, m.Name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// writeRanking writes a table of the top ranked trials.
+func (s *trialSummary) writeRanking(w io.Writer, title string, columns []string, trials []*experimentsv1alpha1.TrialItem, limit int) {
+	header := []string{title}
+	for _, c := range columns {
+		if c != "" {
+			header = append(header, strings.ToUpper(c))
+		}
+	}
+	if s.baseline != nil {
+		header = append(header, "SAVINGS")
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	for i, t := range trials {
+		if limit > 0 && i >= limit {
+			break
+		}
+
+		row := []string{trialNumber(t)}
+		for _, c := range columns {
+			if c != "" {
+				row = append(row, formatMetricValue(t, c))
+			}
+		}
+		if s.baseline != nil {
+			row = append(row, s.formatSavings(t))
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+}
+
+// compareToBaseline describes the estimated monthly savings of a trial.
+func (s *trialSummary) compareToBaseline(t *experimentsv1alpha1.TrialItem) string {
+	cost, ok := metricValue(t, s.cost.Name)
+	if !ok {
+		return fmt.Sprintf("did not report %s", s.cost.Name)
+	}
+	if s.baseline == nil {
+		return fmt.Sprintf("has an estimated monthly %s of %.2f", s.cost.Name, cost)
+	}
+
+	savings, _ := s.savings(t)
+	if savings < 0 {
+		return fmt.Sprintf("costs an estimated %.2f per month more than the baseline", -savings)
+	}
+	return fmt.Sprintf("saves an estimated %.2f per month compared to the baseline (%s)", savings, s.formatSavings(t))
+}
+
+// savings returns the difference in cost between the baseline and the supplied trial.
+func (s *trialSummary) savings(t *experimentsv1alpha1.TrialItem) (float64, bool) {
+	cost, ok := metricValue(t, s.cost.Name)
+	if !ok || s.baseline == nil {
+		return 0, false
+	}
+	baseline, ok := metricValue(s.baseline, s.cost.Name)
+	if !ok {
+		return 0, false
+	}
+	return baseline - cost, true
+}
+
+// formatSavings returns the savings as a percentage of the baseline cost.
+func (s *trialSummary) formatSavings(t *experimentsv1alpha1.TrialItem) string {
+	savings, ok := s.savings(t)
+	if !ok {
+		return "-"
+	}
+	baseline, _ := metricValue(s.baseline, s.cost.Name)
+	if baseline == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", savings/baseline*100)
+}
+
+// rank sorts the trials from best to worst value of the named metric.
+func rank(trials []*experimentsv1alpha1.TrialItem, name string, minimize bool) {
+	sort.SliceStable(trials, func(i, j int) bool {
+		vi, _ := metricValue(trials[i], name)
+		vj, _ := metricValue(trials[j], name)
+		if minimize {
+			return vi < vj
+		}
+		return vi > vj
+	})
+}
+
+// metricValue returns the observed value of a metric for a trial.
+func metricValue(t *experimentsv1alpha1.TrialItem, name string) (float64, bool) {
+	v, err := strconv.ParseFloat(value(t, name), 64)
+	return v, err == nil
+}
+
+// formatMetricValue returns the formatted value of a metric, "-" if it was not observed.
+func formatMetricValue(t *experimentsv1alpha1.TrialItem, name string) string {
+	if v, ok := metricValue(t, name); ok {
+		return formatFloat(&v)
+	}
+	return "-"
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+func TestTrialSummary(t *testing.T) {
+	newTrial := func(number int64, baseline bool, cost, cpu, memory, latency float64) experimentsv1alpha1.TrialItem {
+		ti := experimentsv1alpha1.TrialItem{
+			Number: number,
+			Status: experimentsv1alpha1.TrialCompleted,
+			TrialValues: experimentsv1alpha1.TrialValues{
+				Values: []experimentsv1alpha1.Value{
+					{MetricName: "cost", Value: cost},
+					{MetricName: "cost-cpu-requests", Value: cpu},
+					{MetricName: "cost-memory-requests", Value: memory},
+					{MetricName: "p95-latency", Value: latency},
+				},
+			},
+		}
+		if baseline {
+			ti.Labels = map[string]string{"baseline": "true"}
+		}
+		return ti
+	}
+
+	nonOptimized := false
+	exp := &experimentsv1alpha1.Experiment{
+		DisplayName: "my-exp",
+		Metrics: []experimentsv1alpha1.Metric{
+			{Name: "cost", Minimize: true},
+			{Name: "p95-latency", Minimize: true},
+			{Name: "cost-cpu-requests", Minimize: true, Optimize: &nonOptimized},
+			{Name: "cost-memory-requests", Minimize: true, Optimize: &nonOptimized},
+		},
+	}
+	trials := []experimentsv1alpha1.TrialItem{
+		newTrial(1, true, 100, 4, 8, 50),
+		newTrial(2, false, 40, 1.5, 4, 120),
+		newTrial(3, false, 80, 3, 6, 30),
+		{Number: 4, Status: experimentsv1alpha1.TrialFailed},
+	}
+
+	s, err := newTrialSummary(exp, trials, "", "")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, s.write(&buf, 2))
+	assert.Equal(t, `CHEAPEST  COST  COST-CPU-REQUESTS  COST-MEMORY-REQUESTS  P95-LATENCY  SAVINGS
+2         40    1.5                4                     120          +60.0%
+3         80    3                  6                     30           +20.0%
+
+FASTEST  COST  COST-CPU-REQUESTS  COST-MEMORY-REQUESTS  P95-LATENCY  SAVINGS
+3        80    3                  6                     30           +20.0%
+1        100   4                  8                     50           +0.0%
+
+Baseline trial 1 has an estimated monthly cost of 100.00
+Cheapest trial 2 saves an estimated 60.00 per month compared to the baseline (+60.0%)
+Fastest trial 3 saves an estimated 20.00 per month compared to the baseline (+20.0%)
+`, buf.String())
+
+	_, err = newTrialSummary(&experimentsv1alpha1.Experiment{DisplayName: "my-exp"}, trials, "", "")
+	assert.EqualError(t, err, `unable to find a cost metric for experiment "my-exp", use --cost-metric to specify one`)
+}
+
+func TestTrialSummary_Balance(t *testing.T) {
+	newTrial := func(number int64, cost, latency float64) experimentsv1alpha1.TrialItem {
+		return experimentsv1alpha1.TrialItem{
+			Number: number,
+			Status: experimentsv1alpha1.TrialCompleted,
+			TrialValues: experimentsv1alpha1.TrialValues{
+				Values: []experimentsv1alpha1.Value{
+					{MetricName: "cost", Value: cost},
+					{MetricName: "p95-latency", Value: latency},
+				},
+			},
+		}
+	}
+
+	exp := &experimentsv1alpha1.Experiment{
+		DisplayName: "my-exp",
+		Metrics: []experimentsv1alpha1.Metric{
+			{Name: "cost", Minimize: true},
+			{Name: "p95-latency", Minimize: true},
+		},
+		Labels: map[string]string{
+			"weight.cost":        "0.7",
+			"weight.p95-latency": "0.3",
+		},
+	}
+	trials := []experimentsv1alpha1.TrialItem{
+		newTrial(1, 100, 50),
+		newTrial(2, 40, 120),
+		newTrial(3, 60, 30),
+		{Number: 4, Status: experimentsv1alpha1.TrialFailed},
+	}
+
+	cases := []struct {
+		desc     string
+		weights  map[string]string
+		expected []int64
+	}{
+		{
+			desc:     "experiment weights",
+			expected: []int64{3, 2, 1},
+		},
+		{
+			desc:     "explicit weights",
+			weights:  map[string]string{"p95-latency": "1"},
+			expected: []int64{3, 1, 2},
+		},
+		{
+			desc:    "no weights",
+			weights: map[string]string{"cost": "0"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s, err := newTrialSummary(exp, trials, "", "")
+			require.NoError(t, err)
+
+			weights, err := metricWeights(exp, c.weights)
+			require.NoError(t, err)
+			s.balance(exp, trials, weights)
+
+			var actual []int64
+			for _, ti := range s.balanced {
+				actual = append(actual, ti.Number)
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+
+	_, err := metricWeights(exp, map[string]string{"cost": "lots"})
+	assert.EqualError(t, err, `invalid weight for metric "cost": lots`)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+// watch polls the Experiments API and prints trials as they finish.
+func (o *Options) watch(ctx context.Context) error {
+	w := &trialWatcher{}
+	for {
+		exp, tl, err := getTrials(ctx, o.ExperimentsAPI, o.ExperimentName, false)
+		if err != nil {
+			return err
+		}
+
+		if err := w.update(o.Out, exp, tl.Trials); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(o.WatchInterval):
+		}
+	}
+}
+
+// trialWatcher keeps track of the finished trials that have already been reported.
+type trialWatcher struct {
+	seen map[int64]bool
+	// The normalized objective values of every completed trial seen so far
+	completed [][]float64
+}
+
+// update prints a line for each trial that has finished since the last update, the first update only
+// establishes the current state of the experiment without printing historical trials.
+func (w *trialWatcher) update(out io.Writer, exp *experimentsv1alpha1.Experiment, trials []experimentsv1alpha1.TrialItem) error {
+	initial := w.seen == nil
+	if initial {
+		w.seen = make(map[int64]bool, len(trials))
+	}
+
+	objectives := objectiveMetrics(exp.Metrics)
+	for i := range trials {
+		t := &trials[i]
+		if w.seen[t.Number] || (t.Status != experimentsv1alpha1.TrialCompleted && t.Status != experimentsv1alpha1.TrialFailed) {
+			continue
+		}
+		w.seen[t.Number] = true
+
+		var improved bool
+		if t.Status == experimentsv1alpha1.TrialCompleted && len(objectives) > 0 {
+			if v := objectiveValues(objectives, t); v != nil {
+				improved = w.improves(v)
+				w.completed = append(w.completed, v)
+			}
+		}
+
+		if initial {
+			continue
+		}
+
+		if _, err := fmt.Fprintln(out, formatTrial(exp, t, improved)); err != nil {
+			return err
+		}
+	}
+
+	if initial {
+		_, err := fmt.Fprintf(out, "Watching %s, %d trials finished so far\n", exp.DisplayName, len(w.seen))
+		return err
+	}
+
+	return nil
+}
+
+// improves checks to see if the supplied objective values are better than every previously completed trial, for
+// multi-objective experiments this means no previous trial is at least as good for every objective.
+func (w *trialWatcher) improves(v []float64) bool {
+	for _, c := range w.completed {
+		if noWorse(c, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTrial returns a one line summary of a finished trial.
+func formatTrial(exp *experimentsv1alpha1.Experiment, t *experimentsv1alpha1.TrialItem, improved bool) string {
+	name := fmt.Sprintf("%s-%03d", exp.DisplayName, t.Number)
+
+	if t.Status == experimentsv1alpha1.TrialFailed {
+		msg := fmt.Sprintf("%s failed", name)
+		if t.FailureReason != "" {
+			msg += ": " + t.FailureReason
+		}
+		if t.FailureMessage != "" {
+			msg += " (" + t.FailureMessage + ")"
+		}
+		return msg
+	}
+
+	values := make([]string, 0, len(exp.Metrics))
+	for i := range exp.Metrics {
+		if v := value(t, exp.Metrics[i].Name); v != "" {
+			values = append(values, fmt.Sprintf("%s=%s", exp.Metrics[i].Name, v))
+		}
+	}
+
+	msg := fmt.Sprintf("%s completed: %s", name, strings.Join(values, ", "))
+	if improved {
+		msg += " (new best)"
+	}
+	return msg
+}
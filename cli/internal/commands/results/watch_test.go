@@ -0,0 +1,99 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package results
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+func TestTrialWatcher(t *testing.T) {
+	exp := &experimentsv1alpha1.Experiment{
+		DisplayName: "test",
+		Metrics: []experimentsv1alpha1.Metric{
+			{Name: "cost", Minimize: true},
+		},
+	}
+
+	newTrial := func(number int64, status experimentsv1alpha1.TrialStatus, cost float64) experimentsv1alpha1.TrialItem {
+		t := experimentsv1alpha1.TrialItem{Number: number, Status: status}
+		if status == experimentsv1alpha1.TrialCompleted {
+			t.Values = []experimentsv1alpha1.Value{{MetricName: "cost", Value: cost}}
+		}
+		if status == experimentsv1alpha1.TrialFailed {
+			t.FailureReason = "Timeout"
+		}
+		return t
+	}
+
+	completed0 := newTrial(0, experimentsv1alpha1.TrialCompleted, 10)
+	completed1 := newTrial(1, experimentsv1alpha1.TrialCompleted, 5)
+
+	cases := []struct {
+		desc     string
+		trials   []experimentsv1alpha1.TrialItem
+		expected string
+	}{
+		{
+			desc: "initial",
+			trials: []experimentsv1alpha1.TrialItem{
+				completed0,
+				newTrial(1, experimentsv1alpha1.TrialActive, 0),
+			},
+			expected: "Watching test, 1 trials finished so far\n",
+		},
+		{
+			desc: "no change",
+			trials: []experimentsv1alpha1.TrialItem{
+				completed0,
+				newTrial(1, experimentsv1alpha1.TrialActive, 0),
+			},
+		},
+		{
+			desc: "improved",
+			trials: []experimentsv1alpha1.TrialItem{
+				completed0,
+				completed1,
+				newTrial(2, experimentsv1alpha1.TrialActive, 0),
+			},
+			expected: "test-001 completed: cost=5 (new best)\n",
+		},
+		{
+			desc: "not improved",
+			trials: []experimentsv1alpha1.TrialItem{
+				completed0,
+				completed1,
+				newTrial(2, experimentsv1alpha1.TrialCompleted, 7),
+				newTrial(3, experimentsv1alpha1.TrialFailed, 0),
+			},
+			expected: "test-002 completed: cost=7\ntest-003 failed: Timeout\n",
+		},
+	}
+
+	w := &trialWatcher{}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			require.NoError(t, w.update(out, exp, c.trials))
+			assert.Equal(t, c.expected, out.String())
+		})
+	}
+}
@@ -70,7 +70,7 @@ func (o *Options) revoke(ctx context.Context) error {
 		_, _ = fmt.Fprintf(o.Out, "Revoked credential '%s'.\n", ri)
 	}
 	if ri.Authorization.Credential.ClientCredential != nil {
-		_, _ = fmt.Fprintf(o.Out, "Unable to revoke client credential '%s', removing reference from configuration", ri)
+		_, _ = fmt.Fprintf(o.Out, "Unable to revoke client credential '%s', removing reference from configuration\n", ri)
 	}
 
 	if err := o.Config.Update(ri.RemoveAuthorization()); err != nil {
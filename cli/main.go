@@ -21,10 +21,13 @@ import (
 	crypto_rand "crypto/rand"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"math/rand"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-controller/v2/cli/internal/commands"
@@ -50,6 +53,11 @@ func main() {
 	// Create a new root command
 	cmd := commands.NewRootCommand()
 
+	// Let users of the legacy binary name know it has been renamed
+	if name := filepath.Base(os.Args[0]); strings.HasPrefix(name, "redskyctl") {
+		_, _ = fmt.Fprintf(os.Stderr, "The %s binary has been renamed to %s, please update your scripts\n", name, cmd.Root().Name())
+	}
+
 	// TODO Include OS, etc. in comment?
 	uaRoundTripper := version.UserAgent(cmd.Root().Name(), "", nil)
 
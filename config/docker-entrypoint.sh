@@ -15,6 +15,24 @@ case "$1" in
 		chart: ../prometheus
 		EOF
 
+    # Scrape the exporters installed by the built-in exporters setup task
+    values=""
+    if [ "$EXPORTERS" = "true" ]; then
+      values="$values promServer.scrapes.exporters"
+    fi
+
+    # Only use namespaced roles when no cluster scoped permissions were granted
+    if [ "$NAMESPACED_RBAC" = "true" ]; then
+      values="$values rbac.namespaced"
+    fi
+
+    if [ -n "$values" ]; then
+      echo "values:" >>helm.yaml
+      for v in $values; do
+        printf -- '- name: %s\n  value: true\n' "$v" >>helm.yaml
+      done
+    fi
+
     export HELM_CONFIG=$(cat helm.yaml | base64 -w0)
 
     waitFn() {
@@ -22,6 +40,34 @@ case "$1" in
       kubectl wait --for condition=Available=true --namespace "${NAMESPACE}" --timeout 5m deployment.apps "optimize-${NAMESPACE}-prometheus-server"
     }
   ;;
+  exporters)
+    shift
+
+    cat <<-EOF >helm.yaml
+		apiVersion: konjure.carbonrelay.com/v1beta1
+		kind: HelmGenerator
+		metadata:
+		  name: exporters
+		releaseName: optimize-${NAMESPACE}-exporters
+		releaseNamespace: ${NAMESPACE}
+		chart: ../exporters
+		EOF
+
+    # The node exporter runs on every node, only include it when explicitly requested
+    if [ "$NODE_EXPORTER" = "true" ]; then
+      printf -- 'values:\n- name: nodeExporter.enabled\n  value: true\n' >>helm.yaml
+    fi
+
+    export HELM_CONFIG=$(cat helm.yaml | base64 -w0)
+
+    waitFn() {
+      # Wait on {{ releaseName }}-kube-state-metrics and {{ releaseName }}-node-exporter
+      kubectl wait --for condition=Available=true --namespace "${NAMESPACE}" --timeout 5m deployment.apps "optimize-${NAMESPACE}-exporters-kube-state-metrics"
+      if [ "$NODE_EXPORTER" = "true" ]; then
+        kubectl rollout status --namespace "${NAMESPACE}" --timeout 5m daemonset.apps "optimize-${NAMESPACE}-exporters-node-exporter"
+      fi
+    }
+  ;;
   *)
     waitFn() { :; }
   ;;
@@ -53,6 +99,13 @@ if [ -n "$HELM_CONFIG" ] ; then
       echo "releaseName: optimize-${NAMESPACE}-prometheus" >> helm.yaml
     fi
 
+    # Ensure releaseName is present when the chart is our local exporters
+    if [ -n "$(grep -e 'chart: .*../exporters' helm.yaml)" ] && \
+       [ -z "$(grep -w releaseName helm.yaml)" ] && \
+       [ -n "$NAMESPACE" ]; then
+      echo "releaseName: optimize-${NAMESPACE}-exporters" >> helm.yaml
+    fi
+
     # Ensure releaseNamespace is present
     if [ -z "$(grep -w releaseNamespace helm.yaml)" ] && [ -n "$NAMESPACE" ]; then
       echo "releaseNamespace: ${NAMESPACE}" >> helm.yaml
@@ -63,7 +116,8 @@ fi
 
 
 # Add trial labels to the resulting manifests so they can be more easily located
-if [ -n "$TRIAL" ]; then
+# (patches are applied to existing resources which do not belong to the trial)
+if [ -n "$TRIAL" ] && [ "$1" != "patch" ] && [ "$1" != "restore" ]; then
     # Note, this heredoc block must be indented with tabs
     # <<- allows for indentation via tabs, if spaces are used it is no good.
     cat <<-EOF >"trial_labels.yaml"
@@ -81,6 +135,13 @@ if [ -n "$TRIAL" ]; then
 fi
 
 
+# Wait for the workloads in a file to finish rolling out
+rolloutStatus () {
+    kubectl get -f "$1" -o name | grep -E '^(deployment|statefulset|daemonset)\.apps/' | \
+        xargs -r -n1 kubectl rollout status --namespace "$NAMESPACE" --timeout=5m
+}
+
+
 # Process arguments
 while [ "$#" != "0" ] ; do
     case "$1" in
@@ -90,6 +151,45 @@ while [ "$#" != "0" ] ; do
             kubectl create -f -
         }
 
+        shift
+        ;;
+    patch)
+        handle () {
+            cat >patch.yaml
+
+            # Record the existing release objects so the delete job can restore them
+            kubectl get -f patch.yaml --ignore-not-found -o yaml | sed -E '/^ {2,4}resourceVersion: /d' >original.yaml
+            kubectl create configmap "${TRIAL}-${NAME}-restore" --namespace "$NAMESPACE" --from-file=original.yaml --dry-run -o yaml | kubectl apply -f -
+
+            # Apply the re-rendered chart over the existing release so only the differences are changed
+            kubectl apply -f patch.yaml
+        }
+
+        waitFn () {
+            rolloutStatus patch.yaml
+        }
+
+        shift
+        ;;
+    restore)
+        handle () {
+            cat >/dev/null
+
+            # Replace the patched release objects with the versions recorded before the patch was applied
+            if kubectl get configmap "${TRIAL}-${NAME}-restore" --namespace "$NAMESPACE" -o jsonpath='{.data.original\.yaml}' >original.yaml ; then
+                if [ -s original.yaml ]; then
+                    kubectl replace -f original.yaml
+                fi
+                kubectl delete configmap "${TRIAL}-${NAME}-restore" --namespace "$NAMESPACE"
+            fi
+        }
+
+        waitFn () {
+            if [ -s original.yaml ]; then
+                rolloutStatus original.yaml
+            fi
+        }
+
         shift
         ;;
     delete)
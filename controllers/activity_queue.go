@@ -0,0 +1,149 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+)
+
+// defaultActivityWorkers is the number of activities processed concurrently by default
+const defaultActivityWorkers = 4
+
+// activityQueue processes activities using a bounded number of workers. Activities for
+// different applications are processed concurrently while activities for the same
+// application are processed in the order they were received.
+type activityQueue struct {
+	handle  func(context.Context, applications.ActivityItem)
+	workers chan struct{}
+
+	mu      sync.Mutex
+	pending map[string][]applications.ActivityItem
+	wg      sync.WaitGroup
+}
+
+// newActivityQueue returns a queue which processes at most the specified number of activities at once.
+func newActivityQueue(workers int, handle func(context.Context, applications.ActivityItem)) *activityQueue {
+	if workers < 1 {
+		workers = defaultActivityWorkers
+	}
+
+	return &activityQueue{
+		handle:  handle,
+		workers: make(chan struct{}, workers),
+		pending: make(map[string][]applications.ActivityItem),
+	}
+}
+
+// Add queues an activity for processing.
+func (q *activityQueue) Add(ctx context.Context, activity applications.ActivityItem) {
+	key := activityApplication(activity)
+
+	q.mu.Lock()
+	backlog, active := q.pending[key]
+	q.pending[key] = append(backlog, activity)
+	q.mu.Unlock()
+
+	// There is already a goroutine processing activities for this application
+	if active {
+		return
+	}
+
+	q.wg.Add(1)
+	go q.process(ctx, key)
+}
+
+// Wait blocks until all of the queued activities have been processed.
+func (q *activityQueue) Wait() {
+	q.wg.Wait()
+}
+
+// process handles the pending activities for a single application, in order.
+func (q *activityQueue) process(ctx context.Context, key string) {
+	defer q.wg.Done()
+
+	for {
+		q.mu.Lock()
+		backlog := q.pending[key]
+		if len(backlog) == 0 {
+			delete(q.pending, key)
+			q.mu.Unlock()
+			return
+		}
+		activity := backlog[0]
+		q.pending[key] = backlog[1:]
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			// Drop anything left on the backlog
+			continue
+		case q.workers <- struct{}{}:
+		}
+
+		q.handle(ctx, activity)
+		<-q.workers
+	}
+}
+
+// activityApplication returns the key used to serialize activities for the same application.
+func activityApplication(activity applications.ActivityItem) string {
+	// The external URL references a scenario nested under the application
+	if pos := strings.LastIndex(activity.ExternalURL, "/scenarios/"); pos >= 0 {
+		return activity.ExternalURL[:pos]
+	}
+	return activity.ExternalURL
+}
+
+// maxProcessedActivities is the number of activity identifiers remembered to detect redelivery
+const maxProcessedActivities = 1024
+
+// processedActivities remembers a bounded number of the most recently processed activity identifiers.
+type processedActivities struct {
+	mu    sync.Mutex
+	seen  map[string]struct{}
+	order []string
+	size  int
+}
+
+// newProcessedActivities returns a set remembering up to the specified number of activity identifiers.
+func newProcessedActivities(size int) *processedActivities {
+	return &processedActivities{seen: make(map[string]struct{}, size), size: size}
+}
+
+// Mark records the activity identifier, returning false if it was already processed.
+func (p *processedActivities) Mark(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.seen[id]; ok {
+		return false
+	}
+
+	// Forget the oldest identifier once we reach capacity
+	if len(p.order) >= p.size {
+		delete(p.seen, p.order[0])
+		p.order = p.order[1:]
+	}
+
+	p.seen[id] = struct{}{}
+	p.order = append(p.order, id)
+	return true
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+)
+
+func TestActivityApplication(t *testing.T) {
+	cases := []struct {
+		desc        string
+		externalURL string
+		expected    string
+	}{
+		{
+			desc:        "scenario",
+			externalURL: "https://example.com/v2/applications/app1/scenarios/scn1",
+			expected:    "https://example.com/v2/applications/app1",
+		},
+		{
+			desc:        "no scenario",
+			externalURL: "https://example.com/v2/applications/app1",
+			expected:    "https://example.com/v2/applications/app1",
+		},
+		{
+			desc: "empty",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, activityApplication(applications.ActivityItem{ExternalURL: c.externalURL}))
+		})
+	}
+}
+
+func TestActivityQueue(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		order     = map[string][]string{}
+		running   int
+		maxActive int
+	)
+
+	handle := func(ctx context.Context, activity applications.ActivityItem) {
+		mu.Lock()
+		running++
+		if running > maxActive {
+			maxActive = running
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		key := activityApplication(activity)
+		order[key] = append(order[key], activity.ID)
+		mu.Unlock()
+	}
+
+	q := newActivityQueue(2, handle)
+	ctx := context.Background()
+	for _, app := range []string{"a", "b", "c"} {
+		for _, id := range []string{"1", "2", "3"} {
+			q.Add(ctx, applications.ActivityItem{
+				ID:          app + id,
+				ExternalURL: "https://example.com/v2/applications/" + app + "/scenarios/" + id,
+			})
+		}
+	}
+	q.Wait()
+
+	assert.Equal(t, map[string][]string{
+		"https://example.com/v2/applications/a": {"a1", "a2", "a3"},
+		"https://example.com/v2/applications/b": {"b1", "b2", "b3"},
+		"https://example.com/v2/applications/c": {"c1", "c2", "c3"},
+	}, order)
+	assert.Equal(t, 2, maxActive)
+}
+
+func TestProcessedActivities(t *testing.T) {
+	p := newProcessedActivities(2)
+	assert.True(t, p.Mark("a"))
+	assert.True(t, p.Mark("b"))
+	assert.False(t, p.Mark("a"))
+
+	// Adding a third identifier forgets the oldest
+	assert.True(t, p.Mark("c"))
+	assert.True(t, p.Mark("a"))
+	assert.False(t, p.Mark("c"))
+}
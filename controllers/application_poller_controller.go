@@ -17,9 +17,13 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base32"
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -30,6 +34,7 @@ import (
 	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/application"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
 	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
 	"github.com/thestormforge/optimize-controller/v2/internal/scan"
 	"github.com/thestormforge/optimize-controller/v2/internal/server"
@@ -37,89 +42,250 @@ import (
 	"github.com/thestormforge/optimize-controller/v2/internal/version"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metameta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/kyaml/kio"
 )
 
+// PollerOptions control how the poller communicates with the application service and scans resources.
+type PollerOptions struct {
+	// The user agent comment sent to the application service, defaults to the controller version.
+	UserAgent string
+	// The maximum amount of time a single kubectl invocation can take during a scan, zero for no limit.
+	ScanTimeout time.Duration
+	// The kubectl binary used to scan resources, the in-process kubectl is used when empty.
+	KubectlPath string
+	// Flag indicating that resources should be scanned using the kubeconfig discovery rules instead
+	// of the controller's in-cluster configuration.
+	UseKubeconfig bool
+	// The maximum number of activities processed concurrently, defaults to 4.
+	Workers int
+	// The namespace used for activity runs when it cannot be determined from the application.
+	Namespace string
+	// The name of the pod the controller is running in, used to report the activity feed connection status.
+	PodName string
+	// The namespace of the pod the controller is running in.
+	PodNamespace string
+}
+
 // Poller handles checking with the Application Services to trigger an in cluster
 // activity such as scanning resources or running an experiment.
 type Poller struct {
 	Log        logr.Logger
+	Recorder   record.EventRecorder
+	Options    PollerOptions
 	client     client.Client
 	apiClient  applications.API
+	previewAPI server.PreviewAPI
 	filterOpts scan.FilterOptions
+	processed  *processedActivities
+	pod        *corev1.Pod
+	connected  corev1.ConditionStatus
 }
 
 func (p *Poller) SetupWithManager(mgr ctrl.Manager) error {
-	appAPI, err := server.NewApplicationAPI(context.Background(), version.GetInfo().String())
+	userAgent := p.Options.UserAgent
+	if userAgent == "" {
+		userAgent = version.GetInfo().String()
+	}
+
+	appAPI, err := server.NewApplicationAPI(context.Background(), userAgent)
 	if err != nil {
 		p.Log.Info("Application API is unavailable, skipping setup", "message", err.Error())
 		return nil
 	}
 
 	p.apiClient = appAPI
+	if p.previewAPI, err = server.NewPreviewAPI(context.Background(), userAgent); err != nil {
+		p.Log.Info("Preview API is unavailable, previews will fail", "message", err.Error())
+	}
 	p.client = mgr.GetClient()
-	p.filterOpts.KubectlOptions = append(p.filterOpts.KubectlOptions,
-		scan.WithKubectlRESTConfig(mgr.GetConfig()),
-	)
+	if p.Recorder == nil {
+		p.Recorder = mgr.GetEventRecorderFor("application-poller")
+	}
+	if !p.Options.UseKubeconfig {
+		p.filterOpts.KubectlOptions = append(p.filterOpts.KubectlOptions, scan.WithKubectlRESTConfig(mgr.GetConfig()))
+	}
+	if p.Options.KubectlPath != "" {
+		p.filterOpts.KubectlOptions = append(p.filterOpts.KubectlOptions, scan.WithKubectlPath(p.Options.KubectlPath))
+	}
+	if p.Options.ScanTimeout > 0 {
+		p.filterOpts.KubectlOptions = append(p.filterOpts.KubectlOptions, scan.WithKubectlTimeout(p.Options.ScanTimeout))
+	}
+	if p.Options.PodName != "" && p.Options.PodNamespace != "" {
+		pod := &corev1.Pod{}
+		podKey := client.ObjectKey{Namespace: p.Options.PodNamespace, Name: p.Options.PodName}
+		if err := mgr.GetAPIReader().Get(context.Background(), podKey, pod); err != nil {
+			p.Log.Info("Controller pod is unavailable, connection status will not be reported", "message", err.Error())
+		} else {
+			p.pod = pod
+		}
+	}
 
 	return mgr.Add(p)
 }
 
+// tagPreview is the activity tag used to request the manifests for a run without applying them.
+const tagPreview = "preview"
+
+const (
+	// activityFieldManager is the server-side apply field manager (and managed-by label value) for activity objects.
+	activityFieldManager = "optimize-controller"
+	// activityManagedByLabel is the label used to identify objects applied for an activity.
+	activityManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// activityMinBackoff is the initial delay before reconnecting to the application service.
+	activityMinBackoff = time.Second
+	// activityMaxBackoff is the longest delay between attempts to reconnect to the application service.
+	activityMaxBackoff = 5 * time.Minute
+	// activityStableConnection is how long a subscription must last before the backoff is reset.
+	activityStableConnection = time.Minute
+
+	// ActivityConnected is the controller pod condition reflecting the activity feed subscription.
+	ActivityConnected corev1.PodConditionType = "stormforge.io/application-activity-connected"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get
+// +kubebuilder:rbac:groups="",resources=pods/status,verbs=patch
+
 // Start is used to initiate the polling loop for new tasks.
 // Start satisfies the controller-runtime/manager.Runnable interface so we
 // can plug into the underlying controller runtime manager that the rest of the
 // controllers use.
-// If there was an issue connecting to the application services, this will immediately
-// return.
+// If there was an issue connecting to the application services, the connection
+// is retried using an exponential backoff until the manager is stopped.
 func (p *Poller) Start(ch <-chan struct{}) error {
 	p.Log.Info("Starting application poller")
 
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	// Handle controller-manager signal
 	go func() {
 		for range ch {
 			cancel()
 		}
+		cancel()
 	}()
 
 	query := applications.ActivityFeedQuery{}
-	query.SetType(applications.TagScan, applications.TagRun)
-	subscriber, err := p.apiClient.SubscribeActivity(ctx, query)
-	if err != nil {
-		p.Log.Error(err, "Unable to connect to application service")
-		return nil
+	query.SetType(applications.TagScan, applications.TagRun, tagPreview)
+
+	// Activities are queued so a slow task does not block unrelated applications
+	queue := newActivityQueue(p.Options.Workers, p.handleActivity)
+	if p.processed == nil {
+		p.processed = newProcessedActivities(maxProcessedActivities)
 	}
+	defer queue.Wait()
 
+	var failures int
 	for {
-		activityCh := make(chan applications.ActivityItem)
+		start := time.Now()
+		err := p.subscribe(ctx, query, queue)
+		controller.ApplicationActivityConnected.Set(0)
 
-		go func() {
-			for activityItem := range activityCh {
-				p.handleActivity(ctx, activityItem)
-			}
-		}()
+		// Clean exit/shutdown
+		if err == nil || errors.Is(err, context.Canceled) || ctx.Err() != nil {
+			return nil
+		}
 
-		if err := subscriber.Subscribe(ctx, activityCh); err != nil {
-			// Clean exit/shutdown
-			if errors.Is(err, context.Canceled) {
-				return nil
-			}
+		// Do not penalize a long lived connection for an eventual interruption
+		if time.Since(start) > activityStableConnection {
+			failures = 0
+		}
+		failures++
 
-			p.Log.Error(err, "Application service connection interrupted, restarting")
+		delay := activityBackoff(failures)
+		controller.ApplicationActivityReconnects.Inc()
+		p.updateConnectedCondition(ctx, corev1.ConditionFalse, "ConnectionFailed", err.Error())
+		p.Log.Error(err, "Application service connection interrupted, reconnecting", "attempt", failures, "retryAfter", delay.String())
 
-			continue
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
 		}
+	}
+}
 
-		// Clean exit from subscriber should end here
-		return nil
+// subscribe connects to the application service activity feed and queues
+// activity until the subscription ends.
+func (p *Poller) subscribe(ctx context.Context, query applications.ActivityFeedQuery, queue *activityQueue) error {
+	subscriber, err := p.apiClient.SubscribeActivity(ctx, query)
+	if err != nil {
+		return fmt.Errorf("unable to connect to application service: %w", err)
 	}
+
+	controller.ApplicationActivityConnected.Set(1)
+	p.updateConnectedCondition(ctx, corev1.ConditionTrue, "Connected", "")
+
+	activityCh := make(chan applications.ActivityItem)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for activityItem := range activityCh {
+			queue.Add(ctx, activityItem)
+		}
+	}()
+
+	// The subscriber closes the channel when it returns
+	err = subscriber.Subscribe(ctx, activityCh)
+	<-done
+	return err
+}
+
+// activityBackoff returns the jittered delay before the specified reconnect attempt.
+func activityBackoff(attempt int) time.Duration {
+	d := activityMinBackoff
+	for i := 1; i < attempt && d < activityMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > activityMaxBackoff {
+		d = activityMaxBackoff
+	}
+	return wait.Jitter(d/2, 1.0)
+}
+
+// updateConnectedCondition records the state of the activity feed subscription on the controller pod.
+// The pod is only patched when the status changes so a flapping connection does not flood the API server.
+func (p *Poller) updateConnectedCondition(ctx context.Context, status corev1.ConditionStatus, reason, message string) {
+	if p.pod == nil || p.connected == status {
+		return
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []corev1.PodCondition{
+				{
+					Type:               ActivityConnected,
+					Status:             status,
+					Reason:             reason,
+					Message:            message,
+					LastTransitionTime: metav1.Now(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		p.Log.Error(err, "Failed to encode controller pod condition")
+		return
+	}
+
+	if err := p.client.Status().Patch(ctx, p.pod, client.RawPatch(types.StrategicMergePatchType, data)); err != nil {
+		p.Log.Error(err, "Failed to update controller pod condition", "condition", ActivityConnected)
+		return
+	}
+
+	p.connected = status
 }
 
 // handleActivity performs the task required for each activity.
@@ -127,15 +293,10 @@ func (p *Poller) Start(ch <-chan struct{}) error {
 // is converted into an api.Template consisting of parameters and metrics.
 // When an ActivityItem is tagged with run, the previous scanned template results are merged with
 // the results of an experiment generation workflow. Following this, the generated resources are applied/created
-// in the cluster.
+// in the cluster. When an ActivityItem is tagged with preview, the same resources are rendered and uploaded for
+// review instead of being created.
 // note, rbac defined in cli/internal/commands/grant_permissions/generator
 func (p *Poller) handleActivity(ctx context.Context, activity applications.ActivityItem) {
-	const (
-		ActivityReasonInvalidApplication = "InvalidApplication"
-		ActivityReasonGenerationFailed   = "GenerationFailed"
-		ActivityReasonScanFailed         = "ScanFailed"
-		ActivityReasonRunFailed          = "RunFailed"
-	)
 	log := p.Log.WithValues(
 		"activityId", activity.ID,
 		"activityTags", strings.Join(activity.Tags, ", "),
@@ -155,77 +316,72 @@ func (p *Poller) handleActivity(ctx context.Context, activity applications.Activ
 		}
 	}()
 
+	// A redelivered activity only needs to be acknowledged again
+	if p.processed != nil && !p.processed.Mark(activity.ID) {
+		log.Info("Ignoring previously processed activity")
+		return
+	}
+
 	log.Info("Starting activity task")
 
+	if failure := p.runActivity(ctx, log, activity); failure != nil {
+		p.handleErrors(ctx, log.WithValues("activityExternalURL", activity.ExternalURL), failure)
+	}
+}
+
+// runActivity performs the scan or run task for an activity, returning a description of the failure if the task
+// could not be completed.
+func (p *Poller) runActivity(ctx context.Context, log logr.Logger, activity applications.ActivityItem) *activityFailure {
+	const (
+		ActivityReasonInvalidApplication = "InvalidApplication"
+		ActivityReasonGenerationFailed   = "GenerationFailed"
+		ActivityReasonScanFailed         = "ScanFailed"
+		ActivityReasonRunFailed          = "RunFailed"
+		ActivityReasonPreviewFailed      = "PreviewFailed"
+	)
+
+	fail := func(reason, message string, err error) *activityFailure {
+		return &activityFailure{URL: activity.URL, Reason: reason, Message: message, Err: err}
+	}
+
 	// Activity feed provides us with a scenario URL
 	scenario, err := p.apiClient.GetScenario(ctx, activity.ExternalURL)
 	if err != nil {
-		log = log.WithValues("activityExternalURL", activity.ExternalURL)
-		p.handleErrors(ctx, log, activity.URL, ActivityReasonInvalidApplication, "Failed to get scenario", err)
-		return
+		return fail(ActivityReasonInvalidApplication, "Failed to get scenario", err)
 	}
 
 	// Need to fetch top level application so we can get the resources
 	applicationURL := scenario.Link(api.RelationUp)
 	if applicationURL == "" {
-		p.handleErrors(ctx, log, activity.URL, ActivityReasonInvalidApplication, "No matching application URL for scenario", nil)
-		return
+		return fail(ActivityReasonInvalidApplication, "No matching application URL for scenario", nil)
 	}
 
 	templateURL := scenario.Link(api.RelationTemplate)
 	if templateURL == "" {
-		p.handleErrors(ctx, log, activity.URL, ActivityReasonInvalidApplication, "No matching template URL for scenario", nil)
-		return
+		return fail(ActivityReasonInvalidApplication, "No matching template URL for scenario", nil)
 	}
 
 	experimentURL := scenario.Link(api.RelationExperiments)
 	if experimentURL == "" {
-		p.handleErrors(ctx, log, activity.URL, ActivityReasonInvalidApplication, "No matching experiment URL for scenario", nil)
-		return
+		return fail(ActivityReasonInvalidApplication, "No matching experiment URL for scenario", nil)
 	}
 
 	apiApp, err := p.apiClient.GetApplication(ctx, applicationURL)
 	if err != nil {
-		p.handleErrors(ctx, log, activity.URL, ActivityReasonInvalidApplication, "Failed to get application", err)
-		return
+		return fail(ActivityReasonInvalidApplication, "Failed to get application", err)
 	}
 
 	var assembledApp *optimizeappsv1alpha1.Application
 	if assembledApp, err = server.APIApplicationToClusterApplication(apiApp, scenario); err != nil {
-		p.handleErrors(ctx, log, activity.URL, ActivityReasonGenerationFailed, "Failed to assemble application", err)
-		return
+		return fail(ActivityReasonGenerationFailed, "Failed to assemble application", err)
 	}
 
-	// Use resource namespaces for application namespace.
-	// We'll attempt to discover the namespace for the experiment by looking at
-	// 1. resource.Namespace
-	// 2. resource.Namespaces[0]
-	// 3. #TODO? We may look at evaluating namespace selector since we have
-	//    access to the kube client
-	assembledApp.Namespace = "default"
-
-	for i := range assembledApp.Resources {
-		k := assembledApp.Resources[i].Kubernetes
-		if k == nil {
-			continue
-		}
-
-		// Guess the namespace based on the Kubernetes resource
-		if k.Namespace != "" {
-			assembledApp.Namespace = k.Namespace
-			break
-		}
-
-		if len(k.Namespaces) > 0 && k.Namespaces[0] != "" {
-			assembledApp.Namespace = k.Namespaces[0]
-			break
-		}
-	}
+	// All of the generated objects are placed in the target namespace
+	assembledApp.Namespace = targetNamespace(assembledApp, p.Options.Namespace)
 
-	generatedResources, err := p.generateApp(*assembledApp, scenario.Name.String())
+	generatedResources, err := p.generateApp(ctx, *assembledApp, scenario.Name.String())
 	if err != nil {
-		p.handleErrors(ctx, log, activity.URL, ActivityReasonGenerationFailed, "Failed to generate application", err)
-		return
+		return fail(ActivityReasonGenerationFailed, "Failed to generate application", err)
 	}
 
 	var exp *optimizev1beta2.Experiment
@@ -240,8 +396,7 @@ func (p *Poller) handleActivity(ctx context.Context, activity applications.Activ
 	}
 
 	if exp == nil {
-		p.handleErrors(ctx, log, activity.URL, "GenerationFailed", "Invalid experiment generated", err)
-		return
+		return fail(ActivityReasonGenerationFailed, "Invalid experiment generated", err)
 	}
 
 	switch activity.Tags[0] {
@@ -249,16 +404,44 @@ func (p *Poller) handleActivity(ctx context.Context, activity applications.Activ
 
 		template, err := server.ClusterExperimentToAPITemplate(exp)
 		if err != nil {
-			p.handleErrors(ctx, log, activity.URL, ActivityReasonScanFailed, "Failed to convert experiment template", err)
-			return
+			return fail(ActivityReasonScanFailed, "Failed to convert experiment template", err)
 		}
 
 		if err := p.apiClient.UpdateTemplate(ctx, templateURL, *template); err != nil {
-			p.handleErrors(ctx, log, activity.URL, ActivityReasonScanFailed, "Failed to save experiment template in server", err)
-			return
+			return fail(ActivityReasonScanFailed, "Failed to save experiment template in server", err)
 		}
 
 		log.Info("Successfully completed resource scan")
+	case tagPreview:
+
+		// Render the resources exactly as they would be created by a run
+		previousTemplate, err := p.apiClient.GetTemplate(ctx, templateURL)
+		if err != nil {
+			return fail(ActivityReasonPreviewFailed, "Failed to get experiment template from server, a 'scan' task must be completed first", err)
+		}
+
+		if err = server.APITemplateToClusterExperiment(exp, &previousTemplate); err != nil {
+			return fail(ActivityReasonPreviewFailed, "Failed to convert experiment template", err)
+		}
+
+		previewURL := scenario.Link(server.RelationPreview)
+		if previewURL == "" {
+			return fail(ActivityReasonPreviewFailed, "No matching preview URL for scenario", nil)
+		}
+		if p.previewAPI == nil {
+			return fail(ActivityReasonPreviewFailed, "Preview API is unavailable", nil)
+		}
+
+		manifests, err := renderManifests(generatedResources)
+		if err != nil {
+			return fail(ActivityReasonPreviewFailed, "Failed to render manifests", err)
+		}
+
+		if err := p.previewAPI.UploadPreview(ctx, previewURL, manifests); err != nil {
+			return fail(ActivityReasonPreviewFailed, "Failed to upload manifests to server", err)
+		}
+
+		log.Info("Successfully uploaded resource preview")
 	case applications.TagRun:
 
 		// We wont compare existing scan with current scan
@@ -267,75 +450,252 @@ func (p *Poller) handleActivity(ctx context.Context, activity applications.Activ
 		// Get previous template
 		previousTemplate, err := p.apiClient.GetTemplate(ctx, templateURL)
 		if err != nil {
-			p.handleErrors(ctx, log, activity.URL, ActivityReasonRunFailed, "Failed to get experiment template from server, a 'scan' task must be completed first", err)
-			return
+			return fail(ActivityReasonRunFailed, "Failed to get experiment template from server, a 'scan' task must be completed first", err)
 		}
 
 		// Overwrite current scan results with previous scan results
 		if err = server.APITemplateToClusterExperiment(exp, &previousTemplate); err != nil {
-			p.handleErrors(ctx, log, activity.URL, ActivityReasonRunFailed, "Failed to convert experiment template", err)
-			return
+			return fail(ActivityReasonRunFailed, "Failed to convert experiment template", err)
+		}
+
+		// Do not create another experiment for a run which was already started
+		key, err := runKey(applicationURL, scenario.Name.String(), previousTemplate)
+		if err != nil {
+			return fail(ActivityReasonRunFailed, "Failed to compute run key", err)
+		}
+		existing := &optimizev1beta2.ExperimentList{}
+		if err := p.client.List(ctx, existing, client.InNamespace(assembledApp.Namespace), client.MatchingLabels{optimizeappsv1alpha1.LabelRunKey: key}); err != nil {
+			return fail(ActivityReasonRunFailed, "Failed to list experiments", err)
+		}
+		if len(existing.Items) > 0 {
+			log.Info("Experiment already created for run", "experiment", existing.Items[0].Name)
+			return nil
 		}
+		if exp.Labels == nil {
+			exp.Labels = make(map[string]string)
+		}
+		exp.Labels[optimizeappsv1alpha1.LabelRunKey] = key
 
 		// At this point the experiment should be good to create/deploy/run
 		// so let's create all the resources and #profit
 
-		// TODO
-		// try to clean up on failure ( might be a simple / blind p.client.Delete(ctx,generatedResources[i])
+		// The experiment is applied last so it is only created once all of its prerequisites
+		// exist, if anything fails the objects created so far are removed again
+		objs := make([]runtime.Object, 0, len(generatedResources))
 		for i := range generatedResources {
-			objKey, err := client.ObjectKeyFromObject(generatedResources[i])
+			if generatedResources[i] != runtime.Object(exp) {
+				objs = append(objs, generatedResources[i])
+			}
+		}
+		objs = append(objs, exp)
+
+		var created []runtime.Object
+		rollback := func(failure *activityFailure) *activityFailure {
+			p.deleteObjects(ctx, log, created)
+			return failure
+		}
+
+		ns, err := p.ensureNamespace(ctx, assembledApp.Namespace)
+		if err != nil {
+			return fail(ActivityReasonRunFailed, "Failed to create namespace", err)
+		}
+		if ns != nil {
+			created = append(created, ns)
+		}
+
+		for i := range objs {
+			objKey, err := client.ObjectKeyFromObject(objs[i])
 			if err != nil {
-				p.handleErrors(ctx, log, activity.URL, ActivityReasonRunFailed, "Failed to get object key", err)
-				return
+				return rollback(fail(ActivityReasonRunFailed, "Failed to get object key", err))
 			}
 
 			holder := &unstructured.Unstructured{}
-			holder.SetGroupVersionKind(generatedResources[i].GetObjectKind().GroupVersionKind())
+			holder.SetGroupVersionKind(objs[i].GetObjectKind().GroupVersionKind())
 			err = p.client.Get(ctx, objKey, holder)
-			switch {
-			case apierrors.IsNotFound(err):
-				if err := p.client.Create(ctx, generatedResources[i]); err != nil {
-					p.handleErrors(ctx, log, activity.URL, ActivityReasonRunFailed, "Failed to create object", err)
-					return
-				}
-			case err == nil:
-				// Most of this gets handled properly for core resources in kube, but seems like there is a gap around
-				// CRD handling. ref: https://github.com/kubernetes/kubernetes/issues/70674
-				metameta.NewAccessor().SetResourceVersion(generatedResources[i], holder.GetResourceVersion())
-				if err := p.client.Update(ctx, generatedResources[i]); err != nil {
-					p.handleErrors(ctx, log, activity.URL, ActivityReasonRunFailed, "Failed to update object", err)
-					return
-				}
-			default:
+			if err != nil && !apierrors.IsNotFound(err) {
 				// Assume this should be a hard error
-				p.handleErrors(ctx, log, activity.URL, ActivityReasonRunFailed, "Failed to get object", err)
-				return
+				return rollback(fail(ActivityReasonRunFailed, "Failed to get object", err))
+			}
+			exists := err == nil
+
+			// Server-side apply corrects any drift in objects left behind by a previous run
+			if err := setManagedBy(objs[i]); err != nil {
+				return rollback(fail(ActivityReasonRunFailed, "Failed to label object", err))
+			}
+			if err := p.client.Patch(ctx, objs[i], client.Apply, client.FieldOwner(activityFieldManager), client.ForceOwnership); err != nil {
+				return rollback(fail(ActivityReasonRunFailed, "Failed to apply object", err))
+			}
+			if !exists {
+				created = append(created, objs[i])
 			}
 		}
 
 		log.Info("Successfully created in cluster resources")
 	}
+
+	return nil
 }
 
-func (p *Poller) handleErrors(ctx context.Context, log logr.Logger, u, reason, message string, err error) {
-	if reason == "" {
-		panic("must supply a reason to handleErrors")
+// renderManifests returns the YAML stream of the supplied objects.
+func renderManifests(objs []runtime.Object) ([]byte, error) {
+	var buf bytes.Buffer
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{sfio.ObjectSlice(objs)},
+		Outputs: []kio.Writer{&kio.ByteWriter{Writer: &buf}},
+	}.Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// runKey returns the idempotency key for running a scenario using a specific revision of the template.
+func runKey(applicationURL, scenarioName string, template applications.Template) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\n%s\n", applicationURL, scenarioName)
+	_, _ = h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))[0:32], nil
+}
+
+// targetNamespace returns the namespace for the objects generated from an application. We'll attempt
+// to discover the namespace for the experiment by looking at:
+// 1. application.Namespace
+// 2. resource.Namespace
+// 3. resource.Namespaces[0]
+// 4. the configured default namespace
+// #TODO? We may look at evaluating namespace selector since we have access to the kube client
+func targetNamespace(app *optimizeappsv1alpha1.Application, defaultNamespace string) string {
+	if app.Namespace != "" {
+		return app.Namespace
+	}
+
+	for i := range app.Resources {
+		k := app.Resources[i].Kubernetes
+		if k == nil {
+			continue
+		}
+
+		// Guess the namespace based on the Kubernetes resource
+		if k.Namespace != "" {
+			return k.Namespace
+		}
+
+		if len(k.Namespaces) > 0 && k.Namespaces[0] != "" {
+			return k.Namespaces[0]
+		}
+	}
+
+	if defaultNamespace != "" {
+		return defaultNamespace
 	}
 
-	msg := message
+	return "default"
+}
+
+// ensureNamespace creates the target namespace if it does not exist yet, returning the
+// namespace if it was created.
+func (p *Poller) ensureNamespace(ctx context.Context, name string) (runtime.Object, error) {
+	ns := &corev1.Namespace{}
+	err := p.client.Get(ctx, client.ObjectKey{Name: name}, ns)
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	ns = &corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{activityManagedByLabel: activityFieldManager},
+		},
+	}
+	if err := p.client.Create(ctx, ns); err != nil {
+		return nil, err
+	}
+
+	return ns, nil
+}
+
+// setManagedBy labels an object so everything applied for an activity can be found again.
+func setManagedBy(obj runtime.Object) error {
+	acc, err := metameta.Accessor(obj)
 	if err != nil {
-		msg = fmt.Errorf("%s: %w", msg, err).Error()
+		return err
 	}
-	log.Info("Activity task failed", "failureReason", reason, "failureMessage", msg)
 
-	if err := p.apiClient.PatchApplicationActivity(ctx, u, applications.ActivityFailure{FailureReason: reason, FailureMessage: msg}); err != nil {
+	labels := acc.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[activityManagedByLabel] = activityFieldManager
+	acc.SetLabels(labels)
+	return nil
+}
+
+// deleteObjects removes objects created for an activity which could not be completed, in reverse order.
+// Objects which already existed and were updated are left in place.
+func (p *Poller) deleteObjects(ctx context.Context, log logr.Logger, objs []runtime.Object) {
+	for i := len(objs) - 1; i >= 0; i-- {
+		if err := p.client.Delete(ctx, objs[i], client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			name, _ := metameta.NewAccessor().Name(objs[i])
+			log.Error(err, "Failed to remove object after activity task failure", "kind", objs[i].GetObjectKind().GroupVersionKind().Kind, "name", name)
+		}
+	}
+}
+
+// activityFailure describes why an activity task could not be completed.
+type activityFailure struct {
+	// URL is the activity which failed.
+	URL string
+	// Reason is the machine readable failure reason.
+	Reason string
+	// Message is the human readable description of the failure.
+	Message string
+	// Err is the underlying cause of the failure, if any.
+	Err error
+}
+
+func (f *activityFailure) Error() string {
+	if f.Err != nil {
+		return fmt.Sprintf("%s: %v", f.Message, f.Err)
+	}
+	return f.Message
+}
+
+func (f *activityFailure) Unwrap() error {
+	return f.Err
+}
+
+// handleErrors reports a failed activity task back to the application service and records
+// an event against the controller pod (objects created for the activity are rolled back on failure).
+func (p *Poller) handleErrors(ctx context.Context, log logr.Logger, failure *activityFailure) {
+	if failure.Reason == "" {
+		panic("must supply a reason to handleErrors")
+	}
+
+	msg := failure.Error()
+	log.Info("Activity task failed", "failureReason", failure.Reason, "failureMessage", msg)
+
+	if p.pod != nil && p.Recorder != nil {
+		p.Recorder.Event(p.pod, corev1.EventTypeWarning, failure.Reason, msg)
+	}
+
+	if err := p.apiClient.PatchApplicationActivity(ctx, failure.URL, applications.ActivityFailure{FailureReason: failure.Reason, FailureMessage: msg}); err != nil {
 		log.Error(err, "Failed to update application activity")
 	}
 }
 
 const tsEncoder = "0123456789abcdefghjkmnpqrstvwxyz"
 
-func (p *Poller) generateApp(app optimizeappsv1alpha1.Application, scenario string) ([]runtime.Object, error) {
+func (p *Poller) generateApp(ctx context.Context, app optimizeappsv1alpha1.Application, scenario string) ([]runtime.Object, error) {
 	// Set defaults for application
 	app.Default()
 
@@ -354,10 +714,18 @@ func (p *Poller) generateApp(app optimizeappsv1alpha1.Application, scenario stri
 		suffix += string(tsEncoder[rand.Intn(len(tsEncoder))])
 	}
 
+	// Cluster-wide policies cannot be bypassed by the application
+	policyList := &optimizev1beta2.OptimizationPolicyList{}
+	if err := p.client.List(ctx, policyList); err != nil {
+		return nil, err
+	}
+
 	g := &experiment.Generator{
 		Application:    app,
 		ExperimentName: fmt.Sprintf("%s-%s", scn.Name, suffix),
+		Policies:       policyList.Items,
 		FilterOptions:  p.filterOpts,
+		NamespacedRBAC: true,
 	}
 
 	objList := sfio.ObjectList{}
@@ -365,9 +733,25 @@ func (p *Poller) generateApp(app optimizeappsv1alpha1.Application, scenario stri
 		return nil, fmt.Errorf("%s: %w", "failed to generate experiment", err)
 	}
 
+	return typedObjects(objList)
+}
+
+// typedObjects returns the Go typed objects from the generated list, documents of an unknown
+// kind cannot be applied safely so they are rejected.
+func typedObjects(objList sfio.ObjectList) ([]runtime.Object, error) {
 	runtimeObjs := make([]runtime.Object, 0, len(objList.Items))
 	for i := range objList.Items {
-		runtimeObjs = append(runtimeObjs, objList.Items[i].Object)
+		obj := objList.Items[i].Object
+		switch obj.(type) {
+		case nil:
+			tm := metav1.TypeMeta{}
+			_ = json.Unmarshal(objList.Items[i].Raw, &tm)
+			return nil, fmt.Errorf("generated unsupported resource kind %q", tm.GroupVersionKind().String())
+		case runtime.Unstructured:
+			return nil, fmt.Errorf("generated unsupported resource kind %q", obj.GetObjectKind().GroupVersionKind().String())
+		}
+
+		runtimeObjs = append(runtimeObjs, obj)
 	}
 
 	return runtimeObjs, nil
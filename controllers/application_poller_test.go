@@ -22,22 +22,31 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-logr/zapr"
 	"github.com/stretchr/testify/assert"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/konjure"
 	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/scan"
+	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kustomize/kyaml/kio"
 )
 
 func TestPoller(t *testing.T) {
@@ -209,3 +218,295 @@ spec:
             memory: 25Mi
             cpu: 50m`), nil
 }
+
+func TestTypedObjects(t *testing.T) {
+	cases := []struct {
+		desc          string
+		manifests     string
+		expectedKinds []string
+		expectedError string
+	}{
+		{
+			desc: "multiple documents",
+			manifests: `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: prometheus
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: prometheus
+---
+apiVersion: optimize.stormforge.io/v1beta2
+kind: Experiment
+metadata:
+  name: test
+`,
+			expectedKinds: []string{"ServiceAccount", "ConfigMap", "Experiment"},
+		},
+		{
+			desc: "unknown kind",
+			manifests: `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: prometheus
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: test
+`,
+			expectedError: `generated unsupported resource kind "example.com/v1, Kind=Widget"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			objList := sfio.ObjectList{}
+			err := kio.Pipeline{
+				Inputs:  []kio.Reader{&kio.ByteReader{Reader: strings.NewReader(c.manifests), OmitReaderAnnotations: true}},
+				Outputs: []kio.Writer{&objList},
+			}.Execute()
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			objs, err := typedObjects(objList)
+			if c.expectedError != "" {
+				assert.EqualError(t, err, c.expectedError)
+				return
+			}
+			if assert.NoError(t, err) {
+				var kinds []string
+				for _, obj := range objs {
+					kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+				}
+				assert.Equal(t, c.expectedKinds, kinds)
+			}
+		})
+	}
+}
+
+func TestTargetNamespace(t *testing.T) {
+	cases := []struct {
+		desc             string
+		app              optimizeappsv1alpha1.Application
+		defaultNamespace string
+		expected         string
+	}{
+		{
+			desc:     "default",
+			expected: "default",
+		},
+		{
+			desc:             "configured default",
+			defaultNamespace: "optimize",
+			expected:         "optimize",
+		},
+		{
+			desc: "application namespace",
+			app: optimizeappsv1alpha1.Application{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "target"},
+				Resources:  konjure.Resources{{Kubernetes: &konjurev1beta2.Kubernetes{Namespace: "engineering"}}},
+			},
+			defaultNamespace: "optimize",
+			expected:         "target",
+		},
+		{
+			desc: "resource namespace",
+			app: optimizeappsv1alpha1.Application{
+				Resources: konjure.Resources{{Kubernetes: &konjurev1beta2.Kubernetes{Namespace: "engineering"}}},
+			},
+			defaultNamespace: "optimize",
+			expected:         "engineering",
+		},
+		{
+			desc: "resource namespaces",
+			app: optimizeappsv1alpha1.Application{
+				Resources: konjure.Resources{{Kubernetes: &konjurev1beta2.Kubernetes{Namespaces: []string{"sales", "engineering"}}}},
+			},
+			expected: "sales",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, targetNamespace(&c.app, c.defaultNamespace))
+		})
+	}
+}
+
+func TestRunKey(t *testing.T) {
+	template := applications.Template{
+		Parameters: []applications.TemplateParameter{{Name: "cpu", Type: "int"}},
+	}
+	changed := applications.Template{
+		Parameters: []applications.TemplateParameter{{Name: "memory", Type: "int"}},
+	}
+
+	key, err := runKey("http://example.com/v2/applications/app1", "scn1", template)
+	if assert.NoError(t, err) {
+		assert.Len(t, key, 32)
+
+		same, _ := runKey("http://example.com/v2/applications/app1", "scn1", template)
+		assert.Equal(t, key, same)
+
+		otherScenario, _ := runKey("http://example.com/v2/applications/app1", "scn2", template)
+		assert.NotEqual(t, key, otherScenario)
+
+		otherRevision, _ := runKey("http://example.com/v2/applications/app1", "scn1", changed)
+		assert.NotEqual(t, key, otherRevision)
+	}
+}
+
+func TestRenderManifests(t *testing.T) {
+	objs := []runtime.Object{
+		&corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{Name: "optimize-setup", Namespace: "default"},
+		},
+		&optimizev1beta2.Experiment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: optimizev1beta2.GroupVersion.String(), Kind: "Experiment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		},
+	}
+
+	data, err := renderManifests(objs)
+	if assert.NoError(t, err) {
+		docs := strings.Split(string(data), "\n---\n")
+		if assert.Len(t, docs, 2) {
+			assert.Contains(t, docs[0], "kind: ServiceAccount")
+			assert.Contains(t, docs[1], "kind: Experiment")
+		}
+		assert.NotContains(t, string(data), "creationTimestamp")
+	}
+}
+
+func TestPoller_UpdateConnectedCondition(t *testing.T) {
+	type update struct {
+		status  corev1.ConditionStatus
+		reason  string
+		message string
+	}
+	cases := []struct {
+		desc     string
+		updates  []update
+		expected *corev1.PodCondition
+	}{
+		{
+			desc: "connected",
+			updates: []update{
+				{status: corev1.ConditionTrue, reason: "Connected"},
+			},
+			expected: &corev1.PodCondition{Type: ActivityConnected, Status: corev1.ConditionTrue, Reason: "Connected"},
+		},
+		{
+			desc: "connection failed",
+			updates: []update{
+				{status: corev1.ConditionTrue, reason: "Connected"},
+				{status: corev1.ConditionFalse, reason: "ConnectionFailed", message: "unable to connect to application service"},
+			},
+			expected: &corev1.PodCondition{Type: ActivityConnected, Status: corev1.ConditionFalse, Reason: "ConnectionFailed", Message: "unable to connect to application service"},
+		},
+		{
+			desc: "unchanged status",
+			updates: []update{
+				{status: corev1.ConditionFalse, reason: "ConnectionFailed", message: "first"},
+				{status: corev1.ConditionFalse, reason: "ConnectionFailed", message: "second"},
+			},
+			expected: &corev1.PodCondition{Type: ActivityConnected, Status: corev1.ConditionFalse, Reason: "ConnectionFailed", Message: "first"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = corev1.AddToScheme(scheme)
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "stormforge-system", Name: "optimize-controller-manager"},
+				Status: corev1.PodStatus{
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+				},
+			}
+			p := &Poller{
+				Log:    log.NullLogger{},
+				client: fake.NewFakeClientWithScheme(scheme, pod.DeepCopy()),
+				pod:    pod,
+			}
+
+			for _, u := range c.updates {
+				p.updateConnectedCondition(context.TODO(), u.status, u.reason, u.message)
+			}
+
+			actual := &corev1.Pod{}
+			if !assert.NoError(t, p.client.Get(context.TODO(), client.ObjectKey{Namespace: pod.Namespace, Name: pod.Name}, actual)) {
+				return
+			}
+			var conditionTypes []corev1.PodConditionType
+			for i := range actual.Status.Conditions {
+				cc := &actual.Status.Conditions[i]
+				conditionTypes = append(conditionTypes, cc.Type)
+				if cc.Type == ActivityConnected {
+					cc.LastTransitionTime = metav1.Time{}
+					assert.Equal(t, c.expected, cc)
+				}
+			}
+			assert.ElementsMatch(t, []corev1.PodConditionType{corev1.PodReady, ActivityConnected}, conditionTypes)
+		})
+	}
+}
+
+func TestPoller_HandleErrors(t *testing.T) {
+	cases := []struct {
+		desc            string
+		pod             *corev1.Pod
+		failure         activityFailure
+		expectedFailure applications.ActivityFailure
+		expectedEvents  []string
+	}{
+		{
+			desc: "controller pod",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "stormforge-system", Name: "optimize-controller-manager"}},
+			failure: activityFailure{
+				URL:     "http://example.invalid/activity/1",
+				Reason:  "GenerationFailed",
+				Message: "Failed to generate application",
+				Err:     fmt.Errorf("missing resources"),
+			},
+			expectedFailure: applications.ActivityFailure{
+				FailureReason:  "GenerationFailed",
+				FailureMessage: "Failed to generate application: missing resources",
+			},
+			expectedEvents: []string{"Warning GenerationFailed Failed to generate application: missing resources"},
+		},
+		{
+			desc: "no controller pod",
+			failure: activityFailure{
+				URL:     "http://example.invalid/activity/1",
+				Reason:  "InvalidApplication",
+				Message: "No matching application URL for scenario",
+			},
+			expectedFailure: applications.ActivityFailure{
+				FailureReason:  "InvalidApplication",
+				FailureMessage: "No matching application URL for scenario",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			fapi := &fakeAPI{failureCh: make(chan applications.ActivityFailure, 1)}
+			recorder := record.NewFakeRecorder(1)
+			p := &Poller{Recorder: recorder, apiClient: fapi, pod: c.pod}
+
+			p.handleErrors(context.TODO(), log.NullLogger{}, &c.failure)
+
+			if assert.Len(t, fapi.failureCh, 1) {
+				assert.Equal(t, c.expectedFailure, <-fapi.failureCh)
+			}
+			var events []string
+			for len(recorder.Events) > 0 {
+				events = append(events, <-recorder.Events)
+			}
+			assert.Equal(t, c.expectedEvents, events)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"github.com/thestormforge/optimize-controller/v2/internal/meta"
+	"github.com/thestormforge/optimize-controller/v2/internal/patch"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultDriftCheckInterval is the amount of time between drift checks of a completed experiment
+	defaultDriftCheckInterval = time.Hour
+	// defaultDriftThreshold is the relative difference from the best trial at which workloads are considered drifted
+	defaultDriftThreshold = 0.1
+)
+
+// driftCheckInterval returns the configured amount of time between drift checks.
+func driftCheckInterval(log logr.Logger) time.Duration {
+	interval, ok := os.LookupEnv("STORMFORGE_DRIFT_CHECK_INTERVAL")
+	if !ok {
+		return defaultDriftCheckInterval
+	}
+
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		log.Info("Ignoring invalid drift check interval", "driftCheckInterval", interval)
+		return defaultDriftCheckInterval
+	}
+
+	log.Info("Using custom drift check interval", "driftCheckInterval", interval)
+	return d
+}
+
+// driftThreshold returns the configured relative difference at which workloads are considered drifted.
+func driftThreshold(log logr.Logger) float64 {
+	threshold, ok := os.LookupEnv("STORMFORGE_DRIFT_THRESHOLD")
+	if !ok {
+		return defaultDriftThreshold
+	}
+
+	t, err := strconv.ParseFloat(threshold, 64)
+	if err != nil || t < 0 {
+		log.Info("Ignoring invalid drift threshold", "driftThreshold", threshold)
+		return defaultDriftThreshold
+	}
+
+	log.Info("Using custom drift threshold", "driftThreshold", threshold)
+	return t
+}
+
+// DriftReconciler periodically compares the live workloads of a completed experiment to its best trial
+type DriftReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+
+	interval  time.Duration
+	threshold float64
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=list
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *DriftReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("experiment", req.NamespacedName)
+
+	exp := &optimizev1beta2.Experiment{}
+	if err := r.Get(ctx, req.NamespacedName, exp); err != nil {
+		if controller.IgnoreNotFound(err) == nil {
+			controller.ExperimentDrift.DeleteLabelValues(req.Namespace, req.Name)
+		}
+		return ctrl.Result{}, controller.IgnoreNotFound(err)
+	}
+
+	// Stop reporting drift for experiments which are going away
+	if !exp.GetDeletionTimestamp().IsZero() {
+		controller.ExperimentDrift.DeleteLabelValues(exp.Namespace, exp.Name)
+		return ctrl.Result{}, nil
+	}
+
+	// Only completed experiments have a recommended configuration
+	if _, ok := conditionMessage(exp, optimizev1beta2.ExperimentComplete); !ok {
+		return ctrl.Result{}, nil
+	}
+
+	trialList := &optimizev1beta2.TrialList{}
+	if err := r.listTrials(ctx, trialList, exp.TrialSelector()); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Without a best trial (e.g. multi-objective experiments or trials that were cleaned up) there is nothing to compare
+	best := experiment.BestTrial(exp, trialList)
+	if best == nil {
+		return ctrl.Result{}, nil
+	}
+
+	if result, err := r.checkDrift(ctx, log, exp, best); result != nil {
+		return *result, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.interval}, nil
+}
+
+func (r *DriftReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("drift")
+	}
+
+	r.interval = driftCheckInterval(r.Log)
+	r.threshold = driftThreshold(r.Log)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("drift").
+		For(&optimizev1beta2.Experiment{}).
+		Complete(r)
+}
+
+// checkDrift compares the objects patched by the best trial to their live state and records the drift
+func (r *DriftReconciler) checkDrift(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment, best *optimizev1beta2.Trial) (*ctrl.Result, error) {
+	var maxDrift float64
+	var msg string
+	for i := range best.Status.PatchOperations {
+		p := &best.Status.PatchOperations[i]
+		if trial.IsTrialJobReference(best, &p.TargetRef) {
+			continue
+		}
+
+		live := &unstructured.Unstructured{}
+		live.SetName(p.TargetRef.Name)
+		live.SetNamespace(p.TargetRef.Namespace)
+		live.SetGroupVersionKind(p.TargetRef.GroupVersionKind())
+		if err := r.Get(ctx, client.ObjectKey{Namespace: p.TargetRef.Namespace, Name: p.TargetRef.Name}, live); err != nil {
+			if err := controller.IgnoreNotFound(err); err != nil {
+				return &ctrl.Result{}, err
+			}
+			log.Info("Skipping drift check of missing object", "targetRef", p.TargetRef)
+			continue
+		}
+
+		// Let the API server apply the patch so we get the exact recommended state of the live object
+		recommended := live.DeepCopy()
+		if err := r.Patch(ctx, recommended, client.RawPatch(p.PatchType, p.Data), client.DryRunAll); err != nil {
+			return &ctrl.Result{}, err
+		}
+
+		if d, path := patch.Drift(recommended.Object, live.Object); d > maxDrift {
+			maxDrift = d
+			msg = fmt.Sprintf("%s %s drifted %.0f%% from trial %s at %s", p.TargetRef.Kind, p.TargetRef.Name, d*100, best.Name, path)
+		}
+	}
+
+	controller.ExperimentDrift.WithLabelValues(exp.Namespace, exp.Name).Set(maxDrift)
+
+	status, reason := corev1.ConditionFalse, "NoDrift"
+	if maxDrift > r.threshold {
+		status, reason = corev1.ConditionTrue, "DriftDetected"
+	} else {
+		msg = fmt.Sprintf("Live configuration is within %.0f%% of trial %s", r.threshold*100, best.Name)
+	}
+
+	// Only update the experiment when the outcome changes
+	for _, c := range exp.Status.Conditions {
+		if c.Type == optimizev1beta2.ExperimentDrifted && c.Status == status && c.Message == msg {
+			return nil, nil
+		}
+	}
+
+	_, wasDrifted := conditionMessage(exp, optimizev1beta2.ExperimentDrifted)
+	now := metav1.Now()
+	experiment.ApplyCondition(&exp.Status, optimizev1beta2.ExperimentDrifted, status, reason, msg, &now)
+	if err := r.Update(ctx, exp); err != nil {
+		return controller.RequeueConflict(err)
+	}
+
+	if status == corev1.ConditionTrue && !wasDrifted {
+		r.Recorder.Event(exp, corev1.EventTypeWarning, reason, msg)
+	}
+	return nil, nil
+}
+
+func (r *DriftReconciler) listTrials(ctx context.Context, trialList *optimizev1beta2.TrialList, selector *metav1.LabelSelector) error {
+	matchingSelector, err := meta.MatchingSelector(selector)
+	if err != nil {
+		return err
+	}
+	return r.List(ctx, trialList, matchingSelector)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestDriftReconciler_ExperimentDrift(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+
+	// Experiments with the same name in different namespaces
+	expA := &optimizev1beta2.Experiment{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "test"}}
+	expB := &optimizev1beta2.Experiment{ObjectMeta: metav1.ObjectMeta{Namespace: "b", Name: "test"}}
+	best := &optimizev1beta2.Trial{ObjectMeta: metav1.ObjectMeta{Name: "test-000"}}
+
+	r := &DriftReconciler{
+		Client:    fake.NewFakeClientWithScheme(scheme, expA, expB),
+		Log:       log.NullLogger{},
+		Recorder:  record.NewFakeRecorder(10),
+		threshold: defaultDriftThreshold,
+	}
+
+	_, err := r.checkDrift(context.TODO(), r.Log, expA, best)
+	assert.NoError(t, err)
+	_, err = r.checkDrift(context.TODO(), r.Log, expB, best)
+	assert.NoError(t, err)
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(controller.ExperimentDrift.WithLabelValues("a", "test")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(controller.ExperimentDrift.WithLabelValues("b", "test")))
+
+	// Deleting one experiment only removes its own series
+	assert.NoError(t, r.Delete(context.TODO(), expA))
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "a", Name: "test"}})
+	assert.NoError(t, err)
+	assert.False(t, controller.ExperimentDrift.DeleteLabelValues("a", "test"))
+	assert.True(t, controller.ExperimentDrift.DeleteLabelValues("b", "test"))
+}
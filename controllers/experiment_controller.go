@@ -18,15 +18,22 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/controller"
 	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
 	"github.com/thestormforge/optimize-controller/v2/internal/meta"
+	"github.com/thestormforge/optimize-controller/v2/internal/notify"
+	"github.com/thestormforge/optimize-controller/v2/internal/server"
 	"github.com/thestormforge/optimize-controller/v2/internal/trial"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -37,11 +44,17 @@ import (
 // ExperimentReconciler reconciles an Experiment object
 type ExperimentReconciler struct {
 	client.Client
-	Log logr.Logger
+	Log      logr.Logger
+	Recorder record.EventRecorder
+
+	notifications *notifications
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments;experiments/finalizers,verbs=get;list;watch;update
-// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=list;watch;update;delete
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=list;watch;update;patch;delete
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=optimizationpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=configmaps;secrets,verbs=get
 
 func (r *ExperimentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -51,6 +64,10 @@ func (r *ExperimentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return ctrl.Result{}, controller.IgnoreNotFound(err)
 	}
 
+	if result, err := r.applyPolicies(ctx, exp); result != nil {
+		return *result, err
+	}
+
 	trialList := &optimizev1beta2.TrialList{}
 	if err := r.listTrials(ctx, trialList, exp.TrialSelector()); err != nil {
 		return ctrl.Result{}, err
@@ -60,7 +77,7 @@ func (r *ExperimentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return *result, err
 	}
 
-	if result, err := r.updateTrialStatus(ctx, trialList); result != nil {
+	if result, err := r.updateTrialStatus(ctx, exp, trialList); result != nil {
 		return *result, err
 	}
 
@@ -68,10 +85,17 @@ func (r *ExperimentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return *result, err
 	}
 
-	return ctrl.Result{}, nil
+	// Make sure we wake up when the deadline passes or the next trial expires
+	return ctrl.Result{RequeueAfter: requeueAfter(exp, trialList, time.Now())}, nil
 }
 
 func (r *ExperimentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("experiment")
+	}
+
+	r.notifications = newNotifications(r.Log, mgr.GetAPIReader())
+
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("experiment").
 		For(&optimizev1beta2.Experiment{}).
@@ -87,9 +111,45 @@ func trialToExperimentRequest(o handler.MapObject) []reconcile.Request {
 	return nil
 }
 
+// applyPolicies will narrow the experiment to fit the cluster-wide optimization policies, failing the experiment
+// if it cannot be made to conform; once the experiment exists on the server it is only checked against the policies
+func (r *ExperimentReconciler) applyPolicies(ctx context.Context, exp *optimizev1beta2.Experiment) (*ctrl.Result, error) {
+	// Do not interfere with experiments that are finished or going away
+	if experiment.IsFinished(exp) || !exp.GetDeletionTimestamp().IsZero() {
+		return nil, nil
+	}
+
+	policyList := &optimizev1beta2.OptimizationPolicyList{}
+	if err := r.List(ctx, policyList); err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	var dirty bool
+	var err error
+	if meta.HasFinalizer(exp, server.Finalizer) {
+		// Changing the parameters now would reject server suggestions from the original range
+		err = experiment.CheckPolicies(exp, policyList.Items)
+	} else {
+		dirty, err = experiment.ApplyPolicies(exp, policyList.Items)
+	}
+	if err != nil {
+		dirty = experiment.FailExperiment(exp, "PolicyViolation", err)
+	}
+
+	if dirty {
+		if err := r.Update(ctx, exp); err != nil {
+			return controller.RequeueConflict(err)
+		}
+		return &ctrl.Result{}, nil
+	}
+	return nil, nil
+}
+
 // updateStatus will ensure the experiment and trial status matches the current state
 func (r *ExperimentReconciler) updateStatus(ctx context.Context, exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) (*ctrl.Result, error) {
 	var dirty bool
+	_, wasExhausted := conditionMessage(exp, optimizev1beta2.ExperimentBudgetExhausted)
+	_, wasComplete := conditionMessage(exp, optimizev1beta2.ExperimentComplete)
 
 	// Update the HasTrialFinalizer
 	if len(trialList.Items) > 0 {
@@ -98,6 +158,16 @@ func (r *ExperimentReconciler) updateStatus(ctx context.Context, exp *optimizev1
 		dirty = meta.RemoveFinalizer(exp, experiment.HasTrialFinalizer) || dirty
 	}
 
+	// Stop starting new trials once the deadline passes
+	dirty = experiment.CheckDeadline(exp, trialList, time.Now()) || dirty
+
+	// Record the budget consumed by the trials so it is still counted once they are deleted
+	recorded := experiment.RecordBudget(exp, trialList)
+	dirty = len(recorded) > 0 || dirty
+
+	// Stop starting new trials once the budget is exhausted
+	dirty = experiment.CheckBudget(exp, trialList, time.Now()) || dirty
+
 	// Update the experiment status
 	dirty = experiment.UpdateStatus(exp, trialList) || dirty
 
@@ -106,12 +176,27 @@ func (r *ExperimentReconciler) updateStatus(ctx context.Context, exp *optimizev1
 		if err := r.Update(ctx, exp); err != nil {
 			return controller.RequeueConflict(err)
 		}
+
+		// Only mark the trials as recorded once the experiment has been updated, a merge patch avoids conflicts
+		for _, t := range recorded {
+			data := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, optimizev1beta2.AnnotationBudgetRecorded, t.Annotations[optimizev1beta2.AnnotationBudgetRecorded])
+			if err := r.Patch(ctx, t, client.RawPatch(types.MergePatchType, []byte(data))); controller.IgnoreNotFound(err) != nil {
+				return &ctrl.Result{}, err
+			}
+		}
+
+		if msg, ok := conditionMessage(exp, optimizev1beta2.ExperimentBudgetExhausted); ok && !wasExhausted {
+			r.notifications.send(ctx, r.Log, exp, notify.BudgetExhausted, msg)
+		}
+		if msg, ok := conditionMessage(exp, optimizev1beta2.ExperimentComplete); ok && !wasComplete {
+			r.notifications.send(ctx, r.Log, exp, notify.ExperimentCompleted, msg)
+		}
 	}
 	return nil, nil
 }
 
 // updateTrialStatus will update the status of all the experiment trials
-func (r *ExperimentReconciler) updateTrialStatus(ctx context.Context, trialList *optimizev1beta2.TrialList) (*ctrl.Result, error) {
+func (r *ExperimentReconciler) updateTrialStatus(ctx context.Context, exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) (*ctrl.Result, error) {
 	for i := range trialList.Items {
 		t := &trialList.Items[i]
 
@@ -125,6 +210,7 @@ func (r *ExperimentReconciler) updateTrialStatus(ctx context.Context, trialList
 		}
 
 		// Update the trial status
+		previousPhase := t.Status.Phase
 		dirty = trial.UpdateStatus(t) || dirty
 
 		// Only send an update if something actually changed
@@ -132,6 +218,24 @@ func (r *ExperimentReconciler) updateTrialStatus(ctx context.Context, trialList
 			if err := r.Update(ctx, t); err != nil {
 				return controller.RequeueConflict(err)
 			}
+
+			// Describe the phase transitions on both the trial and the experiment
+			for _, e := range trial.PhaseEvents(t, previousPhase) {
+				r.Recorder.Event(t, e.Type, e.Reason, e.Message)
+				r.Recorder.Eventf(exp, e.Type, e.Reason, "Trial %s: %s", t.Name, e.Message)
+
+				switch e.Reason {
+				case "TrialCompleted":
+					if msg, ok := experiment.ImprovedBestTrial(exp, trialList, t); ok {
+						r.notifications.send(ctx, r.Log, exp, notify.BestTrialImproved, msg)
+					}
+				case "TrialFailed":
+					streak := experiment.FailureStreak(trialList)
+					if s := r.notifications.settings(ctx, r.Log, exp).FailureStreak; s > 0 && streak == s {
+						r.notifications.send(ctx, r.Log, exp, notify.TrialFailureStreak, fmt.Sprintf("%d trials failed in a row, most recently %s: %s", streak, t.Name, e.Message))
+					}
+				}
+			}
 		}
 	}
 	return nil, nil
@@ -139,25 +243,72 @@ func (r *ExperimentReconciler) updateTrialStatus(ctx context.Context, trialList
 
 // cleanupTrials will delete any trials whose TTL has expired or are active past
 func (r *ExperimentReconciler) cleanupTrials(ctx context.Context, exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) (*ctrl.Result, error) {
+	// Track which namespaces still have active trials (including trials that are being deleted), deleting a namespace
+	// also deletes the trials in it so they must be reported to the server and recorded against the budget first
+	inUse := make(map[string]bool, len(trialList.Items))
 	for i := range trialList.Items {
 		t := &trialList.Items[i]
+		inUse[t.Namespace] = inUse[t.Namespace] || trial.IsActive(t) || meta.HasFinalizer(t, server.Finalizer) || !experiment.IsBudgetRecorded(t)
 
 		// Trial is already deleted, no clean up possible
 		if !t.GetDeletionTimestamp().IsZero() {
 			continue
 		}
 
-		// Delete trials if they have expired or if the experiment has been deleted
-		if trial.NeedsCleanup(t) || !exp.GetDeletionTimestamp().IsZero() {
+		// Delete trials if they have expired (and were recorded against the budget) or if the experiment has been deleted
+		if (trial.NeedsCleanup(t) && experiment.IsBudgetRecorded(t)) || !exp.GetDeletionTimestamp().IsZero() {
 			// TODO client.PropagationPolicy(metav1.DeletePropagationBackground) ?
 			if err := r.Delete(ctx, t); err != nil {
 				return &ctrl.Result{}, err
 			}
 		}
 	}
+
+	if exp.Spec.NamespaceTemplate == nil {
+		return nil, nil
+	}
+
+	// Once the experiment is deleted and the trials are gone, find any template namespaces that remain
+	if !exp.GetDeletionTimestamp().IsZero() && len(trialList.Items) == 0 {
+		namespaceList := &corev1.NamespaceList{}
+		if err := r.List(ctx, namespaceList, client.MatchingLabels{optimizev1beta2.LabelExperiment: exp.Name}); err != nil {
+			return &ctrl.Result{}, err
+		}
+		for i := range namespaceList.Items {
+			inUse[namespaceList.Items[i].Name] = false
+		}
+	}
+
+	// Delete namespaces created from the template once they no longer have any active trials
+	for namespace, used := range inUse {
+		if used || namespace == exp.Namespace {
+			continue
+		}
+
+		if err := r.cleanupNamespace(ctx, exp, namespace); err != nil {
+			return &ctrl.Result{}, err
+		}
+	}
+
 	return nil, nil
 }
 
+// cleanupNamespace deletes a trial namespace only if it was created from the experiment's namespace template
+func (r *ExperimentReconciler) cleanupNamespace(ctx context.Context, exp *optimizev1beta2.Experiment, namespace string) error {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return ignorePermissions(controller.IgnoreNotFound(err))
+	}
+
+	if !ns.GetDeletionTimestamp().IsZero() || !experiment.IsTemplateNamespace(exp, ns) {
+		return nil
+	}
+
+	r.Log.Info("Deleting trial namespace", "namespace", namespace)
+	err := r.Delete(ctx, ns, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	return ignorePermissions(controller.IgnoreNotFound(err))
+}
+
 // listTrials retrieves the list of trial objects matching the specified selector
 func (r *ExperimentReconciler) listTrials(ctx context.Context, trialList *optimizev1beta2.TrialList, selector *metav1.LabelSelector) error {
 	matchingSelector, err := meta.MatchingSelector(selector)
@@ -166,3 +317,22 @@ func (r *ExperimentReconciler) listTrials(ctx context.Context, trialList *optimi
 	}
 	return r.List(ctx, trialList, matchingSelector)
 }
+
+// requeueAfter returns the amount of time until the earliest of the experiment deadline or trial TTL expiration
+func requeueAfter(exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList, now time.Time) time.Duration {
+	d := experiment.UntilDeadline(exp, now)
+	for i := range trialList.Items {
+		if td := trial.UntilCleanup(&trialList.Items[i], now); td > 0 && (d == 0 || td < d) {
+			d = td
+		}
+	}
+	return d
+}
+
+// ignorePermissions returns nil for errors indicating the controller was not granted access to a resource
+func ignorePermissions(err error) error {
+	if apierrs.IsUnauthorized(err) || apierrs.IsForbidden(err) {
+		return nil
+	}
+	return err
+}
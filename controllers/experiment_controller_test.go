@@ -0,0 +1,99 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/server"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestExperimentReconciler_CleanupTrials(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	exp := &optimizev1beta2.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec:       optimizev1beta2.ExperimentSpec{NamespaceTemplate: &optimizev1beta2.NamespaceTemplateSpec{}},
+	}
+
+	testCases := []struct {
+		desc              string
+		finalizers        []string
+		budgetRecorded    string
+		expectedNamespace bool
+	}{
+		{
+			desc:           "reported and recorded",
+			budgetRecorded: "cost",
+		},
+		{
+			desc:              "not reported",
+			finalizers:        []string{server.Finalizer},
+			budgetRecorded:    "cost",
+			expectedNamespace: true,
+		},
+		{
+			desc:              "not recorded",
+			expectedNamespace: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-ns",
+				Annotations: map[string]string{optimizev1beta2.AnnotationNamespaceTemplate: "default/test"},
+			}}
+			tr := optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "test-ns",
+					Name:        "test-000",
+					Finalizers:  tc.finalizers,
+					Annotations: map[string]string{},
+				},
+				Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+					{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue},
+				}},
+			}
+			if tc.budgetRecorded != "" {
+				tr.Annotations[optimizev1beta2.AnnotationBudgetRecorded] = tc.budgetRecorded
+			}
+			r := &ExperimentReconciler{Client: fake.NewFakeClientWithScheme(scheme, exp, ns, &tr), Log: log.NullLogger{}}
+
+			_, err := r.cleanupTrials(context.TODO(), exp, &optimizev1beta2.TrialList{Items: []optimizev1beta2.Trial{tr}})
+			assert.NoError(t, err)
+
+			err = r.Get(context.TODO(), client.ObjectKey{Name: "test-ns"}, &corev1.Namespace{})
+			if tc.expectedNamespace {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, apierrors.IsNotFound(err))
+			}
+		})
+	}
+}
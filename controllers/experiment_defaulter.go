@@ -0,0 +1,75 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"github.com/thestormforge/optimize-controller/v2/internal/setup"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// experimentDefaulterPath is the path the experiment defaulting webhook is served on
+const experimentDefaulterPath = "/mutate-optimize-stormforge-io-v1beta2-experiment"
+
+// ExperimentDefaulter is a mutating admission webhook that fills in the conventional values of submitted experiments
+type ExperimentDefaulter struct {
+	Log logr.Logger
+
+	decoder *admission.Decoder
+}
+
+// +kubebuilder:webhook:path=/mutate-optimize-stormforge-io-v1beta2-experiment,mutating=true,failurePolicy=ignore,groups=optimize.stormforge.io,resources=experiments,verbs=create;update,versions=v1beta2,name=mexperiment.stormforge.io
+
+var _ admission.Handler = &ExperimentDefaulter{}
+var _ admission.DecoderInjector = &ExperimentDefaulter{}
+
+func (d *ExperimentDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	exp := &optimizev1beta2.Experiment{}
+	if err := d.decoder.Decode(req, exp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if !experiment.ApplyDefaults(exp, setup.DefaultImage()) {
+		return admission.Allowed("")
+	}
+
+	marshaled, err := json.Marshal(exp)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	d.Log.Info("Applied experiment defaults", "experiment", req.Namespace+"/"+req.Name)
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+func (d *ExperimentDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+func (d *ExperimentDefaulter) SetupWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(experimentDefaulterPath, &webhook.Admission{Handler: d})
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"github.com/thestormforge/optimize-controller/v2/internal/meta"
+	"github.com/thestormforge/optimize-controller/v2/internal/metric"
+	"github.com/thestormforge/optimize-controller/v2/internal/server"
+	"github.com/thestormforge/optimize-controller/v2/internal/validation"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// experimentValidatorPath is the path the experiment validating webhook is served on
+const experimentValidatorPath = "/validate-optimize-stormforge-io-v1beta2-experiment"
+
+// queryDryRunTimeout is the maximum amount of time spent executing metric queries during admission
+const queryDryRunTimeout = 5 * time.Second
+
+// ExperimentValidator is a validating admission webhook that rejects experiments with malformed metric queries
+type ExperimentValidator struct {
+	Log logr.Logger
+	// DryRun executes Prometheus queries against the configured Prometheus server
+	DryRun bool
+
+	reader  client.Reader
+	decoder *admission.Decoder
+}
+
+// +kubebuilder:webhook:path=/validate-optimize-stormforge-io-v1beta2-experiment,mutating=false,failurePolicy=ignore,groups=optimize.stormforge.io,resources=experiments,verbs=create;update,versions=v1beta2,name=vexperiment.stormforge.io
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=optimizationpolicies,verbs=list
+
+var _ admission.Handler = &ExperimentValidator{}
+var _ admission.DecoderInjector = &ExperimentValidator{}
+
+func (v *ExperimentValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	exp := &optimizev1beta2.Experiment{}
+	if err := v.decoder.Decode(req, exp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if exp.Namespace == "" {
+		exp.Namespace = req.Namespace
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queryDryRunTimeout)
+	defer cancel()
+
+	if err := validation.CheckGuardrails(&exp.Spec); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	if err := validation.CheckTrialRun(&exp.Spec.TrialTemplate.Spec); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	// Reject experiments the controller would fail for violating the optimization policies
+	policyList := &optimizev1beta2.OptimizationPolicyList{}
+	if err := v.reader.List(ctx, policyList); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if err := checkPolicies(exp, policyList.Items); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	t := experiment.SyntheticTrial(exp, time.Now())
+	for i := range exp.Spec.Metrics {
+		m := &exp.Spec.Metrics[i]
+
+		credentials, err := v.credentials(ctx, exp, m)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+
+		if err := validation.CheckMetricCredentials(m); err != nil {
+			return admission.Denied(err.Error())
+		}
+
+		if _, _, err := metric.ParseUnit(m.Unit); err != nil {
+			return admission.Denied(fmt.Sprintf("metric %q: %s", m.Name, err.Error()))
+		}
+
+		if err := metric.ValidatePrometheusQueries(ctx, m, t, credentials, v.DryRun); err != nil {
+			v.Log.Info("Rejected experiment metric", "experiment", req.Namespace+"/"+req.Name, "metric", m.Name, "message", err.Error())
+			return admission.Denied(fmt.Sprintf("metric %q: %s", m.Name, err.Error()))
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+// checkPolicies verifies the experiment conforms to the optimization policies without modifying it; the parameters
+// of experiments which do not exist on the server yet only need to be narrowable to fit the policy bounds.
+func checkPolicies(exp *optimizev1beta2.Experiment, policies []optimizev1beta2.OptimizationPolicy) error {
+	if meta.HasFinalizer(exp, server.Finalizer) {
+		return experiment.CheckPolicies(exp, policies)
+	}
+	_, err := experiment.ApplyPolicies(exp.DeepCopy(), policies)
+	return err
+}
+
+// credentials returns the secret needed to query the Prometheus server of a metric during a dry run.
+func (v *ExperimentValidator) credentials(ctx context.Context, exp *optimizev1beta2.Experiment, m *optimizev1beta2.Metric) (*corev1.Secret, error) {
+	if !v.DryRun || m.BearerTokenSecretRef == nil {
+		return nil, nil
+	}
+
+	credentials, err := getMetricSecret(ctx, v.reader, exp.Namespace, m)
+	return credentials, client.IgnoreNotFound(err)
+}
+
+func (v *ExperimentValidator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
+
+func (v *ExperimentValidator) SetupWithManager(mgr ctrl.Manager) error {
+	v.reader = mgr.GetAPIReader()
+	mgr.GetWebhookServer().Register(experimentValidatorPath, &webhook.Admission{Handler: v})
+	return nil
+}
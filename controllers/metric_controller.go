@@ -20,7 +20,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"os"
 	"strconv"
+	"time"
 
 	"github.com/go-logr/logr"
 	batchv1 "k8s.io/api/batch/v1"
@@ -29,26 +31,47 @@ import (
 	"github.com/thestormforge/optimize-controller/v2/internal/controller"
 	"github.com/thestormforge/optimize-controller/v2/internal/meta"
 	"github.com/thestormforge/optimize-controller/v2/internal/metric"
+	"github.com/thestormforge/optimize-controller/v2/internal/pricing"
 	"github.com/thestormforge/optimize-controller/v2/internal/trial"
 	"github.com/thestormforge/optimize-controller/v2/internal/validation"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	// defaultGuardrailPeriod is how often guardrails are checked when a period is not specified
+	defaultGuardrailPeriod = 30 * time.Second
+	// defaultMetricAttempts is the number of times metric collection is attempted before failing the trial
+	defaultMetricAttempts = 3
+	// defaultMetricBackoff is the initial delay between metric collection attempts that failed with a transient error
+	defaultMetricBackoff = 5 * time.Second
+	// maxMetricBackoff is the maximum delay between metric collection attempts
+	maxMetricBackoff = 5 * time.Minute
+)
+
 // MetricReconciler reconciles the metrics on a Trial object
 type MetricReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	// Credentials and price sheets are read directly to avoid caching every secret and config map in the cluster
+	apiReader client.Reader
+	attempts  int
+	backoff   time.Duration
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get;list;watch
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=batch;extensions,resources=jobs,verbs=list;patch
 // +kubebuilder:rbac:groups="",resources=pods,verbs=list
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get
 
 func (r *MetricReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -59,6 +82,10 @@ func (r *MetricReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, controller.IgnoreNotFound(err)
 	}
 
+	if result, err := r.checkGuardrails(ctx, t, &now); result != nil {
+		return *result, err
+	}
+
 	if result, err := r.evaluateMetrics(ctx, t, &now); result != nil {
 		return *result, err
 	}
@@ -71,6 +98,8 @@ func (r *MetricReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 }
 
 func (r *MetricReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.apiReader = mgr.GetAPIReader()
+	r.attempts, r.backoff = metricRetryPolicy(r.Log)
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("metric").
 		For(&optimizev1beta2.Trial{}).
@@ -88,12 +117,17 @@ func (r *MetricReconciler) ignoreTrial(t *optimizev1beta2.Trial) bool {
 		return true
 	}
 
-	// Ignore trials to do not have defined start/completion times
+	// Ignore trials to do not have a defined start time
 	// NOTE: This checks the status to prevent needing to reproduce job start/completion lookup logic
-	if t.Status.StartTime == nil || t.Status.CompletionTime == nil {
+	if t.Status.StartTime == nil {
 		return true
 	}
 
+	// Do not ignore trials that are still running, they may have guardrails to check
+	if t.Status.CompletionTime == nil {
+		return false
+	}
+
 	// Do not ignore trials that have metrics pending collection
 	for i := range t.Spec.Values {
 		if t.Spec.Values[i].AttemptsRemaining > 0 {
@@ -110,6 +144,139 @@ func (r *MetricReconciler) ignoreTrial(t *optimizev1beta2.Trial) bool {
 	return true
 }
 
+// checkGuardrails evaluates the experiment guardrails while the trial run is in progress, the trial is aborted if
+// any of the guardrail metrics are out of bounds. A result is always returned for trials that are still running.
+func (r *MetricReconciler) checkGuardrails(ctx context.Context, t *optimizev1beta2.Trial, probeTime *metav1.Time) (*ctrl.Result, error) {
+	// Guardrails only apply while the trial run is in progress
+	if t.Status.CompletionTime != nil {
+		return nil, nil
+	}
+
+	// Fetch the experiment
+	exp := &optimizev1beta2.Experiment{}
+	if err := r.Get(ctx, t.ExperimentNamespacedName(), exp); err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	// Nothing to check if the experiment does not have guardrails, metrics are not collected until the run completes
+	if len(exp.Spec.Guardrails) == 0 {
+		return &ctrl.Result{}, nil
+	}
+
+	// Index a DEEP COPY of the metric definitions so we can safely make changes
+	metrics := make(map[string]*optimizev1beta2.Metric, len(exp.Spec.Metrics))
+	for i := range exp.Spec.Metrics {
+		metrics[exp.Spec.Metrics[i].Name] = exp.Spec.Metrics[i].DeepCopy()
+	}
+
+	// Capture the metrics as if the trial run completed now
+	running := t.DeepCopy()
+	running.Status.CompletionTime = probeTime
+
+	log := r.Log.WithValues(
+		"trial", fmt.Sprintf("%s/%s", t.Namespace, t.Name),
+		"startTime", t.Status.StartTime.Time,
+	)
+
+	var requeueAfter time.Duration
+	for i := range exp.Spec.Guardrails {
+		g := &exp.Spec.Guardrails[i]
+		m := metrics[g.Metric]
+		if m == nil {
+			// Guardrails on undefined metrics are rejected by validation, only experiments created before it get here
+			continue
+		}
+
+		period := time.Duration(g.PeriodSeconds) * time.Second
+		if period <= 0 {
+			period = defaultGuardrailPeriod
+		}
+
+		// Wait for the initial delay before checking the guardrail
+		if delay := t.Status.StartTime.Add(time.Duration(g.InitialDelaySeconds) * time.Second).Sub(probeTime.Time); delay > 0 {
+			if requeueAfter <= 0 || delay < requeueAfter {
+				requeueAfter = delay
+			}
+			continue
+		}
+		if requeueAfter <= 0 || period < requeueAfter {
+			requeueAfter = period
+		}
+
+		// Failure to capture a guardrail metric does not fail the trial
+		value, err := r.captureGuardrail(ctx, log, running, m)
+		if err != nil {
+			log.Error(err, "Unable to check guardrail", "metric", g.Metric)
+			continue
+		}
+
+		if err := validation.CheckGuardrail(g, value); err != nil {
+			log.Info("Aborting trial", "metric", g.Metric, "value", value)
+			trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, "GuardrailViolated", err.Error(), probeTime)
+			if err := r.suspendTrialJobs(ctx, t); err != nil {
+				return &ctrl.Result{}, err
+			}
+			err := r.Update(ctx, t)
+			return controller.RequeueConflict(err)
+		}
+	}
+
+	return &ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// captureGuardrail returns the current value of a guardrail metric.
+func (r *MetricReconciler) captureGuardrail(ctx context.Context, log logr.Logger, t *optimizev1beta2.Trial, m *optimizev1beta2.Metric) (float64, error) {
+	if err := r.applyMetricDefaults(ctx, t, m); err != nil {
+		return 0, err
+	}
+
+	target, err := r.target(ctx, t, m)
+	if err != nil {
+		return 0, err
+	}
+
+	credentials, err := getMetricSecret(ctx, r.reader(), t.ExperimentNamespacedName().Namespace, m)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, err = r.withPricing(ctx, t)
+	if err != nil {
+		return 0, err
+	}
+
+	value, _, err := metric.CaptureMetric(ctx, log, t, m, target, credentials)
+	return value, err
+}
+
+// suspendTrialJobs sets the parallelism of the trial run jobs to 0 to terminate any active pods
+func (r *MetricReconciler) suspendTrialJobs(ctx context.Context, t *optimizev1beta2.Trial) error {
+	matchingSelector, err := meta.MatchingSelector(t.GetJobSelector())
+	if err != nil {
+		return err
+	}
+
+	jobList := &batchv1.JobList{}
+	if err := r.List(ctx, jobList, client.InNamespace(t.Namespace), matchingSelector); err != nil {
+		return err
+	}
+
+	for i := range jobList.Items {
+		job := &jobList.Items[i]
+		if job.Labels[optimizev1beta2.LabelTrialRole] == "trialSetup" {
+			continue
+		}
+		if job.Spec.Parallelism != nil && *job.Spec.Parallelism == 0 {
+			continue
+		}
+		if err := r.Patch(ctx, job, client.RawPatch(types.StrategicMergePatchType, []byte(`{ "spec": { "parallelism": 0  } }`))); controller.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *MetricReconciler) evaluateMetrics(ctx context.Context, t *optimizev1beta2.Trial, probeTime *metav1.Time) (*ctrl.Result, error) {
 	// TODO This check precludes manual additions of Values
 	if len(t.Spec.Values) > 0 {
@@ -126,7 +293,7 @@ func (r *MetricReconciler) evaluateMetrics(ctx context.Context, t *optimizev1bet
 	for _, m := range exp.Spec.Metrics {
 		t.Spec.Values = append(t.Spec.Values, optimizev1beta2.Value{
 			Name:              m.Name,
-			AttemptsRemaining: 3,
+			AttemptsRemaining: r.maxAttempts(),
 		})
 	}
 
@@ -173,18 +340,53 @@ func (r *MetricReconciler) collectMetrics(ctx context.Context, t *optimizev1beta
 			return r.collectionAttempt(ctx, log, t, v, probeTime, err)
 		}
 
+		// Give the metric source time to catch up before collecting
+		if d := metric.CollectionDelay(m, t, probeTime.Time); d > 0 {
+			return &ctrl.Result{RequeueAfter: d}, nil
+		}
+
+		// Back off if the previous attempt failed with a transient error
+		if d := r.retryDelay(t, v, probeTime.Time); d > 0 {
+			return &ctrl.Result{RequeueAfter: d}, nil
+		}
+
 		// Do any Kube API lookups while we have the API client
 		target, err := r.target(ctx, t, m)
 		if err != nil {
 			return r.collectionAttempt(ctx, log, t, v, probeTime, err)
 		}
 
+		// Remote metric sources may need credentials from a secret in the experiment namespace
+		credentials, err := getMetricSecret(ctx, r.reader(), t.ExperimentNamespacedName().Namespace, m)
+		if err != nil {
+			return r.collectionAttempt(ctx, log, t, v, probeTime, err)
+		}
+
+		// Resolve the prices used for cost metrics
+		ctx, err := r.withPricing(ctx, t)
+		if err != nil {
+			return r.collectionAttempt(ctx, log, t, v, probeTime, err)
+		}
+
 		// Capture the metric value
-		value, valueError, err := metric.CaptureMetric(ctx, log, t, m, target)
+		value, valueError, err := metric.CaptureMetric(ctx, log, t, m, target, credentials)
 		if err != nil {
 			return r.collectionAttempt(ctx, log, t, v, probeTime, err)
 		}
 
+		// Reject implausible values (e.g. a zero from a gap in the scraped data) instead of reporting them
+		if err := metric.CheckPlausible(m, value); err != nil {
+			return r.collectionAttempt(ctx, log, t, v, probeTime, err)
+		}
+
+		// Record values in canonical units so metrics from different sources are comparable
+		if value, err = metric.NormalizeValue(m, value); err != nil {
+			return r.collectionAttempt(ctx, log, t, v, probeTime, err)
+		}
+		if valueError, err = metric.NormalizeValue(m, valueError); err != nil {
+			return r.collectionAttempt(ctx, log, t, v, probeTime, err)
+		}
+
 		// Success, record the value
 		v.Value = strconv.FormatFloat(value, 'f', -1, 64)
 		if !math.IsNaN(valueError) {
@@ -226,12 +428,26 @@ func (r *MetricReconciler) collectionAttempt(ctx context.Context, log logr.Logge
 		v.AttemptsRemaining = 0
 	}
 
+	// Classify the error so transient failures can be retried with a delay
+	var reason, message string
+	if err != nil {
+		if failureReason, transient := metric.FailureReason(err); transient {
+			reason, message = failureReason, err.Error()
+		}
+
+		// Implausible values are only collected one more time before failing the trial
+		if reason == metric.ReasonImplausible && observedReason(t) == metric.ReasonImplausible {
+			v.AttemptsRemaining = 0
+		}
+	}
+
 	// Update the probe time and ensure that trial observed is still explicitly false (i.e. we have started observation but it is not complete)
-	trial.ApplyCondition(&t.Status, optimizev1beta2.TrialObserved, corev1.ConditionFalse, "", "", probeTime)
+	trial.ApplyCondition(&t.Status, optimizev1beta2.TrialObserved, corev1.ConditionFalse, reason, message, probeTime)
 
 	// Fail the trial if there is an error and no attempts are left
 	if err != nil && v.AttemptsRemaining == 0 {
-		trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, "MetricFailed", err.Error(), probeTime)
+		reason, _ := metric.FailureReason(err)
+		trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, reason, err.Error(), probeTime)
 
 		// Metric errors contain additional information which should be logged for debugging
 		if merr, ok := err.(*metric.CaptureError); ok {
@@ -243,9 +459,108 @@ func (r *MetricReconciler) collectionAttempt(ctx context.Context, log logr.Logge
 	return controller.RequeueConflict(r.Update(ctx, t))
 }
 
+// observedReason returns the reason of the in-progress trial observed condition.
+func observedReason(t *optimizev1beta2.Trial) string {
+	for _, c := range t.Status.Conditions {
+		if c.Type == optimizev1beta2.TrialObserved && c.Status == corev1.ConditionFalse {
+			return c.Reason
+		}
+	}
+	return ""
+}
+
+// maxAttempts returns the number of times metric collection is attempted.
+func (r *MetricReconciler) maxAttempts() int {
+	if r.attempts > 0 {
+		return r.attempts
+	}
+	return defaultMetricAttempts
+}
+
+// retryDelay returns the amount of time remaining before the next collection attempt for a value whose previous
+// attempt failed with a transient error, the delay doubles with each failed attempt.
+func (r *MetricReconciler) retryDelay(t *optimizev1beta2.Trial, v *optimizev1beta2.Value, now time.Time) time.Duration {
+	failed := r.maxAttempts() - v.AttemptsRemaining
+	if failed <= 0 {
+		return 0
+	}
+
+	var lastAttempt time.Time
+	for _, c := range t.Status.Conditions {
+		if c.Type != optimizev1beta2.TrialObserved || c.Status != corev1.ConditionFalse {
+			continue
+		}
+		if c.Reason != metric.ReasonUnreachable && c.Reason != metric.ReasonNoData && c.Reason != metric.ReasonImplausible {
+			return 0
+		}
+		lastAttempt = c.LastProbeTime.Time
+		if c.LastTransitionTime.After(lastAttempt) {
+			lastAttempt = c.LastTransitionTime.Time
+		}
+	}
+	if lastAttempt.IsZero() {
+		return 0
+	}
+
+	backoff := r.backoff
+	if backoff <= 0 {
+		backoff = defaultMetricBackoff
+	}
+	for i := 1; i < failed && backoff < maxMetricBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxMetricBackoff {
+		backoff = maxMetricBackoff
+	}
+
+	return lastAttempt.Add(backoff).Sub(now)
+}
+
+// metricRetryPolicy returns the configured number of metric collection attempts and the initial backoff between
+// attempts that fail with a transient error.
+func metricRetryPolicy(log logr.Logger) (int, time.Duration) {
+	attempts, backoff := defaultMetricAttempts, defaultMetricBackoff
+
+	if s, ok := os.LookupEnv("STORMFORGE_METRIC_ATTEMPTS"); ok {
+		if a, err := strconv.Atoi(s); err != nil || a < 1 {
+			log.Info("Ignoring invalid metric collection attempts", "attempts", s)
+		} else {
+			log.Info("Using custom metric collection attempts", "attempts", s)
+			attempts = a
+		}
+	}
+
+	if s, ok := os.LookupEnv("STORMFORGE_METRIC_BACKOFF"); ok {
+		if d, err := time.ParseDuration(s); err != nil || d <= 0 {
+			log.Info("Ignoring invalid metric collection backoff", "backoff", s)
+		} else {
+			log.Info("Using custom metric collection backoff", "backoff", s)
+			backoff = d
+		}
+	}
+
+	return attempts, backoff
+}
+
+// withPricing returns a context carrying the price sheet configured for the trial namespace, if any.
+func (r *MetricReconciler) withPricing(ctx context.Context, t *optimizev1beta2.Trial) (context.Context, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.reader().Get(ctx, client.ObjectKey{Namespace: t.Namespace, Name: pricing.ConfigMapName}, cm); err != nil {
+		return ctx, controller.IgnoreNotFound(err)
+	}
+
+	ps, err := pricing.FromConfigMap(cm)
+	if err != nil {
+		return ctx, fmt.Errorf("invalid price sheet %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+	return pricing.NewContext(ctx, ps), nil
+}
+
 // target looks up the Kubernetes object (if any) associated with a metric.
 func (r *MetricReconciler) target(ctx context.Context, t *optimizev1beta2.Trial, m *optimizev1beta2.Metric) (runtime.Object, error) {
-	if m.Type != optimizev1beta2.MetricKubernetes && m.Type != "" {
+	switch m.Type {
+	case optimizev1beta2.MetricKubernetes, optimizev1beta2.MetricKubernetesObject, "":
+	default:
 		return nil, nil
 	}
 
@@ -285,10 +600,40 @@ func (r *MetricReconciler) target(ctx context.Context, t *optimizev1beta2.Trial,
 	return target, nil
 }
 
+// getMetricSecret reads the secret containing the credentials of a remote metric source. The reader should not be
+// backed by the informer cache: that would require permission to list and watch every secret in the cluster.
+func getMetricSecret(ctx context.Context, reader client.Reader, namespace string, m *optimizev1beta2.Metric) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	switch {
+	case m.BearerTokenSecretRef != nil:
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: m.BearerTokenSecretRef.Name}, secret); err != nil {
+			if m.BearerTokenSecretRef.Optional != nil && *m.BearerTokenSecretRef.Optional {
+				return nil, controller.IgnoreNotFound(err)
+			}
+			return nil, err
+		}
+	case m.CredentialsSecretRef != nil:
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: m.CredentialsSecretRef.Name}, secret); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+	return secret, nil
+}
+
+// reader returns the uncached reader used for objects the informer cache should not hold (e.g. secrets).
+func (r *MetricReconciler) reader() client.Reader {
+	if r.apiReader != nil {
+		return r.apiReader
+	}
+	return r.Client
+}
+
 // applyMetricDefaults fills in default values for the supplied metric.
 func (r *MetricReconciler) applyMetricDefaults(ctx context.Context, t *optimizev1beta2.Trial, m *optimizev1beta2.Metric) error {
 	// Give Prometheus metrics a default URL
-	if m.Type == optimizev1beta2.MetricPrometheus && m.URL == "" {
+	if (m.Type == optimizev1beta2.MetricPrometheus || m.Type == optimizev1beta2.MetricPrometheusHistogram) && m.URL == "" {
 		m.URL = fmt.Sprintf("http://optimize-%[1]s-prometheus.%[1]s:9090/", t.Namespace)
 	}
 
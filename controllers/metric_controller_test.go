@@ -0,0 +1,208 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/metric"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestMetricReconciler_RetryDelay(t *testing.T) {
+	now := time.Now()
+	r := &MetricReconciler{attempts: 3, backoff: 10 * time.Second}
+
+	testCases := []struct {
+		desc              string
+		reason            string
+		attemptsRemaining int
+		lastAttempt       time.Time
+		expected          time.Duration
+	}{
+		{
+			desc:              "first attempt",
+			attemptsRemaining: 3,
+			lastAttempt:       now,
+		},
+		{
+			desc:              "not transient",
+			reason:            "",
+			attemptsRemaining: 2,
+			lastAttempt:       now,
+		},
+		{
+			desc:              "unreachable",
+			reason:            metric.ReasonUnreachable,
+			attemptsRemaining: 2,
+			lastAttempt:       now.Add(-4 * time.Second),
+			expected:          6 * time.Second,
+		},
+		{
+			desc:              "no data doubles",
+			reason:            metric.ReasonNoData,
+			attemptsRemaining: 1,
+			lastAttempt:       now.Add(-5 * time.Second),
+			expected:          15 * time.Second,
+		},
+		{
+			desc:              "elapsed",
+			reason:            metric.ReasonNoData,
+			attemptsRemaining: 2,
+			lastAttempt:       now.Add(-time.Minute),
+			expected:          -50 * time.Second,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tr := &optimizev1beta2.Trial{
+				Status: optimizev1beta2.TrialStatus{
+					Conditions: []optimizev1beta2.TrialCondition{
+						{
+							Type:               optimizev1beta2.TrialObserved,
+							Status:             corev1.ConditionFalse,
+							Reason:             tc.reason,
+							LastProbeTime:      metav1.NewTime(tc.lastAttempt),
+							LastTransitionTime: metav1.NewTime(tc.lastAttempt),
+						},
+					},
+				},
+			}
+			v := &optimizev1beta2.Value{Name: "test", AttemptsRemaining: tc.attemptsRemaining}
+			assert.Equal(t, tc.expected, r.retryDelay(tr, v, now))
+		})
+	}
+}
+
+func TestMetricReconciler_CollectionAttemptImplausible(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+
+	tr := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: optimizev1beta2.TrialSpec{
+			Values: []optimizev1beta2.Value{{Name: "latency", AttemptsRemaining: 3}},
+		},
+	}
+	r := &MetricReconciler{Client: fake.NewFakeClientWithScheme(scheme, tr), attempts: 3}
+	implausible := &metric.CaptureError{Message: "metric value 0.000000 for latency is below the plausible minimum of 1m", Implausible: true}
+	probeTime := metav1.Now()
+	v := &tr.Spec.Values[0]
+
+	// The first implausible value is collected again
+	_, err := r.collectionAttempt(context.TODO(), log.NullLogger{}, tr, v, &probeTime, implausible)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, v.AttemptsRemaining)
+		assert.Equal(t, metric.ReasonImplausible, observedReason(tr))
+		assert.False(t, failedImplausible(tr))
+	}
+
+	// The second implausible value fails the trial
+	_, err = r.collectionAttempt(context.TODO(), log.NullLogger{}, tr, v, &probeTime, implausible)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, v.AttemptsRemaining)
+		assert.True(t, failedImplausible(tr))
+	}
+}
+
+func TestMetricReconciler_RunningTrialWithoutGuardrails(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+
+	exp := &optimizev1beta2.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: optimizev1beta2.ExperimentSpec{
+			Metrics: []optimizev1beta2.Metric{{Name: "latency", Query: "1"}},
+		},
+	}
+	startTime := metav1.Now()
+	tr := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-000", Labels: map[string]string{optimizev1beta2.LabelExperiment: "test"}},
+		Status:     optimizev1beta2.TrialStatus{StartTime: &startTime},
+	}
+	r := &MetricReconciler{Client: fake.NewFakeClientWithScheme(scheme, exp, tr), Log: log.NullLogger{}, attempts: 3}
+
+	// Reconcile more than once: the first pass must not leave the trial in a state the next pass cannot handle
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-000"}}
+	for i := 0; i < 2; i++ {
+		_, err := r.Reconcile(req)
+		assert.NoError(t, err)
+	}
+
+	actual := &optimizev1beta2.Trial{}
+	if assert.NoError(t, r.Get(context.TODO(), req.NamespacedName, actual)) {
+		assert.Empty(t, actual.Spec.Values)
+		assert.Nil(t, actual.Status.CompletionTime)
+		for _, c := range actual.Status.Conditions {
+			assert.NotEqual(t, optimizev1beta2.TrialObserved, c.Type)
+		}
+	}
+}
+
+func failedImplausible(t *optimizev1beta2.Trial) bool {
+	for _, c := range t.Status.Conditions {
+		if c.Type == optimizev1beta2.TrialFailed {
+			return c.Status == corev1.ConditionTrue && c.Reason == metric.ReasonImplausible
+		}
+	}
+	return false
+}
+
+func TestMetricReconciler_TargetAndCredentials(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = optimizev1beta2.AddToScheme(scheme)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "prometheus"},
+		Data:       map[string][]byte{"token": []byte("abc123")},
+	}
+	tr := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-000", Labels: map[string]string{optimizev1beta2.LabelExperiment: "test"}},
+	}
+	m := &optimizev1beta2.Metric{
+		Name:  "duration",
+		Query: "{{ duration .StartTime .CompletionTime }}",
+		BearerTokenSecretRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "prometheus"},
+			Key:                  "token",
+		},
+	}
+
+	// Without an API reader the client is used to read the credentials
+	r := &MetricReconciler{Client: fake.NewFakeClientWithScheme(scheme, secret, tr), Log: log.NullLogger{}}
+
+	target, err := r.target(context.TODO(), tr, m)
+	if assert.NoError(t, err) {
+		assert.Equal(t, tr, target)
+	}
+
+	credentials, err := getMetricSecret(context.TODO(), r.reader(), tr.ExperimentNamespacedName().Namespace, m)
+	if assert.NoError(t, err) && assert.NotNil(t, credentials) {
+		assert.Equal(t, "prometheus", credentials.Name)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/notify"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// notificationTimeout is the maximum amount of time spent delivering a single notification
+const notificationTimeout = 30 * time.Second
+
+// notifications resolves the notification settings of experiments, the "optimize-notifications" config map and secret
+// of an experiment namespace take precedence over the settings from the controller environment.
+type notifications struct {
+	reader   client.Reader
+	defaults *notify.Settings
+}
+
+// newNotifications returns the notifications using the settings from the environment as the defaults.
+func newNotifications(log logr.Logger, reader client.Reader) *notifications {
+	defaults, err := notify.FromEnv()
+	if err != nil {
+		log.Info("Ignoring invalid notification configuration", "error", err.Error())
+		defaults = &notify.Settings{}
+	}
+	return &notifications{reader: reader, defaults: defaults}
+}
+
+// settings returns the notification settings for the experiment.
+func (n *notifications) settings(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment) *notify.Settings {
+	if n == nil {
+		return &notify.Settings{}
+	}
+
+	key := client.ObjectKey{Namespace: exp.Namespace, Name: notify.ConfigName}
+	cm, secret := &corev1.ConfigMap{}, &corev1.Secret{}
+	if err := n.reader.Get(ctx, key, cm); err != nil {
+		if controller.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to read notification config map", "configMap", key.String())
+		}
+		cm = nil
+	}
+	if err := n.reader.Get(ctx, key, secret); err != nil {
+		if controller.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to read notification secret", "secret", key.String())
+		}
+		secret = nil
+	}
+	if cm == nil && secret == nil {
+		return n.defaults
+	}
+
+	settings, err := notify.FromConfig(cm, secret)
+	if err != nil {
+		log.Info("Ignoring invalid notification configuration", "namespace", exp.Namespace, "error", err.Error())
+		return n.defaults
+	}
+	return settings
+}
+
+// send delivers an experiment notification in the background so slow backends do not hold up the reconciliation;
+// delivery failures are only logged.
+func (n *notifications) send(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment, notificationType, message string) {
+	notifier := n.settings(ctx, log, exp).Notifier
+	if notifier == nil {
+		return
+	}
+
+	nn := &notify.Notification{
+		Type:       notificationType,
+		Namespace:  exp.Namespace,
+		Experiment: exp.Name,
+		Message:    message,
+		Time:       time.Now().UTC(),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+		defer cancel()
+
+		if err := notifier.Notify(ctx, nn); err != nil {
+			log.Error(err, "Failed to send notification", "experiment", nn.Namespace+"/"+nn.Experiment, "type", nn.Type)
+		}
+	}()
+}
+
+// conditionMessage returns the message of the experiment condition if it is true.
+func conditionMessage(exp *optimizev1beta2.Experiment, conditionType optimizev1beta2.ExperimentConditionType) (string, bool) {
+	for _, c := range exp.Status.Conditions {
+		if c.Type == conditionType {
+			return c.Message, c.Status == corev1.ConditionTrue
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/notify"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestNotifications_Settings(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "configured", Name: notify.ConfigName},
+		Data:       map[string]string{notify.FailureStreakKey: "5"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "configured", Name: notify.ConfigName},
+		Data:       map[string][]byte{notify.WebhookURLKey: []byte("http://example.com/hook")},
+	}
+	invalid := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "invalid", Name: notify.ConfigName},
+		Data:       map[string]string{notify.FailureStreakKey: "many"},
+	}
+
+	defaults := &notify.Settings{FailureStreak: notify.DefaultFailureStreak}
+	n := &notifications{reader: fake.NewFakeClientWithScheme(scheme, cm, secret, invalid), defaults: defaults}
+
+	cases := []struct {
+		desc      string
+		namespace string
+		expected  *notify.Settings
+	}{
+		{
+			desc:      "defaults",
+			namespace: "default",
+			expected:  defaults,
+		},
+		{
+			desc:      "configured",
+			namespace: "configured",
+			expected: &notify.Settings{
+				Notifier:      notify.Notifiers{&notify.Webhook{URL: "http://example.com/hook"}},
+				FailureStreak: 5,
+			},
+		},
+		{
+			desc:      "invalid",
+			namespace: "invalid",
+			expected:  defaults,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: "test"}}
+			assert.Equal(t, c.expected, n.settings(context.TODO(), log.NullLogger{}, exp))
+		})
+	}
+}
@@ -18,20 +18,28 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sort"
+	"time"
 
 	"github.com/go-logr/logr"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/meta"
 	"github.com/thestormforge/optimize-controller/v2/internal/patch"
 	"github.com/thestormforge/optimize-controller/v2/internal/ready"
 	"github.com/thestormforge/optimize-controller/v2/internal/template"
 	"github.com/thestormforge/optimize-controller/v2/internal/trial"
 	"github.com/thestormforge/optimize-controller/v2/internal/validation"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -41,25 +49,40 @@ type PatchReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	apiReader client.Reader
+	pods      corev1client.PodsGetter
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get;list;watch
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups="",resources=pods,verbs=list
+// +kubebuilder:rbac:groups="",resources=pods/resize,verbs=patch
 
 // Reconcile inspects a trial to see if patches need to be applied. The "trial patched" status condition
 // is used to control what actions need to be taken. If the status is "unknown" then the experiment is fetched
 // and the patch templates will be rendered into the list of patch operations on the trial; once the patches
 // are evaluated the status will be "false". If the status is "false" then patch operations will be applied
-// to the cluster; once all the patches are applied the status will be "true".
+// to the cluster; once all the patches are applied the status will be "true". Once the trial is finished (or
+// deleted), the original state of objects patched with "restore" enabled is put back and the "trial restored"
+// status will be "true".
 func (r *PatchReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
 	now := metav1.Now()
 
 	t := &optimizev1beta2.Trial{}
-	if err := r.Get(ctx, req.NamespacedName, t); err != nil || r.ignoreTrial(t) {
+	if err := r.Get(ctx, req.NamespacedName, t); err != nil {
 		return ctrl.Result{}, controller.IgnoreNotFound(err)
 	}
 
+	if result, err := r.restorePatches(ctx, t, &now); result != nil {
+		return *result, err
+	}
+
+	if r.ignoreTrial(t) {
+		return ctrl.Result{}, nil
+	}
+
 	if result, err := r.evaluatePatchOperations(ctx, t, &now); result != nil {
 		return *result, err
 	}
@@ -73,6 +96,15 @@ func (r *PatchReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 // SetupWithManager registers a new patch reconciler with the supplied manager
 func (r *PatchReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.apiReader = mgr.GetAPIReader()
+	if r.pods == nil {
+		cs, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			return err
+		}
+		r.pods = cs.CoreV1()
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("patch").
 		For(&optimizev1beta2.Trial{}).
@@ -157,17 +189,77 @@ func (r *PatchReconciler) evaluatePatchOperations(ctx context.Context, t *optimi
 		} else if rc != nil {
 			t.Status.ReadinessChecks = append(t.Status.ReadinessChecks, *rc)
 		}
+
+		// Restart workloads which only consume the patched object
+		for j := range p.RestartRefs {
+			ref, data, err := patch.RenderRestart(t, &p.RestartRefs[j])
+			if err != nil {
+				return &ctrl.Result{}, err
+			}
+
+			if po, err := patch.CreatePatchOperation(t, &optimizev1beta2.PatchTemplate{Type: optimizev1beta2.PatchStrategic, Wave: p.Wave}, ref, data); err != nil {
+				return &ctrl.Result{}, err
+			} else if po != nil {
+				t.Status.PatchOperations = append(t.Status.PatchOperations, *po)
+			}
+
+			if rc, err := r.createReadinessCheck(t, ref, nil); err != nil {
+				return &ctrl.Result{}, err
+			} else if rc != nil {
+				t.Status.ReadinessChecks = append(t.Status.ReadinessChecks, *rc)
+			}
+		}
+	}
+
+	// Evaluate the endpoints
+	for i := range exp.Spec.Endpoints {
+		e := &exp.Spec.Endpoints[i]
+
+		// Render the endpoint as a strategic merge patch
+		ref, data, err := patch.RenderEndpoint(te, t, e)
+		if err != nil {
+			return &ctrl.Result{}, err
+		}
+
+		// Add a patch operation if necessary
+		if po, err := patch.CreatePatchOperation(t, &optimizev1beta2.PatchTemplate{Type: optimizev1beta2.PatchStrategic}, ref, data); err != nil {
+			return &ctrl.Result{}, err
+		} else if po != nil {
+			t.Status.PatchOperations = append(t.Status.PatchOperations, *po)
+		}
+
+		// Wait for the workload to roll out with the endpoint (config maps are consumed by other workloads)
+		if ref.Kind == "ConfigMap" {
+			continue
+		}
+		if rc, err := r.createReadinessCheck(t, ref, nil); err != nil {
+			return &ctrl.Result{}, err
+		} else if rc != nil {
+			t.Status.ReadinessChecks = append(t.Status.ReadinessChecks, *rc)
+		}
 	}
 
-	// Sort the patch operations so configuration patches are applied first
+	// Sort the patch operations by wave so configuration patches are applied first within each wave
 	sort.SliceStable(t.Status.PatchOperations, func(i, j int) bool {
-		return isConfigReference(&t.Status.PatchOperations[i].TargetRef) &&
-			!isConfigReference(&t.Status.PatchOperations[j].TargetRef)
+		pi, pj := &t.Status.PatchOperations[i], &t.Status.PatchOperations[j]
+		if pi.Wave != pj.Wave {
+			return pi.Wave < pj.Wave
+		}
+		return isConfigReference(&pi.TargetRef) && !isConfigReference(&pj.TargetRef)
 	})
 
 	// Add back any pre-existing readiness checks
 	t.Status.ReadinessChecks = append(t.Status.ReadinessChecks, readinessChecks...)
 
+	// Make sure the patched objects are restored before the trial goes away
+	for i := range t.Status.PatchOperations {
+		if t.Status.PatchOperations[i].Restore && t.Status.PatchOperations[i].AttemptsRemaining > 0 {
+			meta.AddFinalizer(t, patch.Finalizer)
+			trial.ApplyCondition(&t.Status, optimizev1beta2.TrialRestored, corev1.ConditionUnknown, "", "", probeTime)
+			break
+		}
+	}
+
 	// Update the status to indicate that patches are evaluated
 	trial.ApplyCondition(&t.Status, optimizev1beta2.TrialPatched, corev1.ConditionFalse, "", "", probeTime)
 	err := r.Update(ctx, t)
@@ -181,31 +273,52 @@ func (r *PatchReconciler) applyPatches(ctx context.Context, t *optimizev1beta2.T
 		return nil, nil
 	}
 
+	// Patches in later waves are not applied until the targets of the earlier waves are ready
+	wave, ok := currentWave(t.Status.PatchOperations)
+	if ok {
+		if result, err := r.waitForWave(ctx, t, wave, probeTime); result != nil {
+			return result, err
+		}
+	}
+
 	// Iterate over the patches, looking for remaining attempts
 	for i := range t.Status.PatchOperations {
 		p := &t.Status.PatchOperations[i]
-		if p.AttemptsRemaining == 0 {
+		if p.AttemptsRemaining == 0 || p.Wave != wave {
 			continue
 		}
 
-		// Construct a patch on an unstructured object
-		// RBAC: We assume that we have "patch" permission from a customer defined role so we do not limit what types we can patch
-		u := &unstructured.Unstructured{}
-		u.SetName(p.TargetRef.Name)
-		u.SetNamespace(p.TargetRef.Namespace)
-		u.SetGroupVersionKind(p.TargetRef.GroupVersionKind())
-		if err := r.Patch(ctx, u, client.RawPatch(p.PatchType, p.Data)); err != nil {
+		// Persist the restore data before the target is patched, otherwise a conflicting trial update would cause the
+		// patched object to be captured as the original on the next attempt
+		var err error
+		if p.Restore && p.RestoreData == nil {
+			err = r.captureRestoreData(ctx, p, patch.ApplyFieldManager(i))
+		} else if err = r.applyPatchOperation(ctx, p, patch.ApplyFieldManager(i)); err == nil {
+			p.AttemptsRemaining = 0
+			if next, ok := currentWave(t.Status.PatchOperations); ok && next != wave {
+				recordWaveTime(t, probeTime)
+			}
+		}
+
+		if err != nil {
+			reason := "PatchFailed"
 			p.AttemptsRemaining = p.AttemptsRemaining - 1
+
+			// Conflicting field managers will continue to revert the patched values, do not bother trying again
+			var conflict *patch.ConflictError
+			if errors.As(err, &conflict) {
+				reason = "PatchConflict"
+				p.AttemptsRemaining = 0
+			}
+
 			if p.AttemptsRemaining == 0 {
 				// There are no remaining patch attempts remaining, fail the trial
-				trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, "PatchFailed", err.Error(), probeTime)
+				trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, reason, err.Error(), probeTime)
 			}
-		} else {
-			p.AttemptsRemaining = 0
 		}
 
 		// Update the patch operation status
-		err := r.Update(ctx, t)
+		err = r.Update(ctx, t)
 		return controller.RequeueConflict(err)
 	}
 
@@ -215,6 +328,266 @@ func (r *PatchReconciler) applyPatches(ctx context.Context, t *optimizev1beta2.T
 	return controller.RequeueConflict(err)
 }
 
+// waitForWave evaluates the readiness checks of the targets patched before the supplied wave; the initial delay of
+// each check is measured from the time the previous wave finished applying
+func (r *PatchReconciler) waitForWave(ctx context.Context, t *optimizev1beta2.Trial, wave int32, probeTime *metav1.Time) (*ctrl.Result, error) {
+	reader := r.reader()
+	checker := newReadinessChecker(reader, t)
+	if lw, err := time.Parse(time.RFC3339, t.GetAnnotations()[optimizev1beta2.AnnotationLastWaveTime]); err == nil {
+		checker.epoch = metav1.NewTime(lw)
+	}
+	for i := range t.Status.ReadinessChecks {
+		c := &t.Status.ReadinessChecks[i]
+		if !patchedBefore(t.Status.PatchOperations, &c.TargetRef, wave) || checker.skipCheck(c, probeTime) {
+			continue
+		}
+
+		// Get the objects to check
+		ul, err := getCheckTargets(ctx, reader, c)
+		if err != nil {
+			readinessCheckFailed(t, probeTime, err)
+			err := r.Update(ctx, t)
+			return controller.RequeueConflict(err)
+		}
+
+		// Check for readiness
+		if msg, isReady, err := checker.check(ctx, c, ul, probeTime); err != nil {
+			readinessCheckFailed(t, probeTime, err)
+			err := r.Update(ctx, t)
+			return controller.RequeueConflict(err)
+		} else if !isReady {
+			trial.ApplyCondition(&t.Status, optimizev1beta2.TrialPatched, corev1.ConditionFalse, "Waiting", msg, probeTime)
+		}
+	}
+
+	// The earlier waves are ready, the current wave can be applied
+	if checker.ready {
+		return nil, nil
+	}
+
+	// We may need to requeue and try again (e.g. all of the checks are in the initial delay)
+	if checker.requeue && checker.after > 0 {
+		return &ctrl.Result{RequeueAfter: checker.after}, nil
+	}
+
+	err := r.Update(ctx, t)
+	return controller.RequeueConflict(err)
+}
+
+// applyPatchOperation patches the target of the patch operation, possibly resizing the running pods in place
+func (r *PatchReconciler) applyPatchOperation(ctx context.Context, p *optimizev1beta2.PatchOperation, fieldManager string) error {
+	if p.InPlaceResize {
+		ok, err := r.resizeInPlace(ctx, p)
+		if err != nil {
+			return err
+		}
+		if ok {
+			p.InPlaceResized = true
+			return nil
+		}
+	}
+
+	// Construct a patch on an unstructured object
+	// RBAC: We assume that we have "patch" permission from a customer defined role so we do not limit what types we can patch
+	u := &unstructured.Unstructured{}
+	u.SetName(p.TargetRef.Name)
+	u.SetNamespace(p.TargetRef.Namespace)
+	u.SetGroupVersionKind(p.TargetRef.GroupVersionKind())
+	return r.patch(ctx, u, p, fieldManager)
+}
+
+// captureRestoreData records the difference between the original state of the patch target and the result of
+// a dry run of the patch, the patch itself is not applied
+func (r *PatchReconciler) captureRestoreData(ctx context.Context, p *optimizev1beta2.PatchOperation, fieldManager string) error {
+	original := &unstructured.Unstructured{}
+	original.SetGroupVersionKind(p.TargetRef.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKey{Namespace: p.TargetRef.Namespace, Name: p.TargetRef.Name}, original); err != nil {
+		return err
+	}
+
+	// Resized pods are restored to match the workload, if that is not possible the workload is patched instead
+	if p.InPlaceResize {
+		if data, ok := patch.InPlaceRestorePatch(p.Data, original.Object); ok {
+			p.RestoreData = data
+			return nil
+		}
+		p.InPlaceResize = false
+	}
+
+	u := original.DeepCopy()
+	if err := r.patch(ctx, u, p, fieldManager, client.DryRunAll); err != nil {
+		return err
+	}
+
+	data, err := patch.RestorePatch(original.Object, u.Object)
+	if err != nil {
+		return err
+	}
+
+	// An empty merge patch records that the original state was captured and there is nothing to restore
+	if data == nil {
+		data = []byte("{}")
+	}
+	p.RestoreData = data
+	return nil
+}
+
+// patch applies the patch operation server-side when possible, falling back to a regular patch
+func (r *PatchReconciler) patch(ctx context.Context, u *unstructured.Unstructured, p *optimizev1beta2.PatchOperation, fieldManager string, opts ...client.PatchOption) error {
+	data, ok, err := patch.ApplyData(p)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return r.Patch(ctx, u, client.RawPatch(p.PatchType, p.Data), append(opts, client.FieldOwner(patch.FieldManager))...)
+	}
+
+	err = r.Patch(ctx, u, client.RawPatch(types.ApplyPatchType, data), append(opts, client.FieldOwner(fieldManager))...)
+	if conflict, force := patch.Conflict(err); conflict != nil {
+		if !force {
+			return conflict
+		}
+		err = r.Patch(ctx, u, client.RawPatch(types.ApplyPatchType, data), append(opts, client.FieldOwner(fieldManager), client.ForceOwnership)...)
+	}
+
+	// Older clusters may not support server-side apply
+	if apierrors.IsUnsupportedMediaType(err) {
+		return r.Patch(ctx, u, client.RawPatch(p.PatchType, p.Data), append(opts, client.FieldOwner(patch.FieldManager))...)
+	}
+	return err
+}
+
+// restorePatches reverts the patched objects back to their original state once the trial is finished or deleted
+func (r *PatchReconciler) restorePatches(ctx context.Context, t *optimizev1beta2.Trial, probeTime *metav1.Time) (*ctrl.Result, error) {
+	// Only restore patches if the "restored" status is "unknown" or "false"
+	if !trial.CheckCondition(&t.Status, optimizev1beta2.TrialRestored, corev1.ConditionUnknown) &&
+		!trial.CheckCondition(&t.Status, optimizev1beta2.TrialRestored, corev1.ConditionFalse) {
+		return nil, nil
+	}
+
+	// Wait for the trial to finish or get deleted
+	if !trial.IsFinished(t) && t.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	// Restore in the reverse order the patches were applied so configuration objects are restored last
+	reason, message := "", ""
+	for i := len(t.Status.PatchOperations) - 1; i >= 0; i-- {
+		p := &t.Status.PatchOperations[i]
+		if len(p.RestoreData) == 0 || string(p.RestoreData) == "{}" {
+			continue
+		}
+
+		err := controller.IgnoreNotFound(r.restorePatchOperation(ctx, p))
+
+		// Forbidden indicates the namespace is being deleted or we no longer have access, do not hold up the trial
+		if apierrors.IsForbidden(err) {
+			r.Log.Info("Unable to restore patched object", "targetRef", p.TargetRef, "error", err.Error())
+			reason, message = "Forbidden", err.Error()
+			continue
+		}
+		if err != nil {
+			return &ctrl.Result{}, err
+		}
+	}
+
+	trial.ApplyCondition(&t.Status, optimizev1beta2.TrialRestored, corev1.ConditionTrue, reason, message, probeTime)
+	meta.RemoveFinalizer(t, patch.Finalizer)
+	err := r.Update(ctx, t)
+	return controller.RequeueConflict(err)
+}
+
+// restorePatchOperation applies the restore data of a patch operation to the target, or to the pods of the target if
+// they were resized in place
+func (r *PatchReconciler) restorePatchOperation(ctx context.Context, p *optimizev1beta2.PatchOperation) error {
+	if p.InPlaceResized {
+		data, _ := patch.InPlaceResizePatch(p.RestoreData)
+		pods, err := r.listTargetPods(ctx, &p.TargetRef)
+		if err != nil {
+			return err
+		}
+		for i := range pods {
+			// Pods replaced during the trial were created from the unmodified workload and do not need to be restored
+			if err := r.resizePod(&pods[i], data); controller.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Restore data for in-place resize patches is a strategic merge patch of the container resources
+	patchType := types.MergePatchType
+	if p.InPlaceResize {
+		patchType = types.StrategicMergePatchType
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetName(p.TargetRef.Name)
+	u.SetNamespace(p.TargetRef.Namespace)
+	u.SetGroupVersionKind(p.TargetRef.GroupVersionKind())
+	return r.Patch(ctx, u, client.RawPatch(patchType, p.RestoreData), client.FieldOwner(patch.FieldManager))
+}
+
+// resizeInPlace applies the container resources of a patch operation directly to the pods of the target workload,
+// returning false if the pods cannot all be resized (e.g. the InPlacePodVerticalScaling feature is not enabled)
+func (r *PatchReconciler) resizeInPlace(ctx context.Context, p *optimizev1beta2.PatchOperation) (bool, error) {
+	data, ok := patch.InPlaceResizePatch(p.Data)
+	if !ok {
+		return false, nil
+	}
+
+	pods, err := r.listTargetPods(ctx, &p.TargetRef)
+	if err != nil || len(pods) == 0 {
+		return false, err
+	}
+
+	for i := range pods {
+		if err := r.resizePod(&pods[i], data); err != nil {
+			// Fall back to a rollout, it replaces any pods that were already resized so the trial is consistent
+			r.Log.Info("Unable to resize pods in place", "targetRef", p.TargetRef, "resized", i, "error", err.Error())
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// listTargetPods returns the pods selected by the target workload
+func (r *PatchReconciler) listTargetPods(ctx context.Context, ref *corev1.ObjectReference) ([]corev1.Pod, error) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(ref.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, u); err != nil {
+		return nil, err
+	}
+	sel, found, err := unstructured.NestedMap(u.Object, "spec", "selector")
+	if err != nil || !found {
+		return nil, err
+	}
+	ls := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(sel, ls); err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(ls)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(ref.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// resizePod applies a strategic merge patch of the container resources to the "resize" subresource of a pod
+func (r *PatchReconciler) resizePod(pod *corev1.Pod, data []byte) error {
+	if r.pods == nil {
+		return fmt.Errorf("unable to resize pod %q, missing pods client", pod.Name)
+	}
+	_, err := r.pods.Pods(pod.Namespace).Patch(pod.Name, types.StrategicMergePatchType, data, "resize")
+	return err
+}
+
 // createReadinessCheck creates a readiness check for a patch operation
 func (r *PatchReconciler) createReadinessCheck(t *optimizev1beta2.Trial, ref *corev1.ObjectReference, readinessGates []optimizev1beta2.PatchReadinessGate) (*optimizev1beta2.ReadinessCheck, error) {
 	// Do not create a readiness check on the trial job or if there is already an explicit readiness gate
@@ -250,6 +623,49 @@ func (r *PatchReconciler) createReadinessCheck(t *optimizev1beta2.Trial, ref *co
 	return rc, nil
 }
 
+// currentWave returns the lowest wave with patch operations that have not been applied yet
+func currentWave(ops []optimizev1beta2.PatchOperation) (int32, bool) {
+	var wave int32
+	var ok bool
+	for i := range ops {
+		if ops[i].AttemptsRemaining > 0 && (!ok || ops[i].Wave < wave) {
+			wave, ok = ops[i].Wave, true
+		}
+	}
+	return wave, ok
+}
+
+// recordWaveTime records the time at which a wave of patches finished applying
+func recordWaveTime(t *optimizev1beta2.Trial, probeTime *metav1.Time) {
+	annotations := t.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[optimizev1beta2.AnnotationLastWaveTime] = probeTime.UTC().Format(time.RFC3339)
+	t.SetAnnotations(annotations)
+}
+
+// reader returns the uncached reader used to fetch readiness check targets
+func (r *PatchReconciler) reader() client.Reader {
+	if r.apiReader != nil {
+		return r.apiReader
+	}
+	return r.Client
+}
+
+// patchedBefore checks to see if the supplied object reference is the target of a patch operation from an earlier wave
+func patchedBefore(ops []optimizev1beta2.PatchOperation, ref *corev1.ObjectReference, wave int32) bool {
+	for i := range ops {
+		if ops[i].Wave < wave &&
+			ops[i].TargetRef.Kind == ref.Kind &&
+			ops[i].TargetRef.Name == ref.Name &&
+			ops[i].TargetRef.Namespace == ref.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
 // hasTrialReadinessGate checks to see if the trial has an explicit readiness gate for the supplied
 // object reference. If there is a readiness gate defined by the user, we do not need to add an
 // implicit readiness check because of the patch.
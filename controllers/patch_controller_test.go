@@ -0,0 +1,301 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestCurrentWave(t *testing.T) {
+	cases := []struct {
+		desc         string
+		ops          []optimizev1beta2.PatchOperation
+		expectedWave int32
+		expectedOK   bool
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc:         "default wave",
+			ops:          []optimizev1beta2.PatchOperation{{AttemptsRemaining: 3}, {AttemptsRemaining: 3}},
+			expectedWave: 0,
+			expectedOK:   true,
+		},
+		{
+			desc:         "lowest pending wave",
+			ops:          []optimizev1beta2.PatchOperation{{Wave: -1}, {Wave: 2, AttemptsRemaining: 3}, {Wave: 1, AttemptsRemaining: 3}},
+			expectedWave: 1,
+			expectedOK:   true,
+		},
+		{
+			desc: "all applied",
+			ops:  []optimizev1beta2.PatchOperation{{Wave: 1}, {Wave: 2}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			wave, ok := currentWave(c.ops)
+			assert.Equal(t, c.expectedWave, wave)
+			assert.Equal(t, c.expectedOK, ok)
+		})
+	}
+}
+
+func TestPatchReconciler_WaitForWave(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	configRef := corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "config"}
+	appRef := corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "app"}
+	tr := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Status: optimizev1beta2.TrialStatus{
+			PatchOperations: []optimizev1beta2.PatchOperation{
+				{TargetRef: configRef},
+				{TargetRef: appRef, Wave: 1, AttemptsRemaining: 3},
+			},
+			ReadinessChecks: []optimizev1beta2.ReadinessCheck{
+				{
+					TargetRef:         configRef,
+					Expectations:      []optimizev1beta2.ReadinessExpectation{{JSONPath: "{.data.state}", Value: "loaded"}},
+					PeriodSeconds:     5,
+					AttemptsRemaining: 3,
+				},
+				{
+					TargetRef:         appRef,
+					ConditionTypes:    []string{"stormforge.io/app-ready"},
+					PeriodSeconds:     5,
+					AttemptsRemaining: 36,
+				},
+			},
+		},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "config"},
+		Data:       map[string]string{"state": "loading"},
+	}
+	r := &PatchReconciler{Client: fake.NewFakeClientWithScheme(scheme, tr, cm)}
+	probeTime := metav1.Now()
+
+	// The earlier wave is not ready
+	result, err := r.waitForWave(context.TODO(), tr, 1, &probeTime)
+	if assert.NoError(t, err) && assert.NotNil(t, result) {
+		assert.Equal(t, int32(2), tr.Status.ReadinessChecks[0].AttemptsRemaining)
+		assert.True(t, trial.CheckCondition(&tr.Status, optimizev1beta2.TrialPatched, corev1.ConditionFalse))
+	}
+	assert.Equal(t, int32(36), tr.Status.ReadinessChecks[1].AttemptsRemaining)
+
+	// The earlier wave becomes ready once the period elapses
+	cm.Data["state"] = "loaded"
+	assert.NoError(t, r.Update(context.TODO(), cm))
+	nextProbeTime := metav1.NewTime(probeTime.Add(5 * time.Second))
+	_, err = r.waitForWave(context.TODO(), tr, 1, &nextProbeTime)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int32(0), tr.Status.ReadinessChecks[0].AttemptsRemaining)
+	}
+
+	// The current wave can be applied
+	result, err = r.waitForWave(context.TODO(), tr, 1, &nextProbeTime)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestPatchReconciler_WaitForWaveInitialDelay(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	probeTime := metav1.Now()
+	configRef := corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "config"}
+	tr := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Status: optimizev1beta2.TrialStatus{
+			PatchOperations: []optimizev1beta2.PatchOperation{
+				{TargetRef: configRef},
+				{TargetRef: configRef, Wave: 1, AttemptsRemaining: 3},
+			},
+			ReadinessChecks: []optimizev1beta2.ReadinessCheck{
+				{
+					TargetRef:           configRef,
+					Expectations:        []optimizev1beta2.ReadinessExpectation{{JSONPath: "{.data.state}", Value: "loaded"}},
+					InitialDelaySeconds: 30,
+					PeriodSeconds:       60,
+					AttemptsRemaining:   3,
+				},
+			},
+		},
+	}
+	trial.ApplyCondition(&tr.Status, optimizev1beta2.TrialPatched, corev1.ConditionFalse, "", "", &metav1.Time{Time: probeTime.Add(-time.Hour)})
+	recordWaveTime(tr, &metav1.Time{Time: probeTime.Add(-10 * time.Second)})
+	r := &PatchReconciler{Client: fake.NewFakeClientWithScheme(scheme, tr)}
+
+	// The initial delay is measured from the time the previous wave was applied, not when the patches were evaluated
+	result, err := r.waitForWave(context.TODO(), tr, 1, &probeTime)
+	if assert.NoError(t, err) && assert.NotNil(t, result) {
+		assert.InDelta(t, 20*time.Second, result.RequeueAfter, float64(time.Second))
+		assert.Equal(t, int32(3), tr.Status.ReadinessChecks[0].AttemptsRemaining)
+	}
+}
+
+func TestPatchReconciler_ApplyPatchesRestore(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	configRef := corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "config"}
+	tr := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Status: optimizev1beta2.TrialStatus{
+			PatchOperations: []optimizev1beta2.PatchOperation{
+				{TargetRef: configRef, PatchType: types.JSONPatchType, Data: []byte(`[{"op":"replace","path":"/data/state","value":"patched"}]`), AttemptsRemaining: 3, Restore: true},
+			},
+		},
+	}
+	trial.ApplyCondition(&tr.Status, optimizev1beta2.TrialPatched, corev1.ConditionFalse, "", "", nil)
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "config"},
+		Data:       map[string]string{"state": "original"},
+	}
+	r := &PatchReconciler{Client: fake.NewFakeClientWithScheme(scheme, tr, cm)}
+	probeTime := metav1.Now()
+
+	// The restore data is persisted before the target is patched
+	_, err := r.applyPatches(context.TODO(), tr, &probeTime)
+	if assert.NoError(t, err) {
+		assert.NotNil(t, tr.Status.PatchOperations[0].RestoreData)
+		assert.Equal(t, 3, tr.Status.PatchOperations[0].AttemptsRemaining)
+	}
+	assert.NoError(t, r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "config"}, cm))
+	assert.Equal(t, "original", cm.Data["state"])
+
+	// The target is patched on the next attempt
+	_, err = r.applyPatches(context.TODO(), tr, &probeTime)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, tr.Status.PatchOperations[0].AttemptsRemaining)
+	}
+	assert.NoError(t, r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "config"}, cm))
+	assert.Equal(t, "patched", cm.Data["state"])
+}
+
+func TestPatchReconciler_ResizeInPlace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}}},
+	}
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{"app": "test"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		}
+	}
+	po := &optimizev1beta2.PatchOperation{
+		TargetRef:     corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "app"},
+		PatchType:     types.StrategicMergePatchType,
+		Data:          []byte(`{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":"500m"}}}]}}}}`),
+		InPlaceResize: true,
+		RestoreData:   []byte(`{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":null}}}]}}}}`),
+	}
+	failResize := func(name string) k8stesting.ReactionFunc {
+		return func(action k8stesting.Action) (bool, runtime.Object, error) {
+			pa := action.(k8stesting.PatchAction)
+			if pa.GetSubresource() == "resize" && pa.GetName() == name {
+				return true, nil, apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, name, nil)
+			}
+			return false, nil, nil
+		}
+	}
+
+	testCases := []struct {
+		desc            string
+		failPod         string
+		expectedResized bool
+	}{
+		{
+			desc:            "all pods",
+			expectedResized: true,
+		},
+		{
+			desc:    "first pod rejected",
+			failPod: "app-1",
+		},
+		{
+			desc:    "partial failure",
+			failPod: "app-2",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cs := k8sfake.NewSimpleClientset(newPod("app-1"), newPod("app-2"))
+			if tc.failPod != "" {
+				cs.PrependReactor("patch", "pods", failResize(tc.failPod))
+			}
+			r := &PatchReconciler{
+				Client: fake.NewFakeClientWithScheme(scheme, deployment, newPod("app-1"), newPod("app-2")),
+				Log:    log.NullLogger{},
+				pods:   cs.CoreV1(),
+			}
+
+			resized, err := r.resizeInPlace(context.TODO(), po)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedResized, resized)
+			}
+			if tc.expectedResized {
+				pod, err := cs.CoreV1().Pods("default").Get("app-2", metav1.GetOptions{})
+				if assert.NoError(t, err) {
+					assert.Equal(t, "500m", pod.Spec.Containers[0].Resources.Limits.Cpu().String())
+				}
+			}
+		})
+	}
+
+	// Restoring the resized pods reports failures instead of leaving them resized
+	cs := k8sfake.NewSimpleClientset(newPod("app-1"), newPod("app-2"))
+	cs.PrependReactor("patch", "pods", failResize("app-2"))
+	r := &PatchReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, deployment, newPod("app-1"), newPod("app-2")),
+		Log:    log.NullLogger{},
+		pods:   cs.CoreV1(),
+	}
+	restore := po.DeepCopy()
+	restore.InPlaceResized = true
+	assert.Error(t, r.restorePatchOperation(context.TODO(), restore))
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"github.com/thestormforge/optimize-controller/v2/internal/meta"
+	"github.com/thestormforge/optimize-controller/v2/internal/patch"
+	"github.com/thestormforge/optimize-controller/v2/internal/ready"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// promotionCheckInterval is the amount of time between readiness checks of promoted objects during the rollback window
+const promotionCheckInterval = 30 * time.Second
+
+// PromotionReconciler applies the best trial of a completed experiment to the cluster
+type PromotionReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=list
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *PromotionReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("experiment", req.NamespacedName)
+	now := metav1.Now()
+
+	exp := &optimizev1beta2.Experiment{}
+	if err := r.Get(ctx, req.NamespacedName, exp); err != nil {
+		return ctrl.Result{}, controller.IgnoreNotFound(err)
+	}
+
+	// Only completed experiments with a promotion policy are promoted
+	if _, ok := conditionMessage(exp, optimizev1beta2.ExperimentComplete); !ok || exp.Spec.Promotion == nil || !exp.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if result, err := r.promote(ctx, log, exp, &now); result != nil {
+		return *result, err
+	}
+
+	if result, err := r.checkRollback(ctx, log, exp, &now); result != nil {
+		return *result, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *PromotionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("promotion")
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("promotion").
+		For(&optimizev1beta2.Experiment{}).
+		Complete(r)
+}
+
+// promote applies the patches of the best trial. The restore patches are saved with a "promoting" condition before
+// anything is changed so a failed update can safely re-apply the patches without losing the original configuration.
+func (r *PromotionReconciler) promote(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment, probeTime *metav1.Time) (*ctrl.Result, error) {
+	// Only attempt promotion once
+	promoting := false
+	for _, c := range exp.Status.Conditions {
+		if c.Type == optimizev1beta2.ExperimentPromoted {
+			if c.Status != corev1.ConditionUnknown {
+				return nil, nil
+			}
+			promoting = true
+		}
+	}
+
+	trialList := &optimizev1beta2.TrialList{}
+	if err := r.listTrials(ctx, trialList, exp.TrialSelector()); err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	var restore []optimizev1beta2.PatchOperation
+	if data := exp.GetAnnotations()[optimizev1beta2.AnnotationPromotionRestore]; promoting && data != "" {
+		if err := json.Unmarshal([]byte(data), &restore); err != nil {
+			return &ctrl.Result{}, err
+		}
+	}
+
+	best, msg, ok := experiment.PromotionCandidate(exp, trialList)
+	if !ok {
+		// Put back anything changed by an earlier attempt
+		if err := r.restore(ctx, restore); err != nil {
+			return &ctrl.Result{}, err
+		}
+		delete(exp.Annotations, optimizev1beta2.AnnotationPromotionRestore)
+		experiment.ApplyCondition(&exp.Status, optimizev1beta2.ExperimentPromoted, corev1.ConditionFalse, "NotPromoted", msg, probeTime)
+		err := r.Update(ctx, exp)
+		return controller.RequeueConflict(err)
+	}
+
+	// Record how to restore the objects before they are patched
+	if !promoting {
+		for i := range best.Status.PatchOperations {
+			p := &best.Status.PatchOperations[i]
+			if trial.IsTrialJobReference(best, &p.TargetRef) {
+				continue
+			}
+
+			rp, err := r.restorePatch(ctx, p)
+			if err != nil {
+				r.Recorder.Eventf(exp, corev1.EventTypeWarning, "PromotionFailed", "Failed to dry run patch of %s %s: %s", p.TargetRef.Kind, p.TargetRef.Name, err.Error())
+				return &ctrl.Result{}, err
+			}
+			if rp != nil {
+				restore = append(restore, *rp)
+			}
+		}
+
+		data, err := json.Marshal(restore)
+		if err != nil {
+			return &ctrl.Result{}, err
+		}
+		if exp.Annotations == nil {
+			exp.Annotations = make(map[string]string)
+		}
+		exp.Annotations[optimizev1beta2.AnnotationPromotionRestore] = string(data)
+		experiment.ApplyCondition(&exp.Status, optimizev1beta2.ExperimentPromoted, corev1.ConditionUnknown, "Promoting", msg, probeTime)
+		err = r.Update(ctx, exp)
+		return controller.RequeueConflict(err)
+	}
+
+	for i := range best.Status.PatchOperations {
+		p := &best.Status.PatchOperations[i]
+		if trial.IsTrialJobReference(best, &p.TargetRef) {
+			continue
+		}
+
+		if err := r.applyPatch(ctx, p); err != nil {
+			// Put back anything we already changed before giving up
+			if rerr := r.restore(ctx, restore); rerr != nil {
+				log.Error(rerr, "Failed to restore objects after a failed promotion")
+				return &ctrl.Result{}, rerr
+			}
+			r.Recorder.Eventf(exp, corev1.EventTypeWarning, "PromotionFailed", "Failed to patch %s %s: %s", p.TargetRef.Kind, p.TargetRef.Name, err.Error())
+			delete(exp.Annotations, optimizev1beta2.AnnotationPromotionRestore)
+			experiment.ApplyCondition(&exp.Status, optimizev1beta2.ExperimentPromoted, corev1.ConditionFalse, "PromotionFailed", err.Error(), probeTime)
+			err := r.Update(ctx, exp)
+			return controller.RequeueConflict(err)
+		}
+	}
+
+	// Only keep the restore patches if they might be needed
+	if exp.Spec.Promotion.RollbackWindowSeconds <= 0 {
+		delete(exp.Annotations, optimizev1beta2.AnnotationPromotionRestore)
+	}
+
+	experiment.ApplyCondition(&exp.Status, optimizev1beta2.ExperimentPromoted, corev1.ConditionTrue, "Promoted", msg, probeTime)
+	if err := r.Update(ctx, exp); err != nil {
+		return controller.RequeueConflict(err)
+	}
+
+	log.Info("Promoted best trial", "trial", best.Name)
+	r.Recorder.Event(exp, corev1.EventTypeNormal, "Promoted", msg)
+	return &ctrl.Result{}, nil
+}
+
+// checkRollback restores the original configuration if the promoted objects fail or a rollback is requested during the
+// rollback window
+func (r *PromotionReconciler) checkRollback(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment, probeTime *metav1.Time) (*ctrl.Result, error) {
+	data, ok := exp.GetAnnotations()[optimizev1beta2.AnnotationPromotionRestore]
+	if !ok {
+		return nil, nil
+	}
+
+	// Once the rollback window ends the promotion is permanent
+	remaining := experiment.UntilRollbackEnds(exp, probeTime.Time)
+	if remaining <= 0 {
+		delete(exp.Annotations, optimizev1beta2.AnnotationPromotionRestore)
+		err := r.Update(ctx, exp)
+		return controller.RequeueConflict(err)
+	}
+
+	var restore []optimizev1beta2.PatchOperation
+	if err := json.Unmarshal([]byte(data), &restore); err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	reason, msg := "", ""
+	if exp.GetAnnotations()[optimizev1beta2.AnnotationRollback] == "true" {
+		reason, msg = "RollbackRequested", "Rollback requested"
+	} else {
+		checker := &ready.ReadinessChecker{Reader: r}
+		for i := range restore {
+			u := &unstructured.Unstructured{}
+			u.SetGroupVersionKind(restore[i].TargetRef.GroupVersionKind())
+			if err := r.Get(ctx, client.ObjectKey{Namespace: restore[i].TargetRef.Namespace, Name: restore[i].TargetRef.Name}, u); err != nil {
+				return &ctrl.Result{}, controller.IgnoreNotFound(err)
+			}
+
+			// Only a hard failure (e.g. a crash loop) triggers a rollback, the objects may still be rolling out
+			if _, _, err := checker.CheckConditions(ctx, u, []string{ready.ConditionTypeAppReady}); err != nil {
+				reason, msg = "PromotionFailed", fmt.Sprintf("%s %s failed after promotion: %s", u.GetKind(), u.GetName(), err.Error())
+				break
+			}
+		}
+	}
+
+	if reason == "" {
+		if remaining > promotionCheckInterval {
+			remaining = promotionCheckInterval
+		}
+		return &ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	if err := r.restore(ctx, restore); err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	delete(exp.Annotations, optimizev1beta2.AnnotationPromotionRestore)
+	delete(exp.Annotations, optimizev1beta2.AnnotationRollback)
+	experiment.ApplyCondition(&exp.Status, optimizev1beta2.ExperimentPromoted, corev1.ConditionFalse, "RolledBack", msg, probeTime)
+	if err := r.Update(ctx, exp); err != nil {
+		return controller.RequeueConflict(err)
+	}
+
+	log.Info("Rolled back promotion", "reason", reason)
+	r.Recorder.Event(exp, corev1.EventTypeWarning, "RolledBack", msg)
+	return &ctrl.Result{}, nil
+}
+
+// restorePatch returns a patch operation which restores the live object after the trial patch operation is applied,
+// the trial patch is only applied as a dry run
+func (r *PromotionReconciler) restorePatch(ctx context.Context, p *optimizev1beta2.PatchOperation) (*optimizev1beta2.PatchOperation, error) {
+	original, patched, err := r.dryRun(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := patch.RestorePatch(original.Object, patched.Object)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	return &optimizev1beta2.PatchOperation{
+		TargetRef: p.TargetRef,
+		PatchType: types.MergePatchType,
+		Data:      data,
+	}, nil
+}
+
+// applyPatch applies a trial patch operation to the live object, objects which already match the result of the
+// patch are not changed so the patches can be safely re-applied
+func (r *PromotionReconciler) applyPatch(ctx context.Context, p *optimizev1beta2.PatchOperation) error {
+	original, patched, err := r.dryRun(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	if data, err := patch.RestorePatch(original.Object, patched.Object); err != nil || data == nil {
+		return err
+	}
+
+	return r.Patch(ctx, original, client.RawPatch(p.PatchType, p.Data))
+}
+
+// dryRun returns the live object and the result of applying the trial patch operation to it without persisting it
+func (r *PromotionReconciler) dryRun(ctx context.Context, p *optimizev1beta2.PatchOperation) (*unstructured.Unstructured, *unstructured.Unstructured, error) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(p.TargetRef.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKey{Namespace: p.TargetRef.Namespace, Name: p.TargetRef.Name}, u); err != nil {
+		return nil, nil, err
+	}
+
+	patched := u.DeepCopy()
+	if err := r.Patch(ctx, patched, client.RawPatch(p.PatchType, p.Data), client.DryRunAll); err != nil {
+		return nil, nil, err
+	}
+	return u, patched, nil
+}
+
+// restore reverts promoted objects in the reverse order they were patched
+func (r *PromotionReconciler) restore(ctx context.Context, restore []optimizev1beta2.PatchOperation) error {
+	for i := len(restore) - 1; i >= 0; i-- {
+		p := &restore[i]
+		u := &unstructured.Unstructured{}
+		u.SetName(p.TargetRef.Name)
+		u.SetNamespace(p.TargetRef.Namespace)
+		u.SetGroupVersionKind(p.TargetRef.GroupVersionKind())
+		if err := controller.IgnoreNotFound(r.Patch(ctx, u, client.RawPatch(p.PatchType, p.Data))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PromotionReconciler) listTrials(ctx context.Context, trialList *optimizev1beta2.TrialList, selector *metav1.LabelSelector) error {
+	matchingSelector, err := meta.MatchingSelector(selector)
+	if err != nil {
+		return err
+	}
+	return r.List(ctx, trialList, matchingSelector)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/metric"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxPushBodySize is the largest request body accepted by the push server
+const maxPushBodySize = 64 * 1024
+
+// PushServer accepts metric values pushed directly by trial run jobs. Trial run jobs are given the URL and token to
+// use through the `STORMFORGE_PUSH_URL` and `STORMFORGE_PUSH_TOKEN` environment variables (the token is a random value
+// stored in a per-trial secret), the values are recorded on the trial and collected as "push" metrics. For example, a trial run container can push a value using:
+//
+//	curl -H "Authorization: Bearer $STORMFORGE_PUSH_TOKEN" -d '{"latency": 0.25}' "$STORMFORGE_PUSH_URL"
+type PushServer struct {
+	Log  logr.Logger
+	Addr string
+
+	client client.Client
+	// Push tokens are read directly to avoid caching every secret in the cluster
+	reader client.Reader
+}
+
+// pushError is an error with an HTTP status code.
+type pushError struct {
+	code    int
+	message string
+}
+
+func (e *pushError) Error() string {
+	return e.message
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+func (s *PushServer) SetupWithManager(mgr ctrl.Manager) error {
+	s.client = mgr.GetClient()
+	s.reader = mgr.GetAPIReader()
+	return mgr.Add(s)
+}
+
+// NeedLeaderElection allows pushes to be accepted by any instance of the controller.
+func (s *PushServer) NeedLeaderElection() bool {
+	return false
+}
+
+// Start runs the push server until the stop channel is closed.
+func (s *PushServer) Start(stop <-chan struct{}) error {
+	srv := &http.Server{
+		Addr:         s.Addr,
+		Handler:      s,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.Log.Info("Starting push server", "addr", s.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-stop:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	}
+}
+
+// ServeHTTP handles `POST /trials/{namespace}/{name}` requests containing a JSON object of metric values.
+func (s *PushServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "trials" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	values := make(map[string]float64)
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPushBodySize)).Decode(&values); err != nil {
+		http.Error(w, fmt.Sprintf("invalid metric values: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	key := client.ObjectKey{Namespace: parts[1], Name: parts[2]}
+	if err := s.push(r.Context(), key, token, values); err != nil {
+		code := http.StatusInternalServerError
+		if perr, ok := err.(*pushError); ok {
+			code = perr.code
+		} else {
+			s.Log.Error(err, "Failed to record pushed values", "trial", key)
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	s.Log.Info("Recorded pushed values", "trial", key, "count", len(values))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// push records the supplied values on the trial.
+func (s *PushServer) push(ctx context.Context, key client.ObjectKey, token string, values map[string]float64) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		t := &optimizev1beta2.Trial{}
+		if err := s.client.Get(ctx, key, t); err != nil {
+			if apierrors.IsNotFound(err) {
+				return &pushError{code: http.StatusNotFound, message: fmt.Sprintf("trial %s not found", key)}
+			}
+			return err
+		}
+
+		// The push token secret is only exposed to the trial run job
+		secret := &corev1.Secret{}
+		if err := s.reader.Get(ctx, client.ObjectKey{Namespace: t.Namespace, Name: trial.PushTokenSecretName(t)}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				return &pushError{code: http.StatusUnauthorized, message: "invalid push token"}
+			}
+			return err
+		}
+		expected := secret.Data[trial.PushTokenKey]
+		if token == "" || len(expected) == 0 || subtle.ConstantTimeCompare([]byte(token), expected) != 1 {
+			return &pushError{code: http.StatusUnauthorized, message: "invalid push token"}
+		}
+
+		if trial.IsFinished(t) || trial.CheckCondition(&t.Status, optimizev1beta2.TrialObserved, corev1.ConditionTrue) {
+			return &pushError{code: http.StatusConflict, message: fmt.Sprintf("trial %s has already been observed", key)}
+		}
+
+		// Only accept values for push metrics
+		exp := &optimizev1beta2.Experiment{}
+		if err := s.client.Get(ctx, t.ExperimentNamespacedName(), exp); err != nil {
+			return err
+		}
+		names := make(map[string]bool)
+		for _, m := range exp.Spec.Metrics {
+			if m.Type == optimizev1beta2.MetricPush {
+				names[m.Query] = true
+				if m.ErrorQuery != "" {
+					names[m.ErrorQuery] = true
+				}
+			}
+		}
+
+		pushed, err := metric.PushedValues(t)
+		if err != nil {
+			return err
+		}
+		for name, value := range values {
+			if !names[name] {
+				return &pushError{code: http.StatusBadRequest, message: fmt.Sprintf("unknown push metric %q", name)}
+			}
+			pushed[name] = value
+		}
+
+		data, err := json.Marshal(pushed)
+		if err != nil {
+			return err
+		}
+		if t.Annotations == nil {
+			t.Annotations = make(map[string]string)
+		}
+		t.Annotations[optimizev1beta2.AnnotationPushedValues] = string(data)
+		return s.client.Update(ctx, t)
+	})
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/zapr"
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/metric"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPushServer(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		method   string
+		path     string
+		token    string
+		body     string
+		observed bool
+		code     int
+		expected map[string]float64
+	}{
+		{
+			desc:     "push",
+			path:     "/trials/default/test-001",
+			token:    "Bearer secret-token",
+			body:     `{"latency": 1.5}`,
+			code:     http.StatusNoContent,
+			expected: map[string]float64{"latency": 1.5, "existing": 1},
+		},
+		{
+			desc:   "wrong method",
+			method: http.MethodGet,
+			path:   "/trials/default/test-001",
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			desc: "wrong path",
+			path: "/trials/default",
+			code: http.StatusNotFound,
+		},
+		{
+			desc:  "missing trial",
+			path:  "/trials/default/test-002",
+			token: "Bearer secret-token",
+			body:  `{"latency": 1.5}`,
+			code:  http.StatusNotFound,
+		},
+		{
+			desc:  "invalid token",
+			path:  "/trials/default/test-001",
+			token: "Bearer bad",
+			body:  `{"latency": 1.5}`,
+			code:  http.StatusUnauthorized,
+		},
+		{
+			desc:  "trial uid",
+			path:  "/trials/default/test-001",
+			token: "Bearer uid",
+			body:  `{"latency": 1.5}`,
+			code:  http.StatusUnauthorized,
+		},
+		{
+			desc:  "invalid body",
+			path:  "/trials/default/test-001",
+			token: "Bearer secret-token",
+			body:  `{"latency": "fast"}`,
+			code:  http.StatusBadRequest,
+		},
+		{
+			desc:  "unknown metric",
+			path:  "/trials/default/test-001",
+			token: "Bearer secret-token",
+			body:  `{"throughput": 1}`,
+			code:  http.StatusBadRequest,
+		},
+		{
+			desc:     "already observed",
+			path:     "/trials/default/test-001",
+			token:    "Bearer secret-token",
+			body:     `{"latency": 1.5}`,
+			observed: true,
+			code:     http.StatusConflict,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+				Spec: optimizev1beta2.ExperimentSpec{
+					Metrics: []optimizev1beta2.Metric{
+						{Name: "latency", Type: optimizev1beta2.MetricPush, Query: "latency", ErrorQuery: "existing"},
+						{Name: "throughput", Query: "throughput"},
+					},
+				},
+			}
+			trial := &optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "default",
+					Name:        "test-001",
+					UID:         "uid",
+					Labels:      map[string]string{optimizev1beta2.LabelExperiment: "test"},
+					Annotations: map[string]string{optimizev1beta2.AnnotationPushedValues: `{"existing": 1}`},
+				},
+			}
+			if tc.observed {
+				trial.Status.Conditions = []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialObserved, Status: corev1.ConditionTrue}}
+			}
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-001-push-token"},
+				Data:       map[string][]byte{"token": []byte("secret-token")},
+			}
+
+			c := fake.NewFakeClientWithScheme(scheme, exp, trial, secret)
+			s := &PushServer{Log: zapr.NewLogger(zap.NewNop()), client: c, reader: c}
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", tc.token)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+
+			if tc.expected != nil {
+				actual := &optimizev1beta2.Trial{}
+				if assert.NoError(t, c.Get(req.Context(), client.ObjectKey{Namespace: "default", Name: "test-001"}, actual)) {
+					values, err := metric.PushedValues(actual)
+					assert.NoError(t, err)
+					assert.Equal(t, tc.expected, values)
+				}
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"time"
 
@@ -128,6 +129,8 @@ func (r *ReadyReconciler) evaluateReadinessChecks(ctx context.Context, t *optimi
 			},
 			Selector:            c.Selector,
 			ConditionTypes:      c.ConditionTypes,
+			Expectations:        c.Expectations,
+			SettleSeconds:       c.SettleSeconds,
 			InitialDelaySeconds: c.InitialDelaySeconds,
 			PeriodSeconds:       c.PeriodSeconds,
 			AttemptsRemaining:   c.FailureThreshold,
@@ -170,7 +173,7 @@ func (r *ReadyReconciler) checkReadiness(ctx context.Context, t *optimizev1beta2
 		}
 
 		// Get the objects to check
-		ul, err := r.getCheckTargets(ctx, c)
+		ul, err := getCheckTargets(ctx, r.apiReader, c)
 		if err != nil {
 			readinessCheckFailed(t, probeTime, err)
 			err := r.Update(ctx, t)
@@ -202,7 +205,7 @@ func (r *ReadyReconciler) checkReadiness(ctx context.Context, t *optimizev1beta2
 }
 
 // getCheckTargets returns the list of target objects for the readiness check
-func (r *ReadyReconciler) getCheckTargets(ctx context.Context, rc *optimizev1beta2.ReadinessCheck) (*unstructured.UnstructuredList, error) {
+func getCheckTargets(ctx context.Context, reader client.Reader, rc *optimizev1beta2.ReadinessCheck) (*unstructured.UnstructuredList, error) {
 	ul := &unstructured.UnstructuredList{}
 
 	// If there is no kind on the target reference, we can't actually fetch anything
@@ -217,7 +220,7 @@ func (r *ReadyReconciler) getCheckTargets(ctx context.Context, rc *optimizev1bet
 		if err != nil {
 			return nil, err
 		}
-		err = r.apiReader.List(ctx, ul, client.InNamespace(rc.TargetRef.Namespace), client.MatchingLabelsSelector{Selector: s})
+		err = reader.List(ctx, ul, client.InNamespace(rc.TargetRef.Namespace), client.MatchingLabelsSelector{Selector: s})
 		return ul, err
 	}
 
@@ -225,7 +228,7 @@ func (r *ReadyReconciler) getCheckTargets(ctx context.Context, rc *optimizev1bet
 	u := unstructured.Unstructured{}
 	u.SetGroupVersionKind(rc.TargetRef.GroupVersionKind())
 	key := types.NamespacedName{Namespace: rc.TargetRef.Namespace, Name: rc.TargetRef.Name}
-	if err := r.apiReader.Get(ctx, key, &u); err != nil {
+	if err := reader.Get(ctx, key, &u); err != nil {
 		// "Mimic" list behavior by returning an empty list if the object is not found
 		if controller.IgnoreNotFound(err) != nil {
 			return nil, err
@@ -318,6 +321,9 @@ func (rc *readinessChecker) check(ctx context.Context, c *optimizev1beta2.Readin
 	var err error
 	for i := range ul.Items {
 		msg, ok, err = rc.checker.CheckConditions(ctx, &ul.Items[i], c.ConditionTypes)
+		for j := 0; ok && err == nil && j < len(c.Expectations); j++ {
+			msg, ok, err = rc.checker.CheckJSONPath(&ul.Items[i], c.Expectations[j].JSONPath, c.Expectations[j].Value)
+		}
 		if !ok || err != nil {
 			break
 		}
@@ -329,6 +335,19 @@ func (rc *readinessChecker) check(ctx context.Context, c *optimizev1beta2.Readin
 		ok = true
 	}
 
+	// Wait for the target to remain ready for the settle time, starting over if it stops being ready
+	if ok && err == nil && c.SettleSeconds > 0 {
+		if c.ReadySince == nil {
+			c.ReadySince = now
+		}
+		if settled := c.ReadySince.Add(time.Duration(c.SettleSeconds) * time.Second); now.Before(&metav1.Time{Time: settled}) {
+			c.LastCheckTime = now
+			return fmt.Sprintf("Waiting %s for readiness to settle", settled.Sub(now.Time).Round(time.Second)), false, nil
+		}
+	} else if !ok {
+		c.ReadySince = nil
+	}
+
 	// Check is done, it is either ok or had a hard failure
 	if ok || err != nil {
 		c.AttemptsRemaining = 0
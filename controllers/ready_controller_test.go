@@ -0,0 +1,152 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/ready"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReadinessChecker_Settle(t *testing.T) {
+	target := func(phase string) *unstructured.UnstructuredList {
+		return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "DatabaseCluster",
+			"status":     map[string]interface{}{"phase": phase},
+		}}}}
+	}
+
+	start := time.Now()
+	at := func(d time.Duration) *metav1.Time { return &metav1.Time{Time: start.Add(d)} }
+
+	c := &optimizev1beta2.ReadinessCheck{
+		Expectations:      []optimizev1beta2.ReadinessExpectation{{JSONPath: "{.status.phase}", Value: "Ready"}},
+		SettleSeconds:     30,
+		PeriodSeconds:     10,
+		AttemptsRemaining: 3,
+	}
+	c.TargetRef.Kind = "DatabaseCluster"
+	rc := newReadinessChecker(nil, &optimizev1beta2.Trial{})
+
+	// Not ready yet
+	_, ok, err := rc.check(context.TODO(), c, target("Creating"), at(0))
+	if assert.NoError(t, err) {
+		assert.False(t, ok)
+		assert.Nil(t, c.ReadySince)
+		assert.Equal(t, int32(2), c.AttemptsRemaining)
+	}
+
+	// Ready, but has not settled
+	msg, ok, err := rc.check(context.TODO(), c, target("Ready"), at(10*time.Second))
+	if assert.NoError(t, err) {
+		assert.False(t, ok)
+		assert.Equal(t, "Waiting 30s for readiness to settle", msg)
+		assert.Equal(t, at(10*time.Second), c.ReadySince)
+		assert.Equal(t, int32(2), c.AttemptsRemaining)
+	}
+
+	// Still settling
+	_, ok, err = rc.check(context.TODO(), c, target("Ready"), at(30*time.Second))
+	if assert.NoError(t, err) {
+		assert.False(t, ok)
+	}
+
+	// Settled
+	_, ok, err = rc.check(context.TODO(), c, target("Ready"), at(40*time.Second))
+	if assert.NoError(t, err) {
+		assert.True(t, ok)
+		assert.Equal(t, int32(0), c.AttemptsRemaining)
+	}
+}
+
+func TestReadinessCheckFailed_FailureClass(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "app"}}},
+	}
+	pod := func(status corev1.PodStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app-abcde", Labels: map[string]string{"app": "app"}},
+			Spec:       corev1.PodSpec{RestartPolicy: corev1.RestartPolicyNever},
+			Status:     status,
+		}
+	}
+
+	cases := []struct {
+		desc          string
+		pod           *corev1.Pod
+		expectedClass string
+	}{
+		{
+			desc: "unschedulable",
+			pod: pod(corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable},
+			}}),
+			expectedClass: trial.FailureAssignment,
+		},
+		{
+			desc: "oom killed",
+			pod: pod(corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"}},
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}}}),
+			expectedClass: trial.FailureAssignment,
+		},
+		{
+			desc: "container error",
+			pod: pod(corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+			}}}),
+			expectedClass: trial.FailureAssignment,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			if !assert.NoError(t, scheme.Convert(deployment, u, nil)) {
+				return
+			}
+			rc := newReadinessChecker(fake.NewFakeClientWithScheme(scheme, c.pod), &optimizev1beta2.Trial{})
+			check := &optimizev1beta2.ReadinessCheck{ConditionTypes: []string{ready.ConditionTypePodReady}}
+			now := metav1.Now()
+
+			_, _, err := rc.check(context.TODO(), check, &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*u}}, &now)
+			if assert.Error(t, err) {
+				tr := &optimizev1beta2.Trial{}
+				readinessCheckFailed(tr, &now, err)
+				assert.Equal(t, c.expectedClass, trial.FailureClass(tr))
+			}
+		})
+	}
+}
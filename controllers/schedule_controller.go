@@ -0,0 +1,133 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ScheduleReconciler re-runs experiments on a schedule
+type ScheduleReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *ScheduleReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("experiment", req.NamespacedName)
+
+	exp := &optimizev1beta2.Experiment{}
+	if err := r.Get(ctx, req.NamespacedName, exp); err != nil || exp.Spec.Schedule == "" || !exp.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, controller.IgnoreNotFound(err)
+	}
+
+	if result, err := r.initializeSchedule(ctx, exp); result != nil {
+		return *result, err
+	}
+
+	now := time.Now().UTC()
+	due, next, err := experiment.ScheduledRunTime(exp, now)
+	if err != nil {
+		r.Recorder.Event(exp, corev1.EventTypeWarning, "InvalidSchedule", err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	if due.IsZero() {
+		return ctrl.Result{RequeueAfter: untilNextRun(next, now)}, nil
+	}
+
+	if result, err := r.runExperiment(ctx, log, exp, due); result != nil {
+		return *result, err
+	}
+
+	return ctrl.Result{RequeueAfter: untilNextRun(next, now)}, nil
+}
+
+func (r *ScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("schedule")
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("schedule").
+		For(&optimizev1beta2.Experiment{}).
+		Complete(r)
+}
+
+// initializeSchedule records the state needed to create new runs before the experiment finishes
+func (r *ScheduleReconciler) initializeSchedule(ctx context.Context, exp *optimizev1beta2.Experiment) (*ctrl.Result, error) {
+	if experiment.InitializeSchedule(exp) {
+		if err := r.Update(ctx, exp); err != nil {
+			return controller.RequeueConflict(err)
+		}
+		return &ctrl.Result{}, nil
+	}
+	return nil, nil
+}
+
+// runExperiment creates the next run of a scheduled experiment, the run is skipped if the previous run is still active
+func (r *ScheduleReconciler) runExperiment(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment, scheduleTime time.Time) (*ctrl.Result, error) {
+	runList := &optimizev1beta2.ExperimentList{}
+	if err := r.List(ctx, runList, client.InNamespace(exp.Namespace), client.MatchingLabels{optimizev1beta2.LabelScheduledExperiment: exp.Name}); err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	previous := experiment.LatestRun(exp, runList.Items)
+	if experiment.IsFinished(previous) {
+		run := &optimizev1beta2.Experiment{}
+		experiment.PopulateRun(exp, previous, run, scheduleTime)
+
+		// The run name is deterministic so a run that already exists was created by an earlier attempt
+		if err := r.Create(ctx, run); err != nil && !apierrs.IsAlreadyExists(err) {
+			return &ctrl.Result{}, err
+		}
+
+		log.Info("Scheduled experiment run", "run", run.Name)
+		r.Recorder.Eventf(exp, corev1.EventTypeNormal, "RunScheduled", "Created run %s", run.Name)
+	} else {
+		r.Recorder.Eventf(exp, corev1.EventTypeNormal, "RunSkipped", "Skipped scheduled run, %s is still running", previous.Name)
+	}
+
+	exp.Annotations[optimizev1beta2.AnnotationLastScheduleTime] = scheduleTime.Format(time.RFC3339)
+	if err := r.Update(ctx, exp); err != nil {
+		return controller.RequeueConflict(err)
+	}
+	return nil, nil
+}
+
+// untilNextRun returns the amount of time to wait for the next scheduled run, zero if there are no more runs.
+func untilNextRun(next, now time.Time) time.Duration {
+	if next.IsZero() {
+		return 0
+	}
+	return next.Sub(now)
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/thestormforge/optimize-controller/v2/internal/controller"
 	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
 	"github.com/thestormforge/optimize-controller/v2/internal/meta"
+	"github.com/thestormforge/optimize-controller/v2/internal/notify"
 	"github.com/thestormforge/optimize-controller/v2/internal/server"
 	"github.com/thestormforge/optimize-controller/v2/internal/trial"
 	"github.com/thestormforge/optimize-controller/v2/internal/validation"
@@ -40,6 +41,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -48,6 +50,9 @@ import (
 var (
 	defaultServerTrialTTLSecondsAfterFinished = int32((4 * time.Hour) / time.Second)
 	defaultServerTrialTTLSecondsAfterFailure  = int32((48 * time.Hour) / time.Second)
+
+	// concurrencyRequeueInterval is how often a waiting experiment checks for an open slot
+	concurrencyRequeueInterval = 30 * time.Second
 )
 
 // trialCreationRateLimit returns the configured rate for allowing trial creations, the
@@ -76,13 +81,20 @@ type ServerReconciler struct {
 	Scheme          *runtime.Scheme
 	ExperimentsAPI  experiments.API
 	ApplicationsAPI applications.API
+	Recorder        record.EventRecorder
 
 	trialCreation *rate.Limiter
+	trialQuota    *server.TrialQuota
+	concurrency   *server.ConcurrencyLimits
+	notifications *notifications
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=experiments,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=list;watch;create;update
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=optimizationpolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=list
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=configmaps;secrets,verbs=get
 
 func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -114,6 +126,7 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	// Look for active, finished or abandoned trials
 	var activeTrials int32
 	var trialHasFinalizer bool
+	var retryAfter time.Duration
 	for i := range trialList.Items {
 		t := &trialList.Items[i]
 		tlog := log.WithValues("trial", t.Namespace+"/"+t.Name)
@@ -125,6 +138,24 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 		// Trials that have the server finalizer may need to be reported
 		if meta.HasFinalizer(t, server.Finalizer) {
+			// Failed trials are retried instead of being reported if the retry policy allows it
+			if d, ok := trial.NextRetry(t, exp.Spec.RetryPolicy, time.Now()); ok && exp.DeletionTimestamp.IsZero() {
+				if d <= 0 {
+					if result, err := r.retryTrial(ctx, tlog, exp, trialList, t); result != nil {
+						return *result, err
+					}
+				} else if retryAfter <= 0 || d < retryAfter {
+					retryAfter = d
+				}
+
+				// The pending retry occupies the place of the failed trial
+				if !trial.IsActive(t) {
+					activeTrials++
+				}
+				trialHasFinalizer = true
+				continue
+			}
+
 			// TODO Combine report and abandon into one function
 			if trial.IsFinished(t) {
 				if result, err := r.reportTrial(ctx, tlog, t); result != nil {
@@ -142,6 +173,14 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 	// Create a new trial if necessary
 	if exp.GetAnnotations()[optimizev1beta2.AnnotationNextTrialURL] != "" && activeTrials < exp.Replicas() {
+		if result, err := r.checkConcurrency(ctx, log, exp); result != nil {
+			return *result, err
+		}
+
+		if result, err := r.checkReplicas(ctx, log, exp); result != nil {
+			return *result, err
+		}
+
 		if result, err := r.nextTrial(ctx, log, exp, trialList); result != nil {
 			return *result, err
 		}
@@ -154,6 +193,11 @@ func (r *ServerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		}
 	}
 
+	// Check back when the next failed trial can be retried
+	if retryAfter > 0 {
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+
 	// Nothing to do
 	return ctrl.Result{}, nil
 }
@@ -191,6 +235,34 @@ func (r *ServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// Enforce trial creation rate limit (no burst! that is the whole point)
 	r.trialCreation = rate.NewLimiter(trialCreationRateLimit(r.Log), 1)
 
+	// Track trial consumption against the subscription quota
+	if trialQuota, err := server.NewTrialQuota(); err != nil {
+		r.Log.Info("Ignoring invalid trial quota", "message", err.Error())
+	} else if trialQuota != nil {
+		r.Log.Info("Using trial quota", "trialQuota", trialQuota.Limit)
+		controller.TrialQuotaLimit.Set(float64(trialQuota.Limit))
+		r.trialQuota = trialQuota
+	}
+
+	// Queue experiments beyond the configured concurrency limits
+	if concurrency, err := server.NewConcurrencyLimits(); err != nil {
+		r.Log.Info("Ignoring invalid concurrency limits", "message", err.Error())
+	} else if concurrency != nil {
+		r.Log.Info("Using concurrency limits",
+			"maxActiveExperiments", concurrency.MaxExperiments,
+			"maxActiveExperimentsPerNamespace", concurrency.MaxExperimentsPerNamespace,
+			"maxActiveExperimentsBySelector", len(concurrency.MaxExperimentsBySelector),
+			"maxActiveTrials", concurrency.MaxTrials,
+			"maxActiveTrialsPerNamespace", concurrency.MaxTrialsPerNamespace)
+		r.concurrency = concurrency
+	}
+
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("server")
+	}
+
+	r.notifications = newNotifications(r.Log, mgr.GetAPIReader())
+
 	// To search for namespaces by name, we need to index them
 	_ = mgr.GetCache().IndexField(&corev1.Namespace{}, "metadata.name", func(obj runtime.Object) []string { return []string{obj.(*corev1.Namespace).Name} })
 
@@ -231,6 +303,20 @@ func (r *ServerReconciler) createExperiment(ctx context.Context, log logr.Logger
 		return nil, nil
 	}
 
+	// Make sure the policies are applied before the parameters are sent to the server, the experiment reconciler
+	// will not narrow the parameters once the server finalizer is present
+	policyList := &optimizev1beta2.OptimizationPolicyList{}
+	if err := r.List(ctx, policyList); err != nil {
+		return &ctrl.Result{}, err
+	}
+	if _, err := experiment.ApplyPolicies(exp, policyList.Items); err != nil {
+		if experiment.FailExperiment(exp, "PolicyViolation", err) {
+			err := r.Update(ctx, exp)
+			return controller.RequeueConflict(err)
+		}
+		return &ctrl.Result{}, err
+	}
+
 	// Convert the cluster state into a server representation
 	n, e, b, err := server.FromCluster(exp)
 	if err != nil {
@@ -394,8 +480,11 @@ func (r *ServerReconciler) nextTrial(ctx context.Context, log logr.Logger, exp *
 	suggestion, err := r.ExperimentsAPI.NextTrial(ctx, exp.GetAnnotations()[optimizev1beta2.AnnotationNextTrialURL])
 	if err != nil {
 		if experiment.StopExperiment(exp, err) {
-			err := r.Update(ctx, exp)
-			return controller.RequeueConflict(err)
+			if err := r.Update(ctx, exp); err != nil {
+				return controller.RequeueConflict(err)
+			}
+			r.notifications.send(ctx, log, exp, notify.ExperimentCompleted, err.Error())
+			return &ctrl.Result{}, nil
 		}
 		return controller.RequeueIfUnavailable(err)
 	}
@@ -406,6 +495,12 @@ func (r *ServerReconciler) nextTrial(ctx context.Context, log logr.Logger, exp *
 	t.Namespace = namespace
 	server.ToClusterTrial(t, &suggestion)
 
+	// Evaluate the label templates, a bad template should not prevent the trial from running
+	labels, err := trial.ApplyLabelTemplates(t)
+	if err != nil {
+		log.Error(err, "Failed to evaluate trial label templates")
+	}
+
 	// Since the trial originated from the server, we can delete it out of the cluster (require both TTLs to be unset)
 	if t.Spec.TTLSecondsAfterFinished == nil && t.Spec.TTLSecondsAfterFailure == nil {
 		t.Spec.TTLSecondsAfterFinished = &defaultServerTrialTTLSecondsAfterFinished
@@ -418,6 +513,11 @@ func (r *ServerReconciler) nextTrial(ctx context.Context, log logr.Logger, exp *
 		log.Info("Trial is missing a reporting URL")
 	}
 
+	// Verify the server assignments still match the cluster experiment before creating anything
+	if err := validation.CheckAssignments(t, exp); err != nil {
+		return r.rejectTrial(ctx, log, exp, t, suggestion.Link(api.RelationSelf), err)
+	}
+
 	// Create the trial
 	if err := r.Create(ctx, t); err != nil {
 		// If creation fails, abandon the suggestion (ignoring those errors)
@@ -427,10 +527,135 @@ func (r *ServerReconciler) nextTrial(ctx context.Context, log logr.Logger, exp *
 		return &ctrl.Result{}, err
 	}
 
+	// Propagate the templated labels to the server so results can be filtered by them
+	if u := suggestion.Link(api.RelationLabels); len(labels) > 0 && u != "" {
+		if err := r.ExperimentsAPI.LabelTrial(ctx, u, experiments.TrialLabels{Labels: labels}); err != nil {
+			log.Error(err, "Failed to label trial")
+		}
+	}
+
+	controller.ExperimentTrialsRequested.WithLabelValues(exp.Name).Inc()
+	r.checkTrialQuota(ctx, log, exp)
+
 	log.Info("Created new trial", "reportTrialURL", reportTrialURL, "assignments", t.Spec.Assignments)
 	return nil, nil
 }
 
+// checkReplicas records when only one of the experiment replicas can run at a time because every trial must use the
+// same namespace, the warning is only emitted when the condition changes
+func (r *ServerReconciler) checkReplicas(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment) (*ctrl.Result, error) {
+	limited := exp.Replicas() > 1 && experiment.IsSingleNamespace(exp)
+	if limited == experiment.IsReplicasLimited(exp) {
+		return nil, nil
+	}
+
+	if limited {
+		msg := fmt.Sprintf("Only 1 of %d replicas can run concurrently, use a namespace selector or template to run trials in parallel", exp.Replicas())
+		log.Info("Experiment replicas are limited", "message", msg)
+		r.Recorder.Event(exp, corev1.EventTypeWarning, "ReplicasLimited", msg)
+		experiment.ApplyCondition(&exp.Status, optimizev1beta2.ExperimentReplicasLimited, corev1.ConditionTrue, "SingleNamespace", msg, nil)
+	} else {
+		experiment.ApplyCondition(&exp.Status, optimizev1beta2.ExperimentReplicasLimited, corev1.ConditionFalse, "", "", nil)
+	}
+
+	err := r.Update(ctx, exp)
+	return controller.RequeueConflict(err)
+}
+
+// checkConcurrency holds back trial creation when the controller concurrency limits have been reached, the experiment
+// is marked as waiting until it is admitted
+func (r *ServerReconciler) checkConcurrency(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment) (*ctrl.Result, error) {
+	admitted, msg := true, ""
+	if r.concurrency != nil {
+		expList := &optimizev1beta2.ExperimentList{}
+		if err := r.List(ctx, expList); err != nil {
+			return &ctrl.Result{}, err
+		}
+
+		trialList := &optimizev1beta2.TrialList{}
+		if err := r.List(ctx, trialList); err != nil {
+			return &ctrl.Result{}, err
+		}
+
+		admitted, msg = r.concurrency.Admit(exp, expList.Items, trialList.Items)
+	}
+
+	if admitted {
+		// Clear the waiting condition so the experiment leaves the queue
+		if !experiment.IsWaiting(exp) {
+			return nil, nil
+		}
+
+		log.Info("Experiment admitted by concurrency limits")
+		experiment.ApplyCondition(&exp.Status, optimizev1beta2.ExperimentWaiting, corev1.ConditionFalse, "Admitted", "", nil)
+		err := r.Update(ctx, exp)
+		return controller.RequeueConflict(err)
+	}
+
+	if !experiment.IsWaiting(exp) {
+		log.Info("Experiment is waiting on concurrency limits", "message", msg)
+		r.Recorder.Event(exp, corev1.EventTypeNormal, "Waiting", msg)
+		experiment.ApplyCondition(&exp.Status, optimizev1beta2.ExperimentWaiting, corev1.ConditionTrue, "ConcurrencyLimit", msg, nil)
+		if err := r.Update(ctx, exp); err != nil {
+			return controller.RequeueConflict(err)
+		}
+	}
+
+	// There is no watch on other experiments, so poll for an open slot
+	return &ctrl.Result{RequeueAfter: concurrencyRequeueInterval}, nil
+}
+
+// checkTrialQuota refreshes the subscription quota usage after a new trial is created, warning once when the quota is
+// almost exhausted
+func (r *ServerReconciler) checkTrialQuota(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment) {
+	if r.trialQuota == nil {
+		return
+	}
+
+	if err := r.trialQuota.Refresh(ctx, r.ExperimentsAPI, time.Now()); err != nil {
+		log.Error(err, "Failed to refresh trial quota usage")
+	}
+	controller.TrialQuotaUsed.Set(float64(r.trialQuota.Used()))
+
+	if r.trialQuota.ShouldWarn() {
+		remaining := r.trialQuota.Remaining()
+		log.Info("Trial quota is almost exhausted", "remainingTrials", remaining, "trialQuota", r.trialQuota.Limit)
+		r.Recorder.Eventf(exp, corev1.EventTypeWarning, "TrialQuotaLow", "Only %d of %d trials remain in the subscription", remaining, r.trialQuota.Limit)
+	}
+}
+
+// rejectTrial reports a suggestion whose assignments do not match the cluster experiment as a failed trial, if
+// the suggestion cannot be reported it is abandoned instead
+func (r *ServerReconciler) rejectTrial(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment, t *optimizev1beta2.Trial, selfURL string, assignmentErr error) (*ctrl.Result, error) {
+	reportTrialURL := t.GetAnnotations()[optimizev1beta2.AnnotationReportTrialURL]
+	log = log.WithValues("reportTrialURL", reportTrialURL, "assignments", t.Spec.Assignments)
+
+	switch {
+	case reportTrialURL != "":
+		trialValues := experiments.TrialValues{
+			Failed:         true,
+			FailureReason:  "InvalidAssignments",
+			FailureMessage: assignmentErr.Error(),
+		}
+		if err := r.ExperimentsAPI.ReportTrial(ctx, reportTrialURL, trialValues); controller.IgnoreReportError(err) != nil {
+			return &ctrl.Result{}, err
+		}
+
+	case selfURL != "":
+		// Without a report URL the best we can do is abandon the suggestion so it is not left running
+		if err := r.ExperimentsAPI.AbandonRunningTrial(ctx, selfURL); controller.IgnoreNotFound(err) != nil {
+			return &ctrl.Result{}, err
+		}
+
+	default:
+		log.Info("Rejected trial suggestion cannot be reported or abandoned")
+	}
+
+	log.Error(assignmentErr, "Rejected trial suggestion")
+	r.Recorder.Eventf(exp, corev1.EventTypeWarning, "InvalidAssignments", "Rejected trial suggestion: %s", assignmentErr.Error())
+	return nil, nil
+}
+
 // reportTrial will report the values from a finished in cluster trial back to the server
 func (r *ServerReconciler) reportTrial(ctx context.Context, log logr.Logger, t *optimizev1beta2.Trial) (*ctrl.Result, error) {
 	if !meta.RemoveFinalizer(t, server.Finalizer) {
@@ -456,6 +681,11 @@ func (r *ServerReconciler) reportTrial(ctx context.Context, log logr.Logger, t *
 		if controller.IgnoreReportError(err) != nil {
 			return &ctrl.Result{}, err
 		}
+
+		// The quota is consumed by the observation, not the creation of the trial
+		if err == nil && r.trialQuota != nil {
+			r.trialQuota.Record()
+		}
 	}
 
 	// Update the trial
@@ -463,10 +693,53 @@ func (r *ServerReconciler) reportTrial(ctx context.Context, log logr.Logger, t *
 		return controller.RequeueConflict(err)
 	}
 
+	controller.ExperimentTrialsReported.WithLabelValues(t.ExperimentNamespacedName().Name).Inc()
 	log.Info("Reported trial")
 	return nil, nil
 }
 
+// retryTrial replaces a failed trial with a new trial using the same assignments, the failure is never reported
+func (r *ServerReconciler) retryTrial(ctx context.Context, log logr.Logger, exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList, t *optimizev1beta2.Trial) (*ctrl.Result, error) {
+	// Determine the namespace (if any) to use for the retry
+	namespace, err := experiment.NextTrialNamespace(ctx, r, exp, trialList)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+	if namespace == "" {
+		return nil, nil
+	}
+
+	// Generate a new trial from the template on the experiment and apply the failed trial's assignments
+	rt := &optimizev1beta2.Trial{}
+	experiment.PopulateTrialFromTemplate(exp, rt)
+	rt.Namespace = namespace
+	trial.PopulateRetry(rt, t)
+	meta.AddFinalizer(rt, server.Finalizer)
+
+	class := trial.FailureClass(t)
+	reportTrialURL := t.GetAnnotations()[optimizev1beta2.AnnotationReportTrialURL]
+	log = log.WithValues("failureClass", class, "reportTrialURL", reportTrialURL)
+
+	// Remove the finalizer first so the failed trial is never reported, even if the retry cannot be created
+	meta.RemoveFinalizer(t, server.Finalizer)
+	if err := r.Update(ctx, t); err != nil {
+		return controller.RequeueConflict(err)
+	}
+
+	// Create the retry
+	if err := r.Create(ctx, rt); err != nil {
+		// If creation fails, abandon the suggestion (ignoring those errors)
+		if reportTrialURL != "" {
+			_ = r.ExperimentsAPI.AbandonRunningTrial(ctx, reportTrialURL)
+		}
+		return &ctrl.Result{}, err
+	}
+
+	r.Recorder.Eventf(exp, corev1.EventTypeNormal, "TrialRetried", "Retrying trial %s as %s following a %s failure", t.Name, rt.Name, strings.ToLower(class))
+	log.Info("Retrying trial", "retry", rt.Namespace+"/"+rt.Name, "attempt", trial.RetryAttempts(rt, class))
+	return &ctrl.Result{}, nil
+}
+
 // abandonTrial will remove the finalizer and try to notify the server that the trial will not be reported
 func (r *ServerReconciler) abandonTrial(ctx context.Context, log logr.Logger, t *optimizev1beta2.Trial) (*ctrl.Result, error) {
 	if !meta.RemoveFinalizer(t, server.Finalizer) {
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// rejectExperimentsAPI records the trials reported or abandoned while rejecting a suggestion.
+type rejectExperimentsAPI struct {
+	experiments.API
+	reported  []string
+	abandoned []string
+}
+
+func (f *rejectExperimentsAPI) ReportTrial(_ context.Context, u string, _ experiments.TrialValues) error {
+	f.reported = append(f.reported, u)
+	return nil
+}
+
+func (f *rejectExperimentsAPI) AbandonRunningTrial(_ context.Context, u string) error {
+	f.abandoned = append(f.abandoned, u)
+	return nil
+}
+
+func TestServerReconciler_RejectTrial(t *testing.T) {
+	cases := []struct {
+		desc              string
+		reportTrialURL    string
+		selfURL           string
+		expectedReported  []string
+		expectedAbandoned []string
+	}{
+		{
+			desc:             "report",
+			reportTrialURL:   "http://example.invalid/trials/1",
+			selfURL:          "http://example.invalid/trials/1",
+			expectedReported: []string{"http://example.invalid/trials/1"},
+		},
+		{
+			desc:              "abandon",
+			selfURL:           "http://example.invalid/trials/1",
+			expectedAbandoned: []string{"http://example.invalid/trials/1"},
+		},
+		{
+			desc: "no links",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			api := &rejectExperimentsAPI{}
+			recorder := record.NewFakeRecorder(1)
+			r := &ServerReconciler{Log: log.NullLogger{}, Recorder: recorder, ExperimentsAPI: api}
+
+			exp := &optimizev1beta2.Experiment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+			tr := &optimizev1beta2.Trial{}
+			if c.reportTrialURL != "" {
+				tr.Annotations = map[string]string{optimizev1beta2.AnnotationReportTrialURL: c.reportTrialURL}
+			}
+
+			result, err := r.rejectTrial(context.TODO(), r.Log, exp, tr, c.selfURL, fmt.Errorf("invalid assignment"))
+			assert.NoError(t, err)
+			assert.Nil(t, result)
+			assert.Equal(t, c.expectedReported, api.reported)
+			assert.Equal(t, c.expectedAbandoned, api.abandoned)
+			if assert.Len(t, recorder.Events, 1) {
+				assert.Contains(t, <-recorder.Events, "InvalidAssignments")
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -31,20 +32,31 @@ import (
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// setupLogTailLines is the number of log lines collected from a failed setup task container
+const setupLogTailLines = 10
+
 // SetupReconciler reconciles a Trial object for setup tasks
 type SetupReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	pods corev1client.PodsGetter
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials;trials/finalizers,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups="",resources=pods,verbs=list
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=batch;extensions,resources=jobs,verbs=list;watch;create
 
 func (r *SetupReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
@@ -80,6 +92,18 @@ func (r *SetupReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 }
 
 func (r *SetupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("setup")
+	}
+
+	if r.pods == nil {
+		cs, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			return err
+		}
+		r.pods = cs.CoreV1()
+	}
+
 	// TODO Have some type of setting to by-pass this
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("setup").
@@ -136,7 +160,13 @@ func (r *SetupReconciler) inspectSetupJobs(ctx context.Context, t *optimizev1bet
 		// Only fail the trial itself if it isn't already finished; both to prevent overwriting an existing success
 		// or failure status and to avoid updating the probe time (which would get us stuck in a busy loop)
 		if failureMessage != "" && !trial.IsFinished(t) {
+			if diagnostics := r.failedContainerDiagnostics(ctx, job); diagnostics != "" {
+				failureMessage = failureMessage + ": " + diagnostics
+			}
 			trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, "SetupJobFailed", failureMessage, probeTime)
+			if r.Recorder != nil {
+				r.Recorder.Event(t, corev1.EventTypeWarning, "SetupJobFailed", failureMessage)
+			}
 		}
 	}
 
@@ -170,6 +200,48 @@ func (r *SetupReconciler) inspectSetupJobPods(ctx context.Context, j *batchv1.Jo
 	return corev1.ConditionFalse, ""
 }
 
+// failedContainerDiagnostics returns a description of the failed containers of a setup job, including the tail of
+// their logs, so the cause of the failure is still available after the job is cleaned up
+func (r *SetupReconciler) failedContainerDiagnostics(ctx context.Context, j *batchv1.Job) string {
+	list := &corev1.PodList{}
+	if matchingSelector, err := meta.MatchingSelector(j.Spec.Selector); err != nil {
+		return ""
+	} else if err := r.List(ctx, list, client.InNamespace(j.Namespace), matchingSelector); err != nil {
+		return ""
+	}
+
+	var diagnostics []string
+	for i := range list.Items {
+		pod := &list.Items[i]
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Terminated == nil || cs.State.Terminated.ExitCode == 0 {
+				continue
+			}
+
+			var logs string
+			if r.pods != nil {
+				logs = r.containerLogs(ctx, pod, cs.Name)
+			}
+			diagnostics = append(diagnostics, setup.TerminatedContainerMessage(cs.Name, cs.State.Terminated, logs))
+		}
+	}
+	return strings.Join(diagnostics, "; ")
+}
+
+// containerLogs returns the last lines of the logs of the specified container, errors are ignored
+func (r *SetupReconciler) containerLogs(ctx context.Context, pod *corev1.Pod, container string) string {
+	tailLines := int64(setupLogTailLines)
+	data, err := r.pods.Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &tailLines,
+	}).Context(ctx).DoRaw()
+	if err != nil {
+		r.Log.Info("Unable to collect setup task logs", "pod", pod.Name, "container", container, "error", err.Error())
+		return ""
+	}
+	return string(data)
+}
+
 // createSetupJob determines if a setup job is necessary and creates it
 func (r *SetupReconciler) createSetupJob(ctx context.Context, t *optimizev1beta2.Trial, probeTime *metav1.Time) (*ctrl.Result, error) {
 	mode := ""
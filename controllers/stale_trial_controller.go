@@ -0,0 +1,210 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/meta"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultStaleTrialMultiplier is the multiple of the expected trial duration after which a trial is stale
+	defaultStaleTrialMultiplier = 3
+	// maxStaleTrialRecoveryAttempts is the number of recoveries attempted before a stale trial is failed
+	maxStaleTrialRecoveryAttempts = 2
+)
+
+// staleTrialMultiplier returns the configured multiple of the expected trial duration used to detect stale
+// trials, a value of zero disables stale trial detection. Only trials with an explicit approximate runtime are
+// checked using the multiplier.
+func staleTrialMultiplier(log logr.Logger) float64 {
+	multiplier, ok := os.LookupEnv("STORMFORGE_STALE_TRIAL_MULTIPLIER")
+	if !ok {
+		return defaultStaleTrialMultiplier
+	}
+
+	m, err := strconv.ParseFloat(multiplier, 64)
+	if err != nil || m < 0 {
+		log.Info("Ignoring invalid stale trial multiplier", "staleTrialMultiplier", multiplier)
+		return defaultStaleTrialMultiplier
+	}
+
+	log.Info("Using custom stale trial multiplier", "staleTrialMultiplier", multiplier)
+	return m
+}
+
+// StaleTrialReconciler recovers (or fails) trials which have stopped making progress or exceeded a configured timeout
+type StaleTrialReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	multiplier float64
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=batch;extensions,resources=jobs,verbs=list;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=list
+
+func (r *StaleTrialReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	now := metav1.Now()
+
+	t := &optimizev1beta2.Trial{}
+	if err := r.Get(ctx, req.NamespacedName, t); err != nil {
+		return ctrl.Result{}, controller.IgnoreNotFound(err)
+	}
+
+	// Check back when the trial would become stale
+	s := trial.CheckStaleness(t, r.multiplier)
+	if s == nil {
+		return ctrl.Result{}, nil
+	}
+	if !s.IsStale(now.Time) {
+		return ctrl.Result{RequeueAfter: s.Remaining(now.Time)}, nil
+	}
+
+	log := r.Log.WithValues("trial", req.NamespacedName, "stage", s.Stage)
+
+	jobList := &batchv1.JobList{}
+	if err := r.listStageJobs(ctx, t, s.Stage, jobList); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if result, err := r.recoverTrial(ctx, log, t, s, jobList, &now); result != nil {
+		return *result, err
+	}
+
+	if result, err := r.failTrial(ctx, log, t, s, jobList, &now); result != nil {
+		return *result, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *StaleTrialReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.multiplier = staleTrialMultiplier(r.Log)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("stale-trial").
+		For(&optimizev1beta2.Trial{}).
+		Complete(r)
+}
+
+// recoverTrial attempts to get a stale trial moving again by recreating the stuck job or re-querying metrics
+func (r *StaleTrialReconciler) recoverTrial(ctx context.Context, log logr.Logger, t *optimizev1beta2.Trial, s *trial.Staleness, jobList *batchv1.JobList, probeTime *metav1.Time) (*ctrl.Result, error) {
+	// Configured timeouts are not recovered, the trial fails as soon as they are exceeded
+	if s.Configured || trial.RecoveryAttempts(t) >= maxStaleTrialRecoveryAttempts {
+		return nil, nil
+	}
+
+	switch s.Stage {
+	case trial.StageSetup:
+		// The setup controller will recreate the job once it notices it is missing
+
+	case trial.StageRun:
+		// We can only recreate trial run jobs, not workflows or pipeline runs
+		if t.Spec.ArgoWorkflow != nil || t.Spec.TektonPipelineRun != nil {
+			return nil, nil
+		}
+
+		// The trial job controller will create a new job once the start time is cleared
+		t.Status.StartTime = nil
+
+	case trial.StageMetrics:
+		// The metric controller will start over if there are no values
+		t.Spec.Values = nil
+		trial.ApplyCondition(&t.Status, optimizev1beta2.TrialObserved, corev1.ConditionUnknown, "StaleRecovery", "", probeTime)
+	}
+
+	for i := range jobList.Items {
+		if err := r.Delete(ctx, &jobList.Items[i], client.PropagationPolicy(metav1.DeletePropagationBackground)); controller.IgnoreNotFound(err) != nil {
+			return &ctrl.Result{}, err
+		}
+	}
+
+	trial.RecordRecoveryAttempt(t, probeTime.Time)
+	log.Info("Attempting to recover stale trial", "attempt", trial.RecoveryAttempts(t), "timeout", s.Timeout.String())
+	err := r.Update(ctx, t)
+	return controller.RequeueConflict(err)
+}
+
+// failTrial marks a stale trial as failed, including any available diagnostics in the failure message
+func (r *StaleTrialReconciler) failTrial(ctx context.Context, log logr.Logger, t *optimizev1beta2.Trial, s *trial.Staleness, jobList *batchv1.JobList, probeTime *metav1.Time) (*ctrl.Result, error) {
+	var pods []corev1.Pod
+	for i := range jobList.Items {
+		matchingSelector, err := meta.MatchingSelector(jobList.Items[i].Spec.Selector)
+		if err != nil {
+			continue
+		}
+
+		podList := &corev1.PodList{}
+		if err := r.List(ctx, podList, client.InNamespace(jobList.Items[i].Namespace), matchingSelector); err == nil {
+			pods = append(pods, podList.Items...)
+		}
+	}
+
+	msg := trial.StaleMessage(t, s, pods)
+	log.Info("Failing stale trial", "reason", s.FailureReason(), "message", msg)
+	trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, s.FailureReason(), msg, probeTime)
+	err := r.Update(ctx, t)
+	return controller.RequeueConflict(err)
+}
+
+// listStageJobs returns the jobs responsible for making progress in the supplied stage of the trial
+func (r *StaleTrialReconciler) listStageJobs(ctx context.Context, t *optimizev1beta2.Trial, stage string, jobList *batchv1.JobList) error {
+	switch stage {
+	case trial.StageSetup:
+		return r.List(ctx, jobList, client.InNamespace(t.Namespace), client.MatchingLabels{
+			optimizev1beta2.LabelTrial:     t.Name,
+			optimizev1beta2.LabelTrialRole: "trialSetup",
+		})
+
+	case trial.StageRun:
+		matchingSelector, err := meta.MatchingSelector(t.GetJobSelector())
+		if err != nil {
+			return err
+		}
+		if err := r.List(ctx, jobList, client.InNamespace(t.Namespace), matchingSelector); err != nil {
+			return err
+		}
+
+		// Setup jobs always have "role=trialSetup" so ignore jobs with that label
+		items := jobList.Items[:0]
+		for i := range jobList.Items {
+			if jobList.Items[i].Labels[optimizev1beta2.LabelTrialRole] != "trialSetup" {
+				items = append(items, jobList.Items[i])
+			}
+		}
+		jobList.Items = items
+	}
+
+	return nil
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/thestormforge/optimize-controller/v2/internal/trial"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -41,11 +42,14 @@ type TrialJobReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// PushURL is the base URL trial run jobs use to push metric values, leave empty to disable
+	PushURL string
 }
 
 // +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups=batch;extensions,resources=jobs,verbs=get;list;watch;create;patch
 // +kubebuilder:rbac:groups="",resources=pods,verbs=list
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=create
 
 func (r *TrialJobReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -101,6 +105,11 @@ func (r *TrialJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 func (r *TrialJobReconciler) ignoreTrial(t *optimizev1beta2.Trial) bool {
+	// Ignore trials that are run using Argo Workflows or Tekton Pipelines
+	if t.Spec.ArgoWorkflow != nil || t.Spec.TektonPipelineRun != nil {
+		return true
+	}
+
 	// Ignore deleted trials
 	if !t.DeletionTimestamp.IsZero() {
 		return true
@@ -143,6 +152,12 @@ func (r *TrialJobReconciler) updateStatus(ctx context.Context, t *optimizev1beta
 // createJob will create a new trial run job
 func (r *TrialJobReconciler) createJob(ctx context.Context, t *optimizev1beta2.Trial) (*ctrl.Result, error) {
 	job := trial.NewJob(t)
+	if r.PushURL != "" {
+		if err := r.createPushTokenSecret(ctx, t); err != nil {
+			return &ctrl.Result{}, err
+		}
+		trial.AddPushEnv(t, job, r.PushURL)
+	}
 	if err := controllerutil.SetControllerReference(t, job, r.Scheme); err != nil {
 		return &ctrl.Result{}, err
 	}
@@ -151,6 +166,23 @@ func (r *TrialJobReconciler) createJob(ctx context.Context, t *optimizev1beta2.T
 	return &ctrl.Result{}, err
 }
 
+// createPushTokenSecret will create the secret containing the token the trial run job uses to push metric values
+func (r *TrialJobReconciler) createPushTokenSecret(ctx context.Context, t *optimizev1beta2.Trial) error {
+	secret, err := trial.NewPushTokenSecret(t)
+	if err != nil {
+		return err
+	}
+	if err := controllerutil.SetControllerReference(t, secret, r.Scheme); err != nil {
+		return err
+	}
+
+	// The secret may be left over from an earlier attempt to create the job
+	if err := r.Create(ctx, secret); err != nil && !apierrs.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
 // listJobs will return all of the jobs for the trial
 func (r *TrialJobReconciler) listJobs(ctx context.Context, jobList *batchv1.JobList, namespace string, selector *metav1.LabelSelector) error {
 	matchingSelector, err := meta.MatchingSelector(selector)
@@ -175,7 +207,7 @@ func (r *TrialJobReconciler) listJobs(ctx context.Context, jobList *batchv1.JobL
 }
 
 func (r *TrialJobReconciler) applyJobStatus(ctx context.Context, t *optimizev1beta2.Trial, job *batchv1.Job, time *metav1.Time) (bool, bool) {
-	var dirty bool
+	var dirty, assertionFailed bool
 
 	// Get the interval of the container execution in the job pods
 	startedAt := job.Status.StartTime
@@ -196,14 +228,18 @@ func (r *TrialJobReconciler) applyJobStatus(ctx context.Context, t *optimizev1be
 				for _, c := range s.Conditions {
 					if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable {
 						trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, c.Reason, fmt.Sprintf("trial pod: %s", c.Message), time)
-
-						// Patch the job and set parallelism to 0 to suspend the job and terminate any active pods
-						if err := r.Patch(ctx, job, client.RawPatch(types.StrategicMergePatchType, []byte(`{ "spec": { "parallelism": 0  } }`))); err != nil {
-							r.Log.WithValues("trial", fmt.Sprintf("%s/%s", t.Namespace, t.Name), "job", fmt.Sprintf("%s/%s", job.Namespace, job.Name)).Error(err, "unable suspend trial job")
-						}
+						r.suspendJob(ctx, t, job)
 						dirty = true
 					}
 				}
+
+				// Look for assertions violated inside the load test (e.g. k6 thresholds), these fail the trial immediately without waiting for the job
+				if msg, ok := trial.AssertionFailure(&podList.Items[i]); ok {
+					assertionFailed = true
+					trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, "AssertionFailed", msg, time)
+					r.suspendJob(ctx, t, job)
+					dirty = true
+				}
 			}
 
 			// Check if the job has a start/completion time, but it is not yet reflected in the pod state we are seeing
@@ -226,9 +262,9 @@ func (r *TrialJobReconciler) applyJobStatus(ctx context.Context, t *optimizev1be
 		dirty = true
 	}
 
-	// Mark the trial as failed if the job itself failed
+	// Mark the trial as failed if the job itself failed (preserving the reason for a violated assertion)
 	for _, c := range job.Status.Conditions {
-		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue && !assertionFailed {
 			trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, c.Reason, c.Message, time)
 			dirty = true
 		}
@@ -237,6 +273,16 @@ func (r *TrialJobReconciler) applyJobStatus(ctx context.Context, t *optimizev1be
 	return dirty, false
 }
 
+// suspendJob patches the job and sets parallelism to 0 to suspend the job and terminate any active pods
+func (r *TrialJobReconciler) suspendJob(ctx context.Context, t *optimizev1beta2.Trial, job *batchv1.Job) {
+	if job.Spec.Parallelism != nil && *job.Spec.Parallelism == 0 {
+		return
+	}
+	if err := r.Patch(ctx, job, client.RawPatch(types.StrategicMergePatchType, []byte(`{ "spec": { "parallelism": 0  } }`))); err != nil {
+		r.Log.WithValues("trial", fmt.Sprintf("%s/%s", t.Namespace, t.Name), "job", fmt.Sprintf("%s/%s", job.Namespace, job.Name)).Error(err, "unable suspend trial job")
+	}
+}
+
 func containerTime(pods *corev1.PodList) (startedAt *metav1.Time, finishedAt *metav1.Time) {
 	for i := range pods.Items {
 		for j := range pods.Items[i].Status.ContainerStatuses {
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestTrialJobReconciler_ApplyJobStatusFailureClass(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = batchv1.AddToScheme(scheme)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-000"},
+		Spec:       batchv1.JobSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"job-name": "test-000"}}},
+	}
+	pod := func(status corev1.PodStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-000-abcde", Labels: map[string]string{"job-name": "test-000"}},
+			Status:     status,
+		}
+	}
+
+	cases := []struct {
+		desc          string
+		pod           *corev1.Pod
+		expectedClass string
+	}{
+		{
+			desc:          "evicted",
+			pod:           pod(corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"}),
+			expectedClass: trial.FailureInfrastructure,
+		},
+		{
+			desc: "unschedulable",
+			pod: pod(corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable},
+			}}),
+			expectedClass: trial.FailureAssignment,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			r := &TrialJobReconciler{Client: fake.NewFakeClientWithScheme(scheme, job.DeepCopy(), c.pod), Log: log.NullLogger{}}
+			tr := &optimizev1beta2.Trial{}
+			now := metav1.Now()
+
+			dirty, _ := r.applyJobStatus(context.TODO(), tr, job.DeepCopy(), &now)
+			assert.True(t, dirty)
+			assert.Equal(t, c.expectedClass, trial.FailureClass(tr))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TrialPipelineRunReconciler reconciles a Trial's Tekton PipelineRun
+type TrialPipelineRunReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=tekton.dev,resources=pipelineruns,verbs=get;list;watch;create
+
+func (r *TrialPipelineRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return (&trialRunReconciler{
+		Client: r.Client,
+		Log:    r.Log,
+		Scheme: r.Scheme,
+		runner: trialRunner{
+			name:              "trial-pipelinerun",
+			gvk:               trial.PipelineRunGroupVersionKind,
+			product:           "Tekton Pipelines",
+			unavailableReason: "PipelineRunUnavailable",
+			isRunBy:           func(t *optimizev1beta2.Trial) bool { return t.Spec.TektonPipelineRun != nil },
+			newRun:            trial.NewTektonPipelineRun,
+			runStatus:         trial.PipelineRunStatus,
+		},
+	}).SetupWithManager(mgr)
+}
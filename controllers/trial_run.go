@@ -0,0 +1,258 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	"github.com/thestormforge/optimize-controller/v2/internal/validation"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// trialRunner describes a custom resource used to run trials instead of a job
+type trialRunner struct {
+	// The name of the controller
+	name string
+	// The type of the custom resource
+	gvk schema.GroupVersionKind
+	// The product name of the custom resource, used in messages
+	product string
+	// The trial failure reason used when the custom resource is not installed
+	unavailableReason string
+	// Checks if the trial should be run using the custom resource
+	isRunBy func(*optimizev1beta2.Trial) bool
+	// Creates a new trial run
+	newRun func(*optimizev1beta2.Trial) (*unstructured.Unstructured, error)
+	// Returns the start and completion times of the trial run, along with a reason and message if it failed
+	runStatus func(*unstructured.Unstructured) (startedAt, finishedAt *metav1.Time, failed bool, reason, message string)
+}
+
+// trialRunReconciler reconciles the custom resources used to run trials instead of jobs
+type trialRunReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	runner    trialRunner
+	available bool
+}
+
+func (r *trialRunReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	now := metav1.Now()
+
+	t := &optimizev1beta2.Trial{}
+	if err := r.Get(ctx, req.NamespacedName, t); err != nil || r.ignoreTrial(t) {
+		return ctrl.Result{}, controller.IgnoreNotFound(err)
+	}
+
+	// Fail trials that cannot be run before anything else happens
+	if result, err := r.checkTrialRun(ctx, t, &now); result != nil {
+		return *result, err
+	}
+
+	// Wait for the trial to become ready
+	if !trial.CheckCondition(&t.Status, optimizev1beta2.TrialReady, corev1.ConditionTrue) {
+		return ctrl.Result{}, nil
+	}
+
+	// Update the trial status based on the existing trial run state
+	if result, err := r.updateStatus(ctx, t, &now); result != nil {
+		return *result, err
+	}
+
+	// Insert a "sleep" between "ready" and the trial run
+	if ids := time.Duration(t.Spec.InitialDelaySeconds) * time.Second; ids > 0 {
+		for _, c := range t.Status.Conditions {
+			if c.Type == optimizev1beta2.TrialReady {
+				startTime := c.LastTransitionTime.Add(ids)
+				if startTime.After(now.Time) {
+					return ctrl.Result{RequeueAfter: startTime.Sub(now.Time)}, nil
+				}
+			}
+		}
+	}
+
+	// Create the trial run
+	if result, err := r.createTrialRun(ctx, t); result != nil {
+		return *result, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *trialRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	ok, err := hasKind(mgr, r.runner.gvk)
+	if err != nil {
+		return err
+	}
+	r.available = ok
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named(r.runner.name).
+		For(&optimizev1beta2.Trial{})
+
+	// Only watch the trial runs if the custom resource is installed in the cluster, otherwise trials using it are failed
+	if r.available {
+		run := &unstructured.Unstructured{}
+		run.SetGroupVersionKind(r.runner.gvk)
+		b = b.Owns(run)
+	} else {
+		r.Log.Info("Trial run resource is unavailable, trials using it will fail", "product", r.runner.product, "kind", r.runner.gvk.Kind)
+	}
+
+	return b.Complete(r)
+}
+
+func (r *trialRunReconciler) ignoreTrial(t *optimizev1beta2.Trial) bool {
+	// Ignore trials that are not run using this custom resource
+	if !r.runner.isRunBy(t) {
+		return true
+	}
+
+	// Ignore deleted trials
+	if !t.DeletionTimestamp.IsZero() {
+		return true
+	}
+
+	// Ignore failed trials
+	if trial.CheckCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue) {
+		return true
+	}
+
+	// Ignore trials that already have a start and completion time
+	if t.Status.StartTime != nil && t.Status.CompletionTime != nil {
+		return true
+	}
+
+	// Reconcile everything else
+	return false
+}
+
+// checkTrialRun fails the trial if it is not possible to run it using the custom resource
+func (r *trialRunReconciler) checkTrialRun(ctx context.Context, t *optimizev1beta2.Trial, probeTime *metav1.Time) (*ctrl.Result, error) {
+	reason, message := "", ""
+	if err := validation.CheckTrialRun(&t.Spec); err != nil {
+		reason, message = "InvalidTrialRun", err.Error()
+	} else if !r.available {
+		reason, message = r.runner.unavailableReason, fmt.Sprintf("%s are not installed in the cluster (%s is unavailable)", r.runner.product, r.runner.gvk.GroupKind())
+	} else {
+		return nil, nil
+	}
+
+	trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, reason, message, probeTime)
+	err := r.Update(ctx, t)
+	return controller.RequeueConflict(err)
+}
+
+// updateStatus will update the trial status based on the trial run, if it exists
+func (r *trialRunReconciler) updateStatus(ctx context.Context, t *optimizev1beta2.Trial, probeTime *metav1.Time) (*ctrl.Result, error) {
+	run := &unstructured.Unstructured{}
+	run.SetGroupVersionKind(r.runner.gvk)
+	if err := r.Get(ctx, client.ObjectKey{Namespace: t.Namespace, Name: t.Name}, run); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return &ctrl.Result{}, err
+	}
+
+	if r.applyRunStatus(t, run, probeTime) {
+		err := r.Update(ctx, t)
+		return controller.RequeueConflict(err)
+	}
+
+	// The trial run exists, there is nothing else to do until it changes
+	return &ctrl.Result{}, nil
+}
+
+// createTrialRun will create a new trial run
+func (r *trialRunReconciler) createTrialRun(ctx context.Context, t *optimizev1beta2.Trial) (*ctrl.Result, error) {
+	run, err := r.runner.newRun(t)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+	if err := controllerutil.SetControllerReference(t, run, r.Scheme); err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	err = r.Create(ctx, run)
+	return &ctrl.Result{}, err
+}
+
+func (r *trialRunReconciler) applyRunStatus(t *optimizev1beta2.Trial, run *unstructured.Unstructured, time *metav1.Time) bool {
+	var dirty bool
+
+	startedAt, finishedAt, failed, reason, message := r.runner.runStatus(run)
+
+	// Adjust the trial start time
+	if startTime, updated := latestTime(t.Status.StartTime, startedAt, t.Spec.StartTimeOffset); updated {
+		t.Status.StartTime = startTime
+		dirty = true
+	}
+
+	// Adjust the trial completion time
+	if completionTime, updated := earliestTime(t.Status.CompletionTime, finishedAt); updated {
+		t.Status.CompletionTime = completionTime
+		dirty = true
+	}
+
+	// Mark the trial as failed if the trial run failed
+	if failed {
+		trial.ApplyCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue, reason, message, time)
+		dirty = true
+	}
+
+	return dirty
+}
+
+// hasKind checks the API server to see if a resource of the supplied kind is available
+func hasKind(mgr ctrl.Manager, gvk schema.GroupVersionKind) (bool, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return false, err
+	}
+
+	rl, err := dc.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	if err != nil {
+		if apierrs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for i := range rl.APIResources {
+		if rl.APIResources[i].Kind == gvk.Kind {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestTrialRunReconciler_CheckTrialRun(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+
+	runner := trialRunner{
+		name:              "trial-workflow",
+		gvk:               trial.WorkflowGroupVersionKind,
+		product:           "Argo Workflows",
+		unavailableReason: "WorkflowUnavailable",
+		isRunBy:           func(t *optimizev1beta2.Trial) bool { return t.Spec.ArgoWorkflow != nil },
+	}
+
+	testCases := []struct {
+		desc           string
+		spec           optimizev1beta2.TrialSpec
+		available      bool
+		expectedReason string
+	}{
+		{
+			desc:           "unavailable",
+			spec:           optimizev1beta2.TrialSpec{ArgoWorkflow: &optimizev1beta2.ArgoWorkflowSpec{}},
+			expectedReason: "WorkflowUnavailable",
+		},
+		{
+			desc: "both workflow and pipeline run",
+			spec: optimizev1beta2.TrialSpec{
+				ArgoWorkflow:      &optimizev1beta2.ArgoWorkflowSpec{},
+				TektonPipelineRun: &optimizev1beta2.TektonPipelineRunSpec{},
+			},
+			available:      true,
+			expectedReason: "InvalidTrialRun",
+		},
+		{
+			desc: "not run by workflow",
+		},
+		{
+			desc:      "waiting for ready",
+			spec:      optimizev1beta2.TrialSpec{ArgoWorkflow: &optimizev1beta2.ArgoWorkflowSpec{}},
+			available: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tr := &optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+				Spec:       tc.spec,
+			}
+			r := &trialRunReconciler{
+				Client:    fake.NewFakeClientWithScheme(scheme, tr),
+				Log:       log.NullLogger{},
+				Scheme:    scheme,
+				runner:    runner,
+				available: tc.available,
+			}
+
+			_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test"}})
+			assert.NoError(t, err)
+
+			actual := &optimizev1beta2.Trial{}
+			if assert.NoError(t, r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "test"}, actual)) {
+				failed := trial.CheckCondition(&actual.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue)
+				assert.Equal(t, tc.expectedReason != "", failed)
+				for _, c := range actual.Status.Conditions {
+					if c.Type == optimizev1beta2.TrialFailed {
+						assert.Equal(t, tc.expectedReason, c.Reason)
+					}
+				}
+			}
+		})
+	}
+}
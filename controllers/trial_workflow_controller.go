@@ -0,0 +1,64 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TrialWorkflowReconciler reconciles a Trial's Argo Workflow
+type TrialWorkflowReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=argoproj.io,resources=workflows,verbs=get;list;watch;create
+
+func (r *TrialWorkflowReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return (&trialRunReconciler{
+		Client: r.Client,
+		Log:    r.Log,
+		Scheme: r.Scheme,
+		runner: trialRunner{
+			name:              "trial-workflow",
+			gvk:               trial.WorkflowGroupVersionKind,
+			product:           "Argo Workflows",
+			unavailableReason: "WorkflowUnavailable",
+			isRunBy:           func(t *optimizev1beta2.Trial) bool { return t.Spec.ArgoWorkflow != nil },
+			newRun:            trial.NewArgoWorkflow,
+			runStatus:         workflowStatus,
+		},
+	}).SetupWithManager(mgr)
+}
+
+// workflowStatus returns the status of the trial run from an Argo Workflow
+func workflowStatus(wf *unstructured.Unstructured) (startedAt, finishedAt *metav1.Time, failed bool, reason, message string) {
+	phase, startedAt, finishedAt, message := trial.WorkflowStatus(wf)
+	if phase == trial.WorkflowFailed || phase == trial.WorkflowError {
+		return startedAt, finishedAt, true, "Workflow" + phase, message
+	}
+	return startedAt, finishedAt, false, "", ""
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/controller"
+	"github.com/thestormforge/optimize-controller/v2/internal/metric"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultUsageSamplePeriod is the amount of time between resource usage samples, metrics-server resolution is
+// typically 15 seconds so there is no point sampling more frequently
+const defaultUsageSamplePeriod = 15 * time.Second
+
+// usageSamplePeriod returns the configured amount of time between resource usage samples.
+func usageSamplePeriod(log logr.Logger) time.Duration {
+	period, ok := os.LookupEnv("STORMFORGE_USAGE_SAMPLE_PERIOD")
+	if !ok {
+		return defaultUsageSamplePeriod
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		log.Info("Ignoring invalid usage sample period", "usageSamplePeriod", period)
+		return defaultUsageSamplePeriod
+	}
+
+	log.Info("Using custom usage sample period", "usageSamplePeriod", period)
+	return d
+}
+
+// UsageReconciler samples the resource usage of the patched pods from the Kubernetes metrics API while a trial run
+// is in progress, the summary is available to "usage" metrics
+type UsageReconciler struct {
+	client.Client
+	Log logr.Logger
+
+	// The metrics API does not support watches so pod metrics must be read directly
+	apiReader client.Reader
+	period    time.Duration
+}
+
+// +kubebuilder:rbac:groups=optimize.stormforge.io,resources=trials,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups="",resources=pods,verbs=list
+// +kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+
+func (r *UsageReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	now := metav1.Now()
+
+	t := &optimizev1beta2.Trial{}
+	if err := r.Get(ctx, req.NamespacedName, t); err != nil || r.ignoreTrial(t) {
+		return ctrl.Result{}, controller.IgnoreNotFound(err)
+	}
+
+	if result, err := r.sampleUsage(ctx, t, &now); result != nil {
+		return *result, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.period}, nil
+}
+
+func (r *UsageReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.apiReader = mgr.GetAPIReader()
+	r.period = usageSamplePeriod(r.Log)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("usage").
+		For(&optimizev1beta2.Trial{}).
+		Complete(r)
+}
+
+// ignoreTrial determines which trial objects can be ignored by this reconciler
+func (r *UsageReconciler) ignoreTrial(t *optimizev1beta2.Trial) bool {
+	// Ignore deleted trials
+	if !t.DeletionTimestamp.IsZero() {
+		return true
+	}
+
+	// Ignore failed trials
+	if trial.CheckCondition(&t.Status, optimizev1beta2.TrialFailed, corev1.ConditionTrue) {
+		return true
+	}
+
+	// Only sample usage while the trial run is in progress
+	return t.Status.StartTime == nil || t.Status.CompletionTime != nil
+}
+
+// sampleUsage records the current resource usage of the patched pods on the trial
+func (r *UsageReconciler) sampleUsage(ctx context.Context, t *optimizev1beta2.Trial, probeTime *metav1.Time) (*ctrl.Result, error) {
+	usage, err := metric.TrialResourceUsage(t)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	// Other updates to the trial should not produce additional samples
+	if d := usage.LastSampleTime.Add(r.period).Sub(probeTime.Time); d > 0 {
+		return &ctrl.Result{RequeueAfter: d}, nil
+	}
+
+	pods, err := r.patchedPods(ctx, t)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	var cpu, memory, cpuRequests, memoryRequests float64
+	sampled := make(map[types.UID]bool)
+	for _, p := range pods {
+		podMetricsList := &metricsv1beta1.PodMetricsList{}
+		if err := r.apiReader.List(ctx, podMetricsList, client.InNamespace(p.namespace), client.MatchingLabelsSelector{Selector: p.selector}); err != nil {
+			// Missing or unavailable metrics should not interfere with the trial
+			r.Log.Info("Unable to sample resource usage", "trial", fmt.Sprintf("%s/%s", t.Namespace, t.Name), "error", err.Error())
+			return &ctrl.Result{RequeueAfter: r.period}, nil
+		}
+
+		podList := &corev1.PodList{}
+		if err := r.List(ctx, podList, client.InNamespace(p.namespace), client.MatchingLabelsSelector{Selector: p.selector}); err != nil {
+			return &ctrl.Result{}, err
+		}
+		podsByName := make(map[string]*corev1.Pod, len(podList.Items))
+		for i := range podList.Items {
+			podsByName[podList.Items[i].Name] = &podList.Items[i]
+		}
+
+		for i := range podMetricsList.Items {
+			pm := &podMetricsList.Items[i]
+			pod, ok := podsByName[pm.Name]
+			if !ok || sampled[pod.UID] {
+				continue
+			}
+			sampled[pod.UID] = true
+
+			for _, c := range pm.Containers {
+				cpu += float64(c.Usage.Cpu().MilliValue()) / 1000
+				memory += float64(c.Usage.Memory().Value())
+			}
+			requests := podRequests(pod)
+			cpuRequests += float64(requests.Cpu().MilliValue()) / 1000
+			memoryRequests += float64(requests.Memory().Value())
+		}
+	}
+
+	// Wait for the metrics API to report on at least one pod
+	if len(sampled) == 0 {
+		return &ctrl.Result{RequeueAfter: r.period}, nil
+	}
+
+	usage.Add(*probeTime, cpu, memory, cpuRequests, memoryRequests)
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	if t.Annotations == nil {
+		t.Annotations = make(map[string]string)
+	}
+	t.Annotations[optimizev1beta2.AnnotationResourceUsage] = string(data)
+	if err := r.Update(ctx, t); err != nil {
+		return controller.RequeueConflict(err)
+	}
+
+	return &ctrl.Result{RequeueAfter: r.period}, nil
+}
+
+// patchedPod identifies the pods of a patched object
+type patchedPod struct {
+	namespace string
+	selector  labels.Selector
+}
+
+// patchedPods returns the pods of the objects patched by the trial
+func (r *UsageReconciler) patchedPods(ctx context.Context, t *optimizev1beta2.Trial) ([]patchedPod, error) {
+	var result []patchedPod
+	for i := range t.Status.PatchOperations {
+		ref := &t.Status.PatchOperations[i].TargetRef
+		if trial.IsTrialJobReference(t, ref) {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(ref.GroupVersionKind())
+		if err := r.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, obj); err != nil {
+			if err := controller.IgnoreNotFound(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		sel, err := podSelector(obj)
+		if err != nil {
+			return nil, err
+		}
+		if sel != nil && !sel.Empty() {
+			result = append(result, patchedPod{namespace: obj.GetNamespace(), selector: sel})
+		}
+	}
+	return result, nil
+}
+
+// podSelector returns the selector for the pods of a workload, nil is returned for objects that do not manage pods.
+func podSelector(obj *unstructured.Unstructured) (labels.Selector, error) {
+	// Pods are matched using all of their labels
+	if obj.GetKind() == "Pod" {
+		return labels.SelectorFromSet(obj.GetLabels()), nil
+	}
+
+	// Workloads (e.g. deployments, stateful sets, daemon sets, etc.) have a standard pod selector
+	m, ok, err := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	ls := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, ls); err != nil {
+		return nil, err
+	}
+	return metav1.LabelSelectorAsSelector(ls)
+}
+
+// podRequests returns the sum of the container resource requests for a pod.
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		for name, q := range c.Resources.Requests {
+			total := result[name]
+			total.Add(q)
+			result[name] = total
+		}
+	}
+	return result
+}
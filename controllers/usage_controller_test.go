@@ -0,0 +1,118 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/metric"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestUsageReconciler_SampleUsage(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = optimizev1beta2.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = metricsv1beta1.AddToScheme(scheme)
+
+	now := metav1.Now()
+	start := metav1.NewTime(now.Add(-time.Minute))
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-001"},
+		Status: optimizev1beta2.TrialStatus{
+			StartTime: &start,
+			PatchOperations: []optimizev1beta2.PatchOperation{
+				{TargetRef: corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"}},
+			},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+	pod := func(name, app string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID("uid-" + name), Labels: map[string]string{"app": app}},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name: "main",
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("256Mi"),
+					}},
+				}},
+			},
+		}
+	}
+	podMetrics := func(name, app, cpu string) *metricsv1beta1.PodMetrics {
+		return &metricsv1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{"app": app}},
+			Containers: []metricsv1beta1.ContainerMetrics{{
+				Name:  "main",
+				Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse("128Mi")},
+			}},
+		}
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme,
+		trial, deployment,
+		pod("web-1", "web"), pod("web-2", "web"), pod("db-1", "db"),
+		podMetrics("web-1", "web", "250m"), podMetrics("web-2", "web", "750m"), podMetrics("db-1", "db", "1"),
+	)
+	r := &UsageReconciler{Client: c, Log: log.NullLogger{}, apiReader: c, period: defaultUsageSamplePeriod}
+
+	ctx := context.TODO()
+	result, err := r.sampleUsage(ctx, trial, &now)
+	if assert.NoError(t, err) && assert.NotNil(t, result) {
+		assert.Equal(t, defaultUsageSamplePeriod, result.RequeueAfter)
+	}
+
+	// Only the deployment pods should be sampled
+	updated := &optimizev1beta2.Trial{}
+	if assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-001"}, updated)) {
+		u, err := metric.TrialResourceUsage(updated)
+		if assert.NoError(t, err) {
+			values := u.Values()
+			assert.Equal(t, 1, u.Samples)
+			assert.Equal(t, 1.0, values["cpu-avg"])
+			assert.Equal(t, 100.0, values["cpu-utilization"])
+			assert.Equal(t, 50.0, values["memory-utilization"])
+		}
+	}
+
+	// Sampling again before the period elapses only waits for the remainder of the period
+	result, err = r.sampleUsage(ctx, updated, &now)
+	if assert.NoError(t, err) && assert.NotNil(t, result) {
+		assert.True(t, result.RequeueAfter > 0 && result.RequeueAfter <= defaultUsageSamplePeriod)
+	}
+}
@@ -44,6 +44,55 @@ var (
 		Name: "optimize_experiment_active_trials_total",
 		Help: "Total number of active trials present for an experiment",
 	}, []string{"experiment"})
+
+	// ExperimentTrialsRequested is a Prometheus counter metric which holds the number
+	// of trials requested from the Experiments API for an experiment
+	ExperimentTrialsRequested = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "optimize_experiment_trials_requested_total",
+		Help: "Total number of trials requested from the Experiments API for an experiment",
+	}, []string{"experiment"})
+
+	// ExperimentTrialsReported is a Prometheus counter metric which holds the number
+	// of trials reported to the Experiments API for an experiment
+	ExperimentTrialsReported = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "optimize_experiment_trials_reported_total",
+		Help: "Total number of trials reported to the Experiments API for an experiment",
+	}, []string{"experiment"})
+
+	// ExperimentDrift is a Prometheus gauge metric which holds the largest relative
+	// difference between the live workloads and the best trial of a completed experiment
+	ExperimentDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "optimize_experiment_drift_ratio",
+		Help: "Largest relative difference between the live workloads and the best trial of an experiment",
+	}, []string{"namespace", "experiment"})
+
+	// TrialQuotaLimit is a Prometheus gauge metric which holds the configured
+	// number of trials allowed by the subscription
+	TrialQuotaLimit = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "optimize_trial_quota_limit",
+		Help: "Number of trials allowed by the subscription",
+	})
+
+	// TrialQuotaUsed is a Prometheus gauge metric which holds the number of
+	// trials consumed against the subscription
+	TrialQuotaUsed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "optimize_trial_quota_used",
+		Help: "Number of trials consumed against the subscription",
+	})
+
+	// ApplicationActivityConnected is a Prometheus gauge metric which is set to
+	// one while the application poller is subscribed to the activity feed
+	ApplicationActivityConnected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "optimize_application_activity_connected",
+		Help: "Whether the application poller is connected to the activity feed",
+	})
+
+	// ApplicationActivityReconnects is a Prometheus counter metric which holds the
+	// number of times the application poller reconnected to the activity feed
+	ApplicationActivityReconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "optimize_application_activity_reconnects_total",
+		Help: "Total number of attempts to reconnect to the application activity feed",
+	})
 )
 
 func init() {
@@ -51,5 +100,12 @@ func init() {
 		ReconcileConflictErrors,
 		ExperimentTrials,
 		ExperimentActiveTrials,
+		ExperimentTrialsRequested,
+		ExperimentTrialsReported,
+		ExperimentDrift,
+		TrialQuotaLimit,
+		TrialQuotaUsed,
+		ApplicationActivityConnected,
+		ApplicationActivityReconnects,
 	)
 }
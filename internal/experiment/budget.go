@@ -0,0 +1,178 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// defaultCostMetric is the name of the metric used to compute the experiment cost when one is not specified.
+const defaultCostMetric = "cost"
+
+// CheckBudget stops the experiment from starting new trials (including requesting new suggestions from the server)
+// once the trial, duration or cost budget is exhausted. The trial and cost budget consumed so far must already be
+// recorded using RecordBudget. After the in-flight trials finish, the experiment is completed
+// with a summary of the best trial so far. Returns true only if changes were necessary.
+func CheckBudget(exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList, now time.Time) bool {
+	if IsFinished(exp) {
+		return false
+	}
+
+	var dirty bool
+	if !checkCondition(&exp.Status, optimizev1beta2.ExperimentBudgetExhausted, corev1.ConditionTrue) {
+		msg := exhaustedBudget(exp, now)
+		if msg == "" {
+			return false
+		}
+
+		exp.SetReplicas(0)
+		delete(exp.GetAnnotations(), optimizev1beta2.AnnotationNextTrialURL)
+		ApplyCondition(&exp.Status, optimizev1beta2.ExperimentBudgetExhausted, corev1.ConditionTrue, "BudgetExhausted", msg, nil)
+		dirty = true
+	}
+
+	// Wait for the in-flight trials to finish
+	for i := range trialList.Items {
+		t := &trialList.Items[i]
+		if trial.IsActive(t) && !trial.IsAbandoned(t) {
+			return dirty
+		}
+	}
+
+	ApplyCondition(&exp.Status, optimizev1beta2.ExperimentComplete, corev1.ConditionTrue, "BudgetExhausted", bestTrialMessage(exp, trialList, "Budget exhausted"), nil)
+	return true
+}
+
+// Values of the budget recorded annotation
+const (
+	budgetRecordedTrial = "trial"
+	budgetRecordedCost  = "cost"
+)
+
+// RecordBudget adds the trials (and the cost of the completed trials) which have not been recorded yet to the budget
+// consumed by the experiment. The consumed budget is kept in the experiment status so trials are still counted after
+// they are deleted. Returns the trials whose budget recorded annotation was changed and must be persisted.
+func RecordBudget(exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) []*optimizev1beta2.Trial {
+	name := costMetric(exp)
+
+	var recorded []*optimizev1beta2.Trial
+	var cost float64
+	for i := range trialList.Items {
+		t := &trialList.Items[i]
+		before := t.GetAnnotations()[optimizev1beta2.AnnotationBudgetRecorded]
+		after := before
+
+		// Retries of failed trials are excluded from the trial count
+		if after == "" {
+			if !trial.IsRetry(t) {
+				exp.Status.ConsumedTrials++
+			}
+			after = budgetRecordedTrial
+		}
+
+		if after == budgetRecordedTrial && trial.CheckCondition(&t.Status, optimizev1beta2.TrialComplete, corev1.ConditionTrue) {
+			cost += trialCost(t, name)
+			after = budgetRecordedCost
+		}
+
+		if after != before {
+			if t.Annotations == nil {
+				t.Annotations = make(map[string]string)
+			}
+			t.Annotations[optimizev1beta2.AnnotationBudgetRecorded] = after
+			recorded = append(recorded, t)
+		}
+	}
+
+	// Use milli-precision to avoid rounding away fractional costs
+	if cost != 0 {
+		total := int64(cost * 1000)
+		if exp.Status.ConsumedCost != nil {
+			total += exp.Status.ConsumedCost.MilliValue()
+		}
+		exp.Status.ConsumedCost = resource.NewMilliQuantity(total, resource.DecimalSI)
+	}
+
+	return recorded
+}
+
+// IsBudgetRecorded checks to see if the trial was fully recorded in the consumed budget of the experiment.
+func IsBudgetRecorded(t *optimizev1beta2.Trial) bool {
+	switch t.GetAnnotations()[optimizev1beta2.AnnotationBudgetRecorded] {
+	case budgetRecordedCost:
+		return true
+	case budgetRecordedTrial:
+		// Only completed trials contribute to the cost
+		return !trial.CheckCondition(&t.Status, optimizev1beta2.TrialComplete, corev1.ConditionTrue)
+	default:
+		return false
+	}
+}
+
+// exhaustedBudget returns a description of the first exhausted budget, an empty string if the budget remains.
+func exhaustedBudget(exp *optimizev1beta2.Experiment, now time.Time) string {
+	if max := exp.Spec.MaxTrials; max != nil && exp.Status.ConsumedTrials >= *max {
+		return fmt.Sprintf("Maximum of %d trials reached, no new trials will be started", *max)
+	}
+
+	if deadline, ok := activeDeadline(exp); ok && !now.Before(deadline) {
+		return fmt.Sprintf("Active deadline of %d seconds reached, no new trials will be started", *exp.Spec.ActiveDeadlineSeconds)
+	}
+
+	if max, cost := exp.Spec.MaxCost, exp.Status.ConsumedCost; max != nil && cost != nil && cost.Cmp(*max) >= 0 {
+		return fmt.Sprintf("Maximum cost of %s reached (%s), no new trials will be started", max.String(), cost.String())
+	}
+
+	return ""
+}
+
+// activeDeadline returns the time after which the experiment should not start new trials.
+func activeDeadline(exp *optimizev1beta2.Experiment) (time.Time, bool) {
+	if exp.Spec.ActiveDeadlineSeconds == nil || exp.CreationTimestamp.IsZero() {
+		return time.Time{}, false
+	}
+	return exp.CreationTimestamp.Add(time.Duration(*exp.Spec.ActiveDeadlineSeconds) * time.Second), true
+}
+
+// costMetric returns the name of the metric used to compute the experiment cost.
+func costMetric(exp *optimizev1beta2.Experiment) string {
+	if exp.Spec.CostMetric != "" {
+		return exp.Spec.CostMetric
+	}
+	return defaultCostMetric
+}
+
+// trialCost returns the value of the cost metric for a trial.
+func trialCost(t *optimizev1beta2.Trial, name string) float64 {
+	var total float64
+	for _, v := range t.Spec.Values {
+		if v.Name != name {
+			continue
+		}
+		if value, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			total += value
+		}
+	}
+	return total
+}
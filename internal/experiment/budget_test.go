@@ -0,0 +1,234 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckBudget(t *testing.T) {
+	now := time.Date(2021, 6, 7, 8, 0, 0, 0, time.UTC)
+	created := metav1.NewTime(now.Add(-time.Hour))
+	two := int32(2)
+	tenMinutes := int64(600)
+	oneDay := int64(86400)
+	maxCost := resource.MustParse("10")
+
+	newTrial := func(name, cost string, complete bool) optimizev1beta2.Trial {
+		t := optimizev1beta2.Trial{}
+		t.Name = name
+		t.Spec.Values = []optimizev1beta2.Value{{Name: "cost", Value: cost}}
+		if complete {
+			t.Status.Conditions = []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue}}
+		}
+		return t
+	}
+
+	cases := []struct {
+		desc            string
+		spec            optimizev1beta2.ExperimentSpec
+		status          optimizev1beta2.ExperimentStatus
+		trials          []optimizev1beta2.Trial
+		expectedDirty   bool
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			desc: "no budget",
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-000", "10", true),
+			},
+		},
+		{
+			desc: "max trials remaining",
+			spec: optimizev1beta2.ExperimentSpec{MaxTrials: &two},
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-000", "10", true),
+			},
+		},
+		{
+			desc: "max trials retry",
+			spec: optimizev1beta2.ExperimentSpec{MaxTrials: &two},
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-000", "10", true),
+				func() optimizev1beta2.Trial {
+					t := newTrial("test-000-retry1", "", false)
+					t.Annotations = map[string]string{optimizev1beta2.AnnotationInfrastructureRetries: "1"}
+					return t
+				}(),
+			},
+		},
+		{
+			desc: "max trials in-flight",
+			spec: optimizev1beta2.ExperimentSpec{MaxTrials: &two},
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-000", "10", true),
+				newTrial("test-001", "", false),
+			},
+			expectedDirty: true,
+		},
+		{
+			desc: "max trials wrap up",
+			spec: optimizev1beta2.ExperimentSpec{MaxTrials: &two},
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-000", "10", true),
+				newTrial("test-001", "5.5", true),
+			},
+			expectedDirty:   true,
+			expectedReason:  "BudgetExhausted",
+			expectedMessage: "Budget exhausted after 2 completed trials, best trial so far is test-001 (cost=5.5)",
+		},
+		{
+			desc:   "max trials deleted",
+			spec:   optimizev1beta2.ExperimentSpec{MaxTrials: &two},
+			status: optimizev1beta2.ExperimentStatus{ConsumedTrials: 1},
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-001", "", false),
+			},
+			expectedDirty: true,
+		},
+		{
+			desc: "before active deadline",
+			spec: optimizev1beta2.ExperimentSpec{ActiveDeadlineSeconds: &oneDay},
+		},
+		{
+			desc:            "active deadline",
+			spec:            optimizev1beta2.ExperimentSpec{ActiveDeadlineSeconds: &tenMinutes},
+			expectedDirty:   true,
+			expectedReason:  "BudgetExhausted",
+			expectedMessage: "Budget exhausted before any trials completed",
+		},
+		{
+			desc: "max cost remaining",
+			spec: optimizev1beta2.ExperimentSpec{MaxCost: &maxCost},
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-000", "4.5", true),
+				newTrial("test-001", "5", true),
+				newTrial("test-002", "", false),
+			},
+		},
+		{
+			desc: "max cost",
+			spec: optimizev1beta2.ExperimentSpec{MaxCost: &maxCost},
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-000", "4.5", true),
+				newTrial("test-001", "5.5", true),
+			},
+			expectedDirty:   true,
+			expectedReason:  "BudgetExhausted",
+			expectedMessage: "Budget exhausted after 2 completed trials, best trial so far is test-000 (cost=4.5)",
+		},
+		{
+			desc:   "max cost deleted",
+			spec:   optimizev1beta2.ExperimentSpec{MaxCost: &maxCost},
+			status: optimizev1beta2.ExperimentStatus{ConsumedCost: resource.NewQuantity(8, resource.DecimalSI)},
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-002", "2", true),
+			},
+			expectedDirty:   true,
+			expectedReason:  "BudgetExhausted",
+			expectedMessage: "Budget exhausted after 1 completed trials, best trial so far is test-002 (cost=2)",
+		},
+		{
+			desc: "custom cost metric",
+			spec: optimizev1beta2.ExperimentSpec{MaxCost: &maxCost, CostMetric: "spend"},
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-000", "4.5", true),
+				newTrial("test-001", "5.5", true),
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{}
+			exp.CreationTimestamp = created
+			exp.Annotations = map[string]string{optimizev1beta2.AnnotationNextTrialURL: "http://example.com/next"}
+			exp.Spec = c.spec
+			exp.Spec.Metrics = []optimizev1beta2.Metric{{Name: "cost", Minimize: true}}
+			exp.Status = c.status
+
+			trialList := &optimizev1beta2.TrialList{Items: c.trials}
+			RecordBudget(exp, trialList)
+			dirty := CheckBudget(exp, trialList, now)
+			assert.Equal(t, c.expectedDirty, dirty)
+			if !c.expectedDirty {
+				assert.Empty(t, exp.Status.Conditions)
+				assert.Contains(t, exp.Annotations, optimizev1beta2.AnnotationNextTrialURL)
+				return
+			}
+
+			assert.Equal(t, int32(0), exp.Replicas())
+			assert.NotContains(t, exp.Annotations, optimizev1beta2.AnnotationNextTrialURL)
+			assert.True(t, checkCondition(&exp.Status, optimizev1beta2.ExperimentBudgetExhausted, corev1.ConditionTrue))
+			assert.Equal(t, c.expectedReason != "", IsFinished(exp))
+			for _, cc := range exp.Status.Conditions {
+				if cc.Type == optimizev1beta2.ExperimentComplete {
+					assert.Equal(t, c.expectedReason, cc.Reason)
+					assert.Equal(t, c.expectedMessage, cc.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordBudget(t *testing.T) {
+	exp := &optimizev1beta2.Experiment{}
+	trialList := &optimizev1beta2.TrialList{Items: []optimizev1beta2.Trial{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-000"},
+			Spec:       optimizev1beta2.TrialSpec{Values: []optimizev1beta2.Value{{Name: "cost", Value: "1.5"}}},
+			Status:     optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-001"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-001-retry1", Annotations: map[string]string{optimizev1beta2.AnnotationInfrastructureRetries: "1"}},
+		},
+	}}
+
+	recorded := RecordBudget(exp, trialList)
+	assert.Len(t, recorded, 3)
+	assert.Equal(t, int32(2), exp.Status.ConsumedTrials)
+	assert.Equal(t, "1500m", exp.Status.ConsumedCost.String())
+	assert.True(t, IsBudgetRecorded(&trialList.Items[0]))
+	assert.True(t, IsBudgetRecorded(&trialList.Items[1]))
+
+	// Recording again does not count the same trials twice
+	assert.Empty(t, RecordBudget(exp, trialList))
+	assert.Equal(t, int32(2), exp.Status.ConsumedTrials)
+
+	// The cost is added once the trial completes
+	trialList.Items[1].Spec.Values = []optimizev1beta2.Value{{Name: "cost", Value: "2"}}
+	trialList.Items[1].Status.Conditions = []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue}}
+	assert.False(t, IsBudgetRecorded(&trialList.Items[1]))
+	assert.Len(t, RecordBudget(exp, trialList), 1)
+	assert.Equal(t, int32(2), exp.Status.ConsumedTrials)
+	assert.Equal(t, "3500m", exp.Status.ConsumedCost.String())
+
+	// Deleted trials remain in the consumed budget
+	trialList.Items = nil
+	assert.Empty(t, RecordBudget(exp, trialList))
+	assert.Equal(t, int32(2), exp.Status.ConsumedTrials)
+}
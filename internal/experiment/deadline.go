@@ -0,0 +1,150 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// CheckDeadline stops the experiment from starting new trials once the deadline has passed. After the in-flight
+// trials finish, the experiment is completed with a summary of the best trial so far. Returns true only if changes
+// were necessary.
+func CheckDeadline(exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList, now time.Time) bool {
+	if exp.Spec.Deadline == nil || IsFinished(exp) || now.Before(exp.Spec.Deadline.Time) {
+		return false
+	}
+
+	var dirty bool
+	if !checkCondition(&exp.Status, optimizev1beta2.ExperimentDeadlineReached, corev1.ConditionTrue) {
+		exp.SetReplicas(0)
+		msg := fmt.Sprintf("Deadline of %s reached, no new trials will be started", exp.Spec.Deadline.UTC().Format(time.RFC3339))
+		ApplyCondition(&exp.Status, optimizev1beta2.ExperimentDeadlineReached, corev1.ConditionTrue, "DeadlineReached", msg, nil)
+		dirty = true
+	}
+
+	// Wait for the in-flight trials to finish
+	for i := range trialList.Items {
+		t := &trialList.Items[i]
+		if trial.IsActive(t) && !trial.IsAbandoned(t) {
+			return dirty
+		}
+	}
+
+	ApplyCondition(&exp.Status, optimizev1beta2.ExperimentComplete, corev1.ConditionTrue, "DeadlineReached", bestTrialMessage(exp, trialList, "Deadline reached"), nil)
+	return true
+}
+
+// UntilDeadline returns the amount of time remaining before the earliest of the experiment deadline or active
+// deadline, zero if there is no deadline.
+func UntilDeadline(exp *optimizev1beta2.Experiment, now time.Time) time.Duration {
+	if IsFinished(exp) {
+		return 0
+	}
+
+	var until time.Duration
+	if exp.Spec.Deadline != nil {
+		if d := exp.Spec.Deadline.Sub(now); d > 0 {
+			until = d
+		}
+	}
+	if deadline, ok := activeDeadline(exp); ok {
+		if d := deadline.Sub(now); d > 0 && (until == 0 || d < until) {
+			until = d
+		}
+	}
+	return until
+}
+
+// bestTrialMessage returns a description of the best completed trial for single objective experiments, the supplied
+// summary describes why the experiment is ending.
+func bestTrialMessage(exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList, summary string) string {
+	best, objective, bestValue, completed := bestTrial(exp, trialList)
+	switch {
+	case completed == 0:
+		return fmt.Sprintf("%s before any trials completed", summary)
+	case best == nil:
+		return fmt.Sprintf("%s after %d completed trials", summary, completed)
+	default:
+		return fmt.Sprintf("%s after %d completed trials, best trial so far is %s (%s=%s)",
+			summary, completed, best.Name, objective.Name, strconv.FormatFloat(bestValue, 'g', -1, 64))
+	}
+}
+
+// BestTrial returns the best completed trial for single objective experiments, nil if there is no best trial.
+func BestTrial(exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) *optimizev1beta2.Trial {
+	best, _, _, _ := bestTrial(exp, trialList)
+	return best
+}
+
+// bestTrial returns the best completed trial (and its objective value) for single objective experiments along with
+// the total number of completed trials. The best trial is nil if the experiment does not have exactly one objective.
+func bestTrial(exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) (*optimizev1beta2.Trial, *optimizev1beta2.Metric, float64, int) {
+	var objectives []*optimizev1beta2.Metric
+	for i := range exp.Spec.Metrics {
+		if m := &exp.Spec.Metrics[i]; m.Optimize == nil || *m.Optimize {
+			objectives = append(objectives, m)
+		}
+	}
+
+	var completed int
+	var best *optimizev1beta2.Trial
+	var bestValue float64
+	for i := range trialList.Items {
+		t := &trialList.Items[i]
+		if !trial.CheckCondition(&t.Status, optimizev1beta2.TrialComplete, corev1.ConditionTrue) {
+			continue
+		}
+		completed++
+
+		if len(objectives) != 1 {
+			continue
+		}
+		for _, v := range t.Spec.Values {
+			if v.Name != objectives[0].Name {
+				continue
+			}
+			value, err := strconv.ParseFloat(v.Value, 64)
+			if err != nil {
+				continue
+			}
+			if best == nil || (objectives[0].Minimize && value < bestValue) || (!objectives[0].Minimize && value > bestValue) {
+				best, bestValue = t, value
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, nil, 0, completed
+	}
+	return best, objectives[0], bestValue, completed
+}
+
+// checkCondition checks to see if the experiment has a condition with the specified status.
+func checkCondition(status *optimizev1beta2.ExperimentStatus, conditionType optimizev1beta2.ExperimentConditionType, conditionStatus corev1.ConditionStatus) bool {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return status.Conditions[i].Status == conditionStatus
+		}
+	}
+	return false
+}
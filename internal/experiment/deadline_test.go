@@ -0,0 +1,152 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckDeadline(t *testing.T) {
+	now := time.Date(2021, 6, 7, 8, 0, 0, 0, time.UTC)
+	deadline := metav1.NewTime(now.Add(-time.Minute))
+	future := metav1.NewTime(now.Add(time.Hour))
+
+	newTrial := func(name, duration string, complete bool) optimizev1beta2.Trial {
+		t := optimizev1beta2.Trial{}
+		t.Name = name
+		t.Spec.Values = []optimizev1beta2.Value{{Name: "duration", Value: duration}}
+		if complete {
+			t.Status.Conditions = []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue}}
+		}
+		return t
+	}
+
+	cases := []struct {
+		desc            string
+		deadline        *metav1.Time
+		trials          []optimizev1beta2.Trial
+		expectedDirty   bool
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			desc: "no deadline",
+		},
+		{
+			desc:     "before deadline",
+			deadline: &future,
+		},
+		{
+			desc:     "in-flight trials",
+			deadline: &deadline,
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-000", "10", true),
+				newTrial("test-001", "", false),
+			},
+			expectedDirty: true,
+		},
+		{
+			desc:     "wrap up",
+			deadline: &deadline,
+			trials: []optimizev1beta2.Trial{
+				newTrial("test-000", "10", true),
+				newTrial("test-001", "5.5", true),
+				newTrial("test-002", "7", true),
+			},
+			expectedDirty:   true,
+			expectedReason:  "DeadlineReached",
+			expectedMessage: "Deadline reached after 3 completed trials, best trial so far is test-001 (duration=5.5)",
+		},
+		{
+			desc:            "no completed trials",
+			deadline:        &deadline,
+			expectedDirty:   true,
+			expectedReason:  "DeadlineReached",
+			expectedMessage: "Deadline reached before any trials completed",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{}
+			exp.Spec.Deadline = c.deadline
+			exp.Spec.Metrics = []optimizev1beta2.Metric{{Name: "duration", Minimize: true}}
+
+			dirty := CheckDeadline(exp, &optimizev1beta2.TrialList{Items: c.trials}, now)
+			assert.Equal(t, c.expectedDirty, dirty)
+			if !c.expectedDirty {
+				assert.Empty(t, exp.Status.Conditions)
+				return
+			}
+
+			assert.Equal(t, int32(0), exp.Replicas())
+			assert.True(t, checkCondition(&exp.Status, optimizev1beta2.ExperimentDeadlineReached, corev1.ConditionTrue))
+			assert.Equal(t, c.expectedReason != "", IsFinished(exp))
+			for _, cc := range exp.Status.Conditions {
+				if cc.Type == optimizev1beta2.ExperimentComplete {
+					assert.Equal(t, c.expectedReason, cc.Reason)
+					assert.Equal(t, c.expectedMessage, cc.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestUntilDeadline(t *testing.T) {
+	now := time.Date(2021, 6, 7, 8, 0, 0, 0, time.UTC)
+	created := metav1.NewTime(now.Add(-time.Hour))
+	deadline := metav1.NewTime(now.Add(time.Hour))
+	twoHours := int64(7200)
+	oneDay := int64(86400)
+
+	cases := []struct {
+		desc     string
+		spec     optimizev1beta2.ExperimentSpec
+		expected time.Duration
+	}{
+		{
+			desc: "no deadline",
+		},
+		{
+			desc:     "deadline",
+			spec:     optimizev1beta2.ExperimentSpec{Deadline: &deadline},
+			expected: time.Hour,
+		},
+		{
+			desc:     "active deadline",
+			spec:     optimizev1beta2.ExperimentSpec{ActiveDeadlineSeconds: &twoHours},
+			expected: time.Hour,
+		},
+		{
+			desc:     "earliest deadline",
+			spec:     optimizev1beta2.ExperimentSpec{Deadline: &deadline, ActiveDeadlineSeconds: &oneDay},
+			expected: time.Hour,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{Spec: c.spec}
+			exp.CreationTimestamp = created
+			assert.Equal(t, c.expected, UntilDeadline(exp, now))
+		})
+	}
+}
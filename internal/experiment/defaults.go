@@ -0,0 +1,79 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultSetupServiceAccountName is the name of the service account created in each templated trial namespace to
+// run setup tasks, it matches the name used for generated experiments
+const defaultSetupServiceAccountName = "optimize-setup"
+
+// defaultMetricDelays are the amounts of time to wait before collecting metrics from sources which ingest samples
+// asynchronously: two scrape intervals of the built-in Prometheus or the typical Datadog ingestion latency
+var defaultMetricDelays = map[optimizev1beta2.MetricType]time.Duration{
+	optimizev1beta2.MetricPrometheus:          20 * time.Second,
+	optimizev1beta2.MetricPrometheusHistogram: 20 * time.Second,
+	optimizev1beta2.MetricDatadog:             time.Minute,
+}
+
+// ApplyDefaults fills in the conventional values of an experiment that were not explicitly specified: the standard
+// trial labels, setup service account and image, and metric collection delays. The setup image is used for setup
+// tasks that do not specify their own image. Returns true only if changes were necessary.
+func ApplyDefaults(exp *optimizev1beta2.Experiment, setupImage string) bool {
+	var dirty bool
+	tt := &exp.Spec.TrialTemplate
+
+	// Label the trials with the experiment name so the default trial selector matches
+	if exp.Spec.Selector == nil && exp.Name != "" && tt.Labels[optimizev1beta2.LabelExperiment] == "" {
+		if tt.Labels == nil {
+			tt.Labels = make(map[string]string, 1)
+		}
+		tt.Labels[optimizev1beta2.LabelExperiment] = exp.Name
+		dirty = true
+	}
+
+	// Setup tasks in templated namespaces run using a service account created in the namespace
+	if exp.Spec.NamespaceTemplate != nil && len(tt.Spec.SetupTasks) > 0 && tt.Spec.SetupServiceAccountName == "" {
+		tt.Spec.SetupServiceAccountName = defaultSetupServiceAccountName
+		dirty = true
+	}
+
+	// Pin the setup task image to the one matching the controller (a command is only honored with an explicit image)
+	for i := range tt.Spec.SetupTasks {
+		task := &tt.Spec.SetupTasks[i]
+		if task.Image == "" && len(task.Command) == 0 && setupImage != "" {
+			task.Image = setupImage
+			dirty = true
+		}
+	}
+
+	// Give metric sources with ingestion latency time to catch up with the end of the trial run
+	for i := range exp.Spec.Metrics {
+		m := &exp.Spec.Metrics[i]
+		if d, ok := defaultMetricDelays[m.Type]; ok && m.Delay == nil {
+			m.Delay = &metav1.Duration{Duration: d}
+			dirty = true
+		}
+	}
+
+	return dirty
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	cases := []struct {
+		desc          string
+		exp           optimizev1beta2.Experiment
+		expectedDirty bool
+		expected      optimizev1beta2.Experiment
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "trial labels",
+			exp: optimizev1beta2.Experiment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			},
+			expectedDirty: true,
+			expected: optimizev1beta2.Experiment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: optimizev1beta2.ExperimentSpec{
+					TrialTemplate: optimizev1beta2.TrialTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{optimizev1beta2.LabelExperiment: "test"}},
+					},
+				},
+			},
+		},
+		{
+			desc: "custom selector",
+			exp: optimizev1beta2.Experiment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec:       optimizev1beta2.ExperimentSpec{Selector: &metav1.LabelSelector{}},
+			},
+			expected: optimizev1beta2.Experiment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec:       optimizev1beta2.ExperimentSpec{Selector: &metav1.LabelSelector{}},
+			},
+		},
+		{
+			desc: "setup tasks",
+			exp: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					NamespaceTemplate: &optimizev1beta2.NamespaceTemplateSpec{},
+					TrialTemplate: optimizev1beta2.TrialTemplateSpec{
+						Spec: optimizev1beta2.TrialSpec{
+							SetupTasks: []optimizev1beta2.SetupTask{
+								{Name: "default"},
+								{Name: "custom", Image: "custom:latest"},
+								{Name: "command", Command: []string{"/bin/true"}},
+							},
+						},
+					},
+				},
+			},
+			expectedDirty: true,
+			expected: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					NamespaceTemplate: &optimizev1beta2.NamespaceTemplateSpec{},
+					TrialTemplate: optimizev1beta2.TrialTemplateSpec{
+						Spec: optimizev1beta2.TrialSpec{
+							SetupServiceAccountName: "optimize-setup",
+							SetupTasks: []optimizev1beta2.SetupTask{
+								{Name: "default", Image: "setuptools:test"},
+								{Name: "custom", Image: "custom:latest"},
+								{Name: "command", Command: []string{"/bin/true"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "setup service account",
+			exp: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					TrialTemplate: optimizev1beta2.TrialTemplateSpec{
+						Spec: optimizev1beta2.TrialSpec{
+							SetupServiceAccountName: "custom",
+							SetupTasks:              []optimizev1beta2.SetupTask{{Name: "custom", Image: "custom:latest"}},
+						},
+					},
+				},
+			},
+			expected: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					TrialTemplate: optimizev1beta2.TrialTemplateSpec{
+						Spec: optimizev1beta2.TrialSpec{
+							SetupServiceAccountName: "custom",
+							SetupTasks:              []optimizev1beta2.SetupTask{{Name: "custom", Image: "custom:latest"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "metric delays",
+			exp: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Metrics: []optimizev1beta2.Metric{
+						{Name: "prometheus", Type: optimizev1beta2.MetricPrometheus},
+						{Name: "datadog", Type: optimizev1beta2.MetricDatadog},
+						{Name: "custom", Type: optimizev1beta2.MetricPrometheus, Delay: &metav1.Duration{Duration: 5 * time.Second}},
+						{Name: "kubernetes", Type: optimizev1beta2.MetricKubernetes},
+					},
+				},
+			},
+			expectedDirty: true,
+			expected: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Metrics: []optimizev1beta2.Metric{
+						{Name: "prometheus", Type: optimizev1beta2.MetricPrometheus, Delay: &metav1.Duration{Duration: 20 * time.Second}},
+						{Name: "datadog", Type: optimizev1beta2.MetricDatadog, Delay: &metav1.Duration{Duration: time.Minute}},
+						{Name: "custom", Type: optimizev1beta2.MetricPrometheus, Delay: &metav1.Duration{Duration: 5 * time.Second}},
+						{Name: "kubernetes", Type: optimizev1beta2.MetricKubernetes},
+					},
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			dirty := ApplyDefaults(&c.exp, "setuptools:test")
+			assert.Equal(t, c.expectedDirty, dirty)
+			assert.Equal(t, c.expected, c.exp)
+		})
+	}
+}
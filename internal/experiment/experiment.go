@@ -43,6 +43,8 @@ const (
 	PhaseIdle = "Idle"
 	// PhaseRunning indicates that there are actively running trials for the experiment
 	PhaseRunning = "Running"
+	// PhaseWaiting indicates that the experiment is queued until the controller concurrency limits allow new trials
+	PhaseWaiting = "Waiting"
 	// PhaseCompleted indicates that the experiment has exhausted it's trial budget and is no longer expecting new trials
 	PhaseCompleted = "Completed"
 	// PhaseFailed indicates that the experiment has failed
@@ -112,6 +114,10 @@ func summarize(exp *optimizev1beta2.Experiment, activeTrials int32, totalTrials
 		return PhasePaused
 	}
 
+	if checkCondition(&exp.Status, optimizev1beta2.ExperimentWaiting, corev1.ConditionTrue) {
+		return PhaseWaiting
+	}
+
 	if totalTrials == 0 {
 		if exp.Annotations[optimizev1beta2.AnnotationExperimentURL] != "" {
 			return PhaseCreated
@@ -133,6 +139,16 @@ func IsFinished(exp *optimizev1beta2.Experiment) bool {
 	return false
 }
 
+// IsWaiting checks to see if the experiment is queued behind the controller concurrency limits.
+func IsWaiting(exp *optimizev1beta2.Experiment) bool {
+	return checkCondition(&exp.Status, optimizev1beta2.ExperimentWaiting, corev1.ConditionTrue)
+}
+
+// IsReplicasLimited checks to see if the experiment replicas were found to be unable to run concurrently.
+func IsReplicasLimited(exp *optimizev1beta2.Experiment) bool {
+	return checkCondition(&exp.Status, optimizev1beta2.ExperimentReplicasLimited, corev1.ConditionTrue)
+}
+
 // StopExperiment updates the experiment in the event that it should be paused or halted.
 func StopExperiment(exp *optimizev1beta2.Experiment, err error) bool {
 	if rse, ok := err.(*api.Error); ok && rse.Type == experimentsv1alpha1.ErrExperimentStopped {
@@ -112,6 +112,33 @@ func TestSummarize(t *testing.T) {
 			expectedPhase: PhaseRunning,
 			activeTrials:  1,
 		},
+		{
+			desc: "paused annotation",
+			experiment: &optimizev1beta2.Experiment{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						optimizev1beta2.AnnotationPaused: "true",
+					},
+				},
+				Spec: optimizev1beta2.ExperimentSpec{
+					Replicas: &oneReplica,
+				},
+			},
+			expectedPhase: PhasePaused,
+			totalTrials:   1,
+		},
+		{
+			desc: "paused annotation active trials",
+			experiment: &optimizev1beta2.Experiment{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						optimizev1beta2.AnnotationPaused: "true",
+					},
+				},
+			},
+			expectedPhase: PhaseRunning,
+			activeTrials:  1,
+		},
 		{
 			desc: "paused budget done",
 			experiment: &optimizev1beta2.Experiment{
@@ -181,6 +208,21 @@ func TestSummarize(t *testing.T) {
 			},
 			expectedPhase: PhaseFailed,
 		},
+		{
+			desc: "waiting",
+			experiment: &optimizev1beta2.Experiment{
+				Status: optimizev1beta2.ExperimentStatus{
+					Conditions: []optimizev1beta2.ExperimentCondition{
+						{
+							Type:   optimizev1beta2.ExperimentWaiting,
+							Status: corev1.ConditionTrue,
+						},
+					},
+				},
+			},
+			totalTrials:   1,
+			expectedPhase: PhaseWaiting,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -19,6 +19,7 @@ package generation
 import (
 	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
 	"github.com/thestormforge/optimize-controller/v2/internal/scan"
+	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
@@ -27,12 +28,35 @@ type ApplicationSelector struct {
 	Application *optimizeappsv1alpha1.Application
 	Scenario    *optimizeappsv1alpha1.Scenario
 	Objective   *optimizeappsv1alpha1.Objective
+
+	// The URL of an existing Prometheus deployment to use instead of the built-in Prometheus.
+	PrometheusURL string
+	// The secret key containing the bearer token used to query an existing Prometheus deployment.
+	PrometheusBearerTokenSecretRef *corev1.SecretKeySelector
+	// Flag indicating that namespaced setup permissions should be granted using roles instead of cluster roles.
+	NamespacedRBAC bool
+	// Flag indicating that the built-in metrics exporters should be installed for the built-in Prometheus.
+	Exporters bool
+
+	workloadSelectors []string
 }
 
 var _ scan.Selector = &ApplicationSelector{}
 
 // Select only returns an empty node to ensure that map will be called.
-func (s *ApplicationSelector) Select([]*yaml.RNode) ([]*yaml.RNode, error) {
+func (s *ApplicationSelector) Select(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	// Record the pod selectors of the application workloads so metrics can be scoped to the application pods
+	s.workloadSelectors = nil
+	for _, node := range nodes {
+		sel, err := workloadSelector(node)
+		if err != nil {
+			return nil, err
+		}
+		if sel != "" {
+			s.workloadSelectors = append(s.workloadSelectors, sel)
+		}
+	}
+
 	// In order to evaluate side effects on the application state, we CANNOT introduce the serialized
 	// application into the resource node stream and process it from there. We still need to
 	// return exactly one node here to make sure `Map` gets called.
@@ -45,6 +69,21 @@ func (s *ApplicationSelector) Map(*yaml.RNode, yaml.ResourceMeta) ([]interface{}
 
 	// NOTE: We iterate by index and obtain pointers because some of these evaluate for side effects
 
+	// Datadog metrics must come first so the goals are implemented before the scenario sources are evaluated
+	if s.Objective != nil && s.Application != nil && s.Application.Datadog != nil {
+		for i := range s.Objective.Goals {
+			goal := &s.Objective.Goals[i]
+			if goal.Prometheus != nil || goal.Datadog != nil || goal.Duration != nil {
+				continue
+			}
+			result = append(result, &DatadogApplicationMetricsSource{
+				Datadog:   s.Application.Datadog,
+				Namespace: s.Application.Namespace,
+				Goal:      goal,
+			})
+		}
+	}
+
 	if s.Scenario != nil {
 		switch {
 		case s.Scenario.StormForge != nil:
@@ -53,6 +92,28 @@ func (s *ApplicationSelector) Map(*yaml.RNode, yaml.ResourceMeta) ([]interface{}
 			result = append(result, &LocustSource{Scenario: s.Scenario, Objective: s.Objective, Application: s.Application})
 		case s.Scenario.Custom != nil:
 			result = append(result, &CustomSource{Scenario: s.Scenario, Objective: s.Objective, Application: s.Application})
+		case s.Scenario.Mirror != nil:
+			result = append(result, &MirrorSource{
+				Scenario:           s.Scenario,
+				Application:        s.Application,
+				SetupTaskName:      "traffic-mirror",
+				ServiceAccountName: "optimize-setup",
+				RoleName:           "optimize-traffic-mirror",
+				RoleBindingName:    "optimize-setup-traffic-mirror",
+			})
+		}
+
+		if s.Scenario.Assertions != nil {
+			result = append(result, &ScenarioAssertionsSource{Scenario: s.Scenario})
+		}
+
+		// Overrides must be applied before the data is mounted into the (possibly additional) containers
+		if s.Scenario.JobOverrides != nil {
+			result = append(result, &JobOverridesSource{JobOverrides: s.Scenario.JobOverrides})
+		}
+
+		if len(s.Scenario.Data) > 0 {
+			result = append(result, &ScenarioDataSource{Scenario: s.Scenario, Application: s.Application})
 		}
 	}
 
@@ -62,7 +123,7 @@ func (s *ApplicationSelector) Map(*yaml.RNode, yaml.ResourceMeta) ([]interface{}
 			case s.Objective.Goals[i].Requests != nil:
 				result = append(result, &RequestsMetricsSource{Goal: &s.Objective.Goals[i]})
 			case s.Objective.Goals[i].Duration != nil:
-				result = append(result, &DurationMetricsSource{Goal: &s.Objective.Goals[i]})
+				result = append(result, &DurationMetricsSource{Goal: &s.Objective.Goals[i], WorkloadSelectors: s.workloadSelectors})
 			case s.Objective.Goals[i].Prometheus != nil:
 				result = append(result, &PrometheusMetricsSource{Goal: &s.Objective.Goals[i]})
 			case s.Objective.Goals[i].Datadog != nil:
@@ -71,12 +132,31 @@ func (s *ApplicationSelector) Map(*yaml.RNode, yaml.ResourceMeta) ([]interface{}
 		}
 	}
 
-	result = append(result, &BuiltInPrometheus{
-		SetupTaskName:          "monitoring",
-		ClusterRoleName:        "optimize-prometheus",
-		ServiceAccountName:     "optimize-setup",
-		ClusterRoleBindingName: "optimize-setup-prometheus",
-	})
+	if s.Application != nil && s.Application.Promotion != nil {
+		result = append(result, &PromotionSource{Promotion: s.Application.Promotion})
+	}
+
+	if s.PrometheusURL != "" {
+		result = append(result, &ExternalPrometheus{
+			URL:                  s.PrometheusURL,
+			BearerTokenSecretRef: s.PrometheusBearerTokenSecretRef,
+		})
+	} else {
+		p := &BuiltInPrometheus{
+			SetupTaskName:          "monitoring",
+			ClusterRoleName:        "optimize-prometheus",
+			ServiceAccountName:     "optimize-setup",
+			ClusterRoleBindingName: "optimize-setup-prometheus",
+		}
+		if s.NamespacedRBAC {
+			p.RoleName = "optimize-prometheus"
+			p.RoleBindingName = "optimize-setup-prometheus"
+		}
+		if s.Exporters {
+			p.ExportersSetupTaskName = "exporters"
+		}
+		result = append(result, p)
+	}
 
 	return result, nil
 }
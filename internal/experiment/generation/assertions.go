@@ -0,0 +1,79 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"fmt"
+
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+)
+
+// ScenarioAssertionsSource adds (non-optimized) metrics whose bounds are the scenario assertions. The controller
+// fails any trial whose collected load test metrics are out of bounds.
+type ScenarioAssertionsSource struct {
+	Scenario *optimizeappsv1alpha1.Scenario
+}
+
+var _ MetricSource = &ScenarioAssertionsSource{}
+
+func (s *ScenarioAssertionsSource) Metrics() ([]optimizev1beta2.Metric, error) {
+	if s.Scenario == nil || s.Scenario.Assertions == nil {
+		return nil, nil
+	}
+
+	// Express the assertions as goals so the queries match the scenario's own load test metrics
+	optimize := false
+	obj := &optimizeappsv1alpha1.Objective{}
+	if q := s.Scenario.Assertions.MaxErrorRate; q != nil {
+		obj.Goals = append(obj.Goals, optimizeappsv1alpha1.Goal{
+			Name:      "assert-max-error-rate",
+			Max:       q,
+			Optimize:  &optimize,
+			ErrorRate: &optimizeappsv1alpha1.ErrorRateGoal{ErrorRateType: optimizeappsv1alpha1.ErrorRateRequests},
+		})
+	}
+	if q := s.Scenario.Assertions.MaxLatencyP99; q != nil {
+		obj.Goals = append(obj.Goals, optimizeappsv1alpha1.Goal{
+			Name:     "assert-max-latency-p99",
+			Max:      q,
+			Optimize: &optimize,
+			Latency:  &optimizeappsv1alpha1.LatencyGoal{LatencyType: optimizeappsv1alpha1.LatencyPercentile99},
+		})
+	}
+
+	var ms MetricSource
+	switch {
+	case s.Scenario.StormForge != nil:
+		ms = &StormForgePerformanceSource{Scenario: s.Scenario, Objective: obj}
+	case s.Scenario.Locust != nil:
+		ms = &LocustSource{Scenario: s.Scenario, Objective: obj}
+	default:
+		return nil, fmt.Errorf("assertions are not supported for scenario %q", s.Scenario.Name)
+	}
+
+	result, err := ms.Metrics()
+	if err != nil {
+		return nil, err
+	}
+	for i := range obj.Goals {
+		if !obj.Goals[i].Implemented {
+			return nil, fmt.Errorf("unable to check assertion %q for scenario %q", obj.Goals[i].Name, s.Scenario.Name)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestScenarioAssertionsSource_Metrics(t *testing.T) {
+	errorRate := resource.MustParse("50m")
+	latency := resource.MustParse("250")
+	optimize := false
+
+	cases := []struct {
+		desc          string
+		scenario      optimizeappsv1alpha1.Scenario
+		expected      []optimizev1beta2.Metric
+		expectedError string
+	}{
+		{
+			desc:     "no assertions",
+			scenario: optimizeappsv1alpha1.Scenario{Locust: &optimizeappsv1alpha1.LocustScenario{}},
+		},
+		{
+			desc: "locust",
+			scenario: optimizeappsv1alpha1.Scenario{
+				Locust: &optimizeappsv1alpha1.LocustScenario{},
+				Assertions: &optimizeappsv1alpha1.ScenarioAssertions{
+					MaxErrorRate:  &errorRate,
+					MaxLatencyP99: &latency,
+				},
+			},
+			expected: []optimizev1beta2.Metric{
+				{
+					Name:     "assert-max-error-rate",
+					Type:     optimizev1beta2.MetricPrometheus,
+					Query:    `scalar(failure_count{job="trialRun",instance="{{ .Trial.Name }}"} / request_count{job="trialRun",instance="{{ .Trial.Name }}"})`,
+					Minimize: true,
+					Max:      &errorRate,
+					Optimize: &optimize,
+				},
+				{
+					Name:     "assert-max-latency-p99",
+					Type:     optimizev1beta2.MetricPrometheus,
+					Query:    `scalar(p99{job="trialRun",instance="{{ .Trial.Name }}"})`,
+					Minimize: true,
+					Max:      &latency,
+					Optimize: &optimize,
+				},
+			},
+		},
+		{
+			desc: "stormforge",
+			scenario: optimizeappsv1alpha1.Scenario{
+				StormForge: &optimizeappsv1alpha1.StormForgeScenario{},
+				Assertions: &optimizeappsv1alpha1.ScenarioAssertions{MaxLatencyP99: &latency},
+			},
+			expected: []optimizev1beta2.Metric{
+				{
+					Name:     "assert-max-latency-p99",
+					Type:     optimizev1beta2.MetricPrometheus,
+					Query:    `scalar(percentile_99{job="trialRun",instance="{{ .Trial.Name }}"})`,
+					Minimize: true,
+					Max:      &latency,
+					Optimize: &optimize,
+				},
+			},
+		},
+		{
+			desc: "custom",
+			scenario: optimizeappsv1alpha1.Scenario{
+				Name:       "custom",
+				Custom:     &optimizeappsv1alpha1.CustomScenario{},
+				Assertions: &optimizeappsv1alpha1.ScenarioAssertions{MaxErrorRate: &errorRate},
+			},
+			expectedError: `assertions are not supported for scenario "custom"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := &ScenarioAssertionsSource{Scenario: &c.scenario}
+			metrics, err := s.Metrics()
+			if c.expectedError != "" {
+				assert.EqualError(t, err, c.expectedError)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, metrics)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package generation
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/thestormforge/konjure/pkg/filters"
 	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
@@ -90,8 +91,20 @@ func (s *ContainerResourcesSelector) Map(node *yaml.RNode, meta yaml.ResourceMet
 		resourcesMatcher.Create = yaml.NewMapRNode(nil)
 	}
 
+	// Collect the names of the containers which should be skipped
+	excluded := make(map[string]bool, len(s.ExcludeContainers))
+	for _, name := range s.ExcludeContainers {
+		excluded[name] = true
+	}
+	for _, name := range strings.Split(meta.Annotations[optimizeappsv1alpha1.AnnotationExcludeContainers], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			excluded[name] = true
+		}
+	}
+
 	return result, node.PipeE(sfio.TeeMatched(
 		containerMatcher,
+		excludeContainers(excluded),
 		sfio.PreserveFieldMatcherPath(resourcesMatcher),
 		yaml.FilterFunc(func(node *yaml.RNode) (*yaml.RNode, error) {
 			result = append(result, &containerResourcesParameter{
@@ -100,14 +113,36 @@ func (s *ContainerResourcesSelector) Map(node *yaml.RNode, meta yaml.ResourceMet
 					fieldPath: node.FieldPath(),
 					value:     node.YNode(),
 				},
-				resources:  s.Resources,
-				limitRange: s.ContainerLimitRange[meta.Namespace],
+				resources:         s.Resources,
+				limitRange:        s.ContainerLimitRange[meta.Namespace],
+				strategy:          s.Strategy,
+				limitRequestRatio: s.LimitRequestRatio,
+				cpuLimit:          s.CPULimit,
+				bounds:            s.Bounds,
 			})
 			return node, nil
 		}),
 	))
 }
 
+// excludeContainers returns a filter that drops containers with an excluded name.
+func excludeContainers(excluded map[string]bool) yaml.Filter {
+	return yaml.FilterFunc(func(node *yaml.RNode) (*yaml.RNode, error) {
+		if len(excluded) == 0 {
+			return node, nil
+		}
+
+		name, err := node.Pipe(yaml.Lookup("name"))
+		if err != nil {
+			return nil, err
+		}
+		if name != nil && excluded[yaml.GetValue(name)] {
+			return nil, nil
+		}
+		return node, nil
+	})
+}
+
 // saveContainerLimitRange captures the container specific limit range item for
 // the specified namespace so that it can be used for defaults later.
 func (s *ContainerResourcesSelector) saveContainerLimitRange(namespace string, node *yaml.RNode) error {
@@ -136,8 +171,12 @@ func (s *ContainerResourcesSelector) saveContainerLimitRange(namespace string, n
 // found by the selector during scanning.
 type containerResourcesParameter struct {
 	pnode
-	resources  []corev1.ResourceName
-	limitRange corev1.LimitRangeItem
+	resources         []corev1.ResourceName
+	limitRange        corev1.LimitRangeItem
+	strategy          optimizeappsv1alpha1.ResourcesStrategy
+	limitRequestRatio *resource.Quantity
+	cpuLimit          optimizeappsv1alpha1.CPULimitCoupling
+	bounds            map[corev1.ResourceName]optimizeappsv1alpha1.ResourceBounds
 }
 
 var _ PatchSource = &containerResourcesParameter{}
@@ -156,6 +195,36 @@ func (p *containerResourcesParameter) Patch(name ParameterNamer) (yaml.Filter, e
 	limitsPatch := yaml.FieldSetter{Name: "limits", Value: yaml.NewMapRNode(nil)}
 	requestsPatch := yaml.FieldSetter{Name: "requests", Value: yaml.NewMapRNode(nil)}
 
+	// The limits are computed from the requests using an integer percentage
+	limitsValue := "{{ index .Values %q }}%s"
+	switch p.strategy {
+	case "", optimizeappsv1alpha1.ResourcesStrategyRequests, optimizeappsv1alpha1.ResourcesStrategyLimits:
+	case optimizeappsv1alpha1.ResourcesStrategyRatio:
+		if p.limitRequestRatio == nil || p.limitRequestRatio.Cmp(resource.MustParse("1")) < 0 {
+			return nil, fmt.Errorf("the ratio strategy requires a limit/request ratio of at least 1")
+		}
+		limitsValue = fmt.Sprintf("{{ percent (index .Values %%q) %d }}%%s", p.limitRequestRatio.MilliValue()/10)
+	default:
+		return nil, fmt.Errorf("unknown container resources strategy %q", p.strategy)
+	}
+
+	// The CPU limit can be coupled to the request independently of the other resources
+	cpuLimitsValue := limitsValue
+	switch p.cpuLimit {
+	case "":
+	case optimizeappsv1alpha1.CPULimitEqual:
+		cpuLimitsValue = "{{ index .Values %q }}%s"
+	case optimizeappsv1alpha1.CPULimitDouble:
+		cpuLimitsValue = "{{ percent (index .Values %q) 200 }}%s"
+	case optimizeappsv1alpha1.CPULimitNone:
+		cpuLimitsValue = ""
+	default:
+		return nil, fmt.Errorf("unknown CPU limit coupling %q", p.cpuLimit)
+	}
+	if p.cpuLimit != "" && (p.strategy == optimizeappsv1alpha1.ResourcesStrategyRequests || p.strategy == optimizeappsv1alpha1.ResourcesStrategyLimits) {
+		return nil, fmt.Errorf("the CPU limit cannot be coupled when using the %s strategy", p.strategy)
+	}
+
 	for _, rn := range p.resources {
 		// Create patch filter for each ResourceName (e.g. "cpu: {{ index .Values ...")
 		parameterName := name(p.meta, p.fieldPath, string(rn))
@@ -163,8 +232,23 @@ func (p *containerResourcesParameter) Patch(name ParameterNamer) (yaml.Filter, e
 		patchFilter := yaml.SetField(string(rn), yaml.NewStringRNode(patch))
 		patchFilter.Value.YNode().Style = yaml.SingleQuotedStyle
 
-		// Apply the same patch filter to both limits and requests
-		if err := limitsPatch.Value.PipeE(patchFilter); err != nil {
+		var limitsPatchFilter yaml.Filter
+		switch {
+		case rn == corev1.ResourceCPU && p.cpuLimit == optimizeappsv1alpha1.CPULimitNone:
+			// An explicit null removes the limit when the strategic merge patch is applied (we cannot
+			// use a field setter because it clears fields with null values)
+			limitsPatchFilter = setNullField(string(rn))
+		case rn == corev1.ResourceCPU:
+			fs := yaml.SetField(string(rn), yaml.NewStringRNode(fmt.Sprintf(cpuLimitsValue, parameterName, ind[rn].Suffix())))
+			fs.Value.YNode().Style = yaml.SingleQuotedStyle
+			limitsPatchFilter = fs
+		default:
+			fs := yaml.SetField(string(rn), yaml.NewStringRNode(fmt.Sprintf(limitsValue, parameterName, ind[rn].Suffix())))
+			fs.Value.YNode().Style = yaml.SingleQuotedStyle
+			limitsPatchFilter = fs
+		}
+
+		if err := limitsPatch.Value.PipeE(limitsPatchFilter); err != nil {
 			return nil, err
 		}
 		if err := requestsPatch.Value.PipeE(patchFilter); err != nil {
@@ -173,7 +257,14 @@ func (p *containerResourcesParameter) Patch(name ParameterNamer) (yaml.Filter, e
 	}
 
 	// Combine the filters using Tee so resulting filter won't change the traversal depth
-	return yaml.Tee(path, yaml.Tee(limitsPatch), yaml.Tee(requestsPatch)), nil
+	switch p.strategy {
+	case optimizeappsv1alpha1.ResourcesStrategyRequests:
+		return yaml.Tee(path, yaml.Tee(requestsPatch)), nil
+	case optimizeappsv1alpha1.ResourcesStrategyLimits:
+		return yaml.Tee(path, yaml.Tee(limitsPatch)), nil
+	default:
+		return yaml.Tee(path, yaml.Tee(limitsPatch), yaml.Tee(requestsPatch)), nil
+	}
 }
 
 // Parameters lists the parameters used by the patch.
@@ -204,20 +295,80 @@ func (p *containerResourcesParameter) indexContainerResources() (map[corev1.Reso
 		return nil, err
 	}
 
+	// When only tuning limits, the baseline comes from the limits
+	baselines := []corev1.ResourceList{scannedValue.Requests, p.limitRange.DefaultRequest, defaultLimitRange.DefaultRequest}
+	if p.strategy == optimizeappsv1alpha1.ResourcesStrategyLimits {
+		baselines = []corev1.ResourceList{scannedValue.Limits, p.limitRange.Default, defaultLimitRange.DefaultRequest}
+	}
+
 	// For each configured resource, capture the baseline and range
 	result := make(map[corev1.ResourceName]containerResources, len(p.resources))
 	for _, rn := range p.resources {
-		result[rn] = containerResources{
+		cr := containerResources{
 			max:          lookupQuantity(rn, p.limitRange.Max, defaultLimitRange.Max),
 			min:          lookupQuantity(rn, p.limitRange.Min, defaultLimitRange.Min),
-			baseline:     lookupQuantity(rn, scannedValue.Requests, p.limitRange.DefaultRequest, defaultLimitRange.DefaultRequest),
+			baseline:     lookupQuantity(rn, baselines...),
 			defaultScale: defaultScale[rn],
 		}
+
+		// Explicit bounds take precedence over the observed values
+		if b, ok := p.bounds[rn]; ok {
+			if b.Baseline != nil {
+				cr.baseline = withFormat(*b.Baseline, cr.baseline.Format)
+			}
+			if b.Min != nil {
+				min := withFormat(*b.Min, cr.baseline.Format)
+				cr.explicitMin = &min
+			}
+			if b.Max != nil {
+				max := withFormat(*b.Max, cr.baseline.Format)
+				cr.explicitMax = &max
+			}
+		}
+
+		if err := cr.checkBounds(); err != nil {
+			return nil, fmt.Errorf("invalid %s bounds for %s, %w", rn, p.meta.Name, err)
+		}
+
+		result[rn] = cr
 	}
 
 	return result, nil
 }
 
+// checkBounds verifies the explicit bounds are consistent with each other and the baseline.
+func (cr *containerResources) checkBounds() error {
+	if cr.explicitMin != nil && cr.explicitMax != nil && cr.explicitMin.Cmp(*cr.explicitMax) > 0 {
+		return fmt.Errorf("min (%s) is greater than max (%s)", cr.explicitMin, cr.explicitMax)
+	}
+	if cr.baseline.IsZero() {
+		return nil
+	}
+	if cr.explicitMin != nil && cr.baseline.Cmp(*cr.explicitMin) < 0 {
+		return fmt.Errorf("baseline (%s) is less than min (%s)", &cr.baseline, cr.explicitMin)
+	}
+	if cr.explicitMax != nil && cr.baseline.Cmp(*cr.explicitMax) > 0 {
+		return fmt.Errorf("baseline (%s) is greater than max (%s)", &cr.baseline, cr.explicitMax)
+	}
+	return nil
+}
+
+// setNullField returns a filter that adds an explicit null value to a mapping node.
+func setNullField(name string) yaml.Filter {
+	return yaml.FilterFunc(func(rn *yaml.RNode) (*yaml.RNode, error) {
+		rn.YNode().Content = append(rn.YNode().Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: yaml.NodeTagString, Value: name},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: yaml.NodeTagNull, Value: "null"})
+		return rn, nil
+	})
+}
+
+// withFormat returns a copy of the quantity using the supplied format (the value is unchanged).
+func withFormat(q resource.Quantity, format resource.Format) resource.Quantity {
+	q = *resource.NewMilliQuantity(q.MilliValue(), format)
+	return q
+}
+
 // lookupQuantity returns a quantity from the first resource list that has it.
 func lookupQuantity(rn corev1.ResourceName, rl ...corev1.ResourceList) resource.Quantity {
 	for i := range rl {
@@ -266,10 +417,16 @@ type containerResources struct {
 	min          resource.Quantity
 	baseline     resource.Quantity
 	defaultScale resource.Scale
+	explicitMax  *resource.Quantity
+	explicitMin  *resource.Quantity
 }
 
 // Max returns the configured maximum, or twice the baseline (provided it is smaller than the max).
 func (cr containerResources) Max() int32 {
+	if cr.explicitMax != nil {
+		return AsScaledInt(*cr.explicitMax, cr.scale())
+	}
+
 	max := cr.max
 	max.Format = cr.baseline.Format
 
@@ -287,6 +444,10 @@ func (cr containerResources) Max() int32 {
 
 // Min returns the configured minimum or half the baseline.
 func (cr containerResources) Min() int32 {
+	if cr.explicitMin != nil {
+		return AsScaledInt(*cr.explicitMin, cr.scale())
+	}
+
 	if !cr.baseline.IsZero() {
 		return AsScaledInt(cr.baseline, cr.scale()) / 2
 	}
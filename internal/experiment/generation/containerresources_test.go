@@ -23,6 +23,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -229,6 +230,236 @@ func TestContainerResourcesParameter(t *testing.T) {
                   requests:
                     memory: '{{ index .Values "memory" }}M'`),
 		},
+
+		{
+			desc: "requests strategy",
+
+			containerResourcesParameter: containerResourcesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "resources"},
+					value: encodeResourceRequirements(corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("500m"),
+						},
+					}),
+				},
+				resources: []corev1.ResourceName{corev1.ResourceCPU},
+				strategy:  optimizeappsv1alpha1.ResourcesStrategyRequests,
+			},
+
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "cpu",
+					Baseline: newInt(500),
+					Min:      250,
+					Max:      2000,
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                resources:
+                  requests:
+                    cpu: '{{ index .Values "cpu" }}m'`),
+		},
+
+		{
+			desc: "limits strategy",
+
+			containerResourcesParameter: containerResourcesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "resources"},
+					value: encodeResourceRequirements(corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("512Mi"),
+						},
+					}),
+				},
+				resources: []corev1.ResourceName{corev1.ResourceMemory},
+				strategy:  optimizeappsv1alpha1.ResourcesStrategyLimits,
+			},
+
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "memory",
+					Baseline: newInt(1024),
+					Min:      512,
+					Max:      2048,
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                resources:
+                  limits:
+                    memory: '{{ index .Values "memory" }}Mi'`),
+		},
+
+		{
+			desc: "ratio strategy",
+
+			containerResourcesParameter: containerResourcesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "resources"},
+					value: encodeResourceRequirements(corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					}),
+				},
+				resources:         []corev1.ResourceName{corev1.ResourceMemory},
+				strategy:          optimizeappsv1alpha1.ResourcesStrategyRatio,
+				limitRequestRatio: resource.NewMilliQuantity(1500, resource.DecimalSI),
+			},
+
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "memory",
+					Baseline: newInt(1024),
+					Min:      512,
+					Max:      2048,
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                resources:
+                  limits:
+                    memory: '{{ percent (index .Values "memory") 150 }}Mi'
+                  requests:
+                    memory: '{{ index .Values "memory" }}Mi'`),
+		},
+
+		{
+			desc: "cpu limit double",
+
+			containerResourcesParameter: containerResourcesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "resources"},
+					value: encodeResourceRequirements(corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("2.0"),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					}),
+				},
+				resources:         []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory},
+				strategy:          optimizeappsv1alpha1.ResourcesStrategyRatio,
+				limitRequestRatio: resource.NewMilliQuantity(1500, resource.DecimalSI),
+				cpuLimit:          optimizeappsv1alpha1.CPULimitDouble,
+			},
+
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "cpu",
+					Baseline: newInt(2000),
+					Min:      1000,
+					Max:      4000,
+				},
+				{
+					Name:     "memory",
+					Baseline: newInt(1024),
+					Min:      512,
+					Max:      2048,
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                resources:
+                  limits:
+                    cpu: '{{ percent (index .Values "cpu") 200 }}m'
+                    memory: '{{ percent (index .Values "memory") 150 }}Mi'
+                  requests:
+                    cpu: '{{ index .Values "cpu" }}m'
+                    memory: '{{ index .Values "memory" }}Mi'`),
+		},
+
+		{
+			desc: "cpu limit none",
+
+			containerResourcesParameter: containerResourcesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "resources"},
+					value: encodeResourceRequirements(corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("2.0"),
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("2.0"),
+						},
+					}),
+				},
+				resources: []corev1.ResourceName{corev1.ResourceCPU},
+				cpuLimit:  optimizeappsv1alpha1.CPULimitNone,
+			},
+
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "cpu",
+					Baseline: newInt(2000),
+					Min:      1000,
+					Max:      4000,
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                resources:
+                  limits:
+                    cpu: null
+                  requests:
+                    cpu: '{{ index .Values "cpu" }}m'`),
+		},
+
+		{
+			desc: "explicit bounds",
+
+			containerResourcesParameter: containerResourcesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "resources"},
+					value: encodeResourceRequirements(corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1"),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					}),
+				},
+				resources: []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory},
+				bounds: map[corev1.ResourceName]optimizeappsv1alpha1.ResourceBounds{
+					corev1.ResourceCPU: {
+						Min: resource.NewMilliQuantity(250, resource.DecimalSI),
+						Max: resource.NewMilliQuantity(4000, resource.DecimalSI),
+					},
+					corev1.ResourceMemory: {
+						Max:      resource.NewQuantity(8*1024*1024*1024, resource.BinarySI),
+						Baseline: resource.NewQuantity(2*1024*1024*1024, resource.BinarySI),
+					},
+				},
+			},
+
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "cpu",
+					Baseline: newInt(1000),
+					Min:      250,
+					Max:      4000,
+				},
+				{
+					Name:     "memory",
+					Baseline: newInt(2048),
+					Min:      1024,
+					Max:      8192,
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                resources:
+                  limits:
+                    cpu: '{{ index .Values "cpu" }}m'
+                    memory: '{{ index .Values "memory" }}Mi'
+                  requests:
+                    cpu: '{{ index .Values "cpu" }}m'
+                    memory: '{{ index .Values "memory" }}Mi'`),
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
@@ -254,6 +485,53 @@ func TestContainerResourcesParameter(t *testing.T) {
 	}
 }
 
+func TestContainerResourcesParameter_InvalidBounds(t *testing.T) {
+	cases := []struct {
+		desc   string
+		bounds optimizeappsv1alpha1.ResourceBounds
+	}{
+		{
+			desc: "min greater than max",
+			bounds: optimizeappsv1alpha1.ResourceBounds{
+				Min: resource.NewMilliQuantity(2000, resource.DecimalSI),
+				Max: resource.NewMilliQuantity(1000, resource.DecimalSI),
+			},
+		},
+		{
+			desc: "baseline less than min",
+			bounds: optimizeappsv1alpha1.ResourceBounds{
+				Min:      resource.NewMilliQuantity(500, resource.DecimalSI),
+				Baseline: resource.NewMilliQuantity(250, resource.DecimalSI),
+			},
+		},
+		{
+			desc: "observed baseline greater than max",
+			bounds: optimizeappsv1alpha1.ResourceBounds{
+				Max: resource.NewMilliQuantity(500, resource.DecimalSI),
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			p := &containerResourcesParameter{
+				pnode: pnode{
+					fieldPath: []string{"spec", "resources"},
+					value: encodeResourceRequirements(corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+					}),
+				},
+				resources: []corev1.ResourceName{corev1.ResourceCPU},
+				bounds:    map[corev1.ResourceName]optimizeappsv1alpha1.ResourceBounds{corev1.ResourceCPU: c.bounds},
+			}
+
+			_, err := p.Parameters(ignoreMetaForName)
+			assert.Error(t, err)
+			_, err = p.Patch(ignoreMetaForName)
+			assert.Error(t, err)
+		})
+	}
+}
+
 // encodeResourceRequirements is a helper to generate the YAML content necessary
 // for the pnode value of the containerResourcesParameter.
 func encodeResourceRequirements(rr corev1.ResourceRequirements) *yaml.Node {
@@ -288,3 +566,70 @@ func unindent(s string) string {
 	}
 	return strings.Join(result, "\n")
 }
+
+func TestContainerResourcesSelector_Map(t *testing.T) {
+	cases := []struct {
+		desc              string
+		excludeContainers []string
+		annotations       string
+		expected          []string
+	}{
+		{
+			desc:     "default exclusions",
+			expected: []string{"app", "logger"},
+		},
+		{
+			desc:        "annotation",
+			annotations: "logger, other",
+			expected:    []string{"app"},
+		},
+		{
+			desc:              "no exclusions",
+			excludeContainers: []string{},
+			expected:          []string{"app", "istio-proxy", "logger"},
+		},
+		{
+			desc:              "explicit exclusions",
+			excludeContainers: []string{"app"},
+			expected:          []string{"istio-proxy", "logger"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			node := yaml.MustParse(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        resources: {}
+      - name: istio-proxy
+        resources: {}
+      - name: logger
+        resources: {}
+`)
+			if c.annotations != "" {
+				require.NoError(t, node.PipeE(yaml.SetAnnotation(optimizeappsv1alpha1.AnnotationExcludeContainers, c.annotations)))
+			}
+			meta, err := node.GetMeta()
+			require.NoError(t, err)
+
+			s := &ContainerResourcesSelector{ExcludeContainers: c.excludeContainers}
+			(*optimizeappsv1alpha1.ContainerResources)(s).Default()
+
+			result, err := s.Map(node, meta)
+			if assert.NoError(t, err) {
+				var actual []string
+				for _, r := range result {
+					fieldPath := r.(*containerResourcesParameter).fieldPath
+					actual = append(actual, strings.TrimSuffix(strings.TrimPrefix(fieldPath[4], "[name="), "]"))
+				}
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
@@ -55,12 +55,25 @@ func (s *CustomSource) Update(exp *optimizev1beta2.Experiment) error {
 		exp.Spec.TrialTemplate.Spec.ApproximateRuntime = &metav1.Duration{Duration: time.Duration(rt) * time.Second}
 	}
 
-	if s.Scenario.Custom.Image != "" {
+	if c := s.Scenario.Custom; c.Image != "" || len(c.Command) > 0 || len(c.Args) > 0 || len(c.Env) > 0 {
 		pod := ensureTrialJobPod(exp)
 		if len(pod.Spec.Containers) == 0 {
 			pod.Spec.Containers = make([]corev1.Container, 1)
 		}
-		pod.Spec.Containers[0].Image = s.Scenario.Custom.Image
+
+		container := &pod.Spec.Containers[0]
+		if c.Image != "" {
+			container.Image = c.Image
+		}
+		if len(c.Command) > 0 {
+			container.Command = append([]string{}, c.Command...)
+		}
+		if len(c.Args) > 0 {
+			container.Args = append([]string{}, c.Args...)
+		}
+		for i := range c.Env {
+			container.Env = setEnvVar(container.Env, *c.Env[i].DeepCopy())
+		}
 	}
 
 	// It is possible we ended up in an invalid state, try to clean things up
@@ -130,3 +143,14 @@ func (s *CustomSource) Metrics() ([]optimizev1beta2.Metric, error) {
 
 	return result, nil
 }
+
+// setEnvVar adds or replaces the named environment variable.
+func setEnvVar(env []corev1.EnvVar, v corev1.EnvVar) []corev1.EnvVar {
+	for i := range env {
+		if env[i].Name == v.Name {
+			env[i] = v
+			return env
+		}
+	}
+	return append(env, v)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCustomSource_Update(t *testing.T) {
+	cases := []struct {
+		desc     string
+		custom   optimizeappsv1alpha1.CustomScenario
+		expected []corev1.Container
+	}{
+		{
+			desc: "image only",
+			custom: optimizeappsv1alpha1.CustomScenario{
+				Image: "example.invalid/load:latest",
+			},
+			expected: []corev1.Container{
+				{Name: "custom", Image: "example.invalid/load:latest"},
+			},
+		},
+		{
+			desc: "image command args env",
+			custom: optimizeappsv1alpha1.CustomScenario{
+				Image:   "example.invalid/batch",
+				Command: []string{"/bin/run"},
+				Args:    []string{"--iterations", "10"},
+				Env:     []corev1.EnvVar{{Name: "TARGET", Value: "http://app"}},
+			},
+			expected: []corev1.Container{
+				{
+					Name:    "custom",
+					Image:   "example.invalid/batch",
+					Command: []string{"/bin/run"},
+					Args:    []string{"--iterations", "10"},
+					Env:     []corev1.EnvVar{{Name: "TARGET", Value: "http://app"}},
+				},
+			},
+		},
+		{
+			desc: "env overrides pod template",
+			custom: optimizeappsv1alpha1.CustomScenario{
+				PodTemplate: &corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name:  "harness",
+								Image: "example.invalid/harness",
+								Env: []corev1.EnvVar{
+									{Name: "TARGET", Value: "http://old"},
+									{Name: "MODE", Value: "fast"},
+								},
+							},
+						},
+					},
+				},
+				Env: []corev1.EnvVar{{Name: "TARGET", Value: "http://new"}},
+			},
+			expected: []corev1.Container{
+				{
+					Name:  "harness",
+					Image: "example.invalid/harness",
+					Env: []corev1.EnvVar{
+						{Name: "TARGET", Value: "http://new"},
+						{Name: "MODE", Value: "fast"},
+					},
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			custom := c.custom
+			s := &CustomSource{
+				Scenario:    &optimizeappsv1alpha1.Scenario{Name: "custom", Custom: &custom},
+				Application: &optimizeappsv1alpha1.Application{},
+			}
+			exp := &optimizev1beta2.Experiment{}
+			if assert.NoError(t, s.Update(exp)) {
+				assert.Equal(t, c.expected, exp.Spec.TrialTemplate.Spec.JobTemplate.Spec.Template.Spec.Containers)
+			}
+		})
+	}
+}
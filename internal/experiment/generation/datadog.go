@@ -17,10 +17,16 @@ limitations under the License.
 package generation
 
 import (
+	"fmt"
 	"net/url"
+	"sort"
+	"strings"
 
 	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 )
 
 type DatadogMetricsSource struct {
@@ -45,3 +51,132 @@ func (s *DatadogMetricsSource) Metrics() ([]optimizev1beta2.Metric, error) {
 
 	return result, nil
 }
+
+// DatadogApplicationMetricsSource implements the standard objective goals using Datadog queries
+// instead of the Prometheus queries normally used.
+type DatadogApplicationMetricsSource struct {
+	Datadog   *optimizeappsv1alpha1.Datadog
+	Namespace string
+	Goal      *optimizeappsv1alpha1.Goal
+}
+
+var _ MetricSource = &DatadogApplicationMetricsSource{}
+
+func (s *DatadogApplicationMetricsSource) Metrics() ([]optimizev1beta2.Metric, error) {
+	var result []optimizev1beta2.Metric
+	if s.Goal == nil || s.Goal.Implemented || s.Datadog == nil {
+		return result, nil
+	}
+
+	query := s.query()
+	if query == "" {
+		return result, nil
+	}
+
+	m := newGoalMetric(s.Goal, query)
+	m.Type = optimizev1beta2.MetricDatadog
+	m.Minimize = s.Goal.Throughput == nil
+	if s.Datadog.Aggregator != "" {
+		m.URL = "?" + url.Values{"aggregator": []string{s.Datadog.Aggregator}}.Encode()
+	}
+	if s.Datadog.SecretName != "" {
+		m.CredentialsSecretRef = &corev1.LocalObjectReference{Name: s.Datadog.SecretName}
+	}
+	result = append(result, m)
+
+	return result, nil
+}
+
+// query returns the Datadog query for the goal, an empty string indicates the goal cannot be implemented.
+func (s *DatadogApplicationMetricsSource) query() string {
+	if q, ok := s.Datadog.Queries[s.Goal.Name]; ok {
+		return q
+	}
+
+	switch {
+
+	case s.Goal.Requests != nil:
+		cpuWeight := s.Goal.Requests.Weights.Cpu()
+		if cpuWeight == nil {
+			cpuWeight = &zero
+		}
+		memoryWeight := s.Goal.Requests.Weights.Memory()
+		if memoryWeight == nil {
+			memoryWeight = &zero
+		}
+		tags := s.tags(s.Goal.Requests.Selector)
+		return fmt.Sprintf("(sum:kubernetes.cpu.requests{%s} * %d) + (sum:kubernetes.memory.requests{%s} / 1000000000 * %d)",
+			tags, cpuWeight.Value(), tags, memoryWeight.Value())
+
+	case s.Goal.Latency != nil && s.Datadog.Service != "":
+		if agg := datadogLatency(s.Goal.Latency.LatencyType); agg != "" {
+			return fmt.Sprintf("%s:trace.%s{service:%s}", agg, s.operation(), s.Datadog.Service)
+		}
+
+	case s.Goal.Throughput != nil && s.Datadog.Service != "":
+		if s.Goal.Throughput.ThroughputType == optimizeappsv1alpha1.ThroughputRequests {
+			return fmt.Sprintf("sum:trace.%s.hits{service:%s}.as_rate()", s.operation(), s.Datadog.Service)
+		}
+
+	case s.Goal.ErrorRate != nil && s.Datadog.Service != "":
+		if s.Goal.ErrorRate.ErrorRateType == optimizeappsv1alpha1.ErrorRateRequests {
+			return fmt.Sprintf("sum:trace.%[1]s.errors{service:%[2]s}.as_count() / sum:trace.%[1]s.hits{service:%[2]s}.as_count()",
+				s.operation(), s.Datadog.Service)
+		}
+
+	}
+
+	return ""
+}
+
+func (s *DatadogApplicationMetricsSource) operation() string {
+	if s.Datadog.Operation != "" {
+		return s.Datadog.Operation
+	}
+	return "http.request"
+}
+
+// tags converts the namespace and the equality based requirements of a label selector to Datadog tags.
+func (s *DatadogApplicationMetricsSource) tags(selector string) string {
+	var tags []string
+	if s.Namespace != "" {
+		tags = append(tags, "kube_namespace:"+s.Namespace)
+	}
+
+	if sel, err := labels.Parse(selector); err == nil {
+		reqs, _ := sel.Requirements()
+		for _, req := range reqs {
+			switch req.Operator() {
+			case selection.Equals, selection.DoubleEquals, selection.In:
+				if values := req.Values().List(); len(values) == 1 {
+					tags = append(tags, req.Key()+":"+values[0])
+				}
+			}
+		}
+	}
+
+	if len(tags) == 0 {
+		return "*"
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
+
+func datadogLatency(lt optimizeappsv1alpha1.LatencyType) string {
+	switch optimizeappsv1alpha1.FixLatency(lt) {
+	case optimizeappsv1alpha1.LatencyMinimum:
+		return "min"
+	case optimizeappsv1alpha1.LatencyMaximum:
+		return "max"
+	case optimizeappsv1alpha1.LatencyMean:
+		return "avg"
+	case optimizeappsv1alpha1.LatencyPercentile50:
+		return "p50"
+	case optimizeappsv1alpha1.LatencyPercentile95:
+		return "p95"
+	case optimizeappsv1alpha1.LatencyPercentile99:
+		return "p99"
+	default:
+		return ""
+	}
+}
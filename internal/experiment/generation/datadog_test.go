@@ -0,0 +1,144 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestDatadogApplicationMetricsSource_Metrics(t *testing.T) {
+	dd := &optimizeappsv1alpha1.Datadog{
+		SecretName: "datadog",
+		Service:    "web",
+		Queries:    map[string]string{"custom": "avg:web.queue.depth{*}"},
+	}
+
+	cases := []struct {
+		desc     string
+		goal     optimizeappsv1alpha1.Goal
+		expected []optimizev1beta2.Metric
+	}{
+		{
+			desc: "latency",
+			goal: optimizeappsv1alpha1.Goal{
+				Name:    "p95-latency",
+				Latency: &optimizeappsv1alpha1.LatencyGoal{LatencyType: "p95"},
+			},
+			expected: []optimizev1beta2.Metric{
+				{
+					Name:                 "p95-latency",
+					Type:                 optimizev1beta2.MetricDatadog,
+					Query:                "p95:trace.http.request{service:web}",
+					Minimize:             true,
+					CredentialsSecretRef: &corev1.LocalObjectReference{Name: "datadog"},
+				},
+			},
+		},
+		{
+			desc: "error rate",
+			goal: optimizeappsv1alpha1.Goal{
+				Name:      "error-rate",
+				ErrorRate: &optimizeappsv1alpha1.ErrorRateGoal{ErrorRateType: optimizeappsv1alpha1.ErrorRateRequests},
+			},
+			expected: []optimizev1beta2.Metric{
+				{
+					Name:                 "error-rate",
+					Type:                 optimizev1beta2.MetricDatadog,
+					Query:                "sum:trace.http.request.errors{service:web}.as_count() / sum:trace.http.request.hits{service:web}.as_count()",
+					Minimize:             true,
+					CredentialsSecretRef: &corev1.LocalObjectReference{Name: "datadog"},
+				},
+			},
+		},
+		{
+			desc: "throughput",
+			goal: optimizeappsv1alpha1.Goal{
+				Name:       "throughput",
+				Throughput: &optimizeappsv1alpha1.ThroughputGoal{ThroughputType: optimizeappsv1alpha1.ThroughputRequests},
+			},
+			expected: []optimizev1beta2.Metric{
+				{
+					Name:                 "throughput",
+					Type:                 optimizev1beta2.MetricDatadog,
+					Query:                "sum:trace.http.request.hits{service:web}.as_rate()",
+					CredentialsSecretRef: &corev1.LocalObjectReference{Name: "datadog"},
+				},
+			},
+		},
+		{
+			desc: "cost",
+			goal: optimizeappsv1alpha1.Goal{
+				Name: "cost",
+				Requests: &optimizeappsv1alpha1.RequestsGoal{
+					Selector: "app=web",
+					Weights: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("17"),
+						corev1.ResourceMemory: resource.MustParse("2"),
+					},
+				},
+			},
+			expected: []optimizev1beta2.Metric{
+				{
+					Name:                 "cost",
+					Type:                 optimizev1beta2.MetricDatadog,
+					Query:                "(sum:kubernetes.cpu.requests{app:web,kube_namespace:default} * 17) + (sum:kubernetes.memory.requests{app:web,kube_namespace:default} / 1000000000 * 2)",
+					Minimize:             true,
+					CredentialsSecretRef: &corev1.LocalObjectReference{Name: "datadog"},
+				},
+			},
+		},
+		{
+			desc: "query override",
+			goal: optimizeappsv1alpha1.Goal{
+				Name: "custom",
+			},
+			expected: []optimizev1beta2.Metric{
+				{
+					Name:                 "custom",
+					Type:                 optimizev1beta2.MetricDatadog,
+					Query:                "avg:web.queue.depth{*}",
+					Minimize:             true,
+					CredentialsSecretRef: &corev1.LocalObjectReference{Name: "datadog"},
+				},
+			},
+		},
+		{
+			desc: "already implemented",
+			goal: optimizeappsv1alpha1.Goal{
+				Name:        "p50-latency",
+				Latency:     &optimizeappsv1alpha1.LatencyGoal{LatencyType: "p50"},
+				Implemented: true,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := &DatadogApplicationMetricsSource{Datadog: dd, Namespace: "default", Goal: &c.goal}
+			actual, err := s.Metrics()
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, actual)
+				assert.True(t, c.goal.Implemented)
+			}
+		})
+	}
+}
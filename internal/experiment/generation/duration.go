@@ -17,12 +17,20 @@ limitations under the License.
 package generation
 
 import (
+	"fmt"
+	"strings"
+
 	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 type DurationMetricsSource struct {
 	Goal *optimizeappsv1alpha1.Goal
+	// The pod label selectors of the application workloads.
+	WorkloadSelectors []string
 }
 
 var _ MetricSource = &DurationMetricsSource{}
@@ -38,7 +46,57 @@ func (s *DurationMetricsSource) Metrics() ([]optimizev1beta2.Metric, error) {
 		m := newGoalMetric(s.Goal, `{{ duration .StartTime .CompletionTime }}`)
 		m.Type = ""
 		result = append(result, m)
+
+	case optimizeappsv1alpha1.DurationStartup:
+		// Only consider the pods of the application workloads, i.e. exclude the trial job and setup task pods
+		var args []string
+		for _, sel := range s.WorkloadSelectors {
+			args = append(args, fmt.Sprintf(" %q", sel))
+		}
+		m := newGoalMetric(s.Goal, fmt.Sprintf(`{{ startupTime .Target .Trial.CreationTimestamp.Time%s }}`, strings.Join(args, "")))
+		m.Type = optimizev1beta2.MetricKubernetes
+		m.Target = &optimizev1beta2.ResourceTarget{
+			APIVersion: "v1",
+			Kind:       "PodList",
+			LabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: optimizev1beta2.LabelTrialRole, Operator: metav1.LabelSelectorOpDoesNotExist},
+				},
+			},
+		}
+		result = append(result, m)
 	}
 
 	return result, nil
 }
+
+// workloadSelector returns the pod label selector of a workload resource, or an empty string for any other resource.
+func workloadSelector(node *yaml.RNode) (string, error) {
+	meta, err := node.GetMeta()
+	if err != nil {
+		return "", err
+	}
+
+	switch meta.Kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet":
+	default:
+		return "", nil
+	}
+
+	selectorNode, err := node.Pipe(yaml.Lookup("spec", "selector"))
+	if err != nil || selectorNode == nil {
+		return "", err
+	}
+
+	labelSelector := &metav1.LabelSelector{}
+	if err := sfio.DecodeYAMLToJSON(selectorNode, labelSelector); err != nil {
+		return "", err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil || selector.Empty() {
+		return "", err
+	}
+
+	return selector.String(), nil
+}
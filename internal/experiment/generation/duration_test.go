@@ -0,0 +1,114 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestApplicationSelector_DurationStartup(t *testing.T) {
+	cases := []struct {
+		desc     string
+		nodes    []string
+		expected string
+	}{
+		{
+			desc:     "no workloads",
+			expected: `{{ startupTime .Target .Trial.CreationTimestamp.Time }}`,
+		},
+		{
+			desc: "workloads",
+			nodes: []string{
+				`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+`,
+				`
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  selector:
+    matchExpressions:
+    - key: component
+      operator: In
+      values: [db, cache]
+`,
+				`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+`,
+			},
+			expected: `{{ startupTime .Target .Trial.CreationTimestamp.Time "app=web" "component in (cache,db)" }}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var nodes []*yaml.RNode
+			for _, n := range c.nodes {
+				nodes = append(nodes, yaml.MustParse(n))
+			}
+
+			s := &ApplicationSelector{
+				Objective: &optimizeappsv1alpha1.Objective{
+					Goals: []optimizeappsv1alpha1.Goal{
+						{
+							Name:     "startup-time",
+							Duration: &optimizeappsv1alpha1.DurationGoal{DurationType: optimizeappsv1alpha1.DurationStartup},
+						},
+					},
+				},
+			}
+
+			selected, err := s.Select(nodes)
+			if !assert.NoError(t, err) {
+				return
+			}
+			mapped, err := s.Map(selected[0], yaml.ResourceMeta{})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, m := range mapped {
+				if ms, ok := m.(*DurationMetricsSource); ok {
+					metrics, err := ms.Metrics()
+					if assert.NoError(t, err) && assert.Len(t, metrics, 1) {
+						assert.Equal(t, c.expected, metrics[0].Query)
+					}
+					return
+				}
+			}
+			assert.Fail(t, "missing duration metrics source")
+		})
+	}
+}
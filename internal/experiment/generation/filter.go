@@ -23,6 +23,7 @@ import (
 	"github.com/thestormforge/konjure/pkg/filters"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
+	appsv1 "k8s.io/api/apps/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"sigs.k8s.io/kustomize/kyaml/openapi"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
@@ -59,7 +60,7 @@ func SetNamespace(namespace string) yaml.Filter {
 				yaml.SetK8sNamespace(namespace),
 			),
 			yaml.Tee(
-				isClusterRoleOrBinding(),
+				isRoleOrBinding(),
 				yaml.Get("subjects"),
 				yaml.GetElementByKey("name"),
 				&yaml.FieldMatcher{Name: "namespace", Create: yaml.NewScalarRNode(namespace)},
@@ -69,7 +70,7 @@ func SetNamespace(namespace string) yaml.Filter {
 }
 
 // SetExperimentName sets the name on the experiment. In addition, the experiment name is set as a
-// suffix on any generated cluster roles or cluster role bindings.
+// suffix on any generated roles or role bindings.
 func SetExperimentName(name string) yaml.Filter {
 	return yaml.FilterFunc(func(node *yaml.RNode) (*yaml.RNode, error) {
 		suffix := &yaml.SuffixSetter{Value: fmt.Sprintf("-%x", sha256.Sum256([]byte(name)))[0:7]}
@@ -85,13 +86,21 @@ func SetExperimentName(name string) yaml.Filter {
 					yaml.Lookup("spec", "trialTemplate", "spec"),
 					yaml.Tee(yaml.Lookup("setupServiceAccountName"), suffix),
 					yaml.Lookup("jobTemplate", "spec", "template", "spec"),
+					yaml.Tee(yaml.Lookup("serviceAccountName"), suffix),
 					sfio.TeeMatched(sfio.PathMatcher("containers", "[name=]", "env", "[name=STORMFORGER_JWT]", "valueFrom", "secretKeyRef", "name"), suffix),
-					sfio.TeeMatched(sfio.PathMatcher("volumes", "[name=test-case-file|locustfile]", "configMap", "name"), suffix),
+					sfio.TeeMatched(sfio.PathMatcher("volumes", "[name=test-case-file|locustfile|scenario-data-source]", "configMap", "name"), suffix),
 				),
 			),
 
 			yaml.Tee(
-				isClusterRoleOrBinding(),
+				isDeployment(),
+
+				// Update experiment specific resource references (e.g. from the Locust workers)
+				sfio.TeeMatched(sfio.PathMatcher("spec", "template", "spec", "volumes", "[name=locustfile]", "configMap", "name"), suffix),
+			),
+
+			yaml.Tee(
+				isRoleOrBinding(),
 
 				// Update experiment specific resource references
 				yaml.Tee(yaml.Lookup("roleRef", "name"), suffix),
@@ -108,6 +117,18 @@ func SetExperimentName(name string) yaml.Filter {
 	})
 }
 
+// SetExperimentOwner labels the cluster scoped resources generated for an experiment so they can be found (and pruned)
+// once the experiment no longer exists.
+func SetExperimentOwner(name string) yaml.Filter {
+	return yaml.FilterFunc(func(node *yaml.RNode) (*yaml.RNode, error) {
+		return node.Pipe(yaml.Tee(
+			isClusterRoleOrBinding(),
+			yaml.Tee(yaml.SetLabel(optimizev1beta2.LabelExperiment, name)),
+			yaml.Tee(yaml.SetLabel(optimizev1beta2.LabelTrialRole, "trialSetup")),
+		))
+	})
+}
+
 func isExperiment() yaml.Filter {
 	return filters.FilterOne(&filters.ResourceMetaFilter{
 		Group:   optimizev1beta2.GroupVersion.Group,
@@ -116,6 +137,22 @@ func isExperiment() yaml.Filter {
 	})
 }
 
+func isDeployment() yaml.Filter {
+	return filters.FilterOne(&filters.ResourceMetaFilter{
+		Group:   appsv1.SchemeGroupVersion.Group,
+		Version: appsv1.SchemeGroupVersion.Version,
+		Kind:    "Deployment",
+	})
+}
+
+func isRoleOrBinding() yaml.Filter {
+	return filters.FilterOne(&filters.ResourceMetaFilter{
+		Group:   rbacv1.SchemeGroupVersion.Group,
+		Version: rbacv1.SchemeGroupVersion.Version,
+		Kind:    "Role|RoleBinding|ClusterRole|ClusterRoleBinding",
+	})
+}
+
 func isClusterRoleOrBinding() yaml.Filter {
 	return filters.FilterOne(&filters.ResourceMetaFilter{
 		Group:   rbacv1.SchemeGroupVersion.Group,
@@ -139,6 +176,6 @@ func isNamespaceScoped() yaml.Filter {
 
 func isExperimentSpecific() yaml.Filter {
 	return filters.FilterOne(&filters.ResourceMetaFilter{
-		Kind: "ConfigMap|Secret|ServiceAccount|ClusterRole|ClusterRoleBinding",
+		Kind: "ConfigMap|Secret|ServiceAccount|Role|RoleBinding|ClusterRole|ClusterRoleBinding",
 	})
 }
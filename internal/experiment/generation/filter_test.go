@@ -0,0 +1,67 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestSetExperimentOwner(t *testing.T) {
+	cases := []struct {
+		desc     string
+		node     string
+		expected map[string]string
+	}{
+		{
+			desc: "cluster role binding",
+			node: `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: optimize-setup-prometheus
+`,
+			expected: map[string]string{
+				optimizev1beta2.LabelExperiment: "test",
+				optimizev1beta2.LabelTrialRole:  "trialSetup",
+			},
+		},
+		{
+			desc: "role binding",
+			node: `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: optimize-setup-prometheus
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			node := yaml.MustParse(c.node)
+			if assert.NoError(t, node.PipeE(SetExperimentOwner("test"))) {
+				labels, err := node.GetLabels()
+				if assert.NoError(t, err) {
+					assert.Equal(t, c.expected, labels)
+				}
+			}
+		})
+	}
+}
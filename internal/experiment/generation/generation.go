@@ -42,6 +42,7 @@ func newGoalMetric(obj *optimizeappsv1alpha1.Goal, query string) optimizev1beta2
 		Min:      obj.Min,
 		Max:      obj.Max,
 		Optimize: obj.Optimize,
+		Weight:   obj.Weight,
 	}
 }
 
@@ -0,0 +1,96 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"fmt"
+
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// JobOverridesSource merges user supplied customizations into the generated trial job.
+type JobOverridesSource struct {
+	JobOverrides *optimizeappsv1alpha1.JobOverrides
+}
+
+var _ ExperimentSource = &JobOverridesSource{} // Update trial job
+
+func (s *JobOverridesSource) Update(exp *optimizev1beta2.Experiment) error {
+	if s.JobOverrides == nil {
+		return nil
+	}
+
+	pod := &ensureTrialJobPod(exp).Spec
+
+	// Resources and environment only apply to the generated (load generating) containers
+	for i := range pod.Containers {
+		c := &pod.Containers[i]
+		if s.JobOverrides.Resources != nil {
+			c.Resources.Requests = mergeResourceList(c.Resources.Requests, s.JobOverrides.Resources.Requests)
+			c.Resources.Limits = mergeResourceList(c.Resources.Limits, s.JobOverrides.Resources.Limits)
+		}
+		for _, env := range s.JobOverrides.Env {
+			c.Env = mergeEnvVar(c.Env, env)
+		}
+	}
+
+	pod.Tolerations = append(pod.Tolerations, s.JobOverrides.Tolerations...)
+
+	for i := range s.JobOverrides.Containers {
+		c := &s.JobOverrides.Containers[i]
+		for j := range pod.Containers {
+			if pod.Containers[j].Name == c.Name {
+				return fmt.Errorf("job override container %q conflicts with a generated container", c.Name)
+			}
+		}
+		pod.Containers = append(pod.Containers, *c.DeepCopy())
+	}
+
+	if s.JobOverrides.TTLSecondsAfterFinished != nil {
+		ttl := *s.JobOverrides.TTLSecondsAfterFinished
+		exp.Spec.TrialTemplate.Spec.JobTemplate.Spec.TTLSecondsAfterFinished = &ttl
+	}
+
+	return nil
+}
+
+// mergeEnvVar replaces the environment variable with the same name or appends it to the list.
+func mergeEnvVar(env []corev1.EnvVar, e corev1.EnvVar) []corev1.EnvVar {
+	for i := range env {
+		if env[i].Name == e.Name {
+			env[i] = *e.DeepCopy()
+			return env
+		}
+	}
+	return append(env, *e.DeepCopy())
+}
+
+// mergeResourceList overwrites the quantities in the list with the overrides.
+func mergeResourceList(rl corev1.ResourceList, overrides corev1.ResourceList) corev1.ResourceList {
+	if len(overrides) == 0 {
+		return rl
+	}
+	if rl == nil {
+		rl = make(corev1.ResourceList, len(overrides))
+	}
+	for name, q := range overrides {
+		rl[name] = q.DeepCopy()
+	}
+	return rl
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestJobOverridesSource_Update(t *testing.T) {
+	ttl := int32(600)
+
+	cases := []struct {
+		desc         string
+		jobOverrides optimizeappsv1alpha1.JobOverrides
+		expected     corev1.PodSpec
+		expectedTTL  *int32
+		expectedErr  string
+	}{
+		{
+			desc: "resources and env",
+			jobOverrides: optimizeappsv1alpha1.JobOverrides{
+				Resources: &corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+				Env: []corev1.EnvVar{
+					{Name: "USERS", Value: "100"},
+					{Name: "HTTPS_PROXY", Value: "http://proxy:3128"},
+				},
+			},
+			expected: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name: "load",
+						Env: []corev1.EnvVar{
+							{Name: "USERS", Value: "100"},
+							{Name: "HTTPS_PROXY", Value: "http://proxy:3128"},
+						},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+							Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "tolerations, containers and ttl",
+			jobOverrides: optimizeappsv1alpha1.JobOverrides{
+				Tolerations:             []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+				Containers:              []corev1.Container{{Name: "proxy", Image: "envoyproxy/envoy"}},
+				TTLSecondsAfterFinished: &ttl,
+			},
+			expected: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name: "load",
+						Env:  []corev1.EnvVar{{Name: "USERS", Value: "10"}},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+						},
+					},
+					{Name: "proxy", Image: "envoyproxy/envoy"},
+				},
+				Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+			},
+			expectedTTL: &ttl,
+		},
+		{
+			desc: "conflicting container",
+			jobOverrides: optimizeappsv1alpha1.JobOverrides{
+				Containers: []corev1.Container{{Name: "load"}},
+			},
+			expectedErr: `job override container "load" conflicts with a generated container`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{}
+			pod := &ensureTrialJobPod(exp).Spec
+			pod.Containers = []corev1.Container{
+				{
+					Name: "load",
+					Env:  []corev1.EnvVar{{Name: "USERS", Value: "10"}},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+					},
+				},
+			}
+
+			s := &JobOverridesSource{JobOverrides: &c.jobOverrides}
+			err := s.Update(exp)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, *pod)
+				assert.Equal(t, c.expectedTTL, exp.Spec.TrialTemplate.Spec.JobTemplate.Spec.TTLSecondsAfterFinished)
+			}
+		})
+	}
+}
@@ -18,15 +18,28 @@ package generation
 
 import (
 	"fmt"
+	"path"
 
 	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
+// locustMasterPort is the port the Locust master listens on for worker connections.
+const locustMasterPort = 5557
+
+var locustfileMount = corev1.VolumeMount{
+	Name:      "locustfile",
+	ReadOnly:  true,
+	MountPath: "/mnt/locust",
+}
+
 type LocustSource struct {
 	Scenario    *optimizeappsv1alpha1.Scenario
 	Objective   *optimizeappsv1alpha1.Objective
@@ -35,41 +48,25 @@ type LocustSource struct {
 
 var _ ExperimentSource = &LocustSource{} // Update trial job
 var _ MetricSource = &LocustSource{}     // Locust specific metrics
-var _ kio.Reader = &LocustSource{}       // ConfigMap for the locustfile.py
+var _ kio.Reader = &LocustSource{}       // ConfigMap for the locustfile.py, workers
 
 func (s *LocustSource) Update(exp *optimizev1beta2.Experiment) error {
 	if s.Scenario == nil || s.Application == nil {
 		return nil
 	}
 
-	pod := &ensureTrialJobPod(exp).Spec
+	template := ensureTrialJobPod(exp)
+	pod := &template.Spec
 	pod.Containers = []corev1.Container{
 		{
-			Name:  s.Scenario.Name,
-			Image: trialJobImage("locust"),
-			Env:   s.locustEnv(),
-			VolumeMounts: []corev1.VolumeMount{
-				{
-					Name:      "locustfile",
-					ReadOnly:  true,
-					MountPath: "/mnt/locust",
-				},
-			},
+			Name:         s.Scenario.Name,
+			Image:        s.locustImage(),
+			Env:          s.locustEnv(),
+			VolumeMounts: []corev1.VolumeMount{locustfileMount},
 		},
 	}
 
-	pod.Volumes = []corev1.Volume{
-		{
-			Name: "locustfile",
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: s.locustConfigMapName(),
-					},
-				},
-			},
-		},
-	}
+	pod.Volumes = []corev1.Volume{s.locustfileVolume()}
 
 	// TODO We need to rethink how ingress scanning works, this just preserves existing behavior
 	var ingressURL string
@@ -81,6 +78,24 @@ func (s *LocustSource) Update(exp *optimizev1beta2.Experiment) error {
 	}
 	pod.Containers[0].Env = append(pod.Containers[0].Env, corev1.EnvVar{Name: "HOST", Value: ingressURL})
 
+	// The trial job runs the master, the workers connect to it through the master service
+	if workers := s.workers(); workers > 0 {
+		pod.Containers[0].Env = append(pod.Containers[0].Env,
+			corev1.EnvVar{Name: "LOCUST_MODE_MASTER", Value: "true"},
+			corev1.EnvVar{Name: "LOCUST_EXPECT_WORKERS", Value: fmt.Sprintf("%d", workers)},
+		)
+		pod.Containers[0].Ports = []corev1.ContainerPort{{Name: "master", ContainerPort: locustMasterPort}}
+
+		if template.Labels == nil {
+			template.Labels = map[string]string{}
+		}
+		for k, v := range s.masterLabels() {
+			template.Labels[k] = v
+		}
+	}
+
+	s.finishPod(pod)
+
 	return nil
 }
 
@@ -101,6 +116,51 @@ func (s *LocustSource) Read() ([]*yaml.RNode, error) {
 		return nil, fmt.Errorf("missing Locust file for scenario %q", s.Scenario.Name)
 	}
 
+	// Distributed load requires a service for the master and a deployment of the workers; the workers keep
+	// running between trials and reconnect to the master of the next trial once its job starts
+	if workers := int32(s.workers()); workers > 0 {
+		svc := &corev1.Service{}
+		svc.Name = s.locustMasterServiceName()
+		svc.Spec.Selector = s.masterLabels()
+		svc.Spec.Ports = []corev1.ServicePort{
+			{
+				Name:       "master",
+				Port:       locustMasterPort,
+				TargetPort: intstr.FromString("master"),
+			},
+		}
+		result = append(result, svc)
+
+		workerLabels := map[string]string{
+			optimizeappsv1alpha1.LabelApplication: s.Application.Name,
+			optimizeappsv1alpha1.LabelScenario:    s.Scenario.Name,
+			"app.kubernetes.io/component":         "locust-worker",
+		}
+
+		deploy := &appsv1.Deployment{}
+		deploy.Name = s.locustWorkerName()
+		deploy.Spec.Replicas = &workers
+		deploy.Spec.Selector = &metav1.LabelSelector{MatchLabels: workerLabels}
+		deploy.Spec.Template.Labels = workerLabels
+		deploy.Spec.Template.Spec.Containers = []corev1.Container{
+			{
+				Name:    "worker",
+				Image:   s.locustImage(),
+				Command: []string{"locust"},
+				Env: []corev1.EnvVar{
+					{Name: "LOCUST_MODE_WORKER", Value: "true"},
+					{Name: "LOCUST_MASTER_NODE_HOST", Value: svc.Name},
+					{Name: "LOCUST_MASTER_NODE_PORT", Value: fmt.Sprintf("%d", locustMasterPort)},
+					{Name: "LOCUST_LOCUSTFILE", Value: path.Join(locustfileMount.MountPath, "locustfile.py")},
+				},
+				VolumeMounts: []corev1.VolumeMount{locustfileMount},
+			},
+		}
+		deploy.Spec.Template.Spec.Volumes = []corev1.Volume{s.locustfileVolume()}
+		s.finishPod(&deploy.Spec.Template.Spec)
+		result = append(result, deploy)
+	}
+
 	return result.Read()
 }
 
@@ -129,6 +189,14 @@ func (s *LocustSource) Metrics() ([]optimizev1beta2.Metric, error) {
 				result = append(result, newGoalMetric(goal, query))
 			}
 
+		case goal.Throughput != nil:
+			if goal.Throughput.ThroughputType == optimizeappsv1alpha1.ThroughputRequests {
+				query := `scalar(request_count{job="trialRun",instance="{{ .Trial.Name }}"}) / {{ duration .StartTime .CompletionTime }}`
+				m := newGoalMetric(goal, query)
+				m.Minimize = false
+				result = append(result, m)
+			}
+
 		}
 	}
 
@@ -139,6 +207,84 @@ func (s *LocustSource) locustConfigMapName() string {
 	return fmt.Sprintf("%s-locustfile", s.Scenario.Name)
 }
 
+func (s *LocustSource) locustMasterServiceName() string {
+	return fmt.Sprintf("%s-locust-master", s.Scenario.Name)
+}
+
+func (s *LocustSource) locustWorkerName() string {
+	return fmt.Sprintf("%s-locust-worker", s.Scenario.Name)
+}
+
+func (s *LocustSource) locustImage() string {
+	if s.Scenario.Locust.Image != "" {
+		return s.Scenario.Locust.Image
+	}
+	return trialJobImage("locust")
+}
+
+func (s *LocustSource) workers() int {
+	if s.Scenario.Locust.Workers != nil {
+		return *s.Scenario.Locust.Workers
+	}
+	return 0
+}
+
+// masterLabels returns the labels used to select the trial job pod running the Locust master.
+func (s *LocustSource) masterLabels() map[string]string {
+	return map[string]string{
+		optimizeappsv1alpha1.LabelApplication: s.Application.Name,
+		optimizeappsv1alpha1.LabelScenario:    s.Scenario.Name,
+		optimizev1beta2.LabelTrialRole:        "trialRun",
+	}
+}
+
+func (s *LocustSource) locustfileVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: locustfileMount.Name,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: s.locustConfigMapName(),
+				},
+			},
+		},
+	}
+}
+
+// finishPod installs the additional requirements and adds the user supplied environment to the Locust containers.
+func (s *LocustSource) finishPod(pod *corev1.PodSpec) {
+	// Install the additional requirements into a shared volume before Locust starts
+	if len(s.Scenario.Locust.Requirements) > 0 {
+		packagesMount := corev1.VolumeMount{
+			Name:      "locust-packages",
+			MountPath: "/mnt/locust-packages",
+		}
+
+		pod.InitContainers = append(pod.InitContainers, corev1.Container{
+			Name:         "pip-install",
+			Image:        s.locustImage(),
+			Command:      append([]string{"pip", "install", "--no-cache-dir", "--target", packagesMount.MountPath}, s.Scenario.Locust.Requirements...),
+			VolumeMounts: []corev1.VolumeMount{packagesMount},
+		})
+
+		pod.Volumes = append(pod.Volumes, corev1.Volume{
+			Name:         packagesMount.Name,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+
+		packagesMount.ReadOnly = true
+		for i := range pod.Containers {
+			pod.Containers[i].Env = append(pod.Containers[i].Env, corev1.EnvVar{Name: "PYTHONPATH", Value: packagesMount.MountPath})
+			pod.Containers[i].VolumeMounts = append(pod.Containers[i].VolumeMounts, packagesMount)
+		}
+	}
+
+	// Add the user supplied environment last so it can override the generated values
+	for i := range pod.Containers {
+		pod.Containers[i].Env = append(pod.Containers[i].Env, s.Scenario.Locust.Env...)
+	}
+}
+
 func (s *LocustSource) locustEnv() []corev1.EnvVar {
 	var env []corev1.EnvVar
 
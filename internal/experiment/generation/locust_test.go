@@ -0,0 +1,145 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLocustSource_Update(t *testing.T) {
+	two := 2
+
+	cases := []struct {
+		desc               string
+		locust             optimizeappsv1alpha1.LocustScenario
+		expectedContainers []string
+		expectedInit       []string
+		expectedImage      string
+	}{
+		{
+			desc:               "default",
+			expectedContainers: []string{"test"},
+			expectedImage:      trialJobImage("locust"),
+		},
+		{
+			desc: "distributed",
+			locust: optimizeappsv1alpha1.LocustScenario{
+				Workers:      &two,
+				Image:        "example.com/locust:custom",
+				Requirements: []string{"faker==8.0.0"},
+				Env:          []corev1.EnvVar{{Name: "API_KEY", Value: "secret"}},
+			},
+			expectedContainers: []string{"test"},
+			expectedInit:       []string{"pip-install"},
+			expectedImage:      "example.com/locust:custom",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := &LocustSource{
+				Scenario: &optimizeappsv1alpha1.Scenario{Name: "test", Locust: &c.locust},
+				Application: &optimizeappsv1alpha1.Application{
+					Ingress: &optimizeappsv1alpha1.Ingress{URL: "http://example.com"},
+				},
+			}
+
+			exp := &optimizev1beta2.Experiment{}
+			if !assert.NoError(t, s.Update(exp)) {
+				return
+			}
+
+			pod := exp.Spec.TrialTemplate.Spec.JobTemplate.Spec.Template.Spec
+			var containers, initContainers []string
+			for _, container := range pod.Containers {
+				containers = append(containers, container.Name)
+				assert.Equal(t, c.expectedImage, container.Image)
+				for _, env := range c.locust.Env {
+					assert.Contains(t, container.Env, env)
+				}
+				if len(c.locust.Requirements) > 0 {
+					assert.Contains(t, container.Env, corev1.EnvVar{Name: "PYTHONPATH", Value: "/mnt/locust-packages"})
+				}
+			}
+			for _, container := range pod.InitContainers {
+				initContainers = append(initContainers, container.Name)
+			}
+			assert.Equal(t, c.expectedContainers, containers)
+			assert.Equal(t, c.expectedInit, initContainers)
+
+			if c.locust.Workers != nil {
+				assert.Contains(t, pod.Containers[0].Env, corev1.EnvVar{Name: "LOCUST_EXPECT_WORKERS", Value: "2"})
+				assert.Equal(t, "test", exp.Spec.TrialTemplate.Spec.JobTemplate.Spec.Template.Labels[optimizeappsv1alpha1.LabelScenario])
+			}
+		})
+	}
+}
+
+func TestLocustSource_Read(t *testing.T) {
+	two := 2
+
+	s := &LocustSource{
+		Scenario: &optimizeappsv1alpha1.Scenario{
+			Name: "test",
+			Locust: &optimizeappsv1alpha1.LocustScenario{
+				Locustfile:   "from locust import HttpUser\n",
+				Workers:      &two,
+				Requirements: []string{"faker==8.0.0"},
+			},
+		},
+		Application: &optimizeappsv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "app"},
+		},
+	}
+	nodes, err := s.Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var kinds []string
+	for _, node := range nodes {
+		kinds = append(kinds, node.GetKind())
+	}
+	assert.Equal(t, []string{"ConfigMap", "Service", "Deployment"}, kinds)
+
+	svc := &corev1.Service{}
+	if assert.NoError(t, sfio.DecodeYAMLToJSON(nodes[1], svc)) {
+		assert.Equal(t, "test-locust-master", svc.Name)
+		assert.Equal(t, "trialRun", svc.Spec.Selector[optimizev1beta2.LabelTrialRole])
+		assert.Equal(t, "test", svc.Spec.Selector[optimizeappsv1alpha1.LabelScenario])
+	}
+
+	deploy := &appsv1.Deployment{}
+	if assert.NoError(t, sfio.DecodeYAMLToJSON(nodes[2], deploy)) {
+		assert.Equal(t, int32(2), *deploy.Spec.Replicas)
+		pod := deploy.Spec.Template.Spec
+		if assert.Len(t, pod.Containers, 1) {
+			assert.Contains(t, pod.Containers[0].Env, corev1.EnvVar{Name: "LOCUST_MASTER_NODE_HOST", Value: "test-locust-master"})
+		}
+		if assert.Len(t, pod.InitContainers, 1) {
+			assert.Equal(t, "pip-install", pod.InitContainers[0].Name)
+		}
+		assert.Equal(t, "test-locustfile", pod.Volumes[0].ConfigMap.Name)
+	}
+}
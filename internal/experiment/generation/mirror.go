@@ -0,0 +1,246 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// defaultMirrorObservation is the amount of time traffic is mirrored when the scenario does not specify it.
+const defaultMirrorObservation = 5 * time.Minute
+
+// MirrorSource mirrors production traffic to the application being tuned instead of generating synthetic load. A
+// setup task creates the mirroring configuration before the trial starts and deletes it once the trial completes,
+// the trial job itself just waits for the observation window to elapse.
+type MirrorSource struct {
+	Scenario           *optimizeappsv1alpha1.Scenario
+	Application        *optimizeappsv1alpha1.Application
+	SetupTaskName      string
+	ServiceAccountName string
+	RoleName           string
+	RoleBindingName    string
+
+	sfio.ObjectSlice
+}
+
+var _ ExperimentSource = &MirrorSource{} // Setup task and trial duration
+var _ kio.Reader = &MirrorSource{}       // ConfigMap for the mirroring configuration and RBAC
+
+func (s *MirrorSource) Update(exp *optimizev1beta2.Experiment) error {
+	if s.Scenario == nil || s.Application == nil {
+		return nil
+	}
+
+	mirror := s.Scenario.Mirror
+	if mirror.Service == "" || mirror.MirrorService == "" {
+		return fmt.Errorf("mirror scenario %q must specify both the service and the mirror service", s.Scenario.Name)
+	}
+
+	manifest, rule, err := s.mirrorManifest()
+	if err != nil {
+		return err
+	}
+
+	// Without a trial job the controller runs a container that sleeps for the approximate runtime
+	d := defaultMirrorObservation
+	if mirror.ObservationSeconds > 0 {
+		d = time.Duration(mirror.ObservationSeconds) * time.Second
+	}
+	exp.Spec.TrialTemplate.Spec.ApproximateRuntime = &metav1.Duration{Duration: d}
+
+	// The setup tools image builds (and creates or deletes) every manifest in its working directory
+	volumeName := s.mirrorConfigMapName()
+	exp.Spec.TrialTemplate.Spec.SetupTasks = append(exp.Spec.TrialTemplate.Spec.SetupTasks, optimizev1beta2.SetupTask{
+		Name: s.SetupTaskName,
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      volumeName,
+				ReadOnly:  true,
+				MountPath: path.Join("/home/setup/base", "traffic-mirror.yaml"),
+				SubPath:   "traffic-mirror.yaml",
+			},
+		},
+		Rules: []rbacv1.PolicyRule{rule},
+	})
+	exp.Spec.TrialTemplate.Spec.SetupVolumes = append(exp.Spec.TrialTemplate.Spec.SetupVolumes, corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: s.mirrorConfigMapName()},
+			},
+		},
+	})
+
+	if exp.Spec.TrialTemplate.Spec.SetupServiceAccountName == "" {
+		exp.Spec.TrialTemplate.Spec.SetupServiceAccountName = s.ServiceAccountName
+		s.ObjectSlice = append(s.ObjectSlice, &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: s.ServiceAccountName,
+			},
+		})
+	}
+
+	cm := &corev1.ConfigMap{}
+	cm.Name = s.mirrorConfigMapName()
+	cm.Data = map[string]string{"traffic-mirror.yaml": manifest}
+
+	s.ObjectSlice = append(s.ObjectSlice,
+		cm,
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: s.RoleName,
+			},
+			Rules: []rbacv1.PolicyRule{rule},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: s.RoleBindingName,
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "Role",
+				Name:     s.RoleName,
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind: rbacv1.ServiceAccountKind,
+					Name: exp.Spec.TrialTemplate.Spec.SetupServiceAccountName,
+				},
+			},
+		},
+	)
+
+	return nil
+}
+
+// mirrorManifest returns the provider specific resource used to mirror the traffic along with the
+// permissions required to manage it.
+func (s *MirrorSource) mirrorManifest() (string, rbacv1.PolicyRule, error) {
+	mirror := s.Scenario.Mirror
+	name := fmt.Sprintf("%s-mirror", s.Scenario.Name)
+
+	mirrorPort := mirror.MirrorPort
+	if mirrorPort == 0 {
+		mirrorPort = mirror.Port
+	}
+
+	var obj map[string]interface{}
+	var rule rbacv1.PolicyRule
+	switch mirror.Provider {
+
+	case optimizeappsv1alpha1.MirrorIstio, "":
+		destination := map[string]interface{}{"host": mirror.Service}
+		if mirror.Port > 0 {
+			destination["port"] = map[string]interface{}{"number": mirror.Port}
+		}
+		mirrorDestination := map[string]interface{}{"host": mirror.MirrorService}
+		if mirrorPort > 0 {
+			mirrorDestination["port"] = map[string]interface{}{"number": mirrorPort}
+		}
+
+		route := map[string]interface{}{
+			"route":  []interface{}{map[string]interface{}{"destination": destination}},
+			"mirror": mirrorDestination,
+		}
+		if mirror.Percentage != nil {
+			route["mirrorPercentage"] = map[string]interface{}{"value": *mirror.Percentage}
+		}
+
+		obj = map[string]interface{}{
+			"apiVersion": "networking.istio.io/v1beta1",
+			"kind":       "VirtualService",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec": map[string]interface{}{
+				"hosts": []interface{}{mirror.Service},
+				"http":  []interface{}{route},
+			},
+		}
+		rule = rbacv1.PolicyRule{
+			Verbs:     []string{"get", "create", "delete"},
+			APIGroups: []string{"networking.istio.io"},
+			Resources: []string{"virtualservices"},
+		}
+
+	case optimizeappsv1alpha1.MirrorLinkerd, optimizeappsv1alpha1.MirrorGatewayAPI:
+		if mirror.Port == 0 {
+			return "", rule, fmt.Errorf("mirror scenario %q must specify the service port when using %s", s.Scenario.Name, mirror.Provider)
+		}
+
+		requestMirror := map[string]interface{}{
+			"backendRef": map[string]interface{}{"name": mirror.MirrorService, "port": mirrorPort},
+		}
+		if mirror.Percentage != nil {
+			requestMirror["percent"] = *mirror.Percentage
+		}
+
+		obj = map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1beta1",
+			"kind":       "HTTPRoute",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec": map[string]interface{}{
+				// Attaching the route to the service applies it to the mesh traffic (GAMMA)
+				"parentRefs": []interface{}{
+					map[string]interface{}{"group": "", "kind": "Service", "name": mirror.Service, "port": mirror.Port},
+				},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"filters": []interface{}{
+							map[string]interface{}{"type": "RequestMirror", "requestMirror": requestMirror},
+						},
+						"backendRefs": []interface{}{
+							map[string]interface{}{"name": mirror.Service, "port": mirror.Port},
+						},
+					},
+				},
+			},
+		}
+		rule = rbacv1.PolicyRule{
+			Verbs:     []string{"get", "create", "delete"},
+			APIGroups: []string{"gateway.networking.k8s.io"},
+			Resources: []string{"httproutes"},
+		}
+
+	default:
+		return "", rule, fmt.Errorf("unknown traffic mirroring provider %q", mirror.Provider)
+	}
+
+	node, err := yaml.FromMap(obj)
+	if err != nil {
+		return "", rule, err
+	}
+	manifest, err := node.String()
+	if err != nil {
+		return "", rule, err
+	}
+
+	return manifest, rule, nil
+}
+
+func (s *MirrorSource) mirrorConfigMapName() string {
+	return fmt.Sprintf("%s-traffic-mirror", s.Scenario.Name)
+}
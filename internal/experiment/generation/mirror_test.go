@@ -0,0 +1,180 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestMirrorSource_Update(t *testing.T) {
+	fifty := int32(50)
+
+	cases := []struct {
+		desc                string
+		mirror              optimizeappsv1alpha1.MirrorScenario
+		serviceAccountName  string
+		expectedRuntime     time.Duration
+		expectedManifest    string
+		expectedRule        rbacv1.PolicyRule
+		expectedObjectCount int
+		expectedError       string
+	}{
+		{
+			desc: "istio",
+			mirror: optimizeappsv1alpha1.MirrorScenario{
+				Service:       "frontend",
+				Port:          8080,
+				MirrorService: "frontend-canary",
+				Percentage:    &fifty,
+			},
+			expectedRuntime: defaultMirrorObservation,
+			expectedManifest: `apiVersion: networking.istio.io/v1beta1
+kind: VirtualService
+metadata:
+  name: test-mirror
+spec:
+  hosts:
+  - frontend
+  http:
+  - mirror:
+      host: frontend-canary
+      port:
+        number: 8080
+    mirrorPercentage:
+      value: 50
+    route:
+    - destination:
+        host: frontend
+        port:
+          number: 8080
+`,
+			expectedRule: rbacv1.PolicyRule{
+				Verbs:     []string{"get", "create", "delete"},
+				APIGroups: []string{"networking.istio.io"},
+				Resources: []string{"virtualservices"},
+			},
+			expectedObjectCount: 4,
+		},
+		{
+			desc: "gateway api",
+			mirror: optimizeappsv1alpha1.MirrorScenario{
+				Provider:           optimizeappsv1alpha1.MirrorGatewayAPI,
+				Service:            "frontend",
+				Port:               80,
+				MirrorService:      "frontend-canary",
+				MirrorPort:         8080,
+				ObservationSeconds: 600,
+			},
+			serviceAccountName: "existing",
+			expectedRuntime:    10 * time.Minute,
+			expectedManifest: `apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  name: test-mirror
+spec:
+  parentRefs:
+  - group: ""
+    kind: Service
+    name: frontend
+    port: 80
+  rules:
+  - backendRefs:
+    - name: frontend
+      port: 80
+    filters:
+    - requestMirror:
+        backendRef:
+          name: frontend-canary
+          port: 8080
+      type: RequestMirror
+`,
+			expectedRule: rbacv1.PolicyRule{
+				Verbs:     []string{"get", "create", "delete"},
+				APIGroups: []string{"gateway.networking.k8s.io"},
+				Resources: []string{"httproutes"},
+			},
+			expectedObjectCount: 3,
+		},
+		{
+			desc: "gateway api missing port",
+			mirror: optimizeappsv1alpha1.MirrorScenario{
+				Provider:      optimizeappsv1alpha1.MirrorLinkerd,
+				Service:       "frontend",
+				MirrorService: "frontend-canary",
+			},
+			expectedError: `mirror scenario "test" must specify the service port when using linkerd`,
+		},
+		{
+			desc: "missing mirror service",
+			mirror: optimizeappsv1alpha1.MirrorScenario{
+				Service: "frontend",
+			},
+			expectedError: `mirror scenario "test" must specify both the service and the mirror service`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := &MirrorSource{
+				Scenario:           &optimizeappsv1alpha1.Scenario{Name: "test", Mirror: &c.mirror},
+				Application:        &optimizeappsv1alpha1.Application{},
+				SetupTaskName:      "traffic-mirror",
+				ServiceAccountName: "optimize-setup",
+				RoleName:           "optimize-traffic-mirror",
+				RoleBindingName:    "optimize-setup-traffic-mirror",
+			}
+
+			exp := &optimizev1beta2.Experiment{}
+			exp.Spec.TrialTemplate.Spec.SetupServiceAccountName = c.serviceAccountName
+			err := s.Update(exp)
+			if c.expectedError != "" {
+				assert.EqualError(t, err, c.expectedError)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			spec := exp.Spec.TrialTemplate.Spec
+			assert.Nil(t, spec.JobTemplate)
+			assert.Equal(t, c.expectedRuntime, spec.ApproximateRuntime.Duration)
+			if assert.Len(t, spec.SetupTasks, 1) {
+				assert.Equal(t, "traffic-mirror", spec.SetupTasks[0].Name)
+				assert.Equal(t, []rbacv1.PolicyRule{c.expectedRule}, spec.SetupTasks[0].Rules)
+			}
+			assert.Len(t, spec.SetupVolumes, 1)
+			assert.NotEmpty(t, spec.SetupServiceAccountName)
+
+			assert.Len(t, s.ObjectSlice, c.expectedObjectCount)
+			for _, obj := range s.ObjectSlice {
+				switch o := obj.(type) {
+				case *corev1.ConfigMap:
+					assert.Equal(t, "test-traffic-mirror", o.Name)
+					assert.Equal(t, c.expectedManifest, o.Data["traffic-mirror.yaml"])
+				case *rbacv1.RoleBinding:
+					assert.Equal(t, spec.SetupServiceAccountName, o.Subjects[0].Name)
+				}
+			}
+		})
+	}
+}
@@ -17,9 +17,12 @@ limitations under the License.
 package generation
 
 import (
+	"regexp"
+
 	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -40,17 +43,74 @@ func (s *PrometheusMetricsSource) Metrics() ([]optimizev1beta2.Metric, error) {
 
 	m := newGoalMetric(s.Goal, s.Goal.Prometheus.Query)
 	m.URL = s.Goal.Prometheus.URL
+	if m.URL != "" && s.Goal.Prometheus.BearerTokenSecretRef != nil {
+		m.BearerTokenSecretRef = s.Goal.Prometheus.BearerTokenSecretRef.DeepCopy()
+	}
 	m.Minimize = !s.Goal.Prometheus.Maximize
 	result = append(result, m)
 
 	return result, nil
 }
 
+// ExternalPrometheus points the generated Prometheus metrics at an existing Prometheus
+// deployment instead of the one installed by the built-in setup task.
+type ExternalPrometheus struct {
+	URL                  string
+	BearerTokenSecretRef *corev1.SecretKeySelector
+}
+
+var _ ExperimentSource = &ExternalPrometheus{}
+
+func (p *ExternalPrometheus) Update(exp *optimizev1beta2.Experiment) error {
+	for i := range exp.Spec.Metrics {
+		m := &exp.Spec.Metrics[i]
+		if (m.Type != optimizev1beta2.MetricPrometheus && m.Type != optimizev1beta2.MetricPrometheusHistogram) || m.URL != "" {
+			continue
+		}
+
+		m.URL = p.URL
+		if p.BearerTokenSecretRef != nil {
+			m.BearerTokenSecretRef = p.BearerTokenSecretRef.DeepCopy()
+		}
+	}
+
+	return nil
+}
+
+// clusterScopedQuery matches rendered queries that depend on metrics the built-in Prometheus can only collect using
+// cluster scoped permissions, e.g. the cAdvisor metrics scraped from the nodes.
+var clusterScopedQuery = regexp.MustCompile(`\b(container_\w+|node_\w+|kube_node_\w+)\b`)
+
+// needsClusterScope checks to see if the rendered query of a metric depends on cluster scoped metrics, queries which
+// cannot be rendered ahead of a trial are assumed to need them.
+func needsClusterScope(exp *optimizev1beta2.Experiment, m *optimizev1beta2.Metric) bool {
+	now := metav1.Now()
+	t := &optimizev1beta2.Trial{}
+	t.Name = exp.Name
+	t.Namespace = exp.Namespace
+	t.Status.StartTime = &now
+	t.Status.CompletionTime = &now
+
+	q, eq, err := template.New().RenderMetricQueries(m, t, nil)
+	if err != nil {
+		return true
+	}
+	return clusterScopedQuery.MatchString(q) || clusterScopedQuery.MatchString(eq)
+}
+
+// BuiltInPrometheus adds the setup task and RBAC for the Prometheus installed into the trial namespace. When a role
+// name is configured and none of the metrics require cluster scoped permissions, only namespaced roles are generated.
+// When an exporters setup task name is configured, the built-in exporters are also installed into the trial namespace;
+// note that the exporters only provide the pod labels and resource requests, the container usage needed for the
+// utilization metrics is still scraped from the cAdvisor endpoint of each node.
 type BuiltInPrometheus struct {
 	SetupTaskName          string
+	ExportersSetupTaskName string
 	ClusterRoleName        string
 	ServiceAccountName     string
 	ClusterRoleBindingName string
+	RoleName               string
+	RoleBindingName        string
 
 	sfio.ObjectSlice
 }
@@ -60,11 +120,12 @@ var _ kio.Reader = &BuiltInPrometheus{}       // RBAC
 
 func (p *BuiltInPrometheus) Update(exp *optimizev1beta2.Experiment) error {
 	// Detect if we need built-in Prometheus by checking the generated metrics
-	var needsPrometheus bool
-	for _, m := range exp.Spec.Metrics {
-		if m.Type == optimizev1beta2.MetricPrometheus && m.URL == "" {
+	var needsPrometheus, clusterScoped bool
+	for i := range exp.Spec.Metrics {
+		m := &exp.Spec.Metrics[i]
+		if (m.Type == optimizev1beta2.MetricPrometheus || m.Type == optimizev1beta2.MetricPrometheusHistogram) && m.URL == "" {
 			needsPrometheus = true
-			break
+			clusterScoped = clusterScoped || needsClusterScope(exp, m)
 		}
 	}
 
@@ -72,73 +133,182 @@ func (p *BuiltInPrometheus) Update(exp *optimizev1beta2.Experiment) error {
 		return nil
 	}
 
+	// Without any cluster scoped metrics, everything can be granted using a role
+	namespacedOnly := p.RoleName != "" && !clusterScoped
+
+	setupTask := optimizev1beta2.SetupTask{
+		Name: p.SetupTaskName,
+		Args: []string{"prometheus", "$(MODE)"},
+	}
+	if namespacedOnly {
+		setupTask.Env = append(setupTask.Env, corev1.EnvVar{Name: "NAMESPACED_RBAC", Value: "true"})
+	}
+
+	// Other setup tasks may have already generated the service account
+	createServiceAccount := exp.Spec.TrialTemplate.Spec.SetupServiceAccountName != p.ServiceAccountName
+
 	exp.Spec.TrialTemplate.Spec.SetupServiceAccountName = p.ServiceAccountName
-	exp.Spec.TrialTemplate.Spec.SetupTasks = append(exp.Spec.TrialTemplate.Spec.SetupTasks,
-		optimizev1beta2.SetupTask{
-			Name: p.SetupTaskName,
-			Args: []string{"prometheus", "$(MODE)"},
+	exp.Spec.TrialTemplate.Spec.SetupTasks = append(exp.Spec.TrialTemplate.Spec.SetupTasks, setupTask)
+	if p.ExportersSetupTaskName != "" {
+		exp.Spec.TrialTemplate.Spec.SetupTasks = append(exp.Spec.TrialTemplate.Spec.SetupTasks, optimizev1beta2.SetupTask{
+			Name: p.ExportersSetupTaskName,
+			Args: []string{"exporters", "$(MODE)"},
 		})
+	}
 
-	p.ObjectSlice = append(p.ObjectSlice,
-		&corev1.ServiceAccount{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: p.ServiceAccountName,
-			},
+	// Run the trial job as the generated service account instead of the namespace default, the job itself does not
+	// need any permissions so the token is not mounted
+	if jt := exp.Spec.TrialTemplate.Spec.JobTemplate; p.RoleName != "" && jt != nil && jt.Spec.Template.Spec.ServiceAccountName == "" {
+		automount := false
+		jt.Spec.Template.Spec.ServiceAccountName = p.ServiceAccountName
+		jt.Spec.Template.Spec.AutomountServiceAccountToken = &automount
+	}
+
+	// Required to manage the namespaced Prometheus resources in the setup task
+	namespacedRules := []rbacv1.PolicyRule{
+		{
+			Verbs:     []string{"get", "create", "delete"},
+			APIGroups: []string{""},
+			Resources: []string{"serviceaccounts", "services", "configmaps"},
+		},
+		{
+			Verbs:     []string{"get", "create", "delete", "list", "watch"},
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments"},
+		},
+	}
+
+	clusterRules := []rbacv1.PolicyRule{
+		// Required to manage the Prometheus resources in the setup task
+		{
+			Verbs:     []string{"get", "create", "delete"},
+			APIGroups: []string{rbacv1.GroupName},
+			Resources: []string{"clusterroles", "clusterrolebindings"},
 		},
 
-		&rbacv1.ClusterRole{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: p.ClusterRoleName,
+		// Permissions we need to delegate to Prometheus runtime (prometheus-server-rbac.yaml)
+		{
+			Verbs:     []string{"list", "watch", "get"},
+			APIGroups: []string{""},
+			Resources: []string{"nodes", "nodes/metrics", "nodes/proxy", "services"},
+		},
+		{
+			Verbs:     []string{"list", "watch"},
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+		},
+	}
+
+	if namespacedOnly {
+		namespacedRules = append(namespacedRules,
+			// Required to manage the namespaced Prometheus roles in the setup task
+			rbacv1.PolicyRule{
+				Verbs:     []string{"get", "create", "delete"},
+				APIGroups: []string{rbacv1.GroupName},
+				Resources: []string{"roles", "rolebindings"},
+			},
+
+			// Permissions we need to delegate to Prometheus runtime (prometheus-server-rbac.yaml)
+			rbacv1.PolicyRule{
+				Verbs:     []string{"list", "watch"},
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+			},
+		)
+		clusterRules = nil
+	}
+
+	if p.ExportersSetupTaskName != "" {
+		namespacedRules = append(namespacedRules,
+			// Required to manage the exporter resources in the setup task (exporters/templates)
+			rbacv1.PolicyRule{
+				Verbs:     []string{"get", "create", "delete", "list", "watch"},
+				APIGroups: []string{"apps"},
+				Resources: []string{"daemonsets"},
 			},
-			Rules: []rbacv1.PolicyRule{
-				// Required to manage the Prometheus resources in the setup task
-				{
+		)
+		if !namespacedOnly {
+			namespacedRules = append(namespacedRules,
+				// The kube-state-metrics role is always namespaced
+				rbacv1.PolicyRule{
 					Verbs:     []string{"get", "create", "delete"},
 					APIGroups: []string{rbacv1.GroupName},
-					Resources: []string{"clusterroles", "clusterrolebindings"},
+					Resources: []string{"roles", "rolebindings"},
 				},
-				{
-					Verbs:     []string{"get", "create", "delete"},
-					APIGroups: []string{""},
-					Resources: []string{"serviceaccounts", "services", "configmaps"},
+			)
+		}
+	}
+
+	// Without a role, the namespaced rules must be granted cluster wide
+	if p.RoleName == "" {
+		clusterRules = append(namespacedRules, clusterRules...)
+	}
+
+	if createServiceAccount {
+		p.ObjectSlice = append(p.ObjectSlice,
+			&corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: p.ServiceAccountName,
 				},
-				{
-					Verbs:     []string{"get", "create", "delete", "list", "watch"},
-					APIGroups: []string{"apps"},
-					Resources: []string{"deployments"},
+			},
+		)
+	}
+
+	if len(clusterRules) > 0 {
+		p.ObjectSlice = append(p.ObjectSlice,
+			&rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: p.ClusterRoleName,
 				},
+				Rules: clusterRules,
+			},
 
-				// Permissions we need to delegate to Prometheus runtime (prometheus-server-rbac.yaml)
-				{
-					Verbs:     []string{"list", "watch", "get"},
-					APIGroups: []string{""},
-					Resources: []string{"nodes", "nodes/metrics", "nodes/proxy", "services"},
+			&rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: p.ClusterRoleBindingName,
 				},
-				{
-					Verbs:     []string{"list", "watch"},
-					APIGroups: []string{""},
-					Resources: []string{"pods"},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: rbacv1.GroupName,
+					Kind:     "ClusterRole",
+					Name:     p.ClusterRoleName,
+				},
+				Subjects: []rbacv1.Subject{
+					{
+						Kind: "ServiceAccount",
+						Name: p.ServiceAccountName,
+					},
 				},
 			},
-		},
+		)
+	}
 
-		&rbacv1.ClusterRoleBinding{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: p.ClusterRoleBindingName,
-			},
-			RoleRef: rbacv1.RoleRef{
-				APIGroup: rbacv1.GroupName,
-				Kind:     "ClusterRole",
-				Name:     p.ClusterRoleName,
+	if p.RoleName != "" {
+		p.ObjectSlice = append(p.ObjectSlice,
+			&rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: p.RoleName,
+				},
+				Rules: namespacedRules,
 			},
-			Subjects: []rbacv1.Subject{
-				{
-					Kind: "ServiceAccount",
-					Name: p.ServiceAccountName,
+
+			&rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: p.RoleBindingName,
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: rbacv1.GroupName,
+					Kind:     "Role",
+					Name:     p.RoleName,
+				},
+				Subjects: []rbacv1.Subject{
+					{
+						Kind: "ServiceAccount",
+						Name: p.ServiceAccountName,
+					},
 				},
 			},
-		},
-	)
+		)
+	}
 
 	return nil
 }
@@ -0,0 +1,247 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestExternalPrometheus_Update(t *testing.T) {
+	globalToken := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "global"},
+		Key:                  "token",
+	}
+	thanosToken := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "thanos"},
+		Key:                  "token",
+	}
+
+	cases := []struct {
+		desc     string
+		goal     optimizeappsv1alpha1.PrometheusGoal
+		expected optimizev1beta2.Metric
+	}{
+		{
+			desc: "global",
+			goal: optimizeappsv1alpha1.PrometheusGoal{
+				Query: "up",
+			},
+			expected: optimizev1beta2.Metric{
+				URL:                  "http://prometheus:9090",
+				BearerTokenSecretRef: globalToken,
+			},
+		},
+		{
+			desc: "per-goal url",
+			goal: optimizeappsv1alpha1.PrometheusGoal{
+				Query: "up",
+				URL:   "http://thanos-query:9090",
+			},
+			expected: optimizev1beta2.Metric{
+				URL: "http://thanos-query:9090",
+			},
+		},
+		{
+			desc: "per-goal credentials",
+			goal: optimizeappsv1alpha1.PrometheusGoal{
+				Query:                "up",
+				URL:                  "http://thanos-query:9090",
+				BearerTokenSecretRef: thanosToken,
+			},
+			expected: optimizev1beta2.Metric{
+				URL:                  "http://thanos-query:9090",
+				BearerTokenSecretRef: thanosToken,
+			},
+		},
+		{
+			desc: "credentials without url",
+			goal: optimizeappsv1alpha1.PrometheusGoal{
+				Query:                "up",
+				BearerTokenSecretRef: thanosToken,
+			},
+			expected: optimizev1beta2.Metric{
+				URL:                  "http://prometheus:9090",
+				BearerTokenSecretRef: globalToken,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			goal := &optimizeappsv1alpha1.Goal{Name: "test", Prometheus: &c.goal}
+			metrics, err := (&PrometheusMetricsSource{Goal: goal}).Metrics()
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			exp := &optimizev1beta2.Experiment{Spec: optimizev1beta2.ExperimentSpec{Metrics: metrics}}
+			p := &ExternalPrometheus{URL: "http://prometheus:9090", BearerTokenSecretRef: globalToken}
+			if assert.NoError(t, p.Update(exp)) && assert.Len(t, exp.Spec.Metrics, 1) {
+				assert.Equal(t, c.expected.URL, exp.Spec.Metrics[0].URL)
+				assert.Equal(t, c.expected.BearerTokenSecretRef, exp.Spec.Metrics[0].BearerTokenSecretRef)
+			}
+		})
+	}
+}
+
+func TestBuiltInPrometheus_Update(t *testing.T) {
+	cases := []struct {
+		desc                 string
+		roleName             string
+		exporters            bool
+		query                string
+		expectedKinds        []string
+		expectedClusterRules int
+		expectedRoleRules    int
+		expectedEnv          []corev1.EnvVar
+	}{
+		{
+			desc:                 "cluster role",
+			query:                `{{ cpuUtilization . "app=test" }}`,
+			expectedKinds:        []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding"},
+			expectedClusterRules: 5,
+		},
+		{
+			desc:                 "namespaced role",
+			roleName:             "optimize-prometheus",
+			query:                `{{ cpuUtilization . "app=test" }}`,
+			expectedKinds:        []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding"},
+			expectedClusterRules: 3,
+			expectedRoleRules:    2,
+		},
+		{
+			desc:                 "cluster role without cluster scoped metrics",
+			query:                `{{ cost . "app=test" }}`,
+			expectedKinds:        []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding"},
+			expectedClusterRules: 5,
+		},
+		{
+			desc:              "namespaced role without cluster scoped metrics",
+			roleName:          "optimize-prometheus",
+			query:             `{{ cost . "app=test" }}`,
+			expectedKinds:     []string{"ServiceAccount", "Role", "RoleBinding"},
+			expectedRoleRules: 4,
+			expectedEnv:       []corev1.EnvVar{{Name: "NAMESPACED_RBAC", Value: "true"}},
+		},
+		{
+			desc:                 "namespaced role with a cluster scoped query",
+			roleName:             "optimize-prometheus",
+			query:                `scalar(sum(container_memory_working_set_bytes{namespace="{{ .Trial.Namespace }}"}))`,
+			expectedKinds:        []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding"},
+			expectedClusterRules: 3,
+			expectedRoleRules:    2,
+		},
+		{
+			desc:              "namespaced role with exporters",
+			roleName:          "optimize-prometheus",
+			exporters:         true,
+			query:             `{{ cost . "app=test" }}`,
+			expectedKinds:     []string{"ServiceAccount", "Role", "RoleBinding"},
+			expectedRoleRules: 5,
+			expectedEnv:       []corev1.EnvVar{{Name: "NAMESPACED_RBAC", Value: "true"}},
+		},
+		{
+			desc:                 "namespaced role with exporters and cluster scoped metrics",
+			roleName:             "optimize-prometheus",
+			exporters:            true,
+			query:                `{{ cpuUtilization . "app=test" }}`,
+			expectedKinds:        []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding"},
+			expectedClusterRules: 3,
+			expectedRoleRules:    4,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			p := &BuiltInPrometheus{
+				SetupTaskName:          "monitoring",
+				ClusterRoleName:        "optimize-prometheus",
+				ServiceAccountName:     "optimize-setup",
+				ClusterRoleBindingName: "optimize-setup-prometheus",
+				RoleName:               c.roleName,
+				RoleBindingName:        c.roleName,
+			}
+			if c.exporters {
+				p.ExportersSetupTaskName = "exporters"
+			}
+			exp := &optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Metrics: []optimizev1beta2.Metric{{Name: "cost", Type: optimizev1beta2.MetricPrometheus, Query: c.query}},
+				},
+			}
+
+			if assert.NoError(t, p.Update(exp)) {
+				var kinds []string
+				for _, obj := range p.ObjectSlice {
+					switch o := obj.(type) {
+					case *corev1.ServiceAccount:
+						kinds = append(kinds, "ServiceAccount")
+					case *rbacv1.ClusterRole:
+						assert.Len(t, o.Rules, c.expectedClusterRules)
+						kinds = append(kinds, "ClusterRole")
+					case *rbacv1.ClusterRoleBinding:
+						kinds = append(kinds, "ClusterRoleBinding")
+					case *rbacv1.Role:
+						assert.Len(t, o.Rules, c.expectedRoleRules)
+						kinds = append(kinds, "Role")
+					case *rbacv1.RoleBinding:
+						assert.Equal(t, "Role", o.RoleRef.Kind)
+						kinds = append(kinds, "RoleBinding")
+					}
+				}
+				assert.Equal(t, c.expectedKinds, kinds)
+				assert.Equal(t, "optimize-setup", exp.Spec.TrialTemplate.Spec.SetupServiceAccountName)
+				if c.exporters && assert.Len(t, exp.Spec.TrialTemplate.Spec.SetupTasks, 2) {
+					assert.Equal(t, []string{"exporters", "$(MODE)"}, exp.Spec.TrialTemplate.Spec.SetupTasks[1].Args)
+				} else if !c.exporters {
+					assert.Len(t, exp.Spec.TrialTemplate.Spec.SetupTasks, 1)
+				}
+				assert.Equal(t, c.expectedEnv, exp.Spec.TrialTemplate.Spec.SetupTasks[0].Env)
+			}
+		})
+	}
+}
+
+func TestBuiltInPrometheus_UpdateTrialJob(t *testing.T) {
+	p := &BuiltInPrometheus{
+		SetupTaskName:      "monitoring",
+		ServiceAccountName: "optimize-setup",
+		RoleName:           "optimize-prometheus",
+		RoleBindingName:    "optimize-setup-prometheus",
+	}
+	exp := &optimizev1beta2.Experiment{
+		Spec: optimizev1beta2.ExperimentSpec{
+			Metrics: []optimizev1beta2.Metric{{Name: "cost", Type: optimizev1beta2.MetricPrometheus, Query: `{{ cost . "app=test" }}`}},
+			TrialTemplate: optimizev1beta2.TrialTemplateSpec{
+				Spec: optimizev1beta2.TrialSpec{JobTemplate: &batchv1beta1.JobTemplateSpec{}},
+			},
+		},
+	}
+
+	if assert.NoError(t, p.Update(exp)) {
+		pod := exp.Spec.TrialTemplate.Spec.JobTemplate.Spec.Template.Spec
+		assert.Equal(t, "optimize-setup", pod.ServiceAccountName)
+		if assert.NotNil(t, pod.AutomountServiceAccountToken) {
+			assert.False(t, *pod.AutomountServiceAccountToken)
+		}
+	}
+}
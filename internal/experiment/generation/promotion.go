@@ -0,0 +1,44 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+)
+
+// PromotionSource enables the automatic promotion of the best trial once the experiment completes.
+type PromotionSource struct {
+	Promotion *optimizeappsv1alpha1.Promotion
+}
+
+var _ ExperimentSource = &PromotionSource{} // Update promotion policy
+
+func (s *PromotionSource) Update(exp *optimizev1beta2.Experiment) error {
+	if s.Promotion == nil {
+		return nil
+	}
+
+	exp.Spec.Promotion = &optimizev1beta2.PromotionPolicy{
+		MinImprovementPercent: s.Promotion.MinImprovement,
+	}
+	if s.Promotion.RollbackWindow != nil {
+		exp.Spec.Promotion.RollbackWindowSeconds = int32(s.Promotion.RollbackWindow.Seconds())
+	}
+
+	return nil
+}
@@ -55,11 +55,16 @@ func (s *ReplicaSelector) Map(node *yaml.RNode, meta yaml.ResourceMeta) ([]inter
 				value = &yaml.Node{Kind: yaml.ScalarNode, Value: "1"}
 			}
 
-			result = append(result, &replicaParameter{pnode: pnode{
-				meta:      meta,
-				fieldPath: node.FieldPath(),
-				value:     value,
-			}})
+			result = append(result, &replicaParameter{
+				pnode: pnode{
+					meta:      meta,
+					fieldPath: node.FieldPath(),
+					value:     value,
+				},
+				min:      s.Min,
+				max:      s.Max,
+				baseline: s.Baseline,
+			})
 
 			return node, nil
 		}))
@@ -67,6 +72,9 @@ func (s *ReplicaSelector) Map(node *yaml.RNode, meta yaml.ResourceMeta) ([]inter
 
 type replicaParameter struct {
 	pnode
+	min      *int32
+	max      *int32
+	baseline *int32
 }
 
 var _ PatchSource = &replicaParameter{}
@@ -86,6 +94,9 @@ func (p *replicaParameter) Parameters(name ParameterNamer) ([]optimizev1beta2.Pa
 	if err := p.value.Decode(&v); err != nil {
 		return nil, err
 	}
+	if p.baseline != nil {
+		v = int(*p.baseline)
+	}
 	if v <= 0 {
 		return nil, nil
 	}
@@ -98,6 +109,17 @@ func (p *replicaParameter) Parameters(name ParameterNamer) ([]optimizev1beta2.Pa
 		maxReplicas = baselineReplicas.IntVal
 	}
 
+	// Explicit bounds take precedence over the defaults
+	if p.min != nil {
+		minReplicas = *p.min
+	}
+	if p.max != nil {
+		maxReplicas = *p.max
+	}
+	if minReplicas > maxReplicas {
+		return nil, fmt.Errorf("invalid replica bounds for %s, min (%d) is greater than max (%d)", p.meta.Name, minReplicas, maxReplicas)
+	}
+
 	return []optimizev1beta2.Parameter{{
 		Name:     name(p.meta, p.fieldPath, "replicas"),
 		Min:      minReplicas,
@@ -29,6 +29,9 @@ var zero = resource.MustParse("0")
 
 const requestsQueryFormat = `({{ cpuRequests . %q }} * %d) + ({{ memoryRequests . %q | GB }} * %d)`
 
+// costQueryFormat uses the price sheet resolved by the controller to compute the hourly cost of the requests
+const costQueryFormat = `{{ cost . %q }}`
+
 type RequestsMetricsSource struct {
 	Goal *optimizeappsv1alpha1.Goal
 }
@@ -51,7 +54,11 @@ func (s *RequestsMetricsSource) Metrics() ([]optimizev1beta2.Metric, error) {
 		memoryWeight = &zero
 	}
 
+	// The price sheet is only used instead of the weights when it is explicitly requested
 	query := fmt.Sprintf(requestsQueryFormat, s.Goal.Requests.Selector, cpuWeight.Value(), s.Goal.Requests.Selector, memoryWeight.Value())
+	if s.Goal.Requests.PriceSheet {
+		query = fmt.Sprintf(costQueryFormat, s.Goal.Requests.Selector)
+	}
 	result = append(result, newGoalMetric(s.Goal, query))
 
 	// If the name contains "cost" and the weights are non-zero, add non-optimized metrics for each request
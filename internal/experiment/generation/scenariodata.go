@@ -0,0 +1,269 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// scenarioDataPath is the directory where scenario data is available to the trial job containers.
+	scenarioDataPath = "/mnt/data"
+	// scenarioDataContainerName is the name of the init container used to populate the scenario data.
+	scenarioDataContainerName = "scenario-data"
+)
+
+// ScenarioDataSource makes additional data files available to the trial job. Because the data can come
+// from multiple places (and may need to be rendered for each trial), an init container is used to
+// populate a shared volume with the final contents.
+type ScenarioDataSource struct {
+	Scenario    *optimizeappsv1alpha1.Scenario
+	Application *optimizeappsv1alpha1.Application
+}
+
+var _ ExperimentSource = &ScenarioDataSource{} // Update trial job
+var _ kio.Reader = &ScenarioDataSource{}       // ConfigMap for the static data
+
+func (s *ScenarioDataSource) Update(exp *optimizev1beta2.Experiment) error {
+	if s.Scenario == nil || len(s.Scenario.Data) == 0 {
+		return nil
+	}
+
+	pod := &ensureTrialJobPod(exp).Spec
+
+	var script []string
+	var templates []*optimizeappsv1alpha1.ScenarioData
+	initContainer := corev1.Container{
+		Name:  scenarioDataContainerName,
+		Image: "busybox",
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "scenario-data",
+				MountPath: scenarioDataPath,
+			},
+		},
+	}
+
+	for i := range s.Scenario.Data {
+		data := &s.Scenario.Data[i]
+		if err := s.checkData(data); err != nil {
+			return err
+		}
+
+		dst := path.Join(scenarioDataPath, data.Name)
+		switch {
+
+		case data.Template:
+			// The actual value is supplied by a patch so it can be rendered for each trial
+			templates = append(templates, data)
+			script = append(script, fmt.Sprintf("printf '%%s' \"$%s\" > %s", scenarioDataEnvName(len(templates)-1), dst))
+
+		case data.ConfigMapKeyRef != nil:
+			name := fmt.Sprintf("scenario-data-%d", i)
+			mountPath := path.Join("/mnt", name)
+			initContainer.VolumeMounts = append(initContainer.VolumeMounts, corev1.VolumeMount{
+				Name:      name,
+				ReadOnly:  true,
+				MountPath: mountPath,
+			})
+			pod.Volumes = append(pod.Volumes, corev1.Volume{
+				Name: name,
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: data.ConfigMapKeyRef.LocalObjectReference,
+						Items:                []corev1.KeyToPath{{Key: data.ConfigMapKeyRef.Key, Path: data.Name}},
+						Optional:             data.ConfigMapKeyRef.Optional,
+					},
+				},
+			})
+			script = append(script, fmt.Sprintf("cp -L %s %s", path.Join(mountPath, data.Name), dst))
+
+		default:
+			script = append(script, fmt.Sprintf("cp -L %s %s", path.Join("/mnt/scenario-data-source", data.Name), dst))
+
+		}
+	}
+
+	// Only mount the generated ConfigMap if there is static data in it
+	if s.hasStaticData() {
+		initContainer.VolumeMounts = append(initContainer.VolumeMounts, corev1.VolumeMount{
+			Name:      "scenario-data-source",
+			ReadOnly:  true,
+			MountPath: "/mnt/scenario-data-source",
+		})
+		pod.Volumes = append(pod.Volumes, corev1.Volume{
+			Name: "scenario-data-source",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: s.scenarioDataConfigMapName(),
+					},
+				},
+			},
+		})
+	}
+
+	initContainer.Command = []string{"/bin/sh"}
+	initContainer.Args = []string{"-c", strings.Join(script, " && ")}
+	pod.InitContainers = append(pod.InitContainers, initContainer)
+
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name:         "scenario-data",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+
+	// Make the data available to every container
+	for i := range pod.Containers {
+		pod.Containers[i].VolumeMounts = append(pod.Containers[i].VolumeMounts, corev1.VolumeMount{
+			Name:      "scenario-data",
+			ReadOnly:  true,
+			MountPath: scenarioDataPath,
+		})
+	}
+
+	// Templates are rendered into the init container environment using a patch on the trial job
+	if len(templates) > 0 {
+		patch, err := s.templatePatch(templates)
+		if err != nil {
+			return err
+		}
+
+		exp.Spec.Patches = append(exp.Spec.Patches, optimizev1beta2.PatchTemplate{
+			Patch: patch,
+			TargetRef: &corev1.ObjectReference{
+				Kind:       "Job",
+				APIVersion: "batch/v1",
+			},
+		})
+	}
+
+	return nil
+}
+
+func (s *ScenarioDataSource) Read() ([]*yaml.RNode, error) {
+	result := sfio.ObjectSlice{}
+	if s.Scenario == nil || !s.hasStaticData() {
+		return result.Read()
+	}
+
+	cm := &corev1.ConfigMap{}
+	cm.Name = s.scenarioDataConfigMapName()
+	cm.Data = make(map[string]string)
+	for i := range s.Scenario.Data {
+		data := &s.Scenario.Data[i]
+		if data.Template || data.ConfigMapKeyRef != nil {
+			continue
+		}
+
+		value, err := s.loadData(data)
+		if err != nil {
+			return nil, err
+		}
+		cm.Data[data.Name] = value
+	}
+	result = append(result, cm)
+
+	return result.Read()
+}
+
+// checkData verifies the scenario data can be used.
+func (s *ScenarioDataSource) checkData(data *optimizeappsv1alpha1.ScenarioData) error {
+	if errs := validation.IsConfigMapKey(data.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid data name %q for scenario %q: %s", data.Name, s.Scenario.Name, strings.Join(errs, ", "))
+	}
+
+	if data.Template && data.ConfigMapKeyRef != nil {
+		return fmt.Errorf("data %q for scenario %q cannot be a template when using a ConfigMap reference", data.Name, s.Scenario.Name)
+	}
+
+	return nil
+}
+
+// loadData returns the contents of inline or file based data.
+func (s *ScenarioDataSource) loadData(data *optimizeappsv1alpha1.ScenarioData) (string, error) {
+	if data.File == "" {
+		return data.Value, nil
+	}
+
+	b, err := loadApplicationData(s.Application, data.File)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// hasStaticData checks to see if any of the data must be included in the generated ConfigMap.
+func (s *ScenarioDataSource) hasStaticData() bool {
+	for i := range s.Scenario.Data {
+		if !s.Scenario.Data[i].Template && s.Scenario.Data[i].ConfigMapKeyRef == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// templatePatch returns a trial job patch that sets the (unrendered) template values on the init container.
+func (s *ScenarioDataSource) templatePatch(templates []*optimizeappsv1alpha1.ScenarioData) (string, error) {
+	env := yaml.NewListRNode()
+	for i, data := range templates {
+		value, err := s.loadData(data)
+		if err != nil {
+			return "", err
+		}
+
+		// Use a literal block so the rendered template is still valid YAML
+		v := yaml.NewScalarRNode(value)
+		v.YNode().Style = yaml.LiteralStyle
+
+		e := yaml.NewMapRNode(&map[string]string{"name": scenarioDataEnvName(i)})
+		if err := e.PipeE(yaml.SetField("value", v)); err != nil {
+			return "", err
+		}
+		env.YNode().Content = append(env.YNode().Content, e.YNode())
+	}
+
+	c := yaml.NewMapRNode(&map[string]string{"name": scenarioDataContainerName})
+	if err := c.PipeE(yaml.SetField("env", env)); err != nil {
+		return "", err
+	}
+
+	patch := yaml.NewMapRNode(nil)
+	if err := patch.PipeE(yaml.LookupCreate(yaml.SequenceNode, "spec", "template", "spec", "initContainers"), yaml.Append(c.YNode())); err != nil {
+		return "", err
+	}
+
+	return patch.String()
+}
+
+func (s *ScenarioDataSource) scenarioDataConfigMapName() string {
+	return fmt.Sprintf("%s-data", s.Scenario.Name)
+}
+
+// scenarioDataEnvName returns the name of the environment variable used for the nth template.
+func scenarioDataEnvName(n int) string {
+	return fmt.Sprintf("SCENARIO_DATA_%d", n)
+}
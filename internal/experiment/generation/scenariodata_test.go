@@ -0,0 +1,138 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestScenarioDataSource_Update(t *testing.T) {
+	cases := []struct {
+		desc        string
+		data        []optimizeappsv1alpha1.ScenarioData
+		expectedCmd string
+		expectedEnv map[string]string
+		expectedErr string
+	}{
+		{
+			desc: "inline",
+			data: []optimizeappsv1alpha1.ScenarioData{
+				{Name: "body.json", Value: `{"id": 1}`},
+			},
+			expectedCmd: "cp -L /mnt/scenario-data-source/body.json /mnt/data/body.json",
+		},
+		{
+			desc: "configmap reference",
+			data: []optimizeappsv1alpha1.ScenarioData{
+				{
+					Name: "users.csv",
+					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "datasets"},
+						Key:                  "users",
+					},
+				},
+			},
+			expectedCmd: "cp -L /mnt/scenario-data-0/users.csv /mnt/data/users.csv",
+		},
+		{
+			desc: "template",
+			data: []optimizeappsv1alpha1.ScenarioData{
+				{Name: "body.json", Value: "{\n  \"size\": {{ .Values.size }}\n}", Template: true},
+				{Name: "headers.txt", Value: "X-Trial: {{ .Trial.Name }}", Template: true},
+			},
+			expectedCmd: `printf '%s' "$SCENARIO_DATA_0" > /mnt/data/body.json && printf '%s' "$SCENARIO_DATA_1" > /mnt/data/headers.txt`,
+			expectedEnv: map[string]string{
+				"SCENARIO_DATA_0": "{\n  \"size\": 10\n}",
+				"SCENARIO_DATA_1": "X-Trial: test-001",
+			},
+		},
+		{
+			desc: "invalid name",
+			data: []optimizeappsv1alpha1.ScenarioData{
+				{Name: "data/body.json", Value: "{}"},
+			},
+			expectedErr: `invalid data name "data/body.json" for scenario "test": a valid config key must consist of alphanumeric characters, '-', '_' or '.' (e.g. 'key.name',  or 'KEY_NAME',  or 'key-name', regex used for validation is '[-._a-zA-Z0-9]+')`,
+		},
+		{
+			desc: "configmap template",
+			data: []optimizeappsv1alpha1.ScenarioData{
+				{Name: "body.json", ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "body"}, Template: true},
+			},
+			expectedErr: `data "body.json" for scenario "test" cannot be a template when using a ConfigMap reference`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := &ScenarioDataSource{
+				Scenario: &optimizeappsv1alpha1.Scenario{Name: "test", Data: c.data},
+			}
+
+			exp := &optimizev1beta2.Experiment{}
+			pod := &ensureTrialJobPod(exp).Spec
+			pod.Containers = []corev1.Container{{Name: "load"}}
+
+			err := s.Update(exp)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if assert.Len(t, pod.InitContainers, 1) {
+				assert.Equal(t, []string{"/bin/sh", "-c", c.expectedCmd}, append(pod.InitContainers[0].Command, pod.InitContainers[0].Args...))
+			}
+			assert.Contains(t, pod.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "scenario-data", ReadOnly: true, MountPath: "/mnt/data"})
+
+			if len(c.expectedEnv) == 0 {
+				assert.Empty(t, exp.Spec.Patches)
+				return
+			}
+
+			// Render the patch as if for an actual trial
+			if !assert.Len(t, exp.Spec.Patches, 1) {
+				return
+			}
+			trial := &optimizev1beta2.Trial{}
+			trial.Name = "test-001"
+			trial.Spec.Assignments = []optimizev1beta2.Assignment{{Name: "size", Value: intstr.FromInt(10)}}
+			data, err := template.New().RenderPatch(&exp.Spec.Patches[0], trial)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			job := &batchv1.Job{}
+			if assert.NoError(t, json.Unmarshal(data, job)) && assert.Len(t, job.Spec.Template.Spec.InitContainers, 1) {
+				env := make(map[string]string)
+				for _, e := range job.Spec.Template.Spec.InitContainers[0].Env {
+					env[e.Name] = e.Value
+				}
+				assert.Equal(t, c.expectedEnv, env)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"fmt"
+	"regexp"
+
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/scan"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// StorageSelector scans for persistent volume claims.
+type StorageSelector optimizeappsv1alpha1.Storage
+
+var _ scan.Selector = &StorageSelector{}
+
+func (s *StorageSelector) Select(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	return s.ResourceMetaFilter.Filter(nodes)
+}
+
+func (s *StorageSelector) Map(node *yaml.RNode, meta yaml.ResourceMeta) ([]interface{}, error) {
+	var result []interface{}
+
+	// Only stand-alone claims can be resized, volume claim templates (e.g. on a StatefulSet) are immutable
+	if meta.APIVersion != "v1" || meta.Kind != "PersistentVolumeClaim" {
+		return nil, nil
+	}
+
+	// An empty claim name matches everything
+	if s.ClaimName != "" {
+		claimName, err := regexp.Compile("^(?:" + s.ClaimName + ")$")
+		if err != nil {
+			return nil, err
+		}
+		if !claimName.MatchString(meta.Name) {
+			return nil, nil
+		}
+	}
+
+	spec, err := node.Pipe(yaml.Lookup("spec"))
+	if err != nil || spec == nil {
+		return nil, err
+	}
+
+	return append(result, &storageParameter{
+		pnode: pnode{
+			meta:      meta,
+			fieldPath: []string{"spec"},
+			value:     spec.YNode(),
+		},
+		sizes: s.Sizes,
+	}), nil
+}
+
+// storageParameter is used to record the position of a persistent volume claim specification found by the selector during scanning.
+type storageParameter struct {
+	pnode
+	sizes []resource.Quantity
+}
+
+var _ PatchSource = &storageParameter{}
+var _ ParameterSource = &storageParameter{}
+
+func (p *storageParameter) Patch(name ParameterNamer) (yaml.Filter, error) {
+	size := p.size()
+	if size == nil && len(p.sizes) == 0 {
+		return yaml.FilterFunc(func(patch *yaml.RNode) (*yaml.RNode, error) { return patch, nil }), nil
+	}
+
+	value := fmt.Sprintf("{{ index .Values %q }}", name(p.meta, p.fieldPath, "storage"))
+	if len(p.sizes) == 0 {
+		value += sizeSuffix(size)
+	}
+
+	return yaml.FilterFunc(func(patch *yaml.RNode) (*yaml.RNode, error) {
+		return patch, patch.PipeE(
+			&yaml.PathGetter{Path: []string{"spec", "resources", "requests", "storage"}, Create: yaml.ScalarNode},
+			yaml.FieldSetter{StringValue: value},
+		)
+	}), nil
+}
+
+func (p *storageParameter) Parameters(name ParameterNamer) ([]optimizev1beta2.Parameter, error) {
+	size := p.size()
+	param := optimizev1beta2.Parameter{
+		Name:     name(p.meta, p.fieldPath, "storage"),
+		Baseline: new(intstr.IntOrString),
+	}
+
+	// Volumes can be expanded but not shrunk, do not go below the current size
+	switch {
+	case len(p.sizes) > 0:
+		for _, s := range p.sizes {
+			if size == nil || s.Cmp(*size) >= 0 {
+				param.Values = append(param.Values, s.String())
+			}
+		}
+		if size != nil {
+			*param.Baseline = intstr.FromString(size.String())
+			param.Values = appendMissing(param.Values, param.Baseline.StrVal)
+		} else {
+			*param.Baseline = intstr.FromString(param.Values[0])
+		}
+
+	case size != nil:
+		baseline := AsScaledInt(*size, resource.Giga)
+		if baseline < 1 {
+			baseline = 1
+		}
+		*param.Baseline = intstr.FromInt(int(baseline))
+		param.Min = baseline
+		param.Max = baseline * 4
+
+	default:
+		return nil, nil
+	}
+
+	return []optimizev1beta2.Parameter{param}, nil
+}
+
+// size returns the currently requested storage size, if it is known.
+func (p *storageParameter) size() *resource.Quantity {
+	value, err := yaml.NewRNode(p.value).Pipe(yaml.Lookup("resources", "requests", "storage"))
+	if err != nil || value == nil {
+		return nil
+	}
+
+	size, err := resource.ParseQuantity(yaml.GetValue(value))
+	if err != nil {
+		return nil
+	}
+	return &size
+}
+
+// sizeSuffix returns the suffix used to express the storage size in (binary or decimal) gigabytes.
+func sizeSuffix(size *resource.Quantity) string {
+	if suffix := QuantitySuffix(resource.Giga, size.Format); suffix != "" {
+		return suffix
+	}
+	return "G"
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/konjure/pkg/filters"
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestStorageSelector(t *testing.T) {
+	statefulSet := `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+    spec:
+      accessModes: [ReadWriteOnce]
+      resources:
+        requests:
+          storage: 10Gi
+`
+	persistentVolumeClaim := `
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: cache
+spec:
+  storageClassName: standard
+  resources:
+    requests:
+      storage: 20G
+`
+
+	cases := []struct {
+		desc               string
+		storage            optimizeappsv1alpha1.Storage
+		resource           string
+		expectedParameters []optimizev1beta2.Parameter
+		expectedPatch      string
+	}{
+		{
+			desc:     "persistent volume claim size",
+			resource: persistentVolumeClaim,
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "persistentvolumeclaim/cache/storage",
+					Baseline: newInt(20),
+					Min:      20,
+					Max:      80,
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                resources:
+                  requests:
+                    storage: '{{ index .Values "persistentvolumeclaim/cache/storage" }}G'`),
+		},
+		{
+			desc: "categorical sizes",
+			storage: optimizeappsv1alpha1.Storage{
+				ClaimName: "cache",
+				Sizes:     []resource.Quantity{resource.MustParse("5G"), resource.MustParse("50G"), resource.MustParse("100G")},
+			},
+			resource: persistentVolumeClaim,
+			expectedParameters: []optimizev1beta2.Parameter{
+				{
+					Name:     "persistentvolumeclaim/cache/storage",
+					Baseline: newString("20G"),
+					Values:   []string{"50G", "100G", "20G"},
+				},
+			},
+			expectedPatch: unindent(`
+              spec:
+                resources:
+                  requests:
+                    storage: '{{ index .Values "persistentvolumeclaim/cache/storage" }}'`),
+		},
+		{
+			desc:     "claim name mismatch",
+			storage:  optimizeappsv1alpha1.Storage{ClaimName: "data"},
+			resource: persistentVolumeClaim,
+		},
+		{
+			desc:     "statefulset volume claim template",
+			storage:  optimizeappsv1alpha1.Storage{ResourceMetaFilter: filters.ResourceMetaFilter{Kind: "StatefulSet"}},
+			resource: statefulSet,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			c.storage.Default()
+			s := (*StorageSelector)(&c.storage)
+
+			node := yaml.MustParse(c.resource)
+			nodes, err := s.Select([]*yaml.RNode{node})
+			require.NoError(t, err)
+			require.Len(t, nodes, 1)
+			meta, err := node.GetMeta()
+			require.NoError(t, err)
+
+			result, err := s.Map(node, meta)
+			require.NoError(t, err)
+			if c.expectedParameters == nil {
+				assert.Empty(t, result)
+				return
+			}
+			require.Len(t, result, 1)
+			p := result[0].(*storageParameter)
+
+			name := parameterNamer()
+			params, err := p.Parameters(name)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expectedParameters, params)
+			}
+
+			f, err := p.Patch(name)
+			if assert.NoError(t, err) {
+				patch := yaml.NewMapRNode(nil)
+				if assert.NoError(t, patch.PipeE(f)) {
+					assert.YAMLEq(t, c.expectedPatch, patch.MustString())
+				}
+			}
+		})
+	}
+}
+
+func newString(s string) *intstr.IntOrString {
+	v := intstr.FromString(s)
+	return &v
+}
@@ -30,6 +30,10 @@ import (
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
+// StormForgePerformanceSource generates a trial job which runs a StormForge Performance test case. The
+// "stormforge-perf" trial job image launches the remote test run at the start of the trial, polls it until it
+// finishes (so the trial run lasts as long as the test run) and pushes the test run statistics to the Pushgateway;
+// the latency, error rate and throughput goals are collected from the pushed statistics.
 type StormForgePerformanceSource struct {
 	Scenario    *optimizeappsv1alpha1.Scenario
 	Objective   *optimizeappsv1alpha1.Objective
@@ -175,6 +179,14 @@ func (s *StormForgePerformanceSource) Metrics() ([]optimizev1beta2.Metric, error
 				result = append(result, newGoalMetric(goal, query))
 			}
 
+		case goal.Throughput != nil:
+			if goal.Throughput.ThroughputType == optimizeappsv1alpha1.ThroughputRequests {
+				query := `scalar(request_count{job="trialRun",instance="{{ .Trial.Name }}"}) / {{ duration .StartTime .CompletionTime }}`
+				m := newGoalMetric(goal, query)
+				m.Minimize = false
+				result = append(result, m)
+			}
+
 		}
 	}
 	return result, nil
@@ -100,3 +100,27 @@ func TestStormForgePerformanceSource_TestCaseFile(t *testing.T) {
 		})
 	}
 }
+
+func TestStormForgePerformanceSource_Metrics(t *testing.T) {
+	s := &StormForgePerformanceSource{
+		Scenario: &optimizeappsv1alpha1.Scenario{StormForge: &optimizeappsv1alpha1.StormForgeScenario{}},
+		Objective: &optimizeappsv1alpha1.Objective{Goals: []optimizeappsv1alpha1.Goal{
+			{Name: "p95-latency", Latency: &optimizeappsv1alpha1.LatencyGoal{LatencyType: optimizeappsv1alpha1.LatencyPercentile95}},
+			{Name: "error-rate", ErrorRate: &optimizeappsv1alpha1.ErrorRateGoal{ErrorRateType: optimizeappsv1alpha1.ErrorRateRequests}},
+			{Name: "throughput", Throughput: &optimizeappsv1alpha1.ThroughputGoal{ThroughputType: optimizeappsv1alpha1.ThroughputRequests}},
+		}},
+	}
+
+	metrics, err := s.Metrics()
+	if assert.NoError(t, err) && assert.Len(t, metrics, 3) {
+		assert.Equal(t, `scalar(percentile_95{job="trialRun",instance="{{ .Trial.Name }}"})`, metrics[0].Query)
+		assert.True(t, metrics[0].Minimize)
+		assert.Equal(t, `scalar(error_ratio{job="trialRun",instance="{{ .Trial.Name }}"})`, metrics[1].Query)
+		assert.True(t, metrics[1].Minimize)
+		assert.Equal(t, `scalar(request_count{job="trialRun",instance="{{ .Trial.Name }}"}) / {{ duration .StartTime .CompletionTime }}`, metrics[2].Query)
+		assert.False(t, metrics[2].Minimize)
+	}
+	for _, g := range s.Objective.Goals {
+		assert.True(t, g.Implemented, g.Name)
+	}
+}
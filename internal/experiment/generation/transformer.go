@@ -267,7 +267,7 @@ func parameterNamer() ParameterNamer {
 		}
 
 		switch name {
-		case "cpu", "memory", "replicas":
+		case "cpu", "memory", "replicas", "storage", "storageClassName":
 			parts = append(parts, name)
 		}
 
@@ -18,11 +18,15 @@ package experiment
 
 import (
 	"fmt"
+	"strings"
 
 	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/internal/application"
 	"github.com/thestormforge/optimize-controller/v2/internal/experiment/generation"
 	"github.com/thestormforge/optimize-controller/v2/internal/scan"
+	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
+	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/kio/filters"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
@@ -35,18 +39,107 @@ type Generator struct {
 	// The name of the experiment to generate.
 	ExperimentName string
 	// The name of the scenario to generate an experiment for. Required if there are more then one scenario.
+	// A comma separated list of names (or "*" for all scenarios) generates one experiment per scenario.
 	Scenario string
+	// Flag indicating that multiple scenarios should be combined into a single experiment where the
+	// scenario itself is a categorical parameter.
+	CombineScenarios bool
 	// The name of the objective to generate an experiment for. Required if there are more then one set of objectives.
 	Objective string
 	// IncludeApplicationResources is a flag indicating that the application resources should be included in the output.
 	IncludeApplicationResources bool
+	// Cluster-wide policies the generated experiment must conform to.
+	Policies []optimizev1beta2.OptimizationPolicy
+	// The URL of an existing Prometheus deployment, skips the built-in Prometheus setup task when specified.
+	PrometheusURL string
+	// The secret key containing the bearer token used to query an existing Prometheus deployment.
+	PrometheusBearerTokenSecretRef *corev1.SecretKeySelector
+	// Flag indicating that namespaced setup permissions should be granted using roles in the experiment namespace
+	// instead of cluster roles.
+	NamespacedRBAC bool
+	// Flag indicating that the built-in metrics exporters should be installed alongside the built-in Prometheus, for
+	// clusters that do not already export the pod labels and resource requests.
+	Exporters bool
 	// Configure the filter options.
 	scan.FilterOptions
 }
 
 // Execute the experiment generation pipeline, sending the results to the supplied writer.
 func (g *Generator) Execute(output kio.Writer) error {
-	scenario, err := application.GetScenario(&g.Application, g.Scenario)
+	scenarioNames := g.scenarioNames()
+	if len(scenarioNames) < 2 {
+		return g.execute(output, g.Scenario, g.ExperimentName)
+	}
+
+	objective, err := application.GetObjective(&g.Application, g.Objective)
+	if err != nil {
+		return err
+	}
+	objectiveName := ""
+	if objective != nil {
+		objectiveName = objective.Name
+	}
+
+	// Generate each scenario independently, generation updates the application as a side effect
+	var scenarioNodes [][]*yaml.RNode
+	for _, scenarioName := range scenarioNames {
+		sg := *g
+		sg.Application = *g.Application.DeepCopy()
+
+		experimentName := ""
+		if g.ExperimentName != "" {
+			experimentName = g.ExperimentName + "-" + scenarioName
+		}
+
+		buf := &kio.PackageBuffer{}
+		if err := sg.execute(buf, scenarioName, experimentName); err != nil {
+			return err
+		}
+		scenarioNodes = append(scenarioNodes, buf.Nodes)
+	}
+
+	if !g.CombineScenarios {
+		var nodes []*yaml.RNode
+		for i := range scenarioNodes {
+			nodes = append(nodes, scenarioNodes[i]...)
+		}
+		return output.Write(nodes)
+	}
+
+	experimentName := g.ExperimentName
+	if experimentName == "" {
+		experimentName = application.ExperimentName(&g.Application, "", objectiveName)
+	}
+
+	nodes, err := combineScenarios(experimentName, scenarioNames, scenarioNodes)
+	if err != nil {
+		return err
+	}
+	return output.Write(nodes)
+}
+
+// scenarioNames returns the list of scenario names to generate experiments for.
+func (g *Generator) scenarioNames() []string {
+	if g.Scenario == "*" {
+		var names []string
+		for i := range g.Application.Scenarios {
+			names = append(names, g.Application.Scenarios[i].Name)
+		}
+		return names
+	}
+
+	var names []string
+	for _, name := range strings.Split(g.Scenario, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// execute runs the experiment generation pipeline for a single scenario.
+func (g *Generator) execute(output kio.Writer, scenarioName, experimentName string) error {
+	scenario, err := application.GetScenario(&g.Application, scenarioName)
 	if err != nil {
 		return err
 	}
@@ -57,7 +150,7 @@ func (g *Generator) Execute(output kio.Writer) error {
 	}
 
 	// Compute the effective scenario, objective, and experiment names
-	scenarioName, objectiveName, experimentName := "", "", g.ExperimentName
+	scenarioName, objectiveName := "", ""
 	if scenario != nil {
 		scenarioName = scenario.Name
 	}
@@ -85,9 +178,13 @@ func (g *Generator) Execute(output kio.Writer) error {
 				},
 				Selectors: append(g.selectors(),
 					&generation.ApplicationSelector{
-						Application: &g.Application,
-						Scenario:    scenario,
-						Objective:   objective,
+						Application:                    &g.Application,
+						Scenario:                       scenario,
+						Objective:                      objective,
+						PrometheusURL:                  g.PrometheusURL,
+						PrometheusBearerTokenSecretRef: g.PrometheusBearerTokenSecretRef,
+						NamespacedRBAC:                 g.NamespacedRBAC,
+						Exporters:                      g.Exporters,
 					}),
 			},
 
@@ -95,10 +192,14 @@ func (g *Generator) Execute(output kio.Writer) error {
 			kio.FilterAll(yaml.SetLabel(optimizeappsv1alpha1.LabelApplication, g.Application.Name)),
 			kio.FilterAll(generation.SetNamespace(g.Application.Namespace)),
 			kio.FilterAll(generation.SetExperimentName(experimentName)),
+			kio.FilterAll(generation.SetExperimentOwner(experimentName)),
 			kio.FilterAll(generation.SetExperimentLabel(optimizeappsv1alpha1.LabelApplication, g.Application.Name)),
 			kio.FilterAll(generation.SetExperimentLabel(optimizeappsv1alpha1.LabelScenario, scenarioName)),
 			kio.FilterAll(generation.SetExperimentLabel(optimizeappsv1alpha1.LabelObjective, objectiveName)),
 
+			// Enforce the optimization policies once the experiment is complete
+			kio.FilterAll(yaml.FilterFunc(g.applyPolicies)),
+
 			// Apply Kubernetes formatting conventions and clean up the objects
 			&filters.FormatFilter{UseSchema: true},
 			kio.FilterAll(yaml.ClearAnnotation(filters.FmtAnnotation)),
@@ -125,12 +226,44 @@ func (g *Generator) selectors() []scan.Selector {
 			result = append(result, (*generation.ReplicaSelector)(g.Application.Configuration[i].Replicas))
 		case g.Application.Configuration[i].EnvironmentVariable != nil:
 			result = append(result, (*generation.EnvironmentVariablesSelector)(g.Application.Configuration[i].EnvironmentVariable))
+		case g.Application.Configuration[i].Storage != nil:
+			result = append(result, (*generation.StorageSelector)(g.Application.Configuration[i].Storage))
 		}
 	}
 
 	return result
 }
 
+// applyPolicies narrows and verifies the generated experiment against the configured policies.
+func (g *Generator) applyPolicies(node *yaml.RNode) (*yaml.RNode, error) {
+	if len(g.Policies) == 0 {
+		return node, nil
+	}
+
+	if m, err := node.GetMeta(); err != nil {
+		return nil, err
+	} else if m.Kind != "Experiment" || !strings.HasPrefix(m.APIVersion, optimizev1beta2.GroupVersion.Group+"/") {
+		return node, nil
+	}
+
+	exp := &optimizev1beta2.Experiment{}
+	if err := sfio.DecodeYAMLToJSON(node, exp); err != nil {
+		return nil, err
+	}
+
+	if dirty, err := ApplyPolicies(exp, g.Policies); err != nil {
+		return nil, err
+	} else if !dirty {
+		return node, nil
+	}
+
+	nodes, err := (sfio.ObjectSlice{exp}).Read()
+	if err != nil {
+		return nil, err
+	}
+	return nodes[0], nil
+}
+
 // validate is basically just a hook to perform final verifications before actually emitting anything.
 func (g *Generator) validate([]*yaml.RNode) error {
 
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/setup"
 	"github.com/thestormforge/optimize-controller/v2/internal/trial"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -51,7 +52,7 @@ func NextTrialNamespace(ctx context.Context, c client.Client, exp *optimizev1bet
 	if n := exp.Spec.TrialTemplate.Namespace; n != "" {
 		// If there is an explicit target namespace on the trial template it is the only one we will be allowed to use
 		selector = client.MatchingFields{"metadata.name": n}
-	} else if exp.Spec.NamespaceSelector == nil && exp.Spec.NamespaceTemplate == nil {
+	} else if IsSingleNamespace(exp) {
 		// If there is no namespace selector/template we can only use the experiment namespace
 		selector = client.MatchingFields{"metadata.name": exp.Namespace}
 	} else {
@@ -69,6 +70,10 @@ func NextTrialNamespace(ctx context.Context, c client.Client, exp *optimizev1bet
 		return "", err
 	}
 	for i := range namespaceList.Items {
+		// Skip namespaces that are being deleted, e.g. when a template namespace from a previous trial is cleaned up
+		if !namespaceList.Items[i].DeletionTimestamp.IsZero() {
+			continue
+		}
 		if n := namespaceList.Items[i].Name; !activeNamespaces[n] {
 			return n, nil
 		}
@@ -83,6 +88,25 @@ func NextTrialNamespace(ctx context.Context, c client.Client, exp *optimizev1bet
 	return "", nil
 }
 
+// IsSingleNamespace checks to see if all of the experiment's trials must run in the same namespace, in which case
+// only one trial can be active at a time regardless of the number of replicas
+func IsSingleNamespace(exp *optimizev1beta2.Experiment) bool {
+	if exp.Spec.TrialTemplate.Namespace != "" {
+		return true
+	}
+	return exp.Spec.NamespaceSelector == nil && exp.Spec.NamespaceTemplate == nil
+}
+
+// IsTemplateNamespace checks to see if the namespace was created from the experiment's namespace template.
+func IsTemplateNamespace(exp *optimizev1beta2.Experiment, n *corev1.Namespace) bool {
+	return n.GetAnnotations()[optimizev1beta2.AnnotationNamespaceTemplate] == namespaceTemplateOwner(exp)
+}
+
+// namespaceTemplateOwner returns the value used to identify namespaces created from the experiment's template.
+func namespaceTemplateOwner(exp *optimizev1beta2.Experiment) string {
+	return exp.Namespace + "/" + exp.Name
+}
+
 func ignorePermissions(err error) error {
 	if apierrs.IsUnauthorized(err) {
 		return nil
@@ -107,7 +131,11 @@ func createNamespaceFromTemplate(ctx context.Context, c client.Client, exp *opti
 	n.Labels[optimizev1beta2.LabelExperiment] = exp.Name
 	n.Labels[optimizev1beta2.LabelTrialRole] = "trialSetup"
 
-	// TODO We should also record the fact that we created the namespace for possible clean up later
+	// Record the fact that we created the namespace so it can be cleaned up later
+	if n.Annotations == nil {
+		n.Annotations = map[string]string{}
+	}
+	n.Annotations[optimizev1beta2.AnnotationNamespaceTemplate] = namespaceTemplateOwner(exp)
 
 	// NOTE: The ignorePermission call is in different places for the namespace and supporting objects because
 	// if the namespace creation fails we cannot continue creating the supporting objects
@@ -137,6 +165,16 @@ func createNamespaceFromTemplate(ctx context.Context, c client.Client, exp *opti
 			return "", err
 		}
 	}
+	if ts.ResourceQuota != nil {
+		if err := c.Create(ctx, ts.ResourceQuota); ignorePermissions(err) != nil {
+			return "", err
+		}
+	}
+	if ts.LimitRange != nil {
+		if err := c.Create(ctx, ts.LimitRange); ignorePermissions(err) != nil {
+			return "", err
+		}
+	}
 
 	return n.Name, nil
 }
@@ -146,36 +184,57 @@ type trialNamespace struct {
 	ServiceAccount *corev1.ServiceAccount
 	Role           *rbacv1.Role
 	RoleBindings   []rbacv1.RoleBinding
+	ResourceQuota  *corev1.ResourceQuota
+	LimitRange     *corev1.LimitRange
 }
 
 func createTrialNamespace(exp *optimizev1beta2.Experiment, namespace string) *trialNamespace {
 	ts := &trialNamespace{}
 
+	// Label the supporting objects so they can be found if they are orphaned
+	labels := func() map[string]string {
+		return map[string]string{
+			optimizev1beta2.LabelExperiment: exp.Name,
+			optimizev1beta2.LabelTrialRole:  "trialSetup",
+		}
+	}
+
 	// Fill in the details about the service account
 	ts.ServiceAccount = &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      exp.Spec.TrialTemplate.Spec.SetupServiceAccountName,
 			Namespace: namespace,
+			Labels:    labels(),
 		},
 	}
 	if ts.ServiceAccount.Name == "" {
 		ts.ServiceAccount.Name = "default"
 	}
 
-	// Add a namespaced role and binding based on the default setup task policy rules
-	if len(exp.Spec.TrialTemplate.Spec.SetupDefaultRules) > 0 {
+	// Add a namespaced role and binding based on the default and task specific setup policy rules
+	rules := append([]rbacv1.PolicyRule{}, exp.Spec.TrialTemplate.Spec.SetupDefaultRules...)
+	for i := range exp.Spec.TrialTemplate.Spec.SetupTasks {
+		task := &exp.Spec.TrialTemplate.Spec.SetupTasks[i]
+		rules = append(rules, task.Rules...)
+		if task.HelmPatch && task.HelmChart != "" {
+			rules = append(rules, setup.HelmPatchRules...)
+		}
+	}
+	if len(rules) > 0 {
 		ts.Role = &rbacv1.Role{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "optimize-setup-role",
 				Namespace: namespace,
+				Labels:    labels(),
 			},
-			Rules: exp.Spec.TrialTemplate.Spec.SetupDefaultRules,
+			Rules: rules,
 		}
 
 		ts.RoleBindings = append(ts.RoleBindings, rbacv1.RoleBinding{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "optimize-setup-rolebinding",
 				Namespace: namespace,
+				Labels:    labels(),
 			},
 			Subjects: []rbacv1.Subject{{
 				Kind:      "ServiceAccount",
@@ -196,6 +255,7 @@ func createTrialNamespace(exp *optimizev1beta2.Experiment, namespace string) *tr
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "optimize-setup-cluster-rolebinding",
 				Namespace: namespace,
+				Labels:    labels(),
 			},
 			Subjects: []rbacv1.Subject{{
 				Kind:      "ServiceAccount",
@@ -210,6 +270,28 @@ func createTrialNamespace(exp *optimizev1beta2.Experiment, namespace string) *tr
 		})
 	}
 
+	// Constrain the resources available to the trial
+	if nt := exp.Spec.NamespaceTemplate; nt != nil && nt.ResourceQuota != nil {
+		ts.ResourceQuota = &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "optimize-trial-resourcequota",
+				Namespace: namespace,
+				Labels:    labels(),
+			},
+		}
+		nt.ResourceQuota.DeepCopyInto(&ts.ResourceQuota.Spec)
+	}
+	if nt := exp.Spec.NamespaceTemplate; nt != nil && nt.LimitRange != nil {
+		ts.LimitRange = &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "optimize-trial-limitrange",
+				Namespace: namespace,
+				Labels:    labels(),
+			},
+		}
+		nt.LimitRange.DeepCopyInto(&ts.LimitRange.Spec)
+	}
+
 	// Don't actually return the default service account for creation
 	if ts.ServiceAccount.Name == "default" {
 		ts.ServiceAccount = nil
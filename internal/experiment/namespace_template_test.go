@@ -0,0 +1,222 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/setup"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsSingleNamespace(t *testing.T) {
+	cases := []struct {
+		desc     string
+		spec     optimizev1beta2.ExperimentSpec
+		expected bool
+	}{
+		{
+			desc:     "default",
+			expected: true,
+		},
+		{
+			desc: "namespace selector",
+			spec: optimizev1beta2.ExperimentSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}},
+			},
+		},
+		{
+			desc: "namespace template",
+			spec: optimizev1beta2.ExperimentSpec{
+				NamespaceTemplate: &optimizev1beta2.NamespaceTemplateSpec{},
+			},
+		},
+		{
+			desc: "explicit trial namespace",
+			spec: optimizev1beta2.ExperimentSpec{
+				NamespaceTemplate: &optimizev1beta2.NamespaceTemplateSpec{},
+				TrialTemplate:     optimizev1beta2.TrialTemplateSpec{ObjectMeta: metav1.ObjectMeta{Namespace: "foo"}},
+			},
+			expected: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, IsSingleNamespace(&optimizev1beta2.Experiment{Spec: c.spec}))
+		})
+	}
+}
+
+func TestIsTemplateNamespace(t *testing.T) {
+	exp := &optimizev1beta2.Experiment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+
+	cases := []struct {
+		desc        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			desc: "no annotation",
+		},
+		{
+			desc:        "created from template",
+			annotations: map[string]string{optimizev1beta2.AnnotationNamespaceTemplate: "default/test"},
+			expected:    true,
+		},
+		{
+			desc:        "other experiment",
+			annotations: map[string]string{optimizev1beta2.AnnotationNamespaceTemplate: "other/test"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			n := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			assert.Equal(t, c.expected, IsTemplateNamespace(exp, n))
+		})
+	}
+}
+
+func TestCreateTrialNamespace(t *testing.T) {
+	quota := &corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse("4")},
+	}
+	limits := &corev1.LimitRangeSpec{
+		Limits: []corev1.LimitRangeItem{{
+			Type:    corev1.LimitTypeContainer,
+			Default: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		}},
+	}
+
+	cases := []struct {
+		desc              string
+		namespaceTemplate *optimizev1beta2.NamespaceTemplateSpec
+	}{
+		{
+			desc: "no template",
+		},
+		{
+			desc:              "empty template",
+			namespaceTemplate: &optimizev1beta2.NamespaceTemplateSpec{},
+		},
+		{
+			desc:              "resource quota",
+			namespaceTemplate: &optimizev1beta2.NamespaceTemplateSpec{ResourceQuota: quota},
+		},
+		{
+			desc:              "limit range",
+			namespaceTemplate: &optimizev1beta2.NamespaceTemplateSpec{LimitRange: limits},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+			exp.Spec.NamespaceTemplate = c.namespaceTemplate
+
+			ts := createTrialNamespace(exp, "test-trial")
+
+			if c.namespaceTemplate != nil && c.namespaceTemplate.ResourceQuota != nil {
+				if assert.NotNil(t, ts.ResourceQuota) {
+					assert.Equal(t, "test-trial", ts.ResourceQuota.Namespace)
+					assert.Equal(t, "test", ts.ResourceQuota.Labels[optimizev1beta2.LabelExperiment])
+					assert.Equal(t, *quota, ts.ResourceQuota.Spec)
+				}
+			} else {
+				assert.Nil(t, ts.ResourceQuota)
+			}
+
+			if c.namespaceTemplate != nil && c.namespaceTemplate.LimitRange != nil {
+				if assert.NotNil(t, ts.LimitRange) {
+					assert.Equal(t, "test-trial", ts.LimitRange.Namespace)
+					assert.Equal(t, "test", ts.LimitRange.Labels[optimizev1beta2.LabelExperiment])
+					assert.Equal(t, *limits, ts.LimitRange.Spec)
+				}
+			} else {
+				assert.Nil(t, ts.LimitRange)
+			}
+		})
+	}
+}
+
+func TestCreateTrialNamespace_SetupRules(t *testing.T) {
+	defaultRule := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}
+	taskRule := rbacv1.PolicyRule{APIGroups: []string{"batch"}, Resources: []string{"jobs"}, Verbs: []string{"create", "delete"}}
+
+	cases := []struct {
+		desc          string
+		defaultRules  []rbacv1.PolicyRule
+		taskRules     []rbacv1.PolicyRule
+		helmPatch     bool
+		expectedRules []rbacv1.PolicyRule
+	}{
+		{
+			desc: "no rules",
+		},
+		{
+			desc:          "default rules",
+			defaultRules:  []rbacv1.PolicyRule{defaultRule},
+			expectedRules: []rbacv1.PolicyRule{defaultRule},
+		},
+		{
+			desc:          "task rules",
+			taskRules:     []rbacv1.PolicyRule{taskRule},
+			expectedRules: []rbacv1.PolicyRule{taskRule},
+		},
+		{
+			desc:          "default and task rules",
+			defaultRules:  []rbacv1.PolicyRule{defaultRule},
+			taskRules:     []rbacv1.PolicyRule{taskRule},
+			expectedRules: []rbacv1.PolicyRule{defaultRule, taskRule},
+		},
+		{
+			desc:          "helm patch rules",
+			taskRules:     []rbacv1.PolicyRule{taskRule},
+			helmPatch:     true,
+			expectedRules: append([]rbacv1.PolicyRule{taskRule}, setup.HelmPatchRules...),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+			exp.Spec.TrialTemplate.Spec.SetupServiceAccountName = "optimize-setup"
+			exp.Spec.TrialTemplate.Spec.SetupDefaultRules = c.defaultRules
+			exp.Spec.TrialTemplate.Spec.SetupTasks = []optimizev1beta2.SetupTask{
+				{Name: "seed", Image: "example.com/seed-loader:1", Rules: c.taskRules},
+			}
+			if c.helmPatch {
+				exp.Spec.TrialTemplate.Spec.SetupTasks[0].HelmChart = "stable/postgresql"
+				exp.Spec.TrialTemplate.Spec.SetupTasks[0].HelmPatch = true
+			}
+
+			ts := createTrialNamespace(exp, "test-trial")
+
+			if c.expectedRules == nil {
+				assert.Nil(t, ts.Role)
+				assert.Empty(t, ts.RoleBindings)
+				return
+			}
+			if assert.NotNil(t, ts.Role) && assert.Len(t, ts.RoleBindings, 1) {
+				assert.Equal(t, c.expectedRules, ts.Role.Rules)
+				assert.Equal(t, "optimize-setup", ts.RoleBindings[0].Subjects[0].Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// PolicyError is raised when an experiment violates an optimization policy
+type PolicyError struct {
+	// The name of the policy that was violated
+	Policy string
+	// Descriptions of the individual violations
+	Violations []string
+}
+
+// Error returns a message describing the policy violations
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("experiment violates optimization policy %q: %s", e.Policy, strings.Join(e.Violations, "; "))
+}
+
+// ApplyPolicies narrows the experiment parameters to fit the bounds of each policy and then verifies the
+// remaining policy constraints. Returns true if the experiment was modified. Policies must only be applied before the
+// experiment is created on the server, use `CheckPolicies` afterwards.
+func ApplyPolicies(exp *optimizev1beta2.Experiment, policies []optimizev1beta2.OptimizationPolicy) (bool, error) {
+	var dirty bool
+	for i := range policies {
+		d, err := applyParameterBounds(exp, policies[i].Spec.ParameterBounds)
+		if err != nil {
+			return dirty, fmt.Errorf("invalid optimization policy %q: %w", policies[i].Name, err)
+		}
+		dirty = d || dirty
+	}
+
+	return dirty, CheckPolicies(exp, policies)
+}
+
+// CheckPolicies ensures the experiment does not violate any of the supplied policies without modifying it.
+func CheckPolicies(exp *optimizev1beta2.Experiment, policies []optimizev1beta2.OptimizationPolicy) error {
+	for i := range policies {
+		if err := CheckPolicy(exp, &policies[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckPolicy ensures the experiment does not violate the supplied policy.
+func CheckPolicy(exp *optimizev1beta2.Experiment, policy *optimizev1beta2.OptimizationPolicy) error {
+	err := &PolicyError{Policy: policy.Name}
+
+	violations, e := checkParameterBounds(exp, policy.Spec.ParameterBounds)
+	if e != nil {
+		return fmt.Errorf("invalid optimization policy %q: %w", policy.Name, e)
+	}
+	err.Violations = append(err.Violations, violations...)
+
+	for _, ns := range policy.Spec.ForbiddenNamespaces {
+		if exp.Namespace == ns {
+			err.Violations = append(err.Violations, fmt.Sprintf("namespace %q is forbidden", ns))
+		}
+		if exp.Spec.TrialTemplate.Namespace == ns {
+			err.Violations = append(err.Violations, fmt.Sprintf("trial namespace %q is forbidden", ns))
+		}
+	}
+
+	for _, l := range policy.Spec.RequiredLabels {
+		if _, ok := exp.Labels[l]; !ok {
+			err.Violations = append(err.Violations, fmt.Sprintf("missing required label %q", l))
+		}
+	}
+
+	if max := policy.Spec.MaxReplicas; max != nil && exp.Replicas() > *max {
+		err.Violations = append(err.Violations, fmt.Sprintf("replicas %d exceeds the maximum of %d", exp.Replicas(), *max))
+	}
+
+	if max := policy.Spec.MaxExperimentBudget; max != nil {
+		for _, o := range exp.Spec.Optimization {
+			if o.Name != "experimentBudget" {
+				continue
+			}
+			if budget, e := strconv.ParseInt(o.Value, 10, 32); e != nil {
+				err.Violations = append(err.Violations, fmt.Sprintf("experiment budget %q is not a valid number of trials", o.Value))
+			} else if int32(budget) > *max {
+				err.Violations = append(err.Violations, fmt.Sprintf("experiment budget %q exceeds the maximum of %d", o.Value, *max))
+			}
+		}
+	}
+
+	if len(err.Violations) == 0 {
+		return nil
+	}
+	return err
+}
+
+// applyParameterBounds narrows numeric parameter ranges to fit the supplied bounds.
+func applyParameterBounds(exp *optimizev1beta2.Experiment, bounds []optimizev1beta2.ParameterBounds) (bool, error) {
+	var dirty bool
+	for _, b := range bounds {
+		re, err := regexp.Compile("^(?:" + b.Name + ")$")
+		if err != nil {
+			return dirty, err
+		}
+
+		for i := range exp.Spec.Parameters {
+			p := &exp.Spec.Parameters[i]
+			if len(p.Values) > 0 || (b.Name != "" && !re.MatchString(p.Name)) {
+				continue
+			}
+
+			if b.Min != nil && p.Min < *b.Min {
+				p.Min = *b.Min
+				dirty = true
+			}
+			if b.Max != nil && p.Max > *b.Max {
+				p.Max = *b.Max
+				dirty = true
+			}
+			if p.Min > p.Max {
+				return dirty, fmt.Errorf("parameter %q has no values within bounds [%d,%d]", p.Name, p.Min, p.Max)
+			}
+
+			// A baseline outside the narrowed range would be rejected by the server
+			if p.Baseline != nil && p.Baseline.Type == intstr.Int {
+				if v := p.Baseline.IntVal; v < p.Min || v > p.Max {
+					p.Baseline = nil
+					dirty = true
+				}
+			}
+		}
+	}
+	return dirty, nil
+}
+
+// checkParameterBounds returns a description of each numeric parameter range that does not fit the supplied bounds.
+func checkParameterBounds(exp *optimizev1beta2.Experiment, bounds []optimizev1beta2.ParameterBounds) ([]string, error) {
+	var violations []string
+	for _, b := range bounds {
+		re, err := regexp.Compile("^(?:" + b.Name + ")$")
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range exp.Spec.Parameters {
+			p := &exp.Spec.Parameters[i]
+			if len(p.Values) > 0 || (b.Name != "" && !re.MatchString(p.Name)) {
+				continue
+			}
+
+			if b.Min != nil && p.Min < *b.Min {
+				violations = append(violations, fmt.Sprintf("parameter %q minimum %d is below the bound of %d", p.Name, p.Min, *b.Min))
+			}
+			if b.Max != nil && p.Max > *b.Max {
+				violations = append(violations, fmt.Sprintf("parameter %q maximum %d exceeds the bound of %d", p.Name, p.Max, *b.Max))
+			}
+		}
+	}
+	return violations, nil
+}
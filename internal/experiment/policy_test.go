@@ -0,0 +1,236 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestApplyPolicies(t *testing.T) {
+	one, four, ten := int32(1), int32(4), int32(10)
+	minCPU, maxCPU := int32(200), int32(3000)
+	baseline := intstr.FromInt(2000)
+
+	cases := []struct {
+		desc          string
+		experiment    optimizev1beta2.Experiment
+		policy        optimizev1beta2.OptimizationPolicySpec
+		expected      []optimizev1beta2.Parameter
+		dirty         bool
+		hasError      bool
+		expectedError string
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "narrow matching parameter",
+			experiment: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Parameters: []optimizev1beta2.Parameter{
+						{Name: "cpu", Min: 100, Max: 4000, Baseline: &baseline},
+						{Name: "memory", Min: 128, Max: 4096},
+					},
+				},
+			},
+			policy: optimizev1beta2.OptimizationPolicySpec{
+				ParameterBounds: []optimizev1beta2.ParameterBounds{
+					{Name: "cpu", Min: &minCPU, Max: &maxCPU},
+				},
+			},
+			expected: []optimizev1beta2.Parameter{
+				{Name: "cpu", Min: 200, Max: 3000, Baseline: &baseline},
+				{Name: "memory", Min: 128, Max: 4096},
+			},
+			dirty: true,
+		},
+		{
+			desc: "no values within bounds",
+			experiment: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Parameters: []optimizev1beta2.Parameter{
+						{Name: "cpu", Min: 100, Max: 4000, Baseline: &baseline},
+						{Name: "memory", Min: 128, Max: 4096},
+					},
+				},
+			},
+			policy: optimizev1beta2.OptimizationPolicySpec{
+				ParameterBounds: []optimizev1beta2.ParameterBounds{
+					{Name: "cpu", Min: &ten, Max: &one},
+				},
+			},
+			hasError: true,
+		},
+		{
+			desc: "narrow all parameters",
+			experiment: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Parameters: []optimizev1beta2.Parameter{
+						{Name: "replicas", Min: 0, Max: 20, Baseline: &baseline},
+						{Name: "mode", Values: []string{"a", "b"}},
+					},
+				},
+			},
+			policy: optimizev1beta2.OptimizationPolicySpec{
+				ParameterBounds: []optimizev1beta2.ParameterBounds{
+					{Min: &one, Max: &ten},
+				},
+			},
+			expected: []optimizev1beta2.Parameter{
+				{Name: "replicas", Min: 1, Max: 10},
+				{Name: "mode", Values: []string{"a", "b"}},
+			},
+			dirty: true,
+		},
+		{
+			desc: "forbidden namespace",
+			experiment: optimizev1beta2.Experiment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system"},
+			},
+			policy: optimizev1beta2.OptimizationPolicySpec{
+				ForbiddenNamespaces: []string{"kube-system"},
+			},
+			hasError: true,
+		},
+		{
+			desc: "missing required label",
+			experiment: optimizev1beta2.Experiment{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "a"}},
+			},
+			policy: optimizev1beta2.OptimizationPolicySpec{
+				RequiredLabels: []string{"team", "cost-center"},
+			},
+			hasError: true,
+		},
+		{
+			desc: "budget ceiling",
+			experiment: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Optimization: []optimizev1beta2.Optimization{{Name: "experimentBudget", Value: "20"}},
+				},
+			},
+			policy: optimizev1beta2.OptimizationPolicySpec{
+				MaxExperimentBudget: &ten,
+			},
+			hasError:      true,
+			expectedError: "exceeds the maximum",
+		},
+		{
+			desc: "invalid budget",
+			experiment: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Optimization: []optimizev1beta2.Optimization{{Name: "experimentBudget", Value: "lots"}},
+				},
+			},
+			policy: optimizev1beta2.OptimizationPolicySpec{
+				MaxExperimentBudget: &ten,
+			},
+			hasError:      true,
+			expectedError: "not a valid number",
+		},
+		{
+			desc: "replica ceiling",
+			experiment: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Replicas: &four,
+				},
+			},
+			policy: optimizev1beta2.OptimizationPolicySpec{
+				MaxReplicas: &four,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := c.experiment.DeepCopy()
+			dirty, err := ApplyPolicies(exp, []optimizev1beta2.OptimizationPolicy{{Spec: c.policy}})
+			if c.hasError {
+				if assert.Error(t, err) && c.expectedError != "" {
+					assert.Contains(t, err.Error(), c.expectedError)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.dirty, dirty)
+				assert.Equal(t, c.expected, exp.Spec.Parameters)
+			}
+		})
+	}
+}
+
+func TestCheckPolicies(t *testing.T) {
+	one, ten := int32(1), int32(10)
+
+	cases := []struct {
+		desc       string
+		experiment optimizev1beta2.Experiment
+		policy     optimizev1beta2.OptimizationPolicySpec
+		hasError   bool
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "within bounds",
+			experiment: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Parameters: []optimizev1beta2.Parameter{
+						{Name: "replicas", Min: 1, Max: 10},
+					},
+				},
+			},
+			policy: optimizev1beta2.OptimizationPolicySpec{
+				ParameterBounds: []optimizev1beta2.ParameterBounds{
+					{Min: &one, Max: &ten},
+				},
+			},
+		},
+		{
+			desc: "outside bounds",
+			experiment: optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Parameters: []optimizev1beta2.Parameter{
+						{Name: "replicas", Min: 0, Max: 20},
+					},
+				},
+			},
+			policy: optimizev1beta2.OptimizationPolicySpec{
+				ParameterBounds: []optimizev1beta2.ParameterBounds{
+					{Min: &one, Max: &ten},
+				},
+			},
+			hasError: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := c.experiment.DeepCopy()
+			err := CheckPolicies(exp, []optimizev1beta2.OptimizationPolicy{{Spec: c.policy}})
+			if c.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, c.experiment.Spec.Parameters, exp.Spec.Parameters)
+		})
+	}
+}
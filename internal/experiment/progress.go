@@ -0,0 +1,71 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ImprovedBestTrial checks to see if the supplied (completed) trial is now the best trial of a single objective
+// experiment. The first completed trial is not considered an improvement. Returns a description of the improvement.
+func ImprovedBestTrial(exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList, t *optimizev1beta2.Trial) (string, bool) {
+	best, objective, bestValue, completed := bestTrial(exp, trialList)
+	if best == nil || completed < 2 || best.Name != t.Name || best.Namespace != t.Namespace {
+		return "", false
+	}
+
+	return fmt.Sprintf("Trial %s is the new best trial after %d completed trials (%s=%s)",
+		t.Name, completed, objective.Name, strconv.FormatFloat(bestValue, 'g', -1, 64)), true
+}
+
+// FailureStreak returns the number of consecutive failed trials, counting back from the most recently finished trial.
+func FailureStreak(trialList *optimizev1beta2.TrialList) int {
+	type finished struct {
+		time   metav1.Time
+		failed bool
+	}
+
+	var trials []finished
+	for i := range trialList.Items {
+		for _, c := range trialList.Items[i].Status.Conditions {
+			if c.Status != corev1.ConditionTrue {
+				continue
+			}
+			if c.Type == optimizev1beta2.TrialComplete || c.Type == optimizev1beta2.TrialFailed {
+				trials = append(trials, finished{time: c.LastTransitionTime, failed: c.Type == optimizev1beta2.TrialFailed})
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(trials, func(i, j int) bool { return trials[j].time.Before(&trials[i].time) })
+
+	var streak int
+	for _, t := range trials {
+		if !t.failed {
+			break
+		}
+		streak++
+	}
+	return streak
+}
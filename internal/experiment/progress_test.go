@@ -0,0 +1,116 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestImprovedBestTrial(t *testing.T) {
+	exp := &optimizev1beta2.Experiment{}
+	exp.Spec.Metrics = []optimizev1beta2.Metric{{Name: "duration", Minimize: true}}
+
+	newTrial := func(name, duration string) optimizev1beta2.Trial {
+		t := optimizev1beta2.Trial{}
+		t.Name = name
+		t.Spec.Values = []optimizev1beta2.Value{{Name: "duration", Value: duration}}
+		t.Status.Conditions = []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue}}
+		return t
+	}
+
+	cases := []struct {
+		desc            string
+		trials          []optimizev1beta2.Trial
+		trial           string
+		expectedMessage string
+	}{
+		{
+			desc:   "first trial",
+			trials: []optimizev1beta2.Trial{newTrial("a", "10")},
+			trial:  "a",
+		},
+		{
+			desc:   "not better",
+			trials: []optimizev1beta2.Trial{newTrial("a", "10"), newTrial("b", "20")},
+			trial:  "b",
+		},
+		{
+			desc:            "better",
+			trials:          []optimizev1beta2.Trial{newTrial("a", "10"), newTrial("b", "5")},
+			trial:           "b",
+			expectedMessage: "Trial b is the new best trial after 2 completed trials (duration=5)",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			trialList := &optimizev1beta2.TrialList{Items: c.trials}
+			tr := &optimizev1beta2.Trial{}
+			tr.Name = c.trial
+			msg, ok := ImprovedBestTrial(exp, trialList, tr)
+			assert.Equal(t, c.expectedMessage != "", ok)
+			assert.Equal(t, c.expectedMessage, msg)
+		})
+	}
+}
+
+func TestFailureStreak(t *testing.T) {
+	now := time.Date(2021, 6, 7, 8, 0, 0, 0, time.UTC)
+	newTrial := func(conditionType optimizev1beta2.TrialConditionType, ago time.Duration) optimizev1beta2.Trial {
+		t := optimizev1beta2.Trial{}
+		t.Status.Conditions = []optimizev1beta2.TrialCondition{{Type: conditionType, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-ago))}}
+		return t
+	}
+
+	cases := []struct {
+		desc           string
+		trials         []optimizev1beta2.Trial
+		expectedStreak int
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "last completed",
+			trials: []optimizev1beta2.Trial{
+				newTrial(optimizev1beta2.TrialFailed, 2*time.Minute),
+				newTrial(optimizev1beta2.TrialComplete, time.Minute),
+			},
+		},
+		{
+			desc: "failures after completed",
+			trials: []optimizev1beta2.Trial{
+				newTrial(optimizev1beta2.TrialFailed, time.Minute),
+				newTrial(optimizev1beta2.TrialComplete, 3*time.Minute),
+				newTrial(optimizev1beta2.TrialFailed, 2*time.Minute),
+				newTrial(optimizev1beta2.TrialFailed, 4*time.Minute),
+				{},
+			},
+			expectedStreak: 2,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expectedStreak, FailureStreak(&optimizev1beta2.TrialList{Items: c.trials}))
+		})
+	}
+}
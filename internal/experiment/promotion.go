@@ -0,0 +1,96 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PromotionCandidate returns the best trial of a completed experiment if it improves on the baseline trial by at
+// least the minimum improvement of the promotion policy. The returned message describes the improvement, or why there
+// is no candidate for promotion.
+func PromotionCandidate(exp *optimizev1beta2.Experiment, trialList *optimizev1beta2.TrialList) (*optimizev1beta2.Trial, string, bool) {
+	if exp.Spec.Promotion == nil {
+		return nil, "Promotion is not enabled", false
+	}
+
+	best, objective, bestValue, _ := bestTrial(exp, trialList)
+	if best == nil {
+		return nil, "No best trial to promote", false
+	}
+
+	var baselineValue float64
+	var baseline *optimizev1beta2.Trial
+	for i := range trialList.Items {
+		t := &trialList.Items[i]
+		if !trial.IsBaseline(t, exp) || !trial.CheckCondition(&t.Status, optimizev1beta2.TrialComplete, corev1.ConditionTrue) {
+			continue
+		}
+		for _, v := range t.Spec.Values {
+			if value, err := strconv.ParseFloat(v.Value, 64); err == nil && v.Name == objective.Name {
+				baseline, baselineValue = t, value
+			}
+		}
+	}
+	if baseline == nil {
+		return nil, "No completed baseline trial to measure the improvement against", false
+	}
+	if best.Name == baseline.Name && best.Namespace == baseline.Namespace {
+		return nil, fmt.Sprintf("Baseline trial %s is the best trial", baseline.Name), false
+	}
+
+	improvement := 1.0
+	if baselineValue != 0 {
+		improvement = (bestValue - baselineValue) / math.Abs(baselineValue)
+		if objective.Minimize {
+			improvement = -improvement
+		}
+	}
+
+	improvementPercent := improvement * 100
+	if improvementPercent < float64(exp.Spec.Promotion.MinImprovementPercent) {
+		return nil, fmt.Sprintf("Trial %s improves %s by %.1f%% over the baseline, at least %d%% is required",
+			best.Name, objective.Name, improvementPercent, exp.Spec.Promotion.MinImprovementPercent), false
+	}
+
+	return best, fmt.Sprintf("Trial %s improves %s by %.1f%% over the baseline", best.Name, objective.Name, improvementPercent), true
+}
+
+// UntilRollbackEnds returns the amount of time remaining in the rollback window of a promoted experiment, zero if the
+// experiment was not promoted or the rollback window has ended.
+func UntilRollbackEnds(exp *optimizev1beta2.Experiment, now time.Time) time.Duration {
+	if exp.Spec.Promotion == nil || exp.Spec.Promotion.RollbackWindowSeconds <= 0 {
+		return 0
+	}
+
+	for _, c := range exp.Status.Conditions {
+		if c.Type == optimizev1beta2.ExperimentPromoted && c.Status == corev1.ConditionTrue {
+			window := time.Duration(exp.Spec.Promotion.RollbackWindowSeconds) * time.Second
+			if d := c.LastTransitionTime.Add(window).Sub(now); d > 0 {
+				return d
+			}
+		}
+	}
+	return 0
+}
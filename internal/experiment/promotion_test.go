@@ -0,0 +1,140 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestPromotionCandidate(t *testing.T) {
+	baseline := intstr.FromInt(1)
+	newTrial := func(name, duration string, baseline bool) optimizev1beta2.Trial {
+		t := optimizev1beta2.Trial{}
+		t.Name = name
+		t.Spec.Assignments = []optimizev1beta2.Assignment{{Name: "replicas", Value: intstr.FromInt(2)}}
+		if baseline {
+			t.Spec.Assignments[0].Value = intstr.FromInt(1)
+		}
+		t.Spec.Values = []optimizev1beta2.Value{{Name: "duration", Value: duration}}
+		t.Status.Conditions = []optimizev1beta2.TrialCondition{{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue}}
+		return t
+	}
+
+	cases := []struct {
+		desc            string
+		promotion       *optimizev1beta2.PromotionPolicy
+		trials          []optimizev1beta2.Trial
+		expectedTrial   string
+		expectedMessage string
+	}{
+		{
+			desc:            "not enabled",
+			trials:          []optimizev1beta2.Trial{newTrial("a", "10", true), newTrial("b", "5", false)},
+			expectedMessage: "Promotion is not enabled",
+		},
+		{
+			desc:            "no baseline",
+			promotion:       &optimizev1beta2.PromotionPolicy{},
+			trials:          []optimizev1beta2.Trial{newTrial("a", "10", false), newTrial("b", "5", false)},
+			expectedMessage: "No completed baseline trial to measure the improvement against",
+		},
+		{
+			desc:            "baseline is best",
+			promotion:       &optimizev1beta2.PromotionPolicy{},
+			trials:          []optimizev1beta2.Trial{newTrial("a", "10", true), newTrial("b", "15", false)},
+			expectedMessage: "Baseline trial a is the best trial",
+		},
+		{
+			desc:            "insufficient improvement",
+			promotion:       &optimizev1beta2.PromotionPolicy{MinImprovementPercent: 60},
+			trials:          []optimizev1beta2.Trial{newTrial("a", "10", true), newTrial("b", "5", false)},
+			expectedMessage: "Trial b improves duration by 50.0% over the baseline, at least 60% is required",
+		},
+		{
+			desc:            "promoted",
+			promotion:       &optimizev1beta2.PromotionPolicy{MinImprovementPercent: 20},
+			trials:          []optimizev1beta2.Trial{newTrial("a", "10", true), newTrial("b", "5", false), newTrial("c", "7", false)},
+			expectedTrial:   "b",
+			expectedMessage: "Trial b improves duration by 50.0% over the baseline",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{}
+			exp.Spec.Parameters = []optimizev1beta2.Parameter{{Name: "replicas", Min: 1, Max: 5, Baseline: &baseline}}
+			exp.Spec.Metrics = []optimizev1beta2.Metric{{Name: "duration", Minimize: true}}
+			exp.Spec.Promotion = c.promotion
+
+			best, msg, ok := PromotionCandidate(exp, &optimizev1beta2.TrialList{Items: c.trials})
+			assert.Equal(t, c.expectedTrial != "", ok)
+			assert.Equal(t, c.expectedMessage, msg)
+			if ok {
+				assert.Equal(t, c.expectedTrial, best.Name)
+			}
+		})
+	}
+}
+
+func TestUntilRollbackEnds(t *testing.T) {
+	now := time.Date(2021, 6, 7, 8, 0, 0, 0, time.UTC)
+	promoted := []optimizev1beta2.ExperimentCondition{
+		{Type: optimizev1beta2.ExperimentPromoted, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-time.Minute))},
+	}
+
+	cases := []struct {
+		desc       string
+		promotion  *optimizev1beta2.PromotionPolicy
+		conditions []optimizev1beta2.ExperimentCondition
+		expected   time.Duration
+	}{
+		{
+			desc:       "no window",
+			promotion:  &optimizev1beta2.PromotionPolicy{},
+			conditions: promoted,
+		},
+		{
+			desc:      "not promoted",
+			promotion: &optimizev1beta2.PromotionPolicy{RollbackWindowSeconds: 300},
+		},
+		{
+			desc:       "in window",
+			promotion:  &optimizev1beta2.PromotionPolicy{RollbackWindowSeconds: 300},
+			conditions: promoted,
+			expected:   4 * time.Minute,
+		},
+		{
+			desc:       "window ended",
+			promotion:  &optimizev1beta2.PromotionPolicy{RollbackWindowSeconds: 30},
+			conditions: promoted,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{}
+			exp.Spec.Promotion = c.promotion
+			exp.Status.Conditions = c.conditions
+			assert.Equal(t, c.expected, UntilRollbackEnds(exp, now))
+		})
+	}
+}
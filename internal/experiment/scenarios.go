@@ -0,0 +1,226 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	optimizeappsv1alpha1 "github.com/thestormforge/optimize-controller/v2/api/apps/v1alpha1"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// ScenarioParameterName is the name of the categorical parameter used to select the scenario of a combined experiment.
+const ScenarioParameterName = "scenario"
+
+// combineScenarios merges the experiments generated for individual scenarios into a single experiment
+// where the scenario is selected using a categorical parameter. The first scenario is used as the baseline,
+// the trial job for the remaining scenarios is selected using a patch on the trial job.
+func combineScenarios(experimentName string, scenarioNames []string, scenarioNodes [][]*yaml.RNode) ([]*yaml.RNode, error) {
+	exps := make([]*optimizev1beta2.Experiment, len(scenarioNodes))
+	for i := range scenarioNodes {
+		for _, node := range scenarioNodes[i] {
+			if m, err := node.GetMeta(); err != nil {
+				return nil, err
+			} else if m.Kind != "Experiment" || !strings.HasPrefix(m.APIVersion, optimizev1beta2.GroupVersion.Group+"/") {
+				continue
+			}
+
+			exps[i] = &optimizev1beta2.Experiment{}
+			if err := sfio.DecodeYAMLToJSON(node, exps[i]); err != nil {
+				return nil, err
+			}
+		}
+		if exps[i] == nil {
+			return nil, fmt.Errorf("no experiment generated for scenario %q", scenarioNames[i])
+		}
+	}
+
+	exp := exps[0]
+	exp.Name = experimentName
+	removeScenarioLabel(exp)
+
+	// All of the scenarios must be measured the same way
+	metricNames := sortedMetricNames(exp)
+	for i := 1; i < len(exps); i++ {
+		if strings.Join(sortedMetricNames(exps[i]), ",") != strings.Join(metricNames, ",") {
+			return nil, fmt.Errorf("scenarios %q and %q cannot be combined, they produce different metrics", scenarioNames[0], scenarioNames[i])
+		}
+	}
+
+	// Add the scenario as a categorical parameter
+	for i := range exp.Spec.Parameters {
+		if exp.Spec.Parameters[i].Name == ScenarioParameterName {
+			return nil, fmt.Errorf("scenarios cannot be combined, a parameter named %q already exists", ScenarioParameterName)
+		}
+	}
+	exp.Spec.Parameters = append(exp.Spec.Parameters, optimizev1beta2.Parameter{
+		Name:     ScenarioParameterName,
+		Baseline: &intstr.IntOrString{Type: intstr.String, StrVal: scenarioNames[0]},
+		Values:   scenarioNames,
+	})
+
+	// Select the trial job pod for the scenario, any scenario specific trial job patches must be applied after
+	patches, err := scenarioPodPatches(scenarioNames, exps)
+	if err != nil {
+		return nil, err
+	}
+	for i := range exp.Spec.Patches {
+		if !isTrialJobPatch(&exp.Spec.Patches[i]) {
+			patches = append([]optimizev1beta2.PatchTemplate{exp.Spec.Patches[i]}, patches...)
+		}
+	}
+	for i := range exps {
+		for j := range exps[i].Spec.Patches {
+			p := exps[i].Spec.Patches[j]
+			if isTrialJobPatch(&p) {
+				p.Patch = scenarioCondition(scenarioNames[i]) + "\n" + p.Patch + "\n{{- end }}\n"
+				patches = append(patches, p)
+			}
+		}
+	}
+	exp.Spec.Patches = patches
+
+	// Include the setup tasks from every scenario
+	for i := 1; i < len(exps); i++ {
+		for _, st := range exps[i].Spec.TrialTemplate.Spec.SetupTasks {
+			if !hasSetupTask(exp, st.Name) {
+				exp.Spec.TrialTemplate.Spec.SetupTasks = append(exp.Spec.TrialTemplate.Spec.SetupTasks, st)
+			}
+		}
+	}
+
+	// Collect the supporting resources, only scenario specific data is needed from the other scenarios
+	result, err := (sfio.ObjectSlice{exp}).Read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range scenarioNodes {
+		for _, node := range scenarioNodes[i] {
+			m, err := node.GetMeta()
+			if err != nil {
+				return nil, err
+			}
+			if m.Kind == "Experiment" && strings.HasPrefix(m.APIVersion, optimizev1beta2.GroupVersion.Group+"/") {
+				continue
+			}
+			if i > 0 && m.Kind != "ConfigMap" && m.Kind != "Secret" {
+				continue
+			}
+			result = append(result, node)
+		}
+	}
+
+	return result, nil
+}
+
+// scenarioPodPatches returns a trial job patch which replaces the pod specification of the baseline scenario.
+func scenarioPodPatches(scenarioNames []string, exps []*optimizev1beta2.Experiment) ([]optimizev1beta2.PatchTemplate, error) {
+	var patch strings.Builder
+	for i := 1; i < len(exps); i++ {
+		jobTemplate := exps[i].Spec.TrialTemplate.Spec.JobTemplate
+		if jobTemplate == nil {
+			continue
+		}
+
+		podSpec := make(map[string]interface{})
+		if data, err := json.Marshal(&jobTemplate.Spec.Template.Spec); err != nil {
+			return nil, err
+		} else if err := json.Unmarshal(data, &podSpec); err != nil {
+			return nil, err
+		}
+		podSpec["$patch"] = "replace"
+
+		data, err := k8syaml.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": podSpec,
+				},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if patch.Len() > 0 {
+			patch.WriteString("{{- end }}\n")
+		}
+		patch.WriteString(scenarioCondition(scenarioNames[i]))
+		patch.WriteString("\n")
+		patch.Write(data)
+	}
+
+	if patch.Len() == 0 {
+		return nil, nil
+	}
+	patch.WriteString("{{- end }}\n")
+
+	return []optimizev1beta2.PatchTemplate{
+		{
+			Patch: patch.String(),
+			TargetRef: &corev1.ObjectReference{
+				Kind:       "Job",
+				APIVersion: "batch/v1",
+			},
+		},
+	}, nil
+}
+
+// scenarioCondition returns the template action used to conditionally render a patch for a scenario.
+func scenarioCondition(scenarioName string) string {
+	return fmt.Sprintf("{{- if eq (index .Values %q) %q }}", ScenarioParameterName, scenarioName)
+}
+
+// isTrialJobPatch checks to see if a patch template applies to the trial job.
+func isTrialJobPatch(p *optimizev1beta2.PatchTemplate) bool {
+	return p.TargetRef != nil && p.TargetRef.Kind == "Job" && p.TargetRef.Name == ""
+}
+
+// removeScenarioLabel removes the scenario label from the experiment and the trials it produces.
+func removeScenarioLabel(exp *optimizev1beta2.Experiment) {
+	delete(exp.Labels, optimizeappsv1alpha1.LabelScenario)
+	delete(exp.Spec.TrialTemplate.Labels, optimizeappsv1alpha1.LabelScenario)
+	if jt := exp.Spec.TrialTemplate.Spec.JobTemplate; jt != nil {
+		delete(jt.Labels, optimizeappsv1alpha1.LabelScenario)
+		delete(jt.Spec.Template.Labels, optimizeappsv1alpha1.LabelScenario)
+	}
+}
+
+func sortedMetricNames(exp *optimizev1beta2.Experiment) []string {
+	var names []string
+	for i := range exp.Spec.Metrics {
+		names = append(names, exp.Spec.Metrics[i].Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hasSetupTask(exp *optimizev1beta2.Experiment, name string) bool {
+	for i := range exp.Spec.TrialTemplate.Spec.SetupTasks {
+		if exp.Spec.TrialTemplate.Spec.SetupTasks[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/sfio"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestCombineScenarios(t *testing.T) {
+	newExperiment := func(image string, metrics ...string) *optimizev1beta2.Experiment {
+		exp := &optimizev1beta2.Experiment{}
+		exp.APIVersion = optimizev1beta2.GroupVersion.String()
+		exp.Kind = "Experiment"
+		exp.Name = "test-" + image
+		exp.Spec.Parameters = []optimizev1beta2.Parameter{{Name: "cpu", Min: 100, Max: 4000}}
+		exp.Spec.TrialTemplate.Spec.JobTemplate = &batchv1beta1.JobTemplateSpec{}
+		exp.Spec.TrialTemplate.Spec.JobTemplate.Spec.Template.Spec.Containers = []corev1.Container{{Name: "load", Image: image}}
+		for _, m := range metrics {
+			exp.Spec.Metrics = append(exp.Spec.Metrics, optimizev1beta2.Metric{Name: m})
+		}
+		return exp
+	}
+
+	newNodes := func(objs ...runtime.Object) []*yaml.RNode {
+		nodes, err := sfio.ObjectSlice(objs).Read()
+		require.NoError(t, err)
+		return nodes
+	}
+
+	cases := []struct {
+		desc          string
+		scenarioNames []string
+		scenarioNodes [][]*yaml.RNode
+		expectedPatch string
+		hasError      bool
+	}{
+		{
+			desc:          "two scenarios",
+			scenarioNames: []string{"read", "write"},
+			scenarioNodes: [][]*yaml.RNode{
+				newNodes(newExperiment("read", "duration")),
+				newNodes(newExperiment("write", "duration")),
+			},
+			expectedPatch: `{{- if eq (index .Values "scenario") "write" }}
+spec:
+  template:
+    spec:
+      $patch: replace
+      containers:
+      - image: write
+        name: load
+        resources: {}
+{{- end }}
+`,
+		},
+		{
+			desc:          "different metrics",
+			scenarioNames: []string{"read", "write"},
+			scenarioNodes: [][]*yaml.RNode{
+				newNodes(newExperiment("read", "duration")),
+				newNodes(newExperiment("write", "throughput")),
+			},
+			hasError: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			nodes, err := combineScenarios("test", c.scenarioNames, c.scenarioNodes)
+			if c.hasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, nodes, 1)
+
+			exp := &optimizev1beta2.Experiment{}
+			require.NoError(t, sfio.DecodeYAMLToJSON(nodes[0], exp))
+			assert.Equal(t, "test", exp.Name)
+			if assert.Len(t, exp.Spec.Parameters, 2) {
+				assert.Equal(t, ScenarioParameterName, exp.Spec.Parameters[1].Name)
+				assert.Equal(t, c.scenarioNames, exp.Spec.Parameters[1].Values)
+				assert.Equal(t, c.scenarioNames[0], exp.Spec.Parameters[1].Baseline.StrVal)
+			}
+			if assert.Len(t, exp.Spec.Patches, 1) {
+				assert.Equal(t, c.expectedPatch, exp.Spec.Patches[0].Patch)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/schedule"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InitializeSchedule records the state of a scheduled experiment needed to create subsequent runs. Returns true only
+// if changes were necessary.
+func InitializeSchedule(exp *optimizev1beta2.Experiment) bool {
+	if exp.Spec.Schedule == "" {
+		return false
+	}
+	if _, ok := exp.GetAnnotations()[optimizev1beta2.AnnotationLastScheduleTime]; ok {
+		return false
+	}
+
+	if exp.Annotations == nil {
+		exp.Annotations = map[string]string{}
+	}
+	exp.Annotations[optimizev1beta2.AnnotationLastScheduleTime] = exp.CreationTimestamp.UTC().Format(time.RFC3339)
+	if exp.Spec.Replicas != nil {
+		exp.Annotations[optimizev1beta2.AnnotationScheduleReplicas] = strconv.Itoa(int(*exp.Spec.Replicas))
+	}
+	return true
+}
+
+// ScheduledRunTime returns the most recent scheduled time (the zero time if a run is not due) along with the next
+// scheduled time after the supplied time (the zero time if the schedule never matches again).
+func ScheduledRunTime(exp *optimizev1beta2.Experiment, now time.Time) (time.Time, time.Time, error) {
+	s, err := schedule.Parse(exp.Spec.Schedule)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	last := exp.CreationTimestamp.UTC()
+	if lst, err := time.Parse(time.RFC3339, exp.GetAnnotations()[optimizev1beta2.AnnotationLastScheduleTime]); err == nil {
+		last = lst.UTC()
+	}
+
+	// Skip over missed runs so only the most recent one is due
+	var due time.Time
+	next := s.Next(last)
+	for !next.IsZero() && !next.After(now) {
+		due, next = next, s.Next(next)
+	}
+	return due, next, nil
+}
+
+// LatestRun returns the most recent run of a scheduled experiment, the experiment itself is the first run.
+func LatestRun(exp *optimizev1beta2.Experiment, runs []optimizev1beta2.Experiment) *optimizev1beta2.Experiment {
+	latest, latestRun := exp, 1
+	for i := range runs {
+		if run := scheduleRun(&runs[i]); run > latestRun {
+			latest, latestRun = &runs[i], run
+		}
+	}
+	return latest
+}
+
+// PopulateRun creates the next run of a scheduled experiment.
+func PopulateRun(exp, previous, run *optimizev1beta2.Experiment, scheduleTime time.Time) {
+	n := scheduleRun(previous) + 1
+	run.Name = fmt.Sprintf("%s-v%d", exp.Name, n)
+	run.Namespace = exp.Namespace
+
+	run.Labels = make(map[string]string, len(exp.Labels)+1)
+	for k, v := range exp.Labels {
+		run.Labels[k] = v
+	}
+	run.Labels[optimizev1beta2.LabelScheduledExperiment] = exp.Name
+
+	// Do not copy the annotations used to track the server or schedule state
+	run.Annotations = make(map[string]string, len(exp.Annotations)+2)
+	for k, v := range exp.Annotations {
+		switch k {
+		case optimizev1beta2.AnnotationExperimentURL,
+			optimizev1beta2.AnnotationNextTrialURL,
+			optimizev1beta2.AnnotationLastScheduleTime,
+			optimizev1beta2.AnnotationScheduleReplicas,
+			"kubectl.kubernetes.io/last-applied-configuration":
+			continue
+		}
+		run.Annotations[k] = v
+	}
+	run.Annotations[optimizev1beta2.AnnotationScheduleRun] = strconv.Itoa(n)
+	run.Annotations[optimizev1beta2.AnnotationPreviousRun] = previous.Name
+
+	exp.Spec.DeepCopyInto(&run.Spec)
+	run.Spec.Schedule = ""
+
+	// Restore the replica count, the experiment may have scaled itself down when it finished
+	run.Spec.Replicas = nil
+	if r, err := strconv.ParseInt(exp.Annotations[optimizev1beta2.AnnotationScheduleReplicas], 10, 32); err == nil {
+		replicas := int32(r)
+		run.Spec.Replicas = &replicas
+	}
+
+	// Deadlines are relative to the start of the run
+	if exp.Spec.Deadline != nil {
+		run.Spec.Deadline = &metav1.Time{Time: scheduleTime.Add(exp.Spec.Deadline.Sub(exp.CreationTimestamp.Time))}
+	}
+
+	// Each run only selects its own trials
+	run.Spec.Selector = nil
+	delete(run.Spec.TrialTemplate.Labels, optimizev1beta2.LabelExperiment)
+}
+
+// scheduleRun returns the run number of an experiment, the scheduled experiment itself is the first run.
+func scheduleRun(exp *optimizev1beta2.Experiment) int {
+	if n, err := strconv.Atoi(exp.GetAnnotations()[optimizev1beta2.AnnotationScheduleRun]); err == nil {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScheduledRunTime(t *testing.T) {
+	created := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		desc         string
+		lastSchedule string
+		now          time.Time
+		expectedDue  time.Time
+		expectedNext time.Time
+	}{
+		{
+			desc:         "not due",
+			now:          created.Add(time.Hour),
+			expectedNext: time.Date(2021, 6, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:         "due",
+			now:          time.Date(2021, 6, 2, 0, 5, 0, 0, time.UTC),
+			expectedDue:  time.Date(2021, 6, 2, 0, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2021, 6, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:         "missed runs",
+			now:          time.Date(2021, 6, 5, 1, 0, 0, 0, time.UTC),
+			expectedDue:  time.Date(2021, 6, 5, 0, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2021, 6, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:         "last schedule",
+			lastSchedule: "2021-06-02T00:00:00Z",
+			now:          time.Date(2021, 6, 2, 0, 5, 0, 0, time.UTC),
+			expectedNext: time.Date(2021, 6, 3, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			exp := &optimizev1beta2.Experiment{}
+			exp.CreationTimestamp = metav1.NewTime(created)
+			exp.Spec.Schedule = "@daily"
+			if c.lastSchedule != "" {
+				exp.Annotations = map[string]string{optimizev1beta2.AnnotationLastScheduleTime: c.lastSchedule}
+			}
+
+			due, next, err := ScheduledRunTime(exp, c.now)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expectedDue, due)
+				assert.Equal(t, c.expectedNext, next)
+			}
+		})
+	}
+}
+
+func TestPopulateRun(t *testing.T) {
+	created := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	scheduleTime := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+	deadline := metav1.NewTime(created.Add(24 * time.Hour))
+
+	exp := &optimizev1beta2.Experiment{}
+	exp.Name = "my-exp"
+	exp.Namespace = "default"
+	exp.CreationTimestamp = metav1.NewTime(created)
+	exp.Labels = map[string]string{"app": "test"}
+	exp.Annotations = map[string]string{
+		optimizev1beta2.AnnotationExperimentURL:    "http://example.com/experiments/my-exp",
+		optimizev1beta2.AnnotationScheduleReplicas: "2",
+		"custom": "value",
+	}
+	exp.Spec.Schedule = "@monthly"
+	exp.Spec.Deadline = &deadline
+	exp.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{optimizev1beta2.LabelExperiment: "my-exp"}}
+	exp.Spec.TrialTemplate.Labels = map[string]string{optimizev1beta2.LabelExperiment: "my-exp"}
+	exp.SetReplicas(2)
+	assert.True(t, InitializeSchedule(exp))
+	exp.SetReplicas(0)
+
+	previous := &optimizev1beta2.Experiment{}
+	previous.Name = "my-exp-v2"
+	previous.Annotations = map[string]string{optimizev1beta2.AnnotationScheduleRun: "2"}
+	assert.Equal(t, previous, LatestRun(exp, []optimizev1beta2.Experiment{*previous}))
+
+	run := &optimizev1beta2.Experiment{}
+	PopulateRun(exp, previous, run, scheduleTime)
+	assert.Equal(t, "my-exp-v3", run.Name)
+	assert.Equal(t, "default", run.Namespace)
+	assert.Equal(t, map[string]string{"app": "test", optimizev1beta2.LabelScheduledExperiment: "my-exp"}, run.Labels)
+	assert.Equal(t, map[string]string{
+		"custom":                              "value",
+		optimizev1beta2.AnnotationScheduleRun: "3",
+		optimizev1beta2.AnnotationPreviousRun: "my-exp-v2",
+	}, run.Annotations)
+	assert.Empty(t, run.Spec.Schedule)
+	assert.Nil(t, run.Spec.Selector)
+	assert.Empty(t, run.Spec.TrialTemplate.Labels)
+	assert.Equal(t, int32(2), run.Replicas())
+	if assert.NotNil(t, run.Spec.Deadline) {
+		assert.Equal(t, scheduleTime.Add(24*time.Hour), run.Spec.Deadline.Time)
+	}
+}
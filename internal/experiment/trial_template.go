@@ -17,7 +17,10 @@ limitations under the License.
 package experiment
 
 import (
+	"time"
+
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -72,6 +75,39 @@ func PopulateTrialFromTemplate(exp *optimizev1beta2.Experiment, t *optimizev1bet
 	}
 }
 
+// SyntheticTrial returns a trial that can be used to render the templates of an experiment before any trials have
+// run. Parameters are assigned their baseline (or minimum) values and the trial is considered to have completed at
+// the supplied time.
+func SyntheticTrial(exp *optimizev1beta2.Experiment, completionTime time.Time) *optimizev1beta2.Trial {
+	t := &optimizev1beta2.Trial{}
+	PopulateTrialFromTemplate(exp, t)
+	if t.Name == "" {
+		t.Name = exp.Name + "-000"
+	}
+	if t.Namespace == "" {
+		t.Namespace = exp.Namespace
+	}
+
+	for _, p := range exp.Spec.Parameters {
+		if ParameterConstant(p) != nil {
+			continue
+		}
+
+		v := p.Baseline
+		if v == nil && len(p.Values) > 0 {
+			v = &intstr.IntOrString{Type: intstr.String, StrVal: p.Values[0]}
+		} else if v == nil {
+			v = &intstr.IntOrString{Type: intstr.Int, IntVal: p.Min}
+		}
+		t.Spec.Assignments = append(t.Spec.Assignments, optimizev1beta2.Assignment{Name: p.Name, Value: *v})
+	}
+
+	startTime := metav1.NewTime(completionTime.Add(-trial.ExpectedDuration(t)))
+	t.Status.StartTime = &startTime
+	t.Status.CompletionTime = &metav1.Time{Time: completionTime}
+	return t
+}
+
 // ParameterConstant returns the value of the supplied parameter if it is constant.
 // A constant parameter has a domain which includes exactly 1 value (i.e. min==max
 // for numeric, or len(values)==1 for categorical); these parameters are not processed
@@ -25,19 +25,11 @@ import (
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/zorkian/go-datadog-api"
+	corev1 "k8s.io/api/core/v1"
 )
 
-func captureDatadogMetric(m *optimizev1beta2.Metric, startTime, completionTime time.Time) (float64, float64, error) {
-	apiKey := os.Getenv("DATADOG_API_KEY")
-	if apiKey == "" {
-		apiKey = os.Getenv("DD_API_KEY")
-	}
-
-	applicationKey := os.Getenv("DATADOG_APP_KEY")
-	if applicationKey == "" {
-		applicationKey = os.Getenv("DD_APP_KEY")
-	}
-
+func captureDatadogMetric(m *optimizev1beta2.Metric, credentials *corev1.Secret, startTime, completionTime time.Time) (float64, float64, error) {
+	apiKey, applicationKey := datadogKeys(credentials)
 	client := datadog.NewClient(apiKey, applicationKey)
 
 	metrics, err := client.QueryMetrics(startTime.Unix(), completionTime.Unix(), m.Query)
@@ -53,7 +45,10 @@ func captureDatadogMetric(m *optimizev1beta2.Metric, startTime, completionTime t
 	if err != nil {
 		return 0, 0, err
 	}
-	aggregator := u.Query().Get("aggregator")
+	aggregator := m.Aggregation
+	if aggregator == "" {
+		aggregator = u.Query().Get("aggregator")
+	}
 
 	var value, n float64
 	for _, p := range metrics[0].Points {
@@ -84,3 +79,27 @@ func captureDatadogMetric(m *optimizev1beta2.Metric, startTime, completionTime t
 
 	return value, math.NaN(), nil
 }
+
+// datadogKeys returns the API and application keys from the credentials secret (if present) or the environment.
+func datadogKeys(credentials *corev1.Secret) (apiKey string, applicationKey string) {
+	if credentials != nil {
+		apiKey = string(credentials.Data["api-key"])
+		applicationKey = string(credentials.Data["app-key"])
+	}
+
+	if apiKey == "" {
+		apiKey = os.Getenv("DATADOG_API_KEY")
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv("DD_API_KEY")
+	}
+
+	if applicationKey == "" {
+		applicationKey = os.Getenv("DATADOG_APP_KEY")
+	}
+	if applicationKey == "" {
+		applicationKey = os.Getenv("DD_APP_KEY")
+	}
+
+	return apiKey, applicationKey
+}
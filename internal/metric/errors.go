@@ -0,0 +1,75 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+)
+
+const (
+	// ReasonUnreachable is the trial failure reason used when the metric backend could not be reached
+	ReasonUnreachable = "MetricUnreachable"
+	// ReasonNoData is the trial failure reason used when the metric query did not return any data
+	ReasonNoData = "MetricNoData"
+	// ReasonImplausible is the trial failure reason used when the metric value was outside the plausible range
+	ReasonImplausible = "MetricImplausible"
+	// ReasonFailed is the trial failure reason used for all other metric collection errors
+	ReasonFailed = "MetricFailed"
+)
+
+// FailureReason classifies a metric collection error, returning the reason to use if the error fails the trial
+// along with an indication of whether the error is transient and the collection should be retried after a delay.
+func FailureReason(err error) (reason string, transient bool) {
+	var captureErr *CaptureError
+	if errors.As(err, &captureErr) {
+		switch {
+		case captureErr.Unreachable:
+			return ReasonUnreachable, true
+		case captureErr.NoData:
+			return ReasonNoData, true
+		case captureErr.Implausible:
+			return ReasonImplausible, true
+		}
+	}
+
+	// Template functions report missing data while the queries are rendered
+	if errors.Is(err, template.ErrNoData) {
+		return ReasonNoData, true
+	}
+
+	var promErr *promv1.Error
+	if errors.As(err, &promErr) {
+		switch promErr.Type {
+		case promv1.ErrServer, promv1.ErrTimeout, promv1.ErrCanceled:
+			return ReasonUnreachable, true
+		}
+		return ReasonFailed, false
+	}
+
+	// This includes URL errors returned by the HTTP client
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return ReasonUnreachable, true
+	}
+
+	return ReasonFailed, false
+}
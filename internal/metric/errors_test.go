@@ -0,0 +1,97 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+)
+
+func TestFailureReason(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		err       error
+		reason    string
+		transient bool
+	}{
+		{
+			desc:   "unclassified",
+			err:    fmt.Errorf("invalid query"),
+			reason: ReasonFailed,
+		},
+		{
+			desc:      "no data",
+			err:       &CaptureError{Message: "metric data not available", NoData: true},
+			reason:    ReasonNoData,
+			transient: true,
+		},
+		{
+			desc:      "template no data",
+			err:       fmt.Errorf("template: metric:1:3: executing \"metric\" at <startupTime>: error calling startupTime: %w", template.ErrNoData),
+			reason:    ReasonNoData,
+			transient: true,
+		},
+		{
+			desc:      "unreachable",
+			err:       &CaptureError{Message: "metric source returned 503 Service Unavailable", Unreachable: true},
+			reason:    ReasonUnreachable,
+			transient: true,
+		},
+		{
+			desc:      "implausible",
+			err:       &CaptureError{Message: "metric value 0.000000 for latency is below the plausible minimum of 1m", Implausible: true},
+			reason:    ReasonImplausible,
+			transient: true,
+		},
+		{
+			desc:      "prometheus server error",
+			err:       &promv1.Error{Type: promv1.ErrServer, Msg: "server error: 502"},
+			reason:    ReasonUnreachable,
+			transient: true,
+		},
+		{
+			desc:   "prometheus bad data",
+			err:    &promv1.Error{Type: promv1.ErrBadData, Msg: "parse error"},
+			reason: ReasonFailed,
+		},
+		{
+			desc:      "connection refused",
+			err:       &url.Error{Op: "Get", URL: "http://prometheus:9090", Err: fmt.Errorf("connection refused")},
+			reason:    ReasonUnreachable,
+			transient: true,
+		},
+		{
+			desc:      "deadline exceeded",
+			err:       fmt.Errorf("query failed: %w", context.DeadlineExceeded),
+			reason:    ReasonUnreachable,
+			transient: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			reason, transient := FailureReason(tc.err)
+			assert.Equal(t, tc.reason, reason)
+			assert.Equal(t, tc.transient, transient)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func captureInfluxDBMetric(ctx context.Context, m *optimizev1beta2.Metric, credentials *corev1.Secret, startTime, completionTime time.Time) (float64, float64, error) {
+	token, err := bearerToken(m, credentials)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	value, err := queryFlux(ctx, m.URL, token, m.Query, startTime, completionTime)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	valueError := math.NaN()
+	if m.ErrorQuery != "" {
+		valueError, err = queryFlux(ctx, m.URL, token, m.ErrorQuery, startTime, completionTime)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return value, valueError, nil
+}
+
+// fluxQuery is the request body of the InfluxDB v2 query API.
+type fluxQuery struct {
+	Query   string      `json:"query"`
+	Type    string      `json:"type"`
+	Dialect fluxDialect `json:"dialect"`
+}
+
+type fluxDialect struct {
+	Header      bool     `json:"header"`
+	Annotations []string `json:"annotations"`
+}
+
+// queryFlux evaluates a Flux query which must produce a single `_value`. The trial run times are made available
+// to the query as `v.timeRangeStart` and `v.timeRangeStop`, the same as the InfluxDB UI.
+func queryFlux(ctx context.Context, address, token, query string, startTime, completionTime time.Time) (float64, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return 0, err
+	}
+	if !strings.HasSuffix(u.Path, "/api/v2/query") {
+		u.Path = path.Join("/", u.Path, "api/v2/query")
+	}
+
+	// Inject the range bounds as an option so the query can use them in a `range` call
+	query = fmt.Sprintf("option v = {timeRangeStart: %s, timeRangeStop: %s}\n%s",
+		startTime.UTC().Format(time.RFC3339), completionTime.UTC().Format(time.RFC3339), query)
+
+	body, err := json.Marshal(&fluxQuery{
+		Query:   query,
+		Type:    "flux",
+		Dialect: fluxDialect{Header: true, Annotations: []string{}},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/csv")
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return 0, &CaptureError{Message: fmt.Sprintf("InfluxDB query failed (%s): %s", resp.Status, strings.TrimSpace(string(msg))), Address: address, Query: query, Unreachable: unavailable(resp.StatusCode)}
+	}
+
+	values, err := fluxValues(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(values) {
+	case 0:
+		return 0, &CaptureError{Message: "metric data not available", Address: address, Query: query, NoData: true}
+	case 1:
+		return strconv.ParseFloat(values[0], 64)
+	default:
+		return 0, fmt.Errorf("expected one result, got %d", len(values))
+	}
+}
+
+// fluxValues returns the `_value` column from each table of a Flux CSV response.
+func fluxValues(r io.Reader) ([]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	var values []string
+	col := -1
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Each table in the response starts with its own header row
+		if i := indexOf(record, "_value"); i >= 0 {
+			col = i
+			continue
+		}
+		if col < 0 || col >= len(record) {
+			continue
+		}
+
+		values = append(values, record[col])
+	}
+}
+
+func indexOf(record []string, s string) int {
+	for i := range record {
+		if record[i] == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFluxValues(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		response string
+		expected []string
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc:     "single table",
+			response: ",result,table,_start,_stop,_value\r\n,_result,0,2021-01-01T00:00:00Z,2021-01-01T00:05:00Z,42.5\r\n",
+			expected: []string{"42.5"},
+		},
+		{
+			desc: "multiple tables",
+			response: ",result,table,_value,host\r\n,_result,0,1,a\r\n\r\n" +
+				",result,table,host,_value\r\n,_result,1,b,2\r\n",
+			expected: []string{"1", "2"},
+		},
+		{
+			desc:     "no value column",
+			response: ",result,table,_start\r\n,_result,0,2021-01-01T00:00:00Z\r\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			values, err := fluxValues(strings.NewReader(tc.response))
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, values)
+			}
+		})
+	}
+}
+
+func TestQueryFlux(t *testing.T) {
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	completionTime := startTime.Add(5 * time.Minute)
+
+	testCases := []struct {
+		desc          string
+		response      string
+		status        int
+		expected      float64
+		expectedError string
+	}{
+		{
+			desc:     "value",
+			response: ",result,table,_value\r\n,_result,0,42.5\r\n",
+			expected: 42.5,
+		},
+		{
+			desc:          "no data",
+			response:      ",result,table,_value\r\n",
+			expectedError: "metric data not available",
+		},
+		{
+			desc:          "too many values",
+			response:      ",result,table,_value\r\n,_result,0,1\r\n,_result,1,2\r\n",
+			expectedError: "expected one result, got 2",
+		},
+		{
+			desc:          "unauthorized",
+			response:      `{"code":"unauthorized","message":"unauthorized access"}`,
+			status:        http.StatusUnauthorized,
+			expectedError: `InfluxDB query failed (401 Unauthorized): {"code":"unauthorized","message":"unauthorized access"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v2/query", r.URL.Path)
+				assert.Equal(t, "example", r.URL.Query().Get("org"))
+				assert.Equal(t, "Token secret", r.Header.Get("Authorization"))
+
+				q := fluxQuery{}
+				if assert.NoError(t, json.NewDecoder(r.Body).Decode(&q)) {
+					assert.Equal(t, "flux", q.Type)
+					assert.True(t, strings.HasPrefix(q.Query, "option v = {timeRangeStart: 2021-01-01T00:00:00Z, timeRangeStop: 2021-01-01T00:05:00Z}\n"))
+				}
+
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+				}
+				_, _ = fmt.Fprint(w, tc.response)
+			}))
+			defer srv.Close()
+
+			value, err := queryFlux(context.TODO(), srv.URL+"?org=example", "secret", `from(bucket: "test")`, startTime, completionTime)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+}
@@ -18,29 +18,50 @@ package metric
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/util/jsonpath"
 )
 
 // TODO We need some type of client util to encapsulate this
 var httpClient = &http.Client{Timeout: 10 * time.Second}
 
-func captureJSONPathMetric(m *optimizev1beta2.Metric) (value float64, valueError float64, err error) {
-	// Fetch the URL
+// maxBackoff is the longest we will wait between retries of a single metric request
+const maxBackoff = 30 * time.Second
+
+// aggregatePattern matches a JSON path expression wrapped in an aggregate function, e.g. `avg({.items[*].value})`
+var aggregatePattern = regexp.MustCompile(`^\s*(min|max|avg|sum)\((.*)\)\s*$`)
+
+func captureJSONPathMetric(ctx context.Context, m *optimizev1beta2.Metric, credentials *corev1.Secret) (value float64, valueError float64, err error) {
+	// Build the request
 	req, err := http.NewRequest(http.MethodGet, m.URL, nil)
 	if err != nil {
 		return 0, 0, err
 	}
 	req.Header.Set("Accept", "application/json")
-	resp, err := httpClient.Do(req.WithContext(context.TODO()))
+	for k, v := range m.Headers {
+		req.Header.Set(k, v)
+	}
+
+	c, err := jsonPathClient(m, credentials, req)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Fetch the URL
+	resp, err := doWithRetry(ctx, c, req, m.Retry)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -49,7 +70,9 @@ func captureJSONPathMetric(m *optimizev1beta2.Metric) (value float64, valueError
 	}()
 
 	// Check the response status
-	if resp.StatusCode != http.StatusOK {
+	if unavailable(resp.StatusCode) {
+		return 0, 0, &CaptureError{Message: fmt.Sprintf("metric source returned %s", resp.Status), Address: m.URL, Unreachable: true}
+	} else if resp.StatusCode != http.StatusOK {
 		// TODO Should we not ignore this?
 		return 0, math.NaN(), nil
 	}
@@ -60,33 +83,176 @@ func captureJSONPathMetric(m *optimizev1beta2.Metric) (value float64, valueError
 		return 0, 0, err
 	}
 
-	// Evaluate the JSON path
-	jp := jsonpath.New(m.Name)
-	if err := jp.Parse(m.Query); err != nil {
+	value, ok, err := jsonPathValue(m.Name, m.Query, data)
+	if err != nil {
 		return 0, 0, err
 	}
-	values, err := jp.FindResults(data)
+	if ok {
+		return value, math.NaN(), nil
+	}
+
+	// If we made it this far we weren't able to extract the value
+	return 0, 0, fmt.Errorf("query '%s' did not match", m.Query)
+}
+
+// jsonPathClient returns the HTTP client to use for the supplied metric, the request is updated to include any
+// configured authorization.
+func jsonPathClient(m *optimizev1beta2.Metric, credentials *corev1.Secret, req *http.Request) (*http.Client, error) {
+	token, err := bearerToken(m, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	// The credentials secret may contain a token, basic authentication credentials and a CA bundle
+	var caData []byte
+	if secret := credentials; secret != nil && m.CredentialsSecretRef != nil {
+		if t, ok := secret.Data["token"]; ok && token == "" {
+			token = strings.TrimSpace(string(t))
+		}
+		if username, ok := secret.Data["username"]; ok {
+			req.SetBasicAuth(string(username), string(secret.Data["password"]))
+		}
+		caData = secret.Data["ca.crt"]
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if len(caData) == 0 {
+		return httpClient, nil
+	}
+
+	pool, err := x509.SystemCertPool()
 	if err != nil {
-		return 0, 0, err
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(caData) {
+		return nil, fmt.Errorf("unable to load CA bundle from secret %q", m.CredentialsSecretRef.Name)
 	}
 
-	// Convert the result to a float
-	if len(values) == 1 && len(values[0]) == 1 {
-		v := reflect.ValueOf(values[0][0].Interface())
-		switch v.Kind() {
-		case reflect.Float64:
-			return v.Float(), math.NaN(), nil
-		case reflect.String:
-			v, err := strconv.ParseFloat(v.String(), 64)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &http.Client{Timeout: httpClient.Timeout, Transport: transport}, nil
+}
+
+// doWithRetry performs the request, retrying connection failures and server errors according to the retry policy.
+func doWithRetry(ctx context.Context, c *http.Client, req *http.Request, retry *optimizev1beta2.MetricRetry) (*http.Response, error) {
+	attempts, backoff := 1, time.Second
+	if retry != nil {
+		if retry.Attempts > 1 {
+			attempts = int(retry.Attempts)
+		}
+		if retry.BackoffSeconds > 0 {
+			backoff = time.Duration(retry.BackoffSeconds) * time.Second
+		}
+	}
+
+	for i := 1; ; i++ {
+		resp, err := c.Do(req.WithContext(ctx))
+		if i >= attempts || !retryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// retryable checks to see if a request should be retried.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return unavailable(resp.StatusCode)
+}
+
+// unavailable checks to see if an HTTP status code indicates the metric source is temporarily unavailable.
+func unavailable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// jsonPathValue evaluates a JSON path expression, optionally wrapped in an aggregate function, against generic
+// JSON data. Missing keys are not an error, however the result is only ok if the expression produces a single value
+// or the matches were aggregated.
+func jsonPathValue(name, query string, data interface{}) (float64, bool, error) {
+	// Separate the aggregate function (if any) from the JSON path
+	aggregate := ""
+	if match := aggregatePattern.FindStringSubmatch(query); match != nil {
+		aggregate, query = match[1], strings.TrimSpace(match[2])
+	}
+
+	// Evaluate the JSON path
+	jp := jsonpath.New(name).AllowMissingKeys(true)
+	if err := jp.Parse(query); err != nil {
+		return 0, false, err
+	}
+	results, err := jp.FindResults(data)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Convert the matches to floats
+	var values []float64
+	for i := range results {
+		for j := range results[i] {
+			v, err := toFloat(results[i][j])
 			if err != nil {
-				return 0, 0, err
+				return 0, false, err
 			}
-			return v, math.NaN(), nil
-		default:
-			return 0, 0, fmt.Errorf("could not convert match to a floating point number")
+			values = append(values, v)
 		}
 	}
 
-	// If we made it this far we weren't able to extract the value
-	return 0, 0, fmt.Errorf("query '%s' did not match", m.Query)
+	if aggregate != "" && len(values) > 0 {
+		return aggregateValues(aggregate, values), true, nil
+	}
+	if len(values) == 1 {
+		return values[0], true, nil
+	}
+	return 0, false, nil
+}
+
+// toFloat converts a JSON path match to a floating point number.
+func toFloat(match reflect.Value) (float64, error) {
+	v := reflect.ValueOf(match.Interface())
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.String:
+		return strconv.ParseFloat(v.String(), 64)
+	default:
+		return 0, fmt.Errorf("could not convert match to a floating point number")
+	}
+}
+
+// aggregateValues reduces a non-empty list of values using the named aggregate function.
+func aggregateValues(aggregate string, values []float64) float64 {
+	result := values[0]
+	for _, v := range values[1:] {
+		switch aggregate {
+		case "min":
+			result = math.Min(result, v)
+		case "max":
+			result = math.Max(result, v)
+		case "avg", "sum":
+			result += v
+		}
+	}
+	if aggregate == "avg" {
+		result /= float64(len(values))
+	}
+	return result
 }
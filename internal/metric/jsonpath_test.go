@@ -0,0 +1,223 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCaptureJSONPathMetric(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		query    string
+		response string
+		expected float64
+		err      string
+	}{
+		{
+			desc:     "single value",
+			query:    "{.p95}",
+			response: `{"p95": 5}`,
+			expected: 5,
+		},
+		{
+			desc:     "string value",
+			query:    "{.p95}",
+			response: `{"p95": "5.5"}`,
+			expected: 5.5,
+		},
+		{
+			desc:     "multiple values",
+			query:    "{.items[*].latency}",
+			response: `{"items": [{"latency": 1}, {"latency": 2}]}`,
+			err:      "query '{.items[*].latency}' did not match",
+		},
+		{
+			desc:     "min",
+			query:    "min({.items[*].latency})",
+			response: `{"items": [{"latency": 3}, {"latency": 1}, {"latency": 2}]}`,
+			expected: 1,
+		},
+		{
+			desc:     "max",
+			query:    "max({.items[*].latency})",
+			response: `{"items": [{"latency": 3}, {"latency": 1}, {"latency": 2}]}`,
+			expected: 3,
+		},
+		{
+			desc:     "avg",
+			query:    "avg({.items[*].latency})",
+			response: `{"items": [{"latency": 3}, {"latency": 1}, {"latency": 2}]}`,
+			expected: 2,
+		},
+		{
+			desc:     "sum",
+			query:    " sum( {.items[*].latency} ) ",
+			response: `{"items": [{"latency": 3}, {"latency": 1}, {"latency": 2}]}`,
+			expected: 6,
+		},
+		{
+			desc:     "aggregate no match",
+			query:    "avg({.items[*].latency})",
+			response: `{"items": []}`,
+			err:      "query 'avg({.items[*].latency})' did not match",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, tc.response)
+			}))
+			defer srv.Close()
+
+			value, _, err := captureJSONPathMetric(context.TODO(), &optimizev1beta2.Metric{Name: "test", Query: tc.query, URL: srv.URL}, nil)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, value)
+			}
+		})
+	}
+}
+
+func TestCaptureJSONPathMetricRequest(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		metric      optimizev1beta2.Metric
+		credentials *corev1.Secret
+		failures    int
+		check       func(t *testing.T, r *http.Request)
+		expected    float64
+		unreachable bool
+	}{
+		{
+			desc: "headers",
+			metric: optimizev1beta2.Metric{
+				Headers: map[string]string{"X-Tenant": "test"},
+			},
+			check: func(t *testing.T, r *http.Request) {
+				assert.Equal(t, "test", r.Header.Get("X-Tenant"))
+			},
+			expected: 1,
+		},
+		{
+			desc: "bearer token",
+			metric: optimizev1beta2.Metric{
+				BearerTokenSecretRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "metrics"},
+					Key:                  "token",
+				},
+			},
+			credentials: &corev1.Secret{Data: map[string][]byte{"token": []byte("secret\n")}},
+			check: func(t *testing.T, r *http.Request) {
+				assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			},
+			expected: 1,
+		},
+		{
+			desc: "basic auth",
+			metric: optimizev1beta2.Metric{
+				CredentialsSecretRef: &corev1.LocalObjectReference{Name: "metrics"},
+			},
+			credentials: &corev1.Secret{Data: map[string][]byte{"username": []byte("user"), "password": []byte("pass")}},
+			check: func(t *testing.T, r *http.Request) {
+				username, password, ok := r.BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, "user", username)
+				assert.Equal(t, "pass", password)
+			},
+			expected: 1,
+		},
+		{
+			desc: "retry",
+			metric: optimizev1beta2.Metric{
+				Retry: &optimizev1beta2.MetricRetry{Attempts: 2},
+			},
+			failures: 1,
+			expected: 1,
+		},
+		{
+			desc:        "no retry",
+			failures:    1,
+			unreachable: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			failures := tc.failures
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.check != nil {
+					tc.check(t, r)
+				}
+				if failures > 0 {
+					failures--
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = fmt.Fprint(w, `{"value": 1}`)
+			}))
+			defer srv.Close()
+
+			tc.metric.Name = "test"
+			tc.metric.Query = "{.value}"
+			tc.metric.URL = srv.URL
+			value, _, err := captureJSONPathMetric(context.TODO(), &tc.metric, tc.credentials)
+			if tc.unreachable {
+				reason, transient := FailureReason(err)
+				assert.Equal(t, ReasonUnreachable, reason)
+				assert.True(t, transient)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, value)
+			}
+		})
+	}
+}
+
+func TestCaptureJSONPathMetricCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"value": 1}`)
+	}))
+	defer srv.Close()
+
+	m := &optimizev1beta2.Metric{
+		Name:                 "test",
+		Query:                "{.value}",
+		URL:                  srv.URL,
+		CredentialsSecretRef: &corev1.LocalObjectReference{Name: "metrics"},
+	}
+
+	// Without the CA bundle the server certificate is not trusted
+	_, _, err := captureJSONPathMetric(context.TODO(), m, &corev1.Secret{})
+	assert.Error(t, err)
+
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	value, valueError, err := captureJSONPathMetric(context.TODO(), m, &corev1.Secret{Data: map[string][]byte{"ca.crt": caData}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1.0, value)
+		assert.True(t, math.IsNaN(valueError))
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"fmt"
+	"math"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func captureKubernetesObjectMetric(m *optimizev1beta2.Metric, target runtime.Object) (value float64, valueError float64, err error) {
+	if target == nil {
+		return 0, 0, fmt.Errorf("missing target for metric %s", m.Name)
+	}
+
+	// Evaluate the queries against the generic representation of the object
+	var data map[string]interface{}
+	if u, ok := target.(runtime.Unstructured); ok {
+		data = u.UnstructuredContent()
+	} else if data, err = runtime.DefaultUnstructuredConverter.ToUnstructured(target); err != nil {
+		return 0, 0, err
+	}
+
+	value, ok, err := jsonPathValue(m.Name, m.Query, data)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !ok {
+		// Status fields are frequently omitted until the object has been reconciled
+		return 0, 0, &CaptureError{Message: fmt.Sprintf("query '%s' did not match", m.Query), Query: m.Query, NoData: true}
+	}
+
+	valueError = math.NaN()
+	if m.ErrorQuery != "" {
+		if valueError, ok, err = jsonPathValue(m.Name, m.ErrorQuery, data); err != nil {
+			return 0, 0, err
+		} else if !ok {
+			return 0, 0, &CaptureError{Message: fmt.Sprintf("error query '%s' did not match", m.ErrorQuery), Query: m.ErrorQuery, NoData: true}
+		}
+	}
+
+	return value, valueError, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestCaptureKubernetesObjectMetric(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		query       string
+		errorQuery  string
+		target      runtime.Object
+		expected    float64
+		expectedErr float64
+		noData      bool
+	}{
+		{
+			desc:     "typed object",
+			query:    "{.status.readyReplicas}",
+			target:   &appsv1.Deployment{Status: appsv1.DeploymentStatus{ReadyReplicas: 3}},
+			expected: 3,
+		},
+		{
+			desc:  "unstructured object",
+			query: "{.status.currentReplicas}",
+			target: &unstructured.Unstructured{Object: map[string]interface{}{
+				"kind":   "HorizontalPodAutoscaler",
+				"status": map[string]interface{}{"currentReplicas": int64(4)},
+			}},
+			expected: 4,
+		},
+		{
+			desc:  "list aggregate",
+			query: "sum({.items[*].status.readyReplicas})",
+			target: &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+				{Object: map[string]interface{}{"status": map[string]interface{}{"readyReplicas": int64(1)}}},
+				{Object: map[string]interface{}{"status": map[string]interface{}{"readyReplicas": int64(2)}}},
+			}},
+			expected: 3,
+		},
+		{
+			desc:        "error query",
+			query:       "{.status.replicas}",
+			errorQuery:  "{.status.unavailableReplicas}",
+			target:      &appsv1.Deployment{Status: appsv1.DeploymentStatus{Replicas: 3, UnavailableReplicas: 1}},
+			expected:    3,
+			expectedErr: 1,
+		},
+		{
+			desc:   "missing field",
+			query:  "{.status.readyReplicas}",
+			target: &appsv1.Deployment{},
+			noData: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			m := &optimizev1beta2.Metric{Name: "test", Type: optimizev1beta2.MetricKubernetesObject, Query: tc.query, ErrorQuery: tc.errorQuery}
+			value, valueError, err := captureKubernetesObjectMetric(m, tc.target)
+			if tc.noData {
+				reason, _ := FailureReason(err)
+				assert.Equal(t, ReasonNoData, reason)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, value)
+				if tc.errorQuery == "" {
+					assert.True(t, math.IsNaN(valueError))
+				} else {
+					assert.Equal(t, tc.expectedErr, valueError)
+				}
+			}
+		})
+	}
+}
@@ -24,31 +24,61 @@ import (
 
 	"github.com/go-logr/logr"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/pricing"
 	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
-// CaptureMetric captures a point-in-time metric value and it's error rate.
-func CaptureMetric(ctx context.Context, log logr.Logger, trial *optimizev1beta2.Trial, metric *optimizev1beta2.Metric, target runtime.Object) (float64, float64, error) {
+// CaptureMetric captures a point-in-time metric value and it's error rate. The target is the Kubernetes object the
+// metric is evaluated against, the credentials are used to authenticate with remote metric sources; either may be nil.
+func CaptureMetric(ctx context.Context, log logr.Logger, trial *optimizev1beta2.Trial, metric *optimizev1beta2.Metric, target runtime.Object, credentials *corev1.Secret) (float64, float64, error) {
+	// Only observe the configured window of the trial run
+	trial = observedTrial(metric, trial)
+
 	// Execute the queries as Go templates
 	var err error
-	if metric.Query, metric.ErrorQuery, err = template.New().RenderMetricQueries(metric, trial, target); err != nil {
+	eng := template.New()
+	eng.Pricing = pricing.FromContext(ctx)
+	if metric.Query, metric.ErrorQuery, err = eng.RenderMetricQueries(metric, trial, target); err != nil {
 		return 0, 0, err
 	}
 
+	// Only some metric types can aggregate values
+	switch metric.Type {
+	case optimizev1beta2.MetricPrometheus, optimizev1beta2.MetricPrometheusHistogram, optimizev1beta2.MetricDatadog:
+	default:
+		if metric.Aggregation != "" {
+			return 0, 0, fmt.Errorf("aggregation is not supported for %s metrics", metric.Type)
+		}
+	}
+
 	// Capture the value based on the metric type
 	switch metric.Type {
 	case optimizev1beta2.MetricKubernetes, "":
 		value, err := strconv.ParseFloat(metric.Query, 64)
 		return value, math.NaN(), err
+	case optimizev1beta2.MetricKubernetesObject:
+		return captureKubernetesObjectMetric(metric, target)
 	case optimizev1beta2.MetricPrometheus:
-		return capturePrometheusMetric(ctx, log, metric, trial.Status.CompletionTime.Time)
+		return capturePrometheusMetric(ctx, log, metric, credentials, trial.Status.StartTime.Time, trial.Status.CompletionTime.Time)
+	case optimizev1beta2.MetricPrometheusHistogram:
+		if metric.Query, err = histogramQuantileQuery(metric, trial.Status.StartTime.Time, trial.Status.CompletionTime.Time); err != nil {
+			return 0, 0, err
+		}
+		return capturePrometheusMetric(ctx, log, metric, credentials, trial.Status.StartTime.Time, trial.Status.CompletionTime.Time)
 	case optimizev1beta2.MetricDatadog:
-		return captureDatadogMetric(metric, trial.Status.StartTime.Time, trial.Status.CompletionTime.Time)
+		return captureDatadogMetric(metric, credentials, trial.Status.StartTime.Time, trial.Status.CompletionTime.Time)
 	case optimizev1beta2.MetricJSONPath:
-		return captureJSONPathMetric(metric)
+		return captureJSONPathMetric(ctx, metric, credentials)
 	case optimizev1beta2.MetricNewRelic:
 		return captureNewRelicMetric(metric, trial.Status.StartTime.Time, trial.Status.CompletionTime.Time)
+	case optimizev1beta2.MetricInfluxDB:
+		return captureInfluxDBMetric(ctx, metric, credentials, trial.Status.StartTime.Time, trial.Status.CompletionTime.Time)
+	case optimizev1beta2.MetricPush:
+		return capturePushMetric(trial, metric)
+	case optimizev1beta2.MetricUsage:
+		return captureUsageMetric(trial, metric)
 	default:
 		return 0, 0, fmt.Errorf("unknown metric type: %s", metric.Type)
 	}
@@ -129,7 +129,7 @@ func TestCaptureMetric(t *testing.T) {
 				},
 			}
 
-			duration, _, err := CaptureMetric(context.TODO(), log, trial, tc.metric, tc.obj)
+			duration, _, err := CaptureMetric(context.TODO(), log, trial, tc.metric, tc.obj, nil)
 			assert.NoError(t, err)
 			assert.Equal(t, tc.expected, duration)
 		})
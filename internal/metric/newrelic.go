@@ -80,10 +80,7 @@ func captureNewRelicMetric(m *optimizev1beta2.Metric, startTime, completionTime
 
 	switch len(resp.Actor.Account.NRQL.Results) {
 	case 0:
-		// TODO: see if we can inject a delay/retry here
-		// We cant use CaptureError as is today because it causes retry counter to not decrement
-		// return 0, 0, &CaptureError{Message: "metric data not available", Query: nrql, RetryAfter: 30 * time.Second}
-		return 0, 0, fmt.Errorf("query returned no results: %s", nrql)
+		return 0, 0, &CaptureError{Message: "query returned no results", Query: nrql, NoData: true}
 	case 1:
 		// Successful case
 	default:
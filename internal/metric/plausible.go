@@ -0,0 +1,56 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"fmt"
+	"math"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// CheckPlausible ensures a value collected for the metric (in the units of the metric) falls within the plausible
+// range of the metric. Implausible values are reported as a capture error so they can be recollected.
+func CheckPlausible(m *optimizev1beta2.Metric, value float64) error {
+	if m.Plausible == nil {
+		return nil
+	}
+
+	var bound string
+	switch {
+	case math.IsNaN(value):
+		bound = "outside the plausible range"
+	case m.Plausible.Min != nil && value < quantityValue(m.Plausible.Min):
+		bound = fmt.Sprintf("below the plausible minimum of %s", m.Plausible.Min.String())
+	case m.Plausible.Max != nil && value > quantityValue(m.Plausible.Max):
+		bound = fmt.Sprintf("above the plausible maximum of %s", m.Plausible.Max.String())
+	default:
+		return nil
+	}
+
+	return &CaptureError{
+		Message:     fmt.Sprintf("metric value %f for %s is %s", value, m.Name, bound),
+		Query:       m.Query,
+		Implausible: true,
+	}
+}
+
+// quantityValue returns the floating point value of a quantity.
+func quantityValue(q *resource.Quantity) float64 {
+	return float64(q.ScaledValue(resource.Nano)) / 1000000000
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestCheckPlausible(t *testing.T) {
+	min := resource.MustParse("1m")
+	max := resource.MustParse("10")
+
+	testCases := []struct {
+		desc      string
+		plausible *optimizev1beta2.MetricRange
+		value     float64
+		err       string
+	}{
+		{
+			desc:  "no range",
+			value: 0,
+		},
+		{
+			desc:      "within range",
+			plausible: &optimizev1beta2.MetricRange{Min: &min, Max: &max},
+			value:     0.5,
+		},
+		{
+			desc:      "inclusive",
+			plausible: &optimizev1beta2.MetricRange{Min: &min, Max: &max},
+			value:     10,
+		},
+		{
+			desc:      "below minimum",
+			plausible: &optimizev1beta2.MetricRange{Min: &min},
+			value:     0,
+			err:       "metric value 0.000000 for test is below the plausible minimum of 1m",
+		},
+		{
+			desc:      "above maximum",
+			plausible: &optimizev1beta2.MetricRange{Max: &max},
+			value:     11,
+			err:       "metric value 11.000000 for test is above the plausible maximum of 10",
+		},
+		{
+			desc:      "not a number",
+			plausible: &optimizev1beta2.MetricRange{Min: &min},
+			value:     math.NaN(),
+			err:       "metric value NaN for test is outside the plausible range",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := CheckPlausible(&optimizev1beta2.Metric{Name: "test", Plausible: tc.plausible}, tc.value)
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else if assert.EqualError(t, err, tc.err) {
+				assert.True(t, err.(*CaptureError).Implausible)
+			}
+		})
+	}
+}
@@ -20,6 +20,10 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -27,6 +31,7 @@ import (
 	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // CaptureError describes problems that arise while capturing Prometheus metric values.
@@ -39,15 +44,28 @@ type CaptureError struct {
 	Query string
 	// The minimum amount of time until the metric is expected to be available
 	RetryAfter time.Duration
+	// True if the metric backend could not be reached or failed to process the request
+	Unreachable bool
+	// True if the metric query succeeded but did not produce any data
+	NoData bool
+	// True if the metric value was outside the plausible range of the metric
+	Implausible bool
 }
 
 func (e *CaptureError) Error() string {
 	return e.Message
 }
 
-func capturePrometheusMetric(ctx context.Context, log logr.Logger, m *optimizev1beta2.Metric, completionTime time.Time) (value float64, valueError float64, err error) {
+func capturePrometheusMetric(ctx context.Context, log logr.Logger, m *optimizev1beta2.Metric, credentials *corev1.Secret, startTime, completionTime time.Time) (value float64, valueError float64, err error) {
 	// Get the Prometheus API
-	c, err := prom.NewClient(prom.Config{Address: m.URL})
+	cfg := prom.Config{Address: m.URL}
+	if token, err := bearerToken(m, credentials); err != nil {
+		return 0, 0, err
+	} else if token != "" {
+		cfg.RoundTripper = &bearerAuthRoundTripper{token: token, rt: prom.DefaultRoundTripper}
+	}
+
+	c, err := prom.NewClient(cfg)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -59,14 +77,19 @@ func capturePrometheusMetric(ctx context.Context, log logr.Logger, m *optimizev1
 		return 0, 0, err
 	}
 
-	// Execute the query
-	value, err = queryScalar(ctx, promAPI, m.Query, completionTime)
+	// Execute the query, aggregating over the trial run if necessary
+	aggregate := m.Aggregation != "" && m.Aggregation != "last"
+	if aggregate {
+		value, err = queryAggregate(ctx, promAPI, m.Query, m.Aggregation, startTime, completionTime)
+	} else {
+		value, err = queryScalar(ctx, promAPI, m.Query, completionTime)
+	}
 	if err != nil {
 		return 0, 0, err
 	}
 
 	// If we got NaN, it might be that the final scrape hadn't finished
-	if math.IsNaN(value) && lastScrapeEndTime.After(completionTime) {
+	if !aggregate && math.IsNaN(value) && lastScrapeEndTime.After(completionTime) {
 		log.Info("Retrying Prometheus query to include final scrape", "lastScrapeEndTime", lastScrapeEndTime)
 
 		value, err = queryScalar(ctx, promAPI, m.Query, lastScrapeEndTime)
@@ -79,7 +102,7 @@ func capturePrometheusMetric(ctx context.Context, log logr.Logger, m *optimizev1
 	// not account for gaps in the timeline or it might be because Prometheus
 	// never pulled in any matching metrics (despite our best efforts in checkReady)
 	if math.IsNaN(value) {
-		return 0, 0, &CaptureError{Message: "metric data not available", Address: m.URL, Query: m.Query}
+		return 0, 0, &CaptureError{Message: "metric data not available", Address: m.URL, Query: m.Query, NoData: true}
 	}
 
 	// Execute the error query (if configured)
@@ -93,6 +116,42 @@ func capturePrometheusMetric(ctx context.Context, log logr.Logger, m *optimizev1
 	return value, valueError, nil
 }
 
+// bearerToken extracts the bearer token from the credentials secret resolved for the metric.
+func bearerToken(m *optimizev1beta2.Metric, credentials *corev1.Secret) (string, error) {
+	ref := m.BearerTokenSecretRef
+	if ref == nil {
+		return "", nil
+	}
+
+	secret := credentials
+	if secret == nil {
+		if ref.Optional != nil && *ref.Optional {
+			return "", nil
+		}
+		return "", fmt.Errorf("unable to find bearer token secret %q", ref.Name)
+	}
+
+	token, ok := secret.Data[ref.Key]
+	if !ok && (ref.Optional == nil || !*ref.Optional) {
+		return "", fmt.Errorf("bearer token secret %q is missing key %q", ref.Name, ref.Key)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// bearerAuthRoundTripper sets the authorization header on Prometheus requests.
+type bearerAuthRoundTripper struct {
+	token string
+	rt    http.RoundTripper
+}
+
+func (rt *bearerAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+rt.token)
+	}
+	return rt.rt.RoundTrip(req)
+}
+
 // Choose lower then normal default scrape parameters
 // TODO We could use `api.Config` to get the actual values (global defaults and per-target settings)
 const scrapeInterval = 5 * time.Second // Prometheus default is 1m
@@ -152,3 +211,74 @@ func queryScalar(ctx context.Context, api promv1.API, q string, t time.Time) (fl
 		return 0, fmt.Errorf("expected scalar query result, got %s", v.Type())
 	}
 }
+
+// queryAggregate evaluates the query over a range and combines the results using the named aggregation.
+func queryAggregate(ctx context.Context, api promv1.API, q, aggregation string, startTime, completionTime time.Time) (float64, error) {
+	// Aim for roughly 100 samples, but not more frequently then we expect data to be scraped
+	step := completionTime.Sub(startTime) / 100
+	if step < scrapeInterval {
+		step = scrapeInterval
+	}
+
+	v, _, err := api.QueryRange(ctx, q, promv1.Range{Start: startTime, End: completionTime, Step: step})
+	if err != nil {
+		return 0, err
+	}
+
+	m, ok := v.(model.Matrix)
+	if !ok {
+		return 0, fmt.Errorf("expected matrix query result, got %s", v.Type())
+	}
+	switch len(m) {
+	case 0:
+		return math.NaN(), nil
+	case 1:
+	default:
+		return 0, fmt.Errorf("expected one series, got %d", len(m))
+	}
+
+	values := make([]float64, 0, len(m[0].Values))
+	for _, p := range m[0].Values {
+		if !math.IsNaN(float64(p.Value)) {
+			values = append(values, float64(p.Value))
+		}
+	}
+	if len(values) == 0 {
+		return math.NaN(), nil
+	}
+
+	switch aggregation {
+	case "avg", "max", "min":
+		return aggregateValues(aggregation, values), nil
+	default:
+		return 0, fmt.Errorf("unsupported aggregation: %s (expected: avg, last, max, min)", aggregation)
+	}
+}
+
+// histogramSelectorPattern matches a histogram name with optional label matchers.
+var histogramSelectorPattern = regexp.MustCompile(`^\s*([a-zA-Z_:][a-zA-Z0-9_:]*)\s*(\{.*\})?\s*$`)
+
+// histogramQuantileQuery returns the PromQL used to compute a quantile of a histogram over the trial run.
+func histogramQuantileQuery(m *optimizev1beta2.Metric, startTime, completionTime time.Time) (string, error) {
+	if m.Quantile == nil {
+		return "", fmt.Errorf("histogram metric %q is missing a quantile", m.Name)
+	}
+	q, err := strconv.ParseFloat(m.Quantile.AsDec().String(), 64)
+	if err != nil || q < 0 || q > 1 {
+		return "", fmt.Errorf("histogram metric %q quantile must be between 0 and 1, got %s", m.Name, m.Quantile.String())
+	}
+
+	match := histogramSelectorPattern.FindStringSubmatch(m.Query)
+	if match == nil {
+		return "", fmt.Errorf("histogram metric %q query must be a histogram name with optional labels, got %q", m.Name, m.Query)
+	}
+	name, labels := match[1], match[2]
+	if !strings.HasSuffix(name, "_bucket") {
+		name += "_bucket"
+	}
+
+	// Rates need at least a second worth of data
+	r := math.Max(completionTime.Sub(startTime).Seconds(), 1)
+	return fmt.Sprintf("scalar(histogram_quantile(%s, sum by (le) (rate(%s%s[%.0fs]))))",
+		strconv.FormatFloat(q, 'f', -1, 64), name, labels, r), nil
+}
@@ -12,6 +12,9 @@ import (
 	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func TestPrometheusCheckReady(t *testing.T) {
@@ -79,3 +82,120 @@ func promTargetsHttpTestServer(scrapeTime time.Time) *httptest.Server {
 		fmt.Fprintf(w, respStr, t, t, t)
 	}))
 }
+
+func TestHistogramQuantileQuery(t *testing.T) {
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	completionTime := startTime.Add(5 * time.Minute)
+	q95 := resource.MustParse("0.95")
+	q999 := resource.MustParse("0.999")
+	q2 := resource.MustParse("2")
+
+	testCases := []struct {
+		desc     string
+		query    string
+		quantile *resource.Quantity
+		expected string
+		hasError bool
+	}{
+		{
+			desc:     "name only",
+			query:    "http_request_duration_seconds",
+			quantile: &q95,
+			expected: "scalar(histogram_quantile(0.95, sum by (le) (rate(http_request_duration_seconds_bucket[300s]))))",
+		},
+		{
+			desc:     "labels",
+			query:    `http_request_duration_seconds{job="api", code=~"2.."}`,
+			quantile: &q999,
+			expected: `scalar(histogram_quantile(0.999, sum by (le) (rate(http_request_duration_seconds_bucket{job="api", code=~"2.."}[300s]))))`,
+		},
+		{
+			desc:     "bucket name",
+			query:    " http_request_duration_seconds_bucket ",
+			quantile: &q95,
+			expected: "scalar(histogram_quantile(0.95, sum by (le) (rate(http_request_duration_seconds_bucket[300s]))))",
+		},
+		{
+			desc:     "missing quantile",
+			query:    "http_request_duration_seconds",
+			hasError: true,
+		},
+		{
+			desc:     "invalid quantile",
+			query:    "http_request_duration_seconds",
+			quantile: &q2,
+			hasError: true,
+		},
+		{
+			desc:     "not a selector",
+			query:    "rate(http_request_duration_seconds_bucket[5m])",
+			quantile: &q95,
+			hasError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%q", tc.desc), func(t *testing.T) {
+			query, err := histogramQuantileQuery(&optimizev1beta2.Metric{Name: "test", Query: tc.query, Quantile: tc.quantile}, startTime, completionTime)
+			if tc.hasError {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, query)
+			}
+		})
+	}
+}
+
+func TestPrometheusBearerToken(t *testing.T) {
+	optional := true
+	testCases := []struct {
+		desc        string
+		ref         *corev1.SecretKeySelector
+		credentials *corev1.Secret
+		expected    string
+		hasError    bool
+	}{
+		{
+			desc: "no reference",
+		},
+		{
+			desc: "token",
+			ref: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "prometheus"},
+				Key:                  "token",
+			},
+			credentials: &corev1.Secret{Data: map[string][]byte{"token": []byte("abc123\n")}},
+			expected:    "abc123",
+		},
+		{
+			desc: "missing key",
+			ref: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "prometheus"},
+				Key:                  "token",
+			},
+			credentials: &corev1.Secret{},
+			hasError:    true,
+		},
+		{
+			desc: "optional missing secret",
+			ref: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "prometheus"},
+				Key:                  "token",
+				Optional:             &optional,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%q", tc.desc), func(t *testing.T) {
+			token, err := bearerToken(&optimizev1beta2.Metric{BearerTokenSecretRef: tc.ref}, tc.credentials)
+			if tc.hasError {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, token)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+)
+
+// PushedValues returns the metric values pushed by the trial run.
+func PushedValues(t *optimizev1beta2.Trial) (map[string]float64, error) {
+	values := make(map[string]float64)
+	if data, ok := t.GetAnnotations()[optimizev1beta2.AnnotationPushedValues]; ok {
+		if err := json.Unmarshal([]byte(data), &values); err != nil {
+			return nil, fmt.Errorf("invalid pushed values: %w", err)
+		}
+	}
+	return values, nil
+}
+
+func capturePushMetric(t *optimizev1beta2.Trial, m *optimizev1beta2.Metric) (float64, float64, error) {
+	values, err := PushedValues(t)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	value, ok := values[m.Query]
+	if !ok {
+		return 0, 0, fmt.Errorf("no value pushed for %q", m.Query)
+	}
+
+	valueError := math.NaN()
+	if m.ErrorQuery != "" {
+		if valueError, ok = values[m.ErrorQuery]; !ok {
+			return 0, 0, fmt.Errorf("no value pushed for %q", m.ErrorQuery)
+		}
+	}
+
+	return value, valueError, nil
+}
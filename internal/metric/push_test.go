@@ -0,0 +1,91 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCapturePushMetric(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		pushed        string
+		metric        optimizev1beta2.Metric
+		expected      float64
+		expectedError float64
+		err           string
+	}{
+		{
+			desc:          "value",
+			pushed:        `{"latency": 1.5}`,
+			metric:        optimizev1beta2.Metric{Query: "latency"},
+			expected:      1.5,
+			expectedError: math.NaN(),
+		},
+		{
+			desc:          "value and error",
+			pushed:        `{"latency": 1.5, "latency_stddev": 0.25}`,
+			metric:        optimizev1beta2.Metric{Query: "latency", ErrorQuery: "latency_stddev"},
+			expected:      1.5,
+			expectedError: 0.25,
+		},
+		{
+			desc:   "nothing pushed",
+			metric: optimizev1beta2.Metric{Query: "latency"},
+			err:    `no value pushed for "latency"`,
+		},
+		{
+			desc:   "missing error",
+			pushed: `{"latency": 1.5}`,
+			metric: optimizev1beta2.Metric{Query: "latency", ErrorQuery: "latency_stddev"},
+			err:    `no value pushed for "latency_stddev"`,
+		},
+		{
+			desc:   "invalid annotation",
+			pushed: `latency=1.5`,
+			metric: optimizev1beta2.Metric{Query: "latency"},
+			err:    "invalid pushed values: invalid character 'l' looking for beginning of value",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			trial := &optimizev1beta2.Trial{}
+			if tc.pushed != "" {
+				trial.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{optimizev1beta2.AnnotationPushedValues: tc.pushed}}
+			}
+
+			value, valueError, err := capturePushMetric(trial, &tc.metric)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, value)
+				if math.IsNaN(tc.expectedError) {
+					assert.True(t, math.IsNaN(valueError))
+				} else {
+					assert.Equal(t, tc.expectedError, valueError)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+)
+
+// unit describes how to convert a value to a canonical unit.
+type unit struct {
+	canonical string
+	scale     float64
+}
+
+// units is the table of recognized metric units, keyed by lower case name.
+var units = map[string]unit{
+	// Time, canonical unit is seconds
+	"ns": {"s", 1e-9}, "us": {"s", 1e-6}, "µs": {"s", 1e-6}, "ms": {"s", 1e-3},
+	"s": {"s", 1}, "sec": {"s", 1}, "seconds": {"s", 1},
+	"min": {"s", 60}, "minutes": {"s", 60}, "h": {"s", 3600}, "hours": {"s", 3600},
+
+	// Data, canonical unit is bytes
+	"b": {"B", 1}, "bytes": {"B", 1},
+	"kb": {"B", 1e3}, "mb": {"B", 1e6}, "gb": {"B", 1e9}, "tb": {"B", 1e12},
+	"kib": {"B", 1 << 10}, "mib": {"B", 1 << 20}, "gib": {"B", 1 << 30}, "tib": {"B", 1 << 40},
+
+	// CPU, canonical unit is cores
+	"cores": {"cores", 1}, "millicores": {"cores", 1e-3},
+
+	// Dimensionless, canonical unit is a ratio
+	"ratio": {"ratio", 1}, "%": {"ratio", 1e-2}, "percent": {"ratio", 1e-2},
+
+	// Common rate abbreviations, canonical unit is per second
+	"rps": {"req/s", 1}, "rpm": {"req/s", 1.0 / 60},
+}
+
+// ParseUnit returns the canonical unit and the scale factor used to convert values in the supplied unit. Rates are
+// expressed as a quantity per unit of time, e.g. "req/min", and are converted to a quantity per second. An empty
+// unit is left as-is.
+func ParseUnit(name string) (string, float64, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", 1, nil
+	}
+
+	if u, ok := units[strings.ToLower(name)]; ok {
+		return u.canonical, u.scale, nil
+	}
+
+	if i := strings.LastIndex(name, "/"); i > 0 {
+		if u, ok := units[strings.ToLower(name[i+1:])]; ok && u.canonical == "s" {
+			return name[:i] + "/s", 1 / u.scale, nil
+		}
+	}
+
+	return "", 0, fmt.Errorf("unknown unit %q", name)
+}
+
+// NormalizeValue converts a value collected for the metric into the canonical unit.
+func NormalizeValue(m *optimizev1beta2.Metric, value float64) (float64, error) {
+	_, scale, err := ParseUnit(m.Unit)
+	if err != nil {
+		return 0, err
+	}
+	if scale == 1 || math.IsNaN(value) {
+		return value, nil
+	}
+	return value * scale, nil
+}
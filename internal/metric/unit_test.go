@@ -0,0 +1,75 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+)
+
+func TestParseUnit(t *testing.T) {
+	testCases := []struct {
+		unit      string
+		canonical string
+		scale     float64
+		err       bool
+	}{
+		{unit: "", scale: 1},
+		{unit: "ms", canonical: "s", scale: 1e-3},
+		{unit: "min", canonical: "s", scale: 60},
+		{unit: "MiB", canonical: "B", scale: 1048576},
+		{unit: "GB", canonical: "B", scale: 1e9},
+		{unit: "millicores", canonical: "cores", scale: 1e-3},
+		{unit: "%", canonical: "ratio", scale: 0.01},
+		{unit: "rpm", canonical: "req/s", scale: 1.0 / 60},
+		{unit: "req/min", canonical: "req/s", scale: 1.0 / 60},
+		{unit: "errors/h", canonical: "errors/s", scale: 1.0 / 3600},
+		{unit: "req/MiB", err: true},
+		{unit: "furlongs", err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.unit, func(t *testing.T) {
+			canonical, scale, err := ParseUnit(tc.unit)
+			if tc.err {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.canonical, canonical)
+				assert.InDelta(t, tc.scale, scale, 1e-12)
+			}
+		})
+	}
+}
+
+func TestNormalizeValue(t *testing.T) {
+	m := &optimizev1beta2.Metric{Name: "latency", Unit: "ms"}
+
+	value, err := NormalizeValue(m, 250)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0.25, value)
+	}
+
+	value, err = NormalizeValue(m, math.NaN())
+	if assert.NoError(t, err) {
+		assert.True(t, math.IsNaN(value))
+	}
+
+	_, err = NormalizeValue(&optimizev1beta2.Metric{Name: "latency", Unit: "fortnights"}, 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceUsage accumulates samples of the resource usage of the patched pods.
+type ResourceUsage struct {
+	// The number of samples taken
+	Samples int `json:"samples"`
+	// The time of the most recent sample
+	LastSampleTime metav1.Time `json:"lastSampleTime"`
+	// The sum of the sampled CPU usage, in cores
+	CPU float64 `json:"cpu"`
+	// The maximum sampled CPU usage, in cores
+	MaxCPU float64 `json:"maxCPU"`
+	// The sum of the sampled memory usage, in bytes
+	Memory float64 `json:"memory"`
+	// The maximum sampled memory usage, in bytes
+	MaxMemory float64 `json:"maxMemory"`
+	// The sum of the CPU requests of the sampled pods, in cores
+	CPURequests float64 `json:"cpuRequests"`
+	// The sum of the memory requests of the sampled pods, in bytes
+	MemoryRequests float64 `json:"memoryRequests"`
+}
+
+// TrialResourceUsage returns the resource usage sampled during the trial run.
+func TrialResourceUsage(t *optimizev1beta2.Trial) (*ResourceUsage, error) {
+	u := &ResourceUsage{}
+	if data, ok := t.GetAnnotations()[optimizev1beta2.AnnotationResourceUsage]; ok {
+		if err := json.Unmarshal([]byte(data), u); err != nil {
+			return nil, fmt.Errorf("invalid resource usage: %w", err)
+		}
+	}
+	return u, nil
+}
+
+// Add records a single sample of the total resource usage and requests of the patched pods.
+func (u *ResourceUsage) Add(sampleTime metav1.Time, cpu, memory, cpuRequests, memoryRequests float64) {
+	u.Samples++
+	u.LastSampleTime = sampleTime
+	u.CPU += cpu
+	u.MaxCPU = math.Max(u.MaxCPU, cpu)
+	u.Memory += memory
+	u.MaxMemory = math.Max(u.MaxMemory, memory)
+	u.CPURequests += cpuRequests
+	u.MemoryRequests += memoryRequests
+}
+
+// Values returns the summary values that can be used as metric queries.
+func (u *ResourceUsage) Values() map[string]float64 {
+	values := make(map[string]float64)
+	if u.Samples == 0 {
+		return values
+	}
+
+	n := float64(u.Samples)
+	values["cpu-avg"] = u.CPU / n
+	values["cpu-max"] = u.MaxCPU
+	values["memory-avg"] = u.Memory / n
+	values["memory-max"] = u.MaxMemory
+
+	// Utilization is only available when the pods have requests
+	if u.CPURequests > 0 {
+		values["cpu-utilization"] = u.CPU / u.CPURequests * 100
+	}
+	if u.MemoryRequests > 0 {
+		values["memory-utilization"] = u.Memory / u.MemoryRequests * 100
+	}
+
+	return values
+}
+
+func captureUsageMetric(t *optimizev1beta2.Trial, m *optimizev1beta2.Metric) (float64, float64, error) {
+	u, err := TrialResourceUsage(t)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	values := u.Values()
+	value, ok := values[m.Query]
+	if !ok {
+		return 0, 0, &CaptureError{Message: fmt.Sprintf("resource usage %q not available", m.Query), Query: m.Query, NoData: true}
+	}
+
+	valueError := math.NaN()
+	if m.ErrorQuery != "" {
+		if valueError, ok = values[m.ErrorQuery]; !ok {
+			return 0, 0, &CaptureError{Message: fmt.Sprintf("resource usage %q not available", m.ErrorQuery), Query: m.ErrorQuery, NoData: true}
+		}
+	}
+
+	return value, valueError, nil
+}
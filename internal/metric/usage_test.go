@@ -0,0 +1,63 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCaptureUsageMetric(t *testing.T) {
+	u := &ResourceUsage{}
+	u.Add(metav1.Now(), 0.5, 100e6, 1, 200e6)
+	u.Add(metav1.Now(), 1.5, 300e6, 1, 200e6)
+	data, err := json.Marshal(u)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	trial := &optimizev1beta2.Trial{}
+	trial.Annotations = map[string]string{optimizev1beta2.AnnotationResourceUsage: string(data)}
+
+	testCases := []struct {
+		query    string
+		expected float64
+	}{
+		{query: "cpu-avg", expected: 1},
+		{query: "cpu-max", expected: 1.5},
+		{query: "memory-avg", expected: 200e6},
+		{query: "memory-max", expected: 300e6},
+		{query: "cpu-utilization", expected: 100},
+		{query: "memory-utilization", expected: 100},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			value, _, err := captureUsageMetric(trial, &optimizev1beta2.Metric{Name: "test", Query: tc.query})
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, value)
+			}
+		})
+	}
+
+	_, _, err = captureUsageMetric(&optimizev1beta2.Trial{}, &optimizev1beta2.Metric{Name: "test", Query: "cpu-avg"})
+	reason, _ := FailureReason(err)
+	assert.Equal(t, ReasonNoData, reason)
+}
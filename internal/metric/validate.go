@@ -0,0 +1,105 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	prom "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ValidatePrometheusQueries renders the queries of a Prometheus metric using the supplied trial and checks that they
+// are well formed. If requested, the queries are also executed against the Prometheus server of the metric to confirm
+// they produce a scalar result using the supplied credentials.
+func ValidatePrometheusQueries(ctx context.Context, m *optimizev1beta2.Metric, t *optimizev1beta2.Trial, credentials *corev1.Secret, dryRun bool) error {
+	if m.Type != optimizev1beta2.MetricPrometheus && m.Type != optimizev1beta2.MetricPrometheusHistogram {
+		return nil
+	}
+
+	t = observedTrial(m, t)
+	m = m.DeepCopy()
+	var err error
+	if m.Query, m.ErrorQuery, err = template.New().RenderMetricQueries(m, t, nil); err != nil {
+		return err
+	}
+	if m.Type == optimizev1beta2.MetricPrometheusHistogram {
+		if m.Query, err = histogramQuantileQuery(m, t.Status.StartTime.Time, t.Status.CompletionTime.Time); err != nil {
+			return err
+		}
+	}
+
+	queries := []string{m.Query}
+	if m.ErrorQuery != "" {
+		queries = append(queries, m.ErrorQuery)
+	}
+
+	for _, q := range queries {
+		if err := checkPromQL(q); err != nil {
+			return fmt.Errorf("invalid query %q: %w", q, err)
+		}
+	}
+
+	// The built-in Prometheus does not exist until the trial runs, so only explicit URLs are checked
+	if !dryRun || m.URL == "" {
+		return nil
+	}
+
+	cfg := prom.Config{Address: m.URL}
+	if token, err := bearerToken(m, credentials); err != nil {
+		return err
+	} else if token != "" {
+		cfg.RoundTripper = &bearerAuthRoundTripper{token: token, rt: prom.DefaultRoundTripper}
+	}
+
+	c, err := prom.NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	promAPI := promv1.NewAPI(c)
+
+	for _, q := range queries {
+		v, _, err := promAPI.Query(ctx, q, t.Status.CompletionTime.Time)
+		if err != nil {
+			return fmt.Errorf("query %q failed: %w", q, err)
+		}
+
+		// Capture treats single element vectors as scalars
+		if v.Type() != model.ValScalar && v.Type() != model.ValVector {
+			return fmt.Errorf("query %q must evaluate to a scalar, got %s", q, v.Type())
+		}
+	}
+
+	return nil
+}
+
+// checkPromQL verifies that a query is a syntactically valid PromQL expression.
+func checkPromQL(q string) error {
+	if strings.TrimSpace(q) == "" {
+		return fmt.Errorf("query is empty")
+	}
+
+	_, err := parser.ParseExpr(q)
+	return err
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckPromQL(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		query string
+		err   string
+	}{
+		{
+			desc:  "scalar",
+			query: `scalar(sum(rate(http_requests_total{job="api"}[5m])))`,
+		},
+		{
+			desc:  "subquery",
+			query: `max_over_time(rate(http_requests_total[1m])[30m:1m])`,
+		},
+		{
+			desc:  "brackets in strings",
+			query: `scalar(sum(up{job="(api]"}))`,
+		},
+		{
+			desc:  "escaped quote",
+			query: `scalar(sum(up{job="a\"b"}))`,
+		},
+		{
+			desc:  "empty",
+			query: " ",
+			err:   "query is empty",
+		},
+		{
+			desc:  "unclosed paren",
+			query: `scalar(sum(up)`,
+			err:   "1:15: parse error: unclosed left parenthesis",
+		},
+		{
+			desc:  "mismatched bracket",
+			query: `scalar(up{job="api")`,
+			err:   "1:20: parse error: unexpected character inside braces: ')'",
+		},
+		{
+			desc:  "unterminated string",
+			query: `scalar(up{job="api})`,
+			err:   "1:15: parse error: unterminated quoted string",
+		},
+		{
+			desc:  "invalid range",
+			query: `scalar(rate(http_requests_total[5 minutes]))`,
+			err:   "1:33: parse error: missing unit character in duration",
+		},
+		{
+			desc:  "unknown function",
+			query: `scalar(summ(up))`,
+			err:   `1:8: parse error: unknown function with name "summ"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := checkPromQL(tc.query)
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidatePrometheusQueries(t *testing.T) {
+	completionTime := metav1.NewTime(time.Now())
+	startTime := metav1.NewTime(completionTime.Add(-2 * time.Minute))
+	trial := &optimizev1beta2.Trial{
+		Status: optimizev1beta2.TrialStatus{
+			StartTime:      &startTime,
+			CompletionTime: &completionTime,
+		},
+	}
+
+	testCases := []struct {
+		desc     string
+		query    string
+		response string
+		dryRun   bool
+		hasError bool
+	}{
+		{
+			desc:  "rendered range",
+			query: "scalar(rate(http_requests_total[{{ .Range }}]))",
+		},
+		{
+			desc:     "invalid template",
+			query:    "scalar(rate(http_requests_total[{{ .Range }]))",
+			hasError: true,
+		},
+		{
+			desc:     "dry run scalar",
+			query:    "scalar(up)",
+			response: `{"status":"success","data":{"resultType":"scalar","result":[1595471900.283,"1"]}}`,
+			dryRun:   true,
+		},
+		{
+			desc:     "dry run matrix",
+			query:    "up[5m]",
+			response: `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			dryRun:   true,
+			hasError: true,
+		},
+		{
+			desc:     "dry run parse error",
+			query:    "scalar(up)",
+			response: `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			dryRun:   true,
+			hasError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !tc.dryRun {
+					t.Error("unexpected query")
+				}
+				if strings.Contains(tc.response, "bad_data") {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+				_, _ = fmt.Fprint(w, tc.response)
+			}))
+			defer srv.Close()
+
+			m := &optimizev1beta2.Metric{Name: "test", Type: optimizev1beta2.MetricPrometheus, Query: tc.query, URL: srv.URL}
+			err := ValidatePrometheusQueries(context.TODO(), m, trial, nil, tc.dryRun)
+			if tc.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CollectionDelay returns the amount of time remaining until the metric can be collected.
+func CollectionDelay(m *optimizev1beta2.Metric, t *optimizev1beta2.Trial, now time.Time) time.Duration {
+	if m.Delay == nil || t.Status.CompletionTime == nil {
+		return 0
+	}
+
+	if d := t.Status.CompletionTime.Add(m.Delay.Duration).Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// observedTrial returns a copy of the trial whose start and completion times are restricted to the observation
+// window of the metric.
+func observedTrial(m *optimizev1beta2.Metric, t *optimizev1beta2.Trial) *optimizev1beta2.Trial {
+	if m.Window == nil || t.Status.StartTime == nil || t.Status.CompletionTime == nil {
+		return t
+	}
+
+	start, end := t.Status.StartTime.Time, t.Status.CompletionTime.Time
+	if m.Window.Offset != nil {
+		start = start.Add(m.Window.Offset.Duration)
+	}
+	if start.After(end) {
+		start = end
+	}
+	if m.Window.Duration != nil && start.Add(m.Window.Duration.Duration).Before(end) {
+		end = start.Add(m.Window.Duration.Duration)
+	}
+
+	t = t.DeepCopy()
+	t.Status.StartTime = &metav1.Time{Time: start}
+	t.Status.CompletionTime = &metav1.Time{Time: end}
+	return t
+}
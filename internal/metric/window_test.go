@@ -0,0 +1,180 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCollectionDelay(t *testing.T) {
+	now := time.Now()
+	completionTime := metav1.NewTime(now.Add(-time.Minute))
+
+	testCases := []struct {
+		desc     string
+		delay    *metav1.Duration
+		expected time.Duration
+	}{
+		{
+			desc: "no delay",
+		},
+		{
+			desc:     "waiting",
+			delay:    &metav1.Duration{Duration: 3 * time.Minute},
+			expected: 2 * time.Minute,
+		},
+		{
+			desc:  "elapsed",
+			delay: &metav1.Duration{Duration: 30 * time.Second},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			trial := &optimizev1beta2.Trial{Status: optimizev1beta2.TrialStatus{CompletionTime: &completionTime}}
+			assert.Equal(t, tc.expected, CollectionDelay(&optimizev1beta2.Metric{Delay: tc.delay}, trial, now))
+		})
+	}
+}
+
+func TestObservedTrial(t *testing.T) {
+	startTime := metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	completionTime := metav1.NewTime(startTime.Add(10 * time.Minute))
+
+	testCases := []struct {
+		desc          string
+		window        *optimizev1beta2.MetricWindow
+		expectedStart time.Duration
+		expectedEnd   time.Duration
+	}{
+		{
+			desc:        "no window",
+			expectedEnd: 10 * time.Minute,
+		},
+		{
+			desc:          "offset",
+			window:        &optimizev1beta2.MetricWindow{Offset: &metav1.Duration{Duration: 2 * time.Minute}},
+			expectedStart: 2 * time.Minute,
+			expectedEnd:   10 * time.Minute,
+		},
+		{
+			desc: "offset and duration",
+			window: &optimizev1beta2.MetricWindow{
+				Offset:   &metav1.Duration{Duration: 2 * time.Minute},
+				Duration: &metav1.Duration{Duration: 5 * time.Minute},
+			},
+			expectedStart: 2 * time.Minute,
+			expectedEnd:   7 * time.Minute,
+		},
+		{
+			desc:          "offset past completion",
+			window:        &optimizev1beta2.MetricWindow{Offset: &metav1.Duration{Duration: 20 * time.Minute}},
+			expectedStart: 10 * time.Minute,
+			expectedEnd:   10 * time.Minute,
+		},
+		{
+			desc:        "duration past completion",
+			window:      &optimizev1beta2.MetricWindow{Duration: &metav1.Duration{Duration: 20 * time.Minute}},
+			expectedEnd: 10 * time.Minute,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			trial := &optimizev1beta2.Trial{Status: optimizev1beta2.TrialStatus{StartTime: &startTime, CompletionTime: &completionTime}}
+			actual := observedTrial(&optimizev1beta2.Metric{Window: tc.window}, trial)
+			assert.Equal(t, tc.expectedStart, actual.Status.StartTime.Sub(startTime.Time))
+			assert.Equal(t, tc.expectedEnd, actual.Status.CompletionTime.Sub(startTime.Time))
+			assert.Equal(t, completionTime, *trial.Status.CompletionTime, "original trial should not change")
+		})
+	}
+}
+
+func TestQueryAggregate(t *testing.T) {
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	completionTime := startTime.Add(10 * time.Minute)
+	matrix := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1609459200,"1"],[1609459260,"4"],[1609459320,"NaN"],[1609459380,"1"]]}]}}`
+
+	testCases := []struct {
+		desc        string
+		aggregation string
+		response    string
+		expected    float64
+		hasError    bool
+	}{
+		{
+			desc:        "avg",
+			aggregation: "avg",
+			response:    matrix,
+			expected:    2,
+		},
+		{
+			desc:        "max",
+			aggregation: "max",
+			response:    matrix,
+			expected:    4,
+		},
+		{
+			desc:        "min",
+			aggregation: "min",
+			response:    matrix,
+			expected:    1,
+		},
+		{
+			desc:        "unsupported",
+			aggregation: "sum",
+			response:    matrix,
+			hasError:    true,
+		},
+		{
+			desc:        "multiple series",
+			aggregation: "avg",
+			response:    `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"a":"1"},"values":[[1609459200,"1"]]},{"metric":{"a":"2"},"values":[[1609459200,"1"]]}]}}`,
+			hasError:    true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v1/query_range", r.URL.Path)
+				_, _ = fmt.Fprint(w, tc.response)
+			}))
+			defer srv.Close()
+
+			c, err := prom.NewClient(prom.Config{Address: srv.URL})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			value, err := queryAggregate(context.TODO(), promv1.NewAPI(c), "scalar(up)", tc.aggregation, startTime, completionTime)
+			if tc.hasError {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Notification types
+const (
+	// ExperimentCompleted is sent when an experiment finishes
+	ExperimentCompleted = "ExperimentCompleted"
+	// BestTrialImproved is sent when a completed trial beats the best trial so far
+	BestTrialImproved = "BestTrialImproved"
+	// TrialFailureStreak is sent when several trials fail in a row
+	TrialFailureStreak = "TrialFailureStreak"
+	// BudgetExhausted is sent when an experiment runs out of budget
+	BudgetExhausted = "BudgetExhausted"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Notification describes a noteworthy change to an experiment.
+type Notification struct {
+	// Type is the kind of notification.
+	Type string `json:"type"`
+	// Namespace is the namespace of the experiment.
+	Namespace string `json:"namespace"`
+	// Experiment is the name of the experiment.
+	Experiment string `json:"experiment"`
+	// Message is a human readable description of what happened.
+	Message string `json:"message"`
+	// Time is when the notification was produced.
+	Time time.Time `json:"time"`
+}
+
+// String returns a single line summary of the notification.
+func (n *Notification) String() string {
+	return fmt.Sprintf("[%s] Experiment %s/%s: %s", n.Type, n.Namespace, n.Experiment, n.Message)
+}
+
+// Notifier delivers notifications.
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// ConfigName is the name of the config map and secret holding the notification settings of the experiments in a
+// namespace; sensitive values (e.g. webhook URLs or the SMTP password) should be stored in the secret.
+const ConfigName = "optimize-notifications"
+
+// Configuration keys
+const (
+	// WebhookURLKey is the URL notifications are posted to as JSON
+	WebhookURLKey = "webhookURL"
+	// SlackWebhookURLKey is the URL of a Slack incoming webhook
+	SlackWebhookURLKey = "slackWebhookURL"
+	// SMTPAddressKey is the host and port of the mail server
+	SMTPAddressKey = "smtpAddress"
+	// SMTPFromKey is the sender address of notification emails
+	SMTPFromKey = "smtpFrom"
+	// SMTPToKey is a comma separated list of recipient addresses
+	SMTPToKey = "smtpTo"
+	// SMTPUsernameKey is the user name used to authenticate with the mail server
+	SMTPUsernameKey = "smtpUsername"
+	// SMTPPasswordKey is the password used to authenticate with the mail server
+	SMTPPasswordKey = "smtpPassword"
+	// FailureStreakKey is the number of consecutive trial failures that triggers a notification
+	FailureStreakKey = "failureStreak"
+)
+
+// envKeys maps the configuration keys to the environment variables used to configure the controller defaults
+var envKeys = map[string]string{
+	WebhookURLKey:      "STORMFORGE_NOTIFY_WEBHOOK_URL",
+	SlackWebhookURLKey: "STORMFORGE_NOTIFY_SLACK_WEBHOOK_URL",
+	SMTPAddressKey:     "STORMFORGE_NOTIFY_SMTP_ADDRESS",
+	SMTPFromKey:        "STORMFORGE_NOTIFY_SMTP_FROM",
+	SMTPToKey:          "STORMFORGE_NOTIFY_SMTP_TO",
+	SMTPUsernameKey:    "STORMFORGE_NOTIFY_SMTP_USERNAME",
+	SMTPPasswordKey:    "STORMFORGE_NOTIFY_SMTP_PASSWORD",
+	FailureStreakKey:   "STORMFORGE_NOTIFY_FAILURE_STREAK",
+}
+
+// DefaultFailureStreak is the number of consecutive trial failures that triggers a notification by default
+const DefaultFailureStreak = 3
+
+// Settings describe how notifications are delivered.
+type Settings struct {
+	// Notifier delivers the notifications, nil if no backends are configured.
+	Notifier Notifier
+	// FailureStreak is the number of consecutive trial failures that triggers a notification, zero to disable.
+	FailureStreak int
+}
+
+// FromEnv returns the settings for all of the backends configured in the environment.
+func FromEnv() (*Settings, error) {
+	return fromLookup(func(key string) (string, bool) {
+		return os.LookupEnv(envKeys[key])
+	})
+}
+
+// FromConfig returns the settings for all of the backends configured in a config map and secret, either of which may be
+// nil. Values in the secret take precedence over values in the config map.
+func FromConfig(cm *corev1.ConfigMap, secret *corev1.Secret) (*Settings, error) {
+	return fromLookup(func(key string) (string, bool) {
+		if secret != nil {
+			if v, ok := secret.Data[key]; ok {
+				return string(v), true
+			}
+		}
+		if cm != nil {
+			if v, ok := cm.Data[key]; ok {
+				return v, true
+			}
+		}
+		return "", false
+	})
+}
+
+func fromLookup(lookup func(string) (string, bool)) (*Settings, error) {
+	get := func(key string) string {
+		v, _ := lookup(key)
+		return strings.TrimSpace(v)
+	}
+
+	settings := &Settings{FailureStreak: DefaultFailureStreak}
+	if streak, ok := lookup(FailureStreakKey); ok {
+		s, err := strconv.Atoi(strings.TrimSpace(streak))
+		if err != nil || s < 0 {
+			return nil, fmt.Errorf("invalid failure streak %q", streak)
+		}
+		settings.FailureStreak = s
+	}
+
+	var notifiers Notifiers
+	if u := get(WebhookURLKey); u != "" {
+		notifiers = append(notifiers, &Webhook{URL: u})
+	}
+
+	if u := get(SlackWebhookURLKey); u != "" {
+		notifiers = append(notifiers, &Slack{URL: u})
+	}
+
+	if addr := get(SMTPAddressKey); addr != "" {
+		n := &SMTP{Address: addr, From: get(SMTPFromKey)}
+		for _, to := range strings.Split(get(SMTPToKey), ",") {
+			if to = strings.TrimSpace(to); to != "" {
+				n.To = append(n.To, to)
+			}
+		}
+		if n.From == "" || len(n.To) == 0 {
+			return nil, fmt.Errorf("SMTP notifications require both a sender and recipients")
+		}
+
+		if username := get(SMTPUsernameKey); username != "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SMTP address %q: %w", addr, err)
+			}
+			n.Auth = smtp.PlainAuth("", username, get(SMTPPasswordKey), host)
+		}
+
+		notifiers = append(notifiers, n)
+	}
+
+	if len(notifiers) > 0 {
+		settings.Notifier = notifiers
+	}
+	return settings, nil
+}
+
+// Notifiers sends notifications to multiple backends.
+type Notifiers []Notifier
+
+// Notify sends the notification to every backend, returning the first error encountered.
+func (ns Notifiers) Notify(ctx context.Context, n *Notification) error {
+	var firstErr error
+	for _, notifier := range ns {
+		if err := notifier.Notify(ctx, n); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Webhook posts the JSON representation of notifications to a URL.
+type Webhook struct {
+	URL string
+}
+
+// Notify posts the notification.
+func (w *Webhook) Notify(ctx context.Context, n *Notification) error {
+	return postJSON(ctx, w.URL, n)
+}
+
+// Slack posts notifications to a Slack incoming webhook.
+type Slack struct {
+	URL string
+}
+
+// Notify posts the notification as a Slack message.
+func (s *Slack) Notify(ctx context.Context, n *Notification) error {
+	return postJSON(ctx, s.URL, map[string]string{"text": n.String()})
+}
+
+// SMTP emails notifications.
+type SMTP struct {
+	// Address is the host and port of the mail server.
+	Address string
+	// From is the sender address.
+	From string
+	// To is the list of recipient addresses.
+	To []string
+	// Auth is used to authenticate with the mail server, may be nil.
+	Auth smtp.Auth
+}
+
+// Notify sends the notification as an email.
+func (s *SMTP) Notify(_ context.Context, n *Notification) error {
+	msg := &bytes.Buffer{}
+	_, _ = fmt.Fprintf(msg, "From: %s\r\n", s.From)
+	_, _ = fmt.Fprintf(msg, "To: %s\r\n", strings.Join(s.To, ", "))
+	_, _ = fmt.Fprintf(msg, "Subject: [%s] Experiment %s/%s\r\n", n.Type, n.Namespace, n.Experiment)
+	_, _ = fmt.Fprintf(msg, "\r\n%s\r\n", n.Message)
+	return smtp.SendMail(s.Address, s.Auth, s.From, s.To, msg.Bytes())
+}
+
+func postJSON(ctx context.Context, url string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification failed with status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestFromConfig(t *testing.T) {
+	cases := []struct {
+		desc                  string
+		data                  map[string]string
+		secretData            map[string]string
+		expectedCount         int
+		expectedFailureStreak int
+		expectedError         bool
+	}{
+		{
+			desc:                  "empty",
+			expectedFailureStreak: DefaultFailureStreak,
+		},
+		{
+			desc:                  "webhook",
+			data:                  map[string]string{WebhookURLKey: "http://example.com/hook"},
+			expectedCount:         1,
+			expectedFailureStreak: DefaultFailureStreak,
+		},
+		{
+			desc: "all",
+			data: map[string]string{
+				SMTPAddressKey:   "smtp.example.com:587",
+				SMTPFromKey:      "optimize@example.com",
+				SMTPToKey:        "a@example.com, b@example.com",
+				SMTPUsernameKey:  "optimize",
+				FailureStreakKey: "5",
+			},
+			secretData: map[string]string{
+				WebhookURLKey:      "http://example.com/hook",
+				SlackWebhookURLKey: "https://hooks.slack.com/services/x",
+				SMTPPasswordKey:    "secret",
+			},
+			expectedCount:         3,
+			expectedFailureStreak: 5,
+		},
+		{
+			desc:          "smtp missing recipients",
+			data:          map[string]string{SMTPAddressKey: "smtp.example.com:587", SMTPFromKey: "optimize@example.com"},
+			expectedError: true,
+		},
+		{
+			desc: "smtp invalid address",
+			data: map[string]string{
+				SMTPAddressKey:  "smtp.example.com",
+				SMTPFromKey:     "optimize@example.com",
+				SMTPToKey:       "a@example.com",
+				SMTPUsernameKey: "optimize",
+			},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid failure streak",
+			data:          map[string]string{FailureStreakKey: "-1"},
+			expectedError: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			cm := &corev1.ConfigMap{Data: c.data}
+			var secret *corev1.Secret
+			if c.secretData != nil {
+				secret = &corev1.Secret{Data: map[string][]byte{}}
+				for k, v := range c.secretData {
+					secret.Data[k] = []byte(v)
+				}
+			}
+
+			s, err := FromConfig(cm, secret)
+			if c.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expectedFailureStreak, s.FailureStreak)
+				if c.expectedCount == 0 {
+					assert.Nil(t, s.Notifier)
+				} else if assert.IsType(t, Notifiers{}, s.Notifier) {
+					assert.Len(t, s.Notifier, c.expectedCount)
+				}
+			}
+		})
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("STORMFORGE_NOTIFY_WEBHOOK_URL", "http://example.com/hook")
+	t.Setenv("STORMFORGE_NOTIFY_FAILURE_STREAK", "0")
+
+	s, err := FromEnv()
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, s.FailureStreak)
+		assert.Equal(t, Notifiers{&Webhook{URL: "http://example.com/hook"}}, s.Notifier)
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make(map[string]interface{})
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	n := &Notification{
+		Type:       ExperimentCompleted,
+		Namespace:  "default",
+		Experiment: "my-exp",
+		Message:    "Experiment completed after 10 trials",
+		Time:       time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	ns := Notifiers{&Webhook{URL: srv.URL + "/fail"}, &Webhook{URL: srv.URL + "/hook"}, &Slack{URL: srv.URL + "/slack"}}
+	assert.Error(t, ns.Notify(context.Background(), n))
+	if assert.Len(t, bodies, 3) {
+		assert.Equal(t, "my-exp", bodies[1]["experiment"])
+		assert.Equal(t, ExperimentCompleted, bodies[1]["type"])
+		assert.Equal(t, "[ExperimentCompleted] Experiment default/my-exp: Experiment completed after 10 trials", bodies[2]["text"])
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// FieldManager is the server-side apply field manager used for trial patches, each patch operation uses a distinct
+// field manager with this prefix so patches to the same object do not remove each other's fields.
+const FieldManager = "optimize-trial-patch"
+
+// forcedManagers are the field managers of the clients typically used to deploy an application (kubectl and Helm);
+// fields owned by these managers are taken over by trial patches instead of being reported as conflicts. Generic
+// names (e.g. "manager", the default for controller-runtime based controllers) are deliberately excluded.
+var forcedManagers = map[string]bool{
+	"helm":                      true,
+	"kubectl":                   true,
+	"kubectl-annotate":          true,
+	"kubectl-client-side-apply": true,
+	"kubectl-create":            true,
+	"kubectl-edit":              true,
+	"kubectl-label":             true,
+	"kubectl-patch":             true,
+	"kubectl-replace":           true,
+	"kubectl-rollout":           true,
+	"kubectl-set":               true,
+}
+
+var conflictManagerPattern = regexp.MustCompile(`conflict with "([^"]*)"`)
+
+// ConflictError indicates the fields of a patch are managed by another client (e.g. a controller like the horizontal
+// pod autoscaler that will revert the patched values).
+type ConflictError struct {
+	// The names of the conflicting field managers
+	Managers []string
+	// The conflicting field paths
+	Fields []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("patch conflicts with fields managed by %s: %s", strings.Join(quote(e.Managers), ", "), strings.Join(e.Fields, ", "))
+}
+
+// ApplyFieldManager returns the field manager for the patch operation at the specified index.
+func ApplyFieldManager(index int) string {
+	return fmt.Sprintf("%s-%d", FieldManager, index)
+}
+
+// ApplyData returns the server-side apply configuration equivalent to the patch operation. The boolean return value
+// is false if the patch cannot be expressed as an apply configuration, for example JSON patches or patches which
+// remove fields.
+func ApplyData(p *optimizev1beta2.PatchOperation) ([]byte, bool, error) {
+	if p.PatchType != types.StrategicMergePatchType && p.PatchType != types.MergePatchType {
+		return nil, false, nil
+	}
+	if p.TargetRef.APIVersion == "" || p.TargetRef.Kind == "" || p.TargetRef.Name == "" {
+		return nil, false, nil
+	}
+
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(p.Data, &obj); err != nil {
+		return nil, false, err
+	}
+	if !applicable(obj) {
+		return nil, false, nil
+	}
+
+	md, _ := obj["metadata"].(map[string]interface{})
+	if md == nil {
+		md = make(map[string]interface{})
+	}
+	md["name"] = p.TargetRef.Name
+	if p.TargetRef.Namespace != "" {
+		md["namespace"] = p.TargetRef.Namespace
+	}
+	obj["metadata"] = md
+	obj["apiVersion"] = p.TargetRef.APIVersion
+	obj["kind"] = p.TargetRef.Kind
+
+	data, err := json.Marshal(obj)
+	return data, err == nil, err
+}
+
+// Conflict returns the conflict described by a server-side apply error, if any. The boolean return value indicates
+// that the conflicting fields are only managed by application deployment tools or trial patches and can be forced.
+func Conflict(err error) (*ConflictError, bool) {
+	var statusErr *apierrors.StatusError
+	if !errors.As(err, &statusErr) || !apierrors.IsConflict(err) || statusErr.ErrStatus.Details == nil {
+		return nil, false
+	}
+
+	managers := make(map[string]bool)
+	conflict := &ConflictError{}
+	for _, c := range statusErr.ErrStatus.Details.Causes {
+		if c.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		if m := conflictManagerPattern.FindStringSubmatch(c.Message); m != nil {
+			managers[m[1]] = true
+		}
+		conflict.Fields = append(conflict.Fields, c.Field)
+	}
+	if len(conflict.Fields) == 0 {
+		return nil, false
+	}
+
+	force := true
+	for m := range managers {
+		conflict.Managers = append(conflict.Managers, m)
+		force = force && (forcedManagers[m] || strings.HasPrefix(m, FieldManager))
+	}
+	sort.Strings(conflict.Managers)
+	return conflict, force
+}
+
+// applicable checks that a patch does not contain any values that would be interpreted differently when applied.
+func applicable(v interface{}) bool {
+	switch vv := v.(type) {
+	case nil:
+		return false
+	case map[string]interface{}:
+		for k := range vv {
+			if strings.HasPrefix(k, "$") || !applicable(vv[k]) {
+				return false
+			}
+		}
+	case []interface{}:
+		for i := range vv {
+			if !applicable(vv[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// quote returns a copy of the supplied strings in quotes.
+func quote(s []string) []string {
+	q := make([]string, len(s))
+	for i := range s {
+		q[i] = fmt.Sprintf("%q", s[i])
+	}
+	return q
+}
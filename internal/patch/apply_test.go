@@ -0,0 +1,164 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestApplyData(t *testing.T) {
+	deployment := corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "app", Namespace: "default"}
+	cases := []struct {
+		desc      string
+		patchType types.PatchType
+		targetRef corev1.ObjectReference
+		data      string
+		expected  string
+	}{
+		{
+			desc:      "strategic",
+			patchType: types.StrategicMergePatchType,
+			targetRef: deployment,
+			data:      `{"spec":{"replicas":3}}`,
+			expected:  `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"app","namespace":"default"},"spec":{"replicas":3}}`,
+		},
+		{
+			desc:      "merge with labels",
+			patchType: types.MergePatchType,
+			targetRef: corev1.ObjectReference{APIVersion: "example.com/v1", Kind: "Widget", Name: "w"},
+			data:      `{"metadata":{"labels":{"a":"b"}},"spec":{"size":1}}`,
+			expected:  `{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"labels":{"a":"b"},"name":"w"},"spec":{"size":1}}`,
+		},
+		{
+			desc:      "json patch",
+			patchType: types.JSONPatchType,
+			targetRef: deployment,
+			data:      `[{"op":"replace","path":"/spec/replicas","value":3}]`,
+		},
+		{
+			desc:      "remove field",
+			patchType: types.MergePatchType,
+			targetRef: deployment,
+			data:      `{"spec":{"replicas":null}}`,
+		},
+		{
+			desc:      "directive",
+			patchType: types.StrategicMergePatchType,
+			targetRef: deployment,
+			data:      `{"spec":{"template":{"spec":{"containers":[{"$patch":"replace"},{"name":"app"}]}}}}`,
+		},
+		{
+			desc:      "missing api version",
+			patchType: types.StrategicMergePatchType,
+			targetRef: corev1.ObjectReference{Kind: "Deployment", Name: "app"},
+			data:      `{"spec":{"replicas":3}}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, ok, err := ApplyData(&optimizev1beta2.PatchOperation{PatchType: c.patchType, TargetRef: c.targetRef, Data: []byte(c.data)})
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected != "", ok)
+				if c.expected != "" {
+					assert.JSONEq(t, c.expected, string(actual))
+				}
+			}
+		})
+	}
+}
+
+func TestConflict(t *testing.T) {
+	conflictErr := func(causes ...metav1.StatusCause) error {
+		err := apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "app", errors.New("Apply failed"))
+		err.ErrStatus.Details.Causes = causes
+		return err
+	}
+
+	cases := []struct {
+		desc             string
+		err              error
+		expectedManagers []string
+		expectedFields   []string
+		expectedForce    bool
+	}{
+		{
+			desc: "no error",
+		},
+		{
+			desc: "not a conflict",
+			err:  apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "app"),
+		},
+		{
+			desc: "autoscaler",
+			err: conflictErr(metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldManagerConflict,
+				Message: `conflict with "kube-controller-manager" using apps/v1`,
+				Field:   ".spec.replicas",
+			}),
+			expectedManagers: []string{"kube-controller-manager"},
+			expectedFields:   []string{".spec.replicas"},
+		},
+		{
+			desc: "controller",
+			err: conflictErr(metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldManagerConflict,
+				Message: `conflict with "manager" using apps/v1`,
+				Field:   ".spec.replicas",
+			}),
+			expectedManagers: []string{"manager"},
+			expectedFields:   []string{".spec.replicas"},
+		},
+		{
+			desc: "deployment tools",
+			err: conflictErr(metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldManagerConflict,
+				Message: `conflict with "kubectl-client-side-apply" using apps/v1`,
+				Field:   ".spec.replicas",
+			}, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldManagerConflict,
+				Message: `conflict with "optimize-trial-patch-1" using apps/v1`,
+				Field:   `.spec.template.spec.containers[name="app"].resources.limits.cpu`,
+			}),
+			expectedManagers: []string{"kubectl-client-side-apply", "optimize-trial-patch-1"},
+			expectedFields:   []string{".spec.replicas", `.spec.template.spec.containers[name="app"].resources.limits.cpu`},
+			expectedForce:    true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			conflict, force := Conflict(c.err)
+			assert.Equal(t, c.expectedForce, force)
+			if c.expectedFields == nil {
+				assert.Nil(t, conflict)
+				return
+			}
+			if assert.NotNil(t, conflict) {
+				assert.Equal(t, c.expectedManagers, conflict.Managers)
+				assert.Equal(t, c.expectedFields, conflict.Fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patch
+
+import (
+	"encoding/json"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	// directivePatch is the strategic merge patch directive used to delete or replace a map or list
+	directivePatch = "$patch"
+	// directiveReplace is the value of the patch directive used to replace a map or list instead of merging it
+	directiveReplace = "replace"
+	// directiveDelete is the value of the patch directive used to delete a map
+	directiveDelete = "delete"
+)
+
+// SupportsStrategicMerge checks to see if strategic merge patches can be applied to objects of the specified kind,
+// custom resources only support JSON merge patches.
+func SupportsStrategicMerge(gvk schema.GroupVersionKind) bool {
+	return scheme.Scheme.Recognizes(gvk)
+}
+
+// ToMergePatch translates a strategic merge patch into the equivalent JSON merge patch for objects which do not
+// support strategic merge: fields set to `null` are removed and lists are always replaced wholesale, so the
+// `$patch: delete` directive becomes `null` and the remaining directives are dropped.
+func ToMergePatch(data []byte) ([]byte, error) {
+	var p interface{}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(stripDirectives(p))
+}
+
+// ToStrategicMergePatch translates a JSON merge patch into the equivalent strategic merge patch: lists of objects are
+// marked with the `$patch: replace` directive so they are replaced wholesale instead of being merged by key.
+func ToStrategicMergePatch(data []byte) ([]byte, error) {
+	var p interface{}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(replaceLists(p))
+}
+
+// stripDirectives recursively removes strategic merge patch directives.
+func stripDirectives(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		if vv[directivePatch] == directiveDelete {
+			return nil
+		}
+		for k := range vv {
+			if strings.HasPrefix(k, "$") {
+				delete(vv, k)
+				continue
+			}
+			vv[k] = stripDirectives(vv[k])
+		}
+		return vv
+
+	case []interface{}:
+		result := make([]interface{}, 0, len(vv))
+		for _, e := range vv {
+			// Directive list elements (e.g. `- $patch: replace`) have no merge patch equivalent
+			if m, ok := e.(map[string]interface{}); ok && m[directivePatch] != nil {
+				continue
+			}
+			result = append(result, stripDirectives(e))
+		}
+		return result
+
+	default:
+		return v
+	}
+}
+
+// replaceLists recursively adds the replace directive to lists of objects.
+func replaceLists(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k := range vv {
+			vv[k] = replaceLists(vv[k])
+		}
+		return vv
+
+	case []interface{}:
+		objects := len(vv) > 0
+		for i := range vv {
+			if _, ok := vv[i].(map[string]interface{}); !ok {
+				objects = false
+			}
+			vv[i] = replaceLists(vv[i])
+		}
+		if objects {
+			vv = append([]interface{}{map[string]interface{}{directivePatch: directiveReplace}}, vv...)
+		}
+		return vv
+
+	default:
+		return v
+	}
+}
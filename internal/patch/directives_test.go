@@ -0,0 +1,98 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSupportsStrategicMerge(t *testing.T) {
+	assert.True(t, SupportsStrategicMerge(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}))
+	assert.False(t, SupportsStrategicMerge(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}))
+}
+
+func TestToMergePatch(t *testing.T) {
+	cases := []struct {
+		desc     string
+		data     string
+		expected string
+	}{
+		{
+			desc:     "no directives",
+			data:     `{"spec":{"replicas":3,"limits":{"cpu":null}}}`,
+			expected: `{"spec":{"limits":{"cpu":null},"replicas":3}}`,
+		},
+		{
+			desc:     "replace list",
+			data:     `{"spec":{"items":[{"$patch":"replace"},{"name":"z"}]}}`,
+			expected: `{"spec":{"items":[{"name":"z"}]}}`,
+		},
+		{
+			desc:     "delete map",
+			data:     `{"spec":{"settings":{"$patch":"delete"},"other":{"$patch":"replace","a":1}}}`,
+			expected: `{"spec":{"other":{"a":1},"settings":null}}`,
+		},
+		{
+			desc:     "retain keys",
+			data:     `{"spec":{"strategy":{"$retainKeys":["type"],"type":"Recreate"}}}`,
+			expected: `{"spec":{"strategy":{"type":"Recreate"}}}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := ToMergePatch([]byte(c.data))
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, c.expected, string(actual))
+			}
+		})
+	}
+}
+
+func TestToStrategicMergePatch(t *testing.T) {
+	cases := []struct {
+		desc     string
+		data     string
+		expected string
+	}{
+		{
+			desc:     "remove field",
+			data:     `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":null}}}]}}}}`,
+			expected: `{"spec":{"template":{"spec":{"containers":[{"$patch":"replace"},{"name":"app","resources":{"limits":{"cpu":null}}}]}}}}`,
+		},
+		{
+			desc:     "primitive list",
+			data:     `{"spec":{"args":["a","b"]}}`,
+			expected: `{"spec":{"args":["a","b"]}}`,
+		},
+		{
+			desc:     "empty list",
+			data:     `{"spec":{"tolerations":[]}}`,
+			expected: `{"spec":{"tolerations":[]}}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := ToStrategicMergePatch([]byte(c.data))
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, c.expected, string(actual))
+			}
+		})
+	}
+}
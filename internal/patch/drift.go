@@ -0,0 +1,110 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patch
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Drift compares a live object to the recommended (patched) version of the same object and returns the largest
+// relative difference between any of their values along with the path to that value. Only the labels and annotations
+// of the object metadata are considered and the status is ignored. Values which cannot be compared numerically have
+// a drift of 1 when they differ.
+func Drift(recommended, live map[string]interface{}) (float64, string) {
+	return drift(restorableContent(recommended), restorableContent(live), "")
+}
+
+func drift(recommended, live interface{}, path string) (float64, string) {
+	switch r := recommended.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return 1, path
+		}
+
+		keys := make([]string, 0, len(r))
+		for k := range r {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var maxDrift float64
+		var maxPath string
+		for _, k := range keys {
+			if d, p := drift(r[k], l[k], path+"."+k); d > maxDrift {
+				maxDrift, maxPath = d, p
+			}
+		}
+		return maxDrift, maxPath
+
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(r) {
+			return 1, path
+		}
+
+		var maxDrift float64
+		var maxPath string
+		for i := range r {
+			if d, p := drift(r[i], l[i], fmt.Sprintf("%s[%d]", path, i)); d > maxDrift {
+				maxDrift, maxPath = d, p
+			}
+		}
+		return maxDrift, maxPath
+
+	default:
+		if reflect.DeepEqual(recommended, live) {
+			return 0, path
+		}
+
+		rv, rok := numericValue(recommended)
+		lv, lok := numericValue(live)
+		switch {
+		case !rok || !lok:
+			return 1, path
+		case rv == 0:
+			return 1, path
+		default:
+			return math.Abs(lv-rv) / math.Abs(rv), path
+		}
+	}
+}
+
+// numericValue returns the numeric value of a JSON number or a string quantity.
+func numericValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, true
+		}
+		if q, err := resource.ParseQuantity(strings.TrimSpace(v)); err == nil {
+			return float64(q.MilliValue()) / 1000, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrift(t *testing.T) {
+	cases := []struct {
+		desc          string
+		recommended   string
+		live          string
+		expectedDrift float64
+		expectedPath  string
+	}{
+		{
+			desc:        "unchanged",
+			recommended: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","resourceVersion":"2"},"data":{"a":"1"}}`,
+			live:        `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","resourceVersion":"1"},"data":{"a":"1"}}`,
+		},
+		{
+			desc:          "changed string",
+			recommended:   `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"},"data":{"a":"1","b":"fast"}}`,
+			live:          `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"},"data":{"a":"1","b":"slow"}}`,
+			expectedDrift: 1,
+			expectedPath:  ".data.b",
+		},
+		{
+			desc:          "quantities",
+			recommended:   `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test"},"spec":{"replicas":4,"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":"500m","memory":"1Gi"}}}]}}},"status":{"replicas":2}}`,
+			live:          `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test"},"spec":{"replicas":5,"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":"1","memory":"1024Mi"}}}]}}},"status":{"replicas":5}}`,
+			expectedDrift: 1,
+			expectedPath:  ".spec.template.spec.containers[0].resources.limits.cpu",
+		},
+		{
+			desc:          "numbers",
+			recommended:   `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test"},"spec":{"replicas":4}}`,
+			live:          `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test"},"spec":{"replicas":5}}`,
+			expectedDrift: 0.25,
+			expectedPath:  ".spec.replicas",
+		},
+		{
+			desc:          "removed container",
+			recommended:   `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test"},"spec":{"template":{"spec":{"containers":[{"name":"app"},{"name":"sidecar"}]}}}}`,
+			live:          `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test"},"spec":{"template":{"spec":{"containers":[{"name":"app"}]}}}}`,
+			expectedDrift: 1,
+			expectedPath:  ".spec.template.spec.containers",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			recommended := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal([]byte(c.recommended), &recommended))
+			live := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal([]byte(c.live), &live))
+
+			drift, path := Drift(recommended, live)
+			assert.InDelta(t, c.expectedDrift, drift, 0.0001)
+			assert.Equal(t, c.expectedPath, path)
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -72,6 +73,87 @@ func RenderTemplate(te *template.Engine, t *optimizev1beta2.Trial, p *optimizev1
 	return ref, data, nil
 }
 
+// RenderEndpoint determines the endpoint target and renders a strategic merge patch which injects the endpoint
+func RenderEndpoint(te *template.Engine, t *optimizev1beta2.Trial, e *optimizev1beta2.TrialEndpoint) (*corev1.ObjectReference, []byte, error) {
+	value, err := te.RenderEndpoint(e, t)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ref := e.TargetRef.DeepCopy()
+	if ref.Namespace == "" {
+		ref.Namespace = t.Namespace
+	}
+	if ref.Kind == "" || ref.Name == "" {
+		return nil, nil, fmt.Errorf("invalid endpoint reference: missing kind or name")
+	}
+
+	// ConfigMaps get the endpoint as a data key, everything else is assumed to have containers
+	var p map[string]interface{}
+	if ref.Kind == "ConfigMap" {
+		p = map[string]interface{}{
+			"data": map[string]interface{}{e.Name: value},
+		}
+	} else {
+		if e.ContainerName == "" {
+			return nil, nil, fmt.Errorf("invalid endpoint %q: missing container name", e.Name)
+		}
+
+		p = map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name": e.ContainerName,
+					"env":  []interface{}{map[string]interface{}{"name": e.Name, "value": value}},
+				},
+			},
+		}
+		if ref.Kind == "Pod" {
+			p = map[string]interface{}{"spec": p}
+		} else {
+			p = podTemplatePatch(ref, map[string]interface{}{"spec": p})
+		}
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ref, data, nil
+}
+
+// RenderRestart determines the restart target and renders a strategic merge patch which forces a rollout by
+// annotating the pod template with the trial name
+func RenderRestart(t *optimizev1beta2.Trial, r *corev1.ObjectReference) (*corev1.ObjectReference, []byte, error) {
+	ref := r.DeepCopy()
+	if ref.Namespace == "" {
+		ref.Namespace = t.Namespace
+	}
+	if ref.Kind == "" || ref.Name == "" {
+		return nil, nil, fmt.Errorf("invalid restart reference: missing kind or name")
+	}
+
+	data, err := json.Marshal(podTemplatePatch(ref, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{optimizev1beta2.AnnotationRestartTrial: t.Name},
+		},
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ref, data, nil
+}
+
+// podTemplatePatch nests a patch of the pod template at the location used by the referenced workload kind.
+func podTemplatePatch(ref *corev1.ObjectReference, template map[string]interface{}) map[string]interface{} {
+	spec := map[string]interface{}{"template": template}
+	if ref.Kind == "CronJob" {
+		spec = map[string]interface{}{"jobTemplate": map[string]interface{}{"spec": spec}}
+	}
+	return map[string]interface{}{"spec": spec}
+}
+
 // createPatchOperation creates a new patch operation from a patch template and it's (fully rendered) patch data
 func CreatePatchOperation(t *optimizev1beta2.Trial, p *optimizev1beta2.PatchTemplate, ref *corev1.ObjectReference, data []byte) (*optimizev1beta2.PatchOperation, error) {
 	// If the patch is effectively null, we do not need to evaluate it
@@ -83,6 +165,9 @@ func CreatePatchOperation(t *optimizev1beta2.Trial, p *optimizev1beta2.PatchTemp
 		TargetRef:         *ref,
 		Data:              data,
 		AttemptsRemaining: defaultAttemptsRemaining,
+		InPlaceResize:     p.InPlaceResize,
+		Restore:           p.Restore || p.InPlaceResize,
+		Wave:              p.Wave,
 	}
 
 	// Determine the patch type
@@ -91,12 +176,27 @@ func CreatePatchOperation(t *optimizev1beta2.Trial, p *optimizev1beta2.PatchTemp
 		po.PatchType = types.StrategicMergePatchType
 	case optimizev1beta2.PatchMerge:
 		po.PatchType = types.MergePatchType
-	case optimizev1beta2.PatchJSON:
+	case optimizev1beta2.PatchJSON, optimizev1beta2.PatchJSON6902:
 		po.PatchType = types.JSONPatchType
 	default:
 		return nil, fmt.Errorf("unknown patch type: %s", p.Type)
 	}
 
+	// Custom resources do not support strategic merge, translate the patch (including any directives) to a merge patch
+	if po.PatchType == types.StrategicMergePatchType && ref.APIVersion != "" && !SupportsStrategicMerge(ref.GroupVersionKind()) {
+		data, err := ToMergePatch(po.Data)
+		if err != nil {
+			return nil, err
+		}
+		po.Data = data
+		po.PatchType = types.MergePatchType
+	}
+
+	// Only strategic merge patches can be applied in place
+	if po.InPlaceResize && po.PatchType != types.StrategicMergePatchType {
+		return nil, fmt.Errorf("in-place resize patch must be a strategic merge patch")
+	}
+
 	// If the patch is for the trial job itself, it cannot be applied (since the job won't exist until well after patches are applied)
 	if trial.IsTrialJobReference(t, &po.TargetRef) {
 		po.AttemptsRemaining = 0
@@ -107,3 +207,130 @@ func CreatePatchOperation(t *optimizev1beta2.Trial, p *optimizev1beta2.PatchTemp
 
 	return po, nil
 }
+
+// InPlaceResizePatch extracts the container resources from a strategic merge patch of a workload's pod template
+// and returns an equivalent patch for the pods themselves. The boolean return value is false if the patch contains
+// anything other then container resources.
+func InPlaceResizePatch(data []byte) ([]byte, bool) {
+	p := &struct {
+		Spec struct {
+			Template struct {
+				Spec struct {
+					Containers []map[string]json.RawMessage `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, false
+	}
+
+	// Make sure the patch does not contain anything we would miss by only patching the pods
+	var check interface{}
+	if err := json.Unmarshal(data, &check); err != nil {
+		return nil, false
+	}
+	if !onlyPath(check, "spec", "template", "spec", "containers") {
+		return nil, false
+	}
+
+	containers := p.Spec.Template.Spec.Containers
+	if len(containers) == 0 {
+		return nil, false
+	}
+	for _, c := range containers {
+		if len(c) != 2 || c["name"] == nil || c["resources"] == nil {
+			return nil, false
+		}
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"containers": containers},
+	})
+	if err != nil {
+		return nil, false
+	}
+	return result, true
+}
+
+// InPlaceRestorePatch returns a strategic merge patch of a workload's pod template which reverts the container
+// resources changed by the supplied in-place resize patch back to the values from the current workload. The boolean
+// return value is false if the patch cannot be resized in place or the workload is missing one of the containers.
+func InPlaceRestorePatch(data []byte, workload map[string]interface{}) ([]byte, bool) {
+	if _, ok := InPlaceResizePatch(data); !ok {
+		return nil, false
+	}
+
+	p := &struct {
+		Spec struct {
+			Template struct {
+				Spec struct {
+					Containers []struct {
+						Name      string                            `json:"name"`
+						Resources map[string]map[string]interface{} `json:"resources"`
+					} `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, false
+	}
+
+	templateContainers, _, _ := unstructured.NestedSlice(workload, "spec", "template", "spec", "containers")
+	var containers []interface{}
+	for _, c := range p.Spec.Template.Spec.Containers {
+		original := findContainer(templateContainers, c.Name)
+		if original == nil {
+			return nil, false
+		}
+
+		// Keys that were not originally present are removed by setting them to null
+		resources := make(map[string]interface{}, len(c.Resources))
+		for rt, rl := range c.Resources {
+			restored := make(map[string]interface{}, len(rl))
+			for k := range rl {
+				v, _, _ := unstructured.NestedFieldNoCopy(original, "resources", rt, k)
+				restored[k] = v
+			}
+			resources[rt] = restored
+		}
+
+		containers = append(containers, map[string]interface{}{"name": c.Name, "resources": resources})
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{"containers": containers},
+			},
+		},
+	})
+	if err != nil {
+		return nil, false
+	}
+	return result, true
+}
+
+// findContainer returns the container with the supplied name from a list of unstructured containers.
+func findContainer(containers []interface{}, name string) map[string]interface{} {
+	for i := range containers {
+		if c, ok := containers[i].(map[string]interface{}); ok && c["name"] == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// onlyPath checks that the supplied path is the only content of the value.
+func onlyPath(v interface{}, path ...string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return false
+	}
+	next, ok := m[path[0]]
+	return ok && onlyPath(next, path[1:]...)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/thestormforge/optimize-controller/v2/internal/template"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestPatch(t *testing.T) {
@@ -137,6 +138,51 @@ metadata:
 			},
 			attemptsRemaining: defaultAttemptsRemaining,
 		},
+		{
+			desc:  "patchjson6902",
+			trial: trial,
+			patchTemplate: &optimizev1beta2.PatchTemplate{
+				Type: optimizev1beta2.PatchJSON6902,
+				Operations: []optimizev1beta2.JSONPatchOperation{
+					{Op: "replace", Path: "/spec/template/spec/containers/0/imagePullPolicy", Value: "Always"},
+				},
+				TargetRef: &corev1.ObjectReference{
+					Kind:       "Deployment",
+					APIVersion: "apps/v1",
+					Name:       "myapp",
+					Namespace:  "default",
+				},
+			},
+			attemptsRemaining: defaultAttemptsRemaining,
+		},
+		{
+			desc:  "patchjson6902 w/o targetref",
+			trial: trial,
+			patchTemplate: &optimizev1beta2.PatchTemplate{
+				Type: optimizev1beta2.PatchJSON6902,
+				Operations: []optimizev1beta2.JSONPatchOperation{
+					{Op: "replace", Path: "/spec/template/spec/containers/0/imagePullPolicy", Value: "Always"},
+				},
+			},
+			expectedRenderError: true,
+		},
+		{
+			desc:  "patchjson6902 invalid op",
+			trial: trial,
+			patchTemplate: &optimizev1beta2.PatchTemplate{
+				Type: optimizev1beta2.PatchJSON6902,
+				Operations: []optimizev1beta2.JSONPatchOperation{
+					{Op: "merge", Path: "/spec"},
+				},
+				TargetRef: &corev1.ObjectReference{
+					Kind:       "Deployment",
+					APIVersion: "apps/v1",
+					Name:       "myapp",
+					Namespace:  "default",
+				},
+			},
+			expectedRenderError: true,
+		},
 		{
 			desc:  "patchTrial - json",
 			trial: trial,
@@ -228,3 +274,253 @@ metadata:
 		})
 	}
 }
+
+func TestCreatePatchOperation_CustomResource(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mytrial",
+			Namespace: "default",
+		},
+	}
+	ref := &corev1.ObjectReference{
+		Kind:       "Widget",
+		APIVersion: "example.com/v1",
+		Name:       "mywidget",
+		Namespace:  "default",
+	}
+
+	po, err := CreatePatchOperation(trial, &optimizev1beta2.PatchTemplate{}, ref, []byte(`{"spec":{"items":[{"$patch":"replace"},{"name":"z"}],"limit":null}}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, types.MergePatchType, po.PatchType)
+		assert.JSONEq(t, `{"spec":{"items":[{"name":"z"}],"limit":null}}`, string(po.Data))
+	}
+}
+
+func TestRenderEndpoint(t *testing.T) {
+	te := template.New()
+
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mytrial",
+			Namespace: "default",
+		},
+	}
+
+	testCases := []struct {
+		desc          string
+		endpoint      optimizev1beta2.TrialEndpoint
+		expectedRef   *corev1.ObjectReference
+		expectedData  string
+		expectedError string
+	}{
+		{
+			desc: "deployment",
+			endpoint: optimizev1beta2.TrialEndpoint{
+				Name:          "PROMETHEUS_URL",
+				Value:         "http://optimize-{{ .Trial.Namespace }}-prometheus:9090",
+				TargetRef:     corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "myapp"},
+				ContainerName: "app",
+			},
+			expectedRef:  &corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "myapp", Namespace: "default"},
+			expectedData: `{"spec":{"template":{"spec":{"containers":[{"env":[{"name":"PROMETHEUS_URL","value":"http://optimize-default-prometheus:9090"}],"name":"app"}]}}}}`,
+		},
+		{
+			desc: "pod",
+			endpoint: optimizev1beta2.TrialEndpoint{
+				Name:          "DOWNSTREAM",
+				Value:         "http://mock-{{ .Trial.Name }}:8080",
+				TargetRef:     corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Name: "myapp", Namespace: "other"},
+				ContainerName: "app",
+			},
+			expectedRef:  &corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Name: "myapp", Namespace: "other"},
+			expectedData: `{"spec":{"containers":[{"env":[{"name":"DOWNSTREAM","value":"http://mock-mytrial:8080"}],"name":"app"}]}}`,
+		},
+		{
+			desc: "cron job",
+			endpoint: optimizev1beta2.TrialEndpoint{
+				Name:          "DOWNSTREAM",
+				Value:         "http://mock-{{ .Trial.Name }}:8080",
+				TargetRef:     corev1.ObjectReference{APIVersion: "batch/v1beta1", Kind: "CronJob", Name: "myjob"},
+				ContainerName: "job",
+			},
+			expectedRef:  &corev1.ObjectReference{APIVersion: "batch/v1beta1", Kind: "CronJob", Name: "myjob", Namespace: "default"},
+			expectedData: `{"spec":{"jobTemplate":{"spec":{"template":{"spec":{"containers":[{"env":[{"name":"DOWNSTREAM","value":"http://mock-mytrial:8080"}],"name":"job"}]}}}}}}`,
+		},
+		{
+			desc: "config map",
+			endpoint: optimizev1beta2.TrialEndpoint{
+				Name:      "database.url",
+				Value:     "postgres://db-{{ .Trial.Name }}:5432",
+				TargetRef: corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Name: "myapp-config"},
+			},
+			expectedRef:  &corev1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Name: "myapp-config", Namespace: "default"},
+			expectedData: `{"data":{"database.url":"postgres://db-mytrial:5432"}}`,
+		},
+		{
+			desc: "missing container",
+			endpoint: optimizev1beta2.TrialEndpoint{
+				Name:      "DOWNSTREAM",
+				Value:     "http://mock:8080",
+				TargetRef: corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "myapp"},
+			},
+			expectedError: `invalid endpoint "DOWNSTREAM": missing container name`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ref, data, err := RenderEndpoint(te, trial, &tc.endpoint)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedRef, ref)
+				assert.JSONEq(t, tc.expectedData, string(data))
+			}
+		})
+	}
+}
+
+func TestRenderRestart(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mytrial",
+			Namespace: "default",
+		},
+	}
+
+	testCases := []struct {
+		desc          string
+		ref           corev1.ObjectReference
+		expectedRef   *corev1.ObjectReference
+		expectedData  string
+		expectedError string
+	}{
+		{
+			desc:         "deployment",
+			ref:          corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "myapp"},
+			expectedRef:  &corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "myapp", Namespace: "default"},
+			expectedData: `{"spec":{"template":{"metadata":{"annotations":{"stormforge.io/restart-trial":"mytrial"}}}}}`,
+		},
+		{
+			desc:         "cron job",
+			ref:          corev1.ObjectReference{APIVersion: "batch/v1beta1", Kind: "CronJob", Name: "myjob"},
+			expectedRef:  &corev1.ObjectReference{APIVersion: "batch/v1beta1", Kind: "CronJob", Name: "myjob", Namespace: "default"},
+			expectedData: `{"spec":{"jobTemplate":{"spec":{"template":{"metadata":{"annotations":{"stormforge.io/restart-trial":"mytrial"}}}}}}}`,
+		},
+		{
+			desc:          "missing name",
+			ref:           corev1.ObjectReference{APIVersion: "apps/v1", Kind: "StatefulSet"},
+			expectedError: "invalid restart reference: missing kind or name",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ref, data, err := RenderRestart(trial, &tc.ref)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedRef, ref)
+				assert.JSONEq(t, tc.expectedData, string(data))
+			}
+		})
+	}
+}
+
+func TestInPlaceResizePatch(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		data     string
+		expected string
+	}{
+		{
+			desc:     "resources only",
+			data:     `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"requests":{"cpu":"500m"}}}]}}}}`,
+			expected: `{"spec":{"containers":[{"name":"app","resources":{"requests":{"cpu":"500m"}}}]}}`,
+		},
+		{
+			desc: "replicas",
+			data: `{"spec":{"replicas":3,"template":{"spec":{"containers":[{"name":"app","resources":{"requests":{"cpu":"500m"}}}]}}}}`,
+		},
+		{
+			desc: "environment",
+			data: `{"spec":{"template":{"spec":{"containers":[{"name":"app","env":[{"name":"A","value":"1"}],"resources":{"requests":{"cpu":"500m"}}}]}}}}`,
+		},
+		{
+			desc: "not json",
+			data: `[{"op":"add","path":"/spec/replicas","value":3}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			actual, ok := InPlaceResizePatch([]byte(tc.data))
+			if tc.expected == "" {
+				assert.False(t, ok)
+				return
+			}
+			if assert.True(t, ok) {
+				assert.JSONEq(t, tc.expected, string(actual))
+			}
+		})
+	}
+}
+
+func TestInPlaceRestorePatch(t *testing.T) {
+	workload := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":      "app",
+							"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": "100m"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		desc     string
+		data     string
+		expected string
+	}{
+		{
+			desc:     "original value",
+			data:     `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"requests":{"cpu":"500m"}}}]}}}}`,
+			expected: `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"requests":{"cpu":"100m"}}}]}}}}`,
+		},
+		{
+			desc:     "added value",
+			data:     `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":"1"},"requests":{"cpu":"500m"}}}]}}}}`,
+			expected: `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":null},"requests":{"cpu":"100m"}}}]}}}}`,
+		},
+		{
+			desc: "missing container",
+			data: `{"spec":{"template":{"spec":{"containers":[{"name":"sidecar","resources":{"requests":{"cpu":"500m"}}}]}}}}`,
+		},
+		{
+			desc: "not resizable",
+			data: `{"spec":{"replicas":3}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			actual, ok := InPlaceRestorePatch([]byte(tc.data), workload)
+			if tc.expected == "" {
+				assert.False(t, ok)
+				return
+			}
+			if assert.True(t, ok) {
+				assert.JSONEq(t, tc.expected, string(actual))
+			}
+		})
+	}
+}
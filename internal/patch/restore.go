@@ -0,0 +1,70 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patch
+
+import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+)
+
+const (
+	// Finalizer is used to ensure patched objects are restored before the trial is deleted
+	Finalizer = "patchFinalizer.stormforge.io"
+)
+
+// RestorePatch returns a merge patch which reverts the patched object back to the original object. Only the labels
+// and annotations of the object metadata are considered and the status is ignored; a nil result indicates that the
+// objects are already equivalent.
+func RestorePatch(original, patched map[string]interface{}) ([]byte, error) {
+	originalData, err := json.Marshal(restorableContent(original))
+	if err != nil {
+		return nil, err
+	}
+
+	patchedData, err := json.Marshal(restorableContent(patched))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsonpatch.CreateMergePatch(patchedData, originalData)
+	if err != nil || string(data) == "{}" {
+		return nil, err
+	}
+	return data, nil
+}
+
+// restorableContent returns a shallow copy of the object with the server managed fields removed.
+func restorableContent(obj map[string]interface{}) map[string]interface{} {
+	content := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		switch k {
+		case "apiVersion", "kind", "status":
+			// These fields are never restored
+		case "metadata":
+			if md, ok := v.(map[string]interface{}); ok {
+				content[k] = map[string]interface{}{
+					"labels":      md["labels"],
+					"annotations": md["annotations"],
+				}
+			}
+		default:
+			content[k] = v
+		}
+	}
+	return content
+}
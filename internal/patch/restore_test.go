@@ -0,0 +1,81 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestorePatch(t *testing.T) {
+	cases := []struct {
+		desc     string
+		original string
+		patched  string
+		expected string
+	}{
+		{
+			desc:     "unchanged",
+			original: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","resourceVersion":"1"},"data":{"a":"1"}}`,
+			patched:  `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","resourceVersion":"2"},"data":{"a":"1"}}`,
+		},
+		{
+			desc:     "changed value",
+			original: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"},"data":{"a":"1","b":"2"}}`,
+			patched:  `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"},"data":{"a":"3","b":"2"}}`,
+			expected: `{"data":{"a":"1"}}`,
+		},
+		{
+			desc:     "added value",
+			original: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"},"data":{"a":"1"}}`,
+			patched:  `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"},"data":{"a":"1","b":"2"}}`,
+			expected: `{"data":{"b":null}}`,
+		},
+		{
+			desc:     "containers",
+			original: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test","generation":1},"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"app"}]}}},"status":{"replicas":1}}`,
+			patched:  `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test","generation":2},"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":"1"}}}]}}},"status":{"replicas":2}}`,
+			expected: `{"spec":{"template":{"spec":{"containers":[{"name":"app"}]}}}}`,
+		},
+		{
+			desc:     "annotations",
+			original: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","annotations":{"a":"1"}}}`,
+			patched:  `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","annotations":{"a":"1","b":"2"},"labels":{"c":"3"}}}`,
+			expected: `{"metadata":{"annotations":{"b":null},"labels":null}}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			original := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal([]byte(c.original), &original))
+			patched := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal([]byte(c.patched), &patched))
+
+			actual, err := RestorePatch(original, patched)
+			if assert.NoError(t, err) {
+				if c.expected == "" {
+					assert.Nil(t, actual)
+				} else {
+					assert.JSONEq(t, c.expected, string(actual))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pricing resolves the prices used to compute the cost of the resources requested by a trial.
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ConfigMapName is the name of the config map holding a custom price sheet, it is read from the namespace of the trial
+// being measured (the experiment namespace unless a namespace template or selector places trials elsewhere).
+const ConfigMapName = "optimize-pricing"
+
+// Config map keys
+const (
+	// ProviderKey is the name of the bundled price sheet to start from
+	ProviderKey = "provider"
+	// CPUKey is the price of one CPU for one hour
+	CPUKey = "cpu"
+	// MemoryKey is the price of one GB of memory for one hour
+	MemoryKey = "memory"
+	// NodeTypesKey is a YAML map of instance types to "cpu" and "memory" prices; node type prices are matched using the
+	// `kube_pod_info` and `kube_node_labels` metrics which the built-in Prometheus only collects when it is granted cluster
+	// scoped permissions, otherwise an external Prometheus that collects them is required
+	NodeTypesKey = "nodeTypes"
+)
+
+// Rates are the hourly prices of compute resources.
+type Rates struct {
+	// CPU is the price of one CPU for one hour.
+	CPU float64 `json:"cpu"`
+	// Memory is the price of one GB of memory for one hour.
+	Memory float64 `json:"memory"`
+}
+
+// PriceSheet describes the cost of compute resources.
+type PriceSheet struct {
+	// Rates are the prices used for resources that are not scheduled on a specific node type.
+	Rates
+	// NodeTypes are the prices of resources scheduled on nodes with a specific instance type.
+	NodeTypes map[string]Rates
+}
+
+// NOTE: The bundled prices are derived from the on-demand list prices of general purpose instances in US regions
+// (AWS m5, GCP n1-standard, Azure Dsv3) and are only intended to produce realistic relative costs.
+var providers = map[string]PriceSheet{
+	"aws":   {Rates: Rates{CPU: 0.0312, Memory: 0.0042}},
+	"gcp":   {Rates: Rates{CPU: 0.031611, Memory: 0.004237}},
+	"azure": {Rates: Rates{CPU: 0.0336, Memory: 0.0045}},
+}
+
+// DefaultProvider is the name of the price sheet used when one is not configured.
+const DefaultProvider = "aws"
+
+// Default returns the bundled price sheet for the named cloud provider.
+func Default(provider string) (*PriceSheet, error) {
+	if provider == "" {
+		provider = DefaultProvider
+	}
+	ps, ok := providers[strings.ToLower(provider)]
+	if !ok {
+		names := make([]string, 0, len(providers))
+		for name := range providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown pricing provider %q, must be one of: %s", provider, strings.Join(names, ", "))
+	}
+	return &ps, nil
+}
+
+// FromConfigMap returns the price sheet described by a config map. Prices that are not specified are taken from the
+// bundled price sheet of the configured provider.
+func FromConfigMap(cm *corev1.ConfigMap) (*PriceSheet, error) {
+	ps, err := Default(cm.Data[ProviderKey])
+	if err != nil {
+		return nil, err
+	}
+
+	if ps.CPU, err = parsePrice(cm.Data, CPUKey, ps.CPU); err != nil {
+		return nil, err
+	}
+	if ps.Memory, err = parsePrice(cm.Data, MemoryKey, ps.Memory); err != nil {
+		return nil, err
+	}
+
+	if nodeTypes := cm.Data[NodeTypesKey]; nodeTypes != "" {
+		if err := yaml.UnmarshalStrict([]byte(nodeTypes), &ps.NodeTypes); err != nil {
+			return nil, fmt.Errorf("invalid %s pricing: %w", NodeTypesKey, err)
+		}
+		for name, r := range ps.NodeTypes {
+			if r.CPU < 0 || r.Memory < 0 {
+				return nil, fmt.Errorf("invalid pricing for node type %q: prices must not be negative", name)
+			}
+		}
+	}
+
+	return ps, nil
+}
+
+// parsePrice returns the price stored under the specified key, or the default value if it is not present.
+func parsePrice(data map[string]string, key string, defaultValue float64) (float64, error) {
+	s := strings.TrimSpace(data[key])
+	if s == "" {
+		return defaultValue, nil
+	}
+
+	price, err := strconv.ParseFloat(s, 64)
+	if err != nil || price < 0 {
+		return 0, fmt.Errorf("invalid %s price: %q", key, s)
+	}
+	return price, nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the supplied price sheet.
+func NewContext(ctx context.Context, ps *PriceSheet) context.Context {
+	return context.WithValue(ctx, contextKey{}, ps)
+}
+
+// FromContext returns the price sheet carried by the context, or nil if there is no price sheet.
+func FromContext(ctx context.Context) *PriceSheet {
+	ps, _ := ctx.Value(contextKey{}).(*PriceSheet)
+	return ps
+}
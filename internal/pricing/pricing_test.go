@@ -0,0 +1,94 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestFromConfigMap(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		data     map[string]string
+		expected *PriceSheet
+		err      bool
+	}{
+		{
+			desc:     "defaults",
+			expected: &PriceSheet{Rates: Rates{CPU: 0.0312, Memory: 0.0042}},
+		},
+		{
+			desc:     "provider",
+			data:     map[string]string{ProviderKey: "GCP"},
+			expected: &PriceSheet{Rates: Rates{CPU: 0.031611, Memory: 0.004237}},
+		},
+		{
+			desc:     "override",
+			data:     map[string]string{ProviderKey: "azure", CPUKey: "0.05"},
+			expected: &PriceSheet{Rates: Rates{CPU: 0.05, Memory: 0.0045}},
+		},
+		{
+			desc: "node types",
+			data: map[string]string{
+				CPUKey:       "0.03",
+				MemoryKey:    "0.004",
+				NodeTypesKey: "m5.large:\n  cpu: 0.048\n  memory: 0.006\n",
+			},
+			expected: &PriceSheet{
+				Rates:     Rates{CPU: 0.03, Memory: 0.004},
+				NodeTypes: map[string]Rates{"m5.large": {CPU: 0.048, Memory: 0.006}},
+			},
+		},
+		{
+			desc: "unknown provider",
+			data: map[string]string{ProviderKey: "example"},
+			err:  true,
+		},
+		{
+			desc: "invalid price",
+			data: map[string]string{MemoryKey: "-1"},
+			err:  true,
+		},
+		{
+			desc: "invalid node types",
+			data: map[string]string{NodeTypesKey: "m5.large:\n  gpu: 1\n"},
+			err:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ps, err := FromConfigMap(&corev1.ConfigMap{Data: tc.data})
+			if tc.err {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, ps)
+			}
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, FromContext(ctx))
+
+	ps := &PriceSheet{Rates: Rates{CPU: 1, Memory: 1}}
+	assert.Same(t, ps, FromContext(NewContext(ctx, ps)))
+}
@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/scale/scheme/extensionsv1beta1"
+	"k8s.io/client-go/util/jsonpath"
 	"k8s.io/kubectl/pkg/polymorphichelpers"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -126,6 +127,37 @@ func (r *ReadinessChecker) CheckConditions(ctx context.Context, obj *unstructure
 	return "", true, nil
 }
 
+// CheckJSONPath evaluates a JSON path expression (with or without curly braces) against the specified object and
+// compares the result to the expected value; multiple results are joined with a space before comparison.
+func (r *ReadinessChecker) CheckJSONPath(obj *unstructured.Unstructured, path, value string) (string, bool, error) {
+	expr := path
+	if !strings.HasPrefix(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+
+	jp := jsonpath.New("readiness").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return "", false, &ReadinessError{error: "invalid JSON path", Reason: "InvalidJSONPath", Message: err.Error()}
+	}
+
+	results, err := jp.FindResults(obj.UnstructuredContent())
+	if err != nil {
+		return "", false, err
+	}
+
+	var values []string
+	for i := range results {
+		for j := range results[i] {
+			values = append(values, fmt.Sprintf("%v", results[i][j].Interface()))
+		}
+	}
+
+	if actual := strings.Join(values, " "); actual != value {
+		return fmt.Sprintf("%s is %q, expected %q", path, actual, value), false, nil
+	}
+	return "", true, nil
+}
+
 // alwaysTrue does not actually check any status and just returns true
 func (r *ReadinessChecker) alwaysTrue(obj *unstructured.Unstructured) (string, corev1.ConditionStatus, error) {
 	_ = obj.GroupVersionKind() // Just to be consistent with everyone else
@@ -251,3 +251,75 @@ func TestReadinessChecker_CheckConditions(t *testing.T) {
 		})
 	}
 }
+
+func TestReadinessChecker_CheckJSONPath(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "DatabaseCluster",
+		"status": map[string]interface{}{
+			"phase":         "Ready",
+			"readyReplicas": int64(3),
+			"members":       []interface{}{map[string]interface{}{"role": "primary"}, map[string]interface{}{"role": "replica"}},
+		},
+	}}
+
+	cases := []struct {
+		desc  string
+		path  string
+		value string
+		msg   string
+		ready bool
+		err   bool
+	}{
+		{
+			desc:  "string",
+			path:  "{.status.phase}",
+			value: "Ready",
+			ready: true,
+		},
+		{
+			desc:  "relaxed",
+			path:  ".status.readyReplicas",
+			value: "3",
+			ready: true,
+		},
+		{
+			desc:  "multiple results",
+			path:  "{.status.members[*].role}",
+			value: "primary replica",
+			ready: true,
+		},
+		{
+			desc:  "not ready",
+			path:  "{.status.phase}",
+			value: "Running",
+			msg:   `{.status.phase} is "Ready", expected "Running"`,
+		},
+		{
+			desc:  "missing",
+			path:  "{.status.observedGeneration}",
+			value: "1",
+			msg:   `{.status.observedGeneration} is "", expected "1"`,
+		},
+		{
+			desc:  "invalid",
+			path:  "{.status[}",
+			value: "1",
+			err:   true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rc := &ReadinessChecker{}
+			msg, ready, err := rc.CheckJSONPath(obj, c.path, c.value)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.ready, ready)
+				assert.Equal(t, c.msg, msg)
+			}
+		})
+	}
+}
@@ -17,8 +17,11 @@ limitations under the License.
 package scan
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"os/exec"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/thestormforge/konjure/pkg/konjure"
@@ -78,13 +81,81 @@ func WithKubectlRESTConfig(restConfig *rest.Config) KubectlOption {
 	}
 }
 
+// WithKubectlPath always runs the supplied kubectl binary instead of the in-process kubectl.
+func WithKubectlPath(path string) KubectlOption {
+	return func(k interface{}) error {
+		switch k := k.(type) {
+
+		case *kubectlCommand:
+			k.path = path
+
+		}
+		return nil
+	}
+}
+
+// WithKubectlTimeout limits the amount of time a single kubectl invocation can take.
+func WithKubectlTimeout(timeout time.Duration) KubectlOption {
+	return func(k interface{}) error {
+		switch k := k.(type) {
+
+		case *kubectlCommand:
+			k.timeout = timeout
+
+		case *minikubectl:
+			k.timeout = timeout
+
+		}
+		return nil
+	}
+}
+
+// kubectlCommand holds the options for running kubectl as a subprocess.
+type kubectlCommand struct {
+	path    string
+	timeout time.Duration
+}
+
+// output runs the command and returns its standard output, killing it if it exceeds the timeout.
+func (c *kubectlCommand) output(cmd *exec.Cmd) ([]byte, error) {
+	if c.timeout <= 0 {
+		return cmd.Output()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	tcmd := exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
+	tcmd.Env = cmd.Env
+	tcmd.Dir = cmd.Dir
+	tcmd.Stdin = cmd.Stdin
+	tcmd.Stderr = cmd.Stderr
+	out, err := tcmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("kubectl timed out after %s", c.timeout)
+	}
+	return out, err
+}
+
 func kubectl(opts ...KubectlOption) func(cmd *exec.Cmd) ([]byte, error) {
 	return func(cmd *exec.Cmd) ([]byte, error) {
+		c := &kubectlCommand{}
+		for _, opt := range opts {
+			if err := opt(c); err != nil {
+				return nil, err
+			}
+		}
+
+		// An explicitly configured kubectl binary is always used
+		if c.path != "" {
+			cmd.Path = c.path
+		}
+
 		// If LookPath found the kubectl binary, it is safer to just use it. That
 		// way the cluster version doesn't need to be in the compatibility range of
 		// whatever client-go we were compiled with.
 		if cmd.Path != "kubectl" {
-			return cmd.Output()
+			return c.output(cmd)
 		}
 
 		// Kustomize has a clown. We have minikubectl.
@@ -107,7 +178,7 @@ func kubectl(opts ...KubectlOption) func(cmd *exec.Cmd) ([]byte, error) {
 		// If complete fails, assume it was because we asked too much of minikubectl
 		// and we should just run the real thing in a subprocess
 		if err := k.Complete(flags.Args()); err != nil {
-			return cmd.Output()
+			return c.output(cmd)
 		}
 
 		// Run minikubectl with the remaining arguments
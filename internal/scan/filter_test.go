@@ -0,0 +1,71 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scan
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubectl(t *testing.T) {
+	echo, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo is not available")
+	}
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep is not available")
+	}
+
+	cases := []struct {
+		desc          string
+		opts          []KubectlOption
+		args          []string
+		expected      string
+		expectedError string
+	}{
+		{
+			desc:     "kubectl path",
+			opts:     []KubectlOption{WithKubectlPath(echo)},
+			args:     []string{"get", "pods"},
+			expected: "get pods\n",
+		},
+		{
+			desc:          "timeout",
+			opts:          []KubectlOption{WithKubectlPath(sleep), WithKubectlTimeout(10 * time.Millisecond)},
+			args:          []string{"5"},
+			expectedError: "kubectl timed out after 10ms",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			// The command path is unresolved, just like when kubectl is not on the PATH
+			cmd := &exec.Cmd{Path: "kubectl", Args: append([]string{"kubectl"}, c.args...)}
+			out, err := kubectl(c.opts...)(cmd)
+			if c.expectedError != "" {
+				assert.EqualError(t, err, c.expectedError)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, string(out))
+			}
+		})
+	}
+}
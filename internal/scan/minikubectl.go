@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/spf13/pflag"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -44,6 +45,7 @@ type minikubectl struct {
 	IgnoreNotFound       bool
 
 	restConfig *rest.Config
+	timeout    time.Duration
 	lock       sync.Mutex
 }
 
@@ -127,11 +129,20 @@ func (k *minikubectl) ToRESTConfig() (*rest.Config, error) {
 	defer k.lock.Unlock()
 
 	var err error
-	if k.restConfig != nil {
+	if k.restConfig == nil {
 		k.restConfig, err = k.ToRawKubeConfigLoader().ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
 
+	if k.timeout > 0 {
+		config := rest.CopyConfig(k.restConfig)
+		config.Timeout = k.timeout
+		return config, nil
 	}
-	return k.restConfig, err
+
+	return k.restConfig, nil
 }
 
 // ToDiscoveryClient returns an in-memory cached discovery instance instead of an on-disk cached instance.
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// Tuesday
+	now := time.Date(2021, 6, 1, 12, 30, 15, 0, time.UTC)
+
+	cases := []struct {
+		desc     string
+		spec     string
+		expected time.Time
+	}{
+		{
+			desc:     "every minute",
+			spec:     "* * * * *",
+			expected: time.Date(2021, 6, 1, 12, 31, 0, 0, time.UTC),
+		},
+		{
+			desc:     "hourly",
+			spec:     "@hourly",
+			expected: time.Date(2021, 6, 1, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "monthly",
+			spec:     "@monthly",
+			expected: time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "step",
+			spec:     "*/20 * * * *",
+			expected: time.Date(2021, 6, 1, 12, 40, 0, 0, time.UTC),
+		},
+		{
+			desc:     "list and range",
+			spec:     "0 2,4 * 1-3 *",
+			expected: time.Date(2022, 1, 1, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "day of week",
+			spec:     "0 9 * * 5",
+			expected: time.Date(2021, 6, 4, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "sunday as seven",
+			spec:     "0 0 * * 7",
+			expected: time.Date(2021, 6, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "day of month or day of week",
+			spec:     "0 0 15 * 3",
+			expected: time.Date(2021, 6, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc: "impossible",
+			spec: "0 0 30 2 *",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s, err := Parse(c.spec)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, s.Next(now))
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@often"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := Parse(spec)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/experiment"
+	"github.com/thestormforge/optimize-controller/v2/internal/trial"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+)
+
+// SelectorLimit is a concurrency limit applied to the experiments matching a label selector.
+type SelectorLimit struct {
+	// Selector is used to match experiment labels.
+	Selector k8slabels.Selector
+	// Limit is the maximum number of matching experiments which can be active at once.
+	Limit int
+}
+
+// ConcurrencyLimits restricts the number of experiments and trials that can be active at the same time.
+// A limit of zero is treated as unlimited.
+type ConcurrencyLimits struct {
+	// MaxExperiments is the maximum number of active experiments in the cluster.
+	MaxExperiments int
+	// MaxExperimentsPerNamespace is the maximum number of active experiments in any single namespace.
+	MaxExperimentsPerNamespace int
+	// MaxExperimentsBySelector are the maximum number of active experiments matching a label selector.
+	MaxExperimentsBySelector []SelectorLimit
+	// MaxTrials is the maximum number of active trials in the cluster.
+	MaxTrials int
+	// MaxTrialsPerNamespace is the maximum number of active trials in any single namespace.
+	MaxTrialsPerNamespace int
+}
+
+// NewConcurrencyLimits returns concurrency limits using the environment, nil is returned if no limits are configured.
+func NewConcurrencyLimits() (*ConcurrencyLimits, error) {
+	cl := &ConcurrencyLimits{}
+	var configured bool
+
+	for env, limit := range map[string]*int{
+		"STORMFORGE_MAX_ACTIVE_EXPERIMENTS":               &cl.MaxExperiments,
+		"STORMFORGE_MAX_ACTIVE_EXPERIMENTS_PER_NAMESPACE": &cl.MaxExperimentsPerNamespace,
+		"STORMFORGE_MAX_ACTIVE_TRIALS":                    &cl.MaxTrials,
+		"STORMFORGE_MAX_ACTIVE_TRIALS_PER_NAMESPACE":      &cl.MaxTrialsPerNamespace,
+	} {
+		value, ok := os.LookupEnv(env)
+		if !ok || value == "" {
+			continue
+		}
+
+		l, err := parseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", env, err)
+		}
+
+		*limit = l
+		configured = true
+	}
+
+	if value, ok := os.LookupEnv("STORMFORGE_MAX_ACTIVE_EXPERIMENTS_BY_SELECTOR"); ok && value != "" {
+		sl, err := parseSelectorLimits(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid STORMFORGE_MAX_ACTIVE_EXPERIMENTS_BY_SELECTOR: %w", err)
+		}
+
+		cl.MaxExperimentsBySelector = sl
+		configured = len(sl) > 0 || configured
+	}
+
+	if !configured {
+		return nil, nil
+	}
+	return cl, nil
+}
+
+// parseLimit parses a single positive limit value.
+func parseLimit(value string) (int, error) {
+	l, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || l <= 0 {
+		return 0, fmt.Errorf("limit must be a positive integer, got %q", value)
+	}
+	return l, nil
+}
+
+// parseSelectorLimits parses a semicolon separated list of `selector:limit` pairs.
+func parseSelectorLimits(value string) ([]SelectorLimit, error) {
+	var result []SelectorLimit
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		// Split on the last colon, the selector syntax never uses colons but this allows for extra whitespace
+		pos := strings.LastIndex(item, ":")
+		if pos < 0 {
+			return nil, fmt.Errorf("expected 'selector:limit', got %q", item)
+		}
+
+		sel, err := k8slabels.Parse(item[:pos])
+		if err != nil {
+			return nil, err
+		}
+
+		l, err := parseLimit(item[pos+1:])
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, SelectorLimit{Selector: sel, Limit: l})
+	}
+	return result, nil
+}
+
+// Admit determines if the supplied experiment is allowed to start another trial given the current state of the
+// cluster. Experiments are admitted in the order they were created, experiments which already have active trials
+// are always admitted ahead of waiting experiments so running work is never starved. When the experiment is not
+// admitted, the returned message describes the limit which is being enforced.
+func (cl *ConcurrencyLimits) Admit(exp *optimizev1beta2.Experiment, experiments []optimizev1beta2.Experiment, trials []optimizev1beta2.Trial) (bool, string) {
+	if cl == nil {
+		return true, ""
+	}
+
+	// Count active trials by namespace and by experiment
+	var clusterTrials int
+	namespaceTrials := make(map[string]int)
+	experimentTrials := make(map[string]int)
+	for i := range trials {
+		t := &trials[i]
+		if !trial.IsActive(t) || trial.IsAbandoned(t) {
+			continue
+		}
+
+		clusterTrials++
+		namespaceTrials[t.Namespace]++
+		if ref := t.ExperimentNamespacedName(); ref.Name != "" {
+			experimentTrials[ref.String()]++
+		}
+	}
+
+	// Check the trial limits first, they apply regardless of experiment admission
+	if cl.MaxTrials > 0 && clusterTrials >= cl.MaxTrials {
+		return false, fmt.Sprintf("Waiting for one of %d active trials in the cluster to finish", clusterTrials)
+	}
+	if cl.MaxTrialsPerNamespace > 0 && namespaceTrials[exp.Namespace] >= cl.MaxTrialsPerNamespace {
+		return false, fmt.Sprintf("Waiting for one of %d active trials in namespace %q to finish", namespaceTrials[exp.Namespace], exp.Namespace)
+	}
+
+	// Order the candidate experiments: running experiments first, then oldest first
+	candidates := make([]*optimizev1beta2.Experiment, 0, len(experiments))
+	for i := range experiments {
+		if isCandidate(&experiments[i]) {
+			candidates = append(candidates, &experiments[i])
+		}
+	}
+	running := func(e *optimizev1beta2.Experiment) bool {
+		return experimentTrials[e.Namespace+"/"+e.Name] > 0
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if ri, rj := running(ci), running(cj); ri != rj {
+			return ri
+		}
+		if !ci.CreationTimestamp.Equal(&cj.CreationTimestamp) {
+			return ci.CreationTimestamp.Before(&cj.CreationTimestamp)
+		}
+		if ci.Namespace != cj.Namespace {
+			return ci.Namespace < cj.Namespace
+		}
+		return ci.Name < cj.Name
+	})
+
+	// Admit experiments in order until we reach the one being checked
+	var clusterExperiments int
+	namespaceExperiments := make(map[string]int)
+	selectorExperiments := make([]int, len(cl.MaxExperimentsBySelector))
+	for _, c := range candidates {
+		msg := cl.checkExperiment(c, clusterExperiments, namespaceExperiments[c.Namespace], selectorExperiments)
+		isExp := c.Namespace == exp.Namespace && c.Name == exp.Name
+		if isExp {
+			if running(c) {
+				return true, ""
+			}
+			return msg == "", msg
+		}
+
+		if msg != "" && !running(c) {
+			continue
+		}
+
+		clusterExperiments++
+		namespaceExperiments[c.Namespace]++
+		for i, sl := range cl.MaxExperimentsBySelector {
+			if sl.Selector.Matches(k8slabels.Set(c.Labels)) {
+				selectorExperiments[i]++
+			}
+		}
+	}
+
+	// The experiment was not a candidate (e.g. it is finished), do not block it
+	return true, ""
+}
+
+// checkExperiment returns a non-empty message if admitting the experiment would exceed an experiment limit.
+func (cl *ConcurrencyLimits) checkExperiment(exp *optimizev1beta2.Experiment, clusterExperiments, namespaceExperiments int, selectorExperiments []int) string {
+	if cl.MaxExperiments > 0 && clusterExperiments >= cl.MaxExperiments {
+		return fmt.Sprintf("Waiting for one of %d active experiments in the cluster to finish", clusterExperiments)
+	}
+	if cl.MaxExperimentsPerNamespace > 0 && namespaceExperiments >= cl.MaxExperimentsPerNamespace {
+		return fmt.Sprintf("Waiting for one of %d active experiments in namespace %q to finish", namespaceExperiments, exp.Namespace)
+	}
+	for i, sl := range cl.MaxExperimentsBySelector {
+		if sl.Selector.Matches(k8slabels.Set(exp.Labels)) && selectorExperiments[i] >= sl.Limit {
+			return fmt.Sprintf("Waiting for one of %d active experiments matching %q to finish", selectorExperiments[i], sl.Selector.String())
+		}
+	}
+	return ""
+}
+
+// isCandidate checks to see if an experiment could be competing for an active slot.
+func isCandidate(exp *optimizev1beta2.Experiment) bool {
+	return exp.DeletionTimestamp.IsZero() &&
+		!experiment.IsFinished(exp) &&
+		exp.Replicas() > 0 &&
+		exp.GetAnnotations()[optimizev1beta2.AnnotationNextTrialURL] != "" &&
+		IsServerSyncEnabled(exp)
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseSelectorLimits(t *testing.T) {
+	cases := []struct {
+		desc     string
+		value    string
+		expected map[string]int
+		err      bool
+	}{
+		{
+			desc:     "single",
+			value:    "tier=staging:5",
+			expected: map[string]int{"tier=staging": 5},
+		},
+		{
+			desc:     "multiple",
+			value:    "tier=staging:5; team in (a,b):2;",
+			expected: map[string]int{"tier=staging": 5, "team in (a,b)": 2},
+		},
+		{
+			desc:  "missing limit",
+			value: "tier=staging",
+			err:   true,
+		},
+		{
+			desc:  "invalid limit",
+			value: "tier=staging:0",
+			err:   true,
+		},
+		{
+			desc:  "invalid selector",
+			value: "tier in staging:1",
+			err:   true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := parseSelectorLimits(c.value)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			limits := make(map[string]int, len(actual))
+			for _, sl := range actual {
+				limits[sl.Selector.String()] = sl.Limit
+			}
+			assert.Equal(t, c.expected, limits)
+		})
+	}
+}
+
+func TestConcurrencyLimits_Admit(t *testing.T) {
+	now := time.Now()
+	exp := func(namespace, name string, age time.Duration, labels map[string]string) optimizev1beta2.Experiment {
+		return optimizev1beta2.Experiment{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         namespace,
+				Name:              name,
+				Labels:            labels,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+				Annotations: map[string]string{
+					optimizev1beta2.AnnotationNextTrialURL: "http://example.com/next",
+				},
+			},
+		}
+	}
+	activeTrial := func(namespace, experimentName string) optimizev1beta2.Trial {
+		return optimizev1beta2.Trial{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      experimentName + "-001",
+				Labels:    map[string]string{optimizev1beta2.LabelExperiment: experimentName},
+			},
+		}
+	}
+	finished := exp("default", "finished", 4*time.Hour, nil)
+	finished.Status.Conditions = []optimizev1beta2.ExperimentCondition{
+		{Type: optimizev1beta2.ExperimentComplete, Status: corev1.ConditionTrue},
+	}
+
+	experiments := []optimizev1beta2.Experiment{
+		finished,
+		exp("default", "oldest", 3*time.Hour, map[string]string{"tier": "staging"}),
+		exp("other", "older", 2*time.Hour, map[string]string{"tier": "staging"}),
+		exp("default", "newest", 1*time.Hour, nil),
+	}
+
+	cases := []struct {
+		desc     string
+		limits   *ConcurrencyLimits
+		trials   []optimizev1beta2.Trial
+		name     string
+		expected bool
+	}{
+		{
+			desc:     "no limits",
+			name:     "newest",
+			expected: true,
+		},
+		{
+			desc:     "global first",
+			limits:   &ConcurrencyLimits{MaxExperiments: 1},
+			name:     "oldest",
+			expected: true,
+		},
+		{
+			desc:     "global queued",
+			limits:   &ConcurrencyLimits{MaxExperiments: 1},
+			name:     "older",
+			expected: false,
+		},
+		{
+			desc:     "global running first",
+			limits:   &ConcurrencyLimits{MaxExperiments: 1},
+			trials:   []optimizev1beta2.Trial{activeTrial("default", "newest")},
+			name:     "oldest",
+			expected: false,
+		},
+		{
+			desc:     "namespace other",
+			limits:   &ConcurrencyLimits{MaxExperimentsPerNamespace: 1},
+			name:     "older",
+			expected: true,
+		},
+		{
+			desc:     "namespace queued",
+			limits:   &ConcurrencyLimits{MaxExperimentsPerNamespace: 1},
+			name:     "newest",
+			expected: false,
+		},
+		{
+			desc:     "selector queued",
+			limits:   mustSelectorLimits(t, "tier=staging:1"),
+			name:     "older",
+			expected: false,
+		},
+		{
+			desc:     "selector unmatched",
+			limits:   mustSelectorLimits(t, "tier=staging:1"),
+			name:     "newest",
+			expected: true,
+		},
+		{
+			desc:     "trials global",
+			limits:   &ConcurrencyLimits{MaxTrials: 1},
+			trials:   []optimizev1beta2.Trial{activeTrial("other", "older")},
+			name:     "oldest",
+			expected: false,
+		},
+		{
+			desc:     "trials namespace",
+			limits:   &ConcurrencyLimits{MaxTrialsPerNamespace: 1},
+			trials:   []optimizev1beta2.Trial{activeTrial("other", "older")},
+			name:     "oldest",
+			expected: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var target *optimizev1beta2.Experiment
+			for i := range experiments {
+				if experiments[i].Name == c.name {
+					target = &experiments[i]
+				}
+			}
+			require.NotNil(t, target)
+
+			admitted, msg := c.limits.Admit(target, experiments, c.trials)
+			assert.Equal(t, c.expected, admitted)
+			if admitted {
+				assert.Empty(t, msg)
+			} else {
+				assert.NotEmpty(t, msg)
+			}
+		})
+	}
+}
+
+func mustSelectorLimits(t *testing.T, value string) *ConcurrencyLimits {
+	sl, err := parseSelectorLimits(value)
+	require.NoError(t, err)
+	return &ConcurrencyLimits{MaxExperimentsBySelector: sl}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+// RelationPreview is the link relation of the location used to upload rendered manifests for review.
+const RelationPreview = "https://stormforge.io/rel/preview"
+
+// PreviewAPI uploads the manifests rendered for a scenario so they can be reviewed before a run is approved.
+type PreviewAPI interface {
+	// UploadPreview stores the rendered YAML manifests at the supplied location.
+	UploadPreview(ctx context.Context, u string, manifests []byte) error
+}
+
+// NewPreviewAPI returns a preview API using the application service configuration.
+func NewPreviewAPI(ctx context.Context, uaComment string) (PreviewAPI, error) {
+	client, err := newClientFromConfig(ctx, uaComment, func(srv config.Server) string {
+		return strings.TrimSuffix(srv.API.ApplicationsEndpoint, "/v2/applications/")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &previewAPI{client: client}, nil
+}
+
+type previewAPI struct {
+	client api.Client
+}
+
+func (p *previewAPI) UploadPreview(ctx context.Context, u string, manifests []byte) error {
+	req, err := http.NewRequest(http.MethodPut, u, bytes.NewReader(manifests))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+
+	resp, body, err := p.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	default:
+		return api.NewUnexpectedError(resp, body)
+	}
+}
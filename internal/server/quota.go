@@ -0,0 +1,141 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experimentsv1alpha1 "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+const (
+	// trialQuotaRefreshInterval is the minimum amount of time between usage checks against the Experiments API.
+	trialQuotaRefreshInterval = 5 * time.Minute
+	// trialQuotaWarningRatio is the fraction of the quota that can be consumed before a warning is produced.
+	trialQuotaWarningRatio = 0.9
+)
+
+// TrialQuota tracks the number of trials consumed by the tenant against a locally configured limit.
+type TrialQuota struct {
+	// Limit is the total number of trials allowed by the subscription.
+	Limit int64
+
+	mu      sync.Mutex
+	used    int64
+	checked time.Time
+	warned  bool
+}
+
+// NewTrialQuota returns a trial quota using the limit from the environment, nil is returned if no limit is configured.
+func NewTrialQuota() (*TrialQuota, error) {
+	// NOTE: The Experiments API does not expose usage information so the limit must be configured locally
+	limit, ok := os.LookupEnv("STORMFORGE_TRIAL_QUOTA")
+	if !ok || limit == "" {
+		return nil, nil
+	}
+
+	l, err := strconv.ParseInt(limit, 10, 64)
+	if err != nil || l <= 0 {
+		return nil, fmt.Errorf("invalid trial quota %q", limit)
+	}
+
+	return &TrialQuota{Limit: l}, nil
+}
+
+// Refresh updates the number of used trials using the experiment observation counts. Checks against the
+// Experiments API are limited to avoid listing all of the experiments for every trial.
+func (q *TrialQuota) Refresh(ctx context.Context, expAPI experimentsv1alpha1.API, now time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if now.Sub(q.checked) < trialQuotaRefreshInterval {
+		return nil
+	}
+
+	l, err := expAPI.GetAllExperiments(ctx, experimentsv1alpha1.ExperimentListQuery{})
+	if err != nil {
+		return err
+	}
+
+	used := int64(0)
+	for {
+		for i := range l.Experiments {
+			used += l.Experiments[i].Observations
+		}
+
+		next := l.Link(api.RelationNext)
+		if next == "" {
+			break
+		}
+
+		l, err = expAPI.GetAllExperimentsByPage(ctx, next)
+		if err != nil {
+			return err
+		}
+	}
+
+	q.used, q.checked = used, now
+	return nil
+}
+
+// Record increments the number of used trials when an observation is reported between refreshes. Only reported
+// observations are counted so the local count is consistent with the observation counts from the Experiments API.
+func (q *TrialQuota) Record() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.used++
+}
+
+// Used returns the number of trials consumed across all of the tenant's experiments.
+func (q *TrialQuota) Used() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.used
+}
+
+// Remaining returns the number of trials left in the quota.
+func (q *TrialQuota) Remaining() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.used >= q.Limit {
+		return 0
+	}
+	return q.Limit - q.used
+}
+
+// IsLow checks to see if the quota is about to be exhausted.
+func (q *TrialQuota) IsLow() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return float64(q.used) >= float64(q.Limit)*trialQuotaWarningRatio
+}
+
+// ShouldWarn checks to see if the quota is about to be exhausted and a warning has not already been produced.
+func (q *TrialQuota) ShouldWarn() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	low := float64(q.used) >= float64(q.Limit)*trialQuotaWarningRatio
+	warn := low && !q.warned
+	q.warned = low
+	return warn
+}
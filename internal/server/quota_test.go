@@ -0,0 +1,65 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrialQuota(t *testing.T) {
+	cases := []struct {
+		desc              string
+		limit             int64
+		used              int64
+		expectedRemaining int64
+		expectedLow       bool
+	}{
+		{
+			desc:              "plenty",
+			limit:             100,
+			used:              10,
+			expectedRemaining: 90,
+		},
+		{
+			desc:              "almost exhausted",
+			limit:             100,
+			used:              90,
+			expectedRemaining: 10,
+			expectedLow:       true,
+		},
+		{
+			desc:              "exhausted",
+			limit:             100,
+			used:              120,
+			expectedRemaining: 0,
+			expectedLow:       true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			q := &TrialQuota{Limit: c.limit, used: c.used - 1}
+			q.Record()
+			assert.Equal(t, c.used, q.Used())
+			assert.Equal(t, c.expectedRemaining, q.Remaining())
+			assert.Equal(t, c.expectedLow, q.IsLow())
+			assert.Equal(t, c.expectedLow, q.ShouldWarn())
+			assert.False(t, q.ShouldWarn())
+		})
+	}
+}
@@ -39,6 +39,9 @@ const (
 	Finalizer = "serverFinalizer.stormforge.io"
 )
 
+// MetricWeightLabelPrefix is the prefix of the experiment labels used to record the relative weight of a metric.
+const MetricWeightLabelPrefix = "weight."
+
 var (
 	// nameRegexp is used to validate the experiment labels that are required to be names.
 	nameRegexp = regexp.MustCompile(`^[a-z\d](?:[-a-z\d]{0,61}[a-z\d])?$`)
@@ -60,12 +63,32 @@ func FromCluster(in *optimizev1beta2.Experiment) (experimentsv1alpha1.Experiment
 		}
 	}
 
+	// The optimizer does not support metric weights, record them as labels so they can be used to rank trials
+	for _, m := range in.Spec.Metrics {
+		if m.Weight != nil {
+			if out.Labels == nil {
+				out.Labels = make(map[string]string)
+			}
+			out.Labels[MetricWeightLabelPrefix+m.Name] = m.Weight.AsDec().String()
+		}
+	}
+
 	out.Optimization = nil
+	hasExperimentBudget := false
 	for _, o := range in.Spec.Optimization {
 		out.Optimization = append(out.Optimization, experimentsv1alpha1.Optimization{
 			Name:  o.Name,
 			Value: o.Value,
 		})
+		hasExperimentBudget = hasExperimentBudget || o.Name == "experimentBudget"
+	}
+
+	// Let the server enforce the trial budget since trials do not remain in the cluster indefinitely
+	if in.Spec.MaxTrials != nil && !hasExperimentBudget {
+		out.Optimization = append(out.Optimization, experimentsv1alpha1.Optimization{
+			Name:  "experimentBudget",
+			Value: strconv.FormatInt(int64(*in.Spec.MaxTrials), 10),
+		})
 	}
 
 	out.Parameters = parameters(in)
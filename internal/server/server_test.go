@@ -38,6 +38,7 @@ func TestFromCluster(t *testing.T) {
 	one := intstr.FromInt(1)
 	two := intstr.FromInt(2)
 	three := intstr.FromString("three")
+	twenty := int32(20)
 	now := time.Now()
 	cases := []struct {
 		desc     string
@@ -77,6 +78,35 @@ func TestFromCluster(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "max trials",
+			in: &optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					MaxTrials: &twenty,
+				},
+			},
+			out: &experimentsv1alpha1.Experiment{
+				Optimization: []experimentsv1alpha1.Optimization{
+					{Name: "experimentBudget", Value: "20"},
+				},
+			},
+		},
+		{
+			desc: "max trials with experiment budget",
+			in: &optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					MaxTrials: &twenty,
+					Optimization: []optimizev1beta2.Optimization{
+						{Name: "experimentBudget", Value: "10"},
+					},
+				},
+			},
+			out: &experimentsv1alpha1.Experiment{
+				Optimization: []experimentsv1alpha1.Optimization{
+					{Name: "experimentBudget", Value: "10"},
+				},
+			},
+		},
 		{
 			desc: "parameters",
 			in: &optimizev1beta2.Experiment{
@@ -215,6 +245,27 @@ func TestFromCluster(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "metric weights",
+			in: &optimizev1beta2.Experiment{
+				Spec: optimizev1beta2.ExperimentSpec{
+					Metrics: []optimizev1beta2.Metric{
+						{Name: "cost", Minimize: true, Weight: resource.NewScaledQuantity(7, -1)},
+						{Name: "latency", Minimize: true, Weight: resource.NewScaledQuantity(3, -1)},
+					},
+				},
+			},
+			out: &experimentsv1alpha1.Experiment{
+				Labels: map[string]string{
+					"weight.cost":    "0.7",
+					"weight.latency": "0.3",
+				},
+				Metrics: []experimentsv1alpha1.Metric{
+					{Name: "cost", Minimize: true},
+					{Name: "latency", Minimize: true},
+				},
+			},
+		},
 		{
 			desc: "baseline",
 			in: &optimizev1beta2.Experiment{
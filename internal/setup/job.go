@@ -60,6 +60,12 @@ func getImage(image string, pullPolicy corev1.PullPolicy) (string, corev1.PullPo
 	return Image, corev1.PullPolicy(ImagePullPolicy)
 }
 
+// DefaultImage returns the image used for setup tasks that do not specify one.
+func DefaultImage() string {
+	image, _ := getImage("", "")
+	return image
+}
+
 // NewJob returns a new setup job for either create or delete.
 func NewJob(t *optimizev1beta2.Trial, mode string) (*batchv1.Job, error) {
 	job := &batchv1.Job{}
@@ -90,6 +96,7 @@ func NewJob(t *optimizev1beta2.Trial, mode string) (*batchv1.Job, error) {
 
 	// Create containers for each of the setup tasks
 	for _, task := range t.Spec.SetupTasks {
+		helmPatch := task.HelmPatch && task.HelmChart != ""
 		if (mode == ModeCreate && task.SkipCreate) || (mode == ModeDelete && task.SkipDelete) {
 			continue
 		}
@@ -111,6 +118,13 @@ func NewJob(t *optimizev1beta2.Trial, mode string) (*batchv1.Job, error) {
 
 		if len(c.Args) == 0 {
 			c.Args = []string{mode}
+			if helmPatch {
+				// Helm patches are applied to the existing release resources which are restored instead of deleted
+				c.Args = []string{ModePatch}
+				if mode == ModeDelete {
+					c.Args = []string{ModeRestore}
+				}
+			}
 		}
 
 		if len(task.Command) > 0 && c.Image != "" {
@@ -126,6 +140,11 @@ func NewJob(t *optimizev1beta2.Trial, mode string) (*batchv1.Job, error) {
 		// Include the trial status as environment variables
 		c.Env = AppendStatusEnv(t, c.Env)
 
+		// Have the built-in Prometheus scrape the built-in exporters
+		if IsPrometheusSetupTask(&task) {
+			c.Env = AppendExportersEnv(t, c.Env)
+		}
+
 		// Add the configured volume mounts
 		c.VolumeMounts = append(c.VolumeMounts, task.VolumeMounts...)
 
@@ -134,6 +153,13 @@ func NewJob(t *optimizev1beta2.Trial, mode string) (*batchv1.Job, error) {
 		if helmConfig != nil {
 			te := template.New()
 
+			// Helm chart reference
+			repo, chart, version, err := te.RenderHelmChart(&task, t)
+			if err != nil {
+				return nil, err
+			}
+			helmConfig.Repo, helmConfig.Chart, helmConfig.Version = repo, chart, version
+
 			// Helm Values
 			for _, hv := range task.HelmValues {
 				hgv := helmGeneratorValue{
@@ -195,10 +221,6 @@ func NewJob(t *optimizev1beta2.Trial, mode string) (*batchv1.Job, error) {
 				}
 			}
 
-			if task.HelmRepository != "" {
-				helmConfig.Repo = task.HelmRepository
-			}
-
 			// Record the base64 encoded YAML representation in the environment
 			b, err := yaml.Marshal(helmConfig)
 			if err != nil {
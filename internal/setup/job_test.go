@@ -135,3 +135,60 @@ func TestNewJob(t *testing.T) {
 		})
 	}
 }
+
+func TestNewJob_HelmPatch(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: optimizev1beta2.TrialSpec{
+			SetupTasks: []optimizev1beta2.SetupTask{
+				{
+					Name:      "my-release",
+					HelmChart: "stable/postgresql",
+					HelmPatch: true,
+				},
+			},
+		},
+	}
+
+	j, err := setup.NewJob(trial, setup.ModeCreate)
+	if assert.NoError(t, err) && assert.Len(t, j.Spec.Template.Spec.Containers, 1) {
+		assert.Equal(t, []string{setup.ModePatch}, j.Spec.Template.Spec.Containers[0].Args)
+	}
+
+	// Helm patches are restored instead of deleted
+	j, err = setup.NewJob(trial, setup.ModeDelete)
+	if assert.NoError(t, err) && assert.Len(t, j.Spec.Template.Spec.Containers, 1) {
+		assert.Equal(t, []string{setup.ModeRestore}, j.Spec.Template.Spec.Containers[0].Args)
+	}
+}
+
+func TestNewJob_Exporters(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: optimizev1beta2.TrialSpec{
+			SetupTasks: []optimizev1beta2.SetupTask{
+				{
+					Name: "monitoring",
+					Args: []string{"prometheus", "$(MODE)"},
+				},
+				{
+					Name: "exporters",
+					Args: []string{"exporters", "$(MODE)"},
+				},
+			},
+		},
+	}
+
+	j, err := setup.NewJob(trial, setup.ModeCreate)
+	if assert.NoError(t, err) && assert.Len(t, j.Spec.Template.Spec.Containers, 2) {
+		// Only the built-in Prometheus is told to scrape the exporters
+		assert.Contains(t, j.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "EXPORTERS", Value: "true"})
+		assert.NotContains(t, j.Spec.Template.Spec.Containers[1].Env, corev1.EnvVar{Name: "EXPORTERS", Value: "true"})
+	}
+}
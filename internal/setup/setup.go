@@ -23,6 +23,7 @@ import (
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -31,6 +32,10 @@ const (
 	ModeCreate = "create"
 	// ModeDelete is the primary argument to the setup tools container when the task is deleting objects.
 	ModeDelete = "delete"
+	// ModePatch is the primary argument to the setup tools container when a Helm patch task is applying objects.
+	ModePatch = "patch"
+	// ModeRestore is the primary argument to the setup tools container when a Helm patch task is restoring objects.
+	ModeRestore = "restore"
 
 	// Initializer is used to paused the trial initialization for setup tasks.
 	Initializer = "setupInitializer.stormforge.io"
@@ -38,6 +43,27 @@ const (
 	Finalizer = "setupFinalizer.stormforge.io"
 )
 
+// HelmPatchRules are the permissions required by a Helm patch task to record the original release objects, apply the
+// re-rendered chart over them and wait for the patched workloads to roll out; charts which include other kinds of
+// objects need additional task rules.
+var HelmPatchRules = []rbacv1.PolicyRule{
+	{
+		Verbs:     []string{"get", "create", "update", "patch", "delete"},
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+	},
+	{
+		Verbs:     []string{"get", "update", "patch"},
+		APIGroups: []string{""},
+		Resources: []string{"secrets", "services", "serviceaccounts"},
+	},
+	{
+		Verbs:     []string{"get", "list", "watch", "update", "patch"},
+		APIGroups: []string{"apps"},
+		Resources: []string{"deployments", "statefulsets", "daemonsets"},
+	},
+}
+
 // UpdateStatus returns true if there are setup tasks.
 func UpdateStatus(t *optimizev1beta2.Trial, probeTime *metav1.Time) bool {
 	var needsCreate, needsDelete bool
@@ -144,6 +170,32 @@ func GetConditionStatus(j *batchv1.Job) (corev1.ConditionStatus, string) {
 	return corev1.ConditionFalse, ""
 }
 
+// maxLogLength is the maximum number of bytes of container logs included in a terminated container message.
+const maxLogLength = 1024
+
+// TerminatedContainerMessage returns a description of a failed setup task container, including the tail of its logs.
+func TerminatedContainerMessage(name string, state *corev1.ContainerStateTerminated, logs string) string {
+	msg := fmt.Sprintf("container %q exited with code %d", name, state.ExitCode)
+	if state.Reason != "" {
+		msg += fmt.Sprintf(" (%s)", state.Reason)
+	}
+
+	terminationMessage := strings.TrimSpace(state.Message)
+	if terminationMessage != "" {
+		msg += ": " + terminationMessage
+	}
+
+	logs = strings.TrimSpace(logs)
+	if len(logs) > maxLogLength {
+		logs = "..." + logs[len(logs)-maxLogLength:]
+	}
+	if logs != "" && logs != terminationMessage {
+		msg += "\n" + logs
+	}
+
+	return msg
+}
+
 // AppendAssignmentEnv appends an environment variable for each trial assignment.
 func AppendAssignmentEnv(t *optimizev1beta2.Trial, env []corev1.EnvVar) []corev1.EnvVar {
 	for _, a := range t.Spec.Assignments {
@@ -166,6 +218,17 @@ func AppendPrometheusEnv(t *optimizev1beta2.Trial, env []corev1.EnvVar) []corev1
 	return env
 }
 
+// AppendExportersEnv appends an environment variable to have the built-in Prometheus scrape the built-in exporters.
+func AppendExportersEnv(t *optimizev1beta2.Trial, env []corev1.EnvVar) []corev1.EnvVar {
+	for i := range t.Spec.SetupTasks {
+		if IsExportersSetupTask(&t.Spec.SetupTasks[i]) {
+			return append(env, corev1.EnvVar{Name: "EXPORTERS", Value: "true"})
+		}
+	}
+
+	return env
+}
+
 // AppendStatusEnv appends the trial status as environment variables.
 func AppendStatusEnv(t *optimizev1beta2.Trial, env []corev1.EnvVar) []corev1.EnvVar {
 	for i := range t.Status.Conditions {
@@ -192,3 +255,13 @@ func IsPrometheusSetupTask(st *optimizev1beta2.SetupTask) bool {
 
 	return argsTest || chartTest
 }
+
+// IsExportersSetupTask checks to see if the supplied setup task is for the built-in metrics exporters.
+func IsExportersSetupTask(st *optimizev1beta2.SetupTask) bool {
+	// Needs to have these arguments
+	argsTest := (len(st.Args) == 2 && st.Args[0] == "exporters" && st.Args[1] == "$(MODE)")
+	// Or be using this chart
+	chartTest := (st.HelmChart == "../exporters")
+
+	return argsTest || chartTest
+}
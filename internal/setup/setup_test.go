@@ -112,3 +112,35 @@ func TestGetTrialConditionType(t *testing.T) {
 		})
 	}
 }
+
+func TestTerminatedContainerMessage(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		state    corev1.ContainerStateTerminated
+		logs     string
+		expected string
+	}{
+		{
+			desc:     "exit code only",
+			state:    corev1.ContainerStateTerminated{ExitCode: 1},
+			expected: `container "test-create-seed" exited with code 1`,
+		},
+		{
+			desc:     "reason and logs",
+			state:    corev1.ContainerStateTerminated{ExitCode: 2, Reason: "Error"},
+			logs:     "loading data\nconnection refused\n",
+			expected: "container \"test-create-seed\" exited with code 2 (Error)\nloading data\nconnection refused",
+		},
+		{
+			desc:     "termination message matches logs",
+			state:    corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled", Message: "out of memory\n"},
+			logs:     "out of memory",
+			expected: `container "test-create-seed" exited with code 137 (OOMKilled): out of memory`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, setup.TerminatedContainerMessage("test-create-seed", &tc.state, tc.logs))
+		})
+	}
+}
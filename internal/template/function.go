@@ -17,7 +17,9 @@ limitations under the License.
 package template
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"text/template"
@@ -26,9 +28,13 @@ import (
 	"github.com/Masterminds/sprig/v3"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// ErrNoData is returned by template functions when the data needed to produce a value is not (yet) available
+var ErrNoData = errors.New("no data")
+
 // FuncMap returns the functions used for template evaluation
 func FuncMap() template.FuncMap {
 	f := sprig.TxtFuncMap()
@@ -36,20 +42,25 @@ func FuncMap() template.FuncMap {
 	delete(f, "expandenv")
 
 	extra := template.FuncMap{
-		"duration":          duration,
-		"percent":           percent,
-		"resourceRequests":  resourceRequests,
-		"indexResource":     indexResource,
-		"cpuUtilization":    cpuUtilization,
-		"memoryUtilization": memoryUtilization,
-		"cpuRequests":       cpuRequests,
-		"memoryRequests":    memoryRequests,
-		"GB":                gb,
-		"MB":                mb,
-		"KB":                kb,
-		"GiB":               gib,
-		"MiB":               mib,
-		"KiB":               kib,
+		"duration":           duration,
+		"percent":            percent,
+		"resourceRequests":   resourceRequests,
+		"startupTime":        startupTime,
+		"indexResource":      indexResource,
+		"resourceScale":      resourceScale,
+		"percentOf":          percentOf,
+		"memoryFromJavaHeap": memoryFromJavaHeap,
+		"cpuUtilization":     cpuUtilization,
+		"memoryUtilization":  memoryUtilization,
+		"cpuRequests":        cpuRequests,
+		"memoryRequests":     memoryRequests,
+		"cost":               cost,
+		"GB":                 gb,
+		"MB":                 mb,
+		"KB":                 kb,
+		"GiB":                gib,
+		"MiB":                mib,
+		"KiB":                kib,
 	}
 
 	for k, v := range extra {
@@ -74,18 +85,9 @@ func percent(value int32, percent int32) string {
 
 // resourceRequests uses a map of resource types to weights to calculate a weighted sum of the resource requests
 func resourceRequests(podList runtime.Object, weights string) (float64, error) {
-	var pods *corev1.PodList
-	if podList == nil {
-		return 0.0, fmt.Errorf("missing pod list, the metric type may be incorrect")
-	} else if p, ok := podList.(*corev1.PodList); ok {
-		pods = p
-	} else {
-		pods = &corev1.PodList{}
-		scheme := runtime.NewScheme()
-		_ = corev1.AddToScheme(scheme)
-		if err := scheme.Convert(podList, pods, nil); err != nil {
-			return 0.0, fmt.Errorf("unable to get pod list: %w", err)
-		}
+	pods, err := toPodList(podList)
+	if err != nil {
+		return 0.0, err
 	}
 
 	var totalResources float64
@@ -110,6 +112,78 @@ func resourceRequests(podList runtime.Object, weights string) (float64, error) {
 	return totalResources, nil
 }
 
+// startupTime returns the number of seconds it took the slowest pod created after the supplied time to become ready,
+// optionally only considering pods which match at least one of the supplied label selectors
+func startupTime(podList runtime.Object, since time.Time, selectors ...string) (float64, error) {
+	pods, err := toPodList(podList)
+	if err != nil {
+		return 0.0, err
+	}
+
+	var sel []labels.Selector
+	for _, s := range selectors {
+		ls, err := labels.Parse(s)
+		if err != nil {
+			return 0.0, err
+		}
+		sel = append(sel, ls)
+	}
+
+	var startup float64
+	var started, ready bool
+	for _, pod := range pods.Items {
+		if pod.CreationTimestamp.Time.Before(since) || !matchesAny(sel, pod.Labels) {
+			continue
+		}
+
+		started = true
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+				startup = math.Max(startup, duration(pod.CreationTimestamp.Time, c.LastTransitionTime.Time))
+				ready = true
+			}
+		}
+	}
+
+	if !started {
+		return 0.0, fmt.Errorf("no pods were created after %s: %w", since.UTC().Format(time.RFC3339), ErrNoData)
+	}
+	if !ready {
+		return 0.0, fmt.Errorf("no pods became ready")
+	}
+	return startup, nil
+}
+
+// matchesAny checks if the supplied labels match any of the selectors, an empty list of selectors matches everything
+func matchesAny(selectors []labels.Selector, l map[string]string) bool {
+	if len(selectors) == 0 {
+		return true
+	}
+	for _, s := range selectors {
+		if s.Matches(labels.Set(l)) {
+			return true
+		}
+	}
+	return false
+}
+
+// toPodList converts the supplied object into a pod list
+func toPodList(podList runtime.Object) (*corev1.PodList, error) {
+	if podList == nil {
+		return nil, fmt.Errorf("missing pod list, the metric type may be incorrect")
+	} else if p, ok := podList.(*corev1.PodList); ok {
+		return p, nil
+	}
+
+	pods := &corev1.PodList{}
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	if err := scheme.Convert(podList, pods, nil); err != nil {
+		return nil, fmt.Errorf("unable to get pod list: %w", err)
+	}
+	return pods, nil
+}
+
 // indexResource returns a quantity from a resource list.
 func indexResource(rl corev1.ResourceList, key string) *resource.Quantity {
 	// Solves two problems:
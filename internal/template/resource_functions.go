@@ -0,0 +1,120 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// javaHeapRatio is the fraction of the container memory used for the Java heap
+	javaHeapRatio = 0.75
+	// javaMinOverhead is the minimum amount of non-heap memory (in MiB) for a Java container
+	javaMinOverhead = 256
+)
+
+// resourceScale multiplies a number or resource quantity (e.g. "500m" or "1Gi") by a factor, the factor comes first
+// so the function can be used in a pipeline, e.g. `{{ .Values.cpu | resourceScale 1.5 }}`
+func resourceScale(factor interface{}, value interface{}) (string, error) {
+	f, err := toFloat(factor)
+	if err != nil {
+		return "", err
+	}
+
+	// Plain numbers are scaled as-is
+	if v, err := toFloat(value); err == nil {
+		return strconv.FormatFloat(v*f, 'f', -1, 64), nil
+	}
+
+	q, err := toQuantity(value)
+	if err != nil {
+		return "", err
+	}
+
+	// Binary quantities (i.e. memory) are rounded to whole units, decimal quantities (i.e. CPU) keep milli precision
+	if q.Format == resource.BinarySI {
+		return resource.NewQuantity(int64(math.Round(float64(q.Value())*f)), q.Format).String(), nil
+	}
+	return resource.NewMilliQuantity(int64(math.Round(float64(q.MilliValue())*f)), q.Format).String(), nil
+}
+
+// percentOf returns a percentage (0-100) of a number or resource quantity, e.g. `{{ .Values.memory | percentOf 75 }}`
+func percentOf(percent interface{}, value interface{}) (string, error) {
+	p, err := toFloat(percent)
+	if err != nil {
+		return "", err
+	}
+	return resourceScale(p/100, value)
+}
+
+// memoryFromJavaHeap returns the container memory (in MiB) required for a Java heap size such that the heap is 75% of
+// the container memory with at least 256Mi left for non-heap memory; numeric heap sizes are assumed to be in MiB
+func memoryFromJavaHeap(heap interface{}) (string, error) {
+	heapMiB, err := toFloat(heap)
+	if err != nil {
+		q, err := toQuantity(heap)
+		if err != nil {
+			return "", err
+		}
+		heapMiB = float64(q.Value()) / (1 << 20)
+	}
+
+	memory := math.Max(heapMiB/javaHeapRatio, heapMiB+javaMinOverhead)
+	return fmt.Sprintf("%.fMi", math.Ceil(memory)), nil
+}
+
+// toFloat converts a template value into a floating point number
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case float32:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("unable to convert %v (%T) to a number", v, v)
+	}
+}
+
+// toQuantity converts a template value into a resource quantity
+func toQuantity(v interface{}) (*resource.Quantity, error) {
+	switch q := v.(type) {
+	case *resource.Quantity:
+		return q, nil
+	case resource.Quantity:
+		return &q, nil
+	case string:
+		rq, err := resource.ParseQuantity(q)
+		if err != nil {
+			return nil, err
+		}
+		return &rq, nil
+	default:
+		return nil, fmt.Errorf("unable to convert %v (%T) to a quantity", v, v)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestResourceFunctions(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		Spec: optimizev1beta2.TrialSpec{
+			Assignments: []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromString("500m")},
+				{Name: "memory", Value: intstr.FromString("2Gi")},
+				{Name: "replicas", Value: intstr.FromInt(4)},
+				{Name: "heap", Value: intstr.FromInt(3072)},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc     string
+		patch    string
+		expected string
+	}{
+		{
+			desc:     "scale cpu",
+			patch:    `value: {{ .Values.cpu | resourceScale 1.5 }}`,
+			expected: `{"value":"750m"}`,
+		},
+		{
+			desc:     "scale memory",
+			patch:    `value: {{ .Values.memory | resourceScale 0.5 }}`,
+			expected: `{"value":"1Gi"}`,
+		},
+		{
+			desc:     "scale number",
+			patch:    `value: {{ .Values.replicas | resourceScale 2 }}`,
+			expected: `{"value":8}`,
+		},
+		{
+			desc:     "percent of memory",
+			patch:    `value: {{ .Values.memory | percentOf 75 }}`,
+			expected: `{"value":"1536Mi"}`,
+		},
+		{
+			desc:     "percent of number",
+			patch:    `value: {{ .Values.replicas | percentOf 50 }}`,
+			expected: `{"value":2}`,
+		},
+		{
+			desc:     "java heap",
+			patch:    `value: {{ memoryFromJavaHeap .Values.heap }}`,
+			expected: `{"value":"4096Mi"}`,
+		},
+		{
+			desc:     "small java heap",
+			patch:    `value: {{ memoryFromJavaHeap "256Mi" }}`,
+			expected: `{"value":"512Mi"}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := New().RenderPatch(&optimizev1beta2.PatchTemplate{Patch: c.patch}, trial)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, string(actual))
+			}
+		})
+	}
+}
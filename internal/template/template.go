@@ -18,16 +18,20 @@ package template
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"math"
+	"strings"
 	"text/template"
 	"time"
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/pricing"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
 )
 
 // PatchData represents a trial during patch evaluation
@@ -53,6 +57,8 @@ type MetricData struct {
 	Range string
 	// Trial assignments
 	Values map[string]interface{}
+	// Prices used to compute resource costs, the bundled defaults are used if nil
+	Pricing *pricing.PriceSheet
 }
 
 // Pods returns the metric target if available.
@@ -109,6 +115,8 @@ func newMetricData(t *optimizev1beta2.Trial, target runtime.Object) *MetricData
 // Engine is used to render Go text templates
 type Engine struct {
 	FuncMap template.FuncMap
+	// Pricing is the price sheet made available to metric queries
+	Pricing *pricing.PriceSheet
 }
 
 // New creates a new template engine
@@ -125,6 +133,10 @@ func New() *Engine {
 // RenderPatch returns the JSON representation of the supplied patch template (input can be a Go template that produces YAML)
 func (e *Engine) RenderPatch(patch *optimizev1beta2.PatchTemplate, trial *optimizev1beta2.Trial) ([]byte, error) {
 	data := newPatchData(trial)
+	if patch.Type == optimizev1beta2.PatchJSON6902 {
+		return e.renderJSONPatch(patch.Operations, data)
+	}
+
 	b, err := e.render("patch", patch.Patch, data) // TODO What should we use for patch template names? Something from the targetRef?
 	if err != nil {
 		return nil, err
@@ -132,6 +144,54 @@ func (e *Engine) RenderPatch(patch *optimizev1beta2.PatchTemplate, trial *optimi
 	return yaml.ToJSON(b.Bytes())
 }
 
+// renderJSONPatch returns the JSON patch document for the supplied list of operation templates
+func (e *Engine) renderJSONPatch(operations []optimizev1beta2.JSONPatchOperation, data interface{}) ([]byte, error) {
+	type jsonPatchOperation struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		From  string          `json:"from,omitempty"`
+		Value json.RawMessage `json:"value,omitempty"`
+	}
+
+	result := make([]jsonPatchOperation, 0, len(operations))
+	for i, op := range operations {
+		name := fmt.Sprintf("operation%d", i)
+		rop := jsonPatchOperation{Op: op.Op}
+
+		path, err := e.render(name+"Path", op.Path, data)
+		if err != nil {
+			return nil, err
+		}
+		rop.Path = path.String()
+
+		if op.From != "" {
+			from, err := e.render(name+"From", op.From, data)
+			if err != nil {
+				return nil, err
+			}
+			rop.From = from.String()
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			value, err := e.render(name+"Value", op.Value, data)
+			if err != nil {
+				return nil, err
+			}
+			if rop.Value, err = sigsyaml.YAMLToJSON(value.Bytes()); err != nil {
+				return nil, err
+			}
+		case "remove", "move", "copy":
+		default:
+			return nil, fmt.Errorf("invalid JSON patch operation %q", op.Op)
+		}
+
+		result = append(result, rop)
+	}
+
+	return json.Marshal(result)
+}
+
 // RenderHelmValue returns a rendered string of the supplied Helm value
 func (e *Engine) RenderHelmValue(helmValue *optimizev1beta2.HelmValue, trial *optimizev1beta2.Trial) (string, error) {
 	data := newPatchData(trial)
@@ -142,9 +202,54 @@ func (e *Engine) RenderHelmValue(helmValue *optimizev1beta2.HelmValue, trial *op
 	return b.String(), nil
 }
 
+// RenderHelmChart returns the rendered Helm repository, chart and version of the supplied setup task
+func (e *Engine) RenderHelmChart(task *optimizev1beta2.SetupTask, trial *optimizev1beta2.Trial) (string, string, string, error) {
+	data := newPatchData(trial)
+	var result [3]string
+	for i, v := range []string{task.HelmRepository, task.HelmChart, task.HelmChartVersion} {
+		b, err := e.render(task.Name, v, data)
+		if err != nil {
+			return "", "", "", err
+		}
+		result[i] = strings.TrimSpace(b.String())
+	}
+	return result[0], result[1], result[2], nil
+}
+
+// RenderEndpoint returns a rendered string of the supplied trial endpoint
+func (e *Engine) RenderEndpoint(endpoint *optimizev1beta2.TrialEndpoint, trial *optimizev1beta2.Trial) (string, error) {
+	data := newPatchData(trial)
+	b, err := e.render(endpoint.Name, endpoint.Value, data)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// RenderWorkflowParameter returns a rendered string of the supplied workflow parameter
+func (e *Engine) RenderWorkflowParameter(parameter *optimizev1beta2.WorkflowParameter, trial *optimizev1beta2.Trial) (string, error) {
+	data := newPatchData(trial)
+	b, err := e.render(parameter.Name, parameter.Value, data)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// RenderLabel returns a rendered string of the supplied trial label template
+func (e *Engine) RenderLabel(name, value string, trial *optimizev1beta2.Trial) (string, error) {
+	data := newPatchData(trial)
+	b, err := e.render(name, value, data)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
 // RenderMetricQueries returns the metric query and the metric error query
 func (e *Engine) RenderMetricQueries(metric *optimizev1beta2.Metric, trial *optimizev1beta2.Trial, target runtime.Object) (string, string, error) {
 	data := newMetricData(trial, target)
+	data.Pricing = e.Pricing
 	b1, err := e.render(metric.Name, metric.Query, data)
 	if err != nil {
 		return "", "", err
@@ -17,11 +17,13 @@ limitations under the License.
 package template
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/pricing"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -69,6 +71,34 @@ func TestEngine_RenderPatch(t *testing.T) {
 			},
 			expected: []byte(`{"spec":{"replicas":2}}`),
 		},
+
+		{
+			desc: "json6902 operations",
+			patchTemplate: optimizev1beta2.PatchTemplate{
+				Type: optimizev1beta2.PatchJSON6902,
+				Operations: []optimizev1beta2.JSONPatchOperation{
+					{Op: "replace", Path: "/spec/replicas", Value: "{{ .Values.replicas }}"},
+					{Op: "add", Path: "/spec/template/spec/containers/0/args/-", Value: "--workers={{ .Values.replicas }}"},
+					{Op: "add", Path: "/spec/template/metadata/labels", Value: "{ trial: {{ .Trial.Name }} }"},
+					{Op: "remove", Path: "/spec/strategy"},
+				},
+			},
+			trial: optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-001"},
+				Spec: optimizev1beta2.TrialSpec{
+					Assignments: []optimizev1beta2.Assignment{
+						{
+							Name:  "replicas",
+							Value: intstr.FromInt(2),
+						},
+					},
+				},
+			},
+			expected: []byte(`[{"op":"replace","path":"/spec/replicas","value":2},` +
+				`{"op":"add","path":"/spec/template/spec/containers/0/args/-","value":"--workers=2"},` +
+				`{"op":"add","path":"/spec/template/metadata/labels","value":{"trial":"test-001"}},` +
+				`{"op":"remove","path":"/spec/strategy"}]`),
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
@@ -107,6 +137,58 @@ func TestEngine_RenderHelmValue(t *testing.T) {
 	}
 }
 
+func TestEngine_RenderHelmChart(t *testing.T) {
+	eng := New()
+
+	cases := []struct {
+		desc            string
+		task            optimizev1beta2.SetupTask
+		trial           optimizev1beta2.Trial
+		expectedRepo    string
+		expectedChart   string
+		expectedVersion string
+	}{
+		{
+			desc: "static",
+			task: optimizev1beta2.SetupTask{
+				HelmRepository:   "https://charts.bitnami.com/bitnami",
+				HelmChart:        "redis",
+				HelmChartVersion: "12.7.4",
+			},
+			expectedRepo:    "https://charts.bitnami.com/bitnami",
+			expectedChart:   "redis",
+			expectedVersion: "12.7.4",
+		},
+		{
+			desc: "assignments",
+			task: optimizev1beta2.SetupTask{
+				HelmChart:        "{{ .Values.chart }}",
+				HelmChartVersion: `{{ if eq .Values.major "6" }}12.7.4{{ else }}11.3.4{{ end }}`,
+			},
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{
+					Assignments: []optimizev1beta2.Assignment{
+						{Name: "chart", Value: intstr.FromString("bitnami/redis")},
+						{Name: "major", Value: intstr.FromString("6")},
+					},
+				},
+			},
+			expectedChart:   "bitnami/redis",
+			expectedVersion: "12.7.4",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			repo, chart, version, err := eng.RenderHelmChart(&c.task, &c.trial)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expectedRepo, repo)
+				assert.Equal(t, c.expectedChart, chart)
+				assert.Equal(t, c.expectedVersion, version)
+			}
+		})
+	}
+}
+
 func TestEngine_RenderMetricQueries(t *testing.T) {
 	eng := New()
 	now := metav1.Now()
@@ -187,6 +269,48 @@ func TestEngine_RenderMetricQueries(t *testing.T) {
 			expectedQuery: "25010",
 		},
 
+		{
+			desc: "function startupTime",
+			metric: optimizev1beta2.Metric{
+				Name:  "testMetric",
+				Query: `{{startupTime .Target .Trial.CreationTimestamp.Time}}`,
+			},
+			trial: optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(now.Add(-time.Minute)),
+				},
+			},
+			target: &corev1.PodList{
+				Items: []corev1.Pod{
+					newReadyPod(now.Add(-2*time.Minute), 30*time.Second),
+					newReadyPod(now.Add(-50*time.Second), 12*time.Second),
+					newReadyPod(now.Add(-40*time.Second), 7*time.Second),
+				},
+			},
+			expectedQuery: "12",
+		},
+
+		{
+			desc: "function startupTime with selectors",
+			metric: optimizev1beta2.Metric{
+				Name:  "testMetric",
+				Query: `{{startupTime .Target .Trial.CreationTimestamp.Time "app=a" "app=b"}}`,
+			},
+			trial: optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(now.Add(-time.Minute)),
+				},
+			},
+			target: &corev1.PodList{
+				Items: []corev1.Pod{
+					withLabels(newReadyPod(now.Add(-50*time.Second), 12*time.Second), "app", "a"),
+					withLabels(newReadyPod(now.Add(-40*time.Second), 7*time.Second), "app", "b"),
+					withLabels(newReadyPod(now.Add(-30*time.Second), 20*time.Second), "app", "c"),
+				},
+			},
+			expectedQuery: "12",
+		},
+
 		{
 			desc: "function cpuUtilization with parameters",
 			metric: optimizev1beta2.Metric{
@@ -295,6 +419,24 @@ func TestEngine_RenderMetricQueries(t *testing.T) {
 			expectedQuery: expectedMemoryRequestsQueryWithParams,
 		},
 
+		{
+			desc: "function cost with parameters",
+			metric: optimizev1beta2.Metric{
+				Name:  "testMetric",
+				Query: `{{cost . "component=bob"}}`,
+			},
+			trial: optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+				Status: optimizev1beta2.TrialStatus{
+					StartTime:      &metav1.Time{Time: now.Add(-5 * time.Second)},
+					CompletionTime: &now,
+				},
+			},
+			expectedQuery: expectedCostQueryWithParams,
+		},
+
 		{
 			desc: "function gb",
 			metric: optimizev1beta2.Metric{
@@ -342,15 +484,72 @@ func TestEngine_RenderMetricQueries(t *testing.T) {
 	}
 }
 
+func TestEngine_RenderMetricQueriesCost(t *testing.T) {
+	eng := New()
+	eng.Pricing = &pricing.PriceSheet{
+		Rates: pricing.Rates{CPU: 0.03, Memory: 0.004},
+		NodeTypes: map[string]pricing.Rates{
+			"m5.large":  {CPU: 0.05, Memory: 0.004},
+			"m5.xlarge": {CPU: 0.03, Memory: 0.004},
+		},
+	}
+	now := metav1.Now()
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+		},
+		Status: optimizev1beta2.TrialStatus{
+			StartTime:      &metav1.Time{Time: now.Add(-5 * time.Second)},
+			CompletionTime: &now,
+		},
+	}
+
+	query, _, err := eng.RenderMetricQueries(&optimizev1beta2.Metric{Name: "testMetric", Query: `{{cost .}}`}, trial, nil)
+	if assert.NoError(t, err) {
+		assert.Contains(t, query, `) * 0.03`)
+		assert.Contains(t, query, `) / 1000000000 * 0.004`)
+		assert.Contains(t, query, `) * 0.02`)
+		// Only the CPU price of the "m5.large" node type differs from the defaults
+		assert.Equal(t, 1, strings.Count(query, `kube_node_labels{label_node_kubernetes_io_instance_type="m5.large"}`))
+		assert.NotContains(t, query, "m5.xlarge")
+	}
+}
+
+func newReadyPod(created time.Time, startup time.Duration) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{
+					Type:               corev1.PodReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(created.Add(startup)),
+				},
+			},
+		},
+	}
+}
+
+func withLabels(pod corev1.Pod, kv ...string) corev1.Pod {
+	pod.Labels = map[string]string{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		pod.Labels[kv[i]] = kv[i+1]
+	}
+	return pod
+}
+
 func TestEngine_RenderMetricQueriesFailures(t *testing.T) {
 	eng := New()
 	now := metav1.Now()
 
 	cases := []struct {
-		desc   string
-		metric optimizev1beta2.Metric
-		trial  optimizev1beta2.Trial
-		target runtime.Object
+		desc        string
+		metric      optimizev1beta2.Metric
+		trial       optimizev1beta2.Trial
+		target      runtime.Object
+		expectedErr error
 	}{
 		{
 			desc: "prometheus label key sanitize",
@@ -368,16 +567,55 @@ func TestEngine_RenderMetricQueriesFailures(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "function startupTime no restarted pods",
+			metric: optimizev1beta2.Metric{
+				Name:  "testMetric",
+				Query: `{{startupTime .Target .Trial.CreationTimestamp.Time "app=a"}}`,
+			},
+			trial: optimizev1beta2.Trial{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(now.Add(-time.Minute)),
+				},
+			},
+			target: &corev1.PodList{
+				Items: []corev1.Pod{
+					withLabels(newReadyPod(now.Add(-2*time.Minute), 30*time.Second), "app", "a"),
+					withLabels(newReadyPod(now.Add(-30*time.Second), 20*time.Second), "app", "b"),
+				},
+			},
+			expectedErr: ErrNoData,
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
 			_, _, err := eng.RenderMetricQueries(&c.metric, &c.trial, c.target)
 			assert.Error(t, err)
+			if c.expectedErr != nil {
+				assert.ErrorIs(t, err, c.expectedErr)
+			}
 		})
 	}
 }
 
 var (
+	expectedCostQueryWithParams = `
+scalar(
+  sum(
+    avg_over_time(kube_pod_container_resource_requests_cpu_cores[5s])
+    *
+    on (pod) group_left
+    max_over_time(kube_pod_labels{namespace="default",label_component="bob"}[5s])
+  ) * 0.0312
+  +
+  sum(
+    avg_over_time(kube_pod_container_resource_requests_memory_bytes[5s])
+    *
+    on (pod) group_left
+    max_over_time(kube_pod_labels{namespace="default",label_component="bob"}[5s])
+  ) / 1000000000 * 0.0042
+)`
+
 	expectedCPUUtilizationQueryWithParams = `
 scalar(
   round(
@@ -20,9 +20,12 @@ import (
 	"bytes"
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/thestormforge/optimize-controller/v2/internal/pricing"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 )
@@ -106,10 +109,121 @@ scalar(
 	return renderUtilization(data, labelSelectors, memoryResourcesQueryTemplate)
 }
 
+// cost returns the hourly cost of the resources requested by the matching pods using the configured price sheet.
+// Node type specific prices are applied as adjustments to the default price for pods scheduled on matching nodes.
+func cost(data MetricData, labelSelectors ...string) (string, error) {
+	costQueryTemplate := `
+scalar(
+  sum(
+    avg_over_time(kube_pod_container_resource_requests_cpu_cores[{{ .Range }}])
+    *
+    on (pod) group_left
+    max_over_time(kube_pod_labels{{ .MetricSelector }}[{{ .Range }}])
+  ) * {{ .CPUPrice }}
+  +
+  sum(
+    avg_over_time(kube_pod_container_resource_requests_memory_bytes[{{ .Range }}])
+    *
+    on (pod) group_left
+    max_over_time(kube_pod_labels{{ .MetricSelector }}[{{ .Range }}])
+  ) / 1000000000 * {{ .MemoryPrice }}
+{{- range .Adjustments }}
+  +
+  (
+    sum(
+      avg_over_time(kube_pod_container_resource_requests_{{ .Resource }}[{{ $.Range }}])
+      *
+      on (pod) group_left
+      max_over_time(kube_pod_labels{{ $.MetricSelector }}[{{ $.Range }}])
+      *
+      on (pod) group_left
+      max by (pod) (kube_pod_info{{ $.PodSelector }} * on (node) group_left max by (node) (kube_node_labels{label_node_kubernetes_io_instance_type="{{ .NodeType }}"}))
+    ){{ .Scale }} * {{ .Price }}
+    or vector(0)
+  )
+{{- end }}
+)`
+
+	prices := data.Pricing
+	if prices == nil {
+		var err error
+		if prices, err = pricing.Default(""); err != nil {
+			return "", err
+		}
+	}
+
+	metricSelector, err := podLabelsSelector(data, labelSelectors)
+	if err != nil {
+		return "", err
+	}
+
+	// Node type prices are expressed as a difference from the default prices
+	type adjustment struct {
+		NodeType, Resource, Scale, Price string
+	}
+	nodeTypes := make([]string, 0, len(prices.NodeTypes))
+	for name := range prices.NodeTypes {
+		nodeTypes = append(nodeTypes, name)
+	}
+	sort.Strings(nodeTypes)
+	var adjustments []adjustment
+	for _, name := range nodeTypes {
+		if d := prices.NodeTypes[name].CPU - prices.CPU; d != 0 {
+			adjustments = append(adjustments, adjustment{NodeType: name, Resource: "cpu_cores", Price: formatPrice(d)})
+		}
+		if d := prices.NodeTypes[name].Memory - prices.Memory; d != 0 {
+			adjustments = append(adjustments, adjustment{NodeType: name, Resource: "memory_bytes", Scale: " / 1000000000", Price: formatPrice(d)})
+		}
+	}
+
+	// Wrap the standard metric data with the selectors and pricing information
+	input := struct {
+		MetricData
+		MetricSelector string
+		PodSelector    string
+		CPUPrice       string
+		MemoryPrice    string
+		Adjustments    []adjustment
+	}{
+		MetricData:     data,
+		MetricSelector: metricSelector,
+		PodSelector:    fmt.Sprintf("{namespace=%q}", data.Trial.Namespace),
+		CPUPrice:       formatPrice(prices.CPU),
+		MemoryPrice:    formatPrice(prices.Memory),
+		Adjustments:    adjustments,
+	}
+
+	return renderQuery(costQueryTemplate, input)
+}
+
+// formatPrice formats a price for use in a query, dropping floating point noise from price differences.
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'g', 10, 64)
+}
+
 // https://github.com/prometheus/prometheus/blob/3240cf83f08e448e0b96a4a1f96c0e8b2d51cf61/util/strutil/strconv.go#L23
 var invalidLabelCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
 
 func renderUtilization(metricData MetricData, labelSelectors []string, query string) (string, error) {
+	metricSelector, err := podLabelsSelector(metricData, labelSelectors)
+	if err != nil {
+		return "", err
+	}
+
+	// Wrap the standard metric data with the additional MetricSelector
+	input := struct {
+		MetricData
+		MetricSelector string
+	}{
+		MetricData:     metricData,
+		MetricSelector: metricSelector,
+	}
+
+	return renderQuery(query, input)
+}
+
+// podLabelsSelector converts Kubernetes label selectors into a PromQL metric selector for `kube_pod_labels`.
+func podLabelsSelector(metricData MetricData, labelSelectors []string) (string, error) {
 	// We are accepting Kubernetes label selectors and using them to generate a PromQL metric selector
 	sel, err := labels.Parse(strings.Join(labelSelectors, ","))
 	if err != nil {
@@ -154,17 +268,11 @@ func renderUtilization(metricData MetricData, labelSelectors []string, query str
 	}
 
 	// Add the metric selector start and end markers (we know it will be non-empty because of the namespace)
-	metricSelector := fmt.Sprintf("{%s}", strings.Join(labelMatchers, ","))
-
-	// Wrap the standard metric data with the additional MetricSelector
-	input := struct {
-		MetricData
-		MetricSelector string
-	}{
-		MetricData:     metricData,
-		MetricSelector: metricSelector,
-	}
+	return fmt.Sprintf("{%s}", strings.Join(labelMatchers, ",")), nil
+}
 
+// renderQuery executes a query template from the source code.
+func renderQuery(query string, input interface{}) (string, error) {
 	// Panic if the query in the source code does not parse
 	tmpl := template.Must(template.New("query").Parse(query))
 
@@ -17,8 +17,11 @@ limitations under the License.
 package trial
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
@@ -31,6 +34,13 @@ import (
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 )
 
+// AssertionFailedExitCode is the exit code used by a trial run container to indicate one of its own assertions
+// was violated (this is the same exit code k6 uses for failed thresholds).
+const AssertionFailedExitCode = 99
+
+// PushTokenKey is the key of the push token secret which contains the token used to push metric values for a trial.
+const PushTokenKey = "token"
+
 // NewJob returns a new trial run job from the template on the trial
 func NewJob(t *optimizev1beta2.Trial) *batchv1.Job {
 	job := &batchv1.Job{}
@@ -91,6 +101,47 @@ func NewJob(t *optimizev1beta2.Trial) *batchv1.Job {
 	return job
 }
 
+// AddPushEnv exposes the endpoint used to push metric values for the trial to every container in the trial run job,
+// the token is read from the push token secret of the trial.
+func AddPushEnv(t *optimizev1beta2.Trial, job *batchv1.Job, pushURL string) {
+	url := fmt.Sprintf("%s/trials/%s/%s", strings.TrimSuffix(pushURL, "/"), t.Namespace, t.Name)
+	token := &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: PushTokenSecretName(t)},
+			Key:                  PushTokenKey,
+		},
+	}
+	for i := range job.Spec.Template.Spec.Containers {
+		c := &job.Spec.Template.Spec.Containers[i]
+		c.Env = append(c.Env,
+			corev1.EnvVar{Name: "STORMFORGE_PUSH_URL", Value: url},
+			corev1.EnvVar{Name: "STORMFORGE_PUSH_TOKEN", ValueFrom: token},
+		)
+	}
+}
+
+// PushTokenSecretName returns the name of the secret containing the token used to push metric values for the trial.
+func PushTokenSecretName(t *optimizev1beta2.Trial) string {
+	return t.Name + "-push-token"
+}
+
+// NewPushTokenSecret returns a new secret containing a random token used to push metric values for the trial.
+func NewPushTokenSecret(t *optimizev1beta2.Trial) (*corev1.Secret, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PushTokenSecretName(t),
+			Namespace: t.Namespace,
+			Labels:    map[string]string{optimizev1beta2.LabelTrial: t.Name},
+		},
+		Data: map[string][]byte{PushTokenKey: []byte(hex.EncodeToString(b))},
+	}, nil
+}
+
 func addDefaultContainer(t *optimizev1beta2.Trial, job *batchv1.Job) {
 	// Determine the sleep time
 	s := t.Spec.ApproximateRuntime
@@ -113,7 +164,7 @@ func addDefaultContainer(t *optimizev1beta2.Trial, job *batchv1.Job) {
 }
 
 func patchSelf(t *optimizev1beta2.Trial, job *batchv1.Job) *batchv1.Job {
-	// Look for patch operations that match this trial and apply them
+	// Look for patch operations that match this trial and apply them in order
 	for i := range t.Status.PatchOperations {
 		po := &t.Status.PatchOperations[i]
 		if IsTrialJobReference(t, &po.TargetRef) && po.PatchType == types.StrategicMergePatchType {
@@ -122,7 +173,7 @@ func patchSelf(t *optimizev1beta2.Trial, job *batchv1.Job) *batchv1.Job {
 				j := &batchv1.Job{}
 				if patched, err := strategicpatch.StrategicMergePatch(original, po.Data, j); err == nil {
 					if err := json.Unmarshal(patched, j); err == nil {
-						return j
+						job = j
 					}
 				}
 			}
@@ -130,3 +181,19 @@ func patchSelf(t *optimizev1beta2.Trial, job *batchv1.Job) *batchv1.Job {
 	}
 	return job
 }
+
+// AssertionFailure returns the termination message of the first container in the pod that exited because one of its
+// own assertions was violated.
+func AssertionFailure(pod *corev1.Pod) (string, bool) {
+	for i := range pod.Status.ContainerStatuses {
+		s := pod.Status.ContainerStatuses[i].State.Terminated
+		if s == nil || s.ExitCode != AssertionFailedExitCode {
+			continue
+		}
+		if s.Message != "" {
+			return strings.TrimSpace(s.Message), true
+		}
+		return "scenario assertion failed", true
+	}
+	return "", false
+}
@@ -99,3 +99,91 @@ func TestNewJob(t *testing.T) {
 		})
 	}
 }
+
+func TestAddPushEnv(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-001",
+			Namespace: "default",
+			UID:       "uid",
+		},
+	}
+
+	job := NewJob(trial)
+	AddPushEnv(trial, job, "http://optimize-push-service.stormforge-system:8090/")
+	if assert.Len(t, job.Spec.Template.Spec.Containers, 1) {
+		assert.Equal(t, []corev1.EnvVar{
+			{Name: "STORMFORGE_PUSH_URL", Value: "http://optimize-push-service.stormforge-system:8090/trials/default/test-001"},
+			{Name: "STORMFORGE_PUSH_TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "test-001-push-token"},
+				Key:                  "token",
+			}}},
+		}, job.Spec.Template.Spec.Containers[0].Env)
+	}
+}
+
+func TestNewPushTokenSecret(t *testing.T) {
+	trial := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-001",
+			Namespace: "default",
+		},
+	}
+
+	s1, err := NewPushTokenSecret(trial)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "test-001-push-token", s1.Name)
+		assert.Equal(t, "default", s1.Namespace)
+		assert.Len(t, s1.Data[PushTokenKey], 64)
+	}
+
+	s2, err := NewPushTokenSecret(trial)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, s1.Data[PushTokenKey], s2.Data[PushTokenKey])
+	}
+}
+
+func TestAssertionFailure(t *testing.T) {
+	cases := []struct {
+		desc            string
+		states          []corev1.ContainerState
+		expectedMessage string
+		expectedFailed  bool
+	}{
+		{
+			desc:   "running",
+			states: []corev1.ContainerState{{Running: &corev1.ContainerStateRunning{}}},
+		},
+		{
+			desc:   "other failure",
+			states: []corev1.ContainerState{{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "oops"}}},
+		},
+		{
+			desc: "assertion failed",
+			states: []corev1.ContainerState{
+				{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+				{Terminated: &corev1.ContainerStateTerminated{ExitCode: AssertionFailedExitCode, Message: "error rate 0.12 exceeds 0.05\n"}},
+			},
+			expectedMessage: "error rate 0.12 exceeds 0.05",
+			expectedFailed:  true,
+		},
+		{
+			desc:            "assertion failed without message",
+			states:          []corev1.ContainerState{{Terminated: &corev1.ContainerStateTerminated{ExitCode: AssertionFailedExitCode}}},
+			expectedMessage: "scenario assertion failed",
+			expectedFailed:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			pod := &corev1.Pod{}
+			for _, s := range c.states {
+				pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{State: s})
+			}
+
+			msg, failed := AssertionFailure(pod)
+			assert.Equal(t, c.expectedFailed, failed)
+			assert.Equal(t, c.expectedMessage, msg)
+		})
+	}
+}
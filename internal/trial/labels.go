@@ -0,0 +1,61 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"fmt"
+	"strings"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ApplyLabelTemplates evaluates the label templates of the trial and adds the results to the trial labels, the
+// rendered labels are returned so they can be reported to the server.
+func ApplyLabelTemplates(t *optimizev1beta2.Trial) (map[string]string, error) {
+	if len(t.Spec.LabelTemplates) == 0 {
+		return nil, nil
+	}
+
+	te := template.New()
+	labels := make(map[string]string, len(t.Spec.LabelTemplates))
+	for k, v := range t.Spec.LabelTemplates {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid trial label %q: %s", k, strings.Join(errs, "; "))
+		}
+
+		value, err := te.RenderLabel(k, v, t)
+		if err != nil {
+			return nil, err
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value %q for trial label %q: %s", value, k, strings.Join(errs, "; "))
+		}
+
+		labels[k] = value
+	}
+
+	if t.Labels == nil {
+		t.Labels = make(map[string]string, len(labels))
+	}
+	for k, v := range labels {
+		t.Labels[k] = v
+	}
+
+	return labels, nil
+}
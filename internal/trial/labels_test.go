@@ -0,0 +1,76 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestApplyLabelTemplates(t *testing.T) {
+	cases := []struct {
+		desc           string
+		labelTemplates map[string]string
+		expected       map[string]string
+		expectedLabels map[string]string
+		expectedErr    string
+	}{
+		{
+			desc:           "none",
+			expectedLabels: map[string]string{"existing": "true"},
+		},
+		{
+			desc: "derived category",
+			labelTemplates: map[string]string{
+				"memory-tier": `{{ if gt .Values.memory 2048 }}high{{ else }}low{{ end }}`,
+				"mode":        `{{ .Values.mode }}`,
+			},
+			expected:       map[string]string{"memory-tier": "high", "mode": "fast"},
+			expectedLabels: map[string]string{"existing": "true", "memory-tier": "high", "mode": "fast"},
+		},
+		{
+			desc:           "invalid value",
+			labelTemplates: map[string]string{"mode": `{{ .Values.mode }} mode`},
+			expectedErr:    `invalid value "fast mode" for trial label "mode"`,
+			expectedLabels: map[string]string{"existing": "true"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			tr := &optimizev1beta2.Trial{}
+			tr.Labels = map[string]string{"existing": "true"}
+			tr.Spec.LabelTemplates = c.labelTemplates
+			tr.Spec.Assignments = []optimizev1beta2.Assignment{
+				{Name: "memory", Value: intstr.FromInt(4096)},
+				{Name: "mode", Value: intstr.FromString("fast")},
+			}
+
+			labels, err := ApplyLabelTemplates(tr)
+			if c.expectedErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), c.expectedErr)
+				}
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, labels)
+			}
+			assert.Equal(t, c.expectedLabels, tr.Labels)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PipelineRunGroupVersionKind is the type of the Tekton PipelineRun used to run trials.
+var PipelineRunGroupVersionKind = schema.GroupVersionKind{Group: "tekton.dev", Version: "v1beta1", Kind: "PipelineRun"}
+
+// NewTektonPipelineRun returns a new trial run pipeline run referencing the pipeline on the trial
+func NewTektonPipelineRun(t *optimizev1beta2.Trial) (*unstructured.Unstructured, error) {
+	pr := &unstructured.Unstructured{}
+	pr.SetGroupVersionKind(PipelineRunGroupVersionKind)
+	pr.SetNamespace(t.Namespace)
+	pr.SetName(t.Name)
+	pr.SetLabels(map[string]string{
+		optimizev1beta2.LabelExperiment: t.ExperimentNamespacedName().Name,
+		optimizev1beta2.LabelTrial:      t.Name,
+		optimizev1beta2.LabelTrialRole:  "trialRun",
+	})
+
+	spec := t.Spec.TektonPipelineRun
+	if spec == nil {
+		return pr, nil
+	}
+
+	if err := unstructured.SetNestedField(pr.Object, spec.PipelineRef.Name, "spec", "pipelineRef", "name"); err != nil {
+		return nil, err
+	}
+
+	if spec.ServiceAccountName != "" {
+		if err := unstructured.SetNestedField(pr.Object, spec.ServiceAccountName, "spec", "serviceAccountName"); err != nil {
+			return nil, err
+		}
+	}
+
+	params, err := workflowParameters(t, spec.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) > 0 {
+		if err := unstructured.SetNestedSlice(pr.Object, params, "spec", "params"); err != nil {
+			return nil, err
+		}
+	}
+
+	return pr, nil
+}
+
+// PipelineRunStatus returns the start and completion times from the status of a Tekton PipelineRun, along with the
+// reason and message of the "Succeeded" condition if the pipeline run has failed
+func PipelineRunStatus(pr *unstructured.Unstructured) (startTime, completionTime *metav1.Time, failed bool, reason, message string) {
+	startTime = workflowTime(pr, "startTime")
+	completionTime = workflowTime(pr, "completionTime")
+
+	conditions, _, _ := unstructured.NestedSlice(pr.Object, "status", "conditions")
+	for _, c := range conditions {
+		cm, ok := c.(map[string]interface{})
+		if !ok || cm["type"] != "Succeeded" {
+			continue
+		}
+
+		if cm["status"] == "False" {
+			failed = true
+			reason, _, _ = unstructured.NestedString(cm, "reason")
+			message, _, _ = unstructured.NestedString(cm, "message")
+		}
+	}
+
+	return
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestNewTektonPipelineRun(t *testing.T) {
+	tr := &optimizev1beta2.Trial{}
+	tr.Namespace = "default"
+	tr.Name = "test-001"
+	tr.Spec.Assignments = []optimizev1beta2.Assignment{
+		{Name: "replicas", Value: intstr.FromInt(3)},
+	}
+	tr.Spec.TektonPipelineRun = &optimizev1beta2.TektonPipelineRunSpec{
+		PipelineRef:        optimizev1beta2.TektonPipelineRef{Name: "benchmark"},
+		ServiceAccountName: "tekton",
+	}
+
+	pr, err := NewTektonPipelineRun(tr)
+	require.NoError(t, err)
+
+	assert.Equal(t, "tekton.dev/v1beta1", pr.GetAPIVersion())
+	assert.Equal(t, "PipelineRun", pr.GetKind())
+	assert.Equal(t, "test-001", pr.GetName())
+	assert.Equal(t, "trialRun", pr.GetLabels()[optimizev1beta2.LabelTrialRole])
+
+	name, _, _ := unstructured.NestedString(pr.Object, "spec", "pipelineRef", "name")
+	assert.Equal(t, "benchmark", name)
+
+	sa, _, _ := unstructured.NestedString(pr.Object, "spec", "serviceAccountName")
+	assert.Equal(t, "tekton", sa)
+
+	params, _, _ := unstructured.NestedSlice(pr.Object, "spec", "params")
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "replicas", "value": "3"}}, params)
+}
+
+func TestPipelineRunStatus(t *testing.T) {
+	cases := []struct {
+		desc            string
+		status          map[string]interface{}
+		expectedFailed  bool
+		expectedReason  string
+		expectedMessage string
+		expectedFinish  bool
+	}{
+		{
+			desc: "running",
+			status: map[string]interface{}{
+				"startTime":  "2021-06-07T08:00:00Z",
+				"conditions": []interface{}{map[string]interface{}{"type": "Succeeded", "status": "Unknown", "reason": "Running"}},
+			},
+		},
+		{
+			desc: "succeeded",
+			status: map[string]interface{}{
+				"startTime":      "2021-06-07T08:00:00Z",
+				"completionTime": "2021-06-07T08:05:00Z",
+				"conditions":     []interface{}{map[string]interface{}{"type": "Succeeded", "status": "True", "reason": "Succeeded"}},
+			},
+			expectedFinish: true,
+		},
+		{
+			desc: "failed",
+			status: map[string]interface{}{
+				"startTime":      "2021-06-07T08:00:00Z",
+				"completionTime": "2021-06-07T08:05:00Z",
+				"conditions": []interface{}{map[string]interface{}{
+					"type":    "Succeeded",
+					"status":  "False",
+					"reason":  "Failed",
+					"message": "Tasks Completed: 1 (Failed: 1, Cancelled 0), Skipped: 0",
+				}},
+			},
+			expectedFailed:  true,
+			expectedReason:  "Failed",
+			expectedMessage: "Tasks Completed: 1 (Failed: 1, Cancelled 0), Skipped: 0",
+			expectedFinish:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			pr := &unstructured.Unstructured{Object: map[string]interface{}{"status": c.status}}
+
+			startTime, completionTime, failed, reason, message := PipelineRunStatus(pr)
+			assert.NotNil(t, startTime)
+			assert.Equal(t, c.expectedFinish, completionTime != nil)
+			assert.Equal(t, c.expectedFailed, failed)
+			assert.Equal(t, c.expectedReason, reason)
+			assert.Equal(t, c.expectedMessage, message)
+		})
+	}
+}
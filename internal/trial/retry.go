@@ -0,0 +1,167 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// FailureInfrastructure indicates a trial failed for reasons unrelated to its assignments
+	FailureInfrastructure = "Infrastructure"
+	// FailureAssignment indicates a trial failed because of its assignments
+	FailureAssignment = "Assignment"
+)
+
+// failureClasses maps the reasons recorded on the trial failed condition to a failure class; the reasons come from
+// the pod failures detected by the readiness checks and trial job, pod status reasons for pods removed by the node,
+// metric collection and stale trial recovery
+var failureClasses = map[string]string{
+	// Pods removed or never started by the node
+	"Evicted":                  FailureInfrastructure,
+	"Preempting":               FailureInfrastructure,
+	"NodeLost":                 FailureInfrastructure,
+	"NodeShutdown":             FailureInfrastructure,
+	"Shutdown":                 FailureInfrastructure,
+	"UnexpectedAdmissionError": FailureInfrastructure,
+	// Trials which could not be recovered (e.g. a container waiting on an image pull)
+	"Stale": FailureInfrastructure,
+	// Metric queries which failed for reasons other than the returned values
+	"MetricFailed": FailureInfrastructure,
+
+	// Patched pods which do not fit on a node (e.g. requests which are too large)
+	corev1.PodReasonUnschedulable: FailureAssignment,
+	// Patched containers which ran out of memory or exited with an error
+	"OOMKilled": FailureAssignment,
+	"Error":     FailureAssignment,
+}
+
+// FailureClass returns the class of failure for a failed trial, an empty string is returned if the trial has not
+// failed or the reason for the failure cannot be classified.
+func FailureClass(t *optimizev1beta2.Trial) string {
+	for _, c := range t.Status.Conditions {
+		if c.Type == optimizev1beta2.TrialFailed && c.Status == corev1.ConditionTrue {
+			return failureClasses[c.Reason]
+		}
+	}
+	return ""
+}
+
+// RetryAttempts returns the number of times the assignments of a trial have been retried for a class of failure.
+func RetryAttempts(t *optimizev1beta2.Trial, class string) int {
+	n, _ := strconv.Atoi(t.GetAnnotations()[retryAnnotation(class)])
+	return n
+}
+
+// IsRetry checks to see if the trial was created to retry the assignments of a failed trial.
+func IsRetry(t *optimizev1beta2.Trial) bool {
+	return RetryAttempts(t, FailureInfrastructure) > 0 || RetryAttempts(t, FailureAssignment) > 0
+}
+
+// NextRetry checks to see if a failed trial should be retried according to the supplied policy, returning the amount
+// of time remaining before the retry should be attempted.
+func NextRetry(t *optimizev1beta2.Trial, policy *optimizev1beta2.RetryPolicy, now time.Time) (time.Duration, bool) {
+	if policy == nil {
+		return 0, false
+	}
+
+	var fr *optimizev1beta2.FailureRetry
+	class := FailureClass(t)
+	switch class {
+	case FailureInfrastructure:
+		fr = policy.Infrastructure
+	case FailureAssignment:
+		fr = policy.Assignment
+	}
+
+	attempts := RetryAttempts(t, class)
+	if fr == nil || attempts >= int(fr.Limit) {
+		return 0, false
+	}
+
+	// The backoff doubles with each attempt and starts when the trial failed
+	backoff := time.Duration(fr.BackoffSeconds) * time.Second << uint(attempts)
+	for _, c := range t.Status.Conditions {
+		if c.Type == optimizev1beta2.TrialFailed {
+			if d := c.LastTransitionTime.Add(backoff).Sub(now); d > 0 {
+				return d, true
+			}
+		}
+	}
+	return 0, true
+}
+
+// PopulateRetry copies the assignments of a failed trial into a new trial and records the retry attempt.
+func PopulateRetry(t, failed *optimizev1beta2.Trial) {
+	class := FailureClass(failed)
+
+	t.Name = retryName(failed.Name, RetryAttempts(failed, FailureInfrastructure)+RetryAttempts(failed, FailureAssignment)+1)
+	t.GenerateName = ""
+	t.Spec.Assignments = append(t.Spec.Assignments[:0], failed.Spec.Assignments...)
+	t.Spec.TTLSecondsAfterFinished = failed.Spec.TTLSecondsAfterFinished
+	t.Spec.TTLSecondsAfterFailure = failed.Spec.TTLSecondsAfterFailure
+
+	if t.Labels == nil {
+		t.Labels = make(map[string]string, len(failed.Labels))
+	}
+	for k, v := range failed.Labels {
+		t.Labels[k] = v
+	}
+
+	if t.Annotations == nil {
+		t.Annotations = make(map[string]string, 3)
+	}
+	if u := failed.Annotations[optimizev1beta2.AnnotationReportTrialURL]; u != "" {
+		t.Annotations[optimizev1beta2.AnnotationReportTrialURL] = u
+	}
+	for _, c := range []string{FailureInfrastructure, FailureAssignment} {
+		n := RetryAttempts(failed, c)
+		if c == class {
+			n++
+		}
+		if n > 0 {
+			t.Annotations[retryAnnotation(c)] = strconv.Itoa(n)
+		}
+	}
+}
+
+// retryAnnotation returns the annotation used to count the retries for a class of failure.
+func retryAnnotation(class string) string {
+	switch class {
+	case FailureInfrastructure:
+		return optimizev1beta2.AnnotationInfrastructureRetries
+	case FailureAssignment:
+		return optimizev1beta2.AnnotationAssignmentRetries
+	default:
+		return ""
+	}
+}
+
+// retryName returns the name of a trial retrying the assignments of the named trial.
+func retryName(name string, attempt int) string {
+	if i := strings.LastIndex(name, "-retry"); i > 0 {
+		if _, err := strconv.Atoi(name[i+len("-retry"):]); err == nil {
+			name = name[:i]
+		}
+	}
+	return name + "-retry" + strconv.Itoa(attempt)
+}
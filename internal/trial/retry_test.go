@@ -0,0 +1,152 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestNextRetry(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	failed := func(reason string, ago time.Duration, annotations map[string]string) optimizev1beta2.Trial {
+		return optimizev1beta2.Trial{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+				{Type: optimizev1beta2.TrialFailed, Status: corev1.ConditionTrue, Reason: reason, LastTransitionTime: metav1.NewTime(now.Add(-ago))},
+			}},
+		}
+	}
+	policy := &optimizev1beta2.RetryPolicy{
+		Infrastructure: &optimizev1beta2.FailureRetry{Limit: 3, BackoffSeconds: 60},
+		Assignment:     &optimizev1beta2.FailureRetry{Limit: 1},
+	}
+
+	cases := []struct {
+		desc          string
+		trial         optimizev1beta2.Trial
+		policy        *optimizev1beta2.RetryPolicy
+		expectedRetry bool
+		expectedAfter time.Duration
+	}{
+		{
+			desc:  "no policy",
+			trial: failed("ImagePullBackOff", time.Hour, nil),
+		},
+		{
+			desc:   "not failed",
+			policy: policy,
+		},
+		{
+			desc:   "unclassified",
+			trial:  failed("BackoffLimitExceeded", time.Hour, nil),
+			policy: policy,
+		},
+		{
+			desc:          "infrastructure backoff",
+			trial:         failed("Stale", 15*time.Second, nil),
+			policy:        policy,
+			expectedRetry: true,
+			expectedAfter: 45 * time.Second,
+		},
+		{
+			desc:          "infrastructure backoff doubles",
+			trial:         failed("Evicted", time.Minute, map[string]string{optimizev1beta2.AnnotationInfrastructureRetries: "2"}),
+			policy:        policy,
+			expectedRetry: true,
+			expectedAfter: 3 * time.Minute,
+		},
+		{
+			desc:          "infrastructure backoff elapsed",
+			trial:         failed("MetricFailed", time.Hour, map[string]string{optimizev1beta2.AnnotationAssignmentRetries: "1"}),
+			policy:        policy,
+			expectedRetry: true,
+		},
+		{
+			desc:   "infrastructure limit",
+			trial:  failed("MetricFailed", time.Hour, map[string]string{optimizev1beta2.AnnotationInfrastructureRetries: "3"}),
+			policy: policy,
+		},
+		{
+			desc:          "assignment",
+			trial:         failed("OOMKilled", time.Second, map[string]string{optimizev1beta2.AnnotationInfrastructureRetries: "3"}),
+			policy:        policy,
+			expectedRetry: true,
+		},
+		{
+			desc:   "assignment limit",
+			trial:  failed(corev1.PodReasonUnschedulable, time.Second, map[string]string{optimizev1beta2.AnnotationAssignmentRetries: "1"}),
+			policy: policy,
+		},
+		{
+			desc:   "assignment not retried",
+			trial:  failed("OOMKilled", time.Second, nil),
+			policy: &optimizev1beta2.RetryPolicy{Infrastructure: policy.Infrastructure},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			after, retry := NextRetry(&c.trial, c.policy, now)
+			assert.Equal(t, c.expectedRetry, retry)
+			assert.Equal(t, c.expectedAfter, after)
+		})
+	}
+}
+
+func TestPopulateRetry(t *testing.T) {
+	ttl := int32(60)
+	failed := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "my-exp-003-retry1",
+			Labels: map[string]string{"baseline": "true"},
+			Annotations: map[string]string{
+				optimizev1beta2.AnnotationReportTrialURL:        "http://example.com/trials/3",
+				optimizev1beta2.AnnotationAssignmentRetries:     "1",
+				optimizev1beta2.AnnotationLastRecoveryTime:      "2021-06-01T12:00:00Z",
+				optimizev1beta2.AnnotationInfrastructureRetries: "",
+			},
+		},
+		Spec: optimizev1beta2.TrialSpec{
+			Assignments:            []optimizev1beta2.Assignment{{Name: "one", Value: intstr.FromInt(1)}},
+			TTLSecondsAfterFailure: &ttl,
+		},
+		Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+			{Type: optimizev1beta2.TrialFailed, Status: corev1.ConditionTrue, Reason: "Evicted"},
+		}},
+	}
+
+	rt := &optimizev1beta2.Trial{ObjectMeta: metav1.ObjectMeta{GenerateName: "my-exp-"}}
+	PopulateRetry(rt, failed)
+
+	assert.Equal(t, "my-exp-003-retry2", rt.Name)
+	assert.Empty(t, rt.GenerateName)
+	assert.Equal(t, failed.Spec.Assignments, rt.Spec.Assignments)
+	assert.Equal(t, &ttl, rt.Spec.TTLSecondsAfterFailure)
+	assert.Equal(t, map[string]string{"baseline": "true"}, rt.Labels)
+	assert.Equal(t, map[string]string{
+		optimizev1beta2.AnnotationReportTrialURL:        "http://example.com/trials/3",
+		optimizev1beta2.AnnotationAssignmentRetries:     "1",
+		optimizev1beta2.AnnotationInfrastructureRetries: "1",
+	}, rt.Annotations)
+	assert.True(t, IsRetry(rt))
+}
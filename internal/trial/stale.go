@@ -0,0 +1,245 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// StageSetup indicates the trial is waiting for the setup create job to finish
+	StageSetup = "setup"
+	// StageReadiness indicates the trial is waiting for the patched objects to become ready
+	StageReadiness = "readiness"
+	// StageRun indicates the trial is waiting for the trial run to finish
+	StageRun = "run"
+	// StageMetrics indicates the trial is waiting for metrics to be collected
+	StageMetrics = "metrics"
+
+	// defaultApproximateRuntime matches the duration of the default trial run container
+	defaultApproximateRuntime = 2 * time.Minute
+	// minimumStaleTimeout prevents short trials from being considered stale too quickly
+	minimumStaleTimeout = 10 * time.Minute
+)
+
+// Staleness describes how long a trial has been waiting in its current stage.
+type Staleness struct {
+	// Stage is the part of the trial lifecycle the trial is waiting on.
+	Stage string
+	// Since is the time the trial entered the stage (or was last recovered).
+	Since time.Time
+	// Timeout is the amount of time the trial can spend in the stage before it is considered stale.
+	Timeout time.Duration
+	// Configured indicates the timeout was explicitly configured on the trial, exceeding a configured timeout
+	// fails the trial without attempting recovery.
+	Configured bool
+}
+
+// FailureReason returns the reason used to fail a trial which exceeded its timeout.
+func (s *Staleness) FailureReason() string {
+	if !s.Configured {
+		return "Stale"
+	}
+	switch s.Stage {
+	case StageSetup:
+		return "SetupTimeout"
+	case StageReadiness:
+		return "ReadinessTimeout"
+	case StageRun:
+		return "RunTimeout"
+	case StageMetrics:
+		return "MetricsTimeout"
+	default:
+		return "Timeout"
+	}
+}
+
+// IsStale checks to see if the trial has exceeded the timeout for its current stage.
+func (s *Staleness) IsStale(now time.Time) bool {
+	return s.Remaining(now) <= 0
+}
+
+// Remaining returns the amount of time left before the trial is considered stale.
+func (s *Staleness) Remaining(now time.Time) time.Duration {
+	return s.Since.Add(s.Timeout).Sub(now)
+}
+
+// CheckStaleness returns the staleness of an unfinished trial, nil is returned if the trial is not in a stage that
+// can become stale. The timeout is the one configured on the trial for the current stage, or the expected duration of
+// the trial multiplied by the supplied multiplier. Trials without configured timeouts or an explicit approximate
+// runtime never become stale.
+func CheckStaleness(t *optimizev1beta2.Trial, multiplier float64) *Staleness {
+	if IsFinished(t) || !t.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	s := &Staleness{}
+
+	conditions := make(map[optimizev1beta2.TrialConditionType]*optimizev1beta2.TrialCondition, len(t.Status.Conditions))
+	for i := range t.Status.Conditions {
+		conditions[t.Status.Conditions[i].Type] = &t.Status.Conditions[i]
+	}
+
+	switch {
+	case conditions[optimizev1beta2.TrialSetupCreated] != nil && conditions[optimizev1beta2.TrialSetupCreated].Status != corev1.ConditionTrue:
+		s.Stage = StageSetup
+		s.Since = conditions[optimizev1beta2.TrialSetupCreated].LastTransitionTime.Time
+
+	case conditions[optimizev1beta2.TrialReady] != nil && conditions[optimizev1beta2.TrialReady].Status != corev1.ConditionTrue &&
+		CheckCondition(&t.Status, optimizev1beta2.TrialPatched, corev1.ConditionTrue):
+		s.Stage = StageReadiness
+		s.Since = conditions[optimizev1beta2.TrialPatched].LastTransitionTime.Time
+
+	case t.Status.CompletionTime == nil && CheckCondition(&t.Status, optimizev1beta2.TrialReady, corev1.ConditionTrue):
+		s.Stage = StageRun
+		s.Since = conditions[optimizev1beta2.TrialReady].LastTransitionTime.Time
+
+	case t.Status.CompletionTime != nil && conditions[optimizev1beta2.TrialObserved] != nil && conditions[optimizev1beta2.TrialObserved].Status != corev1.ConditionTrue:
+		s.Stage = StageMetrics
+		s.Since = conditions[optimizev1beta2.TrialObserved].LastTransitionTime.Time
+
+	default:
+		return nil
+	}
+
+	// Prefer the configured timeout, readiness only has a configured timeout since it has its own failure thresholds
+	if d := stageTimeout(t, s.Stage); d > 0 {
+		s.Timeout, s.Configured = d, true
+	} else if multiplier > 0 && s.Stage != StageReadiness && t.Spec.ApproximateRuntime != nil {
+		s.Timeout = time.Duration(multiplier * float64(ExpectedDuration(t)))
+		if s.Timeout < minimumStaleTimeout {
+			s.Timeout = minimumStaleTimeout
+		}
+	} else {
+		return nil
+	}
+
+	// A recovery attempt restarts the clock
+	if lr, err := time.Parse(time.RFC3339, t.GetAnnotations()[optimizev1beta2.AnnotationLastRecoveryTime]); err == nil && lr.After(s.Since) {
+		s.Since = lr
+	}
+
+	return s
+}
+
+// stageTimeout returns the timeout configured on the trial for the supplied stage, zero if there is no timeout.
+func stageTimeout(t *optimizev1beta2.Trial, stage string) time.Duration {
+	timeouts := t.Spec.Timeouts
+	if timeouts == nil {
+		return 0
+	}
+
+	var d *metav1.Duration
+	switch stage {
+	case StageSetup:
+		d = timeouts.Setup
+	case StageReadiness:
+		d = timeouts.Readiness
+	case StageRun:
+		d = timeouts.Run
+	case StageMetrics:
+		d = timeouts.Metrics
+	}
+	if d == nil {
+		return 0
+	}
+	return d.Duration
+}
+
+// ExpectedDuration returns the approximate amount of time the trial run should take.
+func ExpectedDuration(t *optimizev1beta2.Trial) time.Duration {
+	d := defaultApproximateRuntime
+	if t.Spec.ApproximateRuntime != nil && t.Spec.ApproximateRuntime.Duration > 0 {
+		d = t.Spec.ApproximateRuntime.Duration
+	}
+	if t.Spec.StartTimeOffset != nil {
+		d += t.Spec.StartTimeOffset.Duration
+	}
+	return d + time.Duration(t.Spec.InitialDelaySeconds)*time.Second
+}
+
+// RecoveryAttempts returns the number of times recovery of a stale trial has been attempted.
+func RecoveryAttempts(t *optimizev1beta2.Trial) int {
+	n, _ := strconv.Atoi(t.GetAnnotations()[optimizev1beta2.AnnotationRecoveryAttempts])
+	return n
+}
+
+// RecordRecoveryAttempt increments the number of recovery attempts and resets the stale clock.
+func RecordRecoveryAttempt(t *optimizev1beta2.Trial, now time.Time) {
+	annotations := t.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 2)
+	}
+	annotations[optimizev1beta2.AnnotationRecoveryAttempts] = strconv.Itoa(RecoveryAttempts(t) + 1)
+	annotations[optimizev1beta2.AnnotationLastRecoveryTime] = now.UTC().Format(time.RFC3339)
+	t.SetAnnotations(annotations)
+}
+
+// StaleMessage returns a description of a stale trial including any diagnostics available from the pods that
+// are supposed to be making progress.
+func StaleMessage(t *optimizev1beta2.Trial, s *Staleness, pods []corev1.Pod) string {
+	msg := fmt.Sprintf("Trial %s did not finish within %s", s.Stage, s.Timeout)
+	if n := RecoveryAttempts(t); n > 0 {
+		msg += fmt.Sprintf(" after %d recovery attempt(s)", n)
+	}
+
+	var diagnostics []string
+	if s.Stage == StageReadiness {
+		for _, c := range t.Status.Conditions {
+			if c.Type == optimizev1beta2.TrialReady && c.Message != "" {
+				diagnostics = append(diagnostics, c.Message)
+			}
+		}
+	}
+	if s.Stage == StageMetrics {
+		var pending []string
+		for i := range t.Spec.Values {
+			if t.Spec.Values[i].AttemptsRemaining > 0 {
+				pending = append(pending, t.Spec.Values[i].Name)
+			}
+		}
+		if len(pending) > 0 {
+			diagnostics = append(diagnostics, fmt.Sprintf("metrics not collected: %s", strings.Join(pending, ", ")))
+		}
+	}
+
+	for i := range pods {
+		p := &pods[i]
+		for _, c := range p.Status.Conditions {
+			if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse {
+				diagnostics = append(diagnostics, fmt.Sprintf("pod %s is not scheduled: %s", p.Name, strings.TrimSpace(c.Reason+" "+c.Message)))
+			}
+		}
+		for _, cs := range append(p.Status.InitContainerStatuses, p.Status.ContainerStatuses...) {
+			if w := cs.State.Waiting; w != nil && w.Reason != "" {
+				diagnostics = append(diagnostics, fmt.Sprintf("container %s in pod %s is waiting: %s", cs.Name, p.Name, strings.TrimSpace(w.Reason+" "+w.Message)))
+			}
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		msg += " (" + strings.Join(diagnostics, "; ") + ")"
+	}
+	return msg
+}
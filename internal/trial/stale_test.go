@@ -0,0 +1,238 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckStaleness(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	condition := func(ct optimizev1beta2.TrialConditionType, status corev1.ConditionStatus, ago time.Duration) optimizev1beta2.TrialCondition {
+		return optimizev1beta2.TrialCondition{Type: ct, Status: status, LastTransitionTime: metav1.NewTime(now.Add(-ago))}
+	}
+	completionTime := metav1.NewTime(now.Add(-time.Hour))
+
+	cases := []struct {
+		desc           string
+		trial          optimizev1beta2.Trial
+		expectedStage  string
+		expectedStale  bool
+		expectedReason string
+	}{
+		{
+			desc: "created",
+		},
+		{
+			desc: "setup running",
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{ApproximateRuntime: &metav1.Duration{Duration: 2 * time.Minute}},
+				Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialSetupCreated, corev1.ConditionFalse, 5*time.Minute),
+				}},
+			},
+			expectedStage: StageSetup,
+		},
+		{
+			desc: "setup stale",
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{ApproximateRuntime: &metav1.Duration{Duration: 2 * time.Minute}},
+				Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialSetupCreated, corev1.ConditionFalse, time.Hour),
+				}},
+			},
+			expectedStage: StageSetup,
+			expectedStale: true,
+		},
+		{
+			desc: "run with long runtime",
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{ApproximateRuntime: &metav1.Duration{Duration: time.Hour}},
+				Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialSetupCreated, corev1.ConditionTrue, 3*time.Hour),
+					condition(optimizev1beta2.TrialReady, corev1.ConditionTrue, 2*time.Hour),
+				}},
+			},
+			expectedStage: StageRun,
+		},
+		{
+			desc: "run without approximate runtime",
+			trial: optimizev1beta2.Trial{
+				Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialReady, corev1.ConditionTrue, 5*time.Hour),
+				}},
+			},
+		},
+		{
+			desc: "run stale",
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{ApproximateRuntime: &metav1.Duration{Duration: 2 * time.Minute}},
+				Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialReady, corev1.ConditionTrue, time.Hour),
+				}},
+			},
+			expectedStage: StageRun,
+			expectedStale: true,
+		},
+		{
+			desc: "run recovered",
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{ApproximateRuntime: &metav1.Duration{Duration: 2 * time.Minute}},
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					optimizev1beta2.AnnotationLastRecoveryTime: now.Add(-time.Minute).Format(time.RFC3339),
+				}},
+				Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialReady, corev1.ConditionTrue, time.Hour),
+				}},
+			},
+			expectedStage: StageRun,
+		},
+		{
+			desc: "metrics stale",
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{ApproximateRuntime: &metav1.Duration{Duration: 2 * time.Minute}},
+				Status: optimizev1beta2.TrialStatus{
+					CompletionTime: &completionTime,
+					Conditions: []optimizev1beta2.TrialCondition{
+						condition(optimizev1beta2.TrialReady, corev1.ConditionTrue, 2*time.Hour),
+						condition(optimizev1beta2.TrialObserved, corev1.ConditionUnknown, time.Hour),
+					},
+				},
+			},
+			expectedStage: StageMetrics,
+			expectedStale: true,
+		},
+		{
+			desc: "readiness without timeout",
+			trial: optimizev1beta2.Trial{Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+				condition(optimizev1beta2.TrialPatched, corev1.ConditionTrue, time.Hour),
+				condition(optimizev1beta2.TrialReady, corev1.ConditionFalse, time.Hour),
+			}}},
+		},
+		{
+			desc: "readiness timeout",
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{Timeouts: &optimizev1beta2.TrialTimeouts{Readiness: &metav1.Duration{Duration: 5 * time.Minute}}},
+				Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialPatched, corev1.ConditionTrue, 10*time.Minute),
+					condition(optimizev1beta2.TrialReady, corev1.ConditionFalse, time.Minute),
+				}},
+			},
+			expectedStage:  StageReadiness,
+			expectedStale:  true,
+			expectedReason: "ReadinessTimeout",
+		},
+		{
+			desc: "run timeout",
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{Timeouts: &optimizev1beta2.TrialTimeouts{Run: &metav1.Duration{Duration: 2 * time.Minute}}},
+				Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialReady, corev1.ConditionTrue, 5*time.Minute),
+				}},
+			},
+			expectedStage:  StageRun,
+			expectedStale:  true,
+			expectedReason: "RunTimeout",
+		},
+		{
+			desc: "run without timeout",
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{Timeouts: &optimizev1beta2.TrialTimeouts{Setup: &metav1.Duration{Duration: 2 * time.Minute}}},
+				Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialReady, corev1.ConditionTrue, 5*time.Hour),
+				}},
+			},
+		},
+		{
+			desc: "metrics timeout remaining",
+			trial: optimizev1beta2.Trial{
+				Spec: optimizev1beta2.TrialSpec{Timeouts: &optimizev1beta2.TrialTimeouts{Metrics: &metav1.Duration{Duration: 2 * time.Hour}}},
+				Status: optimizev1beta2.TrialStatus{
+					CompletionTime: &completionTime,
+					Conditions: []optimizev1beta2.TrialCondition{
+						condition(optimizev1beta2.TrialReady, corev1.ConditionTrue, 2*time.Hour),
+						condition(optimizev1beta2.TrialObserved, corev1.ConditionUnknown, time.Hour),
+					},
+				},
+			},
+			expectedStage:  StageMetrics,
+			expectedReason: "MetricsTimeout",
+		},
+		{
+			desc: "failed",
+			trial: optimizev1beta2.Trial{Status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+				condition(optimizev1beta2.TrialSetupCreated, corev1.ConditionFalse, time.Hour),
+				condition(optimizev1beta2.TrialFailed, corev1.ConditionTrue, time.Hour),
+			}}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := CheckStaleness(&c.trial, 3)
+			if c.expectedStage == "" {
+				assert.Nil(t, s)
+				return
+			}
+			require.NotNil(t, s)
+			assert.Equal(t, c.expectedStage, s.Stage)
+			assert.Equal(t, c.expectedStale, s.IsStale(now))
+			if c.expectedReason != "" {
+				assert.Equal(t, c.expectedReason, s.FailureReason())
+			} else {
+				assert.Equal(t, "Stale", s.FailureReason())
+			}
+		})
+	}
+}
+
+func TestRecordRecoveryAttempt(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	tr := &optimizev1beta2.Trial{}
+
+	assert.Equal(t, 0, RecoveryAttempts(tr))
+	RecordRecoveryAttempt(tr, now)
+	RecordRecoveryAttempt(tr, now)
+	assert.Equal(t, 2, RecoveryAttempts(tr))
+	assert.Equal(t, "2021-06-01T12:00:00Z", tr.Annotations[optimizev1beta2.AnnotationLastRecoveryTime])
+}
+
+func TestStaleMessage(t *testing.T) {
+	tr := &optimizev1beta2.Trial{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{optimizev1beta2.AnnotationRecoveryAttempts: "2"}},
+	}
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "setup-abcde"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "setup",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+				}},
+			},
+		},
+	}
+
+	msg := StaleMessage(tr, &Staleness{Stage: StageSetup, Timeout: 10 * time.Minute}, pods)
+	assert.Equal(t, "Trial setup did not finish within 10m0s after 2 recovery attempt(s) (container setup in pod setup-abcde is waiting: ImagePullBackOff)", msg)
+}
@@ -51,6 +51,7 @@ var (
 		optimizev1beta2.TrialSetupDeleted,
 		optimizev1beta2.TrialPatched,
 		optimizev1beta2.TrialReady,
+		optimizev1beta2.TrialRunning,
 		optimizev1beta2.TrialObserved,
 		optimizev1beta2.TrialComplete,
 		optimizev1beta2.TrialFailed,
@@ -59,11 +60,12 @@ var (
 
 // UpdateStatus will make sure the trial status matches the current state of the trial; returns true only if changes were necessary
 func UpdateStatus(t *optimizev1beta2.Trial) bool {
+	dirty := updateRunningCondition(t)
+
 	phase := summarize(t)
 	assignments := assignments(t)
 	values := values(t)
 
-	var dirty bool
 	if t.Status.Phase != phase {
 		t.Status.Phase = phase
 		dirty = true
@@ -79,6 +81,26 @@ func UpdateStatus(t *optimizev1beta2.Trial) bool {
 	return dirty
 }
 
+// updateRunningCondition reflects the start and completion times of the trial run in the running condition; returns
+// true only if changes were necessary
+func updateRunningCondition(t *optimizev1beta2.Trial) bool {
+	switch {
+	case t.Status.StartTime == nil:
+		return false
+	case t.Status.CompletionTime != nil:
+		if CheckCondition(&t.Status, optimizev1beta2.TrialRunning, corev1.ConditionFalse) {
+			return false
+		}
+		ApplyCondition(&t.Status, optimizev1beta2.TrialRunning, corev1.ConditionFalse, "Finished", "", t.Status.CompletionTime)
+	default:
+		if CheckCondition(&t.Status, optimizev1beta2.TrialRunning, corev1.ConditionTrue) {
+			return false
+		}
+		ApplyCondition(&t.Status, optimizev1beta2.TrialRunning, corev1.ConditionTrue, "Started", "", t.Status.StartTime)
+	}
+	return true
+}
+
 func summarize(t *optimizev1beta2.Trial) string {
 	// If there is an initializer we are in the "setting up" phase
 	if t.HasInitializer() {
@@ -172,6 +194,59 @@ func summarize(t *optimizev1beta2.Trial) string {
 	return phase
 }
 
+// PhaseEvent describes a milestone in the lifecycle of a trial
+type PhaseEvent struct {
+	// Type is the event type, i.e. "Normal" or "Warning"
+	Type string
+	// Reason is a short code describing the milestone
+	Reason string
+	// Message is a human readable description of the milestone
+	Message string
+}
+
+// phaseMilestones is the number of lifecycle milestones reached by the time a trial is in a given phase
+var phaseMilestones = map[string]int{
+	patched:    1,
+	waiting:    1,
+	stabilized: 2,
+	running:    3,
+	capturing:  4,
+	captured:   5,
+	completed:  6,
+}
+
+// PhaseEvents returns the events for the milestones reached since the trial was in the supplied phase
+func PhaseEvents(t *optimizev1beta2.Trial, previousPhase string) []PhaseEvent {
+	phase := summarize(t)
+	if phase == previousPhase || previousPhase == failed || previousPhase == completed {
+		return nil
+	}
+
+	if phase == failed {
+		for _, c := range t.Status.Conditions {
+			if c.Type == optimizev1beta2.TrialFailed && c.Status == corev1.ConditionTrue {
+				return []PhaseEvent{{Type: corev1.EventTypeWarning, Reason: "TrialFailed", Message: strings.TrimSpace(c.Reason + " " + c.Message)}}
+			}
+		}
+	}
+
+	milestones := []PhaseEvent{
+		{Type: corev1.EventTypeNormal, Reason: "TrialPatched", Message: "Patches applied"},
+		{Type: corev1.EventTypeNormal, Reason: "TrialReady", Message: "Patched objects are ready"},
+		{Type: corev1.EventTypeNormal, Reason: "TrialStarted", Message: "Trial run started"},
+		{Type: corev1.EventTypeNormal, Reason: "TrialFinished", Message: "Trial run finished"},
+		{Type: corev1.EventTypeNormal, Reason: "TrialObserved", Message: fmt.Sprintf("Metrics collected: %s", values(t))},
+		{Type: corev1.EventTypeNormal, Reason: "TrialCompleted", Message: "Trial completed"},
+	}
+
+	// Report every milestone since the previous phase in case the trial moved through several phases at once
+	var events []PhaseEvent
+	for i := phaseMilestones[previousPhase]; i < phaseMilestones[phase]; i++ {
+		events = append(events, milestones[i])
+	}
+	return events
+}
+
 func assignments(t *optimizev1beta2.Trial) string {
 	assignments := make([]string, len(t.Spec.Assignments))
 	for i := range t.Spec.Assignments {
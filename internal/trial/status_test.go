@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestUpdateStatus_Summarize(t *testing.T) {
@@ -207,3 +208,146 @@ func TestUpdateStatus_Values(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateStatus_Running(t *testing.T) {
+	startTime := metav1.Unix(1000, 0)
+	completionTime := metav1.Unix(2000, 0)
+
+	cases := []struct {
+		desc           string
+		startTime      *metav1.Time
+		completionTime *metav1.Time
+		conditions     []optimizev1beta2.TrialCondition
+		expectedDirty  bool
+		expected       []optimizev1beta2.TrialCondition
+	}{
+		{
+			desc: "NotStarted",
+		},
+		{
+			desc:          "Started",
+			startTime:     &startTime,
+			expectedDirty: true,
+			expected: []optimizev1beta2.TrialCondition{
+				{Type: optimizev1beta2.TrialRunning, Status: corev1.ConditionTrue, Reason: "Started", LastProbeTime: startTime, LastTransitionTime: startTime},
+			},
+		},
+		{
+			desc:           "Finished",
+			startTime:      &startTime,
+			completionTime: &completionTime,
+			conditions: []optimizev1beta2.TrialCondition{
+				{Type: optimizev1beta2.TrialRunning, Status: corev1.ConditionTrue, Reason: "Started", LastProbeTime: startTime, LastTransitionTime: startTime},
+			},
+			expectedDirty: true,
+			expected: []optimizev1beta2.TrialCondition{
+				{Type: optimizev1beta2.TrialRunning, Status: corev1.ConditionFalse, Reason: "Finished", LastProbeTime: startTime, LastTransitionTime: completionTime},
+			},
+		},
+		{
+			desc:           "Unchanged",
+			startTime:      &startTime,
+			completionTime: &completionTime,
+			conditions: []optimizev1beta2.TrialCondition{
+				{Type: optimizev1beta2.TrialRunning, Status: corev1.ConditionFalse, Reason: "Finished", LastProbeTime: startTime, LastTransitionTime: completionTime},
+			},
+			expected: []optimizev1beta2.TrialCondition{
+				{Type: optimizev1beta2.TrialRunning, Status: corev1.ConditionFalse, Reason: "Finished", LastProbeTime: startTime, LastTransitionTime: completionTime},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			tt := &optimizev1beta2.Trial{
+				Status: optimizev1beta2.TrialStatus{
+					Phase:          created,
+					StartTime:      c.startTime,
+					CompletionTime: c.completionTime,
+					Conditions:     c.conditions,
+				},
+			}
+			assert.Equal(t, c.expectedDirty, UpdateStatus(tt))
+			assert.Equal(t, c.expected, tt.Status.Conditions)
+		})
+	}
+}
+
+func TestPhaseEvents(t *testing.T) {
+	startTime := metav1.Now()
+	condition := func(ct optimizev1beta2.TrialConditionType, status corev1.ConditionStatus) optimizev1beta2.TrialCondition {
+		return optimizev1beta2.TrialCondition{Type: ct, Status: status}
+	}
+
+	cases := []struct {
+		desc           string
+		previousPhase  string
+		status         optimizev1beta2.TrialStatus
+		expectedEvents []string
+	}{
+		{
+			desc:          "no change",
+			previousPhase: created,
+		},
+		{
+			desc:          "patched",
+			previousPhase: patching,
+			status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+				condition(optimizev1beta2.TrialPatched, corev1.ConditionTrue),
+			}},
+			expectedEvents: []string{"TrialPatched"},
+		},
+		{
+			desc:          "ready and started",
+			previousPhase: waiting,
+			status: optimizev1beta2.TrialStatus{
+				StartTime: &startTime,
+				Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialPatched, corev1.ConditionTrue),
+					condition(optimizev1beta2.TrialReady, corev1.ConditionTrue),
+				},
+			},
+			expectedEvents: []string{"TrialReady", "TrialStarted"},
+		},
+		{
+			desc:          "completed",
+			previousPhase: running,
+			status: optimizev1beta2.TrialStatus{
+				StartTime:      &startTime,
+				CompletionTime: &startTime,
+				Conditions: []optimizev1beta2.TrialCondition{
+					condition(optimizev1beta2.TrialPatched, corev1.ConditionTrue),
+					condition(optimizev1beta2.TrialReady, corev1.ConditionTrue),
+					condition(optimizev1beta2.TrialObserved, corev1.ConditionTrue),
+					condition(optimizev1beta2.TrialComplete, corev1.ConditionTrue),
+				},
+			},
+			expectedEvents: []string{"TrialFinished", "TrialObserved", "TrialCompleted"},
+		},
+		{
+			desc:          "failed",
+			previousPhase: waiting,
+			status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+				condition(optimizev1beta2.TrialPatched, corev1.ConditionTrue),
+				condition(optimizev1beta2.TrialFailed, corev1.ConditionTrue),
+			}},
+			expectedEvents: []string{"TrialFailed"},
+		},
+		{
+			desc:          "already failed",
+			previousPhase: failed,
+			status: optimizev1beta2.TrialStatus{Conditions: []optimizev1beta2.TrialCondition{
+				condition(optimizev1beta2.TrialFailed, corev1.ConditionTrue),
+				condition(optimizev1beta2.TrialSetupDeleted, corev1.ConditionTrue),
+			}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var reasons []string
+			for _, e := range PhaseEvents(&optimizev1beta2.Trial{Status: c.status}, c.previousPhase) {
+				reasons = append(reasons, e.Reason)
+			}
+			assert.Equal(t, c.expectedEvents, reasons)
+		})
+	}
+}
@@ -50,10 +50,14 @@ func IsActive(t *optimizev1beta2.Trial) bool {
 		return true
 	}
 
-	// Check if a setup delete task exists and has not yet completed (remember the TrialSetupDeleted status is optional!)
+	// Check if a setup delete task exists or patches need to be restored and have not yet completed (remember the
+	// TrialSetupDeleted and TrialRestored statuses are optional!)
 	for _, c := range t.Status.Conditions {
-		if c.Type == optimizev1beta2.TrialSetupDeleted && c.Status != corev1.ConditionTrue {
-			return true
+		switch c.Type {
+		case optimizev1beta2.TrialSetupDeleted, optimizev1beta2.TrialRestored:
+			if c.Status != corev1.ConditionTrue {
+				return true
+			}
 		}
 	}
 
@@ -125,9 +129,28 @@ func IsBaseline(t *optimizev1beta2.Trial, exp *optimizev1beta2.Experiment) bool
 
 // NeedsCleanup checks to see if a trial's TTL has expired
 func NeedsCleanup(t *optimizev1beta2.Trial) bool {
+	expiration := cleanupTime(t)
+	return !expiration.IsZero() && expiration.Before(time.Now().UTC())
+}
+
+// UntilCleanup returns the amount of time remaining before a trial's TTL expires, zero if there is no TTL or it
+// has already expired.
+func UntilCleanup(t *optimizev1beta2.Trial, now time.Time) time.Duration {
+	expiration := cleanupTime(t)
+	if expiration.IsZero() {
+		return 0
+	}
+	if d := expiration.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// cleanupTime returns the time at which a trial's TTL expires, the zero time if the trial should not be cleaned up
+func cleanupTime(t *optimizev1beta2.Trial) time.Time {
 	// Already deleted or still active, no cleanup necessary
 	if !t.GetDeletionTimestamp().IsZero() || IsActive(t) {
-		return false
+		return time.Time{}
 	}
 
 	// Try to determine effective finish time and TTL
@@ -149,18 +172,16 @@ func NeedsCleanup(t *optimizev1beta2.Trial) bool {
 
 	// No finish time or TTL, no cleanup necessary
 	if finishTime.IsZero() || ttlSeconds == nil || *ttlSeconds < 0 {
-		return false
+		return time.Time{}
 	}
 
-	// Check to see if we are still in the TTL window
-	ttl := time.Duration(*ttlSeconds) * time.Second
-	return finishTime.UTC().Add(ttl).Before(time.Now().UTC())
+	return finishTime.UTC().Add(time.Duration(*ttlSeconds) * time.Second)
 }
 
 // isFinishTimeCondition returns true if the condition is relevant to the "finish time"
 func isFinishTimeCondition(c *optimizev1beta2.TrialCondition) bool {
 	switch c.Type {
-	case optimizev1beta2.TrialComplete, optimizev1beta2.TrialFailed, optimizev1beta2.TrialSetupDeleted:
+	case optimizev1beta2.TrialComplete, optimizev1beta2.TrialFailed, optimizev1beta2.TrialSetupDeleted, optimizev1beta2.TrialRestored:
 		return c.Status == corev1.ConditionTrue
 	default:
 		return false
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUntilCleanup(t *testing.T) {
+	now := time.Date(2021, 6, 7, 8, 0, 0, 0, time.UTC)
+	finished := metav1.NewTime(now.Add(-time.Hour))
+	twoHours := int32(7200)
+	tenMinutes := int32(600)
+
+	cases := []struct {
+		desc       string
+		spec       optimizev1beta2.TrialSpec
+		conditions []optimizev1beta2.TrialCondition
+		expected   time.Duration
+	}{
+		{
+			desc: "active",
+			spec: optimizev1beta2.TrialSpec{TTLSecondsAfterFinished: &twoHours},
+		},
+		{
+			desc: "no ttl",
+			conditions: []optimizev1beta2.TrialCondition{
+				{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue, LastTransitionTime: finished},
+			},
+		},
+		{
+			desc: "finished",
+			spec: optimizev1beta2.TrialSpec{TTLSecondsAfterFinished: &twoHours},
+			conditions: []optimizev1beta2.TrialCondition{
+				{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue, LastTransitionTime: finished},
+			},
+			expected: time.Hour,
+		},
+		{
+			desc: "failed",
+			spec: optimizev1beta2.TrialSpec{TTLSecondsAfterFinished: &tenMinutes, TTLSecondsAfterFailure: &twoHours},
+			conditions: []optimizev1beta2.TrialCondition{
+				{Type: optimizev1beta2.TrialFailed, Status: corev1.ConditionTrue, LastTransitionTime: finished},
+			},
+			expected: time.Hour,
+		},
+		{
+			desc: "expired",
+			spec: optimizev1beta2.TrialSpec{TTLSecondsAfterFinished: &tenMinutes},
+			conditions: []optimizev1beta2.TrialCondition{
+				{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue, LastTransitionTime: finished},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			tt := &optimizev1beta2.Trial{Spec: c.spec}
+			tt.Status.Conditions = c.conditions
+			assert.Equal(t, c.expected, UntilCleanup(tt, now))
+		})
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	complete := optimizev1beta2.TrialCondition{Type: optimizev1beta2.TrialComplete, Status: corev1.ConditionTrue}
+
+	cases := []struct {
+		desc       string
+		conditions []optimizev1beta2.TrialCondition
+		expected   bool
+	}{
+		{
+			desc:     "not finished",
+			expected: true,
+		},
+		{
+			desc:       "finished",
+			conditions: []optimizev1beta2.TrialCondition{complete},
+		},
+		{
+			desc: "setup delete pending",
+			conditions: []optimizev1beta2.TrialCondition{
+				complete,
+				{Type: optimizev1beta2.TrialSetupDeleted, Status: corev1.ConditionUnknown},
+			},
+			expected: true,
+		},
+		{
+			desc: "restore pending",
+			conditions: []optimizev1beta2.TrialCondition{
+				complete,
+				{Type: optimizev1beta2.TrialRestored, Status: corev1.ConditionUnknown},
+			},
+			expected: true,
+		},
+		{
+			desc: "restored",
+			conditions: []optimizev1beta2.TrialCondition{
+				complete,
+				{Type: optimizev1beta2.TrialRestored, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			tt := &optimizev1beta2.Trial{}
+			tt.Status.Conditions = c.conditions
+			assert.Equal(t, c.expected, IsActive(tt))
+		})
+	}
+}
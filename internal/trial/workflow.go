@@ -0,0 +1,132 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"time"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/template"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// WorkflowGroupVersionKind is the type of the Argo Workflow used to run trials.
+var WorkflowGroupVersionKind = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Workflow"}
+
+// The Argo Workflow phases that indicate the workflow has finished.
+const (
+	WorkflowSucceeded = "Succeeded"
+	WorkflowFailed    = "Failed"
+	WorkflowError     = "Error"
+)
+
+// NewArgoWorkflow returns a new trial run workflow referencing the workflow template on the trial
+func NewArgoWorkflow(t *optimizev1beta2.Trial) (*unstructured.Unstructured, error) {
+	wf := &unstructured.Unstructured{}
+	wf.SetGroupVersionKind(WorkflowGroupVersionKind)
+	wf.SetNamespace(t.Namespace)
+	wf.SetName(t.Name)
+	wf.SetLabels(map[string]string{
+		optimizev1beta2.LabelExperiment: t.ExperimentNamespacedName().Name,
+		optimizev1beta2.LabelTrial:      t.Name,
+		optimizev1beta2.LabelTrialRole:  "trialRun",
+	})
+
+	spec := t.Spec.ArgoWorkflow
+	if spec == nil {
+		return wf, nil
+	}
+
+	ref := map[string]interface{}{"name": spec.WorkflowTemplateRef.Name}
+	if spec.WorkflowTemplateRef.ClusterScope {
+		ref["clusterScope"] = true
+	}
+	if err := unstructured.SetNestedMap(wf.Object, ref, "spec", "workflowTemplateRef"); err != nil {
+		return nil, err
+	}
+
+	if spec.Entrypoint != "" {
+		if err := unstructured.SetNestedField(wf.Object, spec.Entrypoint, "spec", "entrypoint"); err != nil {
+			return nil, err
+		}
+	}
+
+	params, err := workflowParameters(t, spec.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) > 0 {
+		if err := unstructured.SetNestedSlice(wf.Object, params, "spec", "arguments", "parameters"); err != nil {
+			return nil, err
+		}
+	}
+
+	return wf, nil
+}
+
+// WorkflowStatus returns the phase, start and finish times, and message from the status of an Argo Workflow
+func WorkflowStatus(wf *unstructured.Unstructured) (phase string, startedAt, finishedAt *metav1.Time, message string) {
+	phase, _, _ = unstructured.NestedString(wf.Object, "status", "phase")
+	message, _, _ = unstructured.NestedString(wf.Object, "status", "message")
+	startedAt = workflowTime(wf, "startedAt")
+	finishedAt = workflowTime(wf, "finishedAt")
+	return
+}
+
+// workflowParameters returns the rendered workflow parameters, defaulting to one parameter for each assignment
+func workflowParameters(t *optimizev1beta2.Trial, parameters []optimizev1beta2.WorkflowParameter) ([]interface{}, error) {
+	var params []interface{}
+
+	if len(parameters) == 0 {
+		for _, a := range t.Spec.Assignments {
+			value := a.Value.StrVal
+			if a.Value.Type == intstr.Int {
+				value = a.Value.String()
+			}
+			params = append(params, map[string]interface{}{"name": a.Name, "value": value})
+		}
+		return params, nil
+	}
+
+	te := template.New()
+	for i := range parameters {
+		p := &parameters[i]
+		value, err := te.RenderWorkflowParameter(p, t)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, map[string]interface{}{"name": p.Name, "value": value})
+	}
+	return params, nil
+}
+
+// workflowTime returns a timestamp from the status of a workflow or pipeline run, nil if it is not set
+func workflowTime(wf *unstructured.Unstructured, field string) *metav1.Time {
+	s, ok, err := unstructured.NestedString(wf.Object, "status", field)
+	if !ok || err != nil || s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	mt := metav1.NewTime(t)
+	return &mt
+}
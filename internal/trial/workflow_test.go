@@ -0,0 +1,121 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trial
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestNewArgoWorkflow(t *testing.T) {
+	cases := []struct {
+		desc               string
+		argoWorkflow       *optimizev1beta2.ArgoWorkflowSpec
+		expectedRef        map[string]interface{}
+		expectedEntrypoint string
+		expectedParameters []interface{}
+	}{
+		{
+			desc: "default parameters",
+			argoWorkflow: &optimizev1beta2.ArgoWorkflowSpec{
+				WorkflowTemplateRef: optimizev1beta2.ArgoWorkflowTemplateRef{Name: "load-test"},
+			},
+			expectedRef: map[string]interface{}{"name": "load-test"},
+			expectedParameters: []interface{}{
+				map[string]interface{}{"name": "cpu", "value": "500"},
+				map[string]interface{}{"name": "mode", "value": "fast"},
+			},
+		},
+		{
+			desc: "templated parameters",
+			argoWorkflow: &optimizev1beta2.ArgoWorkflowSpec{
+				WorkflowTemplateRef: optimizev1beta2.ArgoWorkflowTemplateRef{Name: "load-test", ClusterScope: true},
+				Entrypoint:          "run",
+				Parameters: []optimizev1beta2.WorkflowParameter{
+					{Name: "trial", Value: "{{ .Trial.Name }}"},
+					{Name: "cpu", Value: "{{ .Values.cpu }}m"},
+				},
+			},
+			expectedRef:        map[string]interface{}{"name": "load-test", "clusterScope": true},
+			expectedEntrypoint: "run",
+			expectedParameters: []interface{}{
+				map[string]interface{}{"name": "trial", "value": "test-001"},
+				map[string]interface{}{"name": "cpu", "value": "500m"},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			tr := &optimizev1beta2.Trial{}
+			tr.Namespace = "default"
+			tr.Name = "test-001"
+			tr.Labels = map[string]string{optimizev1beta2.LabelExperiment: "test"}
+			tr.Spec.Assignments = []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromInt(500)},
+				{Name: "mode", Value: intstr.FromString("fast")},
+			}
+			tr.Spec.ArgoWorkflow = c.argoWorkflow
+
+			wf, err := NewArgoWorkflow(tr)
+			require.NoError(t, err)
+
+			assert.Equal(t, "argoproj.io/v1alpha1", wf.GetAPIVersion())
+			assert.Equal(t, "Workflow", wf.GetKind())
+			assert.Equal(t, "test-001", wf.GetName())
+			assert.Equal(t, "trialRun", wf.GetLabels()[optimizev1beta2.LabelTrialRole])
+
+			ref, _, _ := unstructured.NestedMap(wf.Object, "spec", "workflowTemplateRef")
+			assert.Equal(t, c.expectedRef, ref)
+
+			entrypoint, _, _ := unstructured.NestedString(wf.Object, "spec", "entrypoint")
+			assert.Equal(t, c.expectedEntrypoint, entrypoint)
+
+			params, _, _ := unstructured.NestedSlice(wf.Object, "spec", "arguments", "parameters")
+			assert.Equal(t, c.expectedParameters, params)
+		})
+	}
+}
+
+func TestWorkflowStatus(t *testing.T) {
+	wf := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":      "Failed",
+			"message":    "child 'load-test' failed",
+			"startedAt":  "2021-06-07T08:00:00Z",
+			"finishedAt": "2021-06-07T08:05:00Z",
+		},
+	}}
+
+	phase, startedAt, finishedAt, message := WorkflowStatus(wf)
+	assert.Equal(t, WorkflowFailed, phase)
+	assert.Equal(t, "child 'load-test' failed", message)
+	if assert.NotNil(t, startedAt) && assert.NotNil(t, finishedAt) {
+		assert.Equal(t, metav1.NewTime(time.Date(2021, 6, 7, 8, 0, 0, 0, time.UTC)), *startedAt)
+		assert.Equal(t, 5*time.Minute, finishedAt.Sub(startedAt.Time))
+	}
+
+	_, startedAt, finishedAt, _ = WorkflowStatus(&unstructured.Unstructured{Object: map[string]interface{}{}})
+	assert.Nil(t, startedAt)
+	assert.Nil(t, finishedAt)
+}
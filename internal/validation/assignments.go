@@ -17,6 +17,10 @@ limitations under the License.
 package validation
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -31,12 +35,29 @@ type AssignmentError struct {
 	OutOfBounds []string
 	// Parameter names for which multiple assignments exist
 	Duplicated []string
+	// Parameter names for which the assignment is the wrong type (e.g. a string for a numeric parameter)
+	Mistyped []string
 }
 
 // Error returns a message describing the nature of the problems with the assignments
 func (e *AssignmentError) Error() string {
-	// TODO Improve this error message
-	return "invalid assignments"
+	var problems []string
+	appendProblem := func(desc string, names []string) {
+		if len(names) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: %s", desc, strings.Join(names, ", ")))
+		}
+	}
+
+	appendProblem("unassigned", e.Unassigned)
+	appendProblem("undefined", e.Undefined)
+	appendProblem("out of bounds", e.OutOfBounds)
+	appendProblem("duplicated", e.Duplicated)
+	appendProblem("wrong type", e.Mistyped)
+
+	if len(problems) == 0 {
+		return "invalid assignments"
+	}
+	return "invalid assignments (" + strings.Join(problems, "; ") + ")"
 }
 
 // CheckAssignments ensures the trial assignments match the definitions on the experiment
@@ -56,7 +77,9 @@ func CheckAssignments(t *optimizev1beta2.Trial, exp *optimizev1beta2.Experiment)
 	// Verify against the parameter specifications
 	for _, p := range exp.Spec.Parameters {
 		if a, ok := assignments[p.Name]; ok {
-			if !CheckParameterValue(&p, a) {
+			if !checkParameterType(&p, a) {
+				err.Mistyped = append(err.Mistyped, p.Name)
+			} else if !CheckParameterValue(&p, a) {
 				err.OutOfBounds = append(err.OutOfBounds, p.Name)
 			}
 			delete(assignments, p.Name)
@@ -67,9 +90,10 @@ func CheckAssignments(t *optimizev1beta2.Trial, exp *optimizev1beta2.Experiment)
 	for n := range assignments {
 		err.Undefined = append(err.Undefined, n)
 	}
+	sort.Strings(err.Undefined)
 
 	// If there were no problems found, return nil
-	if len(err.Unassigned) == 0 && len(err.Undefined) == 0 && len(err.OutOfBounds) == 0 && len(err.Duplicated) == 0 {
+	if len(err.Unassigned) == 0 && len(err.Undefined) == 0 && len(err.OutOfBounds) == 0 && len(err.Duplicated) == 0 && len(err.Mistyped) == 0 {
 		return nil
 	}
 	return err
@@ -83,6 +107,11 @@ func CheckParameterValue(p *optimizev1beta2.Parameter, v intstr.IntOrString) boo
 	return v.IntVal >= p.Min && v.IntVal <= p.Max
 }
 
+// checkParameterType ensures the supplied value is the correct type for the parameter.
+func checkParameterType(p *optimizev1beta2.Parameter, v intstr.IntOrString) bool {
+	return (v.Type == intstr.String) == (len(p.Values) > 0)
+}
+
 func contains(values []string, strVal string) bool {
 	for _, c := range values {
 		if strVal == c {
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCheckAssignments(t *testing.T) {
+	exp := &optimizev1beta2.Experiment{
+		Spec: optimizev1beta2.ExperimentSpec{
+			Parameters: []optimizev1beta2.Parameter{
+				{Name: "cpu", Min: 100, Max: 4000},
+				{Name: "gc", Values: []string{"G1", "Parallel"}},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc        string
+		assignments []optimizev1beta2.Assignment
+		expectedErr string
+	}{
+		{
+			desc: "valid",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromInt(500)},
+				{Name: "gc", Value: intstr.FromString("G1")},
+			},
+		},
+		{
+			desc: "unassigned",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromInt(500)},
+			},
+			expectedErr: "invalid assignments (unassigned: gc)",
+		},
+		{
+			desc: "undefined",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromInt(500)},
+				{Name: "gc", Value: intstr.FromString("G1")},
+				{Name: "memory", Value: intstr.FromInt(512)},
+			},
+			expectedErr: "invalid assignments (undefined: memory)",
+		},
+		{
+			desc: "out of bounds",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromInt(8000)},
+				{Name: "gc", Value: intstr.FromString("CMS")},
+			},
+			expectedErr: "invalid assignments (out of bounds: cpu, gc)",
+		},
+		{
+			desc: "duplicated",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromInt(500)},
+				{Name: "cpu", Value: intstr.FromInt(600)},
+				{Name: "gc", Value: intstr.FromString("G1")},
+			},
+			expectedErr: "invalid assignments (duplicated: cpu)",
+		},
+		{
+			desc: "wrong type",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromString("500")},
+				{Name: "gc", Value: intstr.FromInt(1)},
+			},
+			expectedErr: "invalid assignments (wrong type: cpu, gc)",
+		},
+		{
+			desc: "multiple problems",
+			assignments: []optimizev1beta2.Assignment{
+				{Name: "cpu", Value: intstr.FromInt(1)},
+				{Name: "memory", Value: intstr.FromInt(512)},
+			},
+			expectedErr: "invalid assignments (unassigned: gc; undefined: memory; out of bounds: cpu)",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := CheckAssignments(&optimizev1beta2.Trial{Spec: optimizev1beta2.TrialSpec{Assignments: c.assignments}}, exp)
+			if c.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, c.expectedErr)
+			}
+		})
+	}
+}
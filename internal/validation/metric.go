@@ -0,0 +1,52 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+)
+
+// CheckMetricCredentials ensures a metric references at most one secret containing the credentials for a remote
+// metric source.
+func CheckMetricCredentials(m *optimizev1beta2.Metric) error {
+	if m.BearerTokenSecretRef != nil && m.CredentialsSecretRef != nil {
+		return fmt.Errorf("metric %s cannot specify both bearerTokenSecretRef and credentialsSecretRef", m.Name)
+	}
+	return nil
+}
+
+// CheckGuardrails ensures each guardrail references a metric defined by the experiment.
+func CheckGuardrails(spec *optimizev1beta2.ExperimentSpec) error {
+	for _, g := range spec.Guardrails {
+		if !hasMetric(spec.Metrics, g.Metric) {
+			return fmt.Errorf("guardrail references undefined metric %s", g.Metric)
+		}
+	}
+	return nil
+}
+
+// hasMetric checks for a metric with the supplied name.
+func hasMetric(metrics []optimizev1beta2.Metric, name string) bool {
+	for i := range metrics {
+		if metrics[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2020 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCheckMetricCredentials(t *testing.T) {
+	cases := []struct {
+		desc     string
+		metric   optimizev1beta2.Metric
+		hasError bool
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "bearer token",
+			metric: optimizev1beta2.Metric{
+				BearerTokenSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "prometheus"}, Key: "token"},
+			},
+		},
+		{
+			desc: "credentials",
+			metric: optimizev1beta2.Metric{
+				CredentialsSecretRef: &corev1.LocalObjectReference{Name: "datadog"},
+			},
+		},
+		{
+			desc: "both",
+			metric: optimizev1beta2.Metric{
+				BearerTokenSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "prometheus"}, Key: "token"},
+				CredentialsSecretRef: &corev1.LocalObjectReference{Name: "datadog"},
+			},
+			hasError: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := CheckMetricCredentials(&c.metric)
+			if c.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckGuardrails(t *testing.T) {
+	cases := []struct {
+		desc     string
+		spec     optimizev1beta2.ExperimentSpec
+		hasError bool
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "defined metric",
+			spec: optimizev1beta2.ExperimentSpec{
+				Metrics:    []optimizev1beta2.Metric{{Name: "p95-latency"}},
+				Guardrails: []optimizev1beta2.Guardrail{{Metric: "p95-latency"}},
+			},
+		},
+		{
+			desc: "undefined metric",
+			spec: optimizev1beta2.ExperimentSpec{
+				Metrics:    []optimizev1beta2.Metric{{Name: "p95-latency"}},
+				Guardrails: []optimizev1beta2.Guardrail{{Metric: "p99-latency"}},
+			},
+			hasError: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := CheckGuardrails(&c.spec)
+			if c.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
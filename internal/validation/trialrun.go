@@ -0,0 +1,31 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+)
+
+// CheckTrialRun ensures the trial is run using at most one alternative to the job template.
+func CheckTrialRun(spec *optimizev1beta2.TrialSpec) error {
+	if spec.ArgoWorkflow != nil && spec.TektonPipelineRun != nil {
+		return fmt.Errorf("trial cannot be run as both an Argo Workflow and a Tekton PipelineRun")
+	}
+	return nil
+}
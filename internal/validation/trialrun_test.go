@@ -0,0 +1,62 @@
+/*
+Copyright 2021 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+)
+
+func TestCheckTrialRun(t *testing.T) {
+	cases := []struct {
+		desc     string
+		spec     optimizev1beta2.TrialSpec
+		hasError bool
+	}{
+		{
+			desc: "job",
+		},
+		{
+			desc: "workflow",
+			spec: optimizev1beta2.TrialSpec{ArgoWorkflow: &optimizev1beta2.ArgoWorkflowSpec{}},
+		},
+		{
+			desc: "pipeline run",
+			spec: optimizev1beta2.TrialSpec{TektonPipelineRun: &optimizev1beta2.TektonPipelineRunSpec{}},
+		},
+		{
+			desc: "both",
+			spec: optimizev1beta2.TrialSpec{
+				ArgoWorkflow:      &optimizev1beta2.ArgoWorkflowSpec{},
+				TektonPipelineRun: &optimizev1beta2.TektonPipelineRunSpec{},
+			},
+			hasError: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := CheckTrialRun(&c.spec)
+			if c.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"strconv"
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
+	"github.com/thestormforge/optimize-controller/v2/internal/metric"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
@@ -32,17 +33,34 @@ func CheckMetricBounds(m *optimizev1beta2.Metric, v *optimizev1beta2.Value) erro
 		return nil
 	}
 
-	if m.Min != nil {
-		min := float64(m.Min.ScaledValue(resource.Nano)) / 1000000000
-		if value < min {
-			return fmt.Errorf("metric value %f for %s is below the minimum of %s", value, m.Name, m.Min.String())
+	// The value is recorded in canonical units but the bounds are in the units of the metric
+	_, scale, err := metric.ParseUnit(m.Unit)
+	if err != nil {
+		return err
+	}
+
+	return checkBounds(m.Name, value/scale, m.Min, m.Max)
+}
+
+// CheckGuardrail ensures the metric value collected while the trial run is in progress is within the guardrail bounds.
+func CheckGuardrail(g *optimizev1beta2.Guardrail, value float64) error {
+	if err := checkBounds(g.Metric, value, g.Min, g.Max); err != nil {
+		return fmt.Errorf("guardrail violated: %w", err)
+	}
+	return nil
+}
+
+// checkBounds ensures a value is within the inclusive bounds of a metric.
+func checkBounds(name string, value float64, min, max *resource.Quantity) error {
+	if min != nil {
+		if v := float64(min.ScaledValue(resource.Nano)) / 1000000000; value < v {
+			return fmt.Errorf("metric value %f for %s is below the minimum of %s", value, name, min.String())
 		}
 	}
 
-	if m.Max != nil {
-		max := float64(m.Max.ScaledValue(resource.Nano)) / 1000000000
-		if value > max {
-			return fmt.Errorf("metric value %f for %s is above the maximum of %s", value, m.Name, m.Max.String())
+	if max != nil {
+		if v := float64(max.ScaledValue(resource.Nano)) / 1000000000; value > v {
+			return fmt.Errorf("metric value %f for %s is above the maximum of %s", value, name, max.String())
 		}
 	}
 
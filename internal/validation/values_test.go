@@ -69,6 +69,18 @@ func TestCheckMetricBounds(t *testing.T) {
 			hasError: true,
 		},
 
+		{
+			desc:   "unit max",
+			metric: optimizev1beta2.Metric{Max: mustQuantity("500"), Unit: "ms"},
+			value:  optimizev1beta2.Value{Value: "0.25"},
+		},
+		{
+			desc:     "unit min",
+			metric:   optimizev1beta2.Metric{Min: mustQuantity("500"), Unit: "ms"},
+			value:    optimizev1beta2.Value{Value: "0.25"},
+			hasError: true,
+		},
+
 		{
 			desc:     "suffix max",
 			metric:   optimizev1beta2.Metric{Max: mustQuantity("100m")},
@@ -92,3 +104,44 @@ func TestCheckMetricBounds(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckGuardrail(t *testing.T) {
+	cases := []struct {
+		desc      string
+		guardrail optimizev1beta2.Guardrail
+		value     float64
+		hasError  bool
+	}{
+		{
+			desc:  "no bounds",
+			value: 1.0,
+		},
+		{
+			desc:      "error rate ok",
+			guardrail: optimizev1beta2.Guardrail{Metric: "error-rate", Max: mustQuantity("0.05")},
+			value:     0.01,
+		},
+		{
+			desc:      "error rate exceeded",
+			guardrail: optimizev1beta2.Guardrail{Metric: "error-rate", Max: mustQuantity("0.05")},
+			value:     0.2,
+			hasError:  true,
+		},
+		{
+			desc:      "throughput too low",
+			guardrail: optimizev1beta2.Guardrail{Metric: "throughput", Min: mustQuantity("100")},
+			value:     10,
+			hasError:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := CheckGuardrail(&c.guardrail, c.value)
+			if c.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
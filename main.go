@@ -21,6 +21,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	optimizev1beta2 "github.com/thestormforge/optimize-controller/v2/api/v1beta2"
 	"github.com/thestormforge/optimize-controller/v2/controllers"
@@ -30,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
@@ -41,6 +44,7 @@ var (
 
 func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
+	_ = metricsv1beta1.AddToScheme(scheme)
 
 	_ = optimizev1beta2.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
@@ -52,9 +56,43 @@ func main() {
 
 	var metricsAddr string
 	var enableLeaderElection bool
+	var enableWebhooks bool
+	var enableDriftDetection bool
+	var enableQueryDryRun bool
+	var enableUsageCollection bool
+	var pushAddr, pushURL string
+	var pollerOptions controllers.PollerOptions
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", os.Getenv("STORMFORGE_ENABLE_WEBHOOKS") == "true",
+		"Serve the admission webhooks, requires a serving certificate for the webhook server.")
+	flag.BoolVar(&enableQueryDryRun, "enable-query-dry-run", os.Getenv("STORMFORGE_ENABLE_QUERY_DRY_RUN") == "true",
+		"Execute Prometheus metric queries when validating experiments, requires the admission webhooks.")
+	flag.BoolVar(&enableDriftDetection, "enable-drift-detection", os.Getenv("STORMFORGE_ENABLE_DRIFT_DETECTION") == "true",
+		"Periodically compare the live workloads of completed experiments to their best trial.")
+	flag.BoolVar(&enableUsageCollection, "enable-usage-collection", os.Getenv("STORMFORGE_ENABLE_USAGE_COLLECTION") == "true",
+		"Sample the resource usage of patched pods during trial runs, requires the Kubernetes metrics API.")
+	flag.StringVar(&pushAddr, "push-addr", os.Getenv("STORMFORGE_PUSH_ADDR"),
+		"The address the metric push endpoint binds to, leave empty to disable pushing metric values from trial jobs.")
+	flag.StringVar(&pushURL, "push-url", envOrDefault("STORMFORGE_PUSH_SERVICE_URL", "http://optimize-push-service.stormforge-system:8090"),
+		"The URL trial jobs use to reach the metric push endpoint, the default matches the push service from the default deployment.")
+	flag.StringVar(&pollerOptions.UserAgent, "application-user-agent", os.Getenv("STORMFORGE_APPLICATION_USER_AGENT"),
+		"The user agent comment sent to the application service.")
+	flag.DurationVar(&pollerOptions.ScanTimeout, "scan-timeout", envDuration("STORMFORGE_SCAN_TIMEOUT"),
+		"The maximum amount of time a single kubectl invocation can take while scanning resources.")
+	flag.StringVar(&pollerOptions.KubectlPath, "kubectl-path", os.Getenv("STORMFORGE_KUBECTL_PATH"),
+		"The kubectl binary used to scan resources, the built-in kubectl is used when empty.")
+	flag.BoolVar(&pollerOptions.UseKubeconfig, "scan-use-kubeconfig", os.Getenv("STORMFORGE_SCAN_USE_KUBECONFIG") == "true",
+		"Scan resources using the kubeconfig discovery rules instead of the in-cluster configuration.")
+	flag.IntVar(&pollerOptions.Workers, "activity-workers", envInt("STORMFORGE_ACTIVITY_WORKERS"),
+		"The maximum number of application activities processed concurrently, defaults to 4.")
+	flag.StringVar(&pollerOptions.Namespace, "activity-namespace", os.Getenv("STORMFORGE_ACTIVITY_NAMESPACE"),
+		"The namespace used for activity runs when it cannot be determined from the application.")
+	flag.StringVar(&pollerOptions.PodName, "pod-name", os.Getenv("POD_NAME"),
+		"The name of the controller pod, used to report the application activity connection status.")
+	flag.StringVar(&pollerOptions.PodNamespace, "pod-namespace", os.Getenv("POD_NAMESPACE"),
+		"The namespace of the controller pod.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(func(o *zap.Options) {
@@ -117,12 +155,33 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Ready")
 		os.Exit(1)
 	}
+	// Trial jobs are only told about the push endpoint if it is running
+	if pushAddr == "" {
+		pushURL = ""
+	}
 	if err = (&controllers.TrialJobReconciler{
+		Client:  mgr.GetClient(),
+		Log:     ctrl.Log.WithName("controllers").WithName("Trial"),
+		Scheme:  mgr.GetScheme(),
+		PushURL: pushURL,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Trial")
+		os.Exit(1)
+	}
+	if err = (&controllers.TrialWorkflowReconciler{
 		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Trial"),
+		Log:    ctrl.Log.WithName("controllers").WithName("TrialWorkflow"),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Trial")
+		setupLog.Error(err, "unable to create controller", "controller", "TrialWorkflow")
+		os.Exit(1)
+	}
+	if err = (&controllers.TrialPipelineRunReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("TrialPipelineRun"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TrialPipelineRun")
 		os.Exit(1)
 	}
 	if err = (&controllers.MetricReconciler{
@@ -133,12 +192,85 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Metric")
 		os.Exit(1)
 	}
+	if err = (&controllers.ScheduleReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("Schedule"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Schedule")
+		os.Exit(1)
+	}
+	if err = (&controllers.StaleTrialReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("StaleTrial"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "StaleTrial")
+		os.Exit(1)
+	}
+	if err = (&controllers.PromotionReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("Promotion"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Promotion")
+		os.Exit(1)
+	}
+
+	// Drift detection issues dry-run patches against the experiment targets so it must be explicitly enabled
+	if enableDriftDetection {
+		if err = (&controllers.DriftReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("Drift"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Drift")
+			os.Exit(1)
+		}
+	}
+
+	// Resource usage collection depends on the metrics API (e.g. metrics-server) so it must be explicitly enabled
+	if enableUsageCollection {
+		if err = (&controllers.UsageReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("Usage"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Usage")
+			os.Exit(1)
+		}
+	}
+
+	// Trial jobs push metric values directly to the controller so it must be explicitly enabled
+	if pushAddr != "" {
+		if err = (&controllers.PushServer{
+			Log:  ctrl.Log.WithName("controllers").WithName("Push"),
+			Addr: pushAddr,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create server", "server", "Push")
+			os.Exit(1)
+		}
+	}
+
+	// The webhook server will not start without a certificate so webhooks must be explicitly enabled
+	if enableWebhooks {
+		if err = (&controllers.ExperimentDefaulter{
+			Log: ctrl.Log.WithName("webhooks").WithName("ExperimentDefaulter"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ExperimentDefaulter")
+			os.Exit(1)
+		}
+		if err = (&controllers.ExperimentValidator{
+			Log:    ctrl.Log.WithName("webhooks").WithName("ExperimentValidator"),
+			DryRun: enableQueryDryRun,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ExperimentValidator")
+			os.Exit(1)
+		}
+	}
 
 	// +kubebuilder:scaffold:builder
 
 	// The Application Poller isn't strictly a reconciler, but it partakes in the manager lifecycle
 	if err = (&controllers.Poller{
-		Log: ctrl.Log.WithName("controllers").WithName("Application"),
+		Log:     ctrl.Log.WithName("controllers").WithName("Application"),
+		Options: pollerOptions,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Application")
 		os.Exit(1)
@@ -180,3 +312,23 @@ func handleDebugArgs() {
 		}
 	}
 }
+
+// envDuration returns the duration from the named environment variable, or zero if it is not set.
+func envDuration(key string) time.Duration {
+	d, _ := time.ParseDuration(os.Getenv(key))
+	return d
+}
+
+// envInt returns the integer from the named environment variable, or zero if it is not set.
+func envInt(key string) int {
+	i, _ := strconv.Atoi(os.Getenv(key))
+	return i
+}
+
+// envOrDefault returns the value of the named environment variable, or the default value if it is not set.
+func envOrDefault(key, defaultValue string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return defaultValue
+}